@@ -248,13 +248,15 @@ close_reason: merged`,
 				Description: `branch: polecat/Toast/gt-abc
 target: integration/gt-epic
 source_issue: gt-abc
-worker: Toast`,
+worker: Toast
+swarm_id: gt-epic`,
 			},
 			wantFields: &MRFields{
 				Branch:      "polecat/Toast/gt-abc",
 				Target:      "integration/gt-epic",
 				SourceIssue: "gt-abc",
 				Worker:      "Toast",
+				SwarmID:     "gt-epic",
 			},
 		},
 		{
@@ -369,6 +371,9 @@ source_issue: gt-pqr`,
 			if fields.CloseReason != tt.wantFields.CloseReason {
 				t.Errorf("CloseReason = %q, want %q", fields.CloseReason, tt.wantFields.CloseReason)
 			}
+			if fields.SwarmID != tt.wantFields.SwarmID {
+				t.Errorf("SwarmID = %q, want %q", fields.SwarmID, tt.wantFields.SwarmID)
+			}
 		})
 	}
 }
@@ -1752,3 +1757,32 @@ func TestSetupRedirect(t *testing.T) {
 		}
 	})
 }
+
+func TestSetMergeFields(t *testing.T) {
+	issue := &Issue{Description: "Add retry to the fetcher.\n\nmerge_commit: 0ld\nmerged_into: main\n"}
+	fields := &MergeFields{
+		MergeCommit: "abc123",
+		MergedInto:  "integration/gt-epic",
+		MergedFrom:  "polecat/nux/gt-1",
+		MergedMR:    "gt-mr1",
+	}
+
+	got := SetMergeFields(issue, fields)
+	want := `Add retry to the fetcher.
+
+merge_commit: abc123
+merged_into: integration/gt-epic
+merged_from: polecat/nux/gt-1
+merged_mr: gt-mr1`
+	if got != want {
+		t.Errorf("SetMergeFields() =\n%s\nwant:\n%s", got, want)
+	}
+
+	parsed := ParseMergeFields(&Issue{Description: got})
+	if parsed == nil || *parsed != *fields {
+		t.Errorf("ParseMergeFields() = %+v, want %+v", parsed, fields)
+	}
+	if ParseMergeFields(&Issue{Description: "no provenance here"}) != nil {
+		t.Error("ParseMergeFields() on a plain issue should be nil")
+	}
+}
@@ -187,6 +187,9 @@ type MRFields struct {
 	// Convoy tracking (for priority scoring - convoy starvation prevention)
 	ConvoyID        string // Parent convoy ID if part of a convoy
 	ConvoyCreatedAt string // Convoy creation time (ISO 8601) for starvation prevention
+
+	// Swarm membership (the epic whose children a swarm works on)
+	SwarmID string
 }
 
 // ParseMRFields extracts structured merge-request fields from an issue's description.
@@ -261,6 +264,9 @@ func ParseMRFields(issue *Issue) *MRFields {
 		case "convoy_created_at", "convoy-created-at", "convoycreatedat":
 			fields.ConvoyCreatedAt = value
 			hasFields = true
+		case "swarm_id", "swarm-id", "swarmid", "swarm":
+			fields.SwarmID = value
+			hasFields = true
 		}
 	}
 
@@ -325,6 +331,9 @@ func FormatMRFields(fields *MRFields) string {
 	if fields.ConvoyCreatedAt != "" {
 		lines = append(lines, "convoy_created_at: "+fields.ConvoyCreatedAt)
 	}
+	if fields.SwarmID != "" {
+		lines = append(lines, "swarm_id: "+fields.SwarmID)
+	}
 
 	return strings.Join(lines, "\n")
 }
@@ -371,6 +380,10 @@ func SetMRFields(issue *Issue, fields *MRFields) string {
 		"convoy_created_at":  true,
 		"convoy-created-at":  true,
 		"convoycreatedat":    true,
+		"swarm_id":           true,
+		"swarm-id":           true,
+		"swarmid":            true,
+		"swarm":              true,
 	}
 
 	// Collect non-MR lines from existing description
@@ -421,6 +434,125 @@ func SetMRFields(issue *Issue, fields *MRFields) string {
 	return formatted + "\n\n" + strings.Join(otherLines, "\n")
 }
 
+// MergeFields records where an issue's work landed: written onto the source
+// issue when the refinery merges its MR, so the issue → branch → commit
+// trail can be followed later. Stored as key: value lines in the
+// description, like MRFields.
+type MergeFields struct {
+	MergeCommit string // SHA of the merge commit on the target
+	MergedInto  string // Target branch the work landed on
+	MergedFrom  string // Source branch that was merged
+	MergedMR    string // The merge request that landed it
+	MergedAt    string // ISO 8601 timestamp of the merge
+}
+
+// mergeKeys are the MergeFields keys (lowercase) in every accepted spelling.
+var mergeKeys = map[string]string{
+	"merge_commit": "merge_commit", "merge-commit": "merge_commit", "mergecommit": "merge_commit",
+	"merged_into": "merged_into", "merged-into": "merged_into", "mergedinto": "merged_into",
+	"merged_from": "merged_from", "merged-from": "merged_from", "mergedfrom": "merged_from",
+	"merged_mr": "merged_mr", "merged-mr": "merged_mr", "mergedmr": "merged_mr",
+	"merged_at": "merged_at", "merged-at": "merged_at", "mergedat": "merged_at",
+}
+
+// ParseMergeFields extracts merge provenance from an issue's description.
+// Returns nil if the issue has none.
+func ParseMergeFields(issue *Issue) *MergeFields {
+	if issue == nil || issue.Description == "" {
+		return nil
+	}
+
+	fields := &MergeFields{}
+	hasFields := false
+	for _, line := range strings.Split(issue.Description, "\n") {
+		line = strings.TrimSpace(line)
+		colonIdx := strings.Index(line, ":")
+		if colonIdx == -1 {
+			continue
+		}
+		value := strings.TrimSpace(line[colonIdx+1:])
+		if value == "" {
+			continue
+		}
+		switch mergeKeys[strings.ToLower(strings.TrimSpace(line[:colonIdx]))] {
+		case "merge_commit":
+			fields.MergeCommit = value
+		case "merged_into":
+			fields.MergedInto = value
+		case "merged_from":
+			fields.MergedFrom = value
+		case "merged_mr":
+			fields.MergedMR = value
+		case "merged_at":
+			fields.MergedAt = value
+		default:
+			continue
+		}
+		hasFields = true
+	}
+
+	if !hasFields {
+		return nil
+	}
+	return fields
+}
+
+// FormatMergeFields formats MergeFields as description lines. Only
+// non-empty fields are included.
+func FormatMergeFields(fields *MergeFields) string {
+	if fields == nil {
+		return ""
+	}
+
+	var lines []string
+	if fields.MergeCommit != "" {
+		lines = append(lines, "merge_commit: "+fields.MergeCommit)
+	}
+	if fields.MergedInto != "" {
+		lines = append(lines, "merged_into: "+fields.MergedInto)
+	}
+	if fields.MergedFrom != "" {
+		lines = append(lines, "merged_from: "+fields.MergedFrom)
+	}
+	if fields.MergedMR != "" {
+		lines = append(lines, "merged_mr: "+fields.MergedMR)
+	}
+	if fields.MergedAt != "" {
+		lines = append(lines, "merged_at: "+fields.MergedAt)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// SetMergeFields returns issue's description with its merge provenance
+// replaced by fields. The fields go at the end, after the issue's own
+// content; other lines are preserved.
+func SetMergeFields(issue *Issue, fields *MergeFields) string {
+	var otherLines []string
+	if issue != nil && issue.Description != "" {
+		for _, line := range strings.Split(issue.Description, "\n") {
+			trimmed := strings.TrimSpace(line)
+			if colonIdx := strings.Index(trimmed, ":"); colonIdx != -1 {
+				if _, ok := mergeKeys[strings.ToLower(strings.TrimSpace(trimmed[:colonIdx]))]; ok {
+					continue
+				}
+			}
+			otherLines = append(otherLines, line)
+		}
+	}
+	for len(otherLines) > 0 && strings.TrimSpace(otherLines[len(otherLines)-1]) == "" {
+		otherLines = otherLines[:len(otherLines)-1]
+	}
+
+	formatted := FormatMergeFields(fields)
+	if formatted == "" {
+		return strings.Join(otherLines, "\n")
+	}
+	if len(otherLines) == 0 {
+		return formatted
+	}
+	return strings.Join(otherLines, "\n") + "\n\n" + formatted
+}
+
 // SynthesisFields holds structured fields for synthesis beads.
 // These fields track the synthesis step in a convoy workflow.
 type SynthesisFields struct {
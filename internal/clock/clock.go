@@ -0,0 +1,114 @@
+// Package clock abstracts telling the time and waiting, so code whose
+// behavior depends on time — merge windows, MR ages, daily stats and
+// digests, retry backoff — can be tested and simulated deterministically.
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and waits.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// After is time.After on this clock: the channel receives the time
+	// once d has passed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// System is the machine's clock.
+var System Clock = system{}
+
+type system struct{}
+
+func (system) Now() time.Time                         { return time.Now() }
+func (system) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Or returns c, or System if c is nil, so zero-value structs tell the
+// time by the machine's clock.
+func Or(c Clock) Clock {
+	if c == nil {
+		return System
+	}
+	return c
+}
+
+// Fake is a Clock that only moves when told to, for tests and
+// simulations. Advance is time passing; Set is the machine's clock being
+// changed, which, as with time.After, does not hurry or delay waits.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	elapsed time.Duration // Advanced so far; waits go by it
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at time.Duration // Elapsed time it fires at
+	ch chan time.Time
+}
+
+// NewFake returns a Fake reading now.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now returns the fake's current time.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// After returns a channel that receives the fake's time once it has been
+// advanced by d. A d of zero or less fires at once.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- f.now
+		return ch
+	}
+	f.waiters = append(f.waiters, fakeWaiter{at: f.elapsed + d, ch: ch})
+	return ch
+}
+
+// Advance moves the fake forward by d, finishing the waits that were due
+// by then in the order they fall due.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+	f.elapsed += d
+
+	sort.SliceStable(f.waiters, func(i, j int) bool { return f.waiters[i].at < f.waiters[j].at })
+	kept := f.waiters[:0]
+	for _, w := range f.waiters {
+		if w.at > f.elapsed {
+			kept = append(kept, w)
+			continue
+		}
+		w.ch <- f.now
+	}
+	f.waiters = kept
+}
+
+// Set changes the fake's time to t, which may be earlier than now, as
+// when a machine's clock is corrected. Pending waits are unaffected.
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = t
+}
+
+// Waiters returns how many waits are pending, so a test can tell the code
+// it drives has started waiting before advancing the clock.
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
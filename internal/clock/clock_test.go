@@ -0,0 +1,59 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2026, 3, 8, 1, 30, 0, 0, time.UTC)
+	f := NewFake(start)
+	soon, later := f.After(time.Minute), f.After(time.Hour)
+	if f.Waiters() != 2 {
+		t.Fatalf("Waiters = %d, want 2", f.Waiters())
+	}
+
+	// Setting the clock back, as after a correction, hurries no wait
+	f.Set(start.Add(-2 * time.Hour))
+	f.Advance(59 * time.Second)
+	select {
+	case <-soon:
+		t.Fatal("wait fired before its time had passed")
+	default:
+	}
+
+	f.Advance(time.Second)
+	select {
+	case at := <-soon:
+		if want := start.Add(-2*time.Hour + time.Minute); !at.Equal(want) {
+			t.Errorf("fired at %v, want %v", at, want)
+		}
+	default:
+		t.Fatal("wait did not fire once its time had passed")
+	}
+	if f.Waiters() != 1 {
+		t.Errorf("Waiters = %d, want 1", f.Waiters())
+	}
+
+	f.Advance(time.Hour)
+	select {
+	case <-later:
+	default:
+		t.Fatal("second wait did not fire")
+	}
+	select {
+	case <-f.After(0):
+	default:
+		t.Error("a zero wait did not fire at once")
+	}
+}
+
+func TestOr(t *testing.T) {
+	if Or(nil) != System {
+		t.Error("Or(nil) is not the system clock")
+	}
+	f := NewFake(time.Time{})
+	if Or(f) != f {
+		t.Error("Or(f) is not f")
+	}
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+// confirmInput is the reader used for confirmation prompts (overridable in tests).
+var confirmInput io.Reader = os.Stdin
+
+// confirmDestructive prints exactly what a destructive operation will affect
+// and asks the user to confirm. When yes is true (--yes), it prints the
+// summary and proceeds without prompting. Returns false if the user declines
+// or stdin is closed (non-interactive callers must pass --yes).
+func confirmDestructive(action string, affected []string, yes bool) bool {
+	fmt.Printf("%s %s\n", style.Warning.Render("⚠"), action)
+	for _, item := range affected {
+		fmt.Printf("  %s %s\n", style.Bold.Render("→"), item)
+	}
+
+	if yes {
+		return true
+	}
+
+	fmt.Printf("Proceed? [y/N] ")
+	reader := bufio.NewReader(confirmInput)
+	response, err := reader.ReadString('\n')
+	response = strings.TrimSpace(strings.ToLower(response))
+	if err != nil && response == "" {
+		fmt.Println()
+		fmt.Printf("%s No confirmation received (use --yes for non-interactive use)\n", style.Dim.Render("○"))
+		return false
+	}
+	if response != "y" && response != "yes" {
+		fmt.Println("Canceled.")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfirmDestructive(t *testing.T) {
+	orig := confirmInput
+	defer func() { confirmInput = orig }()
+
+	tests := []struct {
+		name  string
+		input string
+		yes   bool
+		want  bool
+	}{
+		{"yes flag skips prompt", "", true, true},
+		{"user confirms", "y\n", false, true},
+		{"user confirms long form", "YES\n", false, true},
+		{"user declines", "n\n", false, false},
+		{"empty answer declines", "\n", false, false},
+		{"closed stdin declines", "", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			confirmInput = strings.NewReader(tt.input)
+			if got := confirmDestructive("test action", []string{"item"}, tt.yes); got != tt.want {
+				t.Errorf("confirmDestructive() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -210,6 +210,12 @@ func attachToTmuxSession(sessionID string) error {
 // Returns true if on default branch (or switched to it), false if user declined.
 // The rigPath parameter is used to look up the configured default branch.
 func ensureDefaultBranch(dir, roleName, rigPath string) bool { //nolint:unparam // bool return kept for future callers to check
+	// A jj working copy has no current branch: git's HEAD stays detached
+	// under jj's working-copy commit, and checking out a branch there would
+	// fight jj
+	if git.IsJJ(dir) {
+		return true
+	}
 	g := git.NewGit(dir)
 
 	branch, err := g.CurrentBranch()
@@ -75,7 +75,8 @@ Patrol checks:
   - patrol-roles-have-prompts Verify role prompts exist
 
 Use --fix to attempt automatic fixes for issues that support it.
-Use --rig to check a specific rig instead of the entire workspace.`,
+Use --rig to check a specific rig instead of the entire workspace.
+Use 'gt rig doctor <rig>' to add operational checks (remote, branches, tools).`,
 	RunE: runDoctor,
 }
 
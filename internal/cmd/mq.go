@@ -26,9 +26,13 @@ var (
 	// Retry flags
 	mqRetryNow bool
 
+	// Global mq flags
+	mqTimestamps bool
+
 	// Reject flags
 	mqRejectReason string
 	mqRejectNotify bool
+	mqRejectYes    bool
 
 	// List command flags
 	mqListReady  bool
@@ -44,6 +48,7 @@ var (
 	mqIntegrationLandForce     bool
 	mqIntegrationLandSkipTests bool
 	mqIntegrationLandDryRun    bool
+	mqIntegrationLandYes       bool
 
 	// Integration status flags
 	mqIntegrationStatusJSON bool
@@ -135,7 +140,8 @@ Examples:
   gt mq list greenplace
   gt mq list greenplace --ready
   gt mq list greenplace --status=open
-  gt mq list greenplace --worker=Nux`,
+  gt mq list greenplace --worker=Nux
+  gt mq list greenplace --timestamps`,
 	Args: cobra.ExactArgs(1),
 	RunE: runMQList,
 }
@@ -148,9 +154,13 @@ var mqRejectCmd = &cobra.Command{
 This closes the MR with a 'rejected' status without merging.
 The source issue is NOT closed (work is not done).
 
+The MR that will be rejected is printed and confirmation is requested
+before acting. Use --yes to skip the prompt in scripts.
+
 Examples:
   gt mq reject greenplace polecat/Nux/gp-xyz --reason "Does not meet requirements"
-  gt mq reject greenplace mr-Nux-12345 --reason "Superseded by other work" --notify`,
+  gt mq reject greenplace mr-Nux-12345 --reason "Superseded by other work" --notify
+  gt mq reject greenplace mr-Nux-12345 --reason "Duplicate" --yes`,
 	Args: cobra.ExactArgs(2),
 	RunE: runMQReject,
 }
@@ -227,6 +237,7 @@ Options:
   --force       Land even if some MRs still open
   --skip-tests  Skip test run
   --dry-run     Preview only, make no changes
+  --yes         Skip the confirmation prompt
 
 Examples:
   gt mq integration land gt-auth-epic
@@ -261,6 +272,9 @@ func init() {
 	mqSubmitCmd.Flags().IntVarP(&mqSubmitPriority, "priority", "p", -1, "Override priority (0-4, default: inherit from issue)")
 	mqSubmitCmd.Flags().BoolVar(&mqSubmitNoCleanup, "no-cleanup", false, "Don't auto-cleanup after submit (for polecats)")
 
+	// Global mq flags
+	mqCmd.PersistentFlags().BoolVar(&mqTimestamps, "timestamps", false, "Show exact timestamps instead of relative ages")
+
 	// Retry flags
 	mqRetryCmd.Flags().BoolVar(&mqRetryNow, "now", false, "Immediately process instead of waiting for refinery loop")
 
@@ -274,6 +288,7 @@ func init() {
 	// Reject flags
 	mqRejectCmd.Flags().StringVarP(&mqRejectReason, "reason", "r", "", "Reason for rejection (required)")
 	mqRejectCmd.Flags().BoolVar(&mqRejectNotify, "notify", false, "Send mail notification to worker")
+	mqRejectCmd.Flags().BoolVarP(&mqRejectYes, "yes", "y", false, "Skip confirmation prompt")
 	_ = mqRejectCmd.MarkFlagRequired("reason") // cobra flags: error only at runtime if missing
 
 	// Status flags
@@ -293,6 +308,7 @@ func init() {
 	mqIntegrationLandCmd.Flags().BoolVar(&mqIntegrationLandForce, "force", false, "Land even if some MRs still open")
 	mqIntegrationLandCmd.Flags().BoolVar(&mqIntegrationLandSkipTests, "skip-tests", false, "Skip test run")
 	mqIntegrationLandCmd.Flags().BoolVar(&mqIntegrationLandDryRun, "dry-run", false, "Preview only, make no changes")
+	mqIntegrationLandCmd.Flags().BoolVarP(&mqIntegrationLandYes, "yes", "y", false, "Skip confirmation prompt")
 	mqIntegrationCmd.AddCommand(mqIntegrationLandCmd)
 
 	// Integration status flags
@@ -396,7 +412,17 @@ func runMQReject(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	result, err := mgr.RejectMR(mrIDOrBranch, mqRejectReason, mqRejectNotify)
+	// Show exactly which MR will be closed before acting
+	target, err := mgr.FindMR(mrIDOrBranch)
+	if err != nil {
+		return fmt.Errorf("rejecting MR: %w", err)
+	}
+	affected := []string{fmt.Sprintf("%s %s (worker: %s)", target.ID, target.Branch, target.Worker)}
+	if !confirmDestructive("This will close the merge request without merging:", affected, mqRejectYes) {
+		return nil
+	}
+
+	result, err := mgr.RejectMR(target.ID, mqRejectReason, mqRejectNotify)
 	if err != nil {
 		return fmt.Errorf("rejecting MR: %w", err)
 	}
@@ -268,6 +268,15 @@ func runMqIntegrationLand(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	affected := []string{
+		fmt.Sprintf("merge %s into main and push to origin", branchName),
+		fmt.Sprintf("delete %s (local and remote)", branchName),
+		fmt.Sprintf("close epic %s", epicID),
+	}
+	if !confirmDestructive("Landing will make the following changes:", affected, mqIntegrationLandYes) {
+		return nil
+	}
+
 	// Ensure working directory is clean
 	status, err := g.Status()
 	if err != nil {
@@ -12,6 +12,7 @@ import (
 	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/mrqueue"
 	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/util"
 )
 
 func runMQList(cmd *cobra.Command, args []string) error {
@@ -229,28 +230,16 @@ func runMQList(cmd *cobra.Command, args []string) error {
 }
 
 // formatMRAge formats the age of an MR from its created_at timestamp.
+// With --timestamps, the exact creation time is shown instead.
 func formatMRAge(createdAt string) string {
-	t, err := time.Parse(time.RFC3339, createdAt)
-	if err != nil {
-		// Try other formats
-		t, err = time.Parse("2006-01-02T15:04:05Z", createdAt)
-		if err != nil {
-			return "?"
-		}
-	}
-
-	d := time.Since(t)
-
-	if d < time.Minute {
-		return fmt.Sprintf("%ds", int(d.Seconds()))
-	}
-	if d < time.Hour {
-		return fmt.Sprintf("%dm", int(d.Minutes()))
+	t, ok := util.ParseTimestamp(createdAt)
+	if !ok {
+		return "?"
 	}
-	if d < 24*time.Hour {
-		return fmt.Sprintf("%dh", int(d.Hours()))
+	if mqTimestamps {
+		return util.FormatTime(t, true)
 	}
-	return fmt.Sprintf("%dd", int(d.Hours()/24))
+	return util.HumanizeDuration(time.Since(t))
 }
 
 // outputJSON outputs data as JSON.
@@ -10,6 +10,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/util"
 )
 
 // MRStatusOutput is the JSON output structure for gt mq status.
@@ -255,44 +256,15 @@ func getStatusIcon(status string) string {
 
 // formatTimeAgo formats a timestamp as a relative time string.
 func formatTimeAgo(timestamp string) string {
-	// Try parsing common formats
-	formats := []string{
-		time.RFC3339,
-		"2006-01-02T15:04:05Z",
-		"2006-01-02T15:04:05",
-		"2006-01-02 15:04:05",
-		"2006-01-02",
-	}
-
-	var t time.Time
-	var err error
-	for _, format := range formats {
-		t, err = time.Parse(format, timestamp)
-		if err == nil {
-			break
-		}
-	}
-	if err != nil {
+	t, ok := util.ParseTimestamp(timestamp)
+	if !ok {
 		return "" // Can't parse, return empty
 	}
 
-	d := time.Since(t)
-	if d < 0 {
+	if time.Since(t) < 0 {
 		return style.Dim.Render("(in the future)")
 	}
-
-	var ago string
-	if d < time.Minute {
-		ago = fmt.Sprintf("%ds ago", int(d.Seconds()))
-	} else if d < time.Hour {
-		ago = fmt.Sprintf("%dm ago", int(d.Minutes()))
-	} else if d < 24*time.Hour {
-		ago = fmt.Sprintf("%dh ago", int(d.Hours()))
-	} else {
-		ago = fmt.Sprintf("%dd ago", int(d.Hours()/24))
-	}
-
-	return style.Dim.Render("(" + ago + ")")
+	return style.Dim.Render("(" + util.RelativeTime(t, time.Now()) + ")")
 }
 
 // truncateString truncates a string to maxLen, adding "..." if truncated.
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -12,6 +13,8 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/refinery"
 	"github.com/steveyegge/gastown/internal/rig"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/workspace"
@@ -138,13 +141,27 @@ func runMqSubmit(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Build MR bead title and description
+	// Build MR bead title, and let [refinery.admission] turn it away or
+	// relabel it
 	title := fmt.Sprintf("Merge: %s", issueID)
+	mgr, _, _, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+	mgr.SetOutput(os.Stderr)
+	admitted := &mrqueue.MR{Branch: branch, Target: target, SourceIssue: issueID, Worker: worker, Rig: rigName, Title: title, Priority: priority}
+	if err := mgr.Admit(context.Background(), admitted); err != nil {
+		return err
+	}
+	title, priority = admitted.Title, admitted.Priority
 	description := fmt.Sprintf("branch: %s\ntarget: %s\nsource_issue: %s\nrig: %s",
 		branch, target, issueID, rigName)
 	if worker != "" {
 		description += fmt.Sprintf("\nworker: %s", worker)
 	}
+	if swarmID := refinery.DetectSwarm(g, bd, branch, target, issueID); swarmID != "" {
+		description += fmt.Sprintf("\nswarm_id: %s", swarmID)
+	}
 
 	// Create MR bead (ephemeral wisp - will be cleaned up after merge)
 	mrIssue, err := bd.Create(beads.CreateOptions{
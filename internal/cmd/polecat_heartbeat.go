@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var polecatWorkersJSON bool
+
+var polecatHeartbeatCmd = &cobra.Command{
+	Use:   "heartbeat [rig/polecat]",
+	Short: "Report that a polecat is alive",
+	Long: `Refresh a polecat's heartbeat with its rig.
+
+Polecats register when their session starts and heartbeat from their
+session hooks. The refinery uses the heartbeat to flag queued branches
+whose worker has gone quiet and to merge live workers' branches first.
+
+Without an address, the polecat is taken from GT_RIG and GT_POLECAT; outside
+a polecat session the command does nothing, so it is safe in shared hooks.
+
+A worker is stale after 10 minutes without a heartbeat and dead after an hour.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runPolecatHeartbeat,
+}
+
+var polecatWorkersCmd = &cobra.Command{
+	Use:   "workers <rig>",
+	Short: "List registered workers, their liveness, and trust tier",
+	Long: `List the workers registered with a rig.
+
+Each worker shows its heartbeat liveness and, when settings/rig.toml assigns
+trust tiers ([refinery.workers] veteran/trusted/new), its tier.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPolecatWorkers,
+}
+
+func init() {
+	polecatWorkersCmd.Flags().BoolVar(&polecatWorkersJSON, "json", false, "Output as JSON")
+	polecatCmd.AddCommand(polecatHeartbeatCmd)
+	polecatCmd.AddCommand(polecatWorkersCmd)
+}
+
+func runPolecatHeartbeat(cmd *cobra.Command, args []string) error {
+	rigName, polecatName := os.Getenv("GT_RIG"), os.Getenv("GT_POLECAT")
+	if len(args) > 0 {
+		var err error
+		if rigName, polecatName, err = parseAddress(args[0]); err != nil {
+			return err
+		}
+	}
+	if rigName == "" || polecatName == "" {
+		return nil // Not a polecat
+	}
+
+	_, r, err := getRig(rigName)
+	if err != nil {
+		return err
+	}
+	return rig.HeartbeatWorker(r.Path, polecatName)
+}
+
+func runPolecatWorkers(cmd *cobra.Command, args []string) error {
+	_, r, err := getRig(args[0])
+	if err != nil {
+		return err
+	}
+	workers, err := rig.LoadWorkers(r.Path)
+	if err != nil {
+		return fmt.Errorf("loading workers: %w", err)
+	}
+	settings, err := config.LoadRefinerySettings(r.Path)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if polecatWorkersJSON {
+		type workerJSON struct {
+			*rig.WorkerInfo
+			Liveness rig.WorkerLiveness `json:"liveness"`
+			Tier     string             `json:"tier,omitempty"`
+		}
+		out := make([]workerJSON, 0, len(workers))
+		for _, w := range workers {
+			out = append(out, workerJSON{w, w.LivenessAt(now), settings.WorkerTier(w.Name)})
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	if len(workers) == 0 {
+		fmt.Printf("No registered workers in %s.\n", r.Name)
+		return nil
+	}
+	fmt.Printf("%s\n\n", style.Bold.Render("Workers in "+r.Name))
+	for _, w := range workers {
+		var icon string
+		switch w.LivenessAt(now) {
+		case rig.WorkerAlive:
+			icon = style.Success.Render("●")
+		case rig.WorkerStale:
+			icon = style.Warning.Render("◐")
+		default:
+			icon = style.Dim.Render("○")
+		}
+		fmt.Printf("  %s %-16s %-6s %-8s %s\n", icon, w.Name, w.LivenessAt(now), settings.WorkerTier(w.Name),
+			style.Dim.Render("last seen "+formatDuration(now.Sub(w.LastSeen))+" ago"))
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/polecat"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/tmux"
+)
+
+var (
+	polecatRetireDelete bool
+	polecatRetireDryRun bool
+	polecatRetireForce  bool
+	polecatRetireJSON   bool
+	polecatRetireYes    bool
+)
+
+var polecatRetireCmd = &cobra.Command{
+	Use:   "retire <rig>/<polecat>",
+	Short: "Sweep a retired polecat's remaining branches",
+	Long: `Sweep the branches a retired polecat left on origin.
+
+Each polecat/<name>... branch on origin is handled by what it would do to
+the rig's default branch:
+  - merged:    already on the target; the branch is deleted and its
+               merge request and source issue are closed
+  - enqueued:  merges cleanly; it is submitted to the merge queue (if not
+               already queued) and the refinery merges it as usual
+  - archived:  conflicts; it is kept as archive/<branch> on origin and
+               deleted, its merge request is closed, and its source issue
+               is reopened unassigned for another worker
+  - deleted:   like archived, but with --delete no archive is kept
+
+Fails if the polecat's session is running (stop first or use --force).
+
+Lists what the sweep will do and asks for confirmation first; --yes skips
+the prompt, and --json (which has no prompt to answer) needs it.
+
+Examples:
+  gt polecat retire greenplace/Toast --dry-run
+  gt polecat retire greenplace/Toast
+  gt polecat retire greenplace/Toast --delete --yes --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPolecatRetire,
+}
+
+func init() {
+	polecatRetireCmd.Flags().BoolVar(&polecatRetireDelete, "delete", false, "Delete conflicting branches instead of archiving them")
+	polecatRetireCmd.Flags().BoolVar(&polecatRetireDryRun, "dry-run", false, "Show what would happen without changing anything")
+	polecatRetireCmd.Flags().BoolVarP(&polecatRetireForce, "force", "f", false, "Sweep even if the polecat's session is running")
+	polecatRetireCmd.Flags().BoolVar(&polecatRetireJSON, "json", false, "Output as JSON")
+	polecatRetireCmd.Flags().BoolVarP(&polecatRetireYes, "yes", "y", false, "Skip confirmation prompt")
+	polecatCmd.AddCommand(polecatRetireCmd)
+}
+
+func runPolecatRetire(cmd *cobra.Command, args []string) error {
+	rigName, polecatName, err := parseAddress(args[0])
+	if err != nil {
+		return err
+	}
+	if polecatRetireJSON && !polecatRetireDryRun && !polecatRetireYes {
+		return fmt.Errorf("--json needs --yes, as there is no prompt to answer")
+	}
+	_, r, err := getRig(rigName)
+	if err != nil {
+		return err
+	}
+
+	if !polecatRetireForce {
+		running, _ := polecat.NewSessionManager(tmux.NewTmux(), r).IsRunning(polecatName)
+		if running {
+			return fmt.Errorf("%s/%s: session is running (stop first or use --force)", rigName, polecatName)
+		}
+	}
+
+	mgr := refinery.NewManager(r)
+	if !polecatRetireDryRun {
+		mgr.SetOutput(io.Discard)
+		plan, err := mgr.RetireWorker(polecatName, refinery.RetireOptions{Delete: polecatRetireDelete, DryRun: true})
+		if err != nil {
+			return err
+		}
+		if len(plan) > 0 && !polecatRetireJSON {
+			var affected []string
+			for _, rb := range plan {
+				affected = append(affected, retirePlanLine(rb, r.DefaultBranch()))
+			}
+			if !confirmDestructive(fmt.Sprintf("Retiring %s/%s will:", rigName, polecatName), affected, polecatRetireYes) {
+				return nil
+			}
+		}
+	}
+	mgr.SetOutput(os.Stdout)
+	if polecatRetireJSON {
+		mgr.SetOutput(os.Stderr)
+	}
+	swept, err := mgr.RetireWorker(polecatName, refinery.RetireOptions{
+		Delete: polecatRetireDelete,
+		DryRun: polecatRetireDryRun,
+	})
+	if polecatRetireJSON {
+		if swept == nil {
+			swept = []refinery.RetiredBranch{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if encErr := enc.Encode(swept); encErr != nil {
+			return encErr
+		}
+		return err
+	}
+
+	if len(swept) == 0 && err == nil {
+		fmt.Printf("No branches left for %s/%s\n", rigName, polecatName)
+		return nil
+	}
+	if polecatRetireDryRun {
+		fmt.Printf("%s Would sweep %d branch(es) of %s/%s:\n\n", style.Bold.Render("Dry run:"), len(swept), rigName, polecatName)
+	} else {
+		fmt.Printf("%s Swept %d branch(es) of %s/%s:\n\n", style.Bold.Render("✓"), len(swept), rigName, polecatName)
+	}
+	for _, rb := range swept {
+		fmt.Printf("  %-9s %s", rb.Action, rb.Branch)
+		if rb.MRID != "" {
+			fmt.Printf(" %s", style.Dim.Render("("+rb.MRID+")"))
+		}
+		fmt.Println()
+		if len(rb.Closed) > 0 {
+			fmt.Printf("            %s\n", style.Dim.Render("closed "+strings.Join(rb.Closed, ", ")))
+		}
+		if len(rb.Released) > 0 {
+			fmt.Printf("            %s\n", style.Dim.Render("released "+strings.Join(rb.Released, ", ")))
+		}
+	}
+	return err
+}
+
+// retirePlanLine describes what a sweep will do with one branch, for the
+// confirmation prompt.
+func retirePlanLine(rb refinery.RetiredBranch, target string) string {
+	var line string
+	switch rb.Action {
+	case refinery.RetireMerged:
+		line = fmt.Sprintf("delete %s from origin (already on %s)", rb.Branch, target)
+	case refinery.RetireEnqueued:
+		line = fmt.Sprintf("queue %s → %s for the refinery", rb.Branch, target)
+	case refinery.RetireArchived:
+		line = fmt.Sprintf("keep %s as %s%s, then delete it from origin (conflicts with %s)", rb.Branch, refinery.ArchivePrefix, rb.Branch, target)
+	default:
+		line = fmt.Sprintf("delete %s from origin, keeping no archive (conflicts with %s)", rb.Branch, target)
+	}
+	if rb.MRID != "" && rb.Action != refinery.RetireEnqueued {
+		line += fmt.Sprintf("; drop %s from the queue", rb.MRID)
+	}
+	if len(rb.Closed) > 0 {
+		line += "; close " + strings.Join(rb.Closed, ", ")
+	}
+	if len(rb.Released) > 0 {
+		line += "; reopen " + strings.Join(rb.Released, ", ") + " unassigned"
+	}
+	return line
+}
@@ -4,13 +4,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/mrqueue"
 	"github.com/steveyegge/gastown/internal/refinery"
 	"github.com/steveyegge/gastown/internal/rig"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/tmux"
+	"github.com/steveyegge/gastown/internal/util"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
 
@@ -19,6 +22,8 @@ var (
 	refineryForeground bool
 	refineryStatusJSON bool
 	refineryQueueJSON  bool
+	refineryDebug      bool
+	refineryTimestamps bool
 )
 
 var refineryCmd = &cobra.Command{
@@ -30,7 +35,35 @@ var refineryCmd = &cobra.Command{
 	Long: `Manage the Refinery merge queue processor for a rig.
 
 The Refinery processes merge requests from polecats, merging their work
-into integration branches and ultimately to main.`,
+into integration branches and ultimately to main.
+
+Use -v/--debug (or GT_DEBUG=1) to trace every git, test, and hook command
+the refinery runs, with its arguments, duration, and exit status.
+
+Times are shown as relative ages ("5m ago"); use --timestamps for exact
+local timestamps.
+
+status, queue, stats, history, pause, resume, enqueue, hold, requeue, and approve also work against a
+refinery on another machine: pass --remote with the address of
+'gt refinery serve' and an operate token (--token or $GT_REFINERY_TOKEN);
+approve needs an admin token, and status, queue, stats, and history only a
+read token.
+
+Executables named gt-refinery-<name> on PATH are subcommands too, as
+git-<name> is for git. They get the rig in $GT_RIG and $GT_RIG_PATH, and
+--remote and --token in $GT_REFINERY_REMOTE and $GT_REFINERY_TOKEN.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if refineryDebug {
+			util.SetTraceWriter(os.Stderr)
+		}
+		if err := checkRefineryRemote(cmd); err != nil {
+			return err
+		}
+		if refineryRemote != "" {
+			return nil // The remote rig's beads are not ours to check
+		}
+		return checkBeadsDependency(cmd, args)
+	},
 }
 
 var refineryStartCmd = &cobra.Command{
@@ -47,7 +80,8 @@ If rig is not specified, infers it from the current directory.
 Examples:
   gt refinery start greenplace
   gt refinery start greenplace --foreground
-  gt refinery start              # infer rig from cwd`,
+  gt refinery start              # infer rig from cwd
+  gt refinery start --all        # every registered rig`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runRefineryStart,
 }
@@ -58,7 +92,8 @@ var refineryStopCmd = &cobra.Command{
 	Long: `Stop a running Refinery.
 
 Gracefully stops the refinery, completing any in-progress merge first.
-If rig is not specified, infers it from the current directory.`,
+If rig is not specified, infers it from the current directory.
+Use --all to stop the refinery of every registered rig.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runRefineryStop,
 }
@@ -69,7 +104,9 @@ var refineryStatusCmd = &cobra.Command{
 	Long: `Show the status of a rig's Refinery.
 
 Displays running state, current work, queue length, and statistics.
-If rig is not specified, infers it from the current directory.`,
+If rig is not specified, infers it from the current directory.
+
+Use --all for one table covering every registered rig.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runRefineryStatus,
 }
@@ -80,7 +117,18 @@ var refineryQueueCmd = &cobra.Command{
 	Long: `Show the merge queue for a rig.
 
 Lists all pending merge requests waiting to be processed.
-If rig is not specified, infers it from the current directory.`,
+If rig is not specified, infers it from the current directory.
+
+--mine shows only your own submissions (from GT_POLECAT or GT_CREW), and
+--worker <name> only that worker's. --epic shows only MRs whose source
+issue is under the epic, and --label only those whose issue carries the
+label.
+
+Examples:
+  gt refinery queue
+  gt refinery queue --mine
+  gt refinery queue greenplace --worker nux
+  gt refinery queue --epic gt-payments --label needs-design`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runRefineryQueue,
 }
@@ -177,7 +225,9 @@ Shows MRs that are:
 - Not currently claimed by any worker (or claim is stale)
 - Not blocked by an open task (e.g., conflict resolution in progress)
 
-This is the preferred command for finding work to process.
+This is the preferred command for finding work to process. With
+[refinery.risk] in settings/rig.toml, each MR's risk score is shown, and
+refinery.risk.order decides the order.
 
 Examples:
   gt refinery ready
@@ -190,12 +240,22 @@ var refineryReadyJSON bool
 
 var refineryBlockedCmd = &cobra.Command{
 	Use:   "blocked [rig]",
-	Short: "List MRs blocked by open tasks",
+	Short: "List MRs blocked by open tasks, awaiting approval, or backlogged",
 	Long: `List merge requests blocked by open tasks.
 
 Shows MRs waiting for conflict resolution or other blocking tasks to complete.
 When the blocking task closes, the MR will appear in 'ready'.
 
+MRs from workers that settings/rig.toml's [refinery.workers] does not trust
+are listed too, until 'gt refinery approve' releases them. So are MRs
+backlogged because their worker already has max_ready MRs ready; they move
+up as the worker's earlier MRs merge. And so are MRs whose source issue
+depends (in beads) on an issue that still has an MR queued; they wait
+until that MR merges. With [refinery.issue_gate], MRs whose source issue
+has not reached the gate's status or label are listed with what the issue
+is missing. With [refinery.budget], MRs held back because today's merges
+reached the budget are listed until the next day.
+
 Examples:
   gt refinery blocked
   gt refinery blocked --json`,
@@ -206,6 +266,10 @@ Examples:
 var refineryBlockedJSON bool
 
 func init() {
+	// Global refinery flags
+	refineryCmd.PersistentFlags().BoolVarP(&refineryDebug, "debug", "v", false, "Trace git/test/hook subprocesses to stderr")
+	refineryCmd.PersistentFlags().BoolVar(&refineryTimestamps, "timestamps", false, "Show exact timestamps instead of relative ages")
+
 	// Start flags
 	refineryStartCmd.Flags().BoolVar(&refineryForeground, "foreground", false, "Run in foreground (default: background)")
 
@@ -214,6 +278,10 @@ func init() {
 
 	// Queue flags
 	refineryQueueCmd.Flags().BoolVar(&refineryQueueJSON, "json", false, "Output as JSON")
+	refineryQueueCmd.Flags().BoolVar(&refineryMine, "mine", false, "Show only your own MRs (GT_POLECAT or GT_CREW)")
+	refineryQueueCmd.Flags().StringVar(&refineryWorker, "worker", "", "Show only this worker's MRs")
+	refineryQueueCmd.Flags().StringVar(&refineryEpic, "epic", "", "Show only MRs whose source issue is under this epic")
+	refineryQueueCmd.Flags().StringSliceVar(&refineryLabels, "label", nil, "Show only MRs whose source issue has this label (repeatable)")
 
 	// Unclaimed flags
 	refineryUnclaimedCmd.Flags().BoolVar(&refineryUnclaimedJSON, "json", false, "Output as JSON")
@@ -265,6 +333,13 @@ func getRefineryManager(rigName string) (*refinery.Manager, *rig.Rig, string, er
 }
 
 func runRefineryStart(cmd *cobra.Command, args []string) error {
+	if err := checkRefineryAll(args); err != nil {
+		return err
+	}
+	if refineryAll {
+		return runRefineryFleet("start", startRefineryForFleet)
+	}
+
 	rigName := ""
 	if len(args) > 0 {
 		rigName = args[0]
@@ -296,6 +371,13 @@ func runRefineryStart(cmd *cobra.Command, args []string) error {
 }
 
 func runRefineryStop(cmd *cobra.Command, args []string) error {
+	if err := checkRefineryAll(args); err != nil {
+		return err
+	}
+	if refineryAll {
+		return runRefineryFleet("stop", stopRefineryForFleet)
+	}
+
 	rigName := ""
 	if len(args) > 0 {
 		rigName = args[0]
@@ -319,6 +401,21 @@ func runRefineryStop(cmd *cobra.Command, args []string) error {
 }
 
 func runRefineryStatus(cmd *cobra.Command, args []string) error {
+	if err := checkRefineryAll(args); err != nil {
+		return err
+	}
+	if refineryAll {
+		return runRefineryFleet("status", nil)
+	}
+
+	client, err := refineryClient()
+	if err != nil {
+		return err
+	}
+	if client != nil {
+		return runRemoteRefineryStatus(client)
+	}
+
 	rigName := ""
 	if len(args) > 0 {
 		rigName = args[0]
@@ -341,7 +438,21 @@ func runRefineryStatus(cmd *cobra.Command, args []string) error {
 		return enc.Encode(ref)
 	}
 
-	// Human-readable output
+	// Get queue length
+	queue, _ := mgr.Queue()
+	pendingCount := 0
+	for _, item := range queue {
+		if item.Position > 0 { // Not currently processing
+			pendingCount++
+		}
+	}
+
+	printRefineryStatus(rigName, ref, pendingCount)
+	return nil
+}
+
+// printRefineryStatus renders the human-readable status for a rig.
+func printRefineryStatus(rigName string, ref *refinery.Refinery, pendingCount int) {
 	fmt.Printf("%s Refinery: %s\n\n", style.Bold.Render("⚙"), rigName)
 
 	stateStr := string(ref.State)
@@ -352,11 +463,17 @@ func runRefineryStatus(cmd *cobra.Command, args []string) error {
 		stateStr = style.Dim.Render("○ stopped")
 	case refinery.StatePaused:
 		stateStr = style.Dim.Render("⏸ paused")
+		if ref.PausedUntil != nil {
+			stateStr = style.Dim.Render("⏸ paused until " + ref.PausedUntil.Local().Format(util.TimestampLayout))
+		}
 	}
 	fmt.Printf("  State: %s\n", stateStr)
+	if ref.RigPaused != nil {
+		fmt.Printf("  Rig:   %s\n", style.Warning.Render(ref.RigPaused.String()))
+	}
 
 	if ref.StartedAt != nil {
-		fmt.Printf("  Started: %s\n", ref.StartedAt.Format("2006-01-02 15:04:05"))
+		fmt.Printf("  Started: %s\n", util.FormatTime(*ref.StartedAt, refineryTimestamps))
 	}
 
 	if ref.CurrentMR != nil {
@@ -368,30 +485,35 @@ func runRefineryStatus(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Get queue length
-	queue, _ := mgr.Queue()
-	pendingCount := 0
-	for _, item := range queue {
-		if item.Position > 0 { // Not currently processing
-			pendingCount++
-		}
-	}
 	fmt.Printf("\n  Queue: %d pending\n", pendingCount)
 
 	if ref.LastMergeAt != nil {
-		fmt.Printf("  Last merge: %s\n", ref.LastMergeAt.Format("2006-01-02 15:04:05"))
+		fmt.Printf("  Last merge: %s\n", util.FormatTime(*ref.LastMergeAt, refineryTimestamps))
 	}
-
-	return nil
 }
 
 func runRefineryQueue(cmd *cobra.Command, args []string) error {
+	worker, err := refineryWorkerScope(false)
+	if err != nil {
+		return err
+	}
 	rigName := ""
 	if len(args) > 0 {
 		rigName = args[0]
 	}
+	filter, err := refineryIssueFilter(rigName)
+	if err != nil {
+		return err
+	}
+	client, err := refineryClient()
+	if err != nil {
+		return err
+	}
+	if client != nil {
+		return runRemoteRefineryQueue(client, worker)
+	}
 
-	mgr, _, rigName, err := getRefineryManager(rigName)
+	mgr, r, rigName, err := getRefineryManager(rigName)
 	if err != nil {
 		return err
 	}
@@ -400,6 +522,31 @@ func runRefineryQueue(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("getting queue: %w", err)
 	}
+	if worker != "" || !filter.IsEmpty() {
+		issueIDs := make([]string, 0, len(queue))
+		for _, item := range queue {
+			if worker == "" || sameWorker(item.MR.Worker, worker) {
+				issueIDs = append(issueIDs, item.MR.IssueID)
+			}
+		}
+		if err := filter.Prefetch(issueIDs); err != nil {
+			return err
+		}
+		selected := queue[:0]
+		for _, item := range queue {
+			if worker != "" && !sameWorker(item.MR.Worker, worker) {
+				continue
+			}
+			ok, err := filter.Match(item.MR.IssueID, item.MR.SwarmID)
+			if err != nil {
+				return fmt.Errorf("checking %s: %w", item.MR.ID, err)
+			}
+			if ok {
+				selected = append(selected, item)
+			}
+		}
+		queue = selected
+	}
 
 	// JSON output
 	if refineryQueueJSON {
@@ -416,6 +563,12 @@ func runRefineryQueue(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	scan, _ := refinery.LoadConflictScan(r.Path) // Flags are advisory; a bad scan file just hides them
+	waits := prerequisiteWaits(r.Path)
+	stacks := stackBases(r.Path)
+	targets := targetProgress(r.Path)
+	routes := queuedRoutes(r.Path)
+	pulls := handedOff(r.Path)
 	for _, item := range queue {
 		status := ""
 		prefix := fmt.Sprintf("  %d.", item.Position)
@@ -454,18 +607,169 @@ func runRefineryQueue(cmd *cobra.Command, args []string) error {
 			issueInfo = fmt.Sprintf(" (%s)", item.MR.IssueID)
 		}
 
+		age := item.Age
+		if refineryTimestamps {
+			age = util.FormatTime(item.MR.CreatedAt, true)
+		}
+
+		switch item.WorkerLiveness {
+		case rig.WorkerDead:
+			status += " " + style.Error.Render("[worker dead]")
+		case rig.WorkerStale:
+			status += " " + style.Warning.Render("[worker stale]")
+		}
+		if item.MR.SwarmID != "" {
+			status += " " + style.Dim.Render("[swarm "+item.MR.SwarmID+"]")
+		}
+		if peers := conflictPeers(scan, item.MR.Branch); len(peers) > 0 {
+			status += " " + style.Warning.Render("[conflicts with "+strings.Join(peers, ", ")+"]")
+		}
+		if peers := overlapPeers(scan, item.MR.Branch); len(peers) > 0 {
+			status += " " + style.Warning.Render("[overlaps "+strings.Join(peers, ", ")+"]")
+		}
+		if issues := waits[item.MR.Branch]; len(issues) > 0 {
+			status += " " + style.Dim.Render("[blocked by "+strings.Join(issues, ", ")+"]")
+		}
+		if base := stacks[item.MR.Branch]; base != "" {
+			status += " " + style.Dim.Render("[stacked on "+base+"]")
+		}
+		if progress := targets[item.MR.Branch]; progress != "" {
+			status += " " + style.Dim.Render("[targets "+progress+"]")
+		}
+		if names := routes[item.MR.Branch]; len(names) > 0 {
+			status += " " + style.Dim.Render("[route "+strings.Join(names, ", ")+"]")
+		}
+		if pr := pulls[item.MR.Branch]; pr != 0 {
+			status += " " + style.Dim.Render(fmt.Sprintf("[GitHub queue #%d]", pr))
+		}
+
 		fmt.Printf("%s %s %s/%s%s %s\n",
 			prefix,
 			status,
 			item.MR.Worker,
 			item.MR.Branch,
 			issueInfo,
-			style.Dim.Render(item.Age))
+			style.Dim.Render(age))
 	}
 
+	swarms := make([]string, len(queue))
+	for i, item := range queue {
+		swarms[i] = item.MR.SwarmID
+	}
+	printSwarmSubtotals(swarms)
 	return nil
 }
 
+// prerequisiteWaits maps each queued branch to the prerequisite issues whose
+// MRs it waits on.
+func prerequisiteWaits(rigPath string) map[string][]string {
+	queued, err := mrqueue.New(rigPath).List()
+	if err != nil {
+		return nil
+	}
+	waits := make(map[string][]string)
+	for _, mr := range queued {
+		if issues := mr.WaitingOn(queued); len(issues) > 0 {
+			waits[mr.Branch] = issues
+		}
+	}
+	return waits
+}
+
+// stackBases maps each queued branch stacked on another MR (see
+// refinery.stacks) to that MR's ID.
+func stackBases(rigPath string) map[string]string {
+	queued, err := mrqueue.New(rigPath).List()
+	if err != nil {
+		return nil
+	}
+	bases := make(map[string]string)
+	for _, mr := range queued {
+		if mr.StackedOn != "" {
+			bases[mr.Branch] = mr.StackedOn
+		}
+	}
+	return bases
+}
+
+// targetProgress maps each queued branch with several targets to them in
+// order, those it has merged into marked ✓: "release/2.3 ✓, main".
+func targetProgress(rigPath string) map[string]string {
+	queued, err := mrqueue.New(rigPath).List()
+	if err != nil {
+		return nil
+	}
+	progress := make(map[string]string)
+	for _, mr := range queued {
+		if len(mr.Targets) == 0 && len(mr.Merged) == 0 {
+			continue
+		}
+		var parts []string
+		for _, m := range mr.Merged {
+			parts = append(parts, m.Target+" ✓")
+		}
+		parts = append(parts, mr.Target)
+		parts = append(parts, mr.Targets...)
+		progress[mr.Branch] = strings.Join(parts, ", ")
+	}
+	return progress
+}
+
+// queuedRoutes maps each queued branch to the [[refinery.routes]] it was
+// queued under.
+func queuedRoutes(rigPath string) map[string][]string {
+	queued, err := mrqueue.New(rigPath).List()
+	if err != nil {
+		return nil
+	}
+	routes := make(map[string][]string)
+	for _, mr := range queued {
+		if len(mr.Routes) > 0 {
+			routes[mr.Branch] = mr.Routes
+		}
+	}
+	return routes
+}
+
+// handedOff maps each queued branch handed to GitHub's merge queue to its
+// pull request number.
+func handedOff(rigPath string) map[string]int {
+	handed, err := mrqueue.New(rigPath).ListHandedOff()
+	if err != nil {
+		return nil
+	}
+	pulls := make(map[string]int, len(handed))
+	for _, mr := range handed {
+		pulls[mr.Branch] = mr.PullRequest
+	}
+	return pulls
+}
+
+// printSwarmSubtotals prints how many queued MRs each swarm has, in queue
+// order, given each MR's swarm ("" for none). Prints nothing if no MR is
+// part of a swarm.
+func printSwarmSubtotals(swarms []string) {
+	counts := make(map[string]int)
+	var order []string
+	for _, id := range swarms {
+		if id == "" {
+			id = "(none)"
+		}
+		if counts[id] == 0 {
+			order = append(order, id)
+		}
+		counts[id]++
+	}
+	if len(order) == 0 || (len(order) == 1 && order[0] == "(none)") {
+		return
+	}
+
+	fmt.Printf("\n%s\n", style.Bold.Render("By swarm:"))
+	for _, id := range order {
+		fmt.Printf("  %-20s %d MR(s)\n", id, counts[id])
+	}
+}
+
 func runRefineryAttach(cmd *cobra.Command, args []string) error {
 	rigName := ""
 	if len(args) > 0 {
@@ -556,6 +860,11 @@ func runRefineryClaim(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("claiming MR: %w", err)
 	}
 
+	// Mirror the claim onto the source issue (best-effort)
+	if mr, err := q.Get(mrID); err == nil {
+		_ = refinery.MarkMRIssue(beads.New("."), mr, refinery.IssueMRMerging)
+	}
+
 	fmt.Printf("%s Claimed %s for %s\n", style.Bold.Render("✓"), mrID, workerID)
 	return nil
 }
@@ -630,6 +939,9 @@ func runRefineryReady(cmd *cobra.Command, args []string) error {
 
 	// Create engineer for the rig (it has beads access for status checking)
 	eng := refinery.NewEngineer(r)
+	if err := eng.LoadConfig(); err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
 
 	// Get ready MRs (unclaimed AND unblocked)
 	ready, err := eng.ListReadyMRs()
@@ -648,19 +960,48 @@ func runRefineryReady(cmd *cobra.Command, args []string) error {
 	fmt.Printf("%s Ready MRs for '%s':\n\n", style.Bold.Render("🚀"), rigName)
 
 	if len(ready) == 0 {
+		if err := rig.CheckNotPaused(r.Path); err != nil {
+			fmt.Printf("  %s\n", style.Dim.Render(fmt.Sprintf("(%v)", err)))
+			return nil
+		}
+		if eng.Paused() {
+			fmt.Printf("  %s\n", style.Dim.Render("(refinery paused; 'gt refinery resume' to continue)"))
+			return nil
+		}
+		if !eng.InMergeWindow() {
+			fmt.Printf("  %s\n", style.Dim.Render("(outside the merge window in settings/rig.toml)"))
+			return nil
+		}
 		fmt.Printf("  %s\n", style.Dim.Render("(none ready)"))
 		return nil
 	}
 
+	risks, err := eng.RiskScores(ready)
+	if err != nil {
+		return err
+	}
 	for i, mr := range ready {
 		priority := fmt.Sprintf("P%d", mr.Priority)
 		fmt.Printf("  %d. [%s] %s → %s\n", i+1, priority, mr.Branch, mr.Target)
 		fmt.Printf("     ID: %s  Worker: %s\n", mr.ID, mr.Worker)
+		if r, ok := risks[mr.ID]; ok {
+			fmt.Printf("     Risk: %s %s\n", formatRisk(r),
+				style.Dim.Render(fmt.Sprintf("(%d lines, %d risky paths, %.0f%% worker failures, %.0f%% untested)",
+					r.Lines, len(r.RiskyPaths), 100*r.FailureRate, 100*r.Untested)))
+		}
 	}
 
 	return nil
 }
 
+// formatRisk renders a risk score, flagged if high.
+func formatRisk(r refinery.Risk) string {
+	if r.High {
+		return style.Warning.Render(fmt.Sprintf("%.2f high", r.Score))
+	}
+	return fmt.Sprintf("%.2f", r.Score)
+}
+
 func runRefineryBlocked(cmd *cobra.Command, args []string) error {
 	rigName := ""
 	if len(args) > 0 {
@@ -681,6 +1022,63 @@ func runRefineryBlocked(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("listing blocked MRs: %w", err)
 	}
 
+	approval, err := eng.ListApprovalMRs()
+	if err != nil {
+		return fmt.Errorf("listing MRs awaiting approval: %w", err)
+	}
+	blocked = append(blocked, approval...)
+
+	if err := eng.LoadConfig(); err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	backlog, err := eng.ListBacklogMRs()
+	if err != nil {
+		return fmt.Errorf("listing backlogged MRs: %w", err)
+	}
+	backlogged := make(map[string]bool, len(backlog))
+	for _, mr := range backlog {
+		backlogged[mr.ID] = true
+	}
+	blocked = append(blocked, backlog...)
+
+	waiting, err := eng.ListWaitingMRs()
+	if err != nil {
+		return fmt.Errorf("listing MRs waiting on prerequisites: %w", err)
+	}
+	listed := make(map[string]bool, len(blocked))
+	for _, mr := range blocked {
+		listed[mr.ID] = true
+	}
+	for _, mr := range waiting {
+		if !listed[mr.ID] {
+			listed[mr.ID] = true
+			blocked = append(blocked, mr)
+		}
+	}
+	waits := prerequisiteWaits(r.Path)
+
+	gated, gateWaits, err := eng.ListGatedMRs()
+	if err != nil {
+		return fmt.Errorf("listing MRs waiting on refinery.issue_gate: %w", err)
+	}
+	for _, mr := range gated {
+		if !listed[mr.ID] {
+			listed[mr.ID] = true
+			blocked = append(blocked, mr)
+		}
+	}
+
+	overBudget, budgetWaits, err := eng.ListOverBudgetMRs()
+	if err != nil {
+		return fmt.Errorf("listing MRs waiting on refinery.budget: %w", err)
+	}
+	for _, mr := range overBudget {
+		if !listed[mr.ID] {
+			listed[mr.ID] = true
+			blocked = append(blocked, mr)
+		}
+	}
+
 	// JSON output
 	if refineryBlockedJSON {
 		enc := json.NewEncoder(os.Stdout)
@@ -703,6 +1101,25 @@ func runRefineryBlocked(cmd *cobra.Command, args []string) error {
 		if mr.BlockedBy != "" {
 			fmt.Printf("     Blocked by: %s\n", mr.BlockedBy)
 		}
+		if issues := waits[mr.Branch]; len(issues) > 0 {
+			fmt.Printf("     Blocked by: %s %s\n", strings.Join(issues, ", "), style.Dim.Render("(prerequisite MRs still queued)"))
+		}
+		if mr.StackedOn != "" {
+			fmt.Printf("     Stacked on: %s %s\n", mr.StackedOn, style.Dim.Render("(merges after it)"))
+		}
+		if mr.NeedsApproval() {
+			fmt.Printf("     Needs approval: %s %s\n", mr.ApprovalReason,
+				style.Dim.Render("(gt refinery approve "+mr.ID+")"))
+		}
+		if why := gateWaits[mr.ID]; why != "" {
+			fmt.Printf("     Waiting on issue: %s %s\n", why, style.Dim.Render("(refinery.issue_gate)"))
+		}
+		if why := budgetWaits[mr.ID]; why != "" {
+			fmt.Printf("     Over change budget: %s %s\n", why, style.Dim.Render("(refinery.budget; merges tomorrow)"))
+		}
+		if backlogged[mr.ID] {
+			fmt.Printf("     Backlogged behind %s's other ready MRs %s\n", mr.Worker, style.Dim.Render("(refinery.workers.max_ready)"))
+		}
 	}
 
 	return nil
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var (
+	refineryBisectTarget  string
+	refineryBisectLimit   int
+	refineryBisectCommand string
+	refineryBisectJSON    bool
+	refineryRevertYes     bool
+)
+
+var refineryBisectCmd = &cobra.Command{
+	Use:   "bisect [rig]",
+	Short: "Find the merge that broke the target",
+	Long: `Find the refinery merge that broke the target branch.
+
+Runs the checks (refinery.checks, or --command) at the target's tip on
+origin. If they fail, binary-searches the target's latest refinery merges
+(--limit, default 20), by the merge commits in the event log, for the
+first one the checks fail at, starting from the target as it was before
+the oldest of them. Checks run in a scratch worktree; nothing is changed.
+
+When a culprit is found, 'gt refinery revert' undoes it in one command.
+
+Examples:
+  gt refinery bisect
+  gt refinery bisect greenplace --limit 50
+  gt refinery bisect --command "go test ./internal/..."`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRefineryBisect,
+}
+
+var refineryRevertCmd = &cobra.Command{
+	Use:   "revert <rig> <mr-id|merge-commit>",
+	Short: "Revert a refinery merge on its target",
+	Long: `Revert a merge the refinery made and push the revert to its target.
+
+The merge is named by its MR ID or its merge commit, as recorded in the
+event log; 'gt refinery bisect' prints the command for the merge it
+blames. A merge commit is reverted against the target it was merged into.
+The revert is made on the target as origin has it, in a scratch worktree,
+and pushed straight to origin.
+
+Examples:
+  gt refinery revert greenplace gt-mr-abc
+  gt refinery revert greenplace 3f2a9c1e --yes`,
+	Args: cobra.ExactArgs(2),
+	RunE: runRefineryRevert,
+}
+
+func init() {
+	refineryBisectCmd.Flags().StringVar(&refineryBisectTarget, "target", "", "Branch to bisect (default: the rig's default branch)")
+	refineryBisectCmd.Flags().IntVar(&refineryBisectLimit, "limit", refinery.DefaultBisectLimit, "How many recent merges to search")
+	refineryBisectCmd.Flags().StringVar(&refineryBisectCommand, "command", "", "Check to run instead of refinery.checks (sh -c)")
+	refineryBisectCmd.Flags().BoolVar(&refineryBisectJSON, "json", false, "Output as JSON")
+	refineryRevertCmd.Flags().BoolVarP(&refineryRevertYes, "yes", "y", false, "Skip the confirmation prompt")
+	refineryCmd.AddCommand(refineryBisectCmd)
+	refineryCmd.AddCommand(refineryRevertCmd)
+}
+
+func runRefineryBisect(cmd *cobra.Command, args []string) error {
+	rigName := ""
+	if len(args) > 0 {
+		rigName = args[0]
+	}
+	_, r, rigName, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+	eng := refinery.NewEngineer(r)
+	if err := eng.LoadConfig(); err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	eng.SetOutput(os.Stderr)
+
+	res, err := eng.Bisect(context.Background(), refineryBisectTarget, refineryBisectLimit, refineryBisectCommand)
+	if err != nil {
+		return err
+	}
+	if refineryBisectJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+	}
+
+	fmt.Printf("%s Bisecting %s %s\n\n", style.Bold.Render("🔎"), res.Target,
+		style.Dim.Render(fmt.Sprintf("(%d merges searched, %d checked)", res.Merges, len(res.Steps))))
+	for _, s := range res.Steps {
+		mark, what := style.Success.Render("✓"), "tip"
+		if !s.Passed {
+			mark = style.Error.Render("✗")
+		}
+		switch {
+		case s.Branch != "":
+			what = s.Branch
+		case s.Commit != res.Tip:
+			what = "before the oldest merge"
+		}
+		line := fmt.Sprintf("  %s %s %s", mark, shortCommit(s.Commit), what)
+		if s.FailedCheck != "" {
+			line += style.Dim.Render(" (" + s.FailedCheck + " failed)")
+		}
+		fmt.Println(line)
+	}
+	fmt.Println()
+
+	switch {
+	case res.Passing:
+		fmt.Printf("%s Checks pass at the tip of %s; nothing to find\n", style.Success.Render("✓"), res.Target)
+	case res.Outside == refinery.BisectBefore:
+		fmt.Printf("%s Checks already fail before the oldest merge searched; try a larger --limit\n", style.Warning.Render("⚠"))
+	case res.Outside == refinery.BisectAfter:
+		fmt.Printf("%s Checks pass at the last refinery merge; a commit pushed to %s since broke it\n", style.Warning.Render("⚠"), res.Target)
+	case res.Culprit != nil:
+		c := res.Culprit
+		fmt.Printf("%s First failing merge: %s %s", style.Error.Render("✗"), c.Branch, style.Dim.Render(shortCommit(c.MergeCommit)))
+		if c.MRID != "" {
+			fmt.Printf(" (MR %s", c.MRID)
+			if c.Worker != "" {
+				fmt.Printf(" by %s", c.Worker)
+			}
+			fmt.Print(")")
+		}
+		fmt.Printf("\n\nTo revert it:\n  gt refinery revert %s %s\n", rigName, shortCommit(c.MergeCommit))
+	}
+	return nil
+}
+
+func runRefineryRevert(cmd *cobra.Command, args []string) error {
+	_, r, _, err := getRefineryManager(args[0])
+	if err != nil {
+		return err
+	}
+	eng := refinery.NewEngineer(r)
+	if err := eng.LoadConfig(); err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	eng.SetOutput(os.Stderr)
+
+	merge, err := eng.FindMerge(args[1])
+	if err != nil {
+		return err
+	}
+	if !confirmDestructive(fmt.Sprintf("Reverting %s on origin/%s", merge.Branch, merge.Target),
+		[]string{fmt.Sprintf("merge commit %s (MR %s)", shortCommit(merge.MergeCommit), merge.MRID)}, refineryRevertYes) {
+		return nil
+	}
+	sha, err := eng.Revert(merge)
+	if err != nil {
+		return fmt.Errorf("reverting %s: %w", merge.Branch, err)
+	}
+	fmt.Printf("%s Reverted %s on %s as %s\n", style.Success.Render("✓"), merge.Branch, merge.Target, shortCommit(sha))
+	return nil
+}
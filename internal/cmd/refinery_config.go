@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var refineryConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Work with the refinery's settings in rig.toml",
+	RunE:  requireSubcommand,
+}
+
+var refineryConfigCheckCmd = &cobra.Command{
+	Use:   "check [rig]",
+	Short: "Check the [refinery] settings before the refinery runs them",
+	Long: `Check the rig's settings/rig.toml and the machine it runs on.
+
+The file is parsed and validated as the refinery would load it: unknown
+keys, bad durations, schedules, patterns, and so on. Then the settings are
+checked against this machine:
+
+  - the programs check, agent, and digest commands start with exist
+    (scripts relative to the repository, others on PATH)
+  - the sandbox runtime is installed
+  - secrets and files referenced from [refinery.env] resolve
+  - origin answers, and has the target branch
+
+Problems are reported as file:line: key: message. Inside a container
+sandbox, check commands are only looked for when they are paths into the
+repository. Command lines that need a shell to take apart (quoting,
+expansions) are not judged.
+
+Exits non-zero if any problem is found.
+
+Examples:
+  gt refinery config check
+  gt refinery config check greenplace`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRefineryConfigCheck,
+}
+
+func init() {
+	refineryConfigCmd.AddCommand(refineryConfigCheckCmd)
+	refineryCmd.AddCommand(refineryConfigCmd)
+}
+
+func runRefineryConfigCheck(cmd *cobra.Command, args []string) error {
+	rigName := ""
+	if len(args) > 0 {
+		rigName = args[0]
+	}
+	_, r, rigName, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s Refinery config for '%s':\n\n", style.Bold.Render("🔧"), rigName)
+	path := config.RigFilePath(r.Path)
+	var problems []error
+	if _, err := config.LoadRigFile(path); errors.Is(err, config.ErrNotFound) {
+		fmt.Printf("  %s\n\n", style.Dim.Render("No "+path+"; using defaults"))
+	} else if err != nil {
+		problems = append(problems, err)
+	}
+
+	// Settings that do not load cannot be checked further.
+	if len(problems) == 0 {
+		eng := refinery.NewEngineer(r)
+		if err := eng.LoadConfig(); err != nil {
+			problems = append(problems, err)
+		} else {
+			problems = eng.CheckConfig()
+		}
+	}
+
+	if len(problems) == 0 {
+		fmt.Printf("  %s No problems found\n", style.Bold.Render("✓"))
+		return nil
+	}
+	fmt.Printf("  %s %d problem(s):\n", style.Error.Render("✗"), len(problems))
+	for _, p := range problems {
+		fmt.Printf("     %v\n", p)
+	}
+	return NewSilentExit(1)
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+var refineryConflictsCmd = &cobra.Command{
+	Use:   "conflicts [rig]",
+	Short: "Test-merge queued branches from different swarms against each other",
+	Long: `Find queued MRs from different swarms that will conflict once both land.
+
+Each swarm merges into its own integration/<epic> branch, so two swarms
+editing the same files only collide when the second one lands. This
+test-merges every pair of queued branches from different swarms, in a
+scratch worktree, and lists the pairs that conflict. Hold or requeue one
+side, or get the workers talking, before the conflict reaches the head
+of the queue.
+
+With [refinery] semantic_conflicts set, it also parses the Go files each
+queued branch changes and lists pairs of MRs, from any swarm, that change
+the same top-level declarations, even if they merge cleanly. With
+"serialize", the later MR of each such pair waits until the earlier one
+has merged.
+
+The refinery runs this each patrol cycle. A scan newer than
+[refinery.integration] conflict_scan_interval (default 15m) is reused;
+pairs whose branch heads have not moved are not merged again.
+'gt refinery queue' flags MRs from the last scan.
+
+Examples:
+  gt refinery conflicts
+  gt refinery conflicts greenplace --force
+  gt refinery conflicts --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRefineryConflicts,
+}
+
+var (
+	refineryConflictsForce bool
+	refineryConflictsJSON  bool
+)
+
+func init() {
+	refineryConflictsCmd.Flags().BoolVar(&refineryConflictsForce, "force", false, "Rescan even if the last scan is recent")
+	refineryConflictsCmd.Flags().BoolVar(&refineryConflictsJSON, "json", false, "Output as JSON")
+	refineryCmd.AddCommand(refineryConflictsCmd)
+}
+
+func runRefineryConflicts(cmd *cobra.Command, args []string) error {
+	rigName := ""
+	if len(args) > 0 {
+		rigName = args[0]
+	}
+	_, r, rigName, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+	eng := refinery.NewEngineer(r)
+	if err := eng.LoadConfig(); err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	scan, err := eng.ScanSwarmConflicts(refineryConflictsForce)
+	if err != nil {
+		return fmt.Errorf("scanning for conflicts: %w", err)
+	}
+
+	if refineryConflictsJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(scan)
+	}
+
+	fmt.Printf("%s Cross-swarm conflicts for '%s' %s\n\n", style.Bold.Render("⚔"), rigName,
+		style.Dim.Render(fmt.Sprintf("(%d pairs, scanned %s)", scan.Pairs, util.FormatTime(scan.ScannedAt, false))))
+	if len(scan.Conflicts) == 0 {
+		fmt.Printf("  %s\n", style.Dim.Render("(none)"))
+	}
+	for _, c := range scan.Conflicts {
+		fmt.Printf("  %s %s (swarm %s) × %s (swarm %s)\n", style.Warning.Render("⚠"),
+			c.MRs[0], c.Swarms[0], c.MRs[1], c.Swarms[1])
+		fmt.Printf("     %s\n     %s\n", c.Branches[0], c.Branches[1])
+		fmt.Printf("     Files: %s\n", strings.Join(c.Files, ", "))
+	}
+
+	if len(scan.Overlaps) > 0 {
+		fmt.Printf("\n%s Semantic overlaps\n\n", style.Bold.Render("⚔"))
+	}
+	for _, o := range scan.Overlaps {
+		fmt.Printf("  %s %s × %s\n", style.Warning.Render("⚠"), o.MRs[0], o.MRs[1])
+		fmt.Printf("     %s\n     %s\n", o.Branches[0], o.Branches[1])
+		fmt.Printf("     Symbols: %s\n", strings.Join(o.Symbols, ", "))
+	}
+	return nil
+}
+
+// conflictPeers returns the MRs the last conflict scan found conflicting
+// with branch.
+func conflictPeers(scan *refinery.ConflictScan, branch string) []string {
+	var peers []string
+	for _, c := range scan.ConflictsWith(branch) {
+		if c.Branches[0] == branch {
+			peers = append(peers, c.MRs[1])
+		} else {
+			peers = append(peers, c.MRs[0])
+		}
+	}
+	return peers
+}
+
+// overlapPeers returns the MRs the last conflict scan found changing the
+// same declarations as branch.
+func overlapPeers(scan *refinery.ConflictScan, branch string) []string {
+	var peers []string
+	for _, o := range scan.OverlapsWith(branch) {
+		if o.Branches[0] == branch {
+			peers = append(peers, o.MRs[1])
+		} else {
+			peers = append(peers, o.MRs[0])
+		}
+	}
+	return peers
+}
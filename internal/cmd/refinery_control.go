@@ -0,0 +1,539 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// Remote control flags (persistent on refineryCmd)
+var (
+	refineryRemote string
+	refineryToken  string
+)
+
+// Refinery control command flags
+var (
+	refineryControlRig    string
+	refineryEnqueueTarget []string
+	refineryEnqueueIssue  string
+	refineryEnqueueWorker string
+	refineryEnqueuePrio   int
+	refineryHoldReason    string
+	refineryApproveBy     string
+	refineryPauseFor      time.Duration
+	refineryPauseUntil    string
+)
+
+// refineryRemoteCommands are the refinery subcommands that accept --remote.
+// Everything else needs the rig on local disk.
+var refineryRemoteCommands = map[string]bool{
+	"status":  true,
+	"queue":   true,
+	"pause":   true,
+	"resume":  true,
+	"enqueue": true,
+	"hold":    true,
+	"requeue": true,
+	"approve": true,
+	"stats":   true,
+	"history": true,
+}
+
+var refineryPauseCmd = &cobra.Command{
+	Use:   "pause [rig]",
+	Short: "Pause merge processing",
+	Long: `Pause a running Refinery. Queued MRs stay queued until 'gt refinery resume'.
+
+With --for or --until the pause is timed: the refinery resumes on its own
+once the deadline passes, so a pause for a demo doesn't last the weekend.
+--until takes a local time today ("17:30", tomorrow if already past), a
+local date and time ("2026-03-02 09:00"), or RFC 3339.
+
+If rig is not specified, infers it from the current directory.
+
+Examples:
+  gt refinery pause greenplace
+  gt refinery pause --for 2h
+  gt refinery pause --until 17:30
+  gt refinery pause --remote https://rig-host:8080`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRefineryPause,
+}
+
+var refineryResumeCmd = &cobra.Command{
+	Use:   "resume [rig]",
+	Short: "Resume merge processing",
+	Long: `Resume a paused Refinery.
+
+If rig is not specified, infers it from the current directory.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRefineryResume,
+}
+
+var refineryEnqueueCmd = &cobra.Command{
+	Use:   "enqueue <branch>",
+	Short: "Add a branch to the merge queue",
+	Long: `Add a branch to the merge queue.
+
+The target defaults to the rig's default branch. Given several targets,
+the MR merges into each in turn, running that target's checks, and stays
+queued until it has merged into the last. Branch a fix that goes to a
+release and main from the release, so merging it brings nothing else in.
+
+Examples:
+  gt refinery enqueue polecat/nux/gt-abc --issue gt-abc
+  gt refinery enqueue polecat/nux/gt-def --target release/2.3,main
+  gt refinery enqueue feature/x --target develop --remote https://rig-host:8080`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRefineryEnqueue,
+}
+
+var refineryHoldCmd = &cobra.Command{
+	Use:   "hold [mr-id]",
+	Short: "Hold an MR so the refinery skips it",
+	Long: `Hold an MR so the refinery skips it until 'gt refinery requeue'.
+
+With --mine or --worker and no MR ID, every queued MR from that worker is
+held. Polecats can only hold their own MRs.
+
+--epic and --label hold every queued MR whose source issue is under the
+epic or carries the labels, for pausing a line of work while a question
+about it is settled.
+
+Examples:
+  gt refinery hold mr-1700000000-abcd1234 --reason "waiting on API change"
+  gt refinery hold --mine --reason "found a bug, fixing"
+  gt refinery hold --epic gt-payments --reason "settling the refund design"
+  gt refinery hold --worker nux --rig greenplace`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRefineryHold,
+}
+
+var refineryRequeueCmd = &cobra.Command{
+	Use:   "requeue [mr-id]",
+	Short: "Clear hold, claim, and block so an MR is retried",
+	Long: `Clear an MR's hold, claim, and block so the refinery retries it.
+
+With --mine or --worker and no MR ID, every queued MR from that worker is
+requeued. Polecats can only requeue their own MRs. --epic and --label
+requeue every queued MR whose source issue is under the epic or carries
+the labels.
+
+Examples:
+  gt refinery requeue mr-1700000000-abcd1234
+  gt refinery requeue --mine
+  gt refinery requeue --epic gt-payments`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRefineryRequeue,
+}
+
+var refineryApproveCmd = &cobra.Command{
+	Use:   "approve <mr-id>",
+	Short: "Approve an MR waiting for review",
+	Long: `Approve an MR waiting for review because its worker is not trusted by
+settings/rig.toml, or because the resolver agent fixed its conflicts with
+less than auto_merge_confidence:
+
+  [refinery.workers]
+  allow = ["nux", "crew-*"]   # only these merge without approval
+  deny = ["scratch-*"]        # these always need approval
+
+The approval is recorded on the MR, so later retries merge without asking
+again. 'gt refinery blocked' lists MRs awaiting approval.
+
+Examples:
+  gt refinery approve mr-1700000000-abcd1234
+  gt refinery approve mr-1700000000-abcd1234 --by mayor --remote https://rig-host:8080`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRefineryApprove,
+}
+
+func init() {
+	refineryCmd.PersistentFlags().StringVar(&refineryRemote, "remote", "", "Operate a refinery served by 'gt refinery serve' at this URL")
+	refineryCmd.PersistentFlags().StringVar(&refineryToken, "token", "", "API token for --remote (default: $"+refinery.TokenEnvVar+")")
+
+	for _, c := range []*cobra.Command{refineryEnqueueCmd, refineryHoldCmd, refineryRequeueCmd, refineryApproveCmd} {
+		c.Flags().StringVar(&refineryControlRig, "rig", "", "Rig name (default: infer from current directory)")
+	}
+	refineryEnqueueCmd.Flags().StringSliceVar(&refineryEnqueueTarget, "target", nil, "Target branches, merged into in order (default: rig default branch)")
+	refineryEnqueueCmd.Flags().StringVar(&refineryEnqueueIssue, "issue", "", "Source issue ID")
+	refineryEnqueueCmd.Flags().StringVar(&refineryEnqueueWorker, "worker", "", "Worker that produced the branch")
+	refineryEnqueueCmd.Flags().IntVar(&refineryEnqueuePrio, "priority", 2, "Priority (0=urgent, 4=backlog)")
+	refineryHoldCmd.Flags().StringVar(&refineryHoldReason, "reason", "", "Why the MR is held")
+	for _, c := range []*cobra.Command{refineryHoldCmd, refineryRequeueCmd} {
+		c.Flags().BoolVar(&refineryMine, "mine", false, "Act on your own MRs (GT_POLECAT or GT_CREW)")
+		c.Flags().StringVar(&refineryWorker, "worker", "", "Act on this worker's MRs")
+		c.Flags().StringVar(&refineryEpic, "epic", "", "Act on MRs whose source issue is under this epic")
+		c.Flags().StringSliceVar(&refineryLabels, "label", nil, "Act on MRs whose source issue has this label (repeatable)")
+	}
+	refineryPauseCmd.Flags().DurationVar(&refineryPauseFor, "for", 0, "Resume automatically after this long (e.g. 2h)")
+	refineryPauseCmd.Flags().StringVar(&refineryPauseUntil, "until", "", "Resume automatically at this time")
+	refineryPauseCmd.MarkFlagsMutuallyExclusive("for", "until")
+	refineryApproveCmd.Flags().StringVar(&refineryApproveBy, "by", "", "Who is approving (default: detected sender)")
+
+	refineryCmd.AddCommand(refineryPauseCmd)
+	refineryCmd.AddCommand(refineryResumeCmd)
+	refineryCmd.AddCommand(refineryEnqueueCmd)
+	refineryCmd.AddCommand(refineryHoldCmd)
+	refineryCmd.AddCommand(refineryRequeueCmd)
+	refineryCmd.AddCommand(refineryApproveCmd)
+}
+
+// refineryClient returns an API client when --remote is set, nil otherwise.
+func refineryClient() (*refinery.Client, error) {
+	if refineryRemote == "" {
+		return nil, nil
+	}
+	token := refineryToken
+	if token == "" {
+		token = os.Getenv(refinery.TokenEnvVar)
+	}
+	return refinery.NewClient(refineryRemote, token)
+}
+
+// checkRefineryRemote rejects --remote on commands that only work locally.
+func checkRefineryRemote(cmd *cobra.Command) error {
+	if refineryRemote != "" && !refineryRemoteCommands[cmd.Name()] {
+		return fmt.Errorf("gt refinery %s does not support --remote", cmd.Name())
+	}
+	return nil
+}
+
+func runRefineryPause(cmd *cobra.Command, args []string) error {
+	var until time.Time
+	switch {
+	case cmd.Flags().Changed("for"):
+		if refineryPauseFor <= 0 {
+			return fmt.Errorf("--for must be positive")
+		}
+		until = time.Now().Add(refineryPauseFor)
+	case refineryPauseUntil != "":
+		t, err := parsePauseUntil(refineryPauseUntil, time.Now())
+		if err != nil {
+			return err
+		}
+		until = t
+	}
+	if until.IsZero() {
+		return changeRefineryState(args, "Paused", (*refinery.Client).Pause, (*refinery.Manager).Pause)
+	}
+	err := changeRefineryState(args, "Paused",
+		func(c *refinery.Client) (*refinery.Refinery, error) { return c.PauseUntil(until) },
+		func(m *refinery.Manager) error { return m.PauseUntil(until) })
+	if err != nil {
+		return err
+	}
+	fmt.Printf("  Resumes automatically at %s\n", until.Local().Format(util.TimestampLayout))
+	return nil
+}
+
+// parsePauseUntil parses --until: a local time of day, meaning its next
+// occurrence after now, a local date and time, or RFC 3339.
+func parsePauseUntil(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.ParseInLocation("15:04", s, time.Local); err == nil {
+		y, m, d := now.Date()
+		at := time.Date(y, m, d, t.Hour(), t.Minute(), 0, 0, time.Local)
+		if !at.After(now) {
+			at = time.Date(y, m, d+1, t.Hour(), t.Minute(), 0, 0, time.Local)
+		}
+		return at, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --until %q: want HH:MM, \"YYYY-MM-DD HH:MM\", or RFC 3339", s)
+}
+
+func runRefineryResume(cmd *cobra.Command, args []string) error {
+	return changeRefineryState(args, "Resumed", (*refinery.Client).Resume, (*refinery.Manager).Resume)
+}
+
+// changeRefineryState applies pause or resume locally or via --remote.
+func changeRefineryState(args []string, verb string, remote func(*refinery.Client) (*refinery.Refinery, error), local func(*refinery.Manager) error) error {
+	client, err := refineryClient()
+	if err != nil {
+		return err
+	}
+	if client != nil {
+		ref, err := remote(client)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s %s refinery for %s\n", style.Bold.Render("✓"), verb, ref.RigName)
+		return nil
+	}
+
+	rigName := ""
+	if len(args) > 0 {
+		rigName = args[0]
+	}
+	mgr, _, rigName, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+	if err := local(mgr); err != nil {
+		return err
+	}
+	fmt.Printf("%s %s refinery for %s\n", style.Bold.Render("✓"), verb, rigName)
+	return nil
+}
+
+func runRefineryEnqueue(cmd *cobra.Command, args []string) error {
+	req := refinery.EnqueueRequest{
+		Branch:      args[0],
+		SourceIssue: refineryEnqueueIssue,
+		Worker:      refineryEnqueueWorker,
+		Priority:    refineryEnqueuePrio,
+	}
+	if len(refineryEnqueueTarget) > 0 {
+		req.Target, req.Targets = refineryEnqueueTarget[0], refineryEnqueueTarget[1:]
+		if err := refinery.CheckTargets(req.Target, req.Targets); err != nil {
+			return err
+		}
+	}
+
+	client, err := refineryClient()
+	if err != nil {
+		return err
+	}
+
+	var mr *mrqueue.MR
+	if client != nil {
+		if mr, err = client.Enqueue(req); err != nil {
+			return err
+		}
+	} else {
+		mgr, r, _, err := getRefineryManager(refineryControlRig)
+		if err != nil {
+			return err
+		}
+		if req.Target == "" {
+			req.Target = r.DefaultBranch()
+		}
+		if err := rig.CheckNotPaused(r.Path); err != nil {
+			return err
+		}
+		for _, target := range append([]string{req.Target}, req.Targets...) {
+			if err := mgr.CheckScope(req.Branch, target); errors.Is(err, refinery.ErrOutOfScope) {
+				return err
+			}
+		}
+		mr = &mrqueue.MR{
+			Branch:      req.Branch,
+			Target:      req.Target,
+			Targets:     req.Targets,
+			SourceIssue: req.SourceIssue,
+			Worker:      req.Worker,
+			Rig:         r.Name,
+			Priority:    req.Priority,
+		}
+		mgr.Route(mr)
+		mr.SwarmID = mgr.SwarmFor(mr.Branch, mr.Target, mr.SourceIssue)
+		mgr.SetOutput(os.Stderr)
+		if err := mgr.Admit(context.Background(), mr); err != nil {
+			return err
+		}
+		if err := mrqueue.New(r.Path).Submit(mr); err != nil {
+			return fmt.Errorf("submitting to queue: %w", err)
+		}
+	}
+
+	targets := append([]string{mr.Target}, mr.Targets...)
+	fmt.Printf("%s Enqueued %s: %s → %s\n", style.Bold.Render("✓"), mr.ID, mr.Branch, strings.Join(targets, ", "))
+	if len(mr.Routes) > 0 {
+		fmt.Printf("  Routes: %s\n", strings.Join(mr.Routes, ", "))
+	}
+	return nil
+}
+
+func runRefineryHold(cmd *cobra.Command, args []string) error {
+	ids, err := scopedMRIDs(args)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		mr, err := updateQueuedMR(id,
+			func(c *refinery.Client, id string) (*mrqueue.MR, error) { return c.Hold(id, refineryHoldReason) },
+			func(q *mrqueue.Queue) error { return q.Hold(id, refineryHoldReason) },
+			func(ev *mrqueue.EventLogger, mr *mrqueue.MR) error { return ev.LogHeld(mr, mr.HeldReason) })
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s Held %s (%s)\n", style.Bold.Render("✓"), mr.ID, mr.HeldReason)
+	}
+	return nil
+}
+
+func runRefineryRequeue(cmd *cobra.Command, args []string) error {
+	ids, err := scopedMRIDs(args)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		mr, err := updateQueuedMR(id,
+			(*refinery.Client).Requeue,
+			func(q *mrqueue.Queue) error { return q.Requeue(id) },
+			(*mrqueue.EventLogger).LogRequeued)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s Requeued %s\n", style.Bold.Render("✓"), mr.ID)
+	}
+	return nil
+}
+
+func runRefineryApprove(cmd *cobra.Command, args []string) error {
+	if os.Getenv("GT_POLECAT") != "" {
+		return fmt.Errorf("polecats cannot approve merges")
+	}
+	by := refineryApproveBy
+	if by == "" {
+		by = detectSender()
+	}
+	mr, err := updateQueuedMR(args[0],
+		func(c *refinery.Client, id string) (*mrqueue.MR, error) { return c.Approve(id, by) },
+		func(q *mrqueue.Queue) error { return q.Approve(args[0], by) },
+		(*mrqueue.EventLogger).LogApproved)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Approved %s (%s → %s)\n", style.Bold.Render("✓"), mr.ID, mr.Branch, mr.Target)
+	return nil
+}
+
+// updateQueuedMR applies a queue mutation locally or via --remote and
+// returns the updated MR. Local changes are recorded in the event log.
+func updateQueuedMR(id string,
+	remote func(*refinery.Client, string) (*mrqueue.MR, error),
+	local func(*mrqueue.Queue) error,
+	logEvent func(*mrqueue.EventLogger, *mrqueue.MR) error,
+) (*mrqueue.MR, error) {
+	client, err := refineryClient()
+	if err != nil {
+		return nil, err
+	}
+	if client != nil {
+		return remote(client, id)
+	}
+
+	_, r, _, err := getRefineryManager(refineryControlRig)
+	if err != nil {
+		return nil, err
+	}
+	q := mrqueue.New(r.Path)
+	if err := local(q); err != nil {
+		return nil, err
+	}
+	mr, err := q.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	_ = logEvent(mrqueue.NewEventLoggerFromRig(r.Path), mr) // Non-fatal: history is best-effort
+	return mr, nil
+}
+
+// runRemoteRefineryStatus is gt refinery status --remote.
+func runRemoteRefineryStatus(client *refinery.Client) error {
+	ref, err := client.Status()
+	if err != nil {
+		return err
+	}
+	if refineryStatusJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(ref)
+	}
+
+	queue, err := client.Queue()
+	if err != nil {
+		return err
+	}
+	pending := 0
+	for _, mr := range queue {
+		if !mr.IsHeld() && !mr.NeedsApproval() {
+			pending++
+		}
+	}
+	printRefineryStatus(ref.RigName, ref, pending)
+	if access, err := client.Access(); err == nil && access.ReadOnly {
+		fmt.Printf("  Access: %s\n", style.Dim.Render("read-only (observer)"))
+	}
+	return nil
+}
+
+// runRemoteRefineryQueue is gt refinery queue --remote. The remote API
+// serves the scored mrqueue, so the listing shows scores and hold state.
+func runRemoteRefineryQueue(client *refinery.Client, worker string) error {
+	queue, err := client.Queue()
+	if err != nil {
+		return err
+	}
+	if worker != "" {
+		mine := queue[:0]
+		for _, mr := range queue {
+			if sameWorker(mr.Worker, worker) {
+				mine = append(mine, mr)
+			}
+		}
+		queue = mine
+	}
+	if refineryQueueJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(queue)
+	}
+
+	fmt.Printf("%s Merge queue at %s:\n\n", style.Bold.Render("📋"), refineryRemote)
+	if len(queue) == 0 {
+		fmt.Printf("  %s\n", style.Dim.Render("(empty)"))
+		return nil
+	}
+
+	queue = mrqueue.GroupBySwarm(queue)
+	swarms := make([]string, len(queue))
+	for i, mr := range queue {
+		swarms[i] = mr.Swarm()
+		status := style.Dim.Render("[pending]")
+		switch {
+		case mr.IsHeld():
+			status = style.Warning.Render("[held: " + mr.HeldReason + "]")
+		case mr.NeedsApproval():
+			status = style.Warning.Render("[needs approval: " + mr.ApprovalReason + "]")
+		case mr.ClaimedBy != "":
+			status = style.Bold.Render("[processing]")
+		case mr.BlockedBy != "":
+			status = style.Dim.Render("[blocked: " + mr.BlockedBy + "]")
+		}
+		if swarms[i] != "" {
+			status += " " + style.Dim.Render("[swarm "+swarms[i]+"]")
+		}
+
+		issueInfo := ""
+		if mr.SourceIssue != "" {
+			issueInfo = fmt.Sprintf(" (%s)", mr.SourceIssue)
+		}
+		fmt.Printf("  %d. %s %s %s/%s%s %s\n",
+			i+1, mr.ID, status, mr.Worker, mr.Branch, issueInfo,
+			style.Dim.Render(util.FormatTime(mr.CreatedAt, refineryTimestamps)))
+	}
+	printSwarmSubtotals(swarms)
+	return nil
+}
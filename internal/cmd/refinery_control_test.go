@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePauseUntil(t *testing.T) {
+	now := time.Date(2026, 3, 6, 16, 0, 0, 0, time.Local)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"17:30", time.Date(2026, 3, 6, 17, 30, 0, 0, time.Local)},
+		{"09:00", time.Date(2026, 3, 7, 9, 0, 0, 0, time.Local)}, // Already past today
+		{"2026-03-09 08:00", time.Date(2026, 3, 9, 8, 0, 0, 0, time.Local)},
+		{"2026-03-09T08:00:00Z", time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := parsePauseUntil(tt.in, now)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("parsePauseUntil(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := parsePauseUntil("after lunch", now); err == nil {
+		t.Error("parsePauseUntil accepted \"after lunch\"")
+	}
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var (
+	refineryDigestDate  string
+	refineryDigestSend  bool
+	refineryDigestForce bool
+	refineryDigestJSON  bool
+)
+
+var refineryDigestCmd = &cobra.Command{
+	Use:   "digest [rig]",
+	Short: "Show or send the day's merge queue digest",
+	Long: `Show a digest of a day's merge queue activity: merges, failures,
+conflicts, and per-worker stats.
+
+With --send, mail today's digest to refinery.notifications.digest in
+settings/rig.toml, written by digest_command if one is set. It is sent once
+a day, after digest_at (default 18:00); before then, or once sent, --send
+does nothing, so the patrol can run it every cycle. --force sends it now.
+
+Examples:
+  gt refinery digest
+  gt refinery digest greenplace --date 2026-03-01
+  gt refinery digest greenplace --send`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRefineryDigest,
+}
+
+func init() {
+	refineryDigestCmd.Flags().StringVar(&refineryDigestDate, "date", "", "Day to show (YYYY-MM-DD, default today)")
+	refineryDigestCmd.Flags().BoolVar(&refineryDigestSend, "send", false, "Mail today's digest if it is due")
+	refineryDigestCmd.Flags().BoolVar(&refineryDigestForce, "force", false, "With --send, send now even if not due or already sent")
+	refineryDigestCmd.Flags().BoolVar(&refineryDigestJSON, "json", false, "Output as JSON")
+	refineryCmd.AddCommand(refineryDigestCmd)
+}
+
+func runRefineryDigest(cmd *cobra.Command, args []string) error {
+	rigName := ""
+	if len(args) > 0 {
+		rigName = args[0]
+	}
+	_, r, _, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+	eng := refinery.NewEngineer(r)
+	if err := eng.LoadConfig(); err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	if refineryDigestSend {
+		if refineryDigestDate != "" {
+			return fmt.Errorf("--send always sends today's digest; drop --date")
+		}
+		sent, err := eng.SendDigest(context.Background(), time.Now(), refineryDigestForce)
+		if err != nil {
+			return err
+		}
+		if sent {
+			fmt.Printf("%s Sent today's digest\n", style.Success.Render("✓"))
+		} else {
+			fmt.Printf("%s\n", style.Dim.Render("Digest not due (already sent, before digest_at, or rig paused)"))
+		}
+		return nil
+	}
+
+	day := time.Now()
+	if refineryDigestDate != "" {
+		if day, err = time.ParseInLocation("2006-01-02", refineryDigestDate, time.Local); err != nil {
+			return fmt.Errorf("--date: want YYYY-MM-DD, got %q", refineryDigestDate)
+		}
+	}
+	d, err := eng.Digest(day)
+	if err != nil {
+		return fmt.Errorf("reading merge events: %w", err)
+	}
+	if refineryDigestJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(d)
+	}
+	fmt.Printf("%s %s\n\n", style.Bold.Render("📰"), d.Subject())
+	fmt.Print(d.Text())
+	return nil
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/polecat"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var refineryFetchCmd = &cobra.Command{
+	Use:   "fetch [rig]",
+	Short: "Fetch polecat branches and queued targets from origin in one call",
+	Long: `Fetch what the merge queue needs from origin, once per patrol cycle.
+
+Fetches the polecat/* branches and every target branch a queued MR merges
+into, with explicit refspecs, in a single git fetch. Every MR processed in
+the cycle works from that fetch, so there is no need to fetch per MR, and
+other refs on origin are never downloaded.
+
+Examples:
+  gt refinery fetch
+  gt refinery fetch greenplace`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRefineryFetch,
+}
+
+func init() {
+	refineryCmd.AddCommand(refineryFetchCmd)
+}
+
+func runRefineryFetch(cmd *cobra.Command, args []string) error {
+	rigName := ""
+	if len(args) > 0 {
+		rigName = args[0]
+	}
+	_, r, _, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+
+	eng := refinery.NewEngineer(r)
+	if err := eng.LoadConfig(); err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	targets, err := eng.FetchQueue()
+	if err != nil {
+		return fmt.Errorf("fetching origin: %w", err)
+	}
+	fmt.Printf("%s Fetched %s* and %s\n", style.Success.Render("✓"), polecat.BranchPrefix, strings.Join(targets, ", "))
+	return nil
+}
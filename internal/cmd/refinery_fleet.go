@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// refineryAll selects every rig for start, stop, and status: the current
+// town's rigs, or the machine registry's when run outside a town.
+var refineryAll bool
+
+// fleetRefineryRow is one rig's line in the consolidated --all output.
+type fleetRefineryRow struct {
+	Rig         string         `json:"rig"`
+	State       refinery.State `json:"state,omitempty"`
+	Queued      int            `json:"queued"`
+	LastMergeAt *time.Time     `json:"last_merge_at,omitempty"`
+	RigPaused   string         `json:"rig_paused,omitempty"`
+	Result      string         `json:"result,omitempty"` // start/stop outcome
+	Error       string         `json:"error,omitempty"`
+}
+
+func init() {
+	for _, c := range []*cobra.Command{refineryStartCmd, refineryStopCmd, refineryStatusCmd} {
+		c.Flags().BoolVar(&refineryAll, "all", false, "Operate on the refinery of every registered rig")
+	}
+}
+
+// checkRefineryAll validates --all against positional rig arguments.
+func checkRefineryAll(args []string) error {
+	if refineryAll && len(args) > 0 {
+		return errors.New("--all cannot be combined with a rig name")
+	}
+	if refineryAll && refineryRemote != "" {
+		return errors.New("--all cannot be combined with --remote")
+	}
+	return nil
+}
+
+// runRefineryFleet applies op to every rig's refinery and prints a table.
+// op returns a short outcome ("started", "already running", ...) or an error.
+// Returns an error if any rig failed, after reporting all of them.
+func runRefineryFleet(verb string, op func(*refinery.Manager) (string, error)) error {
+	rigs, err := getAllRigsOrRegistered()
+	if err != nil {
+		return err
+	}
+	if len(rigs) == 0 {
+		fmt.Printf("%s\n", style.Dim.Render("No rigs registered"))
+		return nil
+	}
+
+	rows := make([]fleetRefineryRow, 0, len(rigs))
+	failed := 0
+	for _, r := range rigs {
+		var result string
+		var opErr error
+		if op != nil {
+			result, opErr = op(refinery.NewManager(r))
+		}
+
+		row := fleetRow(r) // After op, so the state reflects it
+		row.Result = result
+		if opErr != nil {
+			row.Error = opErr.Error()
+			failed++
+		}
+		rows = append(rows, row)
+	}
+
+	if refineryStatusJSON && op == nil {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	}
+
+	printFleetTable(rows, op != nil)
+	if failed > 0 {
+		return fmt.Errorf("%s failed for %d of %d rig(s)", verb, failed, len(rows))
+	}
+	return nil
+}
+
+// fleetRow gathers state, queue size, and last merge for one rig.
+func fleetRow(r *rig.Rig) fleetRefineryRow {
+	row := fleetRefineryRow{Rig: r.Name, Queued: mrqueue.New(r.Path).Count()}
+	ref, err := refinery.NewManager(r).Status()
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	row.State = ref.State
+	row.LastMergeAt = ref.LastMergeAt
+	if ref.RigPaused != nil {
+		row.RigPaused = ref.RigPaused.String()
+	}
+	return row
+}
+
+func printFleetTable(rows []fleetRefineryRow, withResult bool) {
+	last := "LAST MERGE"
+	if withResult {
+		last = "RESULT"
+	}
+	fmt.Printf("%-20s %-10s %-7s %s\n", "RIG", "STATE", "QUEUED", last)
+
+	for _, row := range rows {
+		state := string(row.State)
+		switch row.State {
+		case refinery.StateRunning:
+			state = style.Bold.Render(fmt.Sprintf("%-10s", state))
+		default:
+			state = style.Dim.Render(fmt.Sprintf("%-10s", state))
+		}
+
+		detail := "-"
+		switch {
+		case row.Error != "":
+			detail = style.Error.Render("✗ " + row.Error)
+		case withResult:
+			detail = style.Success.Render("✓ " + row.Result)
+		case row.RigPaused != "":
+			detail = style.Warning.Render("rig " + row.RigPaused)
+		case row.LastMergeAt != nil:
+			detail = util.FormatTime(*row.LastMergeAt, refineryTimestamps)
+		}
+		fmt.Printf("%-20s %s %-7d %s\n", row.Rig, state, row.Queued, detail)
+	}
+}
+
+func startRefineryForFleet(mgr *refinery.Manager) (string, error) {
+	if err := mgr.Start(false); err != nil {
+		if errors.Is(err, refinery.ErrAlreadyRunning) {
+			return "already running", nil
+		}
+		return "", err
+	}
+	return "started", nil
+}
+
+func stopRefineryForFleet(mgr *refinery.Manager) (string, error) {
+	if err := mgr.Stop(); err != nil {
+		if errors.Is(err, refinery.ErrNotRunning) {
+			return "not running", nil
+		}
+		return "", err
+	}
+	return "stopped", nil
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var (
+	refineryForecastHistory time.Duration
+	refineryForecastTarget  time.Duration
+	refineryForecastJSON    bool
+)
+
+var refineryForecastCmd = &cobra.Command{
+	Use:   "forecast [rig]",
+	Short: "Project when the merge queue will clear",
+	Long: `Project from the rig's merge history when the queue as it stands will clear.
+
+Each queued MR is expected to take a merge attempt of the rig's mean
+length, plus the failed attempts its worker's failure rate predicts before
+one lands (the rig's rate for workers with few attempts). MRs are expected
+to keep arriving at the rate they did over --history, taking a share of
+each lane's time. Held MRs and MRs waiting for approval are left out.
+
+With [refinery] lanes set, each target branch is a lane and lanes merge
+side by side; otherwise the rig merges one MR at a time.
+
+With --target, also reports how many merges would have to run at once to
+clear the queue within it, while keeping up with arrivals.
+
+Examples:
+  gt refinery forecast
+  gt refinery forecast greenplace --target 2h
+  gt refinery forecast --history 72h --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRefineryForecast,
+}
+
+func init() {
+	refineryForecastCmd.Flags().DurationVar(&refineryForecastHistory, "history", refinery.DefaultForecastHistory, "How much merge history to forecast from")
+	refineryForecastCmd.Flags().DurationVar(&refineryForecastTarget, "target", 0, "Clearing time to size concurrent merges for (e.g. 2h)")
+	refineryForecastCmd.Flags().BoolVar(&refineryForecastJSON, "json", false, "Output as JSON")
+	refineryCmd.AddCommand(refineryForecastCmd)
+}
+
+func runRefineryForecast(cmd *cobra.Command, args []string) error {
+	if refineryForecastHistory <= 0 {
+		return fmt.Errorf("--history must be positive")
+	}
+	if refineryForecastTarget < 0 {
+		return fmt.Errorf("--target must be positive")
+	}
+	rigName := ""
+	if len(args) > 0 {
+		rigName = args[0]
+	}
+	_, r, rigName, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+	eng := refinery.NewEngineer(r)
+	if err := eng.LoadConfig(); err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	f, err := eng.Forecast(refineryForecastHistory, refineryForecastTarget)
+	if err != nil {
+		return err
+	}
+	if refineryForecastJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(f)
+	}
+
+	round := func(d time.Duration) time.Duration { return d.Round(time.Minute) }
+	fmt.Printf("%s Forecast for '%s'\n\n", style.Bold.Render("📈"), rigName)
+	fmt.Printf("  History: %d attempts since %s, %.0f%% failed\n",
+		f.Attempts, f.Since.Local().Format("2006-01-02 15:04"), f.FailureRate*100)
+	fmt.Printf("  Attempts: merged in %s, failed in %s on average\n", f.MeanMerge.Round(time.Second), f.MeanFailure.Round(time.Second))
+	fmt.Printf("  Arrivals: %.1f new MRs a day\n", f.ArrivalsPerHour*24)
+	fmt.Printf("  Queue: %d MRs, ~%s of merge work", f.Queued, round(f.Work))
+	if f.Held > 0 {
+		fmt.Printf(" (%d held, not counted)", f.Held)
+	}
+	fmt.Println()
+	if f.Queued == 0 {
+		fmt.Printf("\n  %s\n", style.Dim.Render("Nothing to clear"))
+		return nil
+	}
+
+	if len(f.Lanes) > 1 {
+		fmt.Printf("\n  %s\n", style.Bold.Render("Lanes:"))
+		for _, l := range f.Lanes {
+			clears := style.Warning.Render("falls behind")
+			if l.Clears {
+				clears = "clears in ~" + round(l.ClearsIn).String()
+			}
+			fmt.Printf("    %-28s %3d MRs  ~%-8s %s\n", l.Target, l.Queued, round(l.Work), clears)
+		}
+	}
+
+	fmt.Println()
+	if f.Clears {
+		fmt.Printf("  %s Clears in ~%s (around %s)\n", style.Success.Render("✓"),
+			round(f.ClearsIn), f.At.Add(f.ClearsIn).Local().Format("Mon 15:04"))
+	} else {
+		fmt.Printf("  %s Does not clear: MRs arrive faster than a lane merges them\n", style.Warning.Render("⚠"))
+	}
+	if f.SlotsNeeded > 0 {
+		fmt.Printf("  To clear within %s: %d merges at once\n", f.Target, f.SlotsNeeded)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var refineryGitHubSyncCmd = &cobra.Command{
+	Use:   "github-sync [rig]",
+	Short: "Finish MRs handed to GitHub's merge queue",
+	Long: `Check on MRs the refinery handed to GitHub's merge queue.
+
+With [refinery.github_queue] in rig.toml, the refinery validates each MR
+as usual and then adds its pull request to GitHub's native merge queue
+instead of pushing the merge itself. Run this once per patrol cycle: MRs
+whose pull requests GitHub merged are finished as merged (issue, events,
+hooks, notifications), and MRs GitHub dropped from its queue come back
+to the refinery as failures. The rest keep waiting.
+
+Examples:
+  gt refinery github-sync
+  gt refinery github-sync greenplace`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRefineryGitHubSync,
+}
+
+func init() {
+	refineryCmd.AddCommand(refineryGitHubSyncCmd)
+}
+
+func runRefineryGitHubSync(cmd *cobra.Command, args []string) error {
+	rigName := ""
+	if len(args) > 0 {
+		rigName = args[0]
+	}
+	_, r, _, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+
+	eng := refinery.NewEngineer(r)
+	if err := eng.LoadConfig(); err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	sync, err := eng.SyncGitHubQueue(context.Background())
+	if err != nil {
+		return fmt.Errorf("syncing with GitHub: %w", err)
+	}
+	fmt.Printf("%s %d merged, %d dropped, %d still in GitHub's merge queue\n",
+		style.Success.Render("✓"), sync.Merged, sync.Dropped, sync.Waiting)
+	return nil
+}
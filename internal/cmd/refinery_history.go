@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+var (
+	refineryHistoryLimit  int
+	refineryHistoryFollow bool
+	refineryHistoryJSON   bool
+)
+
+var refineryHistoryCmd = &cobra.Command{
+	Use:   "history [rig]",
+	Short: "Show recent merge queue events, and follow new ones",
+	Long: `Show the refinery's recent merge queue events, oldest first: merges,
+failures, holds, approvals, deploys. With --follow, keep printing events
+as they happen until interrupted.
+
+With --remote, a read token is enough: stakeholders who only need to watch
+the queue can be given one ('gt refinery token create <name> --scope
+observer') and can see status, queue, history, and live events, but
+change nothing.
+
+Examples:
+  gt refinery history
+  gt refinery history greenplace --limit 50
+  gt refinery history --follow --remote https://rig-host:8080`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRefineryHistory,
+}
+
+func init() {
+	refineryHistoryCmd.Flags().IntVarP(&refineryHistoryLimit, "limit", "n", 20, "Recent events to show (0 = all)")
+	refineryHistoryCmd.Flags().BoolVarP(&refineryHistoryFollow, "follow", "f", false, "Keep printing new events as they happen")
+	refineryHistoryCmd.Flags().BoolVar(&refineryHistoryJSON, "json", false, "Output events as JSON, one per line")
+	refineryCmd.AddCommand(refineryHistoryCmd)
+}
+
+func runRefineryHistory(cmd *cobra.Command, args []string) error {
+	if refineryHistoryLimit < 0 {
+		return fmt.Errorf("--limit must not be negative")
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	client, err := refineryClient()
+	if err != nil {
+		return err
+	}
+	if client != nil {
+		events, err := client.History(refineryHistoryLimit)
+		if err != nil {
+			return err
+		}
+		printRefineryEvents(events)
+		if !refineryHistoryFollow {
+			return nil
+		}
+		return client.WatchMerges(ctx, func(ev mrqueue.Event) {
+			printRefineryEvents([]mrqueue.Event{ev})
+		})
+	}
+
+	rigName := ""
+	if len(args) > 0 {
+		rigName = args[0]
+	}
+	_, r, _, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+	log := mrqueue.NewEventLoggerFromRig(r.Path)
+	offset := log.Size()
+	events, err := log.ReadEvents(refineryHistoryLimit)
+	if err != nil {
+		return fmt.Errorf("reading merge events: %w", err)
+	}
+	printRefineryEvents(events)
+	if !refineryHistoryFollow {
+		return nil
+	}
+
+	ticker := time.NewTicker(refinery.WatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		events, next, err := log.ReadEventsFrom(offset)
+		if err != nil {
+			return fmt.Errorf("reading merge events: %w", err)
+		}
+		offset = next
+		printRefineryEvents(events)
+	}
+}
+
+// printRefineryEvents prints merge queue events one per line, as JSON
+// with --json.
+func printRefineryEvents(events []mrqueue.Event) {
+	for _, ev := range events {
+		if refineryHistoryJSON {
+			data, _ := json.Marshal(ev)
+			fmt.Println(string(data))
+			continue
+		}
+		fmt.Println(formatRefineryEvent(ev))
+	}
+}
+
+// formatRefineryEvent renders ev as one line of a history listing.
+func formatRefineryEvent(ev mrqueue.Event) string {
+	line := fmt.Sprintf("  %s  %-14s %s", util.FormatTime(ev.Timestamp, refineryTimestamps), ev.Type, ev.Branch)
+	if ev.MergeCommit != "" {
+		line += " " + shortCommit(ev.MergeCommit)
+	}
+	if ev.Reason != "" {
+		line += style.Dim.Render(" (" + ev.Reason + ")")
+	}
+	return line
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+var refineryLanesCmd = &cobra.Command{
+	Use:   "lanes [rig]",
+	Short: "Show the queue split by target branch",
+	Long: `Show each target branch's lane: the MR it is merging and how many wait.
+
+With lanes = true under [refinery] in settings/rig.toml, the rig's target
+and every swarm's integration branch are separate lanes. Each lane merges
+one MR at a time, in its own worktree for integration branches, and
+'gt refinery ready' skips lanes that already have a claimed MR, so several
+refinery workers can merge into different lanes at once. One swarm's
+integration merges no longer wait behind another's.
+
+Without lanes the rig merges one MR at a time overall; this still shows
+how the queue divides between targets.
+
+Examples:
+  gt refinery lanes
+  gt refinery lanes greenplace --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRefineryLanes,
+}
+
+var refineryLanesJSON bool
+
+func init() {
+	refineryLanesCmd.Flags().BoolVar(&refineryLanesJSON, "json", false, "Output as JSON")
+	refineryCmd.AddCommand(refineryLanesCmd)
+}
+
+func runRefineryLanes(cmd *cobra.Command, args []string) error {
+	rigName := ""
+	if len(args) > 0 {
+		rigName = args[0]
+	}
+	_, r, rigName, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+	eng := refinery.NewEngineer(r)
+	if err := eng.LoadConfig(); err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	lanes, err := eng.Lanes()
+	if err != nil {
+		return fmt.Errorf("listing lanes: %w", err)
+	}
+
+	if refineryLanesJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(lanes)
+	}
+
+	fmt.Printf("%s Lanes for '%s':\n\n", style.Bold.Render("🛤"), rigName)
+	if len(lanes) == 0 {
+		fmt.Printf("  %s\n", style.Dim.Render("(queue empty)"))
+		return nil
+	}
+	for _, l := range lanes {
+		fmt.Printf("  %s %s\n", style.Bold.Render(l.Target),
+			style.Dim.Render(fmt.Sprintf("(%d queued, %d ready)", l.Queued, l.Ready)))
+		if l.Current == nil {
+			fmt.Printf("     %s\n", style.Dim.Render("idle"))
+			continue
+		}
+		fmt.Printf("     Merging %s %s  %s\n", l.Current.ID, l.Current.Branch,
+			style.Dim.Render(fmt.Sprintf("claimed by %s %s", l.Current.ClaimedBy, util.FormatTime(*l.Current.ClaimedAt, false))))
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var (
+	refineryMaintainForce bool
+	refineryMaintainJSON  bool
+)
+
+var refineryMaintainCmd = &cobra.Command{
+	Use:   "maintain [rig]",
+	Short: "Run the rig's scheduled maintenance jobs that are due",
+	Long: `Run the housekeeping jobs scheduled in refinery.maintenance in
+settings/rig.toml that have come due since they last ran:
+
+  prune_branches   Delete merged (and, with stale_after, stale) branches on origin
+  gc_mirror        git gc the bare mirror conflict checks use
+  compact_history  Archive old merge queue events to .runtime/history
+  rotate_logs      Rotate the rig's log files
+
+Each job runs on its own cron schedule ("0 3 * * *", "@weekly"), first at
+its next scheduled time, so the patrol can run this every cycle. --force
+runs every configured job now.
+
+Examples:
+  gt refinery maintain
+  gt refinery maintain greenplace --force`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRefineryMaintain,
+}
+
+func init() {
+	refineryMaintainCmd.Flags().BoolVar(&refineryMaintainForce, "force", false, "Run every configured job now, due or not")
+	refineryMaintainCmd.Flags().BoolVar(&refineryMaintainJSON, "json", false, "Output as JSON")
+	refineryCmd.AddCommand(refineryMaintainCmd)
+}
+
+func runRefineryMaintain(cmd *cobra.Command, args []string) error {
+	rigName := ""
+	if len(args) > 0 {
+		rigName = args[0]
+	}
+	_, r, _, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+	eng := refinery.NewEngineer(r)
+	if err := eng.LoadConfig(); err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	eng.SetOutput(io.Discard) // Results are printed below
+
+	results, err := eng.Maintain(context.Background(), time.Now(), refineryMaintainForce)
+	if err != nil {
+		return err
+	}
+	if refineryMaintainJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+	if len(results) == 0 {
+		fmt.Printf("%s\n", style.Dim.Render("No maintenance due"))
+		return nil
+	}
+	failed := 0
+	for _, res := range results {
+		if res.Error != "" {
+			failed++
+			fmt.Printf("%s %s: %s\n", style.Error.Render("✗"), res.Job, res.Error)
+			continue
+		}
+		fmt.Printf("%s %s: %s\n", style.Success.Render("✓"), res.Job, res.Detail)
+	}
+	if failed > 0 {
+		return NewSilentExit(1)
+	}
+	return nil
+}
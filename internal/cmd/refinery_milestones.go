@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var (
+	refineryMilestonesCheck bool
+	refineryMilestonesJSON  bool
+)
+
+var refineryMilestonesCmd = &cobra.Command{
+	Use:   "milestones [rig]",
+	Short: "Show or make the tags refinery.milestones calls for",
+	Long: `Show where each [[refinery.milestones]] rule in settings/rig.toml
+stands: its last tag, and the merges counting toward the next.
+
+Rules tag the target with an annotated tag after every N merges, daily
+after a set time if anything merged, or when an epic's swarm lands. The
+refinery checks them after each merge; --check makes any tags now due, so
+the patrol can run it every cycle for daily rules. Rules with notes = true
+mail release notes for the tag's range to refinery.notifications.digest.
+
+Examples:
+  gt refinery milestones
+  gt refinery milestones greenplace --check`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRefineryMilestones,
+}
+
+func init() {
+	refineryMilestonesCmd.Flags().BoolVar(&refineryMilestonesCheck, "check", false, "Make any milestone tags now due")
+	refineryMilestonesCmd.Flags().BoolVar(&refineryMilestonesJSON, "json", false, "Output as JSON")
+	refineryCmd.AddCommand(refineryMilestonesCmd)
+}
+
+func runRefineryMilestones(cmd *cobra.Command, args []string) error {
+	rigName := ""
+	if len(args) > 0 {
+		rigName = args[0]
+	}
+	_, r, rigName, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+	eng := refinery.NewEngineer(r)
+	if err := eng.LoadConfig(); err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	eng.SetOutput(os.Stderr)
+
+	if refineryMilestonesCheck {
+		made, err := eng.CheckMilestones(context.Background(), time.Now())
+		if err != nil {
+			return err
+		}
+		if refineryMilestonesJSON {
+			return printMilestonesJSON(made)
+		}
+		for _, m := range made {
+			fmt.Printf("%s Tagged %s %s\n", style.Success.Render("✓"), m.Tag,
+				style.Dim.Render(fmt.Sprintf("(%s, %d merges)", m.Rule, m.Merges)))
+		}
+		if len(made) == 0 {
+			fmt.Printf("%s\n", style.Dim.Render("No milestones due"))
+		}
+		return nil
+	}
+
+	statuses, err := eng.MilestoneStatuses(time.Now())
+	if err != nil {
+		return err
+	}
+	if refineryMilestonesJSON {
+		return printMilestonesJSON(statuses)
+	}
+	fmt.Printf("%s Milestones for '%s'\n\n", style.Bold.Render("🏁"), rigName)
+	if len(statuses) == 0 {
+		fmt.Printf("  %s\n", style.Dim.Render("(no [[refinery.milestones]] in settings/rig.toml)"))
+		return nil
+	}
+	for _, s := range statuses {
+		last := style.Dim.Render("not tagged yet")
+		if s.LastTag != "" {
+			last = fmt.Sprintf("last %s", s.LastTag)
+		}
+		due := ""
+		if s.Due {
+			due = " " + style.Warning.Render("(due)")
+		}
+		fmt.Printf("  %s: %s\n    %s, %d merges since%s\n", style.Bold.Render(s.Rule), s.When, last, s.Merges, due)
+	}
+	return nil
+}
+
+func printMilestonesJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+// refineryPluginPrefix names the executables exposed as refinery
+// subcommands: gt-refinery-foo on PATH is 'gt refinery foo', as
+// git-foo is 'git foo'.
+const refineryPluginPrefix = "gt-refinery-"
+
+// findRefineryPlugins returns the refinery plugins on path by subcommand
+// name. As with a shell's lookup, the first directory on path wins.
+func findRefineryPlugins(path string) map[string]string {
+	plugins := make(map[string]string)
+	for _, dir := range filepath.SplitList(path) {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name, ok := strings.CutPrefix(entry.Name(), refineryPluginPrefix)
+			if !ok || name == "" || plugins[name] != "" {
+				continue
+			}
+			full := filepath.Join(dir, entry.Name())
+			info, err := os.Stat(full) // Follow symlinks to what would run
+			if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 {
+				continue
+			}
+			plugins[name] = full
+		}
+	}
+	return plugins
+}
+
+// addRefineryPlugins adds a subcommand to parent for each refinery plugin
+// on path. Built-in subcommands, and their aliases, cannot be replaced.
+func addRefineryPlugins(parent *cobra.Command, path string) {
+	plugins := findRefineryPlugins(path)
+	names := make([]string, 0, len(plugins))
+	for name := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	builtin := make(map[string]bool)
+	for _, c := range parent.Commands() {
+		builtin[c.Name()] = true
+		for _, alias := range c.Aliases {
+			builtin[alias] = true
+		}
+	}
+	for _, name := range names {
+		if builtin[name] {
+			continue
+		}
+		exe := plugins[name]
+		parent.AddCommand(&cobra.Command{
+			Use:                name,
+			Short:              "Plugin: " + exe,
+			DisableFlagParsing: true,
+			// The plugin reports its own errors; gt passes on its exit status
+			SilenceErrors: true,
+			SilenceUsage:  true,
+			// The plugin does its own checks; the refinery's beads check
+			// and --remote validation are not for it
+			PersistentPreRunE: func(*cobra.Command, []string) error { return nil },
+			RunE: func(cmd *cobra.Command, args []string) error {
+				err := runRefineryPlugin(exe, args)
+				if _, ok := IsSilentExit(err); err != nil && !ok {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					return NewSilentExit(1)
+				}
+				return err
+			},
+		})
+	}
+}
+
+// runRefineryPlugin runs the plugin at exe with args, after taking out
+// the flags gt handles for every refinery subcommand (--rig, --remote,
+// --token) and passing them, with the rig they resolve to, in the
+// environment:
+//
+//	GT_TOWN_ROOT, GT_RIG, GT_RIG_PATH  the rig, when one resolves
+//	GT_REFINERY_REMOTE                 the --remote refinery API, if any
+//	GT_REFINERY_TOKEN                  its token (--token or inherited)
+//	GT_EXECUTABLE                      this gt, to call back into
+func runRefineryPlugin(exe string, args []string) error {
+	rigName, remote, token, rest, err := splitRefineryPluginFlags(args)
+	if err != nil {
+		return err
+	}
+	if rigName == "" {
+		rigName = os.Getenv("GT_RIG")
+	}
+
+	env := os.Environ()
+	switch {
+	case remote != "":
+		env = append(env, "GT_REFINERY_REMOTE="+remote)
+		if rigName != "" {
+			env = append(env, "GT_RIG="+rigName)
+		}
+	default:
+		if rigName == "" {
+			if townRoot, err := workspace.FindFromCwdOrError(); err == nil {
+				rigName, _ = inferRigFromCwd(townRoot) // Outside a rig, the plugin gets none
+			}
+		}
+		if rigName != "" {
+			townRoot, r, err := getRig(rigName)
+			if err != nil {
+				return err
+			}
+			env = append(env, "GT_TOWN_ROOT="+townRoot, "GT_RIG="+r.Name, "GT_RIG_PATH="+r.Path)
+		}
+	}
+	if token != "" {
+		env = append(env, refinery.TokenEnvVar+"="+token)
+	}
+	if self, err := os.Executable(); err == nil {
+		env = append(env, "GT_EXECUTABLE="+self)
+	}
+
+	c := exec.Command(exe, rest...) //nolint:gosec // G204: the user put the plugin on PATH
+	c.Env = env
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return NewSilentExit(exitErr.ExitCode())
+		}
+		return fmt.Errorf("running %s: %w", exe, err)
+	}
+	return nil
+}
+
+// splitRefineryPluginFlags takes --rig, --remote, and --token (as
+// "--flag value" or "--flag=value") out of a plugin's args, up to a "--",
+// which is kept so the plugin can pass such flags through untouched.
+func splitRefineryPluginFlags(args []string) (rigName, remote, token string, rest []string, err error) {
+	values := map[string]*string{"--rig": &rigName, "--remote": &remote, "--token": &token}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		flag, value, hasValue := strings.Cut(arg, "=")
+		dest, ok := values[flag]
+		if !ok {
+			rest = append(rest, arg)
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return "", "", "", nil, fmt.Errorf("flag needs an argument: %s", flag)
+			}
+			i++
+			value = args[i]
+		}
+		*dest = value
+	}
+	return rigName, remote, token, rest, nil
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestAddRefineryPlugins(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	write := func(dir, name string, mode os.FileMode) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(first, "gt-refinery-report", 0755)
+	write(second, "gt-refinery-report", 0755) // Shadowed by the first on PATH
+	write(second, "gt-refinery-notes.txt", 0644)
+	write(second, "gt-refinery-status", 0755) // Cannot replace a built-in
+	write(second, "gt-refinery-ref", 0755)    // Nor an alias of one
+	write(second, "gt-mayor-report", 0755)
+
+	plugins := findRefineryPlugins(first + string(os.PathListSeparator) + second)
+	if plugins["report"] != filepath.Join(first, "gt-refinery-report") {
+		t.Errorf("report = %q, want the first on PATH", plugins["report"])
+	}
+	if _, ok := plugins["notes.txt"]; ok {
+		t.Error("found a plugin that is not executable")
+	}
+
+	parent := &cobra.Command{Use: "refinery"}
+	parent.AddCommand(&cobra.Command{Use: "status", Aliases: []string{"ref"}})
+	addRefineryPlugins(parent, first+string(os.PathListSeparator)+second)
+	var names []string
+	for _, c := range parent.Commands() {
+		names = append(names, c.Name())
+	}
+	if want := []string{"report", "status"}; !slices.Equal(names, want) {
+		t.Errorf("subcommands = %v, want %v", names, want)
+	}
+}
+
+func TestSplitRefineryPluginFlags(t *testing.T) {
+	rigName, remote, token, rest, err := splitRefineryPluginFlags([]string{
+		"--rig", "greenplace", "-v", "--remote=https://rig-host:8080", "--token", "gtr_x", "report", "--", "--rig", "other",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rigName != "greenplace" || remote != "https://rig-host:8080" || token != "gtr_x" {
+		t.Errorf("got rig %q, remote %q, token %q", rigName, remote, token)
+	}
+	if want := []string{"-v", "report", "--", "--rig", "other"}; !slices.Equal(rest, want) {
+		t.Errorf("rest = %v, want %v", rest, want)
+	}
+	if _, _, _, _, err := splitRefineryPluginFlags([]string{"--rig"}); err == nil {
+		t.Error("accepted --rig without a value")
+	}
+}
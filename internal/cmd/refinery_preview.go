@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var refineryPreviewCmd = &cobra.Command{
+	Use:   "preview [rig]",
+	Short: "Test-merge every pending MR against its target",
+	Long: `Preview the conflicts waiting in the merge queue.
+
+Fetches the queued targets from origin and test-merges every pending MR
+(not held, waiting for approval, or handed off) into its target as it
+stands, in the refinery's bare mirror. With --pairs, each pair of pending
+MRs for the same target is also test-merged against each other: whichever
+lands second will have to merge on top of the other. Nothing is merged,
+pushed, or recorded.
+
+The result is a matrix in queue order. Column T is the MR against its
+target; numbered columns are the MR against the MR at that position:
+
+  ·  merges cleanly
+  ✗  conflicts (files listed below the matrix)
+  ?  could not be tested (branch or target missing)
+
+Blank cells are MRs for different targets, which are not compared.
+
+Examples:
+  gt refinery preview
+  gt refinery preview greenplace --pairs
+  gt refinery preview --pairs --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRefineryPreview,
+}
+
+var (
+	refineryPreviewPairs bool
+	refineryPreviewJSON  bool
+)
+
+func init() {
+	refineryPreviewCmd.Flags().BoolVar(&refineryPreviewPairs, "pairs", false, "Also test-merge pending MRs against each other")
+	refineryPreviewCmd.Flags().BoolVar(&refineryPreviewJSON, "json", false, "Output as JSON")
+	refineryCmd.AddCommand(refineryPreviewCmd)
+}
+
+func runRefineryPreview(cmd *cobra.Command, args []string) error {
+	rigName := ""
+	if len(args) > 0 {
+		rigName = args[0]
+	}
+	_, r, rigName, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+	eng := refinery.NewEngineer(r)
+	if err := eng.LoadConfig(); err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	eng.SetOutput(os.Stderr)
+
+	preview, err := eng.PreviewQueue(refineryPreviewPairs)
+	if err != nil {
+		return fmt.Errorf("previewing queue: %w", err)
+	}
+
+	if refineryPreviewJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(preview)
+	}
+
+	fmt.Printf("%s Queue preview for '%s' %s\n\n", style.Bold.Render("🔍"), rigName,
+		style.Dim.Render(fmt.Sprintf("(%d pending)", len(preview.MRs))))
+	if len(preview.MRs) == 0 {
+		fmt.Printf("  %s\n", style.Dim.Render("(empty)"))
+		return nil
+	}
+	printPreviewMatrix(preview)
+
+	var details []string
+	for _, mr := range preview.MRs {
+		switch {
+		case mr.Error != "":
+			details = append(details, fmt.Sprintf("  %s %s: %s", style.Dim.Render("?"), mr.ID, mr.Error))
+		case len(mr.Files) > 0:
+			details = append(details, fmt.Sprintf("  %s %s × %s: %s", style.Warning.Render("⚠"), mr.ID, mr.Target, strings.Join(mr.Files, ", ")))
+		}
+	}
+	for _, c := range preview.Conflicts {
+		a, b := preview.MRs[c.MRs[0]], preview.MRs[c.MRs[1]]
+		details = append(details, fmt.Sprintf("  %s %s × %s: %s", style.Warning.Render("⚠"), a.ID, b.ID, strings.Join(c.Files, ", ")))
+	}
+	if len(details) > 0 {
+		fmt.Println()
+		fmt.Println(strings.Join(details, "\n"))
+	}
+	return nil
+}
+
+// printPreviewMatrix prints one row per pending MR: its position, the
+// cell against its target, with --pairs a cell against each other MR,
+// then the MR itself.
+func printPreviewMatrix(p *refinery.QueuePreview) {
+	n := len(p.MRs)
+	w := len(strconv.Itoa(n))
+	cell := func(s string) string { return strings.Repeat(" ", w-1) + s }
+
+	header := fmt.Sprintf("  %*s  %s", w+1, "", cell("T"))
+	if p.Pairwise {
+		header += " "
+		for j := range p.MRs {
+			header += " " + fmt.Sprintf("%*d", w, j+1)
+		}
+	}
+	fmt.Println(style.Dim.Render(header))
+
+	for i, mr := range p.MRs {
+		var b strings.Builder
+		fmt.Fprintf(&b, "  %*d.  ", w, i+1)
+		switch {
+		case mr.Error != "":
+			b.WriteString(cell(style.Dim.Render("?")))
+		case len(mr.Files) > 0:
+			b.WriteString(cell(style.Error.Render("✗")))
+		default:
+			b.WriteString(cell("·"))
+		}
+		if p.Pairwise {
+			b.WriteString(" ")
+			for j, other := range p.MRs {
+				b.WriteString(" ")
+				switch {
+				case i == j:
+					b.WriteString(cell("-"))
+				case other.Target != mr.Target:
+					b.WriteString(cell(" "))
+				case mr.Error != "" || other.Error != "":
+					b.WriteString(cell(style.Dim.Render("?")))
+				case p.Conflict(i, j) != nil:
+					b.WriteString(cell(style.Error.Render("✗")))
+				default:
+					b.WriteString(cell("·"))
+				}
+			}
+		}
+		fmt.Fprintf(&b, "  %s %s → %s", mr.ID, mr.Branch, mr.Target)
+		fmt.Println(b.String())
+	}
+}
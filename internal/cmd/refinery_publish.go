@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+var (
+	refineryInspectRemote  string
+	refineryInspectHistory int
+	refineryInspectJSON    bool
+)
+
+var refineryPublishCmd = &cobra.Command{
+	Use:   "publish [rig]",
+	Short: "Publish the refinery's state for teammates now",
+	Long: `Publish a snapshot of the refinery's status, queue, and recent history
+to refinery.state_sync in settings/rig.toml.
+
+The refinery publishes after every MR it merges or fails; run this after
+pausing, holding, or requeueing by hand so teammates see it sooner.
+
+Examples:
+  gt refinery publish
+  gt refinery publish greenplace`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRefineryPublish,
+}
+
+var refineryInspectCmd = &cobra.Command{
+	Use:   "inspect <location>",
+	Short: "Show a refinery's published state",
+	Long: `Show the status, queue, and recent history a refinery published with
+refinery.state_sync, from any machine: no access to the rig's host needed.
+
+location is the state_sync url (s3:// or gs://, read with the aws or
+gcloud CLI), or its ref, fetched from --git-remote into the repository
+in the current directory.
+
+Examples:
+  gt refinery inspect refs/gastown/state
+  gt refinery inspect s3://team-gastown/greenplace
+  gt refinery inspect gs://team-gastown/greenplace --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRefineryInspect,
+}
+
+func init() {
+	refineryInspectCmd.Flags().StringVar(&refineryInspectRemote, "git-remote", "origin", "Remote to fetch a ref location from")
+	refineryInspectCmd.Flags().IntVar(&refineryInspectHistory, "history", 10, "Recent events to show")
+	refineryInspectCmd.Flags().BoolVar(&refineryInspectJSON, "json", false, "Output the snapshot as JSON")
+	refineryCmd.AddCommand(refineryPublishCmd)
+	refineryCmd.AddCommand(refineryInspectCmd)
+}
+
+func runRefineryPublish(cmd *cobra.Command, args []string) error {
+	rigName := ""
+	if len(args) > 0 {
+		rigName = args[0]
+	}
+	_, r, _, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+
+	eng := refinery.NewEngineer(r)
+	if err := eng.LoadConfig(); err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	where, err := eng.PublishState(context.Background())
+	if err != nil {
+		return fmt.Errorf("publishing state: %w", err)
+	}
+	if where == "" {
+		return fmt.Errorf("no refinery.state_sync in %s/settings/rig.toml", r.Path)
+	}
+	fmt.Printf("%s Published refinery state to %s\n", style.Success.Render("✓"), where)
+	return nil
+}
+
+func runRefineryInspect(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	snap, err := refinery.FetchState(context.Background(), args[0], refineryInspectRemote, cwd)
+	if err != nil {
+		return err
+	}
+	if refineryInspectJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(snap)
+	}
+
+	pending := 0
+	for _, mr := range snap.Queue {
+		if !mr.IsHeld() && !mr.NeedsApproval() {
+			pending++
+		}
+	}
+	if snap.Status != nil {
+		printRefineryStatus(snap.Rig, snap.Status, pending)
+	}
+	fmt.Printf("  %s\n", style.Dim.Render(fmt.Sprintf("Published from %s %s", snap.Host, util.FormatTime(snap.SyncedAt, refineryTimestamps))))
+
+	if len(snap.Queue) > 0 {
+		fmt.Printf("\n  %s\n", style.Bold.Render("Queue:"))
+		for _, mr := range snap.Queue {
+			status := ""
+			switch {
+			case mr.IsHeld():
+				status = style.Warning.Render(" [held: " + mr.HeldReason + "]")
+			case mr.NeedsApproval():
+				status = style.Warning.Render(" [needs approval]")
+			case mr.ClaimedBy != "":
+				status = style.Bold.Render(" [processing]")
+			case mr.BlockedBy != "":
+				status = style.Dim.Render(" [blocked: " + mr.BlockedBy + "]")
+			}
+			fmt.Printf("    %s %s → %s%s\n", mr.ID, mr.Branch, mr.Target, status)
+		}
+	}
+
+	history := snap.History
+	if refineryInspectHistory >= 0 && len(history) > refineryInspectHistory {
+		history = history[len(history)-refineryInspectHistory:]
+	}
+	if len(history) > 0 {
+		fmt.Printf("\n  %s\n", style.Bold.Render("Recent:"))
+		for _, ev := range history {
+			fmt.Println("  " + formatRefineryEvent(ev))
+		}
+	}
+	return nil
+}
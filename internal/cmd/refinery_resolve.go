@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var refineryResolveCmd = &cobra.Command{
+	Use:   "resolve <mr-id>",
+	Short: "Hand an MR's merge conflicts to the rig's resolver agent",
+	Long: `Rebase a conflicting MR onto its target and let the resolver agent
+configured in settings/rig.toml fix each conflicting commit:
+
+  [refinery.resolver]
+  command = "./scripts/resolve-conflicts.sh"
+  timeout = "20m"
+  auto_merge_confidence = 0.8
+
+The agent runs in a scratch worktree stopped mid-rebase. $GT_RESOLVE_CONTEXT
+names a JSON file with the MR, target SHA, and conflicted files; the agent
+edits the files and may write {"confidence": 0.9, "summary": "..."} to
+$GT_RESOLVE_RESULT. The resolved branch is force-pushed.
+
+A resolution rated at or above auto_merge_confidence goes back to the ready
+queue, and the merge re-runs checks on it. Anything else waits for
+'gt refinery approve'. The refinery does this on its own when a merge
+conflicts; if the agent fails, the conflict goes back to the polecat.
+
+Examples:
+  gt refinery resolve mr-1700000000-abcd1234
+  gt refinery resolve mr-1700000000-abcd1234 --rig greenplace`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRefineryResolve,
+}
+
+func init() {
+	refineryResolveCmd.Flags().StringVar(&refineryControlRig, "rig", "", "Rig name (default: infer from current directory)")
+	refineryCmd.AddCommand(refineryResolveCmd)
+}
+
+func runRefineryResolve(cmd *cobra.Command, args []string) error {
+	_, r, _, err := getRefineryManager(refineryControlRig)
+	if err != nil {
+		return err
+	}
+	eng := refinery.NewEngineer(r)
+	if err := eng.LoadConfig(); err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	res, err := eng.ResolveConflicts(cmd.Context(), args[0])
+	if errors.Is(err, refinery.ErrNoResolver) {
+		return fmt.Errorf("%w in %s's settings/rig.toml", err, r.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s Resolved %s at %.8s (confidence %.2f)\n", style.Bold.Render("✓"), args[0], res.SHA, res.Confidence)
+	if s := res.Summary(); s != "" {
+		fmt.Printf("  %s\n", style.Dim.Render(s))
+	}
+	if res.AutoMerge {
+		fmt.Println("  Requeued; checks run again before it merges.")
+	} else {
+		fmt.Printf("  Waiting for approval: gt refinery approve %s\n", args[0])
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+// Refinery serve command flags
+var (
+	refineryServeHTTP string
+	refineryServeAll  bool
+)
+
+var refineryServeCmd = &cobra.Command{
+	Use:   "serve [rig...]",
+	Short: "Serve the refinery API over HTTP",
+	Long: `Serve a JSON API for the rig's refinery so other tools and dashboards
+can inspect and drive the merge queue over HTTP.
+
+Endpoints:
+  GET  /api/status              Refinery state
+  GET  /api/queue               Pending MRs (highest score first)
+  GET  /api/queue/{id}          A single MR
+  GET  /api/history?limit=N     Recent merge queue events
+  GET  /api/stats?days=N        Merge totals and per-day counts
+  GET  /api/access              What the request's token may do
+  GET  /api/openapi.json        OpenAPI document (also: gt refinery openapi)
+  POST /api/queue               Enqueue an MR ({"branch": "...", "target": "..."})
+  POST /api/queue/{id}/hold     Hold an MR ({"reason": "..."})
+  POST /api/queue/{id}/requeue  Clear hold/claim/block and retry the MR
+  POST /api/pause               Pause processing
+  POST /api/resume              Resume processing
+
+Admin (bypass the refinery's gates or discard work):
+  POST   /api/queue/{id}/approve  Approve an MR past its gates ({"by": "..."})
+  DELETE /api/queue/{id}          Drop an MR from the queue ({"reason": "..."})
+
+Streaming (newline-delimited JSON, one record per line):
+  GET  /api/queue/watch         Queue changes (added, updated, removed)
+  GET  /api/merges/watch        Merge lifecycle events as they happen
+
+Server-sent events (text/event-stream, for EventSource clients):
+  GET  /events                  Status changes, queue changes, and merge events
+
+Open the listen address in a browser for the embedded dashboard: the queue,
+the in-flight merge, a live event log, daily stats, and hold/requeue/pause
+controls.
+
+Requests authenticate with 'Authorization: Bearer <token>' using tokens from
+'gt refinery token create'. Mutations always require an operate token and
+admin endpoints an admin token; reads are open until the first token is
+created. A read (observer) token is for stakeholders who only want
+visibility: status, queue, history, and live events from the API, the
+dashboard (shown without its controls), or 'gt refinery status', 'queue',
+and 'history --follow' with --remote, but nothing that changes the queue.
+
+If rig is not specified, infers it from the current directory.
+
+Serving several rigs (more than one rig argument, or --all) namespaces the
+API by rig so one endpoint covers the fleet:
+  GET  /rigs                    Rigs with state and queue size
+  *    /rigs/{name}/...         That rig's API and dashboard
+Each rig keeps its own tokens. Point --remote at /rigs/{name} to operate one.
+Run outside a town, --all serves every rig in the machine registry
+(see 'gt rig registry').
+
+Examples:
+  gt refinery serve --http :8080
+  gt refinery serve greenplace --http 127.0.0.1:9000
+  gt refinery serve --all --http :8080`,
+	RunE: runRefineryServe,
+}
+
+var refineryOpenAPICmd = &cobra.Command{
+	Use:   "openapi",
+	Short: "Print the OpenAPI document for the refinery API",
+	Long: `Print the OpenAPI 3 document describing the API served by
+'gt refinery serve', for generating typed clients.
+
+The same document is served at /api/openapi.json.
+
+Examples:
+  gt refinery openapi > refinery-openapi.json`,
+	Args: cobra.NoArgs,
+	RunE: runRefineryOpenAPI,
+}
+
+func init() {
+	refineryServeCmd.Flags().StringVar(&refineryServeHTTP, "http", "127.0.0.1:8080", "Address to listen on")
+	refineryServeCmd.Flags().BoolVar(&refineryServeAll, "all", false, "Serve every rig in the town (or registry) under /rigs/{name}")
+
+	refineryCmd.AddCommand(refineryServeCmd)
+	refineryCmd.AddCommand(refineryOpenAPICmd)
+}
+
+func runRefineryOpenAPI(cmd *cobra.Command, args []string) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(refinery.OpenAPIDocument())
+}
+
+func runRefineryServe(cmd *cobra.Command, args []string) error {
+	handler, desc, err := refineryServeHandler(args)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{
+		Addr:              refineryServeHTTP,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      60 * time.Second,
+		IdleTimeout:       120 * time.Second,
+	}
+
+	// Shut down cleanly on Ctrl+C / SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Printf("%s Refinery API for %s listening on %s\n", style.Bold.Render("⚙"), desc, refineryServeHTTP)
+	fmt.Printf("   Press Ctrl+C to stop\n")
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("serving refinery API: %w", err)
+	}
+	return nil
+}
+
+// refineryServeHandler builds the API handler for the requested rigs: a
+// single-rig Server, or a Fleet when serving --all or several rigs.
+func refineryServeHandler(args []string) (http.Handler, string, error) {
+	if !refineryServeAll && len(args) <= 1 {
+		rigName := ""
+		if len(args) > 0 {
+			rigName = args[0]
+		}
+		_, r, rigName, err := getRefineryManager(rigName)
+		if err != nil {
+			return nil, "", err
+		}
+		return refinery.NewServer(r), rigName, nil
+	}
+
+	var rigs []*rig.Rig
+	if refineryServeAll {
+		if len(args) > 0 {
+			return nil, "", fmt.Errorf("--all cannot be combined with rig names")
+		}
+		all, err := getAllRigsOrRegistered()
+		if err != nil {
+			return nil, "", err
+		}
+		rigs = all
+	} else {
+		for _, name := range args {
+			_, r, err := getRig(name)
+			if err != nil {
+				return nil, "", err
+			}
+			rigs = append(rigs, r)
+		}
+	}
+	if len(rigs) == 0 {
+		return nil, "", fmt.Errorf("no rigs to serve")
+	}
+
+	fleet := refinery.NewFleet(rigs)
+	return fleet, strings.Join(fleet.Rigs(), ", "), nil
+}
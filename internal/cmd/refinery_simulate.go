@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var (
+	refinerySimulateScenario string
+	refinerySimulateReplay   bool
+	refinerySimulateSince    time.Duration
+	refinerySimulateConfig   string
+	refinerySimulateJSON     bool
+)
+
+var refinerySimulateCmd = &cobra.Command{
+	Use:   "simulate [rig]",
+	Short: "Run a recorded or made-up load through the scheduler in virtual time",
+	Long: `Try [refinery] scheduling settings on a load before a live rig runs them.
+
+The load is a scenario file (--scenario) or the rig's own merge history
+(--replay, the last --since). Each MR's merge takes as long, and ends as,
+the scenario says; the simulation only decides when each starts, using the
+real scheduler against an in-memory repository with a virtual clock. It
+reports how long MRs waited and when the queue drained.
+
+A replay queues each recorded merge attempt when it started, as the event
+log does not say when MRs were submitted. Pipelining is not simulated.
+
+With --config, the same load also runs under the [refinery] section of
+another rig.toml, and the two are compared.
+
+Scenario file (TOML):
+
+  start = 2026-01-05T09:00:00Z
+  [[mrs]]
+  id = "gt-1"
+  worker = "nux"
+  at = "0s"            # Queued this long after start
+  duration = "6m"
+  outcome = "merged"   # merged (default), or a failure: tests, build, conflict
+  lines = 120
+  files = ["internal/auth/token.go"]
+
+Examples:
+  gt refinery simulate --replay --since 168h
+  gt refinery simulate greenplace --replay --config proposed.toml
+  gt refinery simulate --scenario burst.toml --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRefinerySimulate,
+}
+
+func init() {
+	refinerySimulateCmd.Flags().StringVar(&refinerySimulateScenario, "scenario", "", "Scenario file to simulate")
+	refinerySimulateCmd.Flags().BoolVar(&refinerySimulateReplay, "replay", false, "Simulate the rig's recorded merges")
+	refinerySimulateCmd.Flags().DurationVar(&refinerySimulateSince, "since", 7*24*time.Hour, "With --replay, how far back to replay")
+	refinerySimulateCmd.Flags().StringVar(&refinerySimulateConfig, "config", "", "rig.toml whose [refinery] settings to compare against the rig's")
+	refinerySimulateCmd.Flags().BoolVar(&refinerySimulateJSON, "json", false, "Output as JSON")
+	refineryCmd.AddCommand(refinerySimulateCmd)
+}
+
+func runRefinerySimulate(cmd *cobra.Command, args []string) error {
+	if (refinerySimulateScenario == "") == !refinerySimulateReplay {
+		return fmt.Errorf("give one of --scenario or --replay")
+	}
+	rigName := ""
+	if len(args) > 0 {
+		rigName = args[0]
+	}
+	_, r, rigName, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+	eng := refinery.NewEngineer(r)
+	if err := eng.LoadConfig(); err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	var sc *refinery.Scenario
+	if refinerySimulateReplay {
+		if sc, err = eng.Replay(time.Now().Add(-refinerySimulateSince)); err != nil {
+			return fmt.Errorf("reading merge events: %w", err)
+		}
+	} else if sc, err = refinery.LoadScenario(refinerySimulateScenario); err != nil {
+		return err
+	}
+
+	current, err := eng.Simulate(sc)
+	if err != nil {
+		return fmt.Errorf("simulating: %w", err)
+	}
+	results := map[string]*refinery.SimResult{"current": current}
+	if refinerySimulateConfig != "" {
+		rf, err := config.LoadRigFile(refinerySimulateConfig)
+		if err != nil {
+			return err
+		}
+		if results["proposed"], err = refinery.Simulate(sc, rf.Refinery); err != nil {
+			return fmt.Errorf("simulating %s: %w", refinerySimulateConfig, err)
+		}
+	}
+
+	if refinerySimulateJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+
+	fmt.Printf("%s Simulated %d MRs for '%s'\n\n", style.Bold.Render("🧪"), len(sc.MRs), rigName)
+	printSimResult("rig.toml", current)
+	if proposed := results["proposed"]; proposed != nil {
+		fmt.Println()
+		printSimResult(refinerySimulateConfig, proposed)
+	}
+	return nil
+}
+
+func printSimResult(label string, res *refinery.SimResult) {
+	fmt.Printf("  %s\n", style.Bold.Render(label))
+	fmt.Printf("    Merged %d, failed %d, unfinished %d\n", res.Merged, res.Failed, res.Unfinished)
+	fmt.Printf("    Wait: mean %s, max %s\n", res.MeanWait.Round(time.Second), res.MaxWait.Round(time.Second))
+	if res.Merged > 0 {
+		fmt.Printf("    Queued to merged: mean %s\n", res.MeanLead.Round(time.Second))
+	}
+	if res.Unfinished > 0 {
+		fmt.Printf("    Gave up waiting after %s\n", res.End.Sub(res.Start).Round(time.Second))
+	} else {
+		fmt.Printf("    Drained after %s\n", res.End.Sub(res.Start).Round(time.Second))
+	}
+}
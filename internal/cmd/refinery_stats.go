@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+var (
+	refineryStatsDays int
+	refineryStatsJSON bool
+)
+
+var refineryStatsCmd = &cobra.Command{
+	Use:   "stats [rig]",
+	Short: "Show merge totals, per-worker quality, and swarm runs",
+	Long: `Show merge queue outcomes from the event log, with a per-worker breakdown:
+
+  first pass  Share of the worker's MRs that merged on the first attempt
+  conflicts   Share of the worker's merge attempts that hit conflicts
+  checks      Failed attempts by check (or "tests"/"build" without named checks)
+
+Use the per-worker numbers to spot agents that need better prompts, tighter
+trust settings, or retiring. Only the last --days days are counted per worker.
+
+Swarm runs are listed with their member MRs, failure rate, merges per hour,
+and elapsed time from the first merge attempt to landing (or to the latest
+merge while the swarm is still active).
+
+Examples:
+  gt refinery stats
+  gt refinery stats greenplace --days 30
+  gt refinery stats --remote https://rig-host:8080 --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRefineryStats,
+}
+
+func init() {
+	refineryStatsCmd.Flags().IntVar(&refineryStatsDays, "days", refinery.DefaultStatsDays, "Days to include")
+	refineryStatsCmd.Flags().BoolVar(&refineryStatsJSON, "json", false, "Output as JSON")
+	refineryCmd.AddCommand(refineryStatsCmd)
+}
+
+func runRefineryStats(cmd *cobra.Command, args []string) error {
+	if refineryStatsDays <= 0 {
+		return fmt.Errorf("--days must be positive")
+	}
+
+	var stats *refinery.Stats
+	name := refineryRemote
+	client, err := refineryClient()
+	if err != nil {
+		return err
+	}
+	if client != nil {
+		if stats, err = client.Stats(refineryStatsDays); err != nil {
+			return err
+		}
+	} else {
+		rigName := ""
+		if len(args) > 0 {
+			rigName = args[0]
+		}
+		_, r, _, err := getRefineryManager(rigName)
+		if err != nil {
+			return err
+		}
+		s, err := refinery.CachedStats(r.Path, mrqueue.NewEventLoggerFromRig(r.Path), time.Now(), refineryStatsDays)
+		if err != nil {
+			return fmt.Errorf("reading merge events: %w", err)
+		}
+		stats, name = &s, r.Name
+	}
+
+	if refineryStatsJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(stats)
+	}
+
+	fmt.Printf("%s Merge stats for %s\n\n", style.Bold.Render("📊"), name)
+	fmt.Printf("  Merged: %d  Failed: %d  Skipped: %d  Success: %.0f%%\n\n",
+		stats.Merged, stats.Failed, stats.Skipped, stats.SuccessRate*100)
+
+	fmt.Printf("  %s\n", style.Bold.Render(fmt.Sprintf("Workers (last %d days)", refineryStatsDays)))
+	if len(stats.Workers) == 0 {
+		fmt.Printf("  %s\n", style.Dim.Render("(no merge attempts)"))
+	} else {
+		fmt.Printf("  %-16s %4s %7s %10s %9s  %s\n", "WORKER", "MRS", "MERGED", "FIRST PASS", "CONFLICTS", "CHECK FAILURES")
+		for _, w := range stats.Workers {
+			fmt.Printf("  %-16s %4d %7d %9.0f%% %8.0f%%  %s\n",
+				w.Worker, w.MRs, w.Merged, w.FirstPassRate*100, w.ConflictRate*100, formatCheckFailures(w.CheckFailures))
+		}
+	}
+
+	if len(stats.Swarms) == 0 {
+		return nil
+	}
+	fmt.Printf("\n  %s\n", style.Bold.Render("Swarms"))
+	fmt.Printf("  %-16s %4s %7s %8s %9s %10s  %s\n", "SWARM", "MRS", "MERGED", "FAILURES", "MERGES/H", "ELAPSED", "STATE")
+	for _, sw := range stats.Swarms {
+		state := style.Dim.Render("active")
+		switch {
+		case sw.Landed:
+			state = style.Success.Render("landed")
+		case sw.Aborted:
+			state = style.Warning.Render("aborted")
+		}
+		fmt.Printf("  %-16s %4d %7d %7.0f%% %9.1f %10s  %s\n",
+			sw.Swarm, sw.MRs, sw.Merged, sw.FailureRate*100, sw.MergesPerHour,
+			util.HumanizeDuration(time.Duration(sw.ElapsedSeconds)*time.Second), state)
+	}
+	return nil
+}
+
+// formatCheckFailures renders failure counts as "lint:2 tests:1", most
+// frequent first.
+func formatCheckFailures(counts map[string]int) string {
+	if len(counts) == 0 {
+		return style.Dim.Render("-")
+	}
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s:%d", name, counts[name])
+	}
+	return strings.Join(parts, " ")
+}
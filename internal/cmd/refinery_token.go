@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// Refinery token command flags
+var (
+	refineryTokenRig   string
+	refineryTokenScope string
+	refineryTokenJSON  bool
+)
+
+var refineryTokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Manage API tokens for gt refinery serve",
+	Long: `Manage API tokens for the refinery HTTP API.
+
+Tokens have a scope, the role of whoever holds them:
+  read     (viewer)   Status, queue, history, stats, and event streams; for
+                      observers who should see the refinery but never change it
+  operate  (operator) Everything read allows, plus enqueue, hold, requeue, pause, resume
+  admin               Everything operate allows, plus approve and drop, which
+                      bypass the refinery's gates or discard queued work
+
+Mutation requests without an operate token are always rejected, as are
+admin requests without an admin token. Read endpoints are open until the
+first token is created for the rig.
+
+Only a hash of each token is stored (in <rig>/.runtime/api-tokens.json);
+the secret is printed once at creation.`,
+	RunE: requireSubcommand,
+}
+
+var refineryTokenCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create an API token",
+	Long: `Create a named API token and print its secret.
+
+Examples:
+  gt refinery token create dashboard
+  gt refinery token create ci-bot --scope operate --rig greenplace
+  gt refinery token create mayor --scope admin`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRefineryTokenCreate,
+}
+
+var refineryTokenListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List API tokens",
+	Args:  cobra.NoArgs,
+	RunE:  runRefineryTokenList,
+}
+
+var refineryTokenRevokeCmd = &cobra.Command{
+	Use:   "revoke <name>",
+	Short: "Revoke an API token",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runRefineryTokenRevoke,
+}
+
+func init() {
+	refineryTokenCmd.PersistentFlags().StringVar(&refineryTokenRig, "rig", "", "Rig name (default: infer from current directory)")
+	refineryTokenCreateCmd.Flags().StringVar(&refineryTokenScope, "scope", string(refinery.ScopeRead), "Token scope: read, operate, or admin (viewer, observer, and operator are aliases)")
+	refineryTokenListCmd.Flags().BoolVar(&refineryTokenJSON, "json", false, "Output as JSON")
+
+	refineryTokenCmd.AddCommand(refineryTokenCreateCmd)
+	refineryTokenCmd.AddCommand(refineryTokenListCmd)
+	refineryTokenCmd.AddCommand(refineryTokenRevokeCmd)
+	refineryCmd.AddCommand(refineryTokenCmd)
+}
+
+// getTokenStore returns the API token store for --rig (or the cwd rig).
+func getTokenStore() (*refinery.TokenStore, string, error) {
+	_, r, rigName, err := getRefineryManager(refineryTokenRig)
+	if err != nil {
+		return nil, "", err
+	}
+	return refinery.NewTokenStore(r.Path), rigName, nil
+}
+
+func runRefineryTokenCreate(cmd *cobra.Command, args []string) error {
+	scope, err := refinery.ParseTokenScope(refineryTokenScope)
+	if err != nil {
+		return err
+	}
+
+	store, rigName, err := getTokenStore()
+	if err != nil {
+		return err
+	}
+
+	secret, _, err := store.Create(args[0], scope)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s Created %s token %s for %s\n\n", style.Success.Render("✓"), scope, style.Bold.Render(args[0]), rigName)
+	fmt.Printf("  %s\n\n", secret)
+	fmt.Printf("%s\n", style.Dim.Render("Store it now; it cannot be shown again. Send it as 'Authorization: Bearer <token>'."))
+	return nil
+}
+
+func runRefineryTokenList(cmd *cobra.Command, args []string) error {
+	store, rigName, err := getTokenStore()
+	if err != nil {
+		return err
+	}
+
+	tokens, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	if refineryTokenJSON {
+		// Hashes are not useful to callers; emit metadata only.
+		type tokenInfo struct {
+			Name      string              `json:"name"`
+			Scope     refinery.TokenScope `json:"scope"`
+			CreatedAt string              `json:"created_at"`
+		}
+		out := make([]tokenInfo, 0, len(tokens))
+		for _, t := range tokens {
+			out = append(out, tokenInfo{Name: t.Name, Scope: t.Scope, CreatedAt: t.CreatedAt.Format("2006-01-02T15:04:05Z07:00")})
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	fmt.Printf("%s API tokens for '%s':\n\n", style.Bold.Render("🔑"), rigName)
+	if len(tokens) == 0 {
+		fmt.Printf("  %s\n", style.Dim.Render("(none — read endpoints are open, mutations are rejected)"))
+		return nil
+	}
+	for _, t := range tokens {
+		fmt.Printf("  %-20s %-8s %s\n", t.Name, t.Scope, style.Dim.Render("created "+util.FormatTime(t.CreatedAt, refineryTimestamps)))
+	}
+	return nil
+}
+
+func runRefineryTokenRevoke(cmd *cobra.Command, args []string) error {
+	store, rigName, err := getTokenStore()
+	if err != nil {
+		return err
+	}
+
+	if err := store.Revoke(args[0]); err != nil {
+		return err
+	}
+	fmt.Printf("%s Revoked token %s for %s\n", style.Success.Render("✓"), args[0], rigName)
+	return nil
+}
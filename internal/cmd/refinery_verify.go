@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var refineryVerifyCmd = &cobra.Command{
+	Use:   "verify-history [rig]",
+	Short: "Check the merge event log has not been altered",
+	Long: `Verify the refinery's event log (.beads/mq_events.jsonl).
+
+Every event the refinery and 'gt refinery' commands record is hash-chained
+to the one before it, so editing, removing, inserting, or reordering
+entries breaks the chain. With an audit key set, each entry is also
+signed, so the chain cannot be rebuilt without the key:
+
+  gt rig secret set <rig> audit_key
+
+Deleting the newest entries leaves a valid but shorter chain. To catch
+that, record the head sequence number and hash this command prints
+somewhere the rig cannot write, and compare later runs against it.
+
+Events refinery.maintenance compact_history archived to .runtime/history
+are no longer in the log; the chain is checked from where they end, and
+the newest archive must end with the event the log continues from. With
+an audit key, the record of where the log was cut is signed as well.
+
+Exits non-zero if any problem is found.
+
+Examples:
+  gt refinery verify-history
+  gt refinery verify-history greenplace --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRefineryVerify,
+}
+
+var refineryVerifyStateCmd = &cobra.Command{
+	Use:   "verify-state [rig]",
+	Short: "Check refinery state files have not been edited outside gt",
+	Long: `Verify the refinery's state files against the checksums gt records
+beside them (<file>.sha256) each time it writes one:
+
+  .runtime/refinery.json   Refinery state
+  .beads/mq/*.json         Queued MRs
+
+A file that no longer matches was changed by something other than gt.
+Starting the refinery warns about such files, or refuses to start with
+state_integrity = "refuse" under [refinery] in settings/rig.toml.
+
+Once the edits are reviewed, --accept records the files' current contents
+as their checksums. Files written before checksums were kept are not
+judged until gt next writes them.
+
+Exits non-zero if a modified file is found and not accepted.
+
+Examples:
+  gt refinery verify-state
+  gt refinery verify-state greenplace --accept`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRefineryVerifyState,
+}
+
+var (
+	refineryVerifyJSON   bool
+	refineryVerifyAccept bool
+)
+
+func init() {
+	refineryVerifyCmd.Flags().BoolVar(&refineryVerifyJSON, "json", false, "Output as JSON")
+	refineryVerifyStateCmd.Flags().BoolVar(&refineryVerifyJSON, "json", false, "Output as JSON")
+	refineryVerifyStateCmd.Flags().BoolVar(&refineryVerifyAccept, "accept", false, "Accept the modified files' current contents")
+	refineryCmd.AddCommand(refineryVerifyCmd)
+	refineryCmd.AddCommand(refineryVerifyStateCmd)
+}
+
+func runRefineryVerify(cmd *cobra.Command, args []string) error {
+	rigName := ""
+	if len(args) > 0 {
+		rigName = args[0]
+	}
+	_, r, rigName, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+
+	report, err := mrqueue.NewEventLoggerFromRig(r.Path).Verify(mrqueue.RigAuditKey(r.Path))
+	if err != nil {
+		return err
+	}
+
+	if refineryVerifyJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		printChainReport(rigName, report)
+	}
+	if !report.OK() {
+		return NewSilentExit(1)
+	}
+	return nil
+}
+
+func printChainReport(rigName string, report *mrqueue.ChainReport) {
+	fmt.Printf("%s Event history for '%s':\n\n", style.Bold.Render("🔏"), rigName)
+	fmt.Printf("  Events:  %d", report.Events)
+	if report.Legacy > 0 {
+		fmt.Printf(" %s", style.Dim.Render(fmt.Sprintf("(%d from before chaining, not covered)", report.Legacy)))
+	}
+	fmt.Println()
+	if report.HeadHash != "" {
+		fmt.Printf("  Head:    seq %d %s\n", report.HeadSeq, report.HeadHash)
+	}
+	if report.Compacted > 0 {
+		fmt.Printf("  Starts:  after seq %d %s\n", report.Compacted, style.Dim.Render("(earlier events archived in .runtime/history)"))
+	}
+	switch {
+	case report.Signed == 0:
+		fmt.Printf("  Signed:  %s\n", style.Dim.Render("no (set the rig's audit_key secret to sign new entries)"))
+	case !report.Verified:
+		fmt.Printf("  Signed:  %d %s\n", report.Signed, style.Warning.Render("(not checked: this machine cannot read audit_key)"))
+	default:
+		fmt.Printf("  Signed:  %d, all verified\n", report.Signed)
+	}
+	fmt.Println()
+
+	if report.OK() {
+		fmt.Printf("  %s Chain intact\n", style.Bold.Render("✓"))
+		return
+	}
+	fmt.Printf("  %s %d problem(s):\n", style.Error.Render("✗"), len(report.Problems))
+	for _, p := range report.Problems {
+		seq := ""
+		if p.Seq != 0 {
+			seq = fmt.Sprintf(" (seq %d)", p.Seq)
+		}
+		where := fmt.Sprintf("line %d", p.Line)
+		if p.Line == 0 {
+			where = "anchor"
+		}
+		fmt.Printf("     %s%s: %s\n", where, seq, p.Reason)
+	}
+}
+
+func runRefineryVerifyState(cmd *cobra.Command, args []string) error {
+	rigName := ""
+	if len(args) > 0 {
+		rigName = args[0]
+	}
+	mgr, _, rigName, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+
+	var modified []string
+	if refineryVerifyAccept {
+		modified, err = mgr.AcceptState()
+	} else {
+		modified, err = mgr.ModifiedState()
+	}
+	if err != nil {
+		return err
+	}
+
+	if refineryVerifyJSON {
+		if modified == nil {
+			modified = []string{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(map[string]interface{}{"modified": modified, "accepted": refineryVerifyAccept}); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("%s Refinery state for '%s':\n\n", style.Bold.Render("🔏"), rigName)
+		switch {
+		case len(modified) == 0:
+			fmt.Printf("  %s No files modified outside gt\n", style.Bold.Render("✓"))
+		case refineryVerifyAccept:
+			fmt.Printf("  %s Accepted %d modified file(s):\n", style.Success.Render("✓"), len(modified))
+		default:
+			fmt.Printf("  %s %d file(s) modified outside gt:\n", style.Error.Render("✗"), len(modified))
+		}
+		for _, f := range modified {
+			fmt.Printf("     %s\n", f)
+		}
+	}
+	if len(modified) > 0 && !refineryVerifyAccept {
+		return NewSilentExit(1)
+	}
+	return nil
+}
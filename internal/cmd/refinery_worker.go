@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/refinery"
+)
+
+// Worker scope flags, shared by queue, hold, and requeue.
+var (
+	refineryMine   bool
+	refineryWorker string
+)
+
+// Issue filter flags, shared by queue, hold, and requeue.
+var (
+	refineryEpic   string
+	refineryLabels []string
+)
+
+// sameWorker reports whether two worker names refer to the same worker. MR
+// worker fields may be a bare name or an address ("greenplace/polecats/nux").
+func sameWorker(a, b string) bool {
+	return a != "" && b != "" && path.Base(a) == path.Base(b)
+}
+
+// currentWorker returns the polecat or crew member this session runs as.
+func currentWorker() string {
+	if w := os.Getenv("GT_POLECAT"); w != "" {
+		return w
+	}
+	return os.Getenv("GT_CREW")
+}
+
+// refineryWorkerScope returns the worker --mine or --worker limits a command
+// to, or "" for every worker. With enforce, a polecat is always limited to
+// its own MRs, so it cannot hold or requeue another worker's work.
+func refineryWorkerScope(enforce bool) (string, error) {
+	polecat := os.Getenv("GT_POLECAT")
+	switch {
+	case refineryMine && refineryWorker != "":
+		return "", fmt.Errorf("use --mine or --worker, not both")
+	case refineryMine:
+		w := currentWorker()
+		if w == "" {
+			return "", fmt.Errorf("--mine needs a polecat or crew session (GT_POLECAT or GT_CREW); use --worker <name>")
+		}
+		return w, nil
+	case refineryWorker != "":
+		if enforce && polecat != "" && !sameWorker(refineryWorker, polecat) {
+			return "", fmt.Errorf("polecats can only manage their own MRs (%s)", polecat)
+		}
+		return refineryWorker, nil
+	case enforce && polecat != "":
+		return polecat, nil
+	}
+	return "", nil
+}
+
+// queuedMRs lists the scored queue, via --remote or from the rig on disk.
+func queuedMRs() ([]*mrqueue.MR, error) {
+	client, err := refineryClient()
+	if err != nil {
+		return nil, err
+	}
+	if client != nil {
+		return client.Queue()
+	}
+	_, r, _, err := getRefineryManager(refineryControlRig)
+	if err != nil {
+		return nil, err
+	}
+	return mrqueue.New(r.Path).List()
+}
+
+// refineryIssueFilter returns the --epic/--label filter for the rig, or
+// nil when neither is set. It reads the rig's beads, so it does not work
+// with --remote.
+func refineryIssueFilter(rigName string) (*refinery.IssueFilter, error) {
+	if refineryEpic == "" && len(refineryLabels) == 0 {
+		return nil, nil
+	}
+	if refineryRemote != "" {
+		return nil, fmt.Errorf("--epic and --label need the rig on local disk, not --remote")
+	}
+	mgr, _, _, err := getRefineryManager(rigName)
+	if err != nil {
+		return nil, err
+	}
+	return mgr.IssueFilter(refineryEpic, refineryLabels), nil
+}
+
+// describeScope names the MRs a worker scope and issue filter select, for
+// error messages.
+func describeScope(worker string, filter *refinery.IssueFilter) string {
+	var parts []string
+	if worker != "" {
+		parts = append(parts, "from "+worker)
+	}
+	if !filter.IsEmpty() {
+		if filter.Epic != "" {
+			parts = append(parts, "under "+filter.Epic)
+		}
+		if len(filter.Labels) > 0 {
+			parts = append(parts, "labelled "+strings.Join(filter.Labels, ", "))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// scopedMRIDs resolves which MRs hold or requeue act on: the MR named in
+// args, which must belong to the scoped worker if there is one, or every
+// queued MR of the scoped worker whose source issue passes --epic and
+// --label.
+func scopedMRIDs(args []string) ([]string, error) {
+	worker, err := refineryWorkerScope(true)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := refineryIssueFilter(refineryControlRig)
+	if err != nil {
+		return nil, err
+	}
+	if worker == "" && filter.IsEmpty() {
+		if len(args) == 0 {
+			return nil, fmt.Errorf("give an MR ID, --mine, --worker <name>, --epic, or --label")
+		}
+		return args, nil
+	}
+	if len(args) > 0 && !filter.IsEmpty() {
+		return nil, fmt.Errorf("give an MR ID or --epic/--label, not both")
+	}
+
+	queue, err := queuedMRs()
+	if err != nil {
+		return nil, err
+	}
+	if len(args) > 0 {
+		for _, mr := range queue {
+			if mr.ID != args[0] {
+				continue
+			}
+			if !sameWorker(mr.Worker, worker) {
+				return nil, fmt.Errorf("%s was submitted by %s, not %s", mr.ID, mr.Worker, worker)
+			}
+			return args, nil
+		}
+		return nil, fmt.Errorf("%w: %s", mrqueue.ErrNotFound, args[0])
+	}
+
+	issueIDs := make([]string, 0, len(queue))
+	for _, mr := range queue {
+		if worker == "" || sameWorker(mr.Worker, worker) {
+			issueIDs = append(issueIDs, mr.SourceIssue)
+		}
+	}
+	if err := filter.Prefetch(issueIDs); err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, mr := range queue {
+		if worker != "" && !sameWorker(mr.Worker, worker) {
+			continue
+		}
+		ok, err := filter.Match(mr.SourceIssue, mr.Swarm())
+		if err != nil {
+			return nil, fmt.Errorf("checking %s: %w", mr.ID, err)
+		}
+		if ok {
+			ids = append(ids, mr.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no queued MRs %s", describeScope(worker, filter))
+	}
+	return ids, nil
+}
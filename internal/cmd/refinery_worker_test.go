@@ -0,0 +1,56 @@
+package cmd
+
+import "testing"
+
+func TestSameWorker(t *testing.T) {
+	if !sameWorker("greenplace/polecats/nux", "nux") {
+		t.Error("address and bare name should match")
+	}
+	if sameWorker("nux", "toast") || sameWorker("", "") {
+		t.Error("different or empty workers should not match")
+	}
+}
+
+func TestRefineryWorkerScope(t *testing.T) {
+	defer func() { refineryMine, refineryWorker = false, "" }()
+
+	tests := []struct {
+		name     string
+		polecat  string
+		crew     string
+		mine     bool
+		worker   string
+		enforce  bool
+		want     string
+		wantFail bool
+	}{
+		{name: "no scope"},
+		{name: "worker flag", worker: "nux", want: "nux"},
+		{name: "mine as polecat", polecat: "nux", mine: true, want: "nux"},
+		{name: "mine as crew", crew: "max", mine: true, want: "max"},
+		{name: "mine outside a session", mine: true, wantFail: true},
+		{name: "both flags", mine: true, worker: "nux", polecat: "nux", wantFail: true},
+		{name: "polecat listing others", polecat: "nux", worker: "toast", want: "toast"},
+		{name: "polecat acting on others", polecat: "nux", worker: "toast", enforce: true, wantFail: true},
+		{name: "polecat forced to self", polecat: "nux", enforce: true, want: "nux"},
+		{name: "crew not forced", crew: "max", enforce: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GT_POLECAT", tt.polecat)
+			t.Setenv("GT_CREW", tt.crew)
+			refineryMine, refineryWorker = tt.mine, tt.worker
+
+			got, err := refineryWorkerScope(tt.enforce)
+			if tt.wantFail {
+				if err == nil {
+					t.Errorf("refineryWorkerScope = %q, want error", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("refineryWorkerScope = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -60,9 +60,16 @@ The command also:
   - Creates ~/gt/plugins/ (town-level) if it doesn't exist
   - Creates <rig>/plugins/ (rig-level)
 
+Use --template to start settings/rig.toml from a refinery preset with
+checks, branch patterns, and merge strategy for the project type:
+  go-service   go build, go vet, go test -race; squash merges
+  node-app     npm ci, lint, npm test; squash merges
+  monorepo     make build, make test; merge commits, auto-rebase
+
 Example:
   gt rig add gastown https://github.com/steveyegge/gastown
-  gt rig add my-project git@github.com:user/repo.git --prefix mp`,
+  gt rig add my-project git@github.com:user/repo.git --prefix mp
+  gt rig add api git@github.com:user/api.git --template go-service`,
 	Args: cobra.ExactArgs(2),
 	RunE: runRigAdd,
 }
@@ -75,9 +82,31 @@ var rigListCmd = &cobra.Command{
 
 var rigRemoveCmd = &cobra.Command{
 	Use:   "remove <name>",
-	Short: "Remove a rig from the registry (does not delete files)",
-	Args:  cobra.ExactArgs(1),
-	RunE:  runRigRemove,
+	Short: "Remove a rig from the registry (--delete also deletes its files)",
+	Long: `Remove a rig from the town registry.
+
+The rig's refinery is drained first: it is paused so it takes no new MRs,
+any in-flight merge is allowed to finish (up to --drain-timeout), and then
+it is stopped. Files are left in place.
+
+With --delete, the rig is also torn down and deleted:
+  1. Refinery state, merge history, and queue are archived to
+     mayor/archive/<rig>-<timestamp>/
+  2. Git worktrees inside the rig are removed through their owning repos
+  3. Agent locks are released (live locks abort unless --ignore-locks)
+  4. The rig directory is deleted
+
+--delete lists what it will remove and asks first; --yes skips the prompt.
+--force skips draining the refinery. --ignore-locks deletes the rig even
+while agents hold live locks in it, which can pull it out from under a
+process still working there.
+
+Examples:
+  gt rig remove greenplace
+  gt rig remove greenplace --delete
+  gt rig remove greenplace --delete --yes --force`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRigRemove,
 }
 
 var rigResetCmd = &cobra.Command{
@@ -256,6 +285,7 @@ var (
 	rigAddPrefix       string
 	rigAddLocalRepo    string
 	rigAddBranch       string
+	rigAddTemplate     string
 	rigResetHandoff    bool
 	rigResetMail       bool
 	rigResetStale      bool
@@ -276,6 +306,11 @@ func init() {
 	rigCmd.AddCommand(rigListCmd)
 	rigCmd.AddCommand(rigRebootCmd)
 	rigCmd.AddCommand(rigRemoveCmd)
+	rigRemoveCmd.Flags().BoolVar(&rigRemoveDelete, "delete", false, "Archive history, remove worktrees and locks, and delete the rig directory")
+	rigRemoveCmd.Flags().BoolVarP(&rigRemoveForce, "force", "f", false, "Skip draining the refinery")
+	rigRemoveCmd.Flags().BoolVar(&rigRemoveIgnoreLocks, "ignore-locks", false, "With --delete, delete even while agents hold live locks")
+	rigRemoveCmd.Flags().BoolVarP(&rigRemoveYes, "yes", "y", false, "Skip confirmation prompt")
+	rigRemoveCmd.Flags().DurationVar(&rigRemoveDrainTimeout, "drain-timeout", 5*time.Minute, "How long to wait for an in-flight merge")
 	rigCmd.AddCommand(rigResetCmd)
 	rigCmd.AddCommand(rigRestartCmd)
 	rigCmd.AddCommand(rigShutdownCmd)
@@ -286,6 +321,7 @@ func init() {
 	rigAddCmd.Flags().StringVar(&rigAddPrefix, "prefix", "", "Beads issue prefix (default: derived from name)")
 	rigAddCmd.Flags().StringVar(&rigAddLocalRepo, "local-repo", "", "Local repo path to share git objects (optional)")
 	rigAddCmd.Flags().StringVar(&rigAddBranch, "branch", "", "Default branch name (default: auto-detected from remote)")
+	rigAddCmd.Flags().StringVar(&rigAddTemplate, "template", "", "Refinery preset for settings/rig.toml ("+strings.Join(config.RigTemplateNames(), ", ")+")")
 
 	rigResetCmd.Flags().BoolVar(&rigResetHandoff, "handoff", false, "Clear handoff content")
 	rigResetCmd.Flags().BoolVar(&rigResetMail, "mail", false, "Clear stale mail messages")
@@ -350,6 +386,7 @@ func runRigAdd(cmd *cobra.Command, args []string) error {
 		BeadsPrefix:   rigAddPrefix,
 		LocalRepo:     rigAddLocalRepo,
 		DefaultBranch: rigAddBranch,
+		Template:      rigAddTemplate,
 	})
 	if err != nil {
 		return fmt.Errorf("adding rig: %w", err)
@@ -360,6 +397,13 @@ func runRigAdd(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("saving rigs config: %w", err)
 	}
 
+	// Record the rig in the machine registry so it resolves from anywhere
+	if err := updateRigRegistry(func(reg *config.RigRegistry) bool {
+		return reg.Register(registryEntryFor(townRoot, newRig))
+	}); err != nil {
+		fmt.Printf("  %s Could not update rig registry: %v\n", style.Warning.Render("!"), err)
+	}
+
 	// Add route to town-level routes.jsonl for prefix-based routing.
 	// Route points to the canonical beads location:
 	// - If source repo has .beads/ tracked in git, route to mayor/rig
@@ -510,6 +554,35 @@ func runRigRemove(cmd *cobra.Command, args []string) error {
 	g := git.NewGit(townRoot)
 	mgr := rig.NewManager(townRoot, rigsConfig, g)
 
+	// Tear down before unregistering, so nothing is left running against
+	// (or pointing into) a rig the town no longer knows about
+	if r, err := mgr.GetRig(name); err == nil {
+		var allRigs []*rig.Rig
+		if rigRemoveDelete {
+			allRigs, _ = mgr.DiscoverRigs()
+			live, _, err := liveRigLocks(r)
+			if err != nil {
+				return err
+			}
+			if len(live) > 0 && !rigRemoveIgnoreLocks {
+				return liveLocksError(r, live)
+			}
+			if !confirmDestructive(fmt.Sprintf("Deleting rig %s will:", name), rigDeletePlan(townRoot, r, allRigs, live), rigRemoveYes) {
+				return nil
+			}
+		}
+
+		fmt.Printf("Removing rig %s...\n", style.Bold.Render(name))
+		if err := stopRigRefinery(r, rigRemoveDrainTimeout, rigRemoveForce); err != nil {
+			return err
+		}
+		if rigRemoveDelete {
+			if err := deleteRig(townRoot, r, allRigs, rigRemoveIgnoreLocks); err != nil {
+				return err
+			}
+		}
+	}
+
 	if err := mgr.RemoveRig(name); err != nil {
 		return fmt.Errorf("removing rig: %w", err)
 	}
@@ -519,9 +592,17 @@ func runRigRemove(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("saving rigs config: %w", err)
 	}
 
+	if err := updateRigRegistry(func(reg *config.RigRegistry) bool {
+		return reg.Unregister(filepath.Join(townRoot, name))
+	}); err != nil {
+		fmt.Printf("%s Could not update machine rig registry: %v\n", style.Warning.Render("!"), err)
+	}
+
 	fmt.Printf("%s Rig %s removed from registry\n", style.Success.Render("✓"), name)
-	fmt.Printf("\nNote: Files at %s were NOT deleted.\n", filepath.Join(townRoot, name))
-	fmt.Printf("To delete: %s\n", style.Dim.Render(fmt.Sprintf("rm -rf %s", filepath.Join(townRoot, name))))
+	if !rigRemoveDelete {
+		fmt.Printf("\nNote: Files at %s were NOT deleted.\n", filepath.Join(townRoot, name))
+		fmt.Printf("To delete: %s\n", style.Dim.Render(fmt.Sprintf("gt rig remove %s --delete", name)))
+	}
 
 	return nil
 }
@@ -1057,6 +1138,8 @@ func runRigStatus(cmd *cobra.Command, args []string) error {
 		fmt.Printf("  Status: %s (%s)\n", style.Warning.Render(opState), opSource)
 	} else if opState == "DOCKED" {
 		fmt.Printf("  Status: %s (%s)\n", style.Dim.Render(opState), opSource)
+	} else if opState == "PAUSED" {
+		fmt.Printf("  Status: %s (%s)\n", style.Warning.Render(opState), opSource)
 	}
 
 	fmt.Printf("  Path: %s\n", r.Path)
@@ -1065,6 +1148,9 @@ func runRigStatus(cmd *cobra.Command, args []string) error {
 	}
 	fmt.Println()
 
+	// Health (evaluated periodically by the daemon)
+	printRigHealth(r.Path)
+
 	// Witness status
 	fmt.Printf("%s\n", style.Bold.Render("Witness"))
 	witnessSession := fmt.Sprintf("gt-%s-witness", rigName)
@@ -1500,6 +1586,11 @@ func getRigOperationalState(townRoot, rigName string) (state string, source stri
 		}
 	}
 
+	// Rig-wide pause: agents run, merges don't
+	if p, _ := rig.LoadPause(filepath.Join(townRoot, rigName)); p != nil {
+		return "PAUSED", p.String()
+	}
+
 	// Check rig bead labels (global/synced)
 	// Rig identity bead ID: <prefix>-rig-<name>
 	// Look for status:docked or status:parked labels
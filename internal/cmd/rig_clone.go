@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+// Rig clone flags (prefix, branch, local repo, and template are shared with rig add)
+var (
+	rigCloneName  string
+	rigCloneStart bool
+)
+
+var rigCloneCmd = &cobra.Command{
+	Use:   "clone <git-url>",
+	Short: "Clone a repository as a new rig, ready to merge",
+	Long: `Clone a repository into a new rig in one step.
+
+Does everything 'gt rig add' does, naming the rig after the repository, and
+then imports the project's own refinery configuration: if the repository
+has a gastown.toml at its root, it is validated and copied to the rig's
+settings/rig.toml. The file uses the rig.toml schema ([refinery] checks,
+strategy, paths, env, ...). YAML rig files are not supported.
+
+--template is used only when the repository has no gastown.toml.
+With --start the refinery is started, so the rig goes from nothing to
+processing merge requests in one command.
+
+Examples:
+  gt rig clone https://github.com/user/api.git
+  gt rig clone git@github.com:user/web-app.git --name web --start
+  gt rig clone https://github.com/user/svc.git --template go-service`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRigClone,
+}
+
+func init() {
+	rigCloneCmd.Flags().StringVar(&rigCloneName, "name", "", "Rig name (default: derived from the repository name)")
+	rigCloneCmd.Flags().StringVar(&rigAddPrefix, "prefix", "", "Beads issue prefix (default: derived from name)")
+	rigCloneCmd.Flags().StringVar(&rigAddLocalRepo, "local-repo", "", "Local repo path to share git objects (optional)")
+	rigCloneCmd.Flags().StringVar(&rigAddBranch, "branch", "", "Default branch name (default: auto-detected from remote)")
+	rigCloneCmd.Flags().StringVar(&rigAddTemplate, "template", "", "Refinery preset if the repo has no gastown.toml ("+strings.Join(config.RigTemplateNames(), ", ")+")")
+	rigCloneCmd.Flags().BoolVar(&rigCloneStart, "start", false, "Start the refinery once the rig is ready")
+	rigCmd.AddCommand(rigCloneCmd)
+}
+
+func runRigClone(cmd *cobra.Command, args []string) error {
+	gitURL := args[0]
+
+	name := rigCloneName
+	if name == "" {
+		name = rigNameFromURL(gitURL)
+		if name == "" {
+			return fmt.Errorf("cannot derive a rig name from %s; use --name", gitURL)
+		}
+	}
+
+	// The template is a fallback, applied after the repo has been inspected
+	template := rigAddTemplate
+	if template != "" && !config.IsRigTemplate(template) {
+		return fmt.Errorf("unknown rig template %q (available: %s)", template, strings.Join(config.RigTemplateNames(), ", "))
+	}
+	rigAddTemplate = ""
+
+	if err := runRigAdd(cmd, []string{name, gitURL}); err != nil {
+		return err
+	}
+
+	_, r, err := getRig(name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	src, err := config.ImportRepoRigFile(r.Path, filepath.Join(r.Path, "mayor", "rig"))
+	switch {
+	case errors.Is(err, config.ErrRepoRigFileYAML):
+		fmt.Printf("%s %v\n", style.Warning.Render("!"), err)
+	case err != nil:
+		// The rig is usable; leave settings alone and say why
+		fmt.Printf("%s Not importing %s: %v\n", style.Warning.Render("!"), config.RepoRigFileName, err)
+	case src != "":
+		fmt.Printf("%s Imported %s into settings/%s\n", style.Success.Render("✓"), config.RepoRigFileName, config.RigFileName)
+	}
+	if src == "" && template != "" {
+		if err := config.WriteRigTemplate(r.Path, template); err != nil {
+			return fmt.Errorf("writing rig template: %w", err)
+		}
+		fmt.Printf("%s Wrote settings/%s from template %s\n", style.Success.Render("✓"), config.RigFileName, template)
+	}
+
+	if !rigCloneStart {
+		fmt.Printf("  %s\n", style.Dim.Render(fmt.Sprintf("Start merging with 'gt refinery start %s'", name)))
+		return nil
+	}
+	if err := refinery.NewManager(r).Start(false); err != nil && !errors.Is(err, refinery.ErrAlreadyRunning) {
+		return fmt.Errorf("starting refinery: %w", err)
+	}
+	fmt.Printf("%s Refinery started for %s\n", style.Success.Render("✓"), name)
+	return nil
+}
+
+// rigNameFromURL derives a rig name from a repository URL:
+// "git@github.com:user/web-app.git" becomes "web_app". Characters that
+// rig names reserve for agent IDs are replaced with underscores.
+func rigNameFromURL(gitURL string) string {
+	s := strings.TrimRight(gitURL, "/")
+	s = strings.TrimSuffix(s, ".git")
+	if i := strings.LastIndexAny(s, "/:"); i >= 0 {
+		s = s[i+1:]
+	}
+	return strings.ToLower(strings.NewReplacer("-", "_", ".", "_", " ", "_").Replace(s))
+}
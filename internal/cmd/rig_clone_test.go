@@ -0,0 +1,19 @@
+package cmd
+
+import "testing"
+
+func TestRigNameFromURL(t *testing.T) {
+	tests := map[string]string{
+		"https://github.com/steveyegge/gastown": "gastown",
+		"https://github.com/user/api.git":       "api",
+		"git@github.com:user/web-app.git":       "web_app",
+		"ssh://git@host/group/My.Service.git/":  "my_service",
+		"/srv/git/local-repo":                   "local_repo",
+		"git@github.com:flat.git":               "flat",
+	}
+	for url, want := range tests {
+		if got := rigNameFromURL(url); got != want {
+			t.Errorf("rigNameFromURL(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/doctor"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+// Rig doctor command flags
+var (
+	rigDoctorFix     bool
+	rigDoctorVerbose bool
+)
+
+var rigDoctorCmd = &cobra.Command{
+	Use:   "doctor [rig]",
+	Short: "Validate a rig and suggest fixes",
+	Long: `Run health checks on a single rig.
+
+Operational checks:
+  - required-tools           git, tmux, bd, and the agent CLI are on PATH
+  - git-remote-reachable     origin can be contacted (ls-remote)
+  - target-branches-exist    Default branch, rig.toml target, and queued MR targets exist on origin
+  - rig-writable             .runtime/, settings/, .beads/ are writable (fixable)
+  - refinery-state-valid     .runtime/refinery.json, settings/rig.toml, and queue files parse
+  - orphaned-worktrees       Worktrees whose directories are gone (fixable)
+
+Structural checks are the same as 'gt doctor --rig <rig>'.
+
+Each failing check prints a suggested fix. Use --fix to apply the fixable ones.
+If rig is not specified, infers it from the current directory.
+
+Examples:
+  gt rig doctor greenplace
+  gt rig doctor --fix`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRigDoctor,
+}
+
+func init() {
+	rigDoctorCmd.Flags().BoolVar(&rigDoctorFix, "fix", false, "Attempt to automatically fix issues")
+	rigDoctorCmd.Flags().BoolVarP(&rigDoctorVerbose, "verbose", "v", false, "Show detailed output")
+	rigCmd.AddCommand(rigDoctorCmd)
+}
+
+func runRigDoctor(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+
+	rigName := ""
+	if len(args) > 0 {
+		rigName = args[0]
+	} else if rigName, err = inferRigFromCwd(townRoot); err != nil {
+		return fmt.Errorf("could not determine rig: %w\nUsage: gt rig doctor <rig>", err)
+	}
+	if _, _, err := getRig(rigName); err != nil {
+		return err
+	}
+
+	ctx := &doctor.CheckContext{
+		TownRoot: townRoot,
+		RigName:  rigName,
+		Verbose:  rigDoctorVerbose,
+	}
+
+	d := doctor.NewDoctor()
+	d.RegisterAll(doctor.RigHealthChecks()...)
+	d.RegisterAll(doctor.RigChecks()...)
+
+	var report *doctor.Report
+	if rigDoctorFix {
+		report = d.Fix(ctx)
+	} else {
+		report = d.Run(ctx)
+	}
+
+	report.Print(os.Stdout, rigDoctorVerbose)
+
+	if report.HasErrors() {
+		return fmt.Errorf("rig doctor found %d error(s) in %s", report.Summary.Errors, rigName)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var rigHealthJSON bool
+
+var rigHealthCmd = &cobra.Command{
+	Use:   "health <rig>",
+	Short: "Check the environment a rig depends on",
+	Long: `Evaluate a rig's health now and store the result.
+
+Checks:
+  disk-space        Free space on the rig's filesystem
+  remote-reachable  'git ls-remote' against origin
+  clock-skew        Local clock vs. the HTTPS remote's Date header
+  stale-locks       Agent locks whose process has died
+
+The daemon runs the same checks every 10 minutes and mails the mayor when a
+check starts failing. 'gt rig status' shows the latest stored result.
+
+Examples:
+  gt rig health greenplace
+  gt rig health greenplace --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRigHealth,
+}
+
+func init() {
+	rigHealthCmd.Flags().BoolVar(&rigHealthJSON, "json", false, "Output as JSON")
+	rigCmd.AddCommand(rigHealthCmd)
+}
+
+func runRigHealth(cmd *cobra.Command, args []string) error {
+	_, r, err := getRig(args[0])
+	if err != nil {
+		return err
+	}
+
+	report := rig.NewHealthEvaluator().Evaluate(context.Background(), r.Path)
+	if err := rig.SaveHealth(r.Path, report); err != nil {
+		return fmt.Errorf("saving health report: %w", err)
+	}
+
+	if rigHealthJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+
+	fmt.Printf("%s %s\n\n", style.Bold.Render("Health:"), r.Name)
+	for _, f := range report.Findings {
+		fmt.Printf("  %s %-17s %s\n", healthIcon(f.Status), f.Check, f.Message)
+	}
+	return nil
+}
+
+// printRigHealth prints the Health section of 'gt rig status' from the
+// stored report, without re-running the checks.
+func printRigHealth(rigPath string) {
+	fmt.Printf("%s\n", style.Bold.Render("Health"))
+	report, err := rig.LoadHealth(rigPath)
+	switch {
+	case err != nil:
+		fmt.Printf("  %s unreadable: %v\n", style.Warning.Render("!"), err)
+	case report == nil:
+		fmt.Printf("  %s\n", style.Dim.Render("not yet evaluated (run 'gt rig health')"))
+	default:
+		age := style.Dim.Render(fmt.Sprintf("(checked %s ago)", formatDuration(time.Since(report.CheckedAt))))
+		problems := report.Problems()
+		if len(problems) == 0 {
+			fmt.Printf("  %s healthy %s\n", style.Success.Render("✓"), age)
+		}
+		for i, f := range problems {
+			fmt.Printf("  %s %s: %s", healthIcon(f.Status), f.Check, f.Message)
+			if i == 0 {
+				fmt.Printf(" %s", age)
+			}
+			fmt.Println()
+		}
+	}
+	fmt.Println()
+}
+
+func healthIcon(s rig.HealthStatus) string {
+	switch s {
+	case rig.HealthError:
+		return style.Error.Render("✗")
+	case rig.HealthWarning:
+		return style.Warning.Render("!")
+	}
+	return style.Success.Render("✓")
+}
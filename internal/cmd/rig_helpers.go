@@ -1,24 +1,53 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/constants"
 	"github.com/steveyegge/gastown/internal/git"
 	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
 
 // getRig finds the town root and retrieves the specified rig.
 // This is the common boilerplate extracted from get*Manager functions.
 // Returns the town root path and rig instance.
+//
+// A rig not found in the current town (or when run outside any town) is
+// looked up by name in the machine registry.
 func getRig(rigName string) (string, *rig.Rig, error) {
-	townRoot, err := workspace.FindFromCwdOrError()
+	townRoot, wsErr := workspace.FindFromCwdOrError()
+	if wsErr == nil {
+		if r, err := loadTownRig(townRoot, rigName); err == nil {
+			return townRoot, r, nil
+		}
+	}
+
+	regTown, err := lookupRegisteredRig(rigName, townRoot)
 	if err != nil {
-		return "", nil, fmt.Errorf("not in a Gas Town workspace: %w", err)
+		return "", nil, err
+	}
+	if regTown == "" {
+		if wsErr != nil {
+			return "", nil, fmt.Errorf("not in a Gas Town workspace: %w", wsErr)
+		}
+		return "", nil, fmt.Errorf("rig '%s' not found", rigName)
 	}
 
+	r, err := loadTownRig(regTown, rigName)
+	if err != nil {
+		return "", nil, fmt.Errorf("rig '%s' is registered in %s but could not be loaded: %w", rigName, regTown, err)
+	}
+	return regTown, r, nil
+}
+
+// loadTownRig loads a rig from a town's mayor/rigs.json.
+func loadTownRig(townRoot, rigName string) (*rig.Rig, error) {
 	rigsConfigPath := constants.MayorRigsPath(townRoot)
 	rigsConfig, err := config.LoadRigsConfig(rigsConfigPath)
 	if err != nil {
@@ -27,10 +56,103 @@ func getRig(rigName string) (string, *rig.Rig, error) {
 
 	g := git.NewGit(townRoot)
 	rigMgr := rig.NewManager(townRoot, rigsConfig, g)
-	r, err := rigMgr.GetRig(rigName)
+	return rigMgr.GetRig(rigName)
+}
+
+// lookupRegisteredRig returns the town holding rigName according to the
+// machine registry, ignoring entries in skipTown. Returns "" if the name is
+// not registered, and an error if it is registered in more than one town.
+func lookupRegisteredRig(rigName, skipTown string) (string, error) {
+	reg, err := loadRigRegistry()
 	if err != nil {
-		return "", nil, fmt.Errorf("rig '%s' not found", rigName)
+		return "", nil // An unreadable registry only disables the fallback
+	}
+
+	var towns []string
+	for _, e := range reg.Lookup(rigName) {
+		if e.Town != skipTown {
+			towns = append(towns, e.Town)
+		}
+	}
+	switch len(towns) {
+	case 0:
+		return "", nil
+	case 1:
+		return towns[0], nil
+	default:
+		return "", fmt.Errorf("rig '%s' is registered in several towns (%s); run from inside one of them",
+			rigName, strings.Join(towns, ", "))
+	}
+}
+
+// getAllRigsOrRegistered returns the rigs of the current town, or every rig
+// in the machine registry when run outside a town.
+func getAllRigsOrRegistered() ([]*rig.Rig, error) {
+	rigs, _, err := getAllRigs()
+	if err == nil {
+		return rigs, nil
+	}
+	if _, wsErr := workspace.FindFromCwdOrError(); !errors.Is(wsErr, workspace.ErrNotFound) {
+		return nil, err
 	}
 
-	return townRoot, r, nil
+	reg, regErr := loadRigRegistry()
+	if regErr != nil {
+		return nil, regErr
+	}
+	if len(reg.Rigs) == 0 {
+		return nil, err
+	}
+
+	seen := make(map[string]string)
+	for _, e := range reg.Rigs {
+		if town, dup := seen[e.Name]; dup {
+			fmt.Fprintf(os.Stderr, "%s skipping rig %s in %s: name already used by %s\n",
+				style.Warning.Render("!"), e.Name, e.Town, town)
+			continue
+		}
+		r, loadErr := loadTownRig(e.Town, e.Name)
+		if loadErr != nil {
+			fmt.Fprintf(os.Stderr, "%s skipping registered rig %s: %v\n", style.Warning.Render("!"), e.Path, loadErr)
+			continue
+		}
+		seen[e.Name] = e.Town
+		rigs = append(rigs, r)
+	}
+	return rigs, nil
+}
+
+func loadRigRegistry() (*config.RigRegistry, error) {
+	path, err := config.RegistryPath()
+	if err != nil {
+		return nil, err
+	}
+	return config.LoadRigRegistry(path)
+}
+
+// updateRigRegistry loads the registry, applies fn, and saves it if fn
+// reports a change.
+func updateRigRegistry(fn func(*config.RigRegistry) bool) error {
+	path, err := config.RegistryPath()
+	if err != nil {
+		return err
+	}
+	reg, err := config.LoadRigRegistry(path)
+	if err != nil {
+		return err
+	}
+	if !fn(reg) {
+		return nil
+	}
+	return config.SaveRigRegistry(path, reg)
+}
+
+// registryEntryFor builds the registry entry for a rig in townRoot.
+func registryEntryFor(townRoot string, r *rig.Rig) config.RegistryEntry {
+	return config.RegistryEntry{
+		Name:   r.Name,
+		Town:   townRoot,
+		Path:   r.Path,
+		GitURL: r.GitURL,
+	}
 }
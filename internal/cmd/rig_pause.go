@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var rigPauseReason string
+
+var rigPauseCmd = &cobra.Command{
+	Use:   "pause <rig>",
+	Short: "Suspend merge activity for a rig without stopping agents",
+	Long: `Pause all gastown merge activity for a rig.
+
+While a rig is paused:
+  - The refinery reports no ready MRs, so nothing is merged
+  - Enqueues are refused (CLI, HTTP API, and MERGE_READY from the witness)
+  - Refinery notifications from settings/rig.toml are held back
+
+Agents keep running, unlike 'gt rig park'. The pause and its reason are
+shown by 'gt rig status', 'gt refinery status', and 'gt refinery ready'.
+It persists until 'gt rig resume'.
+
+Examples:
+  gt rig pause greenplace --reason "release freeze"
+  gt rig resume greenplace`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRigPause,
+}
+
+var rigResumeCmd = &cobra.Command{
+	Use:   "resume <rig>",
+	Short: "Resume merge activity after 'gt rig pause'",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runRigResume,
+}
+
+func init() {
+	rigPauseCmd.Flags().StringVarP(&rigPauseReason, "reason", "r", "", "Why the rig is paused (shown in status)")
+	rigCmd.AddCommand(rigPauseCmd)
+	rigCmd.AddCommand(rigResumeCmd)
+}
+
+func runRigPause(cmd *cobra.Command, args []string) error {
+	_, r, err := getRig(args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := rig.Pause(r.Path, rigPauseReason, detectSender()); err != nil {
+		return fmt.Errorf("pausing rig: %w", err)
+	}
+
+	fmt.Printf("%s Rig %s paused\n", style.Success.Render("✓"), r.Name)
+	if rigPauseReason != "" {
+		fmt.Printf("  Reason: %s\n", rigPauseReason)
+	}
+	fmt.Printf("  Resume with: %s\n", style.Dim.Render("gt rig resume "+r.Name))
+	return nil
+}
+
+func runRigResume(cmd *cobra.Command, args []string) error {
+	_, r, err := getRig(args[0])
+	if err != nil {
+		return err
+	}
+
+	resumed, err := rig.Unpause(r.Path)
+	if err != nil {
+		return fmt.Errorf("resuming rig: %w", err)
+	}
+	if !resumed {
+		fmt.Printf("%s Rig %s is not paused\n", style.Dim.Render("○"), r.Name)
+		return nil
+	}
+	fmt.Printf("%s Rig %s resumed\n", style.Success.Render("✓"), r.Name)
+	return nil
+}
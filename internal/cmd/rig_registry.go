@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+// Rig registry command flags
+var (
+	rigRegistryJSON  bool
+	rigRegistrySync  bool
+	rigRegistryPrune bool
+)
+
+var rigRegistryCmd = &cobra.Command{
+	Use:   "registry",
+	Short: "Show the machine-level rig registry",
+	Long: `Show the rigs known on this machine, across all towns.
+
+'gt rig add' records each new rig in the registry and 'gt rig remove' drops
+it. Commands that take a rig name use the registry when the rig is not in
+the current town, so they work from any directory. 'gt refinery serve --all'
+and 'gt refinery status --all' use it to enumerate rigs outside a town.
+
+The registry lives at $GT_REGISTRY, or gastown/registry.json under the user
+config directory (~/.config on Linux).
+
+  --sync    Register every rig of the current town (for rigs added before
+            the registry existed)
+  --prune   Drop entries whose rig directory no longer exists
+
+Examples:
+  gt rig registry
+  gt rig registry --sync
+  gt rig registry --prune --json`,
+	Args: cobra.NoArgs,
+	RunE: runRigRegistry,
+}
+
+func init() {
+	rigRegistryCmd.Flags().BoolVar(&rigRegistryJSON, "json", false, "Output as JSON")
+	rigRegistryCmd.Flags().BoolVar(&rigRegistrySync, "sync", false, "Register every rig of the current town")
+	rigRegistryCmd.Flags().BoolVar(&rigRegistryPrune, "prune", false, "Drop entries whose rig directory is gone")
+	rigCmd.AddCommand(rigRegistryCmd)
+}
+
+func runRigRegistry(cmd *cobra.Command, args []string) error {
+	if rigRegistrySync {
+		rigs, townRoot, err := getAllRigs()
+		if err != nil {
+			return err
+		}
+		added := 0
+		if err := updateRigRegistry(func(reg *config.RigRegistry) bool {
+			for _, r := range rigs {
+				if reg.Register(registryEntryFor(townRoot, r)) {
+					added++
+				}
+			}
+			return added > 0
+		}); err != nil {
+			return fmt.Errorf("syncing registry: %w", err)
+		}
+		if !rigRegistryJSON {
+			fmt.Printf("%s Synced %d rig(s) from %s (%d updated)\n", style.Success.Render("✓"), len(rigs), townRoot, added)
+		}
+	}
+
+	if rigRegistryPrune {
+		var pruned []config.RegistryEntry
+		if err := updateRigRegistry(func(reg *config.RigRegistry) bool {
+			pruned = reg.Prune()
+			return len(pruned) > 0
+		}); err != nil {
+			return fmt.Errorf("pruning registry: %w", err)
+		}
+		if !rigRegistryJSON {
+			for _, e := range pruned {
+				fmt.Printf("%s Pruned %s (%s)\n", style.Success.Render("✓"), e.Name, e.Path)
+			}
+		}
+	}
+
+	reg, err := loadRigRegistry()
+	if err != nil {
+		return err
+	}
+
+	if rigRegistryJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(reg.Rigs)
+	}
+
+	if len(reg.Rigs) == 0 {
+		fmt.Println("No rigs registered.")
+		fmt.Printf("\nRegister this town's rigs with: %s\n", style.Dim.Render("gt rig registry --sync"))
+		return nil
+	}
+
+	if rigRegistrySync || rigRegistryPrune {
+		fmt.Println()
+	}
+	for _, e := range reg.Rigs {
+		marker := ""
+		if _, err := os.Stat(e.Path); os.IsNotExist(err) {
+			marker = " " + style.Warning.Render("(missing)")
+		}
+		fmt.Printf("  %s%s\n", style.Bold.Render(e.Name), marker)
+		fmt.Printf("    Path: %s\n", e.Path)
+		if e.GitURL != "" {
+			fmt.Printf("    Repo: %s\n", style.Dim.Render(e.GitURL))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/style"
+	"golang.org/x/term"
+)
+
+var rigSecretCmd = &cobra.Command{
+	Use:   "secret",
+	Short: "Manage a rig's encrypted secrets",
+	Long: `Manage secrets stored encrypted in a rig's settings/secrets.json.
+
+Values are encrypted with a per-machine key kept in the user config
+directory (gastown/secrets.key, created on first 'set'), or taken from
+$GT_SECRETS_KEY. Refer to a secret by name from settings/rig.toml:
+
+  [refinery.env]
+  GITHUB_TOKEN = "${secret:github_token}"
+
+'set' reads the value from stdin (or prompts without echo), so it never
+appears in shell history or the process list.
+
+Examples:
+  gt rig secret set greenplace github_token
+  echo "$TOKEN" | gt rig secret set greenplace github_token
+  gt rig secret list greenplace
+  gt rig secret get greenplace github_token`,
+	RunE: requireSubcommand,
+}
+
+var rigSecretSetCmd = &cobra.Command{
+	Use:   "set <rig> <name>",
+	Short: "Store a secret (value read from stdin)",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runRigSecretSet,
+}
+
+var rigSecretGetCmd = &cobra.Command{
+	Use:   "get <rig> <name>",
+	Short: "Print a secret's value",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runRigSecretGet,
+}
+
+var rigSecretListCmd = &cobra.Command{
+	Use:   "list <rig>",
+	Short: "List secret names (values are not shown)",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runRigSecretList,
+}
+
+var rigSecretRmCmd = &cobra.Command{
+	Use:   "rm <rig> <name>",
+	Short: "Delete a secret",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runRigSecretRm,
+}
+
+func init() {
+	rigSecretCmd.AddCommand(rigSecretSetCmd)
+	rigSecretCmd.AddCommand(rigSecretGetCmd)
+	rigSecretCmd.AddCommand(rigSecretListCmd)
+	rigSecretCmd.AddCommand(rigSecretRmCmd)
+	rigCmd.AddCommand(rigSecretCmd)
+}
+
+// openRigSecrets resolves the rig and opens its secret store.
+func openRigSecrets(rigName string, create bool) (*config.SecretStore, string, error) {
+	_, r, err := getRig(rigName)
+	if err != nil {
+		return nil, "", err
+	}
+	store, err := config.OpenSecretStore(r.Path, create)
+	if err != nil {
+		return nil, "", fmt.Errorf("opening secrets: %w", err)
+	}
+	return store, r.Name, nil
+}
+
+func runRigSecretSet(cmd *cobra.Command, args []string) error {
+	store, rigName, err := openRigSecrets(args[0], true)
+	if err != nil {
+		return err
+	}
+
+	value, err := readSecretValue(args[1])
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		return fmt.Errorf("empty value; nothing stored")
+	}
+
+	if err := store.Set(args[1], value); err != nil {
+		return err
+	}
+	if err := store.Save(); err != nil {
+		return fmt.Errorf("saving secrets: %w", err)
+	}
+	fmt.Printf("%s Stored secret %s for %s\n", style.Success.Render("✓"), args[1], rigName)
+	fmt.Printf("  %s\n", style.Dim.Render(fmt.Sprintf("Reference it in settings/rig.toml as \"${secret:%s}\"", args[1])))
+	return nil
+}
+
+// readSecretValue prompts without echo on a terminal, or reads the first
+// line of piped stdin.
+func readSecretValue(name string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprintf(os.Stderr, "Value for %s: ", name)
+		b, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("reading value: %w", err)
+		}
+		return string(b), nil
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("reading value: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func runRigSecretGet(cmd *cobra.Command, args []string) error {
+	store, _, err := openRigSecrets(args[0], false)
+	if err != nil {
+		return err
+	}
+	value, err := store.Get(args[1])
+	if err != nil {
+		return err
+	}
+	fmt.Println(value)
+	return nil
+}
+
+func runRigSecretList(cmd *cobra.Command, args []string) error {
+	store, rigName, err := openRigSecrets(args[0], true)
+	if err != nil {
+		return err
+	}
+	names := store.Names()
+	if len(names) == 0 {
+		fmt.Printf("No secrets for %s.\n", rigName)
+		return nil
+	}
+	for _, name := range names {
+		fmt.Println(name)
+	}
+	return nil
+}
+
+func runRigSecretRm(cmd *cobra.Command, args []string) error {
+	store, rigName, err := openRigSecrets(args[0], false)
+	if err != nil {
+		return err
+	}
+	if !store.Delete(args[1]) {
+		return fmt.Errorf("%w: %s", config.ErrSecretNotFound, args[1])
+	}
+	if err := store.Save(); err != nil {
+		return fmt.Errorf("saving secrets: %w", err)
+	}
+	fmt.Printf("%s Deleted secret %s from %s\n", style.Success.Render("✓"), args[1], rigName)
+	return nil
+}
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/lock"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+// Rig remove flags
+var (
+	rigRemoveDelete       bool
+	rigRemoveForce        bool
+	rigRemoveIgnoreLocks  bool
+	rigRemoveYes          bool
+	rigRemoveDrainTimeout time.Duration
+)
+
+// stopRigRefinery drains and stops a rig's refinery before the rig goes
+// away, so no session is left merging into an unregistered rig.
+func stopRigRefinery(r *rig.Rig, timeout time.Duration, force bool) error {
+	mgr := refinery.NewManager(r)
+
+	if !force {
+		fmt.Printf("  Draining refinery (up to %s)...\n", timeout)
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := mgr.Drain(ctx, 2*time.Second)
+		cancel()
+		if errors.Is(err, refinery.ErrDrainTimeout) {
+			return fmt.Errorf("refinery for %s is still merging after %s; retry later or use --force", r.Name, timeout)
+		}
+		if err != nil {
+			return fmt.Errorf("draining refinery: %w", err)
+		}
+	}
+
+	if err := mgr.Stop(); err != nil && !errors.Is(err, refinery.ErrNotRunning) {
+		return fmt.Errorf("stopping refinery: %w", err)
+	}
+	fmt.Printf("   ✓ Refinery stopped\n")
+	return nil
+}
+
+// liveRigLocks returns the agent locks under r that are still held, as
+// "<dir> (PID <pid>)", along with every lock found.
+func liveRigLocks(r *rig.Rig) ([]string, map[string]*lock.LockInfo, error) {
+	locks, err := lock.FindAllLocks(r.Path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("scanning agent locks: %w", err)
+	}
+	var live []string
+	for dir, info := range locks {
+		if !info.IsStale() {
+			live = append(live, fmt.Sprintf("%s (PID %d)", dir, info.PID))
+		}
+	}
+	sort.Strings(live)
+	return live, locks, nil
+}
+
+// liveLocksError is the error for deleting a rig agents still hold locks in.
+func liveLocksError(r *rig.Rig, live []string) error {
+	return fmt.Errorf("agents still hold locks in %s:\n  %s\nStop them first ('gt rig stop %s') or use --ignore-locks",
+		r.Name, strings.Join(live, "\n  "), r.Name)
+}
+
+// rigDeletePlan lists what deleteRig will do to r, for confirmation.
+func rigDeletePlan(townRoot string, r *rig.Rig, allRigs []*rig.Rig, live []string) []string {
+	plan := []string{fmt.Sprintf("archive refinery history to %s", filepath.Join(townRoot, "mayor", "archive", r.Name+"-<timestamp>"))}
+	for _, wt := range rigWorktrees(r.Path, allRigs) {
+		plan = append(plan, "remove worktree "+wt.path)
+	}
+	for _, l := range live {
+		plan = append(plan, "release live agent lock "+l)
+	}
+	return append(plan, fmt.Sprintf("delete %s and everything in it", r.Path))
+}
+
+// deleteRig archives the refinery history, removes worktrees and agent
+// locks, and then deletes the rig directory. Live agent locks abort the
+// delete unless ignoreLocks is set.
+func deleteRig(townRoot string, r *rig.Rig, allRigs []*rig.Rig, ignoreLocks bool) error {
+	live, locks, err := liveRigLocks(r)
+	if err != nil {
+		return err
+	}
+	if len(live) > 0 && !ignoreLocks {
+		return liveLocksError(r, live)
+	}
+
+	archiveDir := filepath.Join(townRoot, "mayor", "archive", fmt.Sprintf("%s-%s", r.Name, time.Now().Format("20060102-150405")))
+	written, err := refinery.NewManager(r).ArchiveHistory(archiveDir)
+	if err != nil {
+		return fmt.Errorf("archiving refinery history: %w", err)
+	}
+	if len(written) > 0 {
+		fmt.Printf("   ✓ Archived refinery history to %s\n", archiveDir)
+	}
+
+	removed := removeRigWorktrees(r.Path, allRigs)
+	fmt.Printf("   ✓ Removed %d worktree(s)\n", removed)
+
+	for dir := range locks {
+		_ = lock.New(dir).ForceRelease()
+	}
+	if len(locks) > 0 {
+		fmt.Printf("   ✓ Released %d agent lock(s)\n", len(locks))
+	}
+
+	if err := os.RemoveAll(r.Path); err != nil {
+		return fmt.Errorf("deleting %s: %w", r.Path, err)
+	}
+	fmt.Printf("   ✓ Deleted %s\n", r.Path)
+	return nil
+}
+
+// rigWorktree is a git worktree under a rig and the repository that owns it.
+type rigWorktree struct {
+	repo, path string
+}
+
+// rigWorktrees returns every git worktree located under rigPath, found
+// through the repository that owns it — the rig's own or another rig's
+// (cross-rig crew worktrees).
+func rigWorktrees(rigPath string, allRigs []*rig.Rig) []rigWorktree {
+	prefix := rigPath + string(filepath.Separator)
+	var found []rigWorktree
+	for _, repo := range rigRepos(allRigs) {
+		worktrees, err := git.NewGit(repo).WorktreeList()
+		if err != nil {
+			continue
+		}
+		for _, wt := range worktrees {
+			if wt.Path != repo && strings.HasPrefix(wt.Path, prefix) {
+				found = append(found, rigWorktree{repo: repo, path: wt.Path})
+			}
+		}
+	}
+	return found
+}
+
+// removeRigWorktrees removes the worktrees rigWorktrees finds through
+// their owning repositories, so no repo keeps a dangling entry. Returns
+// the number removed; failures are reported and skipped.
+func removeRigWorktrees(rigPath string, allRigs []*rig.Rig) int {
+	removed := 0
+	for _, wt := range rigWorktrees(rigPath, allRigs) {
+		if err := git.NewGit(wt.repo).WorktreeRemove(wt.path, true); err != nil {
+			fmt.Printf("  %s Could not remove worktree %s: %v\n", style.Warning.Render("!"), wt.path, err)
+			continue
+		}
+		removed++
+	}
+	for _, repo := range rigRepos(allRigs) {
+		_ = git.NewGit(repo).WorktreePrune()
+	}
+	return removed
+}
+
+// rigRepos returns the repositories worktrees are created from: each rig's
+// shared bare repo and its mayor clone.
+func rigRepos(rigs []*rig.Rig) []string {
+	var repos []string
+	for _, r := range rigs {
+		for _, dir := range []string{filepath.Join(r.Path, ".repo.git"), filepath.Join(r.Path, "mayor", "rig")} {
+			if _, err := os.Stat(dir); err == nil {
+				repos = append(repos, dir)
+			}
+		}
+	}
+	return repos
+}
@@ -3,6 +3,7 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -45,6 +46,9 @@ func checkBeadsDependency(cmd *cobra.Command, args []string) error {
 // Execute runs the root command and returns an exit code.
 // The caller (main) should call os.Exit with this code.
 func Execute() int {
+	// Added last, so no plugin can shadow a built-in subcommand
+	addRefineryPlugins(refineryCmd, os.Getenv("PATH"))
+
 	if err := rootCmd.Execute(); err != nil {
 		// Check for silent exit (scripting commands that signal status via exit code)
 		if code, ok := IsSilentExit(err); ok {
@@ -237,8 +237,8 @@ func runMayorStatusLine(t *tmux.Tmux) error {
 
 		// Check if rig is parked or docked
 		opState, _ := getRigOperationalState(townRoot, rigName)
-		if opState == "PARKED" || opState == "DOCKED" {
-			led = "⏸️" // Parked/docked - intentionally offline
+		if opState == "PARKED" || opState == "DOCKED" || opState == "PAUSED" {
+			led = "⏸️" // Parked/docked/paused - intentionally offline
 		} else if status.hasWitness && status.hasRefinery {
 			led = "🟢" // Both running - fully active
 		} else if status.hasWitness || status.hasRefinery {
@@ -12,6 +12,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/constants"
 	"github.com/steveyegge/gastown/internal/git"
 	"github.com/steveyegge/gastown/internal/polecat"
 	"github.com/steveyegge/gastown/internal/rig"
@@ -261,7 +262,15 @@ func runSwarmCreate(cmd *cobra.Command, args []string) error {
 		baseCommit = strings.TrimSpace(string(out))
 	}
 
-	integration := fmt.Sprintf("swarm/%s", swarmEpic)
+	// Swarm members' MRs are routed to the epic's integration branch (see
+	// gt done and gt mq submit), so create it on origin up front.
+	integration := constants.BranchIntegrationPrefix + swarmEpic
+	g := git.NewGit(r.Path)
+	if exists, _ := g.RemoteBranchExists("origin", integration); !exists && baseCommit != "unknown" {
+		if err := g.Push("origin", baseCommit+":refs/heads/"+integration, false); err != nil {
+			style.PrintWarning("couldn't create integration branch %s: %v", integration, err)
+		}
+	}
 
 	// Output
 	fmt.Printf("%s Created swarm %s\n\n", style.Bold.Render("✓"), swarmEpic)
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var swarmAbortCmd = &cobra.Command{
+	Use:   "abort <swarm-id>",
+	Short: "Abort a swarm and flush its MRs from the merge queue",
+	Long: `Abort a swarm: take its MRs out of the rig's merge queue and cancel it.
+
+Every queued MR into the swarm's integration/<epic> branch is dropped, along
+with the swarm's landing if one is queued, and their merge-request beads are
+closed. With --hold the MRs stay queued but held, so the swarm can be picked
+up again with 'gt refinery requeue'; the epic is left open.
+
+--delete-branch also deletes the integration branch from origin. The abort
+is recorded in the refinery's history as a swarm_aborted event.
+
+The MRs and branch affected are listed first and the abort asks for
+confirmation; --yes skips the prompt.
+
+Examples:
+  gt swarm abort gp-abc
+  gt swarm abort gp-abc --hold --reason "waiting on API redesign"
+  gt swarm abort gp-abc --rig greenplace --delete-branch
+  gt swarm abort gp-abc --yes --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSwarmAbort,
+}
+
+var (
+	swarmAbortRig          string
+	swarmAbortHold         bool
+	swarmAbortDeleteBranch bool
+	swarmAbortReason       string
+	swarmAbortJSON         bool
+	swarmAbortYes          bool
+)
+
+func init() {
+	swarmAbortCmd.Flags().StringVar(&swarmAbortRig, "rig", "", "Rig the swarm runs in (default: find it from the epic)")
+	swarmAbortCmd.Flags().BoolVar(&swarmAbortHold, "hold", false, "Hold the swarm's MRs instead of dropping them")
+	swarmAbortCmd.Flags().BoolVar(&swarmAbortDeleteBranch, "delete-branch", false, "Delete the integration branch from origin")
+	swarmAbortCmd.Flags().StringVar(&swarmAbortReason, "reason", "", "Reason recorded on the MRs and in history")
+	swarmAbortCmd.Flags().BoolVar(&swarmAbortJSON, "json", false, "Output as JSON")
+	swarmAbortCmd.Flags().BoolVarP(&swarmAbortYes, "yes", "y", false, "Skip confirmation prompt")
+	swarmCmd.AddCommand(swarmAbortCmd)
+}
+
+func runSwarmAbort(cmd *cobra.Command, args []string) error {
+	swarmID := args[0]
+	if swarmAbortJSON && !swarmAbortYes {
+		return fmt.Errorf("--json needs --yes, as there is no prompt to answer")
+	}
+
+	r, err := findSwarmRig(swarmID, swarmAbortRig)
+	if err != nil {
+		return err
+	}
+
+	mgr := refinery.NewManager(r)
+	members, err := mgr.SwarmMRs(swarmID)
+	if err != nil {
+		return err
+	}
+	var affected []string
+	for _, mr := range members {
+		if swarmAbortHold {
+			affected = append(affected, fmt.Sprintf("hold %s %s → %s", mr.ID, mr.Branch, mr.Target))
+		} else {
+			affected = append(affected, fmt.Sprintf("drop %s %s → %s and close its merge-request bead", mr.ID, mr.Branch, mr.Target))
+		}
+	}
+	if !swarmAbortHold {
+		affected = append(affected, fmt.Sprintf("close swarm epic %s in beads", swarmID))
+	}
+	if swarmAbortDeleteBranch {
+		affected = append(affected, fmt.Sprintf("delete %s%s from origin", constants.BranchIntegrationPrefix, swarmID))
+	}
+	if !swarmAbortJSON && !confirmDestructive(fmt.Sprintf("Aborting swarm %s will:", swarmID), affected, swarmAbortYes) {
+		return nil
+	}
+
+	res, err := mgr.AbortSwarm(swarmID, refinery.AbortSwarmOptions{
+		Hold:         swarmAbortHold,
+		DeleteBranch: swarmAbortDeleteBranch,
+		Reason:       swarmAbortReason,
+	})
+	if err != nil {
+		return err
+	}
+
+	if !swarmAbortHold {
+		reason := swarmAbortReason
+		if reason == "" {
+			reason = "Swarm aborted"
+		}
+		closeArgs := []string{"close", swarmID, "--reason", reason}
+		if sessionID := os.Getenv("CLAUDE_SESSION_ID"); sessionID != "" {
+			closeArgs = append(closeArgs, "--session="+sessionID)
+		}
+		closeCmd := exec.Command("bd", closeArgs...)
+		closeCmd.Dir = r.BeadsPath()
+		if err := closeCmd.Run(); err != nil {
+			style.PrintWarning("couldn't close swarm epic in beads: %v", err)
+		}
+	}
+
+	if swarmAbortJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+	}
+
+	fmt.Printf("%s Swarm %s aborted\n", style.Bold.Render("✓"), swarmID)
+	if len(res.Dropped) > 0 {
+		fmt.Printf("  Dropped from queue: %d\n", len(res.Dropped))
+	}
+	if len(res.Held) > 0 {
+		fmt.Printf("  Held in queue: %d %s\n", len(res.Held), style.Dim.Render("(gt refinery requeue <mr-id> to resume)"))
+	}
+	if len(res.Dropped)+len(res.Held) == 0 {
+		fmt.Printf("  %s\n", style.Dim.Render("No queued MRs"))
+	}
+	if res.BranchDeleted {
+		fmt.Printf("  Deleted %s\n", res.Branch)
+	}
+	return nil
+}
+
+// findSwarmRig returns the named rig, or else the rig whose beads hold the
+// swarm's epic.
+func findSwarmRig(swarmID, rigName string) (*rig.Rig, error) {
+	if rigName != "" {
+		r, _, err := getSwarmRig(rigName)
+		return r, err
+	}
+	rigs, _, err := getAllRigs()
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rigs {
+		checkCmd := exec.Command("bd", "show", swarmID, "--json")
+		checkCmd.Dir = r.BeadsPath()
+		if err := checkCmd.Run(); err == nil {
+			return r, nil
+		}
+	}
+	return nil, fmt.Errorf("swarm '%s' not found", swarmID)
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronSchedule_Next(t *testing.T) {
+	// Friday
+	from := time.Date(2026, 3, 6, 10, 30, 0, 0, time.Local)
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 3, 6, 10, 31, 0, 0, time.Local)},
+		{"*/15 * * * *", time.Date(2026, 3, 6, 10, 45, 0, 0, time.Local)},
+		{"0 3 * * *", time.Date(2026, 3, 7, 3, 0, 0, 0, time.Local)},
+		{"@daily", time.Date(2026, 3, 7, 0, 0, 0, 0, time.Local)},
+		{"0 4 * * sun", time.Date(2026, 3, 8, 4, 0, 0, 0, time.Local)},
+		{"0 4 * * 7", time.Date(2026, 3, 8, 4, 0, 0, 0, time.Local)},
+		{"0 9-17/4 * * mon-fri", time.Date(2026, 3, 6, 13, 0, 0, 0, time.Local)},
+		{"0 0 1 jan,jul *", time.Date(2026, 7, 1, 0, 0, 0, 0, time.Local)},
+		// Both day fields restricted: either matches
+		{"0 0 15 * mon", time.Date(2026, 3, 9, 0, 0, 0, 0, time.Local)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		c, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+		}
+		if got := c.Next(from); !got.Equal(tt.want) {
+			t.Errorf("%q: Next = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "* * * 13 *", "5-1 * * * *", "*/0 * * * *", "@often", "* * * * funday"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) accepted", expr)
+		}
+	}
+}
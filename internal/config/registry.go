@@ -0,0 +1,149 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// RegistryEnvVar overrides the location of the machine-level rig registry.
+const RegistryEnvVar = "GT_REGISTRY"
+
+// CurrentRegistryVersion is the current schema version for RigRegistry.
+const CurrentRegistryVersion = 1
+
+// RigRegistry lists every rig on this machine across all towns, so rigs can
+// be resolved by name outside a workspace. Each town's mayor/rigs.json stays
+// the source of truth; the registry only points at it.
+type RigRegistry struct {
+	Version int             `json:"version"`
+	Rigs    []RegistryEntry `json:"rigs"`
+}
+
+// RegistryEntry is one rig in the machine registry.
+type RegistryEntry struct {
+	Name    string    `json:"name"`
+	Town    string    `json:"town"` // Town root
+	Path    string    `json:"path"` // Rig directory
+	GitURL  string    `json:"git_url,omitempty"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+// RegistryPath returns the machine registry location: $GT_REGISTRY, or
+// gastown/registry.json under the user config directory.
+func RegistryPath() (string, error) {
+	if p := os.Getenv(RegistryEnvVar); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("finding user config directory: %w", err)
+	}
+	return filepath.Join(dir, "gastown", "registry.json"), nil
+}
+
+// LoadRigRegistry loads the registry at path. A missing file is an empty registry.
+func LoadRigRegistry(path string) (*RigRegistry, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is constructed internally, not from user input
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &RigRegistry{Version: CurrentRegistryVersion}, nil
+		}
+		return nil, fmt.Errorf("reading registry: %w", err)
+	}
+
+	var reg RigRegistry
+	if err := json.Unmarshal(data, &reg); err != nil {
+		return nil, fmt.Errorf("parsing registry: %w", err)
+	}
+	if reg.Version > CurrentRegistryVersion {
+		return nil, fmt.Errorf("%w: registry version %d, expected <= %d", ErrInvalidVersion, reg.Version, CurrentRegistryVersion)
+	}
+	return &reg, nil
+}
+
+// SaveRigRegistry writes the registry to path.
+func SaveRigRegistry(path string, reg *RigRegistry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating directory: %w", err)
+	}
+
+	reg.Version = CurrentRegistryVersion
+	sort.Slice(reg.Rigs, func(i, j int) bool {
+		if reg.Rigs[i].Name != reg.Rigs[j].Name {
+			return reg.Rigs[i].Name < reg.Rigs[j].Name
+		}
+		return reg.Rigs[i].Path < reg.Rigs[j].Path
+	})
+
+	data, err := json.MarshalIndent(reg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding registry: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("writing registry: %w", err)
+	}
+	return nil
+}
+
+// Register adds e, replacing any entry for the same rig path.
+// Returns true if the registry changed.
+func (r *RigRegistry) Register(e RegistryEntry) bool {
+	for i, existing := range r.Rigs {
+		if existing.Path == e.Path {
+			if existing.Name == e.Name && existing.Town == e.Town && existing.GitURL == e.GitURL {
+				return false
+			}
+			if e.AddedAt.IsZero() {
+				e.AddedAt = existing.AddedAt
+			}
+			r.Rigs[i] = e
+			return true
+		}
+	}
+	if e.AddedAt.IsZero() {
+		e.AddedAt = time.Now()
+	}
+	r.Rigs = append(r.Rigs, e)
+	return true
+}
+
+// Unregister removes the entry for a rig path. Returns true if one was removed.
+func (r *RigRegistry) Unregister(path string) bool {
+	for i, e := range r.Rigs {
+		if e.Path == path {
+			r.Rigs = append(r.Rigs[:i], r.Rigs[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Lookup returns the entries for a rig name. Names are only unique within a
+// town, so more than one entry means the name is ambiguous on this machine.
+func (r *RigRegistry) Lookup(name string) []RegistryEntry {
+	var matches []RegistryEntry
+	for _, e := range r.Rigs {
+		if e.Name == name {
+			matches = append(matches, e)
+		}
+	}
+	return matches
+}
+
+// Prune drops entries whose rig directory no longer exists and returns them.
+func (r *RigRegistry) Prune() []RegistryEntry {
+	var kept, pruned []RegistryEntry
+	for _, e := range r.Rigs {
+		if _, err := os.Stat(e.Path); os.IsNotExist(err) {
+			pruned = append(pruned, e)
+			continue
+		}
+		kept = append(kept, e)
+	}
+	r.Rigs = kept
+	return pruned
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRigRegistry_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "gastown", "registry.json")
+
+	reg, err := LoadRigRegistry(path)
+	if err != nil || len(reg.Rigs) != 0 {
+		t.Fatalf("missing registry = %+v, %v; want empty", reg, err)
+	}
+
+	townA, townB := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	reg.Register(RegistryEntry{Name: "greenplace", Town: townA, Path: filepath.Join(townA, "greenplace")})
+	reg.Register(RegistryEntry{Name: "greenplace", Town: townB, Path: filepath.Join(townB, "greenplace")})
+	if reg.Register(RegistryEntry{Name: "greenplace", Town: townA, Path: filepath.Join(townA, "greenplace")}) {
+		t.Error("re-registering the same rig reported a change")
+	}
+	if err := SaveRigRegistry(path, reg); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadRigRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.Lookup("greenplace"); len(got) != 2 {
+		t.Errorf("Lookup = %+v, want both towns", got)
+	}
+	if !loaded.Unregister(filepath.Join(townB, "greenplace")) || len(loaded.Lookup("greenplace")) != 1 {
+		t.Error("Unregister did not remove the entry")
+	}
+}
+
+func TestRigRegistry_Prune(t *testing.T) {
+	dir := t.TempDir()
+	reg := &RigRegistry{}
+	reg.Register(RegistryEntry{Name: "here", Town: dir, Path: dir})
+	reg.Register(RegistryEntry{Name: "gone", Town: dir, Path: filepath.Join(dir, "gone")})
+
+	pruned := reg.Prune()
+	if len(pruned) != 1 || pruned[0].Name != "gone" || len(reg.Rigs) != 1 {
+		t.Errorf("Prune = %+v, remaining %+v", pruned, reg.Rigs)
+	}
+}
+
+func TestRegistryPath_Env(t *testing.T) {
+	t.Setenv(RegistryEnvVar, "/tmp/custom.json")
+	if p, err := RegistryPath(); err != nil || p != "/tmp/custom.json" {
+		t.Errorf("RegistryPath = %q, %v", p, err)
+	}
+}
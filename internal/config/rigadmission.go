@@ -0,0 +1,108 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// Admission decisions, and what to do when the policy cannot be reached.
+const (
+	AdmissionAccept = "accept"
+	AdmissionReject = "reject"
+)
+
+// DefaultAdmissionTimeout bounds an admission check unless timeout is set.
+const DefaultAdmissionTimeout = 30 * time.Second
+
+// AdmissionConfig is [refinery.admission]: an organization's own intake
+// policy, consulted before any branch enters the queue. It sees the MR
+// about to be queued and accepts it, rejects it with a reason, or accepts
+// it relabelled: with another priority or title, or other routes.
+//
+// Either Command or URL. The command runs with sh -c from the rig
+// directory, $GT_ADMISSION_REQUEST naming a JSON file with the MR and the
+// files its branch changes, and prints its decision as JSON:
+// {"decision": "reject", "reason": "..."}. URL is POSTed the same JSON and
+// replies with the decision.
+type AdmissionConfig struct {
+	Command string `toml:"command"`
+	URL     string `toml:"url"`
+
+	// Token is an ${env:NAME}, ${file:path}, or ${secret:name} reference,
+	// as in [refinery.env], sent to URL as a bearer token. Optional.
+	Token string `toml:"token"`
+
+	Timeout string `toml:"timeout,omitempty"` // Default DefaultAdmissionTimeout
+
+	// OnError is the decision when the policy fails or cannot be reached:
+	// AdmissionReject (the default) or AdmissionAccept.
+	OnError string `toml:"on_error"`
+}
+
+// TimeoutDuration returns the parsed timeout, or the default.
+func (ac *AdmissionConfig) TimeoutDuration() time.Duration {
+	if d, err := time.ParseDuration(ac.Timeout); err == nil && d > 0 {
+		return d
+	}
+	return DefaultAdmissionTimeout
+}
+
+// FailOpen reports whether MRs are queued when the policy cannot decide.
+func (ac *AdmissionConfig) FailOpen() bool {
+	return ac.OnError == AdmissionAccept
+}
+
+// ResolveToken resolves Token, or returns "" if unset. Like [refinery.env]
+// values, the token is registered with util.RegisterSecret so it is
+// redacted from logs.
+func (ac *AdmissionConfig) ResolveToken(rigPath string) (string, error) {
+	if ac.Token == "" {
+		return "", nil
+	}
+	kind, arg, _ := parseEnvRef(ac.Token)
+	token, err := resolveEnvRef(rigPath, kind, arg)
+	if err != nil {
+		return "", fmt.Errorf("admission.token: %w", err)
+	}
+	util.RegisterSecret(token)
+	return token, nil
+}
+
+func (ac *AdmissionConfig) validate(keyErr keyErrFunc) error {
+	command, hook := strings.TrimSpace(ac.Command) != "", ac.URL != ""
+	if command == hook {
+		return keyErr("admission", "set one of command or url")
+	}
+	if hook {
+		u, err := url.Parse(ac.URL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return keyErr("admission.url", "invalid URL %q", ac.URL)
+		}
+	}
+	if ac.Token != "" {
+		if !hook {
+			return keyErr("admission.token", "only used with url")
+		}
+		if _, _, ok := parseEnvRef(ac.Token); !ok {
+			return keyErr("admission.token", "must be an ${env:...}, ${file:...}, or ${secret:...} reference; keep tokens out of rig.toml")
+		}
+		if err := validateEnvEntry("token", ac.Token); err != nil {
+			return keyErr("admission.token", "%v", err)
+		}
+	}
+	if ac.Timeout != "" {
+		if d, err := time.ParseDuration(ac.Timeout); err != nil || d <= 0 {
+			return keyErr("admission.timeout", "invalid duration %q", ac.Timeout)
+		}
+	}
+	switch ac.OnError {
+	case "", AdmissionReject, AdmissionAccept:
+	default:
+		return keyErr("admission.on_error", "must be %q or %q, got %q", AdmissionReject, AdmissionAccept, ac.OnError)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// BackportConfig is [refinery.backport]: after an MR merges into the rig's
+// target, the refinery cherry-picks it onto release branches and queues
+// each pick as a follow-up MR. An MR asks for a backport with a
+// "Backport: release/1.4" trailer on its commits, or its source issue
+// carries a label with a rule.
+type BackportConfig struct {
+	// Branches are the branches backports may go to, as path.Match globs
+	// ("release/*"). Trailers naming any other branch are ignored.
+	Branches []string `toml:"branches"`
+
+	// Rules backport every MR whose source issue has a label.
+	Rules []BackportRule `toml:"rules"`
+}
+
+// BackportRule sends MRs for issues labelled Label to Branches.
+type BackportRule struct {
+	Label    string   `toml:"label"`
+	Branches []string `toml:"branches"`
+}
+
+// Allowed reports whether branch matches one of Branches.
+func (bc *BackportConfig) Allowed(branch string) bool {
+	for _, pattern := range bc.Branches {
+		if ok, _ := path.Match(pattern, branch); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ForLabels returns the branches the rules send an issue with labels to,
+// in rule order and without repeats.
+func (bc *BackportConfig) ForLabels(labels []string) []string {
+	has := make(map[string]bool, len(labels))
+	for _, l := range labels {
+		has[l] = true
+	}
+	var branches []string
+	seen := make(map[string]bool)
+	for _, rule := range bc.Rules {
+		if !has[rule.Label] {
+			continue
+		}
+		for _, b := range rule.Branches {
+			if !seen[b] {
+				seen[b] = true
+				branches = append(branches, b)
+			}
+		}
+	}
+	return branches
+}
+
+func (bc *BackportConfig) validate(keyErr keyErrFunc) error {
+	if len(bc.Branches) == 0 {
+		return keyErr("backport.branches", "required")
+	}
+	for i, pattern := range bc.Branches {
+		if strings.TrimSpace(pattern) == "" {
+			return keyErr(fmt.Sprintf("backport.branches[%d]", i), "empty pattern")
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return keyErr(fmt.Sprintf("backport.branches[%d]", i), "bad pattern %q: %v", pattern, err)
+		}
+	}
+	for i, rule := range bc.Rules {
+		if strings.TrimSpace(rule.Label) == "" {
+			return keyErr(fmt.Sprintf("backport.rules[%d].label", i), "required")
+		}
+		if len(rule.Branches) == 0 {
+			return keyErr(fmt.Sprintf("backport.rules[%d].branches", i), "required")
+		}
+		for j, b := range rule.Branches {
+			if !bc.Allowed(b) {
+				return keyErr(fmt.Sprintf("backport.rules[%d].branches[%d]", i, j), "%q is not in backport.branches", b)
+			}
+		}
+	}
+	return nil
+}
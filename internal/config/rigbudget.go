@@ -0,0 +1,43 @@
+package config
+
+import "fmt"
+
+// BudgetConfig is [refinery.budget]: a cap on how much may merge each
+// day, to pace change on high-risk repositories. Once a day's merges
+// reach a limit, further MRs wait until the next day (local time).
+type BudgetConfig struct {
+	// Lines caps lines changed (added plus removed). Zero means no cap.
+	Lines int `toml:"lines"`
+
+	// Files caps files changed. Zero means no cap.
+	Files int `toml:"files"`
+
+	// PerWorker applies the caps to each worker separately instead of to
+	// the rig as a whole.
+	PerWorker bool `toml:"per_worker"`
+}
+
+// Exhausted reports whether merges totalling files and lines have used up
+// the budget, and if so which limit was reached.
+func (bc *BudgetConfig) Exhausted(files, lines int) (bool, string) {
+	if bc.Lines > 0 && lines >= bc.Lines {
+		return true, fmt.Sprintf("%d of %d lines merged today", lines, bc.Lines)
+	}
+	if bc.Files > 0 && files >= bc.Files {
+		return true, fmt.Sprintf("%d of %d files merged today", files, bc.Files)
+	}
+	return false, ""
+}
+
+func (bc *BudgetConfig) validate(keyErr keyErrFunc) error {
+	if bc.Lines < 0 {
+		return keyErr("budget.lines", "must not be negative, got %d", bc.Lines)
+	}
+	if bc.Files < 0 {
+		return keyErr("budget.files", "must not be negative, got %d", bc.Files)
+	}
+	if bc.Lines == 0 && bc.Files == 0 {
+		return keyErr("budget", "set lines or files")
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"bytes"
+	"path"
+	"strings"
+	"text/template"
+)
+
+// Changelog defaults.
+const (
+	DefaultChangelogFile    = "CHANGELOG.md"
+	DefaultChangelogHeading = "## Unreleased"
+	DefaultChangelogEntry   = "- {{.Title}}{{if .Issue}} ({{.Issue}}){{end}}"
+)
+
+// ChangelogConfig is [refinery.changelog]: each merge adds an entry for
+// the MR to a changelog file, or writes it as a fragment file, in the
+// merge commit itself, so the changelog never drifts from history.
+type ChangelogConfig struct {
+	// File is the changelog, repo-relative. Defaults to CHANGELOG.md
+	// unless Fragments is set.
+	File string `toml:"file"`
+
+	// Heading is the line in File new entries go under, newest first.
+	// Defaults to "## Unreleased"; it is added if missing.
+	Heading string `toml:"heading"`
+
+	// Fragments is a repo-relative directory each MR's entry is written to
+	// as <mr-id>.md instead of editing File, for tools that assemble the
+	// changelog at release time.
+	Fragments string `toml:"fragments"`
+
+	// Entry is a text/template for the entry, given a ChangelogEntry.
+	// Defaults to DefaultChangelogEntry.
+	Entry string `toml:"entry"`
+}
+
+// ChangelogEntry is what an Entry template is given.
+type ChangelogEntry struct {
+	Title  string // The MR's title, or its branch
+	Issue  string // Source issue ID, if any
+	MR     string
+	Branch string
+	Worker string
+	Target string
+	Date   string // YYYY-MM-DD
+}
+
+// Path returns the file the entry for MR mrID goes in.
+func (c *ChangelogConfig) Path(mrID string) string {
+	if c.Fragments != "" {
+		return path.Join(c.Fragments, mrID+".md")
+	}
+	if c.File != "" {
+		return c.File
+	}
+	return DefaultChangelogFile
+}
+
+// Render renders the entry for e.
+func (c *ChangelogConfig) Render(e ChangelogEntry) (string, error) {
+	tmpl, err := c.template()
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, e); err != nil {
+		return "", err
+	}
+	return strings.TrimRight(buf.String(), "\n"), nil
+}
+
+func (c *ChangelogConfig) template() (*template.Template, error) {
+	entry := c.Entry
+	if entry == "" {
+		entry = DefaultChangelogEntry
+	}
+	return template.New("entry").Option("missingkey=error").Parse(entry)
+}
+
+// Apply returns the file at Path with entry added: the entry alone for a
+// fragment, else current with entry inserted under Heading, which is
+// added below the file's title (or created with one) if missing.
+func (c *ChangelogConfig) Apply(current, entry string) string {
+	if c.Fragments != "" {
+		return entry + "\n"
+	}
+	heading := c.Heading
+	if heading == "" {
+		heading = DefaultChangelogHeading
+	}
+	if strings.TrimSpace(current) == "" {
+		return "# Changelog\n\n" + heading + "\n\n" + entry + "\n"
+	}
+	current = strings.TrimRight(current, "\n") + "\n"
+
+	lines := strings.Split(current, "\n")
+	for i, line := range lines {
+		if strings.TrimRight(line, " \t\r") != heading {
+			continue
+		}
+		at := i + 1
+		if at < len(lines) && strings.TrimSpace(lines[at]) == "" {
+			at++
+		}
+		return joinLines(lines[:at], entry, lines[at:])
+	}
+
+	// No heading: add it with the entry under the title, or at the top.
+	if !strings.HasPrefix(lines[0], "# ") {
+		return joinLines(nil, heading+"\n\n"+entry+"\n", lines)
+	}
+	at := 1
+	for at < len(lines) && strings.TrimSpace(lines[at]) == "" {
+		at++
+	}
+	return joinLines(lines[:1], "\n"+heading+"\n\n"+entry+"\n", lines[at:])
+}
+
+func joinLines(before []string, insert string, after []string) string {
+	out := make([]string, 0, len(before)+len(after)+1)
+	out = append(out, before...)
+	out = append(out, insert)
+	out = append(out, after...)
+	return strings.Join(out, "\n")
+}
+
+func (c *ChangelogConfig) validate(strategy string, keyErr keyErrFunc) error {
+	if c.File != "" && c.Fragments != "" {
+		return keyErr("changelog.fragments", "set file or fragments, not both")
+	}
+	for _, p := range []struct{ key, path string }{{"changelog.file", c.File}, {"changelog.fragments", c.Fragments}} {
+		if p.path != "" && (path.IsAbs(p.path) || path.Clean(p.path) != p.path || strings.HasPrefix(p.path, "..")) {
+			return keyErr(p.key, "must be a clean repo-relative path, got %q", p.path)
+		}
+	}
+	if c.Heading != "" && strings.TrimSpace(c.Heading) != c.Heading {
+		return keyErr("changelog.heading", "must not have leading or trailing space")
+	}
+	if _, err := c.template(); err != nil {
+		return keyErr("changelog.entry", "%v", err)
+	}
+	if strategy == StrategyFFOnly {
+		return keyErr("changelog", "needs a merge commit to go in; not available with strategy = %q", StrategyFFOnly)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// Ways a [[refinery.deploy]] rule triggers its deployment.
+const (
+	DeployCommand = "command" // Runs a command
+	DeployWebhook = "webhook" // POSTs to a URL
+	DeployArgo    = "argo"    // Asks Argo CD to refresh an Application
+	DeployFlux    = "flux"    // Asks Flux to reconcile a Kustomization
+)
+
+// DefaultDeployTimeout bounds a deploy trigger unless timeout is set.
+const DefaultDeployTimeout = 5 * time.Minute
+
+// DeployConfig is one [[refinery.deploy]] rule: once a merge into one of
+// Branches has landed (and passed refinery.post_merge), trigger a
+// deployment of it. Set exactly one of Command, URL, Argo, or Flux.
+//
+// Command runs with sh -c from the rig directory, with $GT_DEPLOY,
+// $GT_MR_ID, $GT_MR_BRANCH, $GT_MR_TARGET, and $GT_MERGE_COMMIT set. URL
+// is POSTed the same as JSON. Argo and Flux name an Argo CD Application
+// or Flux Kustomization that kubectl annotates so it syncs now rather
+// than at its next poll.
+type DeployConfig struct {
+	Name string `toml:"name"`
+
+	// Branches are the targets whose merges deploy, as path.Match globs
+	// ("release/*").
+	Branches []string `toml:"branches"`
+
+	Command string `toml:"command"`
+	URL     string `toml:"url"`
+	Argo    string `toml:"argo"`
+	Flux    string `toml:"flux"`
+
+	// Token is an ${env:NAME}, ${file:path}, or ${secret:name} reference,
+	// as in [refinery.env], sent to URL as a bearer token. Optional.
+	Token string `toml:"token"`
+
+	// Namespace is the Kubernetes namespace of Argo or Flux's resource;
+	// kubectl's current namespace when unset.
+	Namespace string `toml:"namespace"`
+
+	Timeout string `toml:"timeout,omitempty"` // Default DefaultDeployTimeout
+}
+
+// Kind returns how the rule deploys: DeployCommand, DeployWebhook,
+// DeployArgo, or DeployFlux.
+func (d *DeployConfig) Kind() string {
+	switch {
+	case d.URL != "":
+		return DeployWebhook
+	case d.Argo != "":
+		return DeployArgo
+	case d.Flux != "":
+		return DeployFlux
+	default:
+		return DeployCommand
+	}
+}
+
+// Matches reports whether merges into branch deploy.
+func (d *DeployConfig) Matches(branch string) bool {
+	for _, pattern := range d.Branches {
+		if ok, _ := path.Match(pattern, branch); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// TimeoutDuration returns the parsed timeout, or the default.
+func (d *DeployConfig) TimeoutDuration() time.Duration {
+	if t, err := time.ParseDuration(d.Timeout); err == nil && t > 0 {
+		return t
+	}
+	return DefaultDeployTimeout
+}
+
+// ResolveToken resolves Token, or returns "" if unset. Like [refinery.env]
+// values, the token is registered with util.RegisterSecret so it is
+// redacted from logs.
+func (d *DeployConfig) ResolveToken(rigPath string) (string, error) {
+	if d.Token == "" {
+		return "", nil
+	}
+	kind, arg, _ := parseEnvRef(d.Token)
+	token, err := resolveEnvRef(rigPath, kind, arg)
+	if err != nil {
+		return "", fmt.Errorf("deploy %s token: %w", d.Name, err)
+	}
+	util.RegisterSecret(token)
+	return token, nil
+}
+
+// validateDeploys checks [[refinery.deploy]].
+func (s *RefinerySettings) validateDeploys(keyErr keyErrFunc) error {
+	seen := make(map[string]bool)
+	for i, d := range s.Deploy {
+		key := fmt.Sprintf("deploy[%d]", i)
+		if strings.TrimSpace(d.Name) == "" {
+			return keyErr(key+".name", "required")
+		}
+		if seen[d.Name] {
+			return keyErr(key+".name", "duplicate deploy %q", d.Name)
+		}
+		seen[d.Name] = true
+
+		if len(d.Branches) == 0 {
+			return keyErr(key+".branches", "required")
+		}
+		for j, pattern := range d.Branches {
+			if _, err := path.Match(pattern, ""); err != nil || strings.TrimSpace(pattern) == "" {
+				return keyErr(fmt.Sprintf("%s.branches[%d]", key, j), "bad pattern %q", pattern)
+			}
+		}
+
+		set := 0
+		for _, v := range []string{strings.TrimSpace(d.Command), d.URL, d.Argo, d.Flux} {
+			if v != "" {
+				set++
+			}
+		}
+		if set != 1 {
+			return keyErr(key, "set exactly one of command, url, argo, or flux")
+		}
+		if d.URL != "" {
+			u, err := url.Parse(d.URL)
+			if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+				return keyErr(key+".url", "invalid URL %q", d.URL)
+			}
+		}
+		if d.Token != "" {
+			if d.URL == "" {
+				return keyErr(key+".token", "only used with url")
+			}
+			if _, _, ok := parseEnvRef(d.Token); !ok {
+				return keyErr(key+".token", "must be an ${env:...}, ${file:...}, or ${secret:...} reference; keep tokens out of rig.toml")
+			}
+			if err := validateEnvEntry("token", d.Token); err != nil {
+				return keyErr(key+".token", "%v", err)
+			}
+		}
+		if d.Namespace != "" && d.Argo == "" && d.Flux == "" {
+			return keyErr(key+".namespace", "only used with argo or flux")
+		}
+		if d.Timeout != "" {
+			if t, err := time.ParseDuration(d.Timeout); err != nil || t <= 0 {
+				return keyErr(key+".timeout", "invalid duration %q", d.Timeout)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+var envNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Secret reference kinds accepted as [refinery.env] values.
+const (
+	envRefEnv    = "env"    // ${env:NAME}: from the refinery's own environment
+	envRefFile   = "file"   // ${file:path}: file contents, relative to settings/
+	envRefSecret = "secret" // ${secret:name}: from the rig's encrypted store
+)
+
+// parseEnvRef splits "${kind:arg}" into kind and arg. ok is false for
+// literal values.
+func parseEnvRef(value string) (kind, arg string, ok bool) {
+	if !strings.HasPrefix(value, "${") || !strings.HasSuffix(value, "}") {
+		return "", "", false
+	}
+	kind, arg, found := strings.Cut(value[2:len(value)-1], ":")
+	if !found {
+		return "", "", false
+	}
+	return kind, arg, true
+}
+
+func validateEnvEntry(name, value string) error {
+	if !envNameRe.MatchString(name) {
+		return fmt.Errorf("invalid variable name")
+	}
+	kind, arg, ok := parseEnvRef(value)
+	if !ok {
+		if strings.HasPrefix(value, "${") {
+			return fmt.Errorf("malformed reference %q (want ${env:NAME}, ${file:path}, or ${secret:name})", value)
+		}
+		return nil
+	}
+	switch kind {
+	case envRefEnv:
+		if !envNameRe.MatchString(arg) {
+			return fmt.Errorf("invalid variable name in %q", value)
+		}
+	case envRefFile:
+		if strings.TrimSpace(arg) == "" {
+			return fmt.Errorf("empty path in %q", value)
+		}
+	case envRefSecret:
+		if !secretNameRe.MatchString(arg) {
+			return fmt.Errorf("invalid secret name in %q", value)
+		}
+	default:
+		return fmt.Errorf("unknown reference kind %q (want env, file, or secret)", kind)
+	}
+	return nil
+}
+
+// ResolveEnv returns Env as sorted KEY=value pairs with secret references
+// resolved. Errors name the variable but never include a resolved value,
+// and resolved values are registered with util.RegisterSecret so they are
+// redacted from logs and state.
+func (s *RefinerySettings) ResolveEnv(rigPath string) ([]string, error) {
+	if s == nil || len(s.Env) == 0 {
+		return nil, nil
+	}
+
+	env := make([]string, 0, len(s.Env))
+	for _, name := range sortedKeys(s.Env) {
+		value := s.Env[name]
+		if kind, arg, ok := parseEnvRef(value); ok {
+			resolved, err := resolveEnvRef(rigPath, kind, arg)
+			if err != nil {
+				return nil, fmt.Errorf("env %s: %w", name, err)
+			}
+			util.RegisterSecret(resolved)
+			value = resolved
+		}
+		env = append(env, name+"="+value)
+	}
+	return env, nil
+}
+
+func resolveEnvRef(rigPath, kind, arg string) (string, error) {
+	switch kind {
+	case envRefEnv:
+		v, ok := os.LookupEnv(arg)
+		if !ok {
+			return "", fmt.Errorf("%s is not set in the refinery environment", arg)
+		}
+		return v, nil
+	case envRefFile:
+		p := arg
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(filepath.Dir(RigFilePath(rigPath)), p)
+		}
+		data, err := os.ReadFile(p) //nolint:gosec // G304: path is from trusted rig config
+		if err != nil {
+			return "", fmt.Errorf("reading secret file: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	case envRefSecret:
+		store, err := OpenSecretStore(rigPath, false)
+		if err != nil {
+			return "", err
+		}
+		return store.Get(arg)
+	}
+	return "", fmt.Errorf("unknown reference kind %q", kind)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
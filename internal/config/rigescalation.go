@@ -0,0 +1,34 @@
+package config
+
+// DefaultEscalateAfter is how many times an MR may fail before the refinery
+// escalates it, when [refinery.escalation] after is unset.
+const DefaultEscalateAfter = 3
+
+// EscalationConfig is [refinery.escalation]: when an MR keeps failing, the
+// refinery files a beads issue for someone to look at it instead of
+// retrying forever.
+type EscalationConfig struct {
+	// After is how many failed merges an MR may have; the next failure
+	// escalates it. Zero means DefaultEscalateAfter.
+	After int `toml:"after"`
+
+	// Assignee is who the escalation issue is assigned to: a human, or a
+	// triage agent such as "greenplace/crew/triage". Empty leaves it
+	// unassigned.
+	Assignee string `toml:"assignee"`
+}
+
+// Threshold returns After, or the default if unset.
+func (ec *EscalationConfig) Threshold() int {
+	if ec.After == 0 {
+		return DefaultEscalateAfter
+	}
+	return ec.After
+}
+
+func (ec *EscalationConfig) validate(keyErr keyErrFunc) error {
+	if ec.After < 0 {
+		return keyErr("escalation.after", "must not be negative, got %d", ec.After)
+	}
+	return nil
+}
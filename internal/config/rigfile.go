@@ -0,0 +1,856 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// RigFileName is the per-rig TOML configuration file, kept in settings/
+// next to config.json. It holds sections for rig subsystems; settings in
+// it take precedence over the equivalent merge_queue keys in config.json.
+const RigFileName = "rig.toml"
+
+// Merge strategy constants for [refinery] strategy.
+const (
+	StrategyMerge  = "merge"   // Merge commit (--no-ff), the default
+	StrategySquash = "squash"  // Single squashed commit on the target
+	StrategyFFOnly = "ff-only" // Fast-forward only; fails if the branch is behind
+)
+
+// Values of [refinery] issue_status besides a plain beads status.
+const (
+	IssueStatusClosed = "closed" // Close the issue, the default
+	IssueStatusNone   = "none"   // Leave the issue alone
+)
+
+// Values of [refinery] state_integrity.
+const (
+	StateIntegrityWarn   = "warn"   // Warn about edited state, the default
+	StateIntegrityRefuse = "refuse" // Refuse to start until it is accepted
+	StateIntegrityOff    = "off"    // Do not check
+)
+
+// Values of [refinery] semantic_conflicts.
+const (
+	SemanticConflictsWarn      = "warn"      // Flag overlapping pairs
+	SemanticConflictsSerialize = "serialize" // Also hold the later MR until the earlier lands
+)
+
+// RigFilePath returns the path to a rig's rig.toml.
+func RigFilePath(rigPath string) string {
+	return filepath.Join(rigPath, "settings", RigFileName)
+}
+
+// RigFile is the schema of settings/rig.toml.
+//
+//	[refinery]
+//	strategy = "squash"
+//	branch_patterns = ["polecat/*"]
+//	verify_authorship = true
+//	lanes = true
+//	pipeline = true
+//	stacks = true
+//	issue_status = "closed"
+//	state_integrity = "refuse"
+//	semantic_conflicts = "serialize"
+//
+//	[[refinery.checks]]
+//	name = "test"
+//	command = "go test ./..."
+//	timeout = "10m"
+//
+//	[[refinery.post_merge]]
+//	name = "smoke"
+//	command = "make smoke"
+//
+//	[[refinery.routes]]
+//	name = "docs"
+//	paths = ["docs/**", "*.md"]
+//	skip_checks = ["test"]
+//
+//	[[refinery.routes]]
+//	name = "payments"
+//	paths = ["services/payments/"]
+//
+//	[[refinery.routes.checks]]
+//	name = "payments-integration"
+//	command = "make -C services/payments integration"
+//
+//	[[refinery.milestones]]
+//	name = "build"
+//	every = 10
+//
+//	[[refinery.milestones]]
+//	name = "nightly"
+//	daily = "18:00"
+//	tag = "nightly-{{.Date}}"
+//	notes = true
+//
+//	[[refinery.deploy]]
+//	name = "staging"
+//	branches = ["main"]
+//	argo = "api-staging"
+//
+//	[refinery.schedule]
+//	poll_interval = "1m"
+//	windows = ["09:00-18:00"]
+//	days = ["mon", "tue", "wed", "thu", "fri"]
+//
+//	[refinery.notifications]
+//	on_merge = ["mayor/"]
+//	on_failure = ["mayor/", "greenplace/witness"]
+//	digest = ["overseer"]
+//	digest_at = "17:30"
+//	digest_command = "./scripts/summarize-day.sh"
+//
+//	[refinery.env]
+//	GOFLAGS = "-mod=mod"
+//	API_KEY = "${file:secrets/api_key}"
+//	GITHUB_TOKEN = "${secret:github_token}"
+//
+//	[refinery.resolver]
+//	command = "./scripts/resolve-conflicts.sh"
+//	timeout = "20m"
+//	auto_merge_confidence = 0.8
+//
+//	[refinery.summary]
+//	command = "./scripts/summarize-branch.sh"
+//	timeout = "2m"
+//
+//	[refinery.review]
+//	command = "./scripts/review.sh"
+//	timeout = "15m"
+//	guidelines = "REVIEWING.md"
+//
+//	[refinery.triage]
+//	command = "./scripts/triage.sh"
+//	timeout = "5m"
+//
+//	[refinery.admission]
+//	url = "https://intake.example.com/admit"
+//	token = "${secret:admission}"
+//
+//	[refinery.risk]
+//	order = "batch"
+//	high = 0.6
+//	paths = ["internal/auth/", "*.sql"]
+//
+//	[refinery.integration]
+//	auto_land = true
+//	require_approval = true
+//	conflict_scan_interval = "10m"
+//
+//	[[refinery.integration.checks]]
+//	name = "e2e"
+//	command = "make e2e"
+//
+//	[refinery.escalation]
+//	after = 3
+//	assignee = "overseer"
+//
+//	[refinery.quarantine]
+//	after = 3
+//	alert = ["overseer", "mayor/"]
+//
+//	[refinery.issue_gate]
+//	status = "in_review"
+//	label = "approved"
+//
+//	[refinery.signatures]
+//	allowed_signers = "allowed_signers"
+//	keys = ["4AEE18F83AFDEB23"]
+//
+//	[refinery.protected]
+//	paths = ["deploy/", "migrations/", "vendor/", "*.tf"]
+//	content = ["(?i)drop table"]
+//
+//	[refinery.sandbox]
+//	runtime = "docker"
+//	image = "golang:1.24"
+//	writable = ["~/.cache/go-build"]
+//
+//	[refinery.budget]
+//	lines = 2000
+//	files = 40
+//	per_worker = true
+//
+//	[refinery.backport]
+//	branches = ["release/*"]
+//
+//	[[refinery.backport.rules]]
+//	label = "backport-1.4"
+//	branches = ["release/1.4"]
+//
+//	[refinery.github_queue]
+//	repo = "example/greenplace"
+//	token = "${secret:github_token}"
+//
+//	[refinery.changelog]
+//	file = "CHANGELOG.md"
+//	entry = "- {{.Title}} ({{.Issue}})"
+//
+//	[refinery.state_sync]
+//	ref = "refs/gastown/state"
+//
+//	[refinery.maintenance]
+//	prune_branches = "0 3 * * *"
+//	stale_after = "720h"
+//	gc_mirror = "@weekly"
+//
+//	[refinery.workers]
+//	allow = ["nux", "furiosa", "crew-*"]
+//	deny = ["scratch-*"]
+//	max_ready = 3
+//	veteran = ["nux"]
+//	trusted = ["furiosa", "crew-*"]
+//	default_tier = "new"
+//
+//	[refinery.tiers.new]
+//	require_approval = true
+//
+//	[[refinery.tiers.new.checks]]
+//	name = "integration"
+//	command = "make integration"
+type RigFile struct {
+	Refinery *RefinerySettings `toml:"refinery"`
+}
+
+// RefinerySettings is the [refinery] section of rig.toml.
+type RefinerySettings struct {
+	// Strategy is how branches land on the target: merge, squash, or ff-only.
+	Strategy string `toml:"strategy"`
+
+	// TargetBranch overrides the rig's default target branch.
+	TargetBranch string `toml:"target_branch"`
+
+	// OnConflict is "assign_back" or "auto_rebase".
+	OnConflict string `toml:"on_conflict"`
+
+	// BranchPatterns restricts which branches may be merged (path.Match
+	// globs, e.g. "polecat/*"). Empty accepts every branch.
+	BranchPatterns []string `toml:"branch_patterns"`
+
+	// VerifyAuthorship rejects polecat branches (polecat/<name>-<timestamp>)
+	// carrying commits not authored by <name>, the GIT_AUTHOR_NAME polecat
+	// sessions run with.
+	VerifyAuthorship bool `toml:"verify_authorship"`
+
+	// Lanes processes each target branch (the rig's target and every swarm
+	// integration branch) as its own lane, with its own current MR and
+	// merge worktree, so one swarm's merges do not wait behind another's.
+	Lanes bool `toml:"lanes"`
+
+	// Pipeline starts checking the next ready MR while the current one is
+	// pushed, against the target as it will be once the push lands. The
+	// result is used only if the target and the MR's branch have not moved
+	// by the time the MR is merged.
+	Pipeline bool `toml:"pipeline"`
+
+	// Stacks detects queued branches built on another queued branch with
+	// the same target, merges them after it, and rebases them onto the
+	// target once it lands or leaves the queue, or onto its new head if
+	// it is rewritten.
+	Stacks bool `toml:"stacks"`
+
+	// IssueStatus is the status a merged MR's source issue moves to:
+	// "closed" (the default), another beads status such as "in_review" for
+	// work that still needs sign-off, or "none" to leave its status alone.
+	IssueStatus string `toml:"issue_status"`
+
+	// StateIntegrity is what starting the refinery does when its state
+	// (refinery.json, queued MRs) was changed outside gt since it was
+	// written: "warn" (the default), "refuse", or "off".
+	StateIntegrity string `toml:"state_integrity"`
+
+	// SemanticConflicts compares the Go declarations queued branches
+	// change, so two branches editing the same function are caught even
+	// when they merge cleanly. "warn" flags such pairs in conflict scans;
+	// "serialize" also keeps the later MR of a pair out of the ready list
+	// while the earlier one is ready or being merged, so its checks run
+	// against a target that already has the earlier change. Empty turns
+	// the comparison off.
+	SemanticConflicts string `toml:"semantic_conflicts"`
+
+	// Paths scopes the rig to subdirectories of a shared repository
+	// (monorepo). Only branches whose diff touches one of them are queued
+	// and merged, and checks run from the first path. Empty means the
+	// whole repository.
+	Paths []string `toml:"paths"`
+
+	// Checks run in order before merging; any failure rejects the MR.
+	// When set, they replace merge_queue.test_command.
+	Checks []CheckConfig `toml:"checks"`
+
+	// PostMerge checks run against the target once a merge is pushed. If
+	// one fails, the merge is reverted and the MR fails with its output.
+	PostMerge []CheckConfig `toml:"post_merge"`
+
+	// Routes send MRs to another target, or change their checks, by the
+	// paths their diff touches.
+	Routes []RouteConfig `toml:"routes"`
+
+	// Milestones tag the target after every N merges, daily, or when an
+	// epic's swarm lands.
+	Milestones []MilestoneConfig `toml:"milestones"`
+
+	// Deploy triggers a deployment after merges into matching targets.
+	Deploy []DeployConfig `toml:"deploy"`
+
+	// Env is added to the environment of checks, the test command, and
+	// hooks. Values are literal, or a secret reference resolved when the
+	// subprocess starts: "${env:NAME}", "${file:path}", or "${secret:name}"
+	// from the rig's encrypted store (see ResolveEnv and SecretStore).
+	Env map[string]string `toml:"env"`
+
+	Schedule      *ScheduleConfig      `toml:"schedule"`
+	Notifications *NotificationsConfig `toml:"notifications"`
+	Workers       *WorkerPolicyConfig  `toml:"workers"`
+	Resolver      *ResolverConfig      `toml:"resolver"`
+	Summary       *SummaryConfig       `toml:"summary"`
+	Review        *ReviewConfig        `toml:"review"`
+	Triage        *TriageConfig        `toml:"triage"`
+	Admission     *AdmissionConfig     `toml:"admission"`
+	Risk          *RiskConfig          `toml:"risk"`
+	Integration   *IntegrationConfig   `toml:"integration"`
+	Escalation    *EscalationConfig    `toml:"escalation"`
+	Quarantine    *QuarantineConfig    `toml:"quarantine"`
+	IssueGate     *IssueGateConfig     `toml:"issue_gate"`
+	Signatures    *SignatureConfig     `toml:"signatures"`
+	Protected     *ProtectedConfig     `toml:"protected"`
+	Sandbox       *SandboxConfig       `toml:"sandbox"`
+	Budget        *BudgetConfig        `toml:"budget"`
+	Backport      *BackportConfig      `toml:"backport"`
+	GitHubQueue   *GitHubQueueConfig   `toml:"github_queue"`
+	Changelog     *ChangelogConfig     `toml:"changelog"`
+	StateSync     *StateSyncConfig     `toml:"state_sync"`
+	Maintenance   *MaintenanceConfig   `toml:"maintenance"`
+
+	// Tiers sets extra gates for workers in each trust tier ("new",
+	// "trusted", "veteran"); see WorkerPolicyConfig for tier membership.
+	Tiers map[string]*TierPolicy `toml:"tiers"`
+}
+
+// CheckConfig is one pre-merge check.
+type CheckConfig struct {
+	Name    string `toml:"name"`
+	Command string `toml:"command"`           // Run with sh -c from the rig directory
+	Timeout string `toml:"timeout,omitempty"` // e.g. "10m"; empty means no limit
+	Dir     string `toml:"dir,omitempty"`     // Repo-relative directory; defaults to paths[0]
+}
+
+// TimeoutDuration returns the parsed timeout, or 0 for none.
+func (c CheckConfig) TimeoutDuration() time.Duration {
+	d, _ := time.ParseDuration(c.Timeout)
+	return d
+}
+
+// ScheduleConfig limits when the refinery merges.
+type ScheduleConfig struct {
+	// PollInterval is how often to check for new MRs (e.g. "30s").
+	PollInterval string `toml:"poll_interval"`
+
+	// Windows are local "HH:MM-HH:MM" ranges during which merges may land.
+	// A range may wrap midnight ("22:00-06:00"). Empty means any time.
+	Windows []string `toml:"windows"`
+
+	// Days restricts merging to these weekdays ("mon".."sun"). Empty means every day.
+	Days []string `toml:"days"`
+}
+
+// DefaultDigestAt is when the daily digest goes out if digest_at is unset.
+const DefaultDigestAt = "18:00"
+
+// NotificationsConfig lists mail addresses told about merge outcomes.
+type NotificationsConfig struct {
+	OnMerge   []string `toml:"on_merge"`
+	OnFailure []string `toml:"on_failure"`
+
+	// Digest lists addresses sent one digest of each day's merges,
+	// failures, conflicts, and stats, once DigestAt (local "HH:MM",
+	// default DefaultDigestAt) has passed.
+	Digest   []string `toml:"digest"`
+	DigestAt string   `toml:"digest_at"`
+
+	// DigestCommand, if set, writes the digest instead of the built-in
+	// template: it runs with sh -c, $GT_DIGEST_CONTEXT naming the day's
+	// digest as JSON and $GT_DIGEST_TEXT the templated version, and what
+	// it prints is sent. If it fails, the template is sent.
+	DigestCommand string `toml:"digest_command"`
+	DigestTimeout string `toml:"digest_timeout,omitempty"` // Empty means no limit
+}
+
+// DigestMinute returns DigestAt, or the default, in minutes since midnight.
+func (n *NotificationsConfig) DigestMinute() int {
+	at := n.DigestAt
+	if at == "" {
+		at = DefaultDigestAt
+	}
+	t, err := time.Parse("15:04", at)
+	if err != nil {
+		return 0
+	}
+	return t.Hour()*60 + t.Minute()
+}
+
+// DigestTimeoutDuration returns the parsed digest timeout, or 0 for none.
+func (n *NotificationsConfig) DigestTimeoutDuration() time.Duration {
+	d, _ := time.ParseDuration(n.DigestTimeout)
+	return d
+}
+
+// KeyError reports an invalid value at a specific key of a config file.
+type KeyError struct {
+	File string
+	Line int    // Line of the key, or of its table if the key is missing; 0 if unknown
+	Key  string // Dotted path, e.g. "refinery.checks[1].timeout"
+	Msg  string
+}
+
+func (e *KeyError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s: %s", e.File, e.Line, e.Key, e.Msg)
+	}
+	return fmt.Sprintf("%s: %s: %s", e.File, e.Key, e.Msg)
+}
+
+// LoadRigFile loads and validates a rig.toml. Returns ErrNotFound if the
+// file does not exist and a *KeyError for unknown or invalid keys.
+func LoadRigFile(filePath string) (*RigFile, error) {
+	data, err := os.ReadFile(filePath) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, filePath)
+		}
+		return nil, fmt.Errorf("reading rig config: %w", err)
+	}
+
+	var rf RigFile
+	md, err := toml.Decode(string(data), &rf)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		key := undecoded[0].String()
+		return nil, &KeyError{File: filePath, Line: KeyLine(data, key), Key: key, Msg: "unknown key"}
+	}
+
+	if err := rf.validate(); err != nil {
+		var ke *KeyError
+		if errors.As(err, &ke) {
+			ke.File = filePath
+			ke.Line = KeyLine(data, ke.Key)
+		}
+		return nil, err
+	}
+	return &rf, nil
+}
+
+// LoadRefinerySettings returns the [refinery] section of a rig's rig.toml,
+// or nil if the file or section is absent.
+func LoadRefinerySettings(rigPath string) (*RefinerySettings, error) {
+	rf, err := LoadRigFile(RigFilePath(rigPath))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return rf.Refinery, nil
+}
+
+func (rf *RigFile) validate() error {
+	if rf.Refinery == nil {
+		return nil
+	}
+	return rf.Refinery.validate("refinery")
+}
+
+func (s *RefinerySettings) validate(prefix string) error {
+	keyErr := func(key, format string, args ...interface{}) error {
+		return &KeyError{Key: prefix + "." + key, Msg: fmt.Sprintf(format, args...)}
+	}
+
+	switch s.Strategy {
+	case "", StrategyMerge, StrategySquash, StrategyFFOnly:
+	default:
+		return keyErr("strategy", "got %q, want %q, %q, or %q", s.Strategy, StrategyMerge, StrategySquash, StrategyFFOnly)
+	}
+	if s.OnConflict != "" && s.OnConflict != OnConflictAssignBack && s.OnConflict != OnConflictAutoRebase {
+		return keyErr("on_conflict", "got %q, want %q or %q", s.OnConflict, OnConflictAssignBack, OnConflictAutoRebase)
+	}
+	for i, p := range s.BranchPatterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return keyErr(fmt.Sprintf("branch_patterns[%d]", i), "invalid pattern %q", p)
+		}
+	}
+
+	if strings.ContainsAny(s.IssueStatus, " \t\n") {
+		return keyErr("issue_status", "invalid status %q", s.IssueStatus)
+	}
+	switch s.StateIntegrity {
+	case "", StateIntegrityWarn, StateIntegrityRefuse, StateIntegrityOff:
+	default:
+		return keyErr("state_integrity", "got %q, want %q, %q, or %q", s.StateIntegrity, StateIntegrityWarn, StateIntegrityRefuse, StateIntegrityOff)
+	}
+	switch s.SemanticConflicts {
+	case "", SemanticConflictsWarn, SemanticConflictsSerialize:
+	default:
+		return keyErr("semantic_conflicts", "got %q, want %q or %q", s.SemanticConflicts, SemanticConflictsWarn, SemanticConflictsSerialize)
+	}
+
+	for i, p := range s.Paths {
+		if err := validateRepoPath(p); err != nil {
+			return keyErr(fmt.Sprintf("paths[%d]", i), "%v", err)
+		}
+	}
+
+	names := make(map[string]bool)
+	if err := validateChecks(s.Checks, "checks", names, keyErr); err != nil {
+		return err
+	}
+	if err := validateChecks(s.PostMerge, "post_merge", make(map[string]bool), keyErr); err != nil {
+		return err
+	}
+	if len(s.PostMerge) > 0 && s.GitHubQueue != nil {
+		return keyErr("post_merge", "not available with github_queue, which merges on GitHub")
+	}
+
+	for _, name := range sortedKeys(s.Env) {
+		if err := validateEnvEntry(name, s.Env[name]); err != nil {
+			return keyErr("env."+name, "%v", err)
+		}
+	}
+
+	if sc := s.Schedule; sc != nil {
+		if sc.PollInterval != "" {
+			if d, err := time.ParseDuration(sc.PollInterval); err != nil || d <= 0 {
+				return keyErr("schedule.poll_interval", "invalid duration %q", sc.PollInterval)
+			}
+		}
+		for i, w := range sc.Windows {
+			if _, _, err := parseWindow(w); err != nil {
+				return keyErr(fmt.Sprintf("schedule.windows[%d]", i), "%v", err)
+			}
+		}
+		for i, d := range sc.Days {
+			if _, ok := weekdays[strings.ToLower(d)]; !ok {
+				return keyErr(fmt.Sprintf("schedule.days[%d]", i), "unknown day %q (want mon..sun)", d)
+			}
+		}
+	}
+
+	if err := s.validateWorkers(names, keyErr); err != nil {
+		return err
+	}
+	if err := s.validateRoutes(names, keyErr); err != nil {
+		return err
+	}
+	if err := s.validateMilestones(keyErr); err != nil {
+		return err
+	}
+	if err := s.validateDeploys(keyErr); err != nil {
+		return err
+	}
+	if s.Resolver != nil {
+		if err := s.Resolver.validate(keyErr); err != nil {
+			return err
+		}
+	}
+	if s.Summary != nil {
+		if err := s.Summary.validate(keyErr); err != nil {
+			return err
+		}
+	}
+	if s.Review != nil {
+		if err := s.Review.validate(keyErr); err != nil {
+			return err
+		}
+	}
+	if s.Triage != nil {
+		if err := s.Triage.validate(keyErr); err != nil {
+			return err
+		}
+	}
+	if s.Admission != nil {
+		if err := s.Admission.validate(keyErr); err != nil {
+			return err
+		}
+	}
+	if s.Risk != nil {
+		if err := s.Risk.validate(keyErr); err != nil {
+			return err
+		}
+	}
+	if s.Integration != nil {
+		if err := s.Integration.validate(names, keyErr); err != nil {
+			return err
+		}
+	}
+	if s.Escalation != nil {
+		if err := s.Escalation.validate(keyErr); err != nil {
+			return err
+		}
+	}
+	if s.Quarantine != nil {
+		if err := s.Quarantine.validate(keyErr); err != nil {
+			return err
+		}
+	}
+	if s.IssueGate != nil {
+		if err := s.IssueGate.validate(keyErr); err != nil {
+			return err
+		}
+	}
+	if s.Signatures != nil {
+		if err := s.Signatures.validate(keyErr); err != nil {
+			return err
+		}
+	}
+	if s.Protected != nil {
+		if err := s.Protected.validate(keyErr); err != nil {
+			return err
+		}
+	}
+	if s.Sandbox != nil {
+		if err := s.Sandbox.validate(keyErr); err != nil {
+			return err
+		}
+	}
+	if s.Budget != nil {
+		if err := s.Budget.validate(keyErr); err != nil {
+			return err
+		}
+	}
+	if s.Backport != nil {
+		if err := s.Backport.validate(keyErr); err != nil {
+			return err
+		}
+	}
+	if s.GitHubQueue != nil {
+		if err := s.GitHubQueue.validate(keyErr); err != nil {
+			return err
+		}
+	}
+	if s.Changelog != nil {
+		if err := s.Changelog.validate(s.Strategy, keyErr); err != nil {
+			return err
+		}
+	}
+	if s.StateSync != nil {
+		if err := s.StateSync.validate(keyErr); err != nil {
+			return err
+		}
+	}
+	if s.Maintenance != nil {
+		if err := s.Maintenance.validate(keyErr); err != nil {
+			return err
+		}
+	}
+
+	if n := s.Notifications; n != nil {
+		for i, addr := range n.OnMerge {
+			if strings.TrimSpace(addr) == "" {
+				return keyErr(fmt.Sprintf("notifications.on_merge[%d]", i), "empty address")
+			}
+		}
+		for i, addr := range n.OnFailure {
+			if strings.TrimSpace(addr) == "" {
+				return keyErr(fmt.Sprintf("notifications.on_failure[%d]", i), "empty address")
+			}
+		}
+		for i, addr := range n.Digest {
+			if strings.TrimSpace(addr) == "" {
+				return keyErr(fmt.Sprintf("notifications.digest[%d]", i), "empty address")
+			}
+		}
+		if n.DigestAt != "" {
+			if _, err := time.Parse("15:04", n.DigestAt); err != nil {
+				return keyErr("notifications.digest_at", "got %q, want HH:MM", n.DigestAt)
+			}
+		}
+		if n.DigestTimeout != "" {
+			if d, err := time.ParseDuration(n.DigestTimeout); err != nil || d <= 0 {
+				return keyErr("notifications.digest_timeout", "invalid duration %q", n.DigestTimeout)
+			}
+		}
+	}
+	return nil
+}
+
+// MergedIssueStatus returns IssueStatus, defaulting to IssueStatusClosed.
+func (s *RefinerySettings) MergedIssueStatus() string {
+	if s == nil || s.IssueStatus == "" {
+		return IssueStatusClosed
+	}
+	return s.IssueStatus
+}
+
+// StateIntegrityMode returns StateIntegrity, defaulting to
+// StateIntegrityWarn.
+func (s *RefinerySettings) StateIntegrityMode() string {
+	if s == nil || s.StateIntegrity == "" {
+		return StateIntegrityWarn
+	}
+	return s.StateIntegrity
+}
+
+// BranchAllowed reports whether branch matches BranchPatterns.
+func (s *RefinerySettings) BranchAllowed(branch string) bool {
+	if s == nil || len(s.BranchPatterns) == 0 {
+		return true
+	}
+	for _, p := range s.BranchPatterns {
+		if ok, _ := path.Match(p, branch); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// keyErrFunc builds a KeyError for a key relative to the section being
+// validated.
+type keyErrFunc func(key, format string, args ...interface{}) error
+
+// validateChecks validates checks listed under key. names collects check
+// names across lists so they stay unique.
+func validateChecks(checks []CheckConfig, key string, names map[string]bool, keyErr keyErrFunc) error {
+	for i, c := range checks {
+		key := fmt.Sprintf("%s[%d]", key, i)
+		if c.Name == "" {
+			return keyErr(key+".name", "required")
+		}
+		if names[c.Name] {
+			return keyErr(key+".name", "duplicate check %q", c.Name)
+		}
+		names[c.Name] = true
+		if strings.TrimSpace(c.Command) == "" {
+			return keyErr(key+".command", "required")
+		}
+		if c.Timeout != "" {
+			if d, err := time.ParseDuration(c.Timeout); err != nil || d <= 0 {
+				return keyErr(key+".timeout", "invalid duration %q", c.Timeout)
+			}
+		}
+		if c.Dir != "" {
+			if err := validateRepoPath(c.Dir); err != nil {
+				return keyErr(key+".dir", "%v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateRepoPath accepts a clean, relative path inside the repository.
+func validateRepoPath(p string) error {
+	switch {
+	case strings.TrimSpace(p) == "":
+		return fmt.Errorf("empty path")
+	case path.IsAbs(p) || filepath.IsAbs(p):
+		return fmt.Errorf("%q must be relative to the repository root", p)
+	case path.Clean(p) != p || p == "." || p == ".." || strings.HasPrefix(p, "../"):
+		return fmt.Errorf("%q must be a clean path inside the repository", p)
+	}
+	return nil
+}
+
+// Touches reports whether any of files (repo-relative) falls under Paths.
+// Always true when Paths is empty.
+func (s *RefinerySettings) Touches(files []string) bool {
+	if s == nil || len(s.Paths) == 0 {
+		return true
+	}
+	for _, f := range files {
+		for _, p := range s.Paths {
+			if f == p || strings.HasPrefix(f, p+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CheckDir returns the repo-relative directory a check runs in: its own
+// dir, else the first scoped path, else "" for the repository root.
+func (s *RefinerySettings) CheckDir(c CheckConfig) string {
+	if c.Dir != "" {
+		return c.Dir
+	}
+	if s != nil && len(s.Paths) > 0 {
+		return s.Paths[0]
+	}
+	return ""
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Allows reports whether merging is permitted at t (in t's location).
+func (sc *ScheduleConfig) Allows(t time.Time) bool {
+	if sc == nil {
+		return true
+	}
+	if len(sc.Days) > 0 {
+		ok := false
+		for _, d := range sc.Days {
+			if weekdays[strings.ToLower(d)] == t.Weekday() {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	if len(sc.Windows) == 0 {
+		return true
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+	for _, w := range sc.Windows {
+		start, end, err := parseWindow(w)
+		if err != nil {
+			continue
+		}
+		if start <= end && minute >= start && minute < end {
+			return true
+		}
+		if start > end && (minute >= start || minute < end) { // Wraps midnight
+			return true
+		}
+	}
+	return false
+}
+
+// parseWindow parses "HH:MM-HH:MM" into minutes since midnight.
+func parseWindow(w string) (start, end int, err error) {
+	from, to, ok := strings.Cut(w, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid window %q (want HH:MM-HH:MM)", w)
+	}
+	parse := func(s string) (int, error) {
+		t, err := time.Parse("15:04", strings.TrimSpace(s))
+		if err != nil {
+			return 0, fmt.Errorf("invalid window %q (want HH:MM-HH:MM)", w)
+		}
+		return t.Hour()*60 + t.Minute(), nil
+	}
+	if start, err = parse(from); err != nil {
+		return 0, 0, err
+	}
+	if end, err = parse(to); err != nil {
+		return 0, 0, err
+	}
+	if start == end {
+		return 0, 0, fmt.Errorf("empty window %q", w)
+	}
+	return start, end, nil
+}
@@ -0,0 +1,521 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeRigFile(t *testing.T, content string) string {
+	t.Helper()
+	rigPath := t.TempDir()
+	p := RigFilePath(rigPath)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return rigPath
+}
+
+func TestLoadRefinerySettings(t *testing.T) {
+	rigPath := writeRigFile(t, `
+[refinery]
+strategy = "squash"
+branch_patterns = ["polecat/*"]
+
+[[refinery.checks]]
+name = "vet"
+command = "go vet ./..."
+
+[[refinery.checks]]
+name = "test"
+command = "go test ./..."
+timeout = "10m"
+
+[refinery.schedule]
+windows = ["09:00-18:00"]
+days = ["mon", "fri"]
+
+[refinery.notifications]
+on_failure = ["mayor/"]
+`)
+
+	s, err := LoadRefinerySettings(rigPath)
+	if err != nil {
+		t.Fatalf("LoadRefinerySettings: %v", err)
+	}
+	if s.Strategy != StrategySquash || len(s.Checks) != 2 || s.Checks[1].TimeoutDuration() != 10*time.Minute {
+		t.Errorf("settings = %+v", s)
+	}
+	if !s.BranchAllowed("polecat/nux") || s.BranchAllowed("feature/x") {
+		t.Error("BranchAllowed does not follow branch_patterns")
+	}
+	if got := s.Notifications.OnFailure; len(got) != 1 || got[0] != "mayor/" {
+		t.Errorf("on_failure = %v", got)
+	}
+}
+
+func TestLoadRefinerySettings_Missing(t *testing.T) {
+	s, err := LoadRefinerySettings(t.TempDir())
+	if err != nil || s != nil {
+		t.Errorf("missing file = %+v, %v; want nil, nil", s, err)
+	}
+}
+
+func TestLoadRigFile_KeyErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		key     string
+	}{
+		{"[refinery]\nstrategy = \"octopus\"", "refinery.strategy"},
+		{"[refinery]\non_conflict = \"panic\"", "refinery.on_conflict"},
+		{"[refinery]\nbranch_patterns = [\"[\"]", "refinery.branch_patterns[0]"},
+		{"[refinery]\nissue_status = \"in review\"", "refinery.issue_status"},
+		{"[refinery]\nstate_integrity = \"strict\"", "refinery.state_integrity"},
+		{"[refinery]\nsemantic_conflicts = \"block\"", "refinery.semantic_conflicts"},
+		{"[[refinery.checks]]\nname = \"a\"\ncommand = \"true\"\n[[refinery.checks]]\nname = \"b\"\ncommand = \"true\"\ntimeout = \"soon\"", "refinery.checks[1].timeout"},
+		{"[[refinery.checks]]\ncommand = \"true\"", "refinery.checks[0].name"},
+		{"[refinery.schedule]\nwindows = [\"9-5\"]", "refinery.schedule.windows[0]"},
+		{"[refinery.schedule]\ndays = [\"someday\"]", "refinery.schedule.days[0]"},
+		{"[refinery]\nstrategi = \"merge\"", "refinery.strategi"},
+		{"[refinery.workers]\ndeny = [\"[\"]", "refinery.workers.deny[0]"},
+		{"[refinery.workers]\ndefault_tier = \"rookie\"", "refinery.workers.default_tier"},
+		{"[refinery.tiers.rookie]\nrequire_approval = true", "refinery.tiers.rookie"},
+		{"[[refinery.checks]]\nname = \"test\"\ncommand = \"true\"\n[[refinery.tiers.new.checks]]\nname = \"test\"\ncommand = \"true\"", "refinery.tiers.new.checks[0].name"},
+		{"[refinery.workers]\nmax_ready = -1", "refinery.workers.max_ready"},
+		{"[refinery.resolver]\ntimeout = \"5m\"", "refinery.resolver.command"},
+		{"[refinery.resolver]\ncommand = \"true\"\nauto_merge_confidence = 80", "refinery.resolver.auto_merge_confidence"},
+		{"[refinery.summary]\ntimeout = \"2m\"", "refinery.summary.command"},
+		{"[refinery.summary]\ncommand = \"true\"\ntimeout = \"later\"", "refinery.summary.timeout"},
+		{"[refinery.review]\nguidelines = \"REVIEWING.md\"", "refinery.review.command"},
+		{"[refinery.review]\ncommand = \"true\"\ntimeout = \"0s\"", "refinery.review.timeout"},
+		{"[refinery.triage]\ntimeout = \"5m\"", "refinery.triage.command"},
+		{"[refinery.admission]\ncommand = \"./admit.sh\"\nurl = \"https://intake.example.com\"", "refinery.admission"},
+		{"[refinery.admission]\ncommand = \"./admit.sh\"\non_error = \"ignore\"", "refinery.admission.on_error"},
+		{"[refinery.risk]\norder = \"high_first\"", "refinery.risk.order"},
+		{"[refinery.notifications]\ndigest = [\"\"]", "refinery.notifications.digest[0]"},
+		{"[refinery.notifications]\ndigest_at = \"6pm\"", "refinery.notifications.digest_at"},
+		{"[refinery.risk]\nhigh = 2.0", "refinery.risk.high"},
+		{"[refinery.risk]\npaths = [\"[\"]", "refinery.risk.paths[0]"},
+		{"[[refinery.integration.checks]]\nname = \"e2e\"", "refinery.integration.checks[0].command"},
+		{"[[refinery.checks]]\nname = \"test\"\ncommand = \"true\"\n[[refinery.integration.checks]]\nname = \"test\"\ncommand = \"true\"", "refinery.integration.checks[0].name"},
+		{"[refinery.integration]\nconflict_scan_interval = \"often\"", "refinery.integration.conflict_scan_interval"},
+		{"[refinery.escalation]\nafter = -1", "refinery.escalation.after"},
+		{"[refinery.issue_gate]", "refinery.issue_gate"},
+		{"[refinery.issue_gate]\nstatus = \"in review\"", "refinery.issue_gate.status"},
+		{"[refinery.signatures]", "refinery.signatures"},
+		{"[refinery.signatures]\nkeys = [\"ABCD\"]", "refinery.signatures.keys[0]"},
+		{"[refinery.protected]\npaths = [\"deploy/\", \"[\"]", "refinery.protected.paths[1]"},
+		{"[refinery.protected]\ncontent = [\"(\"]", "refinery.protected.content[0]"},
+		{"[refinery.sandbox]\nruntime = \"lxc\"", "refinery.sandbox.runtime"},
+		{"[refinery.sandbox]\nruntime = \"docker\"", "refinery.sandbox.image"},
+		{"[refinery.sandbox]\nruntime = \"bwrap\"\nwritable = [\"cache\"]", "refinery.sandbox.writable[0]"},
+		{"[refinery.budget]\nper_worker = true", "refinery.budget"},
+		{"[refinery.budget]\nlines = -1", "refinery.budget.lines"},
+		{"[[refinery.routes]]\nname = \"docs\"", "refinery.routes[0].paths"},
+		{"[[refinery.routes]]\nname = \"docs\"\npaths = [\"docs/\"]\nskip_checks = [\"lint\"]", "refinery.routes[0].skip_checks[0]"},
+		{"[[refinery.routes]]\nname = \"docs\"\npaths = [\"docs/\"]\ntarget = \"docs fast\"", "refinery.routes[0].target"},
+		{"[refinery.backport]\nrules = [{label = \"lts\", branches = [\"release/1.4\"]}]", "refinery.backport.branches"},
+		{"[refinery.backport]\nbranches = [\"release/*\"]\nrules = [{label = \"lts\", branches = [\"main\"]}]", "refinery.backport.rules[0].branches[0]"},
+		{"[refinery.github_queue]\nrepo = \"greenplace\"", "refinery.github_queue.repo"},
+		{"[refinery.changelog]\nentry = \"- {{.Title\"", "refinery.changelog.entry"},
+		{"[refinery.changelog]\nfile = \"../CHANGELOG.md\"", "refinery.changelog.file"},
+		{"[refinery]\nstrategy = \"ff-only\"\n[refinery.changelog]", "refinery.changelog"},
+		{"[refinery.state_sync]\nurl = \"https://example.com/state\"", "refinery.state_sync.url"},
+		{"[refinery.state_sync]\nref = \"refs/heads/state\"", "refinery.state_sync.ref"},
+		{"[refinery.state_sync]\nurl = \"s3://team/gastown\"\nremote = \"upstream\"", "refinery.state_sync.remote"},
+		{"[refinery.maintenance]\nprune_branches = \"0 3 * *\"", "refinery.maintenance.prune_branches"},
+		{"[refinery.maintenance]\ngc_mirror = \"0 25 * * *\"", "refinery.maintenance.gc_mirror"},
+		{"[refinery.maintenance]\nstale_after = \"30d\"", "refinery.maintenance.stale_after"},
+		{"[refinery.maintenance]\nlogs = [\"../town.log\"]", "refinery.maintenance.logs[0]"},
+		{"[[refinery.post_merge]]\nname = \"smoke\"", "refinery.post_merge[0].command"},
+		{"[refinery.quarantine]\nafter = 1", "refinery.quarantine.after"},
+		{"[[refinery.milestones]]\nname = \"build\"", "refinery.milestones[0]"},
+		{"[[refinery.milestones]]\nname = \"build\"\nevery = 10\ndaily = \"18:00\"", "refinery.milestones[0]"},
+		{"[[refinery.milestones]]\nname = \"nightly\"\ndaily = \"6pm\"", "refinery.milestones[0].daily"},
+		{"[[refinery.milestones]]\nname = \"build\"\nevery = 10\nnotes = true", "refinery.milestones[0].notes"},
+		{"[[refinery.deploy]]\nname = \"staging\"\nbranches = [\"main\"]", "refinery.deploy[0]"},
+		{"[[refinery.deploy]]\nname = \"staging\"\nbranches = [\"main\"]\ncommand = \"./deploy.sh\"\nnamespace = \"apps\"", "refinery.deploy[0].namespace"},
+		{"[refinery.github_queue]\nrepo = \"example/greenplace\"\ntoken = \"ghp_abc\"", "refinery.github_queue.token"},
+	}
+	for _, tt := range tests {
+		rigPath := writeRigFile(t, tt.content)
+		_, err := LoadRigFile(RigFilePath(rigPath))
+		var ke *KeyError
+		if !errors.As(err, &ke) {
+			t.Errorf("%q: error = %v, want KeyError", tt.key, err)
+			continue
+		}
+		if ke.Key != tt.key || !strings.HasSuffix(ke.File, RigFileName) {
+			t.Errorf("KeyError = %+v, want key %q", ke, tt.key)
+		}
+	}
+}
+
+func TestLoadRigFile_KeyErrorLines(t *testing.T) {
+	content := `# Refinery settings
+[refinery]
+strategy = "merge"
+
+[[refinery.checks]]
+name = "vet"
+command = "go vet ./..."
+
+[[refinery.checks]]
+name = "test"
+command = "go test ./..."
+timeout = "soon"
+`
+	tests := []struct {
+		content string
+		line    int
+	}{
+		{content, 12}, // Set on line 12
+		{strings.Replace(content, `name = "test"`, "", 1), 9},            // Missing: the line of its table
+		{strings.Replace(content, `name = "vet"`, `nmae = "vet"`, 1), 6}, // Unknown, as reported by the decoder
+		{"[refinery.schedule]\ndays = [\"mon\",\n  \"someday\"]", 2},
+	}
+	for _, tt := range tests {
+		_, err := LoadRigFile(RigFilePath(writeRigFile(t, tt.content)))
+		var ke *KeyError
+		if !errors.As(err, &ke) {
+			t.Errorf("%q: error = %v, want KeyError", tt.content, err)
+			continue
+		}
+		if ke.Line != tt.line {
+			t.Errorf("%s at line %d, want %d", ke.Key, ke.Line, tt.line)
+		}
+		if want := RigFileName + ":" + strconv.Itoa(tt.line) + ": "; !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not name the line", err)
+		}
+	}
+}
+
+func TestRefinerySettings_WorkerApproval(t *testing.T) {
+	tests := []struct {
+		workers *WorkerPolicyConfig
+		worker  string
+		want    bool
+	}{
+		{nil, "anyone", true},
+		{&WorkerPolicyConfig{Deny: []string{"scratch-*"}}, "nux", true},
+		{&WorkerPolicyConfig{Deny: []string{"scratch-*"}}, "scratch-1", false},
+		{&WorkerPolicyConfig{Deny: []string{"scratch-*"}}, "", true},
+		{&WorkerPolicyConfig{Allow: []string{"nux", "crew-*"}}, "nux", true},
+		{&WorkerPolicyConfig{Allow: []string{"nux", "crew-*"}}, "greenplace/crew-max", true},
+		{&WorkerPolicyConfig{Allow: []string{"nux", "crew-*"}}, "toast", false},
+		{&WorkerPolicyConfig{Allow: []string{"nux", "crew-*"}}, "", false},
+		{&WorkerPolicyConfig{Allow: []string{"*"}, Deny: []string{"nux"}}, "nux", false},
+	}
+	for _, tt := range tests {
+		s := &RefinerySettings{Workers: tt.workers}
+		got, reason := s.WorkerApproval(tt.worker)
+		if got != tt.want {
+			t.Errorf("WorkerApproval(%q) with %+v = %v (%s), want %v", tt.worker, tt.workers, got, reason, tt.want)
+		}
+		if !got && reason == "" {
+			t.Errorf("WorkerApproval(%q) rejected without a reason", tt.worker)
+		}
+	}
+}
+
+func TestRefinerySettings_Tiers(t *testing.T) {
+	rigPath := writeRigFile(t, `
+[[refinery.checks]]
+name = "test"
+command = "go test ./..."
+
+[refinery.workers]
+veteran = ["nux"]
+trusted = ["nux", "crew-*"]
+default_tier = "new"
+
+[refinery.tiers.new]
+require_approval = true
+
+[[refinery.tiers.new.checks]]
+name = "integration"
+command = "make integration"
+
+[[refinery.tiers.trusted.checks]]
+name = "lint"
+command = "make lint"
+`)
+	s, err := LoadRefinerySettings(rigPath)
+	if err != nil {
+		t.Fatalf("LoadRefinerySettings: %v", err)
+	}
+
+	tests := []struct {
+		worker   string
+		tier     string
+		approved bool
+		checks   int
+	}{
+		{"nux", TierVeteran, true, 0},
+		{"greenplace/crew-max", TierTrusted, true, 1},
+		{"toast", TierNew, false, 1},
+		{"", TierNew, false, 1},
+	}
+	for _, tt := range tests {
+		if got := s.WorkerTier(tt.worker); got != tt.tier {
+			t.Errorf("WorkerTier(%q) = %q, want %q", tt.worker, got, tt.tier)
+		}
+		if ok, _ := s.WorkerApproval(tt.worker); ok != tt.approved {
+			t.Errorf("WorkerApproval(%q) = %v, want %v", tt.worker, ok, tt.approved)
+		}
+		if got := s.TierChecks(tt.worker); len(got) != tt.checks {
+			t.Errorf("TierChecks(%q) = %v, want %d checks", tt.worker, got, tt.checks)
+		}
+	}
+
+	if tier := (&RefinerySettings{}).WorkerTier("nux"); tier != "" {
+		t.Errorf("WorkerTier without [refinery.workers] = %q, want none", tier)
+	}
+}
+
+func TestScheduleConfig_Allows(t *testing.T) {
+	at := func(day, hhmm string) time.Time {
+		ts, err := time.Parse("2006-01-02 15:04", day+" "+hhmm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts
+	}
+	monday, saturday := "2026-01-05", "2026-01-10"
+
+	sc := &ScheduleConfig{Windows: []string{"22:00-06:00"}, Days: []string{"Mon"}}
+	tests := []struct {
+		t    time.Time
+		want bool
+	}{
+		{at(monday, "23:30"), true},
+		{at(monday, "05:59"), true},
+		{at(monday, "06:00"), false},
+		{at(monday, "12:00"), false},
+		{at(saturday, "23:30"), false},
+	}
+	for _, tt := range tests {
+		if got := sc.Allows(tt.t); got != tt.want {
+			t.Errorf("Allows(%s) = %v, want %v", tt.t, got, tt.want)
+		}
+	}
+
+	var none *ScheduleConfig
+	if !none.Allows(at(saturday, "03:00")) {
+		t.Error("nil schedule should allow any time")
+	}
+}
+
+func TestRefinerySettings_Env(t *testing.T) {
+	rigPath := writeRigFile(t, `
+[refinery.env]
+GOFLAGS = "-mod=mod"
+TOKEN = "${env:GT_TEST_RIG_TOKEN}"
+`)
+	t.Setenv("GT_TEST_RIG_TOKEN", "abc")
+
+	s, err := LoadRefinerySettings(rigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, err := s.ResolveEnv(rigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(env, " ") != "GOFLAGS=-mod=mod TOKEN=abc" {
+		t.Errorf("ResolveEnv = %v", env)
+	}
+
+	for content, key := range map[string]string{
+		"[refinery.env]\n\"1BAD\" = \"x\"":          "refinery.env.1BAD",
+		"[refinery.env]\nKEY = \"${vault:secret}\"": "refinery.env.KEY",
+		"[refinery.env]\nKEY = \"${env:}\"":         "refinery.env.KEY",
+	} {
+		_, err := LoadRigFile(RigFilePath(writeRigFile(t, content)))
+		var ke *KeyError
+		if !errors.As(err, &ke) || ke.Key != key {
+			t.Errorf("%q: error = %v, want KeyError at %s", content, err, key)
+		}
+	}
+}
+
+func TestRefinerySettings_Paths(t *testing.T) {
+	rigPath := writeRigFile(t, `
+[refinery]
+paths = ["services/api", "libs/common"]
+
+[[refinery.checks]]
+name = "test"
+command = "go test ./..."
+
+[[refinery.checks]]
+name = "lint"
+command = "make lint"
+dir = "tools"
+`)
+	s, err := LoadRefinerySettings(rigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Touches([]string{"README.md", "libs/common/x.go"}) {
+		t.Error("diff under libs/common should be in scope")
+	}
+	if s.Touches([]string{"services/apiserver/main.go", "docs/a.md"}) {
+		t.Error("sibling prefix services/apiserver should be out of scope")
+	}
+	if s.CheckDir(s.Checks[0]) != "services/api" || s.CheckDir(s.Checks[1]) != "tools" {
+		t.Errorf("CheckDir = %q, %q", s.CheckDir(s.Checks[0]), s.CheckDir(s.Checks[1]))
+	}
+
+	for _, bad := range []string{"/abs", "../up", "a/../b", "."} {
+		_, err := LoadRigFile(RigFilePath(writeRigFile(t, "[refinery]\npaths = [\""+bad+"\"]")))
+		var ke *KeyError
+		if !errors.As(err, &ke) || ke.Key != "refinery.paths[0]" {
+			t.Errorf("paths = [%q]: error = %v, want KeyError", bad, err)
+		}
+	}
+}
+
+func TestResolverConfig_AutoMerge(t *testing.T) {
+	rc := &ResolverConfig{Command: "true"}
+	if rc.AutoMerge(1) {
+		t.Error("AutoMerge with no threshold = true, want every resolution to need approval")
+	}
+	rc.AutoMergeConfidence = 0.8
+	if !rc.AutoMerge(0.8) || rc.AutoMerge(0.79) {
+		t.Error("AutoMerge does not compare against auto_merge_confidence")
+	}
+}
+
+func TestIssueGateConfig_Admits(t *testing.T) {
+	var none *IssueGateConfig
+	if ok, _ := none.Admits("open", nil); !ok {
+		t.Error("nil gate should admit every issue")
+	}
+	g := &IssueGateConfig{Status: "in_review", Label: "approved"}
+	if ok, _ := g.Admits("in_review", []string{"backend", "approved"}); !ok {
+		t.Error("Admits(in_review, approved) = false, want true")
+	}
+	if ok, why := g.Admits("open", []string{"approved"}); ok || !strings.Contains(why, "status in_review") {
+		t.Errorf("Admits(open) = %v, %q; want waiting on status", ok, why)
+	}
+	if ok, why := g.Admits("in_review", nil); ok || !strings.Contains(why, "label approved") {
+		t.Errorf("Admits(unlabelled) = %v, %q; want waiting on label", ok, why)
+	}
+}
+
+func TestSignatureConfig_Accepts(t *testing.T) {
+	sc := &SignatureConfig{Keys: []string{"4AEE 18F8 3AFD EB23"}}
+	tests := []struct {
+		status, fingerprint string
+		want                bool
+	}{
+		{"G", "SHA256:1nCqyBgBpmx3l3JV+yjidvagjAkQVaItLvgT/snIeUo", true},
+		{"U", "B0D1E2F3A4B5C6D74AEE18F83AFDEB23", true},
+		{"U", "b0d1e2f3a4b5c6d74aee18f83afdeb23", true},
+		{"U", "B0D1E2F3A4B5C6D7A4B5C6D7E8F90011", false},
+		{"U", "", false},
+		{"N", "", false},
+		{"B", "B0D1E2F3A4B5C6D74AEE18F83AFDEB23", false},
+		{"E", "", false},
+	}
+	for _, tt := range tests {
+		if got := sc.Accepts(tt.status, tt.fingerprint); got != tt.want {
+			t.Errorf("Accepts(%q, %q) = %v, want %v", tt.status, tt.fingerprint, got, tt.want)
+		}
+	}
+}
+
+func TestProtectedConfig_MatchPath(t *testing.T) {
+	pc := &ProtectedConfig{Paths: []string{"deploy/", "db/migrations", "config/*.yaml", "*.tf"}}
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"deploy/prod/app.yaml", "deploy/"},
+		{"db/migrations/001_init.sql", "db/migrations"},
+		{"config/prod.yaml", "config/*.yaml"},
+		{"infra/network/main.tf", "*.tf"},
+		{"config/nested/prod.yaml", ""},
+		{"deployment.md", ""},
+		{"src/main.go", ""},
+	}
+	for _, tt := range tests {
+		got, ok := pc.MatchPath(tt.file)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("MatchPath(%q) = %q, %v; want %q", tt.file, got, ok, tt.want)
+		}
+	}
+}
+
+func TestRefinerySettings_Routes(t *testing.T) {
+	s := &RefinerySettings{
+		Checks: []CheckConfig{{Name: "lint", Command: "make lint"}, {Name: "test", Command: "make test"}},
+		Routes: []RouteConfig{
+			{Name: "docs", Paths: []string{"docs/**", "*.md"}, SkipChecks: []string{"test"}},
+			{Name: "payments", Paths: []string{"services/payments/"}, Checks: []CheckConfig{{Name: "heavy", Command: "make heavy"}}},
+		},
+	}
+	routeNames := func(files ...string) []string {
+		var names []string
+		for _, r := range s.MatchRoutes(files) {
+			names = append(names, r.Name)
+		}
+		return names
+	}
+	tests := []struct {
+		files []string
+		want  []string
+	}{
+		{[]string{"docs/guide/intro.md", "README.md"}, []string{"docs"}},
+		{[]string{"docs/guide/intro.md", "main.go"}, nil}, // Mixed: full checks
+		{[]string{"services/payments/ledger.go", "main.go"}, []string{"payments"}},
+		{[]string{"services/payments/README.md"}, []string{"docs", "payments"}},
+		{nil, nil},
+	}
+	for _, tt := range tests {
+		if got := routeNames(tt.files...); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("MatchRoutes(%v) = %v, want %v", tt.files, got, tt.want)
+		}
+	}
+
+	base, extra := s.RoutedChecks([]string{"docs", "payments", "gone"})
+	if len(base) != 1 || base[0].Name != "lint" || len(extra) != 1 || extra[0].Name != "heavy" {
+		t.Errorf("RoutedChecks = %v, %v; want lint, then heavy", base, extra)
+	}
+}
+
+func TestChangelogConfig_Apply(t *testing.T) {
+	c := &ChangelogConfig{}
+	tests := []struct {
+		current, want string
+	}{
+		{"", "# Changelog\n\n## Unreleased\n\n- new\n"},
+		{"# Changelog\n\n## Unreleased\n\n- old\n", "# Changelog\n\n## Unreleased\n\n- new\n- old\n"},
+		{"# Changelog\n\n## 1.0\n\n- old\n", "# Changelog\n\n## Unreleased\n\n- new\n\n## 1.0\n\n- old\n"},
+		{"- old\n", "## Unreleased\n\n- new\n\n- old\n"},
+	}
+	for _, tt := range tests {
+		if got := c.Apply(tt.current, "- new"); got != tt.want {
+			t.Errorf("Apply(%q) = %q, want %q", tt.current, got, tt.want)
+		}
+	}
+	frag := &ChangelogConfig{Fragments: "changes"}
+	if got := frag.Path("mr-1"); got != "changes/mr-1.md" {
+		t.Errorf("fragment path = %q", got)
+	}
+	if got := frag.Apply("ignored", "- new"); got != "- new\n" {
+		t.Errorf("fragment = %q", got)
+	}
+	if got, err := c.Render(ChangelogEntry{Title: "Fix login", Issue: "gt-1"}); err != nil || got != "- Fix login (gt-1)" {
+		t.Errorf("Render = %q, %v", got, err)
+	}
+}
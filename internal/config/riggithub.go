@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// DefaultGitHubAPI is the API GitHubQueueConfig talks to unless api is set.
+const DefaultGitHubAPI = "https://api.github.com"
+
+// GitHubQueueConfig is [refinery.github_queue]: instead of pushing merges
+// itself, the refinery validates each MR as usual (conflicts, checks,
+// review) and then adds the branch's pull request to GitHub's native merge
+// queue, which does the merging. The MR stays queued until GitHub reports
+// the pull request merged or dropped from its queue.
+type GitHubQueueConfig struct {
+	// Repo is the GitHub repository, "owner/name".
+	Repo string `toml:"repo"`
+
+	// Token is an ${env:NAME}, ${file:path}, or ${secret:name} reference,
+	// as in [refinery.env], to a token that may enqueue pull requests.
+	// Defaults to ${env:GITHUB_TOKEN}.
+	Token string `toml:"token"`
+
+	// API is the REST API base URL, for GitHub Enterprise Server
+	// ("https://github.example.com/api/v3"). Defaults to DefaultGitHubAPI.
+	API string `toml:"api"`
+}
+
+// Owner returns the owner part of Repo.
+func (g *GitHubQueueConfig) Owner() string {
+	owner, _, _ := strings.Cut(g.Repo, "/")
+	return owner
+}
+
+// Name returns the repository part of Repo.
+func (g *GitHubQueueConfig) Name() string {
+	_, name, _ := strings.Cut(g.Repo, "/")
+	return name
+}
+
+// APIURL returns API, or DefaultGitHubAPI when unset.
+func (g *GitHubQueueConfig) APIURL() string {
+	if g.API == "" {
+		return DefaultGitHubAPI
+	}
+	return strings.TrimRight(g.API, "/")
+}
+
+// ResolveToken resolves Token. Like [refinery.env] values, the token is
+// registered with util.RegisterSecret so it is redacted from logs.
+func (g *GitHubQueueConfig) ResolveToken(rigPath string) (string, error) {
+	ref := g.Token
+	if ref == "" {
+		ref = "${env:GITHUB_TOKEN}"
+	}
+	kind, arg, _ := parseEnvRef(ref)
+	token, err := resolveEnvRef(rigPath, kind, arg)
+	if err != nil {
+		return "", fmt.Errorf("github_queue.token: %w", err)
+	}
+	util.RegisterSecret(token)
+	return token, nil
+}
+
+func (g *GitHubQueueConfig) validate(keyErr keyErrFunc) error {
+	owner, name, ok := strings.Cut(g.Repo, "/")
+	if !ok || owner == "" || name == "" || strings.ContainsAny(name, "/ ") {
+		return keyErr("github_queue.repo", "want owner/name, got %q", g.Repo)
+	}
+	if g.Token != "" {
+		if _, _, ok := parseEnvRef(g.Token); !ok {
+			return keyErr("github_queue.token", "must be an ${env:...}, ${file:...}, or ${secret:...} reference; keep tokens out of rig.toml")
+		}
+		if err := validateEnvEntry("token", g.Token); err != nil {
+			return keyErr("github_queue.token", "%v", err)
+		}
+	}
+	if g.API != "" {
+		u, err := url.Parse(g.API)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return keyErr("github_queue.api", "invalid URL %q", g.API)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// RepoRigFileName is the rig configuration a project can commit at its
+// repository root. It uses the settings/rig.toml schema and is imported
+// when the rig is cloned, so a repo carries its own checks and strategy.
+const RepoRigFileName = "gastown.toml"
+
+// ErrRepoRigFileYAML is returned when a repository ships only a YAML rig
+// file. Rig configuration is TOML throughout; the file has to be converted.
+var ErrRepoRigFileYAML = errors.New("gastown.yaml is not supported; convert it to " + RepoRigFileName)
+
+// ImportRepoRigFile validates repoDir/gastown.toml and copies it to the
+// rig's settings/rig.toml. Returns the imported path, or "" if the repo has
+// no rig file. An existing settings/rig.toml is never overwritten.
+func ImportRepoRigFile(rigPath, repoDir string) (string, error) {
+	src := filepath.Join(repoDir, RepoRigFileName)
+	data, err := os.ReadFile(src) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return "", fmt.Errorf("reading %s: %w", RepoRigFileName, err)
+		}
+		for _, name := range []string{"gastown.yaml", "gastown.yml"} {
+			if _, err := os.Stat(filepath.Join(repoDir, name)); err == nil {
+				return "", ErrRepoRigFileYAML
+			}
+		}
+		return "", nil
+	}
+
+	if _, err := LoadRigFile(src); err != nil {
+		return "", err
+	}
+
+	dst := RigFilePath(rigPath)
+	if _, err := os.Stat(dst); err == nil {
+		return "", fmt.Errorf("%s already exists", dst)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return "", fmt.Errorf("creating settings directory: %w", err)
+	}
+	if err := os.WriteFile(dst, data, 0644); err != nil {
+		return "", err
+	}
+	return src, nil
+}
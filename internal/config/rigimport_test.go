@@ -0,0 +1,53 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestImportRepoRigFile(t *testing.T) {
+	repoDir := t.TempDir()
+	rigPath := t.TempDir()
+
+	if src, err := ImportRepoRigFile(rigPath, repoDir); src != "" || err != nil {
+		t.Fatalf("repo without rig file = %q, %v; want nothing imported", src, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(repoDir, "gastown.yaml"), []byte("refinery: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ImportRepoRigFile(rigPath, repoDir); !errors.Is(err, ErrRepoRigFileYAML) {
+		t.Errorf("YAML-only repo = %v, want ErrRepoRigFileYAML", err)
+	}
+
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(repoDir, RepoRigFileName), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("[refinery]\nstrategy = \"rebase\"\n")
+	var ke *KeyError
+	if _, err := ImportRepoRigFile(rigPath, repoDir); !errors.As(err, &ke) {
+		t.Errorf("invalid rig file = %v, want *KeyError", err)
+	}
+	if _, err := os.Stat(RigFilePath(rigPath)); !os.IsNotExist(err) {
+		t.Error("invalid rig file was imported")
+	}
+
+	write("[refinery]\nstrategy = \"squash\"\n\n[[refinery.checks]]\nname = \"test\"\ncommand = \"make test\"\n")
+	if src, err := ImportRepoRigFile(rigPath, repoDir); err != nil || src == "" {
+		t.Fatalf("ImportRepoRigFile = %q, %v", src, err)
+	}
+	s, err := LoadRefinerySettings(rigPath)
+	if err != nil || s == nil || s.Strategy != StrategySquash || len(s.Checks) != 1 {
+		t.Errorf("imported settings = %+v, %v", s, err)
+	}
+
+	if _, err := ImportRepoRigFile(rigPath, repoDir); err == nil {
+		t.Error("existing rig.toml should not be overwritten")
+	}
+}
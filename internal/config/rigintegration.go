@@ -0,0 +1,46 @@
+package config
+
+import "time"
+
+// DefaultConflictScanInterval is how often 'gt refinery conflicts' rescans
+// the queue when [refinery.integration] conflict_scan_interval is unset.
+const DefaultConflictScanInterval = 15 * time.Minute
+
+// IntegrationConfig is [refinery.integration]: how the refinery treats a
+// swarm's integration/<epic> branch, which the epic's child issues merge
+// into before the swarm lands on the target as a whole.
+type IntegrationConfig struct {
+	// Checks run, after the [refinery] checks, on every merge into an
+	// integration branch and on the integration branch's own landing.
+	Checks []CheckConfig `toml:"checks"`
+
+	// AutoLand enqueues the integration branch for the target once every
+	// child issue of its epic is closed and no queued MR still targets it.
+	AutoLand bool `toml:"auto_land"`
+
+	// RequireApproval parks an auto_land landing until an operator runs
+	// 'gt refinery approve'.
+	RequireApproval bool `toml:"require_approval"`
+
+	// ConflictScanInterval is the least time between test merges of queued
+	// branches from different swarms, e.g. "10m".
+	ConflictScanInterval string `toml:"conflict_scan_interval"`
+}
+
+// ScanInterval returns ConflictScanInterval, or the default if unset.
+func (ic *IntegrationConfig) ScanInterval() time.Duration {
+	if ic == nil || ic.ConflictScanInterval == "" {
+		return DefaultConflictScanInterval
+	}
+	d, _ := time.ParseDuration(ic.ConflictScanInterval) // Validated on load
+	return d
+}
+
+func (ic *IntegrationConfig) validate(names map[string]bool, keyErr keyErrFunc) error {
+	if ic.ConflictScanInterval != "" {
+		if d, err := time.ParseDuration(ic.ConflictScanInterval); err != nil || d < 0 {
+			return keyErr("integration.conflict_scan_interval", "invalid duration %q", ic.ConflictScanInterval)
+		}
+	}
+	return validateChecks(ic.Checks, "integration.checks", names, keyErr)
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// IssueGateConfig is [refinery.issue_gate]: an MR merges only once its
+// source issue is in Status and carries Label, so reviewers approve work
+// in the issue tracker rather than with 'gt refinery approve'.
+type IssueGateConfig struct {
+	// Status is the beads status the source issue must be in, such as
+	// "in_review". Empty accepts any status the refinery would otherwise
+	// merge.
+	Status string `toml:"status"`
+
+	// Label is a label the source issue must carry, such as "approved",
+	// recording a reviewer's sign-off. Empty requires no label.
+	Label string `toml:"label"`
+}
+
+// Admits reports whether an issue with this status and these labels may
+// merge, and if not, what it is waiting for.
+func (g *IssueGateConfig) Admits(status string, labels []string) (bool, string) {
+	if g == nil {
+		return true, ""
+	}
+	var missing []string
+	if g.Status != "" && status != g.Status {
+		missing = append(missing, fmt.Sprintf("status %s (is %s)", g.Status, status))
+	}
+	if g.Label != "" && !slices.Contains(labels, g.Label) {
+		missing = append(missing, "label "+g.Label)
+	}
+	if len(missing) == 0 {
+		return true, ""
+	}
+	return false, "issue needs " + strings.Join(missing, " and ")
+}
+
+func (g *IssueGateConfig) validate(keyErr keyErrFunc) error {
+	if g.Status == "" && g.Label == "" {
+		return keyErr("issue_gate", "needs a status or a label")
+	}
+	if strings.ContainsAny(g.Status, " \t\n") {
+		return keyErr("issue_gate.status", "invalid status %q", g.Status)
+	}
+	if strings.ContainsAny(g.Label, " \t\n") {
+		return keyErr("issue_gate.label", "invalid label %q", g.Label)
+	}
+	return nil
+}
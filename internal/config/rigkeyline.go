@@ -0,0 +1,101 @@
+package config
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// indexRe matches the array indexes in a KeyError path.
+var indexRe = regexp.MustCompile(`\[\d+\]`)
+
+// KeyLine returns the line of a rig.toml that sets key, a KeyError path
+// such as "refinery.checks[1].timeout". A key that is not in the file, a
+// required one say, gets the line of the nearest table or key above it,
+// and 0 if there is none. Keys inside inline tables and multi-line values
+// are not seen.
+//
+// Paths from the TOML decoder carry no indexes ("refinery.checks.nmae");
+// those match the first array table that has the key.
+func KeyLine(data []byte, key string) int {
+	exact := make(map[string]int) // Key, with array table indexes, to line
+	loose := make(map[string]int) // Key without indexes to its first line
+	record := func(k string, line int) {
+		if _, ok := exact[k]; !ok {
+			exact[k] = line
+		}
+		if k = indexRe.ReplaceAllString(k, ""); loose[k] == 0 {
+			loose[k] = line
+		}
+	}
+
+	arrays := make(map[string]int)
+	table := ""
+	for i, raw := range strings.Split(string(data), "\n") {
+		line := strings.TrimSpace(raw)
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "[["):
+			end := strings.Index(line, "]]")
+			if end < 0 {
+				continue
+			}
+			name := tomlKey(line[2:end])
+			table = name + "[" + strconv.Itoa(arrays[name]) + "]"
+			arrays[name]++
+			record(table, i+1)
+		case strings.HasPrefix(line, "["):
+			end := strings.Index(line, "]")
+			if end < 0 {
+				continue
+			}
+			table = tomlKey(line[1:end])
+			record(table, i+1)
+		default:
+			k, _, ok := strings.Cut(line, "=")
+			if !ok {
+				continue
+			}
+			if k = tomlKey(k); table != "" {
+				k = table + "." + k
+			}
+			record(k, i+1)
+		}
+	}
+
+	lines := exact
+	if !strings.Contains(key, "[") {
+		lines = loose
+	}
+	for k := key; k != ""; k = parentKey(k) {
+		if n, ok := lines[k]; ok {
+			return n
+		}
+	}
+	return 0
+}
+
+// tomlKey normalizes a dotted TOML key: no spaces around the dots and no
+// quotes around its parts.
+func tomlKey(k string) string {
+	parts := strings.Split(k, ".")
+	for i, p := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(p), `"'`)
+	}
+	return strings.Join(parts, ".")
+}
+
+// parentKey returns the path above a KeyError path: "a.b[1].c" → "a.b[1]"
+// → "a.b" → "a" → "".
+func parentKey(k string) string {
+	if strings.HasSuffix(k, "]") {
+		if i := strings.LastIndex(k, "["); i >= 0 {
+			return k[:i]
+		}
+	}
+	if i := strings.LastIndex(k, "."); i >= 0 {
+		return k[:i]
+	}
+	return ""
+}
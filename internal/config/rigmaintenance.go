@@ -0,0 +1,156 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Maintenance defaults.
+const (
+	DefaultMaintenanceBranches = "polecat/*"
+	DefaultKeepHistory         = 90 * 24 * time.Hour
+	DefaultKeepLogs            = 5
+)
+
+// DefaultMaintenanceLogs are the rig-relative log files rotate_logs
+// rotates unless logs is set.
+var DefaultMaintenanceLogs = []string{".beads/*.log", ".runtime/*.log"}
+
+// MaintenanceConfig is [refinery.maintenance]: housekeeping the refinery
+// runs on cron schedules (see ParseCron), each job only when its
+// expression is set. 'gt refinery maintain', which the patrol runs every
+// cycle, runs whichever jobs have come due since they last ran.
+type MaintenanceConfig struct {
+	// PruneBranches deletes branches on origin matching Branches that are
+	// already on the default branch, or whose tip is older than StaleAfter.
+	// Branches queued for merge, checked out, or owned by a working polecat
+	// are left alone.
+	PruneBranches string   `toml:"prune_branches"`
+	Branches      []string `toml:"branches"`    // Default DefaultMaintenanceBranches
+	StaleAfter    string   `toml:"stale_after"` // e.g. "720h"; empty prunes merged branches only
+
+	// GCMirror runs git gc in the bare mirror conflict checks use.
+	GCMirror string `toml:"gc_mirror"`
+
+	// CompactHistory moves merge queue events older than KeepHistory
+	// (default 90 days) out of the event log into a gzipped archive under
+	// .runtime/history.
+	CompactHistory string `toml:"compact_history"`
+	KeepHistory    string `toml:"keep_history"`
+
+	// RotateLogs rotates the rig-relative log files matching Logs (default
+	// DefaultMaintenanceLogs): each is copied to <file>.1, older copies
+	// shift up to KeepLogs (default DefaultKeepLogs), and it is truncated
+	// in place so processes holding it open keep writing to it.
+	RotateLogs string   `toml:"rotate_logs"`
+	Logs       []string `toml:"logs"`
+	KeepLogs   int      `toml:"keep_logs"`
+}
+
+// Maintenance job names, as keys in maintenance state and output.
+const (
+	MaintenancePruneBranches  = "prune_branches"
+	MaintenanceGCMirror       = "gc_mirror"
+	MaintenanceCompactHistory = "compact_history"
+	MaintenanceRotateLogs     = "rotate_logs"
+)
+
+// MaintenanceJob is a maintenance job and its cron expression.
+type MaintenanceJob struct {
+	Name     string
+	Schedule string
+}
+
+// Jobs returns the configured jobs, in the order they run.
+func (mc *MaintenanceConfig) Jobs() []MaintenanceJob {
+	var jobs []MaintenanceJob
+	for _, j := range []MaintenanceJob{
+		{MaintenancePruneBranches, mc.PruneBranches},
+		{MaintenanceGCMirror, mc.GCMirror},
+		{MaintenanceCompactHistory, mc.CompactHistory},
+		{MaintenanceRotateLogs, mc.RotateLogs},
+	} {
+		if j.Schedule != "" {
+			jobs = append(jobs, j)
+		}
+	}
+	return jobs
+}
+
+// BranchPatterns returns Branches, defaulting to DefaultMaintenanceBranches.
+func (mc *MaintenanceConfig) BranchPatterns() []string {
+	if len(mc.Branches) > 0 {
+		return mc.Branches
+	}
+	return []string{DefaultMaintenanceBranches}
+}
+
+// StaleDuration returns StaleAfter, or 0 if unset.
+func (mc *MaintenanceConfig) StaleDuration() time.Duration {
+	d, _ := time.ParseDuration(mc.StaleAfter) // Validated on load
+	return d
+}
+
+// KeepHistoryDuration returns KeepHistory, defaulting to DefaultKeepHistory.
+func (mc *MaintenanceConfig) KeepHistoryDuration() time.Duration {
+	if d, err := time.ParseDuration(mc.KeepHistory); err == nil && d > 0 {
+		return d
+	}
+	return DefaultKeepHistory
+}
+
+// LogPatterns returns Logs, defaulting to DefaultMaintenanceLogs.
+func (mc *MaintenanceConfig) LogPatterns() []string {
+	if len(mc.Logs) > 0 {
+		return mc.Logs
+	}
+	return DefaultMaintenanceLogs
+}
+
+// KeepLogCount returns KeepLogs, defaulting to DefaultKeepLogs.
+func (mc *MaintenanceConfig) KeepLogCount() int {
+	if mc.KeepLogs > 0 {
+		return mc.KeepLogs
+	}
+	return DefaultKeepLogs
+}
+
+func (mc *MaintenanceConfig) validate(keyErr keyErrFunc) error {
+	for _, j := range mc.Jobs() {
+		if _, err := ParseCron(j.Schedule); err != nil {
+			return keyErr("maintenance."+j.Name, "%v", err)
+		}
+	}
+	for i, p := range mc.Branches {
+		// Patterns are fetched as refspecs, which take one * and no ? or [
+		if strings.TrimSpace(p) == "" || strings.Count(p, "*") > 1 || strings.ContainsAny(p, "?[") {
+			return keyErr(fmt.Sprintf("maintenance.branches[%d]", i), "invalid pattern %q (want a branch name with at most one *)", p)
+		}
+	}
+	if mc.StaleAfter != "" {
+		if d, err := time.ParseDuration(mc.StaleAfter); err != nil || d <= 0 {
+			return keyErr("maintenance.stale_after", "invalid duration %q", mc.StaleAfter)
+		}
+	}
+	if mc.KeepHistory != "" {
+		if d, err := time.ParseDuration(mc.KeepHistory); err != nil || d <= 0 {
+			return keyErr("maintenance.keep_history", "invalid duration %q", mc.KeepHistory)
+		}
+	}
+	for i, p := range mc.Logs {
+		key := fmt.Sprintf("maintenance.logs[%d]", i)
+		if filepath.IsAbs(p) || strings.HasPrefix(path.Clean(p), "..") {
+			return keyErr(key, "must be rig-relative, got %q", p)
+		}
+		if _, err := path.Match(p, ""); err != nil {
+			return keyErr(key, "invalid pattern %q", p)
+		}
+	}
+	if mc.KeepLogs < 0 {
+		return keyErr("maintenance.keep_logs", "must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultMilestoneTag names a milestone's tags if tag is unset.
+const DefaultMilestoneTag = "{{.Name}}-{{.Seq}}"
+
+// MilestoneConfig is one [[refinery.milestones]] entry: a rule that tags
+// the target with an annotated tag when its condition is met. Exactly one
+// of Every, Daily, and Epic is set.
+type MilestoneConfig struct {
+	// Name identifies the rule; its state is kept under it.
+	Name string `toml:"name"`
+
+	// Every tags the Nth merge into Target since the rule's last tag.
+	Every int `toml:"every"`
+
+	// Daily ("HH:MM", local) tags the latest merge into Target once a day
+	// after that time, if anything merged since the last tag.
+	Daily string `toml:"daily"`
+
+	// Epic tags the landing of the swarm for this epic, once.
+	Epic string `toml:"epic"`
+
+	// Target is the branch whose merges count. Defaults to the rig's
+	// default branch; unused with Epic.
+	Target string `toml:"target"`
+
+	// Tag is a text/template for the tag name, given a MilestoneTag.
+	// Defaults to DefaultMilestoneTag.
+	Tag string `toml:"tag"`
+
+	// Notes mails release notes for the tag's range of merges to
+	// refinery.notifications.digest, written by digest_command if set.
+	Notes bool `toml:"notes"`
+}
+
+// MilestoneTag is what a Tag template is given.
+type MilestoneTag struct {
+	Name   string
+	Seq    int    // 1 for the rule's first tag
+	Date   string // YYYY-MM-DD
+	Epic   string
+	Target string
+	Merges int // Merges since the rule's last tag
+}
+
+// TagName renders the tag name for t.
+func (m *MilestoneConfig) TagName(t MilestoneTag) (string, error) {
+	tmpl, err := m.template()
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, t); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+func (m *MilestoneConfig) template() (*template.Template, error) {
+	tag := m.Tag
+	if tag == "" {
+		tag = DefaultMilestoneTag
+	}
+	return template.New("tag").Option("missingkey=error").Parse(tag)
+}
+
+// DailyMinute returns Daily in minutes since midnight.
+func (m *MilestoneConfig) DailyMinute() int {
+	t, err := time.Parse("15:04", m.Daily)
+	if err != nil {
+		return 0
+	}
+	return t.Hour()*60 + t.Minute()
+}
+
+// validateMilestones checks [[refinery.milestones]].
+func (s *RefinerySettings) validateMilestones(keyErr keyErrFunc) error {
+	seen := make(map[string]bool)
+	for i, m := range s.Milestones {
+		key := fmt.Sprintf("milestones[%d]", i)
+		if strings.TrimSpace(m.Name) == "" {
+			return keyErr(key+".name", "required")
+		}
+		if seen[m.Name] {
+			return keyErr(key+".name", "duplicate milestone %q", m.Name)
+		}
+		seen[m.Name] = true
+
+		set := 0
+		if m.Every != 0 {
+			set++
+			if m.Every < 0 {
+				return keyErr(key+".every", "must be positive, got %d", m.Every)
+			}
+		}
+		if m.Daily != "" {
+			set++
+			if _, err := time.Parse("15:04", m.Daily); err != nil {
+				return keyErr(key+".daily", "got %q, want HH:MM", m.Daily)
+			}
+		}
+		if m.Epic != "" {
+			set++
+			if m.Target != "" {
+				return keyErr(key+".target", "not used with epic; the tag goes on the swarm's landing")
+			}
+		}
+		if set != 1 {
+			return keyErr(key, "set exactly one of every, daily, or epic")
+		}
+		if m.Target != "" && (strings.ContainsAny(m.Target, " \t\n~^:?*[\\") || strings.HasPrefix(m.Target, "-")) {
+			return keyErr(key+".target", "invalid branch name %q", m.Target)
+		}
+		if _, err := m.template(); err != nil {
+			return keyErr(key+".tag", "%v", err)
+		}
+		if m.Notes && (s.Notifications == nil || len(s.Notifications.Digest) == 0) {
+			return keyErr(key+".notes", "needs refinery.notifications.digest recipients")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// ProtectedConfig is [refinery.protected]: branches that touch sensitive
+// paths (deploy configs, migrations, vendored code) or add lines matching
+// sensitive patterns wait for 'gt refinery approve' instead of merging
+// automatically.
+type ProtectedConfig struct {
+	// Paths are repo-relative directories or path.Match globs. A directory
+	// protects everything under it; a glob without a slash also matches
+	// file names at any depth ("*.tf").
+	Paths []string `toml:"paths"`
+
+	// Content are regular expressions matched against each line a branch
+	// adds.
+	Content []string `toml:"content"`
+}
+
+// MatchPath returns the entry of Paths that protects file, if any.
+func (pc *ProtectedConfig) MatchPath(file string) (string, bool) {
+	return matchPath(pc.Paths, file)
+}
+
+// matchPath returns the entry of paths that file falls under. Entries are
+// repo-relative directories ("docs/" or "docs/**") or path.Match globs; a
+// glob without a slash also matches file names at any depth.
+func matchPath(paths []string, file string) (string, bool) {
+	for _, p := range paths {
+		dir := strings.TrimSuffix(strings.TrimSuffix(p, "/**"), "/")
+		if file == dir || strings.HasPrefix(file, dir+"/") {
+			return p, true
+		}
+		if ok, _ := path.Match(p, file); ok {
+			return p, true
+		}
+		if !strings.Contains(p, "/") {
+			if ok, _ := path.Match(p, path.Base(file)); ok {
+				return p, true
+			}
+		}
+	}
+	return "", false
+}
+
+// ContentPatterns compiles Content. validate has already rejected bad
+// expressions, so errors only come from settings built in code.
+func (pc *ProtectedConfig) ContentPatterns() ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(pc.Content))
+	for _, c := range pc.Content {
+		re, err := regexp.Compile(c)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+func (pc *ProtectedConfig) validate(keyErr keyErrFunc) error {
+	if err := validatePaths(pc.Paths, "protected.paths", keyErr); err != nil {
+		return err
+	}
+	for i, c := range pc.Content {
+		if _, err := regexp.Compile(c); err != nil {
+			return keyErr(fmt.Sprintf("protected.content[%d]", i), "bad regular expression: %v", err)
+		}
+	}
+	return nil
+}
+
+// validatePaths checks entries for matchPath listed under key.
+func validatePaths(paths []string, key string, keyErr keyErrFunc) error {
+	for i, p := range paths {
+		if strings.TrimSpace(p) == "" {
+			return keyErr(fmt.Sprintf("%s[%d]", key, i), "empty path")
+		}
+		if _, err := path.Match(p, ""); err != nil {
+			return keyErr(fmt.Sprintf("%s[%d]", key, i), "bad pattern %q: %v", p, err)
+		}
+	}
+	return nil
+}
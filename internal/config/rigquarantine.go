@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultQuarantineAfter is how many different MRs in a row may fail their
+// checks before the refinery quarantines the rig, when [refinery.quarantine]
+// after is unset.
+const DefaultQuarantineAfter = 3
+
+// QuarantineConfig is [refinery.quarantine]: when MR after MR fails its
+// checks, the target or the environment is more likely broken than the
+// branches, so the refinery pauses the rig and raises an alert instead of
+// failing the rest of the queue.
+type QuarantineConfig struct {
+	// After is how many different MRs in a row may fail their checks; the
+	// last of them quarantines the rig. Zero means DefaultQuarantineAfter.
+	After int `toml:"after"`
+
+	// Alert lists the addresses sent the alert. Empty means "overseer".
+	Alert []string `toml:"alert"`
+}
+
+// Threshold returns After, or the default if unset.
+func (qc *QuarantineConfig) Threshold() int {
+	if qc.After == 0 {
+		return DefaultQuarantineAfter
+	}
+	return qc.After
+}
+
+// Recipients returns Alert, or the overseer if unset.
+func (qc *QuarantineConfig) Recipients() []string {
+	if len(qc.Alert) == 0 {
+		return []string{"overseer"}
+	}
+	return qc.Alert
+}
+
+func (qc *QuarantineConfig) validate(keyErr keyErrFunc) error {
+	if qc.After < 0 || qc.After == 1 {
+		return keyErr("quarantine.after", "must be at least 2, got %d", qc.After)
+	}
+	for i, addr := range qc.Alert {
+		if strings.TrimSpace(addr) == "" {
+			return keyErr(fmt.Sprintf("quarantine.alert[%d]", i), "empty address")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+// ResolverConfig is [refinery.resolver]: an agent the refinery hands rebase
+// conflicts to before falling back to a resolution task for a polecat.
+//
+// The command runs with sh -c in a scratch worktree stopped mid-rebase, once
+// per conflicting commit. $GT_RESOLVE_CONTEXT names a JSON file describing
+// the conflict; the agent edits the conflicted files and may write
+// {"confidence": 0.9, "summary": "..."} to $GT_RESOLVE_RESULT.
+type ResolverConfig struct {
+	Command string `toml:"command"`
+	Timeout string `toml:"timeout,omitempty"` // Per conflicting commit; empty means no limit
+
+	// AutoMergeConfidence is the lowest reported confidence that merges
+	// without an operator's approval. Zero (the default) means every
+	// resolution waits for approval.
+	AutoMergeConfidence float64 `toml:"auto_merge_confidence"`
+}
+
+// TimeoutDuration returns the parsed timeout, or 0 for no limit.
+func (rc *ResolverConfig) TimeoutDuration() time.Duration {
+	d, _ := time.ParseDuration(rc.Timeout)
+	return d
+}
+
+// AutoMerge reports whether a resolution the agent rated confidence may
+// merge without approval.
+func (rc *ResolverConfig) AutoMerge(confidence float64) bool {
+	return rc.AutoMergeConfidence > 0 && confidence >= rc.AutoMergeConfidence
+}
+
+func (rc *ResolverConfig) validate(keyErr keyErrFunc) error {
+	if strings.TrimSpace(rc.Command) == "" {
+		return keyErr("resolver.command", "required")
+	}
+	if rc.Timeout != "" {
+		if d, err := time.ParseDuration(rc.Timeout); err != nil || d <= 0 {
+			return keyErr("resolver.timeout", "invalid duration %q", rc.Timeout)
+		}
+	}
+	if rc.AutoMergeConfidence < 0 || rc.AutoMergeConfidence > 1 {
+		return keyErr("resolver.auto_merge_confidence", "got %g, want 0 to 1", rc.AutoMergeConfidence)
+	}
+	return nil
+}
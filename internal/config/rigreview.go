@@ -0,0 +1,57 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Review verdicts a [refinery.review] reviewer may report.
+const (
+	ReviewApprove        = "approve"
+	ReviewComment        = "comment"         // Findings worth reading, but the merge goes ahead
+	ReviewRequestChanges = "request_changes" // Blocks the merge
+)
+
+// ReviewConfig is [refinery.review]: a reviewer agent that reads each MR's
+// diff after its checks pass, and blocks the merge if it requests changes.
+//
+// The command runs with sh -c in the refinery's worktree. $GT_REVIEW_DIFF
+// names the branch's diff against the target, $GT_REVIEW_GUIDELINES the
+// rig's review guidelines (if any), and $GT_REVIEW_CONTEXT a JSON file
+// describing the MR. The reviewer must write {"verdict": "approve",
+// "summary": "...", "findings": [...]} to $GT_REVIEW_RESULT.
+type ReviewConfig struct {
+	Command string `toml:"command"`
+	Timeout string `toml:"timeout,omitempty"` // Empty means no limit
+
+	// Guidelines is a file of rig-specific review guidelines, relative to
+	// settings/.
+	Guidelines string `toml:"guidelines,omitempty"`
+}
+
+// TimeoutDuration returns the parsed timeout, or 0 for no limit.
+func (rc *ReviewConfig) TimeoutDuration() time.Duration {
+	d, _ := time.ParseDuration(rc.Timeout)
+	return d
+}
+
+// GuidelinesFile returns the path of Guidelines for the rig, or "".
+func (rc *ReviewConfig) GuidelinesFile(rigPath string) string {
+	if rc.Guidelines == "" || filepath.IsAbs(rc.Guidelines) {
+		return rc.Guidelines
+	}
+	return filepath.Join(filepath.Dir(RigFilePath(rigPath)), rc.Guidelines)
+}
+
+func (rc *ReviewConfig) validate(keyErr keyErrFunc) error {
+	if strings.TrimSpace(rc.Command) == "" {
+		return keyErr("review.command", "required")
+	}
+	if rc.Timeout != "" {
+		if d, err := time.ParseDuration(rc.Timeout); err != nil || d <= 0 {
+			return keyErr("review.timeout", "invalid duration %q", rc.Timeout)
+		}
+	}
+	return nil
+}
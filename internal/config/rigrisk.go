@@ -0,0 +1,71 @@
+package config
+
+// Queue orders for [refinery.risk] order.
+const (
+	RiskOrderLowFirst = "low_first" // Lowest risk first
+	RiskOrderBatch    = "batch"     // Low-risk MRs in queue order, then high-risk ones
+)
+
+// Defaults for [refinery.risk].
+const (
+	DefaultRiskHigh  = 0.5
+	DefaultRiskLines = 500
+)
+
+// RiskConfig is [refinery.risk]: how the refinery scores the risk of each
+// MR, from 0 to 1, and whether it orders the queue by that score. The
+// score weighs the size of the diff, whether it touches risky paths, how
+// often the worker's merges have failed lately, and how much of the
+// changed code has no tests beside it.
+type RiskConfig struct {
+	// Order is "low_first" or "batch"; empty scores MRs (for 'gt refinery
+	// risk') without changing the queue order.
+	Order string `toml:"order"`
+
+	// High is the score at and above which an MR is high risk. Zero means
+	// DefaultRiskHigh.
+	High float64 `toml:"high"`
+
+	// Lines is how many changed lines make a diff as large as it gets for
+	// scoring. Zero means DefaultRiskLines.
+	Lines int `toml:"lines"`
+
+	// Paths are risky paths, matched like protected.paths.
+	Paths []string `toml:"paths"`
+}
+
+// HighThreshold returns High, or the default if unset.
+func (rc *RiskConfig) HighThreshold() float64 {
+	if rc.High == 0 {
+		return DefaultRiskHigh
+	}
+	return rc.High
+}
+
+// LargeLines returns Lines, or the default if unset.
+func (rc *RiskConfig) LargeLines() int {
+	if rc.Lines == 0 {
+		return DefaultRiskLines
+	}
+	return rc.Lines
+}
+
+// MatchPath returns the entry of Paths that file falls under, if any.
+func (rc *RiskConfig) MatchPath(file string) (string, bool) {
+	return matchPath(rc.Paths, file)
+}
+
+func (rc *RiskConfig) validate(keyErr keyErrFunc) error {
+	switch rc.Order {
+	case "", RiskOrderLowFirst, RiskOrderBatch:
+	default:
+		return keyErr("risk.order", "got %q, want %q or %q", rc.Order, RiskOrderLowFirst, RiskOrderBatch)
+	}
+	if rc.High < 0 || rc.High > 1 {
+		return keyErr("risk.high", "got %g, want 0 to 1", rc.High)
+	}
+	if rc.Lines < 0 {
+		return keyErr("risk.lines", "must not be negative, got %d", rc.Lines)
+	}
+	return validatePaths(rc.Paths, "risk.paths", keyErr)
+}
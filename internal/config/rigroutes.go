@@ -0,0 +1,133 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RouteConfig is one [[refinery.routes]] entry: MRs whose diff touches
+// Paths, as it stands when they are queued, are routed to another target
+// or run a different set of checks. A route that retargets or skips
+// checks applies only when every file the MR touches matches, so mixed
+// changes keep the full checks; one that only adds checks applies when
+// any file matches.
+type RouteConfig struct {
+	// Name identifies the route on MRs and in 'gt refinery queue'.
+	Name string `toml:"name"`
+
+	// Paths are repo-relative directories or path.Match globs, as in
+	// [refinery.protected]; "docs/**" means the docs directory.
+	Paths []string `toml:"paths"`
+
+	// Target sends MRs queued for the rig's default branch to this branch
+	// instead, which merges in its own lane.
+	Target string `toml:"target"`
+
+	// SkipChecks names refinery.checks not run for the MR.
+	SkipChecks []string `toml:"skip_checks"`
+
+	// Checks run after refinery.checks for the MR.
+	Checks []CheckConfig `toml:"checks"`
+}
+
+// reduces reports whether the route retargets MRs or skips checks.
+func (r *RouteConfig) reduces() bool {
+	return r.Target != "" || len(r.SkipChecks) > 0
+}
+
+// Applies reports whether the route applies to an MR touching files.
+func (r *RouteConfig) Applies(files []string) bool {
+	if len(files) == 0 {
+		return false
+	}
+	for _, f := range files {
+		_, ok := matchPath(r.Paths, f)
+		if ok && !r.reduces() {
+			return true
+		}
+		if !ok && r.reduces() {
+			return false
+		}
+	}
+	return r.reduces()
+}
+
+// MatchRoutes returns the routes that apply to an MR touching files, in
+// rig.toml order.
+func (s *RefinerySettings) MatchRoutes(files []string) []*RouteConfig {
+	if s == nil {
+		return nil
+	}
+	var routes []*RouteConfig
+	for i := range s.Routes {
+		if s.Routes[i].Applies(files) {
+			routes = append(routes, &s.Routes[i])
+		}
+	}
+	return routes
+}
+
+// RoutedChecks returns refinery.checks less those the named routes skip,
+// and the checks the routes add. Names of routes no longer in rig.toml
+// are ignored.
+func (s *RefinerySettings) RoutedChecks(routes []string) (base, extra []CheckConfig) {
+	if s == nil {
+		return nil, nil
+	}
+	skip := make(map[string]bool)
+	for _, name := range routes {
+		for i := range s.Routes {
+			if r := &s.Routes[i]; r.Name == name {
+				for _, c := range r.SkipChecks {
+					skip[c] = true
+				}
+				extra = append(extra, r.Checks...)
+			}
+		}
+	}
+	for _, c := range s.Checks {
+		if !skip[c.Name] {
+			base = append(base, c)
+		}
+	}
+	return base, extra
+}
+
+// validateRoutes checks [[refinery.routes]]. names holds the
+// refinery.checks names, which skip_checks must name and route checks
+// must not reuse.
+func (s *RefinerySettings) validateRoutes(names map[string]bool, keyErr keyErrFunc) error {
+	seen := make(map[string]bool)
+	for i, r := range s.Routes {
+		key := fmt.Sprintf("routes[%d]", i)
+		if strings.TrimSpace(r.Name) == "" {
+			return keyErr(key+".name", "required")
+		}
+		if seen[r.Name] {
+			return keyErr(key+".name", "duplicate route %q", r.Name)
+		}
+		seen[r.Name] = true
+		if len(r.Paths) == 0 {
+			return keyErr(key+".paths", "required")
+		}
+		if err := validatePaths(r.Paths, key+".paths", keyErr); err != nil {
+			return err
+		}
+		if r.Target != "" && (strings.ContainsAny(r.Target, " \t\n~^:?*[\\") || strings.HasPrefix(r.Target, "-")) {
+			return keyErr(key+".target", "invalid branch name %q", r.Target)
+		}
+		for j, c := range r.SkipChecks {
+			if !names[c] {
+				return keyErr(fmt.Sprintf("%s.skip_checks[%d]", key, j), "no check %q in refinery.checks", c)
+			}
+		}
+		routeNames := make(map[string]bool, len(names))
+		for n := range names {
+			routeNames[n] = true
+		}
+		if err := validateChecks(r.Checks, key+".checks", routeNames, keyErr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Sandbox runtimes for [refinery.sandbox] runtime.
+const (
+	SandboxDocker = "docker" // Run in a throwaway container from image
+	SandboxPodman = "podman" // As docker, rootless by default
+	SandboxBwrap  = "bwrap"  // Bubblewrap: read-only host, no container image
+)
+
+// SandboxConfig is [refinery.sandbox]: checks and the test command, which
+// run code pushed by agents, execute inside a container or bubblewrap
+// sandbox that can write only the merge worktree and, unless Network is
+// set, cannot reach the network.
+type SandboxConfig struct {
+	// Runtime is docker, podman, or bwrap.
+	Runtime string `toml:"runtime"`
+
+	// Image is the container image checks run in (docker and podman).
+	Image string `toml:"image"`
+
+	// Network allows network access; by default the sandbox has none.
+	Network bool `toml:"network"`
+
+	// Writable are further host directories the sandbox may write, such
+	// as build caches. Absolute, or relative to the home directory with
+	// "~/".
+	Writable []string `toml:"writable"`
+
+	// Hooks also sandboxes the merge queue hooks.
+	Hooks bool `toml:"hooks"`
+}
+
+// IsContainer reports whether the sandbox is a container runtime.
+func (sc *SandboxConfig) IsContainer() bool {
+	return sc.Runtime == SandboxDocker || sc.Runtime == SandboxPodman
+}
+
+func (sc *SandboxConfig) validate(keyErr keyErrFunc) error {
+	switch sc.Runtime {
+	case SandboxDocker, SandboxPodman:
+		if strings.TrimSpace(sc.Image) == "" {
+			return keyErr("sandbox.image", "required with runtime %q", sc.Runtime)
+		}
+	case SandboxBwrap:
+		if sc.Image != "" {
+			return keyErr("sandbox.image", "only applies to %q and %q", SandboxDocker, SandboxPodman)
+		}
+	default:
+		return keyErr("sandbox.runtime", "got %q, want %q, %q, or %q", sc.Runtime, SandboxDocker, SandboxPodman, SandboxBwrap)
+	}
+	for i, w := range sc.Writable {
+		if !filepath.IsAbs(w) && !strings.HasPrefix(w, "~/") {
+			return keyErr(fmt.Sprintf("sandbox.writable[%d]", i), "%q must be absolute or start with ~/", w)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// SignatureConfig is [refinery.signatures]: every commit a branch adds must
+// carry a good GPG or SSH signature from an allowed key, or the MR is
+// rejected. For rigs where an unsigned commit should never reach the
+// target.
+type SignatureConfig struct {
+	// AllowedSigners is an SSH allowed-signers file (see ssh-keygen(1)),
+	// relative to settings/. SSH signatures by keys it lists pass.
+	AllowedSigners string `toml:"allowed_signers"`
+
+	// Keys are fingerprints (or GPG long key IDs) of keys whose signatures
+	// pass even if the refinery's GPG keyring does not trust them.
+	Keys []string `toml:"keys"`
+}
+
+// SignersFile returns the path of AllowedSigners for the rig, or "".
+func (sc *SignatureConfig) SignersFile(rigPath string) string {
+	if sc.AllowedSigners == "" || filepath.IsAbs(sc.AllowedSigners) {
+		return sc.AllowedSigners
+	}
+	return filepath.Join(filepath.Dir(RigFilePath(rigPath)), sc.AllowedSigners)
+}
+
+// Accepts reports whether a signature with git's %G? status and this key
+// fingerprint passes. "G" is a good signature from a key git trusts: in
+// the allowed-signers file, or trusted in the GPG keyring. "U" is a good
+// signature from a key git does not trust, which passes only if Keys
+// lists it.
+func (sc *SignatureConfig) Accepts(status, fingerprint string) bool {
+	switch status {
+	case "G":
+		return true
+	case "U":
+		return sc.listed(fingerprint)
+	}
+	return false
+}
+
+// listed reports whether fingerprint is one of Keys. GPG fingerprints
+// compare case-insensitively and ignoring spaces, and a long key ID
+// matches the fingerprint it ends.
+func (sc *SignatureConfig) listed(fingerprint string) bool {
+	fp := normalizeKey(fingerprint)
+	if fp == "" {
+		return false
+	}
+	for _, k := range sc.Keys {
+		if k := normalizeKey(k); k != "" && strings.HasSuffix(fp, k) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeKey(k string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(k), " ", ""))
+}
+
+func (sc *SignatureConfig) validate(keyErr keyErrFunc) error {
+	if sc.AllowedSigners == "" && len(sc.Keys) == 0 {
+		return keyErr("signatures", "needs allowed_signers or keys")
+	}
+	for i, k := range sc.Keys {
+		if len(normalizeKey(k)) < 16 {
+			return keyErr(fmt.Sprintf("signatures.keys[%d]", i), "%q is too short; use a fingerprint or long key ID", k)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"net/url"
+	"strings"
+)
+
+// State sync defaults.
+const (
+	DefaultStateSyncRemote  = "origin"
+	DefaultStateSyncHistory = 200
+)
+
+// StateSyncConfig is [refinery.state_sync]: after each MR it handles, the
+// refinery publishes a snapshot of its status, queue, and recent history
+// to a shared backend, so teammates on other machines can inspect the rig
+// with 'gt refinery inspect' without access to its host.
+//
+// Set URL or Ref. URL is an s3:// or gs:// prefix the snapshot is written
+// under as state.json, with the aws or gcloud CLI and whatever credentials
+// they find. Ref is a git ref, pushed to Remote, whose commit holds
+// state.json; anyone who can fetch the repository can read it.
+type StateSyncConfig struct {
+	URL    string `toml:"url"`
+	Ref    string `toml:"ref"`
+	Remote string `toml:"remote"` // Default DefaultStateSyncRemote; only with Ref
+
+	// History is how many of the most recent queue events the snapshot
+	// carries. Defaults to DefaultStateSyncHistory.
+	History int `toml:"history"`
+}
+
+// RemoteName returns the remote Ref is pushed to.
+func (c *StateSyncConfig) RemoteName() string {
+	if c.Remote != "" {
+		return c.Remote
+	}
+	return DefaultStateSyncRemote
+}
+
+// HistoryLimit returns how many events a snapshot carries.
+func (c *StateSyncConfig) HistoryLimit() int {
+	if c.History > 0 {
+		return c.History
+	}
+	return DefaultStateSyncHistory
+}
+
+func (c *StateSyncConfig) validate(keyErr keyErrFunc) error {
+	if (c.URL == "") == (c.Ref == "") {
+		return keyErr("state_sync", "set one of url or ref")
+	}
+	if c.URL != "" {
+		u, err := url.Parse(c.URL)
+		if err != nil || (u.Scheme != "s3" && u.Scheme != "gs") || u.Host == "" {
+			return keyErr("state_sync.url", "want an s3:// or gs:// bucket URL, got %q", c.URL)
+		}
+	}
+	if c.Ref != "" && (!strings.HasPrefix(c.Ref, "refs/") || strings.HasPrefix(c.Ref, "refs/heads/") ||
+		strings.HasPrefix(c.Ref, "refs/tags/") || strings.ContainsAny(c.Ref, " ~^:?*[\\")) {
+		return keyErr("state_sync.ref", "want a ref outside refs/heads/ and refs/tags/, like refs/gastown/state; got %q", c.Ref)
+	}
+	if c.Remote != "" && c.Ref == "" {
+		return keyErr("state_sync.remote", "only used with ref")
+	}
+	if c.History < 0 {
+		return keyErr("state_sync.history", "must not be negative")
+	}
+	return nil
+}
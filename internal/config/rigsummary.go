@@ -0,0 +1,37 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+// SummaryConfig is [refinery.summary]: a command that writes the body of
+// the commit a squash merge lands, so the target's history says what each
+// branch did rather than only which branch it was.
+//
+// The command runs with sh -c in the refinery's worktree before the merge.
+// $GT_SUMMARY_CONTEXT names a JSON file describing the branch (its issue,
+// commits, and changed files); whatever the command prints becomes the
+// commit body. It is only used with strategy = "squash".
+type SummaryConfig struct {
+	Command string `toml:"command"`
+	Timeout string `toml:"timeout,omitempty"` // Empty means no limit
+}
+
+// TimeoutDuration returns the parsed timeout, or 0 for no limit.
+func (sc *SummaryConfig) TimeoutDuration() time.Duration {
+	d, _ := time.ParseDuration(sc.Timeout)
+	return d
+}
+
+func (sc *SummaryConfig) validate(keyErr keyErrFunc) error {
+	if strings.TrimSpace(sc.Command) == "" {
+		return keyErr("summary.command", "required")
+	}
+	if sc.Timeout != "" {
+		if d, err := time.ParseDuration(sc.Timeout); err != nil || d <= 0 {
+			return keyErr("summary.timeout", "invalid duration %q", sc.Timeout)
+		}
+	}
+	return nil
+}
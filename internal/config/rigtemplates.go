@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// rigTemplates are settings/rig.toml presets keyed by project type. They are
+// kept as TOML text rather than structs so the written file carries comments
+// the user can edit from.
+var rigTemplates = map[string]string{
+	"go-service": `# Go service: build, vet, and race-enabled tests before merge.
+[refinery]
+strategy = "squash"
+on_conflict = "assign_back"
+branch_patterns = ["polecat/*", "crew/*"]
+
+[[refinery.checks]]
+name = "build"
+command = "go build ./..."
+timeout = "5m"
+
+[[refinery.checks]]
+name = "vet"
+command = "go vet ./..."
+timeout = "5m"
+
+[[refinery.checks]]
+name = "test"
+command = "go test -race ./..."
+timeout = "20m"
+`,
+
+	"node-app": `# Node app: clean install, lint, and tests before merge.
+[refinery]
+strategy = "squash"
+on_conflict = "assign_back"
+branch_patterns = ["polecat/*", "crew/*"]
+
+[[refinery.checks]]
+name = "install"
+command = "npm ci"
+timeout = "10m"
+
+[[refinery.checks]]
+name = "lint"
+command = "npm run lint --if-present"
+timeout = "5m"
+
+[[refinery.checks]]
+name = "test"
+command = "npm test"
+timeout = "15m"
+`,
+
+	"monorepo": `# Monorepo: merge commits keep per-branch history; checks run via make so
+# each package can decide what "test" means. Rebase automatically since
+# many workers touch the same tree.
+[refinery]
+strategy = "merge"
+on_conflict = "auto_rebase"
+branch_patterns = ["polecat/*", "crew/*"]
+
+[[refinery.checks]]
+name = "build"
+command = "make build"
+timeout = "20m"
+
+[[refinery.checks]]
+name = "test"
+command = "make test"
+timeout = "45m"
+
+[refinery.schedule]
+poll_interval = "2m"
+`,
+}
+
+// RigTemplateNames returns the available rig template names, sorted.
+func RigTemplateNames() []string {
+	names := make([]string, 0, len(rigTemplates))
+	for name := range rigTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WriteRigTemplate writes the named template to the rig's settings/rig.toml.
+// An existing rig.toml is left alone and reported as an error.
+func WriteRigTemplate(rigPath, name string) error {
+	content, ok := rigTemplates[name]
+	if !ok {
+		return fmt.Errorf("unknown rig template %q (available: %s)", name, strings.Join(RigTemplateNames(), ", "))
+	}
+
+	p := RigFilePath(rigPath)
+	if _, err := os.Stat(p); err == nil {
+		return fmt.Errorf("%s already exists", p)
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return fmt.Errorf("creating settings directory: %w", err)
+	}
+	return os.WriteFile(p, []byte(content), 0644)
+}
+
+// IsRigTemplate reports whether name is a known rig template.
+func IsRigTemplate(name string) bool {
+	_, ok := rigTemplates[name]
+	return ok
+}
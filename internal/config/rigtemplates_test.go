@@ -0,0 +1,40 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestRigTemplates_Valid(t *testing.T) {
+	for _, name := range RigTemplateNames() {
+		rigPath := t.TempDir()
+		if err := WriteRigTemplate(rigPath, name); err != nil {
+			t.Fatalf("WriteRigTemplate(%s): %v", name, err)
+		}
+		s, err := LoadRefinerySettings(rigPath)
+		if err != nil {
+			t.Errorf("template %s does not validate: %v", name, err)
+			continue
+		}
+		if s == nil || len(s.Checks) == 0 || len(s.BranchPatterns) == 0 {
+			t.Errorf("template %s: settings = %+v", name, s)
+		}
+	}
+}
+
+func TestWriteRigTemplate_Errors(t *testing.T) {
+	rigPath := t.TempDir()
+	if err := WriteRigTemplate(rigPath, "cobol-mainframe"); err == nil {
+		t.Error("unknown template should fail")
+	}
+	if _, err := os.Stat(RigFilePath(rigPath)); !os.IsNotExist(err) {
+		t.Error("unknown template wrote a rig.toml")
+	}
+
+	if err := WriteRigTemplate(rigPath, "go-service"); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteRigTemplate(rigPath, "node-app"); err == nil {
+		t.Error("existing rig.toml should not be overwritten")
+	}
+}
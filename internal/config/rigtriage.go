@@ -0,0 +1,45 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+// Failure classes a [refinery.triage] agent may report.
+const (
+	TriageFlaky       = "flaky"       // Fails intermittently, not because of the branch
+	TriageRegression  = "regression"  // The branch broke something
+	TriageEnvironment = "environment" // The refinery's machine or services, not the code
+)
+
+// TriageConfig is [refinery.triage]: an agent that reads a failing check's
+// output and the branch's diff and says what kind of failure it was, so
+// whoever picks it up knows whether to fix the branch, retry, or look at
+// the refinery.
+//
+// The command runs with sh -c in the refinery's worktree. $GT_TRIAGE_CONTEXT
+// names a JSON file with the failure (including the check's output) and
+// $GT_TRIAGE_DIFF the branch's diff against the target. The agent writes
+// {"classification": "flaky", "diagnosis": "..."} to $GT_TRIAGE_RESULT.
+type TriageConfig struct {
+	Command string `toml:"command"`
+	Timeout string `toml:"timeout,omitempty"` // Empty means no limit
+}
+
+// TimeoutDuration returns the parsed timeout, or 0 for no limit.
+func (tc *TriageConfig) TimeoutDuration() time.Duration {
+	d, _ := time.ParseDuration(tc.Timeout)
+	return d
+}
+
+func (tc *TriageConfig) validate(keyErr keyErrFunc) error {
+	if strings.TrimSpace(tc.Command) == "" {
+		return keyErr("triage.command", "required")
+	}
+	if tc.Timeout != "" {
+		if d, err := time.ParseDuration(tc.Timeout); err != nil || d <= 0 {
+			return keyErr("triage.timeout", "invalid duration %q", tc.Timeout)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"sort"
+)
+
+// Worker trust tiers for [refinery.workers] and [refinery.tiers].
+const (
+	TierNew     = "new"
+	TierTrusted = "trusted"
+	TierVeteran = "veteran"
+)
+
+// tierOrder is the order tier lists are matched in: a worker listed in
+// several tiers gets the most trusted one.
+var tierOrder = []string{TierVeteran, TierTrusted, TierNew}
+
+// WorkerPolicyConfig decides which workers' branches merge without review
+// and which trust tier each worker is in. Entries are path.Match globs
+// against the worker name.
+//
+// Branches from a denied worker, or from any worker not allowed when Allow
+// is set, wait in the queue until an operator approves them. Workers
+// matching no tier list get DefaultTier; with no tier lists and no
+// default, tiers are off.
+type WorkerPolicyConfig struct {
+	Allow []string `toml:"allow"`
+	Deny  []string `toml:"deny"`
+
+	// MaxReady caps how many MRs from one worker are ready to merge at once;
+	// the rest are backlogged until earlier ones land. Zero means no cap.
+	MaxReady int `toml:"max_ready"`
+
+	Veteran     []string `toml:"veteran"`
+	Trusted     []string `toml:"trusted"`
+	New         []string `toml:"new"`
+	DefaultTier string   `toml:"default_tier"`
+}
+
+// TierPolicy is the set of gates applied to workers in one trust tier.
+type TierPolicy struct {
+	// RequireApproval parks every MR from the tier until an operator
+	// approves it.
+	RequireApproval bool `toml:"require_approval"`
+
+	// Checks run after refinery.checks (or the test command) for MRs
+	// from the tier.
+	Checks []CheckConfig `toml:"checks"`
+}
+
+func (wp *WorkerPolicyConfig) tierPatterns(tier string) []string {
+	switch tier {
+	case TierVeteran:
+		return wp.Veteran
+	case TierTrusted:
+		return wp.Trusted
+	case TierNew:
+		return wp.New
+	}
+	return nil
+}
+
+// matchWorker reports whether worker matches any pattern. The worker may be
+// a bare name or an address ("greenplace/nux"); patterns match either form.
+func matchWorker(patterns []string, worker string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, worker); ok {
+			return true
+		}
+		if ok, _ := path.Match(p, path.Base(worker)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func isTier(name string) bool {
+	return name == TierNew || name == TierTrusted || name == TierVeteran
+}
+
+// WorkerTier returns the trust tier of worker, or "" when tiers are not
+// configured. Unknown (empty) workers get the default tier.
+func (s *RefinerySettings) WorkerTier(worker string) string {
+	if s == nil || s.Workers == nil {
+		return ""
+	}
+	if worker != "" {
+		for _, tier := range tierOrder {
+			if matchWorker(s.Workers.tierPatterns(tier), worker) {
+				return tier
+			}
+		}
+	}
+	return s.Workers.DefaultTier
+}
+
+// tierPolicy returns the gates for worker's tier, or nil if none apply.
+func (s *RefinerySettings) tierPolicy(worker string) (string, *TierPolicy) {
+	tier := s.WorkerTier(worker)
+	if tier == "" {
+		return "", nil
+	}
+	return tier, s.Tiers[tier]
+}
+
+// WorkerApproval reports whether branches from worker may merge without an
+// operator's approval, and if not, why.
+func (s *RefinerySettings) WorkerApproval(worker string) (bool, string) {
+	if s == nil || s.Workers == nil {
+		return true, ""
+	}
+
+	switch {
+	case worker != "" && matchWorker(s.Workers.Deny, worker):
+		return false, fmt.Sprintf("worker %s is denied by refinery.workers.deny", worker)
+	case len(s.Workers.Allow) == 0:
+	case worker == "":
+		return false, "unknown worker"
+	case !matchWorker(s.Workers.Allow, worker):
+		return false, fmt.Sprintf("worker %s is not in refinery.workers.allow", worker)
+	}
+
+	if tier, policy := s.tierPolicy(worker); policy != nil && policy.RequireApproval {
+		return false, fmt.Sprintf("worker %s is in the %s tier, which requires approval", worker, tier)
+	}
+	return true, ""
+}
+
+// MaxReadyPerWorker returns refinery.workers.max_ready, or 0 for no cap.
+func (s *RefinerySettings) MaxReadyPerWorker() int {
+	if s == nil || s.Workers == nil {
+		return 0
+	}
+	return s.Workers.MaxReady
+}
+
+// TierChecks returns the extra checks for worker's trust tier.
+func (s *RefinerySettings) TierChecks(worker string) []CheckConfig {
+	if s == nil {
+		return nil
+	}
+	if _, policy := s.tierPolicy(worker); policy != nil {
+		return policy.Checks
+	}
+	return nil
+}
+
+// validateWorkers checks [refinery.workers] and [refinery.tiers]. names
+// holds the refinery.checks names, which tier checks must not reuse.
+func (s *RefinerySettings) validateWorkers(names map[string]bool, keyErr keyErrFunc) error {
+	if wp := s.Workers; wp != nil {
+		lists := []struct {
+			key      string
+			patterns []string
+		}{
+			{"allow", wp.Allow}, {"deny", wp.Deny},
+			{TierVeteran, wp.Veteran}, {TierTrusted, wp.Trusted}, {TierNew, wp.New},
+		}
+		for _, l := range lists {
+			for i, p := range l.patterns {
+				if _, err := path.Match(p, ""); err != nil {
+					return keyErr(fmt.Sprintf("workers.%s[%d]", l.key, i), "invalid pattern %q", p)
+				}
+			}
+		}
+		if wp.MaxReady < 0 {
+			return keyErr("workers.max_ready", "got %d, want 0 (no cap) or more", wp.MaxReady)
+		}
+		if wp.DefaultTier != "" && !isTier(wp.DefaultTier) {
+			return keyErr("workers.default_tier", "got %q, want %q, %q, or %q", wp.DefaultTier, TierNew, TierTrusted, TierVeteran)
+		}
+	}
+
+	tiers := make([]string, 0, len(s.Tiers))
+	for tier := range s.Tiers {
+		tiers = append(tiers, tier)
+	}
+	sort.Strings(tiers)
+	for _, tier := range tiers {
+		if !isTier(tier) {
+			return keyErr("tiers."+tier, "unknown tier (want %q, %q, or %q)", TierNew, TierTrusted, TierVeteran)
+		}
+		if policy := s.Tiers[tier]; policy != nil {
+			tierNames := make(map[string]bool, len(names))
+			for n := range names {
+				tierNames[n] = true
+			}
+			if err := validateChecks(policy.Checks, "tiers."+tier+".checks", tierNames, keyErr); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
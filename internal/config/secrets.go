@@ -0,0 +1,210 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// SecretsKeyEnvVar supplies the secrets key directly (base64, 32 bytes),
+// for machines where the key file cannot live in the user config directory.
+const SecretsKeyEnvVar = "GT_SECRETS_KEY"
+
+// SecretsFileName is the per-rig encrypted secrets file, kept in settings/.
+const SecretsFileName = "secrets.json"
+
+// ErrSecretNotFound is returned by SecretStore.Get for unknown names.
+var ErrSecretNotFound = errors.New("secret not found")
+
+var secretNameRe = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+// SecretStore is a rig's settings/secrets.json. Each value is sealed with
+// AES-256-GCM under a machine key, with the secret name as associated data
+// so ciphertexts cannot be swapped between names. Config refers to secrets
+// by name ("${secret:name}"), so tokens stay out of rig.toml and out of
+// shell history.
+type SecretStore struct {
+	path    string
+	key     []byte
+	secrets map[string]string // name -> base64(nonce || ciphertext)
+}
+
+type secretsFile struct {
+	Version int               `json:"version"`
+	Secrets map[string]string `json:"secrets"`
+}
+
+// SecretsFilePath returns the path of a rig's secrets file.
+func SecretsFilePath(rigPath string) string {
+	return filepath.Join(rigPath, "settings", SecretsFileName)
+}
+
+// SecretsKeyPath returns the machine key location: gastown/secrets.key
+// under the user config directory.
+func SecretsKeyPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("finding user config directory: %w", err)
+	}
+	return filepath.Join(dir, "gastown", "secrets.key"), nil
+}
+
+// loadSecretsKey returns the machine key from $GT_SECRETS_KEY or the key
+// file. With create set, a missing key file is generated (mode 0600).
+func loadSecretsKey(create bool) ([]byte, error) {
+	if v := os.Getenv(SecretsKeyEnvVar); v != "" {
+		key, err := base64.StdEncoding.DecodeString(v)
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("%s must be 32 base64-encoded bytes", SecretsKeyEnvVar)
+		}
+		return key, nil
+	}
+
+	path, err := SecretsKeyPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is constructed internally
+	if err == nil {
+		key, err := base64.StdEncoding.DecodeString(string(data))
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("%s is corrupt", path)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) || !create {
+		return nil, fmt.Errorf("reading secrets key: %w", err)
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(key)), 0600); err != nil {
+		return nil, fmt.Errorf("writing secrets key: %w", err)
+	}
+	return key, nil
+}
+
+// OpenSecretStore loads a rig's secrets. With create set, a missing machine
+// key is generated; otherwise opening fails without one. A missing secrets
+// file is an empty store.
+func OpenSecretStore(rigPath string, create bool) (*SecretStore, error) {
+	key, err := loadSecretsKey(create)
+	if err != nil {
+		return nil, err
+	}
+	s := &SecretStore{path: SecretsFilePath(rigPath), key: key, secrets: make(map[string]string)}
+
+	data, err := os.ReadFile(s.path) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("reading secrets: %w", err)
+	}
+	var f secretsFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", s.path, err)
+	}
+	if f.Secrets != nil {
+		s.secrets = f.Secrets
+	}
+	return s, nil
+}
+
+func (s *SecretStore) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(s.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Set encrypts value under name. Call Save to persist it.
+func (s *SecretStore) Set(name, value string) error {
+	if !secretNameRe.MatchString(name) {
+		return fmt.Errorf("invalid secret name %q (letters, digits, '_', '.', '-')", name)
+	}
+	gcm, err := s.aead()
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(value), []byte(name))
+	s.secrets[name] = base64.StdEncoding.EncodeToString(sealed)
+	return nil
+}
+
+// Get decrypts the named secret. A value sealed under another machine key
+// fails to decrypt rather than returning garbage.
+func (s *SecretStore) Get(name string) (string, error) {
+	enc, ok := s.secrets[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(enc)
+	if err != nil {
+		return "", fmt.Errorf("secret %s is corrupt", name)
+	}
+	gcm, err := s.aead()
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("secret %s is corrupt", name)
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, []byte(name))
+	if err != nil {
+		return "", fmt.Errorf("secret %s cannot be decrypted with this machine's key", name)
+	}
+	return string(plain), nil
+}
+
+// Delete removes a secret. Returns false if it did not exist.
+func (s *SecretStore) Delete(name string) bool {
+	_, ok := s.secrets[name]
+	delete(s.secrets, name)
+	return ok
+}
+
+// Names returns the stored secret names, sorted.
+func (s *SecretStore) Names() []string {
+	names := make([]string, 0, len(s.secrets))
+	for name := range s.secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Save writes the store with mode 0600.
+func (s *SecretStore) Save() error {
+	data, err := json.MarshalIndent(&secretsFile{Version: 1, Secrets: s.secrets}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
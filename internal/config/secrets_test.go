@@ -0,0 +1,102 @@
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func setTestSecretsKey(t *testing.T, b byte) {
+	t.Helper()
+	t.Setenv(SecretsKeyEnvVar, base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), 32))))
+}
+
+func TestSecretStore(t *testing.T) {
+	setTestSecretsKey(t, 'a')
+	rigPath := t.TempDir()
+
+	s, err := OpenSecretStore(rigPath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("github_token", "ghp_abc123"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("bad name", "x"); err == nil {
+		t.Error("Set accepted a name with a space")
+	}
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(SecretsFilePath(rigPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "ghp_abc123") {
+		t.Error("secrets file contains the plaintext value")
+	}
+	if info, _ := os.Stat(SecretsFilePath(rigPath)); info.Mode().Perm() != 0600 {
+		t.Errorf("secrets file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	s, err = OpenSecretStore(rigPath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := s.Get("github_token"); err != nil || v != "ghp_abc123" {
+		t.Errorf("Get = %q, %v", v, err)
+	}
+	if _, err := s.Get("missing"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("Get(missing) = %v, want ErrSecretNotFound", err)
+	}
+	if names := s.Names(); len(names) != 1 || names[0] != "github_token" {
+		t.Errorf("Names = %v", names)
+	}
+
+	// Another machine's key cannot read the value
+	setTestSecretsKey(t, 'b')
+	other, err := OpenSecretStore(rigPath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Get("github_token"); err == nil {
+		t.Error("Get with the wrong key succeeded")
+	}
+}
+
+func TestRefinerySettings_SecretEnv(t *testing.T) {
+	setTestSecretsKey(t, 'a')
+	rigPath := writeRigFile(t, "[refinery.env]\nTOKEN = \"${secret:api.token}\"\n")
+
+	s, err := LoadRefinerySettings(rigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ResolveEnv(rigPath); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("ResolveEnv before set = %v, want ErrSecretNotFound", err)
+	}
+
+	store, err := OpenSecretStore(rigPath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set("api.token", "s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+	env, err := s.ResolveEnv(rigPath)
+	if err != nil || strings.Join(env, " ") != "TOKEN=s3cret" {
+		t.Errorf("ResolveEnv = %v, %v", env, err)
+	}
+
+	_, err = LoadRigFile(RigFilePath(writeRigFile(t, "[refinery.env]\nTOKEN = \"${secret:bad name}\"")))
+	var ke *KeyError
+	if !errors.As(err, &ke) || ke.Key != "refinery.env.TOKEN" {
+		t.Errorf("invalid secret name: error = %v, want KeyError", err)
+	}
+}
@@ -374,8 +374,13 @@ func (m *Manager) Pristine(name string) (*PristineResult, error) {
 	}
 	result.HadChanges = hasChanges
 
-	// Pull latest (use origin and current branch)
-	if err := crewGit.Pull("origin", ""); err != nil {
+	// Pull latest (use origin and current branch); a jj working copy has no
+	// current branch, so its changes are rebased onto the default branch
+	pull := func() error { return crewGit.Pull("origin", "") }
+	if git.IsJJ(crewPath) {
+		pull = func() error { return git.NewJJ(crewPath).Pull("origin", m.rig.DefaultBranch()) }
+	}
+	if err := pull(); err != nil {
 		result.PullError = err.Error()
 	} else {
 		result.Pulled = true
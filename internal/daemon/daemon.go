@@ -10,6 +10,7 @@ import (
 	"os/signal"
 	"path/filepath"
 	"strconv"
+	"sync"
 	"syscall"
 	"time"
 
@@ -40,6 +41,10 @@ type Daemon struct {
 	ctx     context.Context
 	cancel  context.CancelFunc
 	curator *feed.Curator
+
+	// healthMu keeps rig health evaluations from overlapping; they run in
+	// the background because remote checks can take seconds per rig.
+	healthMu sync.Mutex
 }
 
 // New creates a new daemon instance.
@@ -205,6 +210,9 @@ func (d *Daemon) heartbeat(state *State) {
 	// This validates tmux sessions are still alive for polecats with work-on-hook
 	d.checkPolecatSessionHealth()
 
+	// 9. Re-evaluate rig health (disk, remote, clock, locks) when stale
+	go d.evaluateRigHealth()
+
 	// Update state
 	state.LastHeartbeat = time.Now()
 	state.HeartbeatCount++
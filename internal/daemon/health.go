@@ -0,0 +1,62 @@
+package daemon
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+// rigHealthInterval is how often each rig's health is re-evaluated. The
+// checks touch the network, so they run far less often than the heartbeat.
+const rigHealthInterval = 10 * time.Minute
+
+// evaluateRigHealth refreshes .runtime/health.json for rigs whose report is
+// older than rigHealthInterval and mails the mayor about newly failing checks.
+func (d *Daemon) evaluateRigHealth() {
+	if !d.healthMu.TryLock() {
+		return // Previous evaluation still running
+	}
+	defer d.healthMu.Unlock()
+
+	evaluator := rig.NewHealthEvaluator()
+	for _, rigName := range d.getKnownRigs() {
+		rigPath := filepath.Join(d.config.TownRoot, rigName)
+		prev, _ := rig.LoadHealth(rigPath)
+		if prev != nil && time.Since(prev.CheckedAt) < rigHealthInterval {
+			continue
+		}
+
+		report := evaluator.Evaluate(d.ctx, rigPath)
+		if err := rig.SaveHealth(rigPath, report); err != nil {
+			d.logger.Printf("Warning: saving health for %s: %v", rigName, err)
+			continue
+		}
+
+		problems := report.NewProblems(prev)
+		if len(problems) == 0 {
+			continue
+		}
+		d.logger.Printf("Rig %s health: %d new problem(s)", rigName, len(problems))
+		d.notifyRigHealth(rigName, problems)
+	}
+}
+
+// notifyRigHealth mails the mayor about newly failing health checks.
+func (d *Daemon) notifyRigHealth(rigName string, problems []rig.HealthFinding) {
+	var lines []string
+	for _, p := range problems {
+		lines = append(lines, fmt.Sprintf("%s [%s]: %s", p.Check, p.Status, p.Message))
+	}
+	subject := fmt.Sprintf("RIG_HEALTH: %s has %d new problem(s)", rigName, len(problems))
+	body := strings.Join(lines, "\n") + fmt.Sprintf("\n\nDetails: gt rig status %s", rigName)
+
+	cmd := exec.Command("gt", "mail", "send", "mayor/", "-s", subject, "-m", body) //nolint:gosec // G204: args are constructed internally
+	cmd.Dir = d.config.TownRoot
+	if err := cmd.Run(); err != nil {
+		d.logger.Printf("Warning: failed to notify mayor of rig health: %v", err)
+	}
+}
@@ -0,0 +1,486 @@
+package doctor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+// remoteTimeout bounds network git commands run by rig checks.
+const remoteTimeout = 15 * time.Second
+
+// rigGitDir returns the clone used for remote checks: mayor/rig, the
+// rig's authoritative clone. Empty if it does not exist.
+func rigGitDir(rigPath string) string {
+	dir := filepath.Join(rigPath, "mayor", "rig")
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		return ""
+	}
+	return dir
+}
+
+// gitOutput runs git in dir with a timeout and returns trimmed stdout.
+func gitOutput(dir string, timeout time.Duration, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...) //nolint:gosec // G204: args are constructed internally
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("timed out after %s", timeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s", msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// GitRemoteReachableCheck verifies origin can be contacted from the rig.
+type GitRemoteReachableCheck struct {
+	BaseCheck
+}
+
+// NewGitRemoteReachableCheck creates a new remote reachability check.
+func NewGitRemoteReachableCheck() *GitRemoteReachableCheck {
+	return &GitRemoteReachableCheck{
+		BaseCheck: BaseCheck{
+			CheckName:        "git-remote-reachable",
+			CheckDescription: "Verify the rig's origin remote is reachable",
+		},
+	}
+}
+
+// Run contacts origin with git ls-remote.
+func (c *GitRemoteReachableCheck) Run(ctx *CheckContext) *CheckResult {
+	dir := rigGitDir(ctx.RigPath())
+	if dir == "" {
+		return &CheckResult{
+			Name:    c.Name(),
+			Status:  StatusWarning,
+			Message: "No mayor/rig clone to check",
+			FixHint: "Run rig-is-git-repo check first",
+		}
+	}
+
+	url, err := gitOutput(dir, remoteTimeout, "remote", "get-url", "origin")
+	if err != nil {
+		return &CheckResult{
+			Name:    c.Name(),
+			Status:  StatusError,
+			Message: "No origin remote configured",
+			Details: []string{err.Error()},
+			FixHint: "git -C " + dir + " remote add origin <url>",
+		}
+	}
+
+	if _, err := gitOutput(dir, remoteTimeout, "ls-remote", "--heads", "origin"); err != nil {
+		return &CheckResult{
+			Name:    c.Name(),
+			Status:  StatusError,
+			Message: fmt.Sprintf("Cannot reach origin (%s)", url),
+			Details: []string{err.Error()},
+			FixHint: "Check network access and credentials (SSH key or token) for " + url,
+		}
+	}
+
+	return &CheckResult{
+		Name:    c.Name(),
+		Status:  StatusOK,
+		Message: fmt.Sprintf("origin reachable (%s)", url),
+	}
+}
+
+// TargetBranchesExistCheck verifies every branch the refinery merges into
+// exists on origin: the rig default branch, rig.toml's target_branch, and
+// the targets of queued MRs.
+type TargetBranchesExistCheck struct {
+	BaseCheck
+}
+
+// NewTargetBranchesExistCheck creates a new target branch check.
+func NewTargetBranchesExistCheck() *TargetBranchesExistCheck {
+	return &TargetBranchesExistCheck{
+		BaseCheck: BaseCheck{
+			CheckName:        "target-branches-exist",
+			CheckDescription: "Verify merge target branches exist on origin",
+		},
+	}
+}
+
+// Run compares the rig's merge targets against origin's branches.
+func (c *TargetBranchesExistCheck) Run(ctx *CheckContext) *CheckResult {
+	rigPath := ctx.RigPath()
+	dir := rigGitDir(rigPath)
+	if dir == "" {
+		return &CheckResult{
+			Name:    c.Name(),
+			Status:  StatusWarning,
+			Message: "No mayor/rig clone to check",
+			FixHint: "Run rig-is-git-repo check first",
+		}
+	}
+
+	targets := mergeTargets(rigPath)
+	out, err := gitOutput(dir, remoteTimeout, "ls-remote", "--heads", "origin")
+	if err != nil {
+		return &CheckResult{
+			Name:    c.Name(),
+			Status:  StatusWarning,
+			Message: "Cannot list origin branches",
+			Details: []string{err.Error()},
+			FixHint: "Fix git-remote-reachable first",
+		}
+	}
+
+	heads := make(map[string]bool)
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		if _, ref, ok := strings.Cut(scanner.Text(), "\t"); ok {
+			heads[strings.TrimPrefix(ref, "refs/heads/")] = true
+		}
+	}
+
+	var missing []string
+	for _, b := range targets {
+		if !heads[b] {
+			missing = append(missing, "Missing on origin: "+b)
+		}
+	}
+	if len(missing) > 0 {
+		return &CheckResult{
+			Name:    c.Name(),
+			Status:  StatusError,
+			Message: fmt.Sprintf("%d merge target(s) missing on origin", len(missing)),
+			Details: missing,
+			FixHint: "Push the branch, or fix default_branch in config.json / target_branch in settings/rig.toml",
+		}
+	}
+
+	return &CheckResult{
+		Name:    c.Name(),
+		Status:  StatusOK,
+		Message: fmt.Sprintf("Target branches exist: %s", strings.Join(targets, ", ")),
+	}
+}
+
+// mergeTargets returns the sorted, de-duplicated branches the rig merges into.
+func mergeTargets(rigPath string) []string {
+	set := map[string]bool{(&rig.Rig{Path: rigPath}).DefaultBranch(): true}
+	if s, err := config.LoadRefinerySettings(rigPath); err == nil && s != nil && s.TargetBranch != "" {
+		set[s.TargetBranch] = true
+	}
+	if mrs, err := mrqueue.New(rigPath).List(); err == nil {
+		for _, mr := range mrs {
+			if mr.Target != "" {
+				set[mr.Target] = true
+			}
+		}
+	}
+
+	targets := make([]string, 0, len(set))
+	for b := range set {
+		targets = append(targets, b)
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+// RigWritableCheck verifies the directories the refinery writes to are writable.
+type RigWritableCheck struct {
+	FixableCheck
+	missing []string
+}
+
+// NewRigWritableCheck creates a new rig writable check.
+func NewRigWritableCheck() *RigWritableCheck {
+	return &RigWritableCheck{
+		FixableCheck: FixableCheck{
+			BaseCheck: BaseCheck{
+				CheckName:        "rig-writable",
+				CheckDescription: "Verify .runtime/, settings/, and .beads/ are writable",
+			},
+		},
+	}
+}
+
+// rigStateDirs are the rig directories the refinery and queue write to.
+var rigStateDirs = []string{".runtime", "settings", ".beads"}
+
+// Run probes each state directory with a temporary file.
+func (c *RigWritableCheck) Run(ctx *CheckContext) *CheckResult {
+	rigPath := ctx.RigPath()
+	c.missing = nil
+
+	var issues []string
+	for _, name := range rigStateDirs {
+		dir := filepath.Join(rigPath, name)
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			c.missing = append(c.missing, dir)
+			issues = append(issues, "Missing: "+name+"/")
+			continue
+		}
+		f, err := os.CreateTemp(dir, ".doctor-*")
+		if err != nil {
+			issues = append(issues, fmt.Sprintf("Not writable: %s/ (%v)", name, err))
+			continue
+		}
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}
+
+	if len(issues) == 0 {
+		return &CheckResult{
+			Name:    c.Name(),
+			Status:  StatusOK,
+			Message: "Rig state directories are writable",
+		}
+	}
+
+	hint := "Fix ownership/permissions: chmod u+w <dir>"
+	if len(c.missing) == len(issues) {
+		hint = "Run 'gt rig doctor --fix' to create missing directories"
+	}
+	return &CheckResult{
+		Name:    c.Name(),
+		Status:  StatusError,
+		Message: "Rig state directories are not all writable",
+		Details: issues,
+		FixHint: hint,
+	}
+}
+
+// Fix creates missing state directories. Permission problems are left to the operator.
+func (c *RigWritableCheck) Fix(ctx *CheckContext) error {
+	for _, dir := range c.missing {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("creating %s: %w", dir, err)
+		}
+	}
+	return nil
+}
+
+// RefineryStateValidCheck verifies the refinery's on-disk state parses:
+// .runtime/refinery.json, settings/rig.toml, and the queue's MR files.
+type RefineryStateValidCheck struct {
+	BaseCheck
+}
+
+// NewRefineryStateValidCheck creates a new refinery state check.
+func NewRefineryStateValidCheck() *RefineryStateValidCheck {
+	return &RefineryStateValidCheck{
+		BaseCheck: BaseCheck{
+			CheckName:        "refinery-state-valid",
+			CheckDescription: "Verify refinery state, rig.toml, and queue files parse",
+		},
+	}
+}
+
+// Run parses each refinery state file.
+func (c *RefineryStateValidCheck) Run(ctx *CheckContext) *CheckResult {
+	rigPath := ctx.RigPath()
+	var issues []string
+
+	statePath := filepath.Join(rigPath, ".runtime", "refinery.json")
+	if data, err := os.ReadFile(statePath); err == nil {
+		var state map[string]interface{}
+		if err := json.Unmarshal(data, &state); err != nil {
+			issues = append(issues, fmt.Sprintf(".runtime/refinery.json: %v (delete it; 'gt refinery start' recreates it)", err))
+		}
+	}
+
+	if _, err := config.LoadRefinerySettings(rigPath); err != nil {
+		issues = append(issues, err.Error())
+	}
+
+	q := mrqueue.New(rigPath)
+	entries, _ := os.ReadDir(q.Dir())
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(q.Dir(), entry.Name()))
+		if err != nil {
+			issues = append(issues, fmt.Sprintf("%s: %v", entry.Name(), err))
+			continue
+		}
+		var mr mrqueue.MR
+		if err := json.Unmarshal(data, &mr); err != nil {
+			issues = append(issues, fmt.Sprintf(".beads/mq/%s: %v (remove or re-submit the MR)", entry.Name(), err))
+		}
+	}
+
+	if len(issues) == 0 {
+		return &CheckResult{
+			Name:    c.Name(),
+			Status:  StatusOK,
+			Message: "Refinery state parses",
+		}
+	}
+	return &CheckResult{
+		Name:    c.Name(),
+		Status:  StatusError,
+		Message: fmt.Sprintf("%d refinery state file(s) invalid", len(issues)),
+		Details: issues,
+		FixHint: "Correct the named key or file; see docs/reference.md for settings/rig.toml",
+	}
+}
+
+// OrphanedWorktreesCheck detects worktrees whose directories are gone.
+type OrphanedWorktreesCheck struct {
+	FixableCheck
+	repos []string
+}
+
+// NewOrphanedWorktreesCheck creates a new orphaned worktree check.
+func NewOrphanedWorktreesCheck() *OrphanedWorktreesCheck {
+	return &OrphanedWorktreesCheck{
+		FixableCheck: FixableCheck{
+			BaseCheck: BaseCheck{
+				CheckName:        "orphaned-worktrees",
+				CheckDescription: "Detect git worktrees whose directories no longer exist",
+			},
+		},
+	}
+}
+
+// Run lists prunable worktrees in the rig's shared repo and mayor clone.
+func (c *OrphanedWorktreesCheck) Run(ctx *CheckContext) *CheckResult {
+	rigPath := ctx.RigPath()
+	c.repos = nil
+
+	var orphans []string
+	for _, repo := range []string{filepath.Join(rigPath, ".repo.git"), filepath.Join(rigPath, "mayor", "rig")} {
+		if _, err := os.Stat(repo); err != nil {
+			continue
+		}
+		out, err := gitOutput(repo, remoteTimeout, "worktree", "list", "--porcelain")
+		if err != nil {
+			continue
+		}
+		found := false
+		var current string
+		for _, line := range strings.Split(out, "\n") {
+			if path, ok := strings.CutPrefix(line, "worktree "); ok {
+				current = path
+			}
+			if strings.HasPrefix(line, "prunable") {
+				orphans = append(orphans, current)
+				found = true
+			}
+		}
+		if found {
+			c.repos = append(c.repos, repo)
+		}
+	}
+
+	if len(orphans) == 0 {
+		return &CheckResult{
+			Name:    c.Name(),
+			Status:  StatusOK,
+			Message: "No orphaned worktrees",
+		}
+	}
+	return &CheckResult{
+		Name:    c.Name(),
+		Status:  StatusWarning,
+		Message: fmt.Sprintf("%d orphaned worktree(s)", len(orphans)),
+		Details: orphans,
+		FixHint: "Run 'gt rig doctor --fix' (git worktree prune)",
+	}
+}
+
+// Fix prunes orphaned worktree metadata.
+func (c *OrphanedWorktreesCheck) Fix(ctx *CheckContext) error {
+	for _, repo := range c.repos {
+		if _, err := gitOutput(repo, remoteTimeout, "worktree", "prune"); err != nil {
+			return fmt.Errorf("pruning worktrees in %s: %w", repo, err)
+		}
+	}
+	return nil
+}
+
+// RequiredToolsCheck verifies the tools the rig's agents depend on are installed.
+type RequiredToolsCheck struct {
+	BaseCheck
+}
+
+// NewRequiredToolsCheck creates a new required tools check.
+func NewRequiredToolsCheck() *RequiredToolsCheck {
+	return &RequiredToolsCheck{
+		BaseCheck: BaseCheck{
+			CheckName:        "required-tools",
+			CheckDescription: "Verify git, tmux, bd, and the agent CLI are on PATH",
+		},
+	}
+}
+
+// requiredTools maps each tool to an install hint.
+var requiredTools = []struct {
+	name string
+	hint string
+}{
+	{"git", "install git from your package manager"},
+	{"tmux", "install tmux from your package manager"},
+	{"bd", "go install github.com/steveyegge/beads/cmd/bd@latest"},
+	{"claude", "npm install -g @anthropic-ai/claude-code (or set the rig's agent in settings/config.json)"},
+}
+
+// Run looks up each tool on PATH.
+func (c *RequiredToolsCheck) Run(ctx *CheckContext) *CheckResult {
+	var missing []string
+	for _, tool := range requiredTools {
+		if _, err := exec.LookPath(tool.name); err != nil {
+			missing = append(missing, fmt.Sprintf("%s: not found (%s)", tool.name, tool.hint))
+		}
+	}
+	if len(missing) == 0 {
+		return &CheckResult{
+			Name:    c.Name(),
+			Status:  StatusOK,
+			Message: "Required tools installed",
+		}
+	}
+
+	status := StatusWarning
+	for _, m := range missing {
+		if strings.HasPrefix(m, "git:") {
+			status = StatusError // Nothing works without git
+		}
+	}
+	return &CheckResult{
+		Name:    c.Name(),
+		Status:  status,
+		Message: fmt.Sprintf("%d required tool(s) missing", len(missing)),
+		Details: missing,
+		FixHint: "Install the missing tools and re-run 'gt rig doctor'",
+	}
+}
+
+// RigHealthChecks returns the operational checks run by 'gt rig doctor'
+// in addition to the structural RigChecks.
+func RigHealthChecks() []Check {
+	return []Check{
+		NewRequiredToolsCheck(),
+		NewGitRemoteReachableCheck(),
+		NewTargetBranchesExistCheck(),
+		NewRigWritableCheck(),
+		NewRefineryStateValidCheck(),
+		NewOrphanedWorktreesCheck(),
+	}
+}
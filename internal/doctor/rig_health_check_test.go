@@ -0,0 +1,125 @@
+package doctor
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@t", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@t")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+// setupRigWithOrigin creates <town>/testrig/mayor/rig cloned from a bare
+// origin that has a main branch.
+func setupRigWithOrigin(t *testing.T) (*CheckContext, string) {
+	t.Helper()
+	townRoot := t.TempDir()
+	origin := filepath.Join(townRoot, "origin.git")
+	runGit(t, townRoot, "init", "--bare", "-b", "main", origin)
+
+	clone := filepath.Join(townRoot, "testrig", "mayor", "rig")
+	runGit(t, townRoot, "clone", origin, clone)
+	runGit(t, clone, "commit", "--allow-empty", "-m", "init")
+	runGit(t, clone, "push", "origin", "HEAD:main")
+
+	return &CheckContext{TownRoot: townRoot, RigName: "testrig"}, clone
+}
+
+func TestGitRemoteAndTargetBranchChecks(t *testing.T) {
+	ctx, _ := setupRigWithOrigin(t)
+
+	if r := NewGitRemoteReachableCheck().Run(ctx); r.Status != StatusOK {
+		t.Errorf("git-remote-reachable = %v: %s %v", r.Status, r.Message, r.Details)
+	}
+	if r := NewTargetBranchesExistCheck().Run(ctx); r.Status != StatusOK {
+		t.Errorf("target-branches-exist = %v: %s %v", r.Status, r.Message, r.Details)
+	}
+
+	settings := filepath.Join(ctx.RigPath(), "settings")
+	if err := os.MkdirAll(settings, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(settings, "rig.toml"), []byte("[refinery]\ntarget_branch = \"release\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r := NewTargetBranchesExistCheck().Run(ctx)
+	if r.Status != StatusError || len(r.Details) != 1 || !strings.Contains(r.Details[0], "release") {
+		t.Errorf("missing release branch: %v %v", r.Status, r.Details)
+	}
+}
+
+func TestRigWritableCheck_Fix(t *testing.T) {
+	ctx := &CheckContext{TownRoot: t.TempDir(), RigName: "testrig"}
+	check := NewRigWritableCheck()
+
+	r := check.Run(ctx)
+	if r.Status != StatusError || len(r.Details) != len(rigStateDirs) {
+		t.Fatalf("before fix: %v %v", r.Status, r.Details)
+	}
+	if err := check.Fix(ctx); err != nil {
+		t.Fatalf("Fix: %v", err)
+	}
+	if r := check.Run(ctx); r.Status != StatusOK {
+		t.Errorf("after fix: %v %v", r.Status, r.Details)
+	}
+}
+
+func TestRefineryStateValidCheck(t *testing.T) {
+	ctx := &CheckContext{TownRoot: t.TempDir(), RigName: "testrig"}
+	check := NewRefineryStateValidCheck()
+	if r := check.Run(ctx); r.Status != StatusOK {
+		t.Errorf("empty rig: %v %v", r.Status, r.Details)
+	}
+
+	rigPath := ctx.RigPath()
+	for dir, file := range map[string]string{".runtime": "refinery.json", ".beads/mq": "mr-1.json"} {
+		if err := os.MkdirAll(filepath.Join(rigPath, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(rigPath, dir, file), []byte("{not json"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(rigPath, "settings"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(rigPath, "settings", "rig.toml"), []byte("[refinery]\nstrategy = \"x\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := check.Run(ctx)
+	if r.Status != StatusError || len(r.Details) != 3 {
+		t.Fatalf("corrupt state: %v %v", r.Status, r.Details)
+	}
+	if !strings.Contains(strings.Join(r.Details, "\n"), "refinery.strategy") {
+		t.Errorf("details should name the rig.toml key: %v", r.Details)
+	}
+}
+
+func TestOrphanedWorktreesCheck(t *testing.T) {
+	ctx, clone := setupRigWithOrigin(t)
+	wt := filepath.Join(ctx.TownRoot, "wt")
+	runGit(t, clone, "worktree", "add", "-b", "scratch", wt)
+	if err := os.RemoveAll(wt); err != nil {
+		t.Fatal(err)
+	}
+
+	check := NewOrphanedWorktreesCheck()
+	if r := check.Run(ctx); r.Status != StatusWarning || len(r.Details) != 1 {
+		t.Fatalf("orphan not detected: %v %v", r.Status, r.Details)
+	}
+	if err := check.Fix(ctx); err != nil {
+		t.Fatalf("Fix: %v", err)
+	}
+	if r := check.Run(ctx); r.Status != StatusOK {
+		t.Errorf("after prune: %v %v", r.Status, r.Details)
+	}
+}
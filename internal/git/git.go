@@ -8,7 +8,11 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
 )
 
 // Common errors
@@ -50,6 +54,11 @@ func (g *Git) IsRepo() bool {
 
 // run executes a git command and returns stdout.
 func (g *Git) run(args ...string) (string, error) {
+	return g.runInput("", args...)
+}
+
+// runInput executes a git command with input on stdin and returns stdout.
+func (g *Git) runInput(input string, args ...string) (string, error) {
 	// If gitDir is set (bare repo), prepend --git-dir flag
 	if g.gitDir != "" {
 		args = append([]string{"--git-dir=" + g.gitDir}, args...)
@@ -59,12 +68,17 @@ func (g *Git) run(args ...string) (string, error) {
 	if g.workDir != "" {
 		cmd.Dir = g.workDir
 	}
+	if input != "" {
+		cmd.Stdin = strings.NewReader(input)
+	}
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
+	started := time.Now()
 	err := cmd.Run()
+	util.TraceCommand(g.workDir, "git", args, started, err)
 	if err != nil {
 		return "", g.wrapError(err, stderr.String(), args)
 	}
@@ -141,6 +155,13 @@ func (g *Git) CloneBare(url, dest string) error {
 	return nil
 }
 
+// FetchPrune updates refspecs from remote and deletes local refs under
+// them whose source is gone, without fetching tags.
+func (g *Git) FetchPrune(remote string, refspecs ...string) error {
+	_, err := g.run(append([]string{"fetch", "--prune", "--no-tags", remote}, refspecs...)...)
+	return err
+}
+
 // configureHooksPath sets core.hooksPath to use the repo's .githooks directory
 // if it exists. This ensures Gas Town agents use the pre-push hook that blocks
 // pushes to non-main branches (internal PRs are not allowed).
@@ -189,6 +210,42 @@ func (g *Git) FetchBranch(remote, branch string) error {
 	return err
 }
 
+// FetchRefs fetches only the named branches of remote into their
+// remote-tracking refs, instead of every ref the remote advertises. A name
+// may be a glob such as "polecat/*". Only those tracking refs are offered
+// as common history during negotiation, so fetches stay quick on
+// repositories with thousands of refs. Tracking refs of branches deleted
+// on the remote are pruned.
+func (g *Git) FetchRefs(remote string, branches ...string) error {
+	args := []string{"fetch", "--no-tags", "--prune"}
+	var exact []string
+	for _, b := range branches {
+		tracking := "refs/remotes/" + remote + "/" + b
+		if strings.ContainsAny(b, "*?[") {
+			// git skips a glob tip that matches nothing.
+			args = append(args, "--negotiation-tip="+tracking)
+		} else {
+			exact = append(exact, tracking)
+		}
+	}
+	if len(exact) > 0 {
+		// An exact tip that does not exist yet is an error.
+		out, err := g.run(append([]string{"for-each-ref", "--format=%(refname)"}, exact...)...)
+		if err != nil {
+			return err
+		}
+		for _, ref := range strings.Fields(out) {
+			args = append(args, "--negotiation-tip="+ref)
+		}
+	}
+	args = append(args, remote)
+	for _, b := range branches {
+		args = append(args, "+refs/heads/"+b+":refs/remotes/"+remote+"/"+b)
+	}
+	_, err := g.run(args...)
+	return err
+}
+
 // Pull pulls from the remote branch.
 func (g *Git) Pull(remote, branch string) error {
 	_, err := g.run("pull", remote, branch)
@@ -205,6 +262,69 @@ func (g *Git) Push(remote, branch string, force bool) error {
 	return err
 }
 
+// Revert commits the reverse of commit on HEAD, with git's message. A
+// merge commit is reverted against its first parent: the target it was
+// merged into.
+func (g *Git) Revert(commit string) error {
+	out, err := g.run("rev-list", "--parents", "-n", "1", commit)
+	if err != nil {
+		return err
+	}
+	args := []string{"revert", "--no-edit"}
+	if len(strings.Fields(out)) > 2 {
+		args = append(args, "-m", "1")
+	}
+	_, err = g.run(append(args, commit)...)
+	return err
+}
+
+// PushHead pushes HEAD to branch on remote, for worktrees with HEAD
+// detached.
+func (g *Git) PushHead(remote, branch string) error {
+	_, err := g.run("push", remote, "HEAD:refs/heads/"+branch)
+	return err
+}
+
+// CreateTag creates the annotated tag name at ref.
+func (g *Git) CreateTag(name, ref, message string) error {
+	_, err := g.run("tag", "-a", name, "-m", message, ref)
+	return err
+}
+
+// PushTag pushes the tag name to remote.
+func (g *Git) PushTag(remote, name string) error {
+	_, err := g.run("push", remote, "refs/tags/"+name)
+	return err
+}
+
+// WriteRefFile points ref at a new parentless commit whose tree holds
+// only name, with content, without touching the index or worktree. For
+// refs outside refs/heads/ that carry data rather than history.
+func (g *Git) WriteRefFile(ref, name, content, message string) (string, error) {
+	blob, err := g.runInput(content, "hash-object", "-w", "--stdin")
+	if err != nil {
+		return "", err
+	}
+	tree, err := g.runInput("100644 blob "+blob+"\t"+name+"\n", "mktree")
+	if err != nil {
+		return "", err
+	}
+	commit, err := g.run("commit-tree", tree, "-m", message)
+	if err != nil {
+		return "", err
+	}
+	if _, err := g.run("update-ref", ref, commit); err != nil {
+		return "", err
+	}
+	return commit, nil
+}
+
+// PushRef force-pushes ref to the same ref on remote.
+func (g *Git) PushRef(remote, ref string) error {
+	_, err := g.run("push", "--force", remote, ref+":"+ref)
+	return err
+}
+
 // Add stages files for commit.
 func (g *Git) Add(paths ...string) error {
 	args := append([]string{"add"}, paths...)
@@ -354,6 +474,215 @@ func (g *Git) MergeNoFF(branch, message string) error {
 	return err
 }
 
+// MergeSquash squashes the given branch into a single commit with message.
+func (g *Git) MergeSquash(branch, message string) error {
+	if _, err := g.run("merge", "--squash", branch); err != nil {
+		return err
+	}
+	_, err := g.run("commit", "-m", message)
+	return err
+}
+
+// AmendFile writes content to path, relative to the worktree, and folds it
+// into HEAD, keeping HEAD's message and parents.
+func (g *Git) AmendFile(path, content string) error {
+	full := filepath.Join(g.workDir, path)
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(full, []byte(content), 0644); err != nil { //nolint:gosec // G306: tracked repo file
+		return err
+	}
+	if _, err := g.run("add", "--", path); err != nil {
+		return err
+	}
+	_, err := g.run("commit", "--amend", "--no-edit", "--no-verify")
+	return err
+}
+
+// MergeFFOnly fast-forwards to the given branch, failing if that is not possible.
+func (g *Git) MergeFFOnly(branch string) error {
+	_, err := g.run("merge", "--ff-only", branch)
+	return err
+}
+
+// ChangedFiles returns the files branch changes relative to its merge base
+// with base (git diff --name-only base...branch).
+func (g *Git) ChangedFiles(base, branch string) ([]string, error) {
+	out, err := g.run("diff", "--name-only", base+"..."+branch)
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, nil
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// Diff returns the patch branch makes relative to its merge base with
+// base (git diff base...branch).
+func (g *Git) Diff(base, branch string) (string, error) {
+	return g.run("diff", base+"..."+branch)
+}
+
+// DiffStat returns how many files and lines (added plus removed) differ
+// between from and to (git diff --numstat from to). Binary files count as
+// files with no lines.
+func (g *Git) DiffStat(from, to string) (files, lines int, err error) {
+	out, err := g.run("diff", "--numstat", "--no-renames", from, to)
+	if err != nil {
+		return 0, 0, err
+	}
+	if out == "" {
+		return 0, 0, nil
+	}
+	for _, line := range strings.Split(out, "\n") {
+		f := strings.SplitN(line, "\t", 3)
+		if len(f) != 3 {
+			return 0, 0, fmt.Errorf("unexpected git diff --numstat line %q", line)
+		}
+		files++
+		added, _ := strconv.Atoi(f[0]) // "-" for binary files
+		removed, _ := strconv.Atoi(f[1])
+		lines += added + removed
+	}
+	return files, lines, nil
+}
+
+// AddedLine is one line a branch adds to a file.
+type AddedLine struct {
+	File string
+	Text string
+}
+
+// AddedLines returns the lines branch adds relative to its merge base with
+// base (git diff base...branch), in diff order.
+func (g *Git) AddedLines(base, branch string) ([]AddedLine, error) {
+	out, err := g.run("diff", "-U0", "--no-color", "--no-ext-diff", base+"..."+branch)
+	if err != nil {
+		return nil, err
+	}
+	var lines []AddedLine
+	file, prev := "", ""
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++ ") && strings.HasPrefix(prev, "--- "):
+			file = strings.TrimPrefix(strings.TrimPrefix(line, "+++ "), "b/")
+		case strings.HasPrefix(line, "+") && file != "/dev/null":
+			lines = append(lines, AddedLine{File: file, Text: line[1:]})
+		}
+		prev = line
+	}
+	return lines, nil
+}
+
+// CommitIdentity is who authored and committed one commit.
+type CommitIdentity struct {
+	SHA            string
+	AuthorName     string
+	AuthorEmail    string
+	CommitterName  string
+	CommitterEmail string
+}
+
+// CommitIdentities returns the identities on the commits branch has that
+// base does not (git log base..branch), newest first.
+func (g *Git) CommitIdentities(base, branch string) ([]CommitIdentity, error) {
+	out, err := g.run("log", "--format=%H%x00%an%x00%ae%x00%cn%x00%ce", base+".."+branch)
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, nil
+	}
+	var ids []CommitIdentity
+	for _, line := range strings.Split(out, "\n") {
+		f := strings.Split(line, "\x00")
+		if len(f) != 5 {
+			return nil, fmt.Errorf("unexpected git log line %q", line)
+		}
+		ids = append(ids, CommitIdentity{
+			SHA:            f[0],
+			AuthorName:     f[1],
+			AuthorEmail:    f[2],
+			CommitterName:  f[3],
+			CommitterEmail: f[4],
+		})
+	}
+	return ids, nil
+}
+
+// CommitSignature is the signature check git made on one commit.
+type CommitSignature struct {
+	SHA string
+
+	// Status is git's %G? code: G good, U good from a key of unknown
+	// validity, B bad, X/Y expired signature/key, R revoked key, E not
+	// checkable (e.g. missing key or signer), N unsigned.
+	Status string
+
+	// Fingerprint is the signing key's fingerprint, and Signer the
+	// signer's identity (GPG user ID or SSH principal), when known.
+	Fingerprint string
+	Signer      string
+}
+
+// CommitSignatures checks the signatures on the commits branch has that
+// base does not, newest first. allowedSigners, if set, is the SSH
+// allowed-signers file to check SSH signatures against.
+func (g *Git) CommitSignatures(base, branch, allowedSigners string) ([]CommitSignature, error) {
+	var args []string
+	if allowedSigners != "" {
+		args = append(args, "-c", "gpg.ssh.allowedSignersFile="+allowedSigners)
+	}
+	args = append(args, "log", "--format=%H%x00%G?%x00%GF%x00%GS", base+".."+branch)
+	out, err := g.run(args...)
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, nil
+	}
+	var sigs []CommitSignature
+	for _, line := range strings.Split(out, "\n") {
+		f := strings.Split(line, "\x00")
+		if len(f) != 4 {
+			return nil, fmt.Errorf("unexpected git log line %q", line)
+		}
+		sigs = append(sigs, CommitSignature{SHA: f[0], Status: f[1], Fingerprint: f[2], Signer: f[3]})
+	}
+	return sigs, nil
+}
+
+// Trailers returns the values of the key trailer (e.g. "Swarm: gt-abc") on
+// the commits branch has that base does not, newest first.
+func (g *Git) Trailers(base, branch, key string) ([]string, error) {
+	out, err := g.run("log", "--format=%(trailers:key="+key+",valueonly)", base+".."+branch)
+	if err != nil {
+		return nil, err
+	}
+	var values []string
+	for _, line := range strings.Split(out, "\n") {
+		if v := strings.TrimSpace(line); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values, nil
+}
+
+// CommitSubjects returns the subject lines of the commits branch has that
+// base does not, oldest first.
+func (g *Git) CommitSubjects(base, branch string) ([]string, error) {
+	out, err := g.run("log", "--reverse", "--format=%s", base+".."+branch)
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, nil
+	}
+	return strings.Split(out, "\n"), nil
+}
+
 // DeleteRemoteBranch deletes a branch on the remote.
 func (g *Git) DeleteRemoteBranch(remote, branch string) error {
 	_, err := g.run("push", remote, "--delete", branch)
@@ -366,6 +695,57 @@ func (g *Git) Rebase(onto string) error {
 	return err
 }
 
+// RebaseOnto replays the current branch's commits after upstream onto
+// onto, as git rebase --onto does. A conflict leaves the rebase stopped for
+// RebaseContinue or AbortRebase.
+func (g *Git) RebaseOnto(onto, upstream string) error {
+	_, err := g.run("rebase", "--onto", onto, upstream)
+	return err
+}
+
+// CherryPickRange commits the changes of base..head onto HEAD as one
+// commit with message. A merge commit whose first parent is base is picked
+// as the change it made to base. Changes already on HEAD leave it where
+// it is. A conflict returns ErrMergeConflict with the pick stopped.
+func (g *Git) CherryPickRange(base, head, message string) error {
+	args := []string{"cherry-pick", "--no-commit", base + ".." + head}
+	if parent, err := g.run("rev-parse", head+"^1"); err == nil && parent == base {
+		if _, err := g.run("rev-parse", "--verify", "--quiet", head+"^2"); err == nil {
+			args = []string{"cherry-pick", "--no-commit", "-m", "1", head}
+		}
+	}
+	if _, err := g.run(args...); err != nil {
+		if files, _ := g.UnmergedFiles(); len(files) > 0 {
+			return ErrMergeConflict
+		}
+		return err
+	}
+	if _, err := g.run("diff", "--cached", "--quiet"); err == nil {
+		return nil // Nothing left to commit
+	}
+	_, err := g.run("commit", "-m", message)
+	return err
+}
+
+// RebaseContinue resumes a stopped rebase without opening an editor.
+func (g *Git) RebaseContinue() error {
+	_, err := g.run("-c", "core.editor=true", "rebase", "--continue")
+	return err
+}
+
+// UnmergedFiles returns the files left conflicted by a stopped merge or
+// rebase.
+func (g *Git) UnmergedFiles() ([]string, error) {
+	out, err := g.run("diff", "--name-only", "--diff-filter=U")
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, nil
+	}
+	return strings.Split(out, "\n"), nil
+}
+
 // AbortMerge aborts a merge in progress.
 func (g *Git) AbortMerge() error {
 	_, err := g.run("merge", "--abort")
@@ -414,6 +794,40 @@ func (g *Git) CheckConflicts(source, target string) ([]string, error) {
 	return nil, nil
 }
 
+// MergeTreeConflicts test-merges source into target without touching a
+// working tree, so it works in a bare repository and leaves any checkout
+// alone. It returns the conflicting files, or none if the merge is clean.
+// Requires git 2.38 or later.
+func (g *Git) MergeTreeConflicts(source, target string) ([]string, error) {
+	args := []string{"merge-tree", "--write-tree", "--name-only", "--no-messages", target, source}
+	fullArgs := args
+	if g.gitDir != "" {
+		fullArgs = append([]string{"--git-dir=" + g.gitDir}, args...)
+	}
+	cmd := exec.Command("git", fullArgs...)
+	cmd.Dir = g.workDir
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	started := time.Now()
+	err := cmd.Run()
+	util.TraceCommand(g.workDir, "git", fullArgs, started, err)
+	if err == nil {
+		return nil, nil
+	}
+	// Exit status 1 means the merge conflicts: the output is the tree
+	// written, then the conflicting files.
+	var exitErr *exec.ExitError
+	out := strings.TrimSpace(stdout.String())
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 || out == "" {
+		return nil, g.wrapError(err, stderr.String(), args)
+	}
+	lines := strings.Split(out, "\n")
+	return lines[1:], nil
+}
+
 // runMergeCheck runs a git merge command and returns error info from both stdout and stderr.
 // This is needed because git merge outputs CONFLICT info to stdout.
 func (g *Git) runMergeCheck(args ...string) (string, error) {
@@ -424,7 +838,9 @@ func (g *Git) runMergeCheck(args ...string) (string, error) {
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
+	started := time.Now()
 	err := cmd.Run()
+	util.TraceCommand(g.workDir, "git", args, started, err)
 	if err != nil {
 		// Check stdout for CONFLICT message (git sends it there)
 		stdoutStr := stdout.String()
@@ -534,6 +950,26 @@ func (g *Git) ListBranches(pattern string) ([]string, error) {
 	return strings.Split(out, "\n"), nil
 }
 
+// ListRemoteBranches returns the remote-tracking branches of remote that
+// match pattern (e.g., "polecat/*"), without the remote prefix. Fetch first
+// to see the remote's current branches.
+func (g *Git) ListRemoteBranches(remote, pattern string) ([]string, error) {
+	out, err := g.run("branch", "-r", "--list", "--format=%(refname:short)", remote+"/"+pattern)
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, nil
+	}
+	var branches []string
+	for _, b := range strings.Split(out, "\n") {
+		if name, ok := strings.CutPrefix(b, remote+"/"); ok && name != "HEAD" {
+			branches = append(branches, name)
+		}
+	}
+	return branches, nil
+}
+
 // ResetBranch force-updates a branch to point to a ref.
 // This is useful for resetting stale polecat branches to main.
 func (g *Git) ResetBranch(name, ref string) error {
@@ -541,11 +977,41 @@ func (g *Git) ResetBranch(name, ref string) error {
 	return err
 }
 
+// UpdateBranchRef points a local branch at sha. Unlike ResetBranch it works
+// when the branch is checked out in another worktree, whose files are left
+// as they were.
+func (g *Git) UpdateBranchRef(name, sha string) error {
+	_, err := g.run("update-ref", "refs/heads/"+name, sha)
+	return err
+}
+
 // Rev returns the commit hash for the given ref.
 func (g *Git) Rev(ref string) (string, error) {
 	return g.run("rev-parse", ref)
 }
 
+// MergeBase returns the best common ancestor of a and b.
+func (g *Git) MergeBase(a, b string) (string, error) {
+	return g.run("merge-base", a, b)
+}
+
+// ListFiles returns every file in ref's tree (git ls-tree -r --name-only).
+func (g *Git) ListFiles(ref string) ([]string, error) {
+	out, err := g.run("ls-tree", "-r", "--name-only", ref)
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, nil
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// ShowFile returns the contents of path as of ref (git show ref:path).
+func (g *Git) ShowFile(ref, path string) (string, error) {
+	return g.run("show", ref+":"+path)
+}
+
 // IsAncestor checks if ancestor is an ancestor of descendant.
 func (g *Git) IsAncestor(ancestor, descendant string) (bool, error) {
 	_, err := g.run("merge-base", "--is-ancestor", ancestor, descendant)
@@ -559,6 +1025,26 @@ func (g *Git) IsAncestor(ancestor, descendant string) (bool, error) {
 	return true, nil
 }
 
+// CommitTime returns the committer date of the commit ref names.
+func (g *Git) CommitTime(ref string) (time.Time, error) {
+	out, err := g.run("log", "-1", "--format=%ct", ref)
+	if err != nil {
+		return time.Time{}, err
+	}
+	secs, err := strconv.ParseInt(out, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parsing commit time %q: %w", out, err)
+	}
+	return time.Unix(secs, 0), nil
+}
+
+// GC packs the repository's loose objects and prunes unreachable ones
+// past git's usual grace period, as git gc does.
+func (g *Git) GC() error {
+	_, err := g.run("gc", "--quiet")
+	return err
+}
+
 // WorktreeAdd creates a new worktree at the given path with a new branch.
 // The new branch is created from the current HEAD.
 // Sparse checkout is enabled to exclude .claude/ from source repos.
@@ -4,6 +4,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"testing"
 )
 
@@ -88,6 +89,22 @@ func TestCloneWithReferenceCreatesAlternates(t *testing.T) {
 	}
 }
 
+func TestIsJJ(t *testing.T) {
+	dir := initTestRepo(t)
+	if IsJJ(dir) {
+		t.Error("IsJJ = true for a plain git repo")
+	}
+	if err := os.Mkdir(filepath.Join(dir, ".jj"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if !IsJJ(dir) {
+		t.Error("IsJJ = false for a colocated jj repo")
+	}
+	if IsJJ(filepath.Join(dir, "sub")) {
+		t.Error("IsJJ = true below the repo root")
+	}
+}
+
 func TestCurrentBranch(t *testing.T) {
 	dir := initTestRepo(t)
 	g := NewGit(dir)
@@ -268,6 +285,122 @@ func TestFetchBranch(t *testing.T) {
 	}
 }
 
+func TestFetchRefs(t *testing.T) {
+	remoteDir := initTestRepo(t)
+	remote := NewGit(remoteDir)
+	mainBranch, _ := remote.CurrentBranch()
+	for _, b := range []string{"polecat/nux", "polecat/toast", "release/v1"} {
+		if err := remote.CreateBranch(b); err != nil {
+			t.Fatalf("CreateBranch %s: %v", b, err)
+		}
+	}
+
+	localDir := t.TempDir()
+	cmd := exec.Command("git", "init")
+	cmd.Dir = localDir
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("git init: %v", err)
+	}
+	cmd = exec.Command("git", "remote", "add", "origin", remoteDir)
+	cmd.Dir = localDir
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("git remote add: %v", err)
+	}
+	g := NewGit(localDir)
+
+	// The first fetch has no tracking refs to negotiate with.
+	if err := g.FetchRefs("origin", mainBranch, "polecat/*"); err != nil {
+		t.Fatalf("FetchRefs: %v", err)
+	}
+	got, err := g.ListRemoteBranches("origin", "*")
+	if err != nil {
+		t.Fatalf("ListRemoteBranches: %v", err)
+	}
+	want := []string{mainBranch, "polecat/nux", "polecat/toast"}
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Errorf("after first fetch, tracking %v, want %v", got, want)
+	}
+
+	if err := remote.DeleteBranch("polecat/nux", true); err != nil {
+		t.Fatalf("DeleteBranch: %v", err)
+	}
+	if err := g.FetchRefs("origin", mainBranch, "polecat/*"); err != nil {
+		t.Fatalf("FetchRefs: %v", err)
+	}
+	got, _ = g.ListRemoteBranches("origin", "polecat/*")
+	if !slices.Equal(got, []string{"polecat/toast"}) {
+		t.Errorf("after delete, tracking %v, want [polecat/toast]", got)
+	}
+}
+
+func TestMergeTreeConflicts(t *testing.T) {
+	dir := initTestRepo(t)
+	g := NewGit(dir)
+	mainBranch, _ := g.CurrentBranch()
+	commit := func(branch, file, content string) {
+		t.Helper()
+		if err := g.Checkout(branch); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := g.Add(file); err != nil {
+			t.Fatal(err)
+		}
+		if err := g.Commit("change " + file); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, b := range []string{"conflicting", "clean"} {
+		if err := g.CreateBranch(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	commit("conflicting", "README.md", "# Branch\n")
+	commit("clean", "other.txt", "new\n")
+	commit(mainBranch, "README.md", "# Main\n")
+
+	// Run against a bare clone: no working tree is needed.
+	bare := filepath.Join(t.TempDir(), "mirror.git")
+	if err := g.CloneBare(dir, bare); err != nil {
+		t.Fatal(err)
+	}
+	m := NewGitWithDir(bare, "")
+
+	files, err := m.MergeTreeConflicts("conflicting", mainBranch)
+	if err != nil {
+		t.Fatalf("MergeTreeConflicts: %v", err)
+	}
+	if !slices.Equal(files, []string{"README.md"}) {
+		t.Errorf("conflicting files = %v, want [README.md]", files)
+	}
+	if files, err := m.MergeTreeConflicts("clean", mainBranch); err != nil || len(files) > 0 {
+		t.Errorf("clean merge = %v, %v; want no conflicts", files, err)
+	}
+	if _, err := m.MergeTreeConflicts("missing", mainBranch); err == nil {
+		t.Error("expected an error for a missing branch")
+	}
+
+	// Branches created and deleted in the source reach the mirror.
+	if err := g.DeleteBranch("clean", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.CreateBranch("later"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.FetchPrune("origin", "+refs/heads/*:refs/heads/*"); err != nil {
+		t.Fatalf("FetchPrune: %v", err)
+	}
+	if _, err := m.Rev("later"); err != nil {
+		t.Errorf("new branch not fetched: %v", err)
+	}
+	if _, err := m.Rev("clean"); err == nil {
+		t.Error("deleted branch not pruned")
+	}
+}
+
 func TestCheckConflicts_NoConflict(t *testing.T) {
 	dir := initTestRepo(t)
 	g := NewGit(dir)
@@ -387,3 +520,207 @@ func TestCheckConflicts_WithConflict(t *testing.T) {
 		t.Error("expected clean working directory after CheckConflicts")
 	}
 }
+
+func TestChangedFiles(t *testing.T) {
+	dir := initTestRepo(t)
+	g := NewGit(dir)
+	mainBranch, _ := g.CurrentBranch()
+
+	if err := g.CreateBranch("feature"); err != nil {
+		t.Fatalf("CreateBranch: %v", err)
+	}
+	if err := g.Checkout("feature"); err != nil {
+		t.Fatalf("Checkout feature: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "services", "api"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "services", "api", "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Add("services"); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := g.Commit("add api"); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	files, err := g.ChangedFiles(mainBranch, "feature")
+	if err != nil {
+		t.Fatalf("ChangedFiles: %v", err)
+	}
+	if len(files) != 1 || files[0] != "services/api/main.go" {
+		t.Errorf("ChangedFiles = %v, want [services/api/main.go]", files)
+	}
+
+	if files, _ := g.ChangedFiles(mainBranch, mainBranch); len(files) != 0 {
+		t.Errorf("ChangedFiles of a branch against itself = %v, want none", files)
+	}
+}
+
+func TestCommitIdentities(t *testing.T) {
+	dir := initTestRepo(t)
+	g := NewGit(dir)
+	mainBranch, _ := g.CurrentBranch()
+
+	if err := g.CreateBranch("polecat/nux"); err != nil {
+		t.Fatalf("CreateBranch: %v", err)
+	}
+	if err := g.Checkout("polecat/nux"); err != nil {
+		t.Fatalf("Checkout: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "fix.go"), []byte("package fix\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Add("fix.go"); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	cmd := exec.Command("git", "commit", "-m", "fix")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=nux")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("commit: %v\n%s", err, out)
+	}
+
+	ids, err := g.CommitIdentities(mainBranch, "polecat/nux")
+	if err != nil {
+		t.Fatalf("CommitIdentities: %v", err)
+	}
+	if len(ids) != 1 {
+		t.Fatalf("CommitIdentities = %v, want 1 commit", ids)
+	}
+	if ids[0].AuthorName != "nux" || ids[0].CommitterName != "Test User" || ids[0].CommitterEmail != "test@test.com" {
+		t.Errorf("CommitIdentities[0] = %+v, want author nux, committer Test User", ids[0])
+	}
+
+	if ids, _ := g.CommitIdentities(mainBranch, mainBranch); len(ids) != 0 {
+		t.Errorf("CommitIdentities of a branch against itself = %v, want none", ids)
+	}
+}
+
+func TestAddedLines(t *testing.T) {
+	dir := initTestRepo(t)
+	g := NewGit(dir)
+	mainBranch, _ := g.CurrentBranch()
+
+	if err := g.CreateBranch("polecat/nux"); err != nil {
+		t.Fatalf("CreateBranch: %v", err)
+	}
+	if err := g.Checkout("polecat/nux"); err != nil {
+		t.Fatalf("Checkout: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Test\nmore\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "db"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "db", "001.sql"), []byte("DROP TABLE users;\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Add("."); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := g.Commit("add migration"); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	got, err := g.AddedLines(mainBranch, "polecat/nux")
+	if err != nil {
+		t.Fatalf("AddedLines: %v", err)
+	}
+	want := []AddedLine{{"README.md", "more"}, {"db/001.sql", "DROP TABLE users;"}}
+	if len(got) != len(want) {
+		t.Fatalf("AddedLines = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("AddedLines[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if files, lines, err := g.DiffStat(mainBranch, "polecat/nux"); err != nil || files != 2 || lines != 2 {
+		t.Errorf("DiffStat = %d files, %d lines, %v; want 2, 2", files, lines, err)
+	}
+}
+
+func TestCommitSignatures(t *testing.T) {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not installed")
+	}
+	dir := initTestRepo(t)
+	g := NewGit(dir)
+	mainBranch, _ := g.CurrentBranch()
+
+	key := filepath.Join(t.TempDir(), "id_ed25519")
+	if out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-f", key).CombinedOutput(); err != nil {
+		t.Fatalf("ssh-keygen: %v\n%s", err, out)
+	}
+	pub, err := os.ReadFile(key + ".pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signers := filepath.Join(t.TempDir(), "allowed_signers")
+	if err := os.WriteFile(signers, append([]byte("test@test.com "), pub...), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := g.CreateBranch("polecat/nux"); err != nil {
+		t.Fatalf("CreateBranch: %v", err)
+	}
+	if err := g.Checkout("polecat/nux"); err != nil {
+		t.Fatalf("Checkout: %v", err)
+	}
+	for _, args := range [][]string{
+		{"-c", "gpg.format=ssh", "-c", "user.signingkey=" + key, "commit", "--allow-empty", "-S", "-m", "signed"},
+		{"commit", "--allow-empty", "-m", "unsigned"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("commit: %v\n%s", err, out)
+		}
+	}
+
+	sigs, err := g.CommitSignatures(mainBranch, "polecat/nux", signers)
+	if err != nil {
+		t.Fatalf("CommitSignatures: %v", err)
+	}
+	if len(sigs) != 2 {
+		t.Fatalf("CommitSignatures = %v, want 2 commits", sigs)
+	}
+	if sigs[0].Status != "N" {
+		t.Errorf("unsigned commit status = %q, want N", sigs[0].Status)
+	}
+	if sigs[1].Status != "G" || sigs[1].Signer != "test@test.com" || sigs[1].Fingerprint == "" {
+		t.Errorf("signed commit = %+v, want good signature by test@test.com", sigs[1])
+	}
+}
+
+func TestTrailers(t *testing.T) {
+	dir := initTestRepo(t)
+	g := NewGit(dir)
+	mainBranch, _ := g.CurrentBranch()
+
+	if err := g.CreateBranch("polecat/nux"); err != nil {
+		t.Fatalf("CreateBranch: %v", err)
+	}
+	if err := g.Checkout("polecat/nux"); err != nil {
+		t.Fatalf("Checkout: %v", err)
+	}
+	for _, msg := range []string{"first\n\nSwarm: gt-epic", "second\n\nRefs: gt-1"} {
+		cmd := exec.Command("git", "commit", "--allow-empty", "-m", msg)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("commit: %v\n%s", err, out)
+		}
+	}
+
+	got, err := g.Trailers(mainBranch, "polecat/nux", "Swarm")
+	if err != nil {
+		t.Fatalf("Trailers: %v", err)
+	}
+	if len(got) != 1 || got[0] != "gt-epic" {
+		t.Errorf("Trailers = %v, want [gt-epic]", got)
+	}
+}
@@ -0,0 +1,1120 @@
+// Package gitfake is an in-memory stand-in for git.Git, so code that drives
+// a repository can be unit tested without creating one on disk.
+//
+// A Repo holds commits, local branches, the branches on origin, and the
+// remote-tracking copies fetched from it. Each Git is a worktree of the
+// repo with its own HEAD; worktrees have no files of their own, only the
+// tree of the commit they have checked out, so merges and checkouts never
+// leave uncommitted changes behind.
+package gitfake
+
+import (
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/steveyegge/gastown/internal/git"
+)
+
+// Commit is one commit in a Repo.
+type Commit struct {
+	SHA     string
+	Parents []string
+	Files   map[string]string // The full tree: path to contents
+	Message string
+
+	Author string // Name; "test" if unset
+	Email  string // "test@example.com" if unset
+
+	// Signature is the commit's %G? status for CommitSignatures; "N"
+	// (unsigned) if unset.
+	Signature git.CommitSignature
+
+	seq int // Creation order; parents always come first
+}
+
+// Subject returns the first line of the commit message.
+func (c *Commit) Subject() string {
+	subject, _, _ := strings.Cut(c.Message, "\n")
+	return subject
+}
+
+// Tag is an annotated tag in a Repo.
+type Tag struct {
+	Name    string
+	Commit  string
+	Message string
+	Pushed  bool // Whether it is on origin
+}
+
+// Repo is an in-memory repository with a single remote, origin. It is safe
+// for concurrent use by its worktrees.
+type Repo struct {
+	mu        sync.Mutex
+	commits   map[string]*Commit
+	branches  map[string]string // refs/heads
+	tracking  map[string]string // refs/remotes/origin
+	origin    map[string]string // Branches on origin itself
+	tags      map[string]*Tag
+	worktrees map[string]*Git
+}
+
+// New returns an empty repository.
+func New() *Repo {
+	return &Repo{
+		commits:   make(map[string]*Commit),
+		branches:  make(map[string]string),
+		tracking:  make(map[string]string),
+		origin:    make(map[string]string),
+		tags:      make(map[string]*Tag),
+		worktrees: make(map[string]*Git),
+	}
+}
+
+// Git returns the worktree at dir, creating it with nothing checked out if
+// there is none.
+func (r *Repo) Git(dir string) *Git {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	g := r.worktrees[dir]
+	if g == nil {
+		g = &Git{repo: r, dir: dir}
+		r.worktrees[dir] = g
+	}
+	return g
+}
+
+// Commit commits changes on top of branch, creating the branch (as a root
+// commit) if it does not exist, and returns the new commit's SHA. An empty
+// value in changes deletes that file.
+func (r *Repo) Commit(branch, message string, changes map[string]string) string {
+	return r.CommitAs(branch, Commit{Message: message}, changes)
+}
+
+// CommitAs is Commit with the author and signature taken from c.
+func (r *Repo) CommitAs(branch string, c Commit, changes map[string]string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	files := make(map[string]string)
+	c.Parents = nil
+	if parent, ok := r.branches[branch]; ok {
+		files = copyFiles(r.commits[parent].Files)
+		c.Parents = []string{parent}
+	}
+	for path, content := range changes {
+		if content == "" {
+			delete(files, path)
+		} else {
+			files[path] = content
+		}
+	}
+	c.Files = files
+	sha := r.add(&c)
+	r.branches[branch] = sha
+	return sha
+}
+
+// Publish pushes branch to origin, as if another clone had, and fetches it.
+func (r *Repo) Publish(branch string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.origin[branch] = r.branches[branch]
+	r.tracking[branch] = r.branches[branch]
+}
+
+// Origin returns the commit branch is at on origin, or "" if it is not there.
+func (r *Repo) Origin(branch string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.origin[branch]
+}
+
+// Tags returns the repository's tags, by name.
+func (r *Repo) Tags() map[string]Tag {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tags := make(map[string]Tag, len(r.tags))
+	for name, t := range r.tags {
+		tags[name] = *t
+	}
+	return tags
+}
+
+// Lookup returns the commit with the given SHA, or nil.
+func (r *Repo) Lookup(sha string) *Commit {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.commits[sha]
+}
+
+// add stores c, filling in its SHA and creation order.
+func (r *Repo) add(c *Commit) string {
+	c.seq = len(r.commits) + 1
+	if c.Author == "" {
+		c.Author = "test"
+	}
+	if c.Email == "" {
+		c.Email = "test@example.com"
+	}
+	if c.Signature.Status == "" {
+		c.Signature.Status = "N"
+	}
+	h := sha1.New()
+	fmt.Fprintf(h, "%d\x00%s\x00%s", c.seq, strings.Join(c.Parents, " "), c.Message)
+	c.SHA = fmt.Sprintf("%x", h.Sum(nil))
+	c.Signature.SHA = c.SHA
+	r.commits[c.SHA] = c
+	return c.SHA
+}
+
+// resolve returns the commit ref names: a local branch, "origin/<branch>",
+// or a full or abbreviated SHA, any of them with a "^" suffix for the first
+// parent. Called with r.mu held.
+func (r *Repo) resolve(ref string) (string, error) {
+	if base, ok := strings.CutSuffix(ref, "^"); ok {
+		sha, err := r.resolve(base)
+		if err != nil {
+			return "", err
+		}
+		if parents := r.commits[sha].Parents; len(parents) > 0 {
+			return parents[0], nil
+		}
+		return "", fmt.Errorf("unknown revision %q", ref)
+	}
+	if sha, ok := r.branches[ref]; ok {
+		return sha, nil
+	}
+	if name, ok := strings.CutPrefix(ref, "origin/"); ok {
+		if sha, ok := r.tracking[name]; ok {
+			return sha, nil
+		}
+	}
+	if _, ok := r.commits[ref]; ok {
+		return ref, nil
+	}
+	if len(ref) >= 4 {
+		var found string
+		for sha := range r.commits {
+			if strings.HasPrefix(sha, ref) {
+				if found != "" {
+					return "", fmt.Errorf("ambiguous argument %q", ref)
+				}
+				found = sha
+			}
+		}
+		if found != "" {
+			return found, nil
+		}
+	}
+	return "", fmt.Errorf("unknown revision %q", ref)
+}
+
+// ancestors returns sha and every commit reachable from it.
+func (r *Repo) ancestors(sha string) map[string]bool {
+	seen := make(map[string]bool)
+	stack := []string{sha}
+	for len(stack) > 0 {
+		cur := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		c := r.commits[cur]
+		if c == nil || seen[cur] {
+			continue
+		}
+		seen[cur] = true
+		stack = append(stack, c.Parents...)
+	}
+	return seen
+}
+
+// mergeBase returns the newest common ancestor of a and b, which no other
+// common ancestor descends from, or "" if they share no history.
+func (r *Repo) mergeBase(a, b string) string {
+	inA := r.ancestors(a)
+	best := ""
+	for sha := range r.ancestors(b) {
+		if inA[sha] && (best == "" || r.commits[sha].seq > r.commits[best].seq) {
+			best = sha
+		}
+	}
+	return best
+}
+
+// between returns the commits reachable from branch but not base, newest
+// first.
+func (r *Repo) between(base, branch string) []*Commit {
+	exclude := r.ancestors(base)
+	var out []*Commit
+	for sha := range r.ancestors(branch) {
+		if !exclude[sha] {
+			out = append(out, r.commits[sha])
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].seq > out[j].seq })
+	return out
+}
+
+// merge3 merges theirs into ours, both descended from base, file by file,
+// returning the merged tree and the files both sides changed differently.
+func merge3(base, ours, theirs map[string]string) (map[string]string, []string) {
+	merged := make(map[string]string)
+	var conflicts []string
+	for _, path := range unionPaths(base, ours, theirs) {
+		b, bok := base[path]
+		o, ook := ours[path]
+		t, tok := theirs[path]
+		switch {
+		case o == t && ook == tok:
+			if ook {
+				merged[path] = o
+			}
+		case o == b && ook == bok:
+			if tok {
+				merged[path] = t
+			}
+		case t == b && tok == bok:
+			if ook {
+				merged[path] = o
+			}
+		default:
+			conflicts = append(conflicts, path)
+			if ook {
+				merged[path] = o
+			}
+		}
+	}
+	return merged, conflicts
+}
+
+// Git is one worktree of a Repo. It implements the methods of git.Git that
+// the refinery uses.
+type Git struct {
+	repo *Repo
+	dir  string
+
+	branch   string   // Checked-out branch, or "" when detached
+	detached string   // HEAD when detached
+	merging  []string // Conflicted files of an unfinished merge
+	rebasing bool     // A rebase stopped at a conflict
+}
+
+// head returns the checked-out commit, "" if none. Called with the repo
+// lock held.
+func (g *Git) head() string {
+	if g.branch != "" {
+		return g.repo.branches[g.branch]
+	}
+	return g.detached
+}
+
+// setHead moves the checked-out branch, or the detached HEAD, to sha.
+func (g *Git) setHead(sha string) {
+	if g.branch != "" {
+		g.repo.branches[g.branch] = sha
+	} else {
+		g.detached = sha
+	}
+}
+
+func (g *Git) tree(sha string) map[string]string {
+	if sha == "" {
+		return nil
+	}
+	return g.repo.commits[sha].Files
+}
+
+// Rev returns the commit hash for the given ref.
+func (g *Git) Rev(ref string) (string, error) {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	if ref == "HEAD" {
+		if h := g.head(); h != "" {
+			return h, nil
+		}
+		return "", fmt.Errorf("unknown revision %q", ref)
+	}
+	return g.repo.resolve(ref)
+}
+
+// Checkout checks out a local branch, or detaches HEAD at any other ref.
+func (g *Git) Checkout(ref string) error {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	if len(g.merging) > 0 {
+		return errors.New("git checkout: you need to resolve your current index first")
+	}
+	if _, ok := g.repo.branches[ref]; ok {
+		g.branch, g.detached = ref, ""
+		return nil
+	}
+	sha, err := g.repo.resolve(ref)
+	if err != nil {
+		return fmt.Errorf("git checkout: %w", err)
+	}
+	g.branch, g.detached = "", sha
+	return nil
+}
+
+// CurrentBranch returns the checked-out branch, or "HEAD" when detached.
+func (g *Git) CurrentBranch() (string, error) {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	if g.branch == "" {
+		return "HEAD", nil
+	}
+	return g.branch, nil
+}
+
+// ChangedFiles returns the files branch changes relative to its merge base
+// with base.
+func (g *Git) ChangedFiles(base, branch string) ([]string, error) {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	from, to, err := g.threeDot(base, branch)
+	if err != nil {
+		return nil, err
+	}
+	return changedPaths(g.tree(from), g.tree(to)), nil
+}
+
+// Diff returns a patch of what branch changes relative to its merge base
+// with base. Each changed file's old lines are removed and new lines
+// added whole, rather than in minimal hunks.
+func (g *Git) Diff(base, branch string) (string, error) {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	from, to, err := g.threeDot(base, branch)
+	if err != nil {
+		return "", err
+	}
+	old, cur := g.tree(from), g.tree(to)
+	var b strings.Builder
+	for _, path := range changedPaths(old, cur) {
+		fmt.Fprintf(&b, "diff --git a/%s b/%s\n--- a/%s\n+++ b/%s\n", path, path, path, path)
+		for _, line := range splitLines(old[path]) {
+			fmt.Fprintf(&b, "-%s\n", line)
+		}
+		for _, line := range splitLines(cur[path]) {
+			fmt.Fprintf(&b, "+%s\n", line)
+		}
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// DiffStat returns how many files differ between from and to, and how many
+// lines were added plus removed, counting lines as a multiset per file.
+func (g *Git) DiffStat(from, to string) (files, lines int, err error) {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	a, err := g.repo.resolve(from)
+	if err != nil {
+		return 0, 0, err
+	}
+	b, err := g.repo.resolve(to)
+	if err != nil {
+		return 0, 0, err
+	}
+	old, cur := g.tree(a), g.tree(b)
+	for _, path := range changedPaths(old, cur) {
+		files++
+		added, removed := lineChanges(old[path], cur[path])
+		lines += len(added) + removed
+	}
+	return files, lines, nil
+}
+
+// AddedLines returns the lines branch adds relative to its merge base with
+// base.
+func (g *Git) AddedLines(base, branch string) ([]git.AddedLine, error) {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	from, to, err := g.threeDot(base, branch)
+	if err != nil {
+		return nil, err
+	}
+	old, cur := g.tree(from), g.tree(to)
+	var out []git.AddedLine
+	for _, path := range changedPaths(old, cur) {
+		added, _ := lineChanges(old[path], cur[path])
+		for _, line := range added {
+			out = append(out, git.AddedLine{File: path, Text: line})
+		}
+	}
+	return out, nil
+}
+
+// ShowFile returns the contents of path as of ref.
+func (g *Git) ShowFile(ref, path string) (string, error) {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	sha, err := g.repo.resolve(ref)
+	if err != nil {
+		return "", err
+	}
+	content, ok := g.tree(sha)[path]
+	if !ok {
+		return "", fmt.Errorf("git show: path %q does not exist in %q", path, ref)
+	}
+	return strings.TrimSpace(content), nil
+}
+
+// MergeBase returns the best common ancestor of a and b.
+func (g *Git) MergeBase(a, b string) (string, error) {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	x, err := g.repo.resolve(a)
+	if err != nil {
+		return "", err
+	}
+	y, err := g.repo.resolve(b)
+	if err != nil {
+		return "", err
+	}
+	if mb := g.repo.mergeBase(x, y); mb != "" {
+		return mb, nil
+	}
+	return "", fmt.Errorf("git merge-base: no common ancestor of %s and %s", a, b)
+}
+
+// ListFiles returns every file in ref's tree, sorted.
+func (g *Git) ListFiles(ref string) ([]string, error) {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	sha, err := g.repo.resolve(ref)
+	if err != nil {
+		return nil, err
+	}
+	return unionPaths(g.tree(sha)), nil
+}
+
+// IsAncestor checks if ancestor is an ancestor of descendant.
+func (g *Git) IsAncestor(ancestor, descendant string) (bool, error) {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	a, err := g.repo.resolve(ancestor)
+	if err != nil {
+		return false, err
+	}
+	d, err := g.repo.resolve(descendant)
+	if err != nil {
+		return false, err
+	}
+	return g.repo.ancestors(d)[a], nil
+}
+
+// CommitSubjects returns the subject lines of the commits branch has that
+// base does not, oldest first.
+func (g *Git) CommitSubjects(base, branch string) ([]string, error) {
+	commits, err := g.log(base, branch)
+	if err != nil {
+		return nil, err
+	}
+	var subjects []string
+	for i := len(commits) - 1; i >= 0; i-- {
+		subjects = append(subjects, commits[i].Subject())
+	}
+	return subjects, nil
+}
+
+// CommitIdentities returns the identities on the commits branch has that
+// base does not, newest first. Commits are their own committers.
+func (g *Git) CommitIdentities(base, branch string) ([]git.CommitIdentity, error) {
+	commits, err := g.log(base, branch)
+	if err != nil {
+		return nil, err
+	}
+	var ids []git.CommitIdentity
+	for _, c := range commits {
+		ids = append(ids, git.CommitIdentity{
+			SHA:            c.SHA,
+			AuthorName:     c.Author,
+			AuthorEmail:    c.Email,
+			CommitterName:  c.Author,
+			CommitterEmail: c.Email,
+		})
+	}
+	return ids, nil
+}
+
+// CommitSignatures returns the signatures set on the commits branch has
+// that base does not, newest first. allowedSigners is ignored.
+func (g *Git) CommitSignatures(base, branch, allowedSigners string) ([]git.CommitSignature, error) {
+	commits, err := g.log(base, branch)
+	if err != nil {
+		return nil, err
+	}
+	var sigs []git.CommitSignature
+	for _, c := range commits {
+		sigs = append(sigs, c.Signature)
+	}
+	return sigs, nil
+}
+
+// Trailers returns the values of the key trailer on the commits branch has
+// that base does not, newest first. Any "Key: value" line in the message
+// counts.
+func (g *Git) Trailers(base, branch, key string) ([]string, error) {
+	commits, err := g.log(base, branch)
+	if err != nil {
+		return nil, err
+	}
+	var values []string
+	for _, c := range commits {
+		for _, line := range strings.Split(c.Message, "\n") {
+			if k, v, ok := strings.Cut(line, ":"); ok && strings.EqualFold(strings.TrimSpace(k), key) {
+				values = append(values, strings.TrimSpace(v))
+			}
+		}
+	}
+	return values, nil
+}
+
+// log returns the commits in base..branch, newest first.
+func (g *Git) log(base, branch string) ([]*Commit, error) {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	a, err := g.repo.resolve(base)
+	if err != nil {
+		return nil, err
+	}
+	b, err := g.repo.resolve(branch)
+	if err != nil {
+		return nil, err
+	}
+	return g.repo.between(a, b), nil
+}
+
+// threeDot resolves base...branch to the merge base and branch. Called
+// with the repo lock held.
+func (g *Git) threeDot(base, branch string) (from, to string, err error) {
+	a, err := g.repo.resolve(base)
+	if err != nil {
+		return "", "", err
+	}
+	b, err := g.repo.resolve(branch)
+	if err != nil {
+		return "", "", err
+	}
+	return g.repo.mergeBase(a, b), b, nil
+}
+
+// FetchRefs copies the given branches, or those matching a trailing "*"
+// pattern, from origin into the remote-tracking branches.
+func (g *Git) FetchRefs(remote string, branches ...string) error {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	if remote != "origin" {
+		return fmt.Errorf("git fetch: no such remote %q", remote)
+	}
+	for _, b := range branches {
+		if prefix, ok := strings.CutSuffix(b, "*"); ok {
+			for name, sha := range g.repo.origin {
+				if strings.HasPrefix(name, prefix) {
+					g.repo.tracking[name] = sha
+				}
+			}
+			continue
+		}
+		sha, ok := g.repo.origin[b]
+		if !ok {
+			return fmt.Errorf("git fetch: couldn't find remote ref %s", b)
+		}
+		g.repo.tracking[b] = sha
+	}
+	return nil
+}
+
+// Push pushes a local branch to origin, refusing to rewind it unless force.
+func (g *Git) Push(remote, branch string, force bool) error {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	if remote != "origin" {
+		return fmt.Errorf("git push: no such remote %q", remote)
+	}
+	sha, ok := g.repo.branches[branch]
+	if !ok {
+		return fmt.Errorf("git push: src refspec %s does not match any", branch)
+	}
+	if prev, ok := g.repo.origin[branch]; ok && !force && !g.repo.ancestors(sha)[prev] {
+		return fmt.Errorf("git push: ! [rejected] %s -> %s (non-fast-forward)", branch, branch)
+	}
+	g.repo.origin[branch] = sha
+	g.repo.tracking[branch] = sha
+	return nil
+}
+
+// CreateTag creates the annotated tag name at ref.
+func (g *Git) CreateTag(name, ref, message string) error {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	if _, ok := g.repo.tags[name]; ok {
+		return fmt.Errorf("git tag: tag '%s' already exists", name)
+	}
+	sha, err := g.repo.resolve(ref)
+	if err != nil {
+		return err
+	}
+	g.repo.tags[name] = &Tag{Name: name, Commit: sha, Message: message}
+	return nil
+}
+
+// PushTag pushes the tag name to origin.
+func (g *Git) PushTag(remote, name string) error {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	t, ok := g.repo.tags[name]
+	if !ok || remote != "origin" {
+		return fmt.Errorf("git push: src refspec refs/tags/%s does not match any", name)
+	}
+	t.Pushed = true
+	return nil
+}
+
+// PushHead pushes HEAD to branch on origin, refusing to rewind it.
+func (g *Git) PushHead(remote, branch string) error {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	if remote != "origin" {
+		return fmt.Errorf("git push: no such remote %q", remote)
+	}
+	sha := g.head()
+	if prev, ok := g.repo.origin[branch]; ok && !g.repo.ancestors(sha)[prev] {
+		return fmt.Errorf("git push: ! [rejected] HEAD -> %s (non-fast-forward)", branch)
+	}
+	g.repo.origin[branch] = sha
+	g.repo.tracking[branch] = sha
+	return nil
+}
+
+// RemoteBranchExists checks if a branch exists on origin.
+func (g *Git) RemoteBranchExists(remote, branch string) (bool, error) {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	_, ok := g.repo.origin[branch]
+	return ok && remote == "origin", nil
+}
+
+// DeleteRemoteBranch deletes a branch on origin.
+func (g *Git) DeleteRemoteBranch(remote, branch string) error {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	if _, ok := g.repo.origin[branch]; !ok || remote != "origin" {
+		return fmt.Errorf("git push: unable to delete '%s': remote ref does not exist", branch)
+	}
+	delete(g.repo.origin, branch)
+	delete(g.repo.tracking, branch)
+	return nil
+}
+
+// Merge merges ref into HEAD, fast-forwarding if it can.
+func (g *Git) Merge(ref string) error {
+	return g.mergeRef(ref, "Merge "+ref, true, false)
+}
+
+// MergeNoFF merges branch with a merge commit carrying message.
+func (g *Git) MergeNoFF(branch, message string) error {
+	return g.mergeRef(branch, message, false, false)
+}
+
+// MergeSquash commits branch's changes as one commit with message.
+func (g *Git) MergeSquash(branch, message string) error {
+	return g.mergeRef(branch, message, false, true)
+}
+
+// AmendFile replaces HEAD with a commit that also has path set to content.
+func (g *Git) AmendFile(path, content string) error {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	head := g.repo.commits[g.head()]
+	if head == nil {
+		return errors.New("git commit: nothing to amend")
+	}
+	files := copyFiles(head.Files)
+	files[path] = content
+	c := *head
+	c.Parents = append([]string(nil), head.Parents...)
+	c.Files = files
+	g.setHead(g.repo.add(&c))
+	return nil
+}
+
+// Revert commits the reverse of commit's change from its first parent on
+// HEAD.
+func (g *Git) Revert(commit string) error {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	sha, err := g.repo.resolve(commit)
+	if err != nil {
+		return fmt.Errorf("git revert: %w", err)
+	}
+	c := g.repo.commits[sha]
+	if len(c.Parents) == 0 {
+		return fmt.Errorf("git revert: %.7s has no parent", sha)
+	}
+	ours := g.head()
+	files, conflicts := merge3(c.Files, g.tree(ours), g.tree(c.Parents[0]))
+	if len(conflicts) > 0 {
+		return fmt.Errorf("git revert: could not revert %.7s: conflicts in %s", sha, strings.Join(conflicts, ", "))
+	}
+	msg := fmt.Sprintf("Revert \"%s\"\n\nThis reverts commit %s.", c.Subject(), sha)
+	g.setHead(g.repo.add(&Commit{Parents: []string{ours}, Files: files, Message: msg}))
+	return nil
+}
+
+// MergeFFOnly fast-forwards to branch, failing if that is not possible.
+func (g *Git) MergeFFOnly(branch string) error {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	theirs, err := g.repo.resolve(branch)
+	if err != nil {
+		return err
+	}
+	ours := g.head()
+	switch {
+	case g.repo.ancestors(ours)[theirs]:
+		return nil // Already up to date
+	case g.repo.ancestors(theirs)[ours]:
+		g.setHead(theirs)
+		return nil
+	}
+	return errors.New("git merge: Not possible to fast-forward, aborting")
+}
+
+func (g *Git) mergeRef(ref, message string, ff, squash bool) error {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	if len(g.merging) > 0 {
+		return errors.New("git merge: you have not concluded your merge")
+	}
+	theirs, err := g.repo.resolve(ref)
+	if err != nil {
+		return fmt.Errorf("git merge: %w", err)
+	}
+	ours := g.head()
+	if ours == "" {
+		return errors.New("git merge: nothing checked out")
+	}
+	if g.repo.ancestors(ours)[theirs] {
+		if squash {
+			return errors.New("git commit: nothing to commit, working tree clean")
+		}
+		return nil // Already up to date
+	}
+	if ff && g.repo.ancestors(theirs)[ours] {
+		g.setHead(theirs)
+		return nil
+	}
+	base := g.repo.mergeBase(ours, theirs)
+	files, conflicts := merge3(g.tree(base), g.tree(ours), g.tree(theirs))
+	if len(conflicts) > 0 {
+		g.merging = conflicts
+		return git.ErrMergeConflict
+	}
+	c := &Commit{Parents: []string{ours, theirs}, Files: files, Message: message}
+	if squash {
+		c.Parents = c.Parents[:1]
+	}
+	g.setHead(g.repo.add(c))
+	return nil
+}
+
+// AbortMerge abandons an unfinished merge.
+func (g *Git) AbortMerge() error {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	if len(g.merging) == 0 {
+		return errors.New("git merge: there is no merge to abort")
+	}
+	g.merging = nil
+	return nil
+}
+
+// RebaseOnto replays the commits in upstream..HEAD, oldest first, onto
+// onto and moves HEAD to the result. Each commit's change from its first
+// parent is merged file by file; commits left empty are dropped, as git
+// drops patches already applied. On a conflict HEAD stays put and the
+// rebase must be aborted.
+func (g *Git) RebaseOnto(onto, upstream string) error {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	if len(g.merging) > 0 || g.rebasing {
+		return errors.New("git rebase: a merge or rebase is in progress")
+	}
+	base, err := g.repo.resolve(onto)
+	if err != nil {
+		return fmt.Errorf("git rebase: %w", err)
+	}
+	from, err := g.repo.resolve(upstream)
+	if err != nil {
+		return fmt.Errorf("git rebase: %w", err)
+	}
+	head := g.head()
+	if head == "" {
+		return errors.New("git rebase: nothing checked out")
+	}
+	commits := g.repo.between(from, head)
+	cur := base
+	for i := len(commits) - 1; i >= 0; i-- {
+		c := commits[i]
+		parent := ""
+		if len(c.Parents) > 0 {
+			parent = c.Parents[0]
+		}
+		files, conflicts := merge3(g.tree(parent), g.tree(cur), c.Files)
+		if len(conflicts) > 0 {
+			g.rebasing = true
+			return git.ErrRebaseConflict
+		}
+		if len(changedPaths(g.tree(cur), files)) == 0 {
+			continue
+		}
+		cur = g.repo.add(&Commit{
+			Parents: []string{cur}, Files: files, Message: c.Message,
+			Author: c.Author, Email: c.Email, Signature: c.Signature,
+		})
+	}
+	g.setHead(cur)
+	return nil
+}
+
+// CherryPickRange commits the change from base to head onto HEAD as one
+// commit with message, merged file by file. A change already on HEAD
+// leaves it where it is. On a conflict HEAD stays put and the pick must be
+// aborted with AbortMerge.
+func (g *Git) CherryPickRange(base, head, message string) error {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	if len(g.merging) > 0 || g.rebasing {
+		return errors.New("git cherry-pick: a merge or rebase is in progress")
+	}
+	from, err := g.repo.resolve(base)
+	if err != nil {
+		return fmt.Errorf("git cherry-pick: %w", err)
+	}
+	to, err := g.repo.resolve(head)
+	if err != nil {
+		return fmt.Errorf("git cherry-pick: %w", err)
+	}
+	ours := g.head()
+	if ours == "" {
+		return errors.New("git cherry-pick: nothing checked out")
+	}
+	files, conflicts := merge3(g.tree(from), g.tree(ours), g.tree(to))
+	if len(conflicts) > 0 {
+		g.merging = conflicts
+		return git.ErrMergeConflict
+	}
+	if len(changedPaths(g.tree(ours), files)) == 0 {
+		return nil
+	}
+	g.setHead(g.repo.add(&Commit{Parents: []string{ours}, Files: files, Message: message}))
+	return nil
+}
+
+// AbortRebase abandons a stopped rebase.
+func (g *Git) AbortRebase() error {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	if !g.rebasing {
+		return errors.New("git rebase: no rebase in progress?")
+	}
+	g.rebasing = false
+	return nil
+}
+
+// CheckConflicts checks out target and returns the files source would
+// conflict in if merged into it, leaving target checked out.
+func (g *Git) CheckConflicts(source, target string) ([]string, error) {
+	if err := g.Checkout(target); err != nil {
+		return nil, fmt.Errorf("checkout target %s: %w", target, err)
+	}
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	theirs, err := g.repo.resolve(source)
+	if err != nil {
+		return nil, err
+	}
+	ours := g.head()
+	_, conflicts := merge3(g.tree(g.repo.mergeBase(ours, theirs)), g.tree(ours), g.tree(theirs))
+	return conflicts, nil
+}
+
+// BranchExists checks if a branch exists locally.
+func (g *Git) BranchExists(name string) (bool, error) {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	_, ok := g.repo.branches[name]
+	return ok, nil
+}
+
+// CreateBranchFrom creates a branch at ref.
+func (g *Git) CreateBranchFrom(name, ref string) error {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	if _, ok := g.repo.branches[name]; ok {
+		return fmt.Errorf("git branch: a branch named '%s' already exists", name)
+	}
+	sha, err := g.repo.resolve(ref)
+	if err != nil {
+		return err
+	}
+	g.repo.branches[name] = sha
+	return nil
+}
+
+// DeleteBranch deletes a local branch that no worktree has checked out.
+// force is accepted for git.Git's signature; merged or not, the branch goes.
+func (g *Git) DeleteBranch(name string, force bool) error {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	if _, ok := g.repo.branches[name]; !ok {
+		return fmt.Errorf("git branch: branch '%s' not found", name)
+	}
+	for _, wt := range g.repo.worktrees {
+		if wt.branch == name {
+			return fmt.Errorf("git branch: cannot delete branch '%s' checked out at '%s'", name, wt.dir)
+		}
+	}
+	delete(g.repo.branches, name)
+	return nil
+}
+
+// UpdateBranchRef points a local branch at sha.
+func (g *Git) UpdateBranchRef(name, sha string) error {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	resolved, err := g.repo.resolve(sha)
+	if err != nil {
+		return err
+	}
+	g.repo.branches[name] = resolved
+	return nil
+}
+
+// WorktreeAddDetached adds a worktree at path with HEAD detached at ref.
+// The directory is created with a .git file in it, as git would, so code
+// that looks for an existing worktree on disk finds it.
+func (g *Git) WorktreeAddDetached(path, ref string) error {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	if _, ok := g.repo.worktrees[path]; ok {
+		return fmt.Errorf("git worktree: '%s' already exists", path)
+	}
+	sha := g.head()
+	if ref != "HEAD" || sha == "" {
+		var err error
+		if sha, err = g.repo.resolve(ref); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(path, ".git"), []byte("gitdir: gitfake\n"), 0644); err != nil {
+		return err
+	}
+	g.repo.worktrees[path] = &Git{repo: g.repo, dir: path, detached: sha}
+	return nil
+}
+
+// WorktreeRemove removes a worktree and its directory.
+func (g *Git) WorktreeRemove(path string, force bool) error {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	if _, ok := g.repo.worktrees[path]; !ok {
+		return fmt.Errorf("git worktree: '%s' is not a working tree", path)
+	}
+	delete(g.repo.worktrees, path)
+	return os.RemoveAll(path)
+}
+
+// WorktreePrune forgets worktrees whose directories are gone.
+func (g *Git) WorktreePrune() error {
+	g.repo.mu.Lock()
+	defer g.repo.mu.Unlock()
+	for path := range g.repo.worktrees {
+		if path == g.dir {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(path, ".git")); err != nil {
+			delete(g.repo.worktrees, path)
+		}
+	}
+	return nil
+}
+
+// CloneBare always fails: a Repo cannot be cloned. Callers that keep a
+// bare mirror should fall back to working without it.
+func (g *Git) CloneBare(url, dest string) error {
+	return errors.New("gitfake: cannot clone")
+}
+
+func copyFiles(files map[string]string) map[string]string {
+	out := make(map[string]string, len(files))
+	for k, v := range files {
+		out[k] = v
+	}
+	return out
+}
+
+// unionPaths returns every path in trees, sorted.
+func unionPaths(trees ...map[string]string) []string {
+	seen := make(map[string]bool)
+	for _, t := range trees {
+		for path := range t {
+			seen[path] = true
+		}
+	}
+	paths := make([]string, 0, len(seen))
+	for path := range seen {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// changedPaths returns the paths added, removed, or changed from old to
+// cur, sorted.
+func changedPaths(old, cur map[string]string) []string {
+	var out []string
+	for _, path := range unionPaths(old, cur) {
+		o, ook := old[path]
+		c, cok := cur[path]
+		if o != c || ook != cok {
+			out = append(out, path)
+		}
+	}
+	return out
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// lineChanges returns the lines of cur not in old, in order, and how many
+// lines of old are not in cur, matching lines as a multiset.
+func lineChanges(old, cur string) (added []string, removed int) {
+	count := make(map[string]int)
+	for _, line := range splitLines(old) {
+		count[line]++
+	}
+	for _, line := range splitLines(cur) {
+		if count[line] > 0 {
+			count[line]--
+		} else {
+			added = append(added, line)
+		}
+	}
+	for _, n := range count {
+		removed += n
+	}
+	return added, removed
+}
@@ -0,0 +1,221 @@
+package gitfake
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/git"
+)
+
+// newRepo returns a repo with main on origin and two branches off it.
+func newRepo(t *testing.T) (*Repo, *Git) {
+	t.Helper()
+	r := New()
+	r.Commit("main", "base", map[string]string{"README": "hi\n", "a.txt": "a\n"})
+	r.Publish("main")
+	g := r.Git("/rig")
+	if err := g.Checkout("main"); err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range []string{"nux", "slit"} {
+		if err := g.CreateBranchFrom(b, "main"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return r, g
+}
+
+func TestMerge(t *testing.T) {
+	r, g := newRepo(t)
+	r.Commit("nux", "nux: b\n\nSwarm: gt-1", map[string]string{"b.txt": "b\n"})
+	r.Commit("slit", "slit: edit a", map[string]string{"a.txt": "slit\n"})
+
+	files, err := g.ChangedFiles("main", "nux")
+	if err != nil || !reflect.DeepEqual(files, []string{"b.txt"}) {
+		t.Errorf("ChangedFiles = %v, %v, want [b.txt]", files, err)
+	}
+	if v, _ := g.Trailers("main", "nux", "Swarm"); !reflect.DeepEqual(v, []string{"gt-1"}) {
+		t.Errorf("Trailers = %v, want [gt-1]", v)
+	}
+
+	if err := g.MergeNoFF("nux", "Merge nux"); err != nil {
+		t.Fatalf("MergeNoFF: %v", err)
+	}
+	if err := g.MergeSquash("slit", "Squash slit"); err != nil {
+		t.Fatalf("MergeSquash: %v", err)
+	}
+	head, _ := g.Rev("HEAD")
+	if c := r.Lookup(head); len(c.Parents) != 1 || c.Files["a.txt"] != "slit\n" || c.Files["b.txt"] != "b\n" {
+		t.Errorf("squash commit = %+v, want one parent and both changes", c)
+	}
+	if subjects, _ := g.CommitSubjects("origin/main", "main"); !reflect.DeepEqual(subjects, []string{"nux: b", "Merge nux", "Squash slit"}) {
+		t.Errorf("CommitSubjects = %v", subjects)
+	}
+	if ok, _ := g.IsAncestor("nux", "main"); !ok {
+		t.Error("nux not merged into main")
+	}
+	if files, lines, _ := g.DiffStat("origin/main", "main"); files != 2 || lines != 3 {
+		t.Errorf("DiffStat = %d files, %d lines, want 2, 3", files, lines)
+	}
+}
+
+func TestMerge_Conflict(t *testing.T) {
+	r, g := newRepo(t)
+	r.Commit("nux", "nux", map[string]string{"a.txt": "nux\n"})
+	r.Commit("slit", "slit", map[string]string{"a.txt": "slit\n"})
+
+	if files, err := g.CheckConflicts("slit", "nux"); err != nil || !reflect.DeepEqual(files, []string{"a.txt"}) {
+		t.Errorf("CheckConflicts = %v, %v, want [a.txt]", files, err)
+	}
+	if err := g.Merge("slit"); !errors.Is(err, git.ErrMergeConflict) {
+		t.Fatalf("Merge = %v, want ErrMergeConflict", err)
+	}
+	if err := g.Checkout("main"); err == nil {
+		t.Error("Checkout succeeded in the middle of a merge")
+	}
+	if err := g.AbortMerge(); err != nil {
+		t.Fatalf("AbortMerge: %v", err)
+	}
+	if err := g.MergeFFOnly("slit"); err == nil {
+		t.Error("MergeFFOnly of a diverged branch succeeded")
+	}
+}
+
+func TestFetchPush(t *testing.T) {
+	r, g := newRepo(t)
+	other := r.Git("/other")
+	if err := other.Checkout("nux"); err != nil {
+		t.Fatal(err)
+	}
+	r.Commit("nux", "nux", map[string]string{"b.txt": "b\n"})
+	if err := other.Push("origin", "nux", false); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+
+	if err := g.FetchRefs("origin", "n*", "main"); err != nil {
+		t.Fatalf("FetchRefs: %v", err)
+	}
+	if sha, err := g.Rev("origin/nux"); err != nil || sha != r.Origin("nux") {
+		t.Errorf("origin/nux = %q, %v, want %q", sha, err, r.Origin("nux"))
+	}
+	if err := g.FetchRefs("origin", "gone"); err == nil {
+		t.Error("FetchRefs of a missing branch succeeded")
+	}
+
+	// Rewinding a pushed branch needs force.
+	if err := g.UpdateBranchRef("nux", "main"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Push("origin", "nux", false); err == nil {
+		t.Error("non-fast-forward push succeeded")
+	}
+	if err := g.Push("origin", "nux", true); err != nil {
+		t.Errorf("forced push: %v", err)
+	}
+	if err := g.DeleteBranch("nux", true); err == nil {
+		t.Error("deleted a branch checked out in another worktree")
+	}
+}
+
+func TestWorktrees(t *testing.T) {
+	_, g := newRepo(t)
+	dir := t.TempDir() + "/lane"
+	if err := g.WorktreeAddDetached(dir, "origin/main"); err != nil {
+		t.Fatalf("WorktreeAddDetached: %v", err)
+	}
+	wt := g.repo.Git(dir)
+	if branch, _ := wt.CurrentBranch(); branch != "HEAD" {
+		t.Errorf("CurrentBranch = %q, want detached", branch)
+	}
+	if err := wt.MergeNoFF("slit", "noop"); err != nil {
+		t.Errorf("MergeNoFF of a merged branch: %v", err)
+	}
+	if err := g.WorktreeRemove(dir, true); err != nil {
+		t.Fatalf("WorktreeRemove: %v", err)
+	}
+	if g.repo.Git(dir) == wt {
+		t.Error("removed worktree still registered")
+	}
+}
+
+func TestRebaseOnto(t *testing.T) {
+	r, g := newRepo(t)
+	base := r.Commit("nux", "nux: b", map[string]string{"b.txt": "b\n"})
+	if err := g.UpdateBranchRef("slit", base); err != nil {
+		t.Fatal(err)
+	}
+	r.Commit("slit", "slit: c", map[string]string{"c.txt": "c\n"}) // slit now stacks on nux
+	r.Commit("main", "main: a", map[string]string{"a.txt": "main\n"})
+
+	if err := g.Checkout("slit"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.RebaseOnto("main", base); err != nil {
+		t.Fatalf("RebaseOnto: %v", err)
+	}
+	head, _ := g.Rev("HEAD")
+	if subjects, _ := g.CommitSubjects("main", "slit"); !reflect.DeepEqual(subjects, []string{"slit: c"}) {
+		t.Errorf("slit after rebase = %v, want only its own commit", subjects)
+	}
+	if c := r.Lookup(head); c.Files["a.txt"] != "main\n" || c.Files["c.txt"] != "c\n" || c.Files["b.txt"] != "" {
+		t.Errorf("rebased tree = %v, want main's a.txt and slit's c.txt without nux's b.txt", c.Files)
+	}
+
+	r.Commit("main", "main: c", map[string]string{"c.txt": "other\n"})
+	if err := g.RebaseOnto("main", "HEAD~"); err == nil {
+		t.Error("RebaseOnto an unknown upstream succeeded")
+	}
+	parent := r.Lookup(head).Parents[0]
+	if err := g.RebaseOnto("main", parent); !errors.Is(err, git.ErrRebaseConflict) {
+		t.Fatalf("RebaseOnto = %v, want ErrRebaseConflict", err)
+	}
+	if err := g.AbortRebase(); err != nil {
+		t.Fatalf("AbortRebase: %v", err)
+	}
+	if sha, _ := g.Rev("HEAD"); sha != head {
+		t.Errorf("HEAD after abort = %s, want %s", sha, head)
+	}
+}
+
+func TestCherryPickRange(t *testing.T) {
+	r, g := newRepo(t)
+	r.Commit("main", "main: a", map[string]string{"a.txt": "a\n"})
+	r.Publish("main")
+	if err := g.CreateBranchFrom("release/1.0", "main"); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := g.Rev("main")
+	r.Commit("main", "main: b", map[string]string{"b.txt": "b\n"})
+	fix := r.Commit("main", "main: fix a", map[string]string{"a.txt": "fixed\n"})
+
+	if err := g.Checkout("release/1.0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.CherryPickRange(r.Lookup(fix).Parents[0], fix, "Backport fix"); err != nil {
+		t.Fatalf("CherryPickRange: %v", err)
+	}
+	head, _ := g.Rev("HEAD")
+	if c := r.Lookup(head); c.Files["a.txt"] != "fixed\n" || c.Files["b.txt"] != "" || c.Subject() != "Backport fix" {
+		t.Errorf("picked commit = %q %v, want only the fix", c.Subject(), c.Files)
+	}
+	if err := g.CherryPickRange(before, fix, "Again"); err != nil {
+		t.Fatalf("CherryPickRange of a range: %v", err)
+	}
+	again, _ := g.Rev("HEAD")
+	if c := r.Lookup(again); c.Parents[0] != head || c.Files["b.txt"] != "b\n" {
+		t.Errorf("range pick = %v, want b.txt added on top of the fix", c.Files)
+	}
+
+	r.Commit("release/1.0", "release: a", map[string]string{"a.txt": "other\n"})
+	top, _ := g.Rev("HEAD")
+	if err := g.CherryPickRange(before, fix, "Conflict"); !errors.Is(err, git.ErrMergeConflict) {
+		t.Fatalf("CherryPickRange = %v, want ErrMergeConflict", err)
+	}
+	if err := g.AbortMerge(); err != nil {
+		t.Fatalf("AbortMerge: %v", err)
+	}
+	if sha, _ := g.Rev("HEAD"); sha != top {
+		t.Errorf("HEAD after abort = %s, want %s", sha, top)
+	}
+}
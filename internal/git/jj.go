@@ -0,0 +1,51 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// IsJJ reports whether dir is the root of a Jujutsu (jj) repository
+// colocated with git: .jj and .git side by side. jj keeps git's HEAD
+// detached at the parent of its working-copy commit and snapshots the
+// working tree on every jj command, so git commands that check out,
+// merge, or pull there fight it; use JJ for those, or a separate git
+// worktree.
+func IsJJ(dir string) bool {
+	if info, err := os.Stat(filepath.Join(dir, ".jj")); err != nil || !info.IsDir() {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
+}
+
+// JJ runs jj commands in a colocated working copy.
+type JJ struct {
+	workDir string
+}
+
+// NewJJ creates a JJ for the working copy at workDir.
+func NewJJ(workDir string) *JJ {
+	return &JJ{workDir: workDir}
+}
+
+func (j *JJ) run(args ...string) (string, error) {
+	out, err := util.ExecWithOutput(j.workDir, "jj", args...)
+	if err != nil {
+		return "", fmt.Errorf("jj %s: %w", args[0], err)
+	}
+	return out, nil
+}
+
+// Pull is jj's counterpart of git pull --rebase: it fetches from remote
+// and rebases the working copy's changes onto branch as fetched.
+func (j *JJ) Pull(remote, branch string) error {
+	if _, err := j.run("git", "fetch", "--remote", remote); err != nil {
+		return err
+	}
+	_, err := j.run("rebase", "--branch", "@", "--destination", branch+"@"+remote)
+	return err
+}
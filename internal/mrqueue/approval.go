@@ -0,0 +1,37 @@
+package mrqueue
+
+// NeedsApproval reports whether the MR is waiting for an operator to approve
+// it before the refinery will merge it.
+func (mr *MR) NeedsApproval() bool {
+	return mr.ApprovalReason != ""
+}
+
+// IsApproved reports whether an operator has approved the MR.
+func (mr *MR) IsApproved() bool {
+	return mr.ApprovedBy != ""
+}
+
+// RequireApproval parks an MR until Approve is called. Like held MRs, it
+// stays in the queue but is skipped by ListReady and ListUnclaimed.
+func (q *Queue) RequireApproval(id, reason string) error {
+	if reason == "" {
+		reason = "needs approval"
+	}
+	return q.update(id, func(mr *MR) {
+		mr.ApprovalReason = reason
+	})
+}
+
+// Approve releases an MR waiting for approval and records who approved it,
+// so later refinery cycles do not park it again.
+func (q *Queue) Approve(id, by string) error {
+	if by == "" {
+		by = "operator"
+	}
+	return q.update(id, func(mr *MR) {
+		now := q.clock()
+		mr.ApprovalReason = ""
+		mr.ApprovedBy = by
+		mr.ApprovedAt = &now
+	})
+}
@@ -0,0 +1,46 @@
+package mrqueue
+
+import (
+	"testing"
+)
+
+func TestRequireApprovalAndApprove(t *testing.T) {
+	q := New(t.TempDir())
+
+	mr := &MR{ID: "mr-approve-1", Branch: "polecat/toast", Target: "main", Worker: "toast"}
+	if err := q.Submit(mr); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	if err := q.RequireApproval(mr.ID, "worker toast is not in refinery.workers.allow"); err != nil {
+		t.Fatalf("RequireApproval: %v", err)
+	}
+	got, _ := q.Get(mr.ID)
+	if !got.NeedsApproval() || got.IsApproved() {
+		t.Errorf("expected MR to need approval, got %+v", got)
+	}
+	if ready, _ := q.ListReady(nil); len(ready) != 0 {
+		t.Errorf("MR awaiting approval should not be ready, got %d ready", len(ready))
+	}
+	if unclaimed, _ := q.ListUnclaimed(); len(unclaimed) != 0 {
+		t.Errorf("MR awaiting approval should not be claimable, got %d", len(unclaimed))
+	}
+
+	if err := q.Approve(mr.ID, "mayor"); err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+	got, _ = q.Get(mr.ID)
+	if got.NeedsApproval() || got.ApprovedBy != "mayor" || got.ApprovedAt == nil {
+		t.Errorf("approve should clear the reason and record the approver: %+v", got)
+	}
+	if ready, _ := q.ListReady(nil); len(ready) != 1 {
+		t.Errorf("approved MR should be ready, got %d ready", len(ready))
+	}
+}
+
+func TestApprove_NotFound(t *testing.T) {
+	q := New(t.TempDir())
+	if err := q.Approve("mr-missing", "mayor"); err != ErrNotFound {
+		t.Errorf("Approve on missing MR = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,309 @@
+package mrqueue
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// AuditKeySecret is the rig secret ('gt rig secret set <rig> audit_key')
+// that signs event log entries. Without it entries are hash-chained but
+// unsigned.
+const AuditKeySecret = "audit_key"
+
+// RigAuditKey returns the rig's audit signing key, or nil if none is set
+// or the machine cannot decrypt it.
+func RigAuditKey(rigPath string) []byte {
+	store, err := config.OpenSecretStore(rigPath, false)
+	if err != nil {
+		return nil
+	}
+	key, err := store.Get(AuditKeySecret)
+	if err != nil || key == "" {
+		return nil
+	}
+	return []byte(key)
+}
+
+// eventHash is the chain hash of event: SHA-256 over its JSON encoding
+// without Hash and Sig, so it covers Seq and Prev.
+func eventHash(event Event) (string, error) {
+	event.Hash, event.Sig = "", ""
+	data, err := json.Marshal(event)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// eventSig is the HMAC-SHA256 of an event's hash under key.
+func eventSig(hash string, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(hash))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// anchorSig is the HMAC-SHA256 under key of what a ChainAnchor vouches
+// for: the archived event the log continues from, and the archive.
+func anchorSig(anchor ChainAnchor, key []byte) string {
+	return eventSig(fmt.Sprintf("anchor:%d:%s:%s", anchor.Seq, anchor.Hash, filepath.Base(anchor.Archive)), key)
+}
+
+// chainEvent links event to the last chained event in the log at f,
+// setting Seq, Prev, Hash, and, with a key, Sig.
+func chainEvent(f *os.File, event *Event, key []byte) error {
+	last, err := lastEvent(f)
+	if err != nil {
+		return err
+	}
+	event.Seq, event.Prev = 1, ""
+	if last != nil && last.Hash != "" {
+		event.Seq, event.Prev = last.Seq+1, last.Hash
+	}
+	if event.Hash, err = eventHash(*event); err != nil {
+		return err
+	}
+	event.Sig = ""
+	if len(key) > 0 {
+		event.Sig = eventSig(event.Hash, key)
+	}
+	return nil
+}
+
+// lastEvent returns the final event in f, or nil for an empty log or a
+// malformed last line, reading backwards so appending stays cheap as the
+// log grows.
+func lastEvent(f *os.File) (*Event, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	end := info.Size()
+	var tail []byte
+	for chunk := int64(4096); ; chunk *= 2 {
+		start := end - chunk
+		if start < 0 {
+			start = 0
+		}
+		tail = make([]byte, end-start)
+		if _, err := f.ReadAt(tail, start); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("reading event log: %w", err)
+		}
+		trimmed := bytes.TrimRight(tail, "\n")
+		if i := bytes.LastIndexByte(trimmed, '\n'); i >= 0 || start == 0 {
+			line := trimmed[i+1:]
+			if len(line) == 0 {
+				return nil, nil
+			}
+			var event Event
+			if err := json.Unmarshal(line, &event); err != nil {
+				return nil, nil // Start a new chain; Verify reports the break
+			}
+			return &event, nil
+		}
+	}
+}
+
+// ChainProblem is one place the event log fails verification. Line is 0
+// for problems with its ChainAnchor.
+type ChainProblem struct {
+	Line   int    `json:"line"`
+	Seq    int64  `json:"seq,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// ChainReport is the result of verifying an event log.
+type ChainReport struct {
+	// Events is how many events the log holds; Legacy of them were written
+	// before hash chaining and precede the chain.
+	Events int `json:"events"`
+	Legacy int `json:"legacy"`
+
+	// Signed is how many events carry a signature. Verified is false when
+	// signatures were present but no key was given to check them.
+	Signed   int  `json:"signed"`
+	Verified bool `json:"verified"`
+
+	// HeadSeq and HeadHash identify the newest chained event. Record them
+	// elsewhere to detect later truncation of the log.
+	HeadSeq  int64  `json:"head_seq"`
+	HeadHash string `json:"head_hash,omitempty"`
+
+	// Compacted is the Seq of the newest event Compact moved to an
+	// archive; the log's chain continues from it.
+	Compacted int64 `json:"compacted,omitempty"`
+
+	Problems []ChainProblem `json:"problems,omitempty"`
+}
+
+// OK reports whether verification found no problems.
+func (r *ChainReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// Verify checks the event log's hash chain: every event after the first
+// chained one must be chained, hash to its recorded Hash, and link to its
+// predecessor by Prev and Seq. With key, signatures are checked too, and
+// once events are signed every later one must be. Edited, reordered,
+// inserted, or deleted entries show up as problems; deleting the newest
+// entries does not, which is what HeadSeq and HeadHash are for. A log
+// Compact shortened may start where its ChainAnchor left off, provided
+// the anchor's archive ends with the event it names and, for a signed
+// log, the anchor is signed too.
+func (l *EventLogger) Verify(key []byte) (*ChainReport, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.Open(l.logPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &ChainReport{Verified: true}, nil
+		}
+		return nil, fmt.Errorf("opening event log: %w", err)
+	}
+	defer f.Close()
+	anchor, err := l.readAnchor()
+	if err != nil {
+		return nil, err
+	}
+	return verifyChain(f, key, anchor)
+}
+
+func verifyChain(r io.Reader, key []byte, anchor *ChainAnchor) (*ChainReport, error) {
+	report := &ChainReport{Verified: true}
+	problem := func(line int, seq int64, format string, args ...interface{}) {
+		report.Problems = append(report.Problems, ChainProblem{Line: line, Seq: seq, Reason: fmt.Sprintf(format, args...)})
+	}
+	if anchor != nil {
+		report.Compacted = anchor.Seq
+		checkAnchor(anchor, key, report, problem)
+	}
+
+	var prev *Event
+	signing := false
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal(line, &event); err != nil {
+			problem(n, 0, "malformed entry")
+			continue
+		}
+		report.Events++
+
+		if event.Hash == "" {
+			if prev != nil {
+				problem(n, 0, "unchained entry after the chain began")
+			} else {
+				report.Legacy++
+			}
+			continue
+		}
+
+		if sum, err := eventHash(event); err != nil || sum != event.Hash {
+			problem(n, event.Seq, "entry does not match its hash (edited)")
+		}
+		switch {
+		case prev == nil && anchor != nil && event.Prev == anchor.Hash && event.Seq == anchor.Seq+1:
+			// Continues from the events Compact archived
+			if event.Sig != "" && anchor.Sig == "" {
+				problem(0, anchor.Seq, "unsigned chain anchor before signed entries (log cut and re-anchored)")
+			}
+		case prev == nil && (event.Prev != "" || event.Seq != 1):
+			problem(n, event.Seq, "chain starts mid-way (earlier entries removed)")
+		case prev != nil && event.Prev != prev.Hash:
+			problem(n, event.Seq, "entry does not follow seq %d (entries removed, inserted, or reordered)", prev.Seq)
+		case prev != nil && event.Seq != prev.Seq+1:
+			problem(n, event.Seq, "sequence jumps from %d", prev.Seq)
+		}
+
+		if event.Sig != "" {
+			report.Signed++
+			signing = true
+			if len(key) == 0 {
+				report.Verified = false
+			} else if !hmac.Equal([]byte(event.Sig), []byte(eventSig(event.Hash, key))) {
+				problem(n, event.Seq, "bad signature")
+			}
+		} else if signing {
+			problem(n, event.Seq, "unsigned entry after signing began")
+		}
+
+		e := event
+		prev = &e
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading event log: %w", err)
+	}
+	if prev != nil {
+		report.HeadSeq, report.HeadHash = prev.Seq, prev.Hash
+	}
+	return report, nil
+}
+
+// checkAnchor reports problems with anchor: a bad signature, or an archive
+// that does not end with the event the anchor names.
+func checkAnchor(anchor *ChainAnchor, key []byte, report *ChainReport, problem func(int, int64, string, ...interface{})) {
+	if anchor.Sig != "" {
+		if len(key) == 0 {
+			report.Verified = false
+		} else if !hmac.Equal([]byte(anchor.Sig), []byte(anchorSig(*anchor, key))) {
+			problem(0, anchor.Seq, "chain anchor has a bad signature")
+		}
+	}
+	last, err := lastArchivedEvent(anchor.Archive)
+	if err != nil {
+		problem(0, anchor.Seq, "cannot read the chain anchor's archive: %v", err)
+		return
+	}
+	if last == nil || last.Seq != anchor.Seq || last.Hash != anchor.Hash {
+		problem(0, anchor.Seq, "archive %s does not end with the anchored entry", filepath.Base(anchor.Archive))
+		return
+	}
+	if sum, err := eventHash(*last); err != nil || sum != last.Hash {
+		problem(0, anchor.Seq, "anchored entry in %s does not match its hash (edited)", filepath.Base(anchor.Archive))
+	}
+}
+
+// lastArchivedEvent returns the newest chained event in a gzipped archive
+// Compact wrote, or nil if it holds none.
+func lastArchivedEvent(path string) (*Event, error) {
+	f, err := os.Open(path) //nolint:gosec // G304: path is from the rig's own anchor
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	var last *Event
+	scanner := bufio.NewScanner(zr)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err == nil && event.Hash != "" {
+			last = &event
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return last, nil
+}
@@ -0,0 +1,313 @@
+package mrqueue
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/clock"
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+func writeChainedLog(t *testing.T, logger *EventLogger) {
+	t.Helper()
+	mr := &MR{ID: "mr-1", Branch: "polecat/nux", Target: "main"}
+	for _, log := range []func() error{
+		func() error { return logger.LogMergeStarted(mr) },
+		func() error { return logger.LogMergeFailed(mr, "tests failed") },
+		func() error { return logger.LogMerged(mr, "abc123") },
+	} {
+		if err := log(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestEventLogger_Verify(t *testing.T) {
+	logger := NewEventLogger(t.TempDir())
+	writeChainedLog(t, logger)
+
+	report, err := logger.Verify(nil)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if !report.OK() || report.Events != 3 || report.HeadSeq != 3 || report.HeadHash == "" {
+		t.Fatalf("Verify of an untouched log = %+v, want 3 chained events and no problems", report)
+	}
+
+	data, err := os.ReadFile(logger.LogPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.SplitAfter(bytes.TrimRight(data, "\n"), []byte("\n"))
+
+	tests := []struct {
+		name string
+		log  []byte
+		want string
+	}{
+		{"edited", bytes.Replace(data, []byte("tests failed"), []byte("flaky infra"), 1), "edited"},
+		{"deleted", append(append([]byte{}, lines[0]...), lines[2]...), "entries removed"},
+		{"reordered", bytes.Join([][]byte{lines[1], lines[0], lines[2]}, nil), "chain starts mid-way"},
+		{"inserted", append(append([]byte{}, data...), []byte(`{"type":"merged","mr_id":"mr-2"}`+"\n")...), "unchained entry"},
+	}
+	for _, tt := range tests {
+		report, err := verifyChain(bytes.NewReader(tt.log), nil, nil)
+		if err != nil {
+			t.Fatalf("%s: verifyChain: %v", tt.name, err)
+		}
+		if report.OK() || !strings.Contains(report.Problems[0].Reason, tt.want) {
+			t.Errorf("%s: problems = %+v, want %q", tt.name, report.Problems, tt.want)
+		}
+	}
+}
+
+func TestEventLogger_VerifyLegacy(t *testing.T) {
+	logger := NewEventLogger(t.TempDir())
+	if err := os.WriteFile(logger.LogPath(), []byte(`{"type":"merged","mr_id":"mr-0"}`+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	writeChainedLog(t, logger)
+
+	report, err := logger.Verify(nil)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if !report.OK() || report.Legacy != 1 || report.HeadSeq != 3 {
+		t.Errorf("Verify of a log with pre-chain events = %+v, want 1 legacy event and a clean chain", report)
+	}
+}
+
+// signingRig returns a rig whose audit key is "audit-signing-key".
+func signingRig(t *testing.T) string {
+	t.Helper()
+	t.Setenv(config.SecretsKeyEnvVar, base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32)))
+	rigPath := t.TempDir()
+	store, err := config.OpenSecretStore(rigPath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(AuditKeySecret, "audit-signing-key"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+	return rigPath
+}
+
+func TestEventLogger_VerifySigned(t *testing.T) {
+	rigPath := signingRig(t)
+
+	logger := NewEventLoggerFromRig(rigPath)
+	writeChainedLog(t, logger)
+
+	report, err := logger.Verify([]byte("audit-signing-key"))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if !report.OK() || report.Signed != 3 || !report.Verified {
+		t.Errorf("Verify with the signing key = %+v, want 3 verified signatures", report)
+	}
+	if report, _ := logger.Verify([]byte("wrong-key")); report.OK() {
+		t.Error("Verify with the wrong key found no problems")
+	}
+	if report, _ := logger.Verify(nil); !report.OK() || report.Verified {
+		t.Errorf("Verify without a key = %+v, want chain OK but signatures unverified", report)
+	}
+}
+
+func TestEventLogger_Compact(t *testing.T) {
+	logger := NewEventLogger(t.TempDir())
+	writeChainedLog(t, logger)
+	offset := logger.Size()
+	archiveDir := filepath.Join(t.TempDir(), "history")
+
+	moved, archive, err := logger.Compact(time.Now().Add(time.Hour), archiveDir)
+	if err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	if moved != 2 {
+		t.Fatalf("moved %d events, want 2 (the newest stays)", moved)
+	}
+	f, err := os.Open(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archived, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(archived), "\n"); n != 2 {
+		t.Errorf("archive holds %d events, want 2", n)
+	}
+
+	if err := logger.LogMerged(&MR{ID: "mr-2", Branch: "polecat/furiosa", Target: "main"}, "def456"); err != nil {
+		t.Fatal(err)
+	}
+	report, err := logger.Verify(nil)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if !report.OK() || report.Events != 2 || report.Compacted != 2 || report.HeadSeq != 4 {
+		t.Errorf("Verify of a compacted log = %+v, want 2 events continuing from seq 2", report)
+	}
+
+	// A reader tailing from before the compaction picks up from the end
+	if events, _, err := logger.ReadEventsFrom(offset); err != nil || len(events) != 0 {
+		t.Errorf("ReadEventsFrom(stale offset) = %v, %v", events, err)
+	}
+
+	if moved, _, err := logger.Compact(time.Now().Add(-time.Hour), archiveDir); err != nil || moved != 0 {
+		t.Errorf("Compact of recent events moved %d, %v", moved, err)
+	}
+}
+
+func TestEventLogger_CompactSameInstant(t *testing.T) {
+	clk := clock.NewFake(time.Date(2031, 3, 1, 12, 0, 0, 0, time.UTC))
+	logger := NewEventLogger(t.TempDir())
+	logger.SetClock(clk)
+	archiveDir := filepath.Join(t.TempDir(), "history")
+
+	// Two compactions without the clock moving: the second must not
+	// replace the first's archive
+	var archives []string
+	for i := 0; i < 2; i++ {
+		writeChainedLog(t, logger)
+		moved, archive, err := logger.Compact(clk.Now().Add(time.Second), archiveDir)
+		if err != nil {
+			t.Fatalf("Compact: %v", err)
+		}
+		if moved == 0 {
+			t.Fatalf("compaction %d moved nothing", i+1)
+		}
+		archives = append(archives, archive)
+	}
+	if archives[0] == archives[1] {
+		t.Fatalf("both compactions wrote %s", archives[0])
+	}
+	if want := "mq_events-20310301-120000.000000000.jsonl.gz"; filepath.Base(archives[0]) != want {
+		t.Errorf("archive named %s, want %s (by the logger's clock)", filepath.Base(archives[0]), want)
+	}
+
+	archived := 0
+	for _, archive := range archives {
+		f, err := os.Open(archive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(zr)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		archived += strings.Count(string(data), "\n")
+	}
+	report, err := logger.Verify(nil)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if archived+report.Events != 6 {
+		t.Errorf("archives hold %d events and the log %d, want all 6", archived, report.Events)
+	}
+	if !report.OK() {
+		t.Errorf("Verify after compacting twice = %+v", report)
+	}
+	if anchor, _ := logger.readAnchor(); anchor == nil || !anchor.At.Equal(clk.Now()) {
+		t.Errorf("anchor = %+v, want dated %s", anchor, clk.Now())
+	}
+}
+
+func TestEventLogger_VerifyAnchor(t *testing.T) {
+	key := []byte("audit-signing-key")
+	newLog := func() *EventLogger {
+		logger := NewEventLoggerFromRig(signingRig(t))
+		writeChainedLog(t, logger)
+		writeChainedLog(t, logger)
+		return logger
+	}
+	writeAnchor := func(logger *EventLogger, anchor ChainAnchor) {
+		data, err := json.Marshal(anchor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(logger.anchorPath(), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Compacted for real: the anchor is signed and matches its archive
+	logger := newLog()
+	if _, _, err := logger.Compact(time.Now().Add(time.Hour), t.TempDir()); err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	anchor, err := logger.Anchor()
+	if err != nil || anchor == nil || anchor.Sig == "" {
+		t.Fatalf("Anchor() = %+v, %v, want a signed anchor", anchor, err)
+	}
+	if report, _ := logger.Verify(key); !report.OK() || !report.Verified {
+		t.Errorf("Verify of a compacted signed log = %+v", report)
+	}
+	if report, _ := logger.Verify(nil); !report.OK() || report.Verified {
+		t.Errorf("Verify without the key = %+v, want OK but unverified", report)
+	}
+
+	// The archive emptied
+	if err := os.WriteFile(anchor.Archive, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if report, _ := logger.Verify(key); report.OK() {
+		t.Error("Verify passed with the anchor's archive emptied")
+	}
+
+	// The head of a log cut off, and a matching anchor and archive forged
+	// without the key
+	logger = newLog()
+	data, err := os.ReadFile(logger.logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	var cut Event
+	if err := json.Unmarshal(lines[2], &cut); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write(bytes.Join(lines[:3], nil))
+	_ = zw.Close()
+	archive := filepath.Join(t.TempDir(), "mq_events-forged.jsonl.gz")
+	if err := os.WriteFile(archive, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(logger.logPath, bytes.Join(lines[3:], nil), 0600); err != nil {
+		t.Fatal(err)
+	}
+	forged := ChainAnchor{Seq: cut.Seq, Hash: cut.Hash, Archive: archive, At: time.Now()}
+	writeAnchor(logger, forged)
+	if report, _ := logger.Verify(key); report.OK() {
+		t.Error("Verify passed a signed log re-anchored without a signature")
+	}
+	forged.Sig = anchorSig(forged, []byte("wrong-key"))
+	writeAnchor(logger, forged)
+	report, _ := logger.Verify(key)
+	if report.OK() || report.Problems[0].Line != 0 || !strings.Contains(report.Problems[0].Reason, "bad signature") {
+		t.Errorf("Verify of an anchor signed with the wrong key = %+v, want a bad signature", report)
+	}
+}
@@ -0,0 +1,156 @@
+package mrqueue
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gofrs/flock"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// ChainAnchor records where Compact last cut the event log: the newest
+// event it moved to an archive, which the log's first event now chains
+// from. With the rig's audit key it is signed, like the events, so the
+// log cannot be cut and re-anchored without the key.
+type ChainAnchor struct {
+	Seq     int64     `json:"seq"`
+	Hash    string    `json:"hash"`
+	Archive string    `json:"archive"`
+	At      time.Time `json:"at"`
+	Sig     string    `json:"sig,omitempty"`
+}
+
+// anchorPath is where Compact records its ChainAnchor.
+func (l *EventLogger) anchorPath() string {
+	return l.logPath + ".anchor"
+}
+
+// readAnchor returns the log's ChainAnchor, or nil if it was never
+// compacted.
+func (l *EventLogger) readAnchor() (*ChainAnchor, error) {
+	data, err := os.ReadFile(l.anchorPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading chain anchor: %w", err)
+	}
+	var anchor ChainAnchor
+	if err := json.Unmarshal(data, &anchor); err != nil {
+		return nil, fmt.Errorf("reading chain anchor: %w", err)
+	}
+	return &anchor, nil
+}
+
+// Anchor returns the log's ChainAnchor, naming the latest archive
+// Compact wrote, or nil if the log was never compacted.
+func (l *EventLogger) Anchor() (*ChainAnchor, error) {
+	return l.readAnchor()
+}
+
+// Compact moves events older than before out of the log into a gzipped
+// archive in dir, named for when it was made (never replacing an earlier
+// archive), and returns how many it
+// moved and the archive's path ("" if none were). The newest event always
+// stays, so new events keep chaining from it, and Verify accepts the
+// shortened log by the ChainAnchor Compact leaves beside it.
+//
+// Compacting rewrites the log, so a reader tailing it with
+// ReadEventsFrom may skip or repeat events appended around then.
+func (l *EventLogger) Compact(before time.Time, dir string) (int, string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lock := flock.New(l.logPath + ".lock")
+	if err := lock.Lock(); err != nil {
+		return 0, "", fmt.Errorf("locking event log: %w", err)
+	}
+	defer func() { _ = lock.Unlock() }()
+
+	data, err := os.ReadFile(l.logPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, "", nil
+		}
+		return 0, "", fmt.Errorf("reading event log: %w", err)
+	}
+
+	// The log is in time order: cut before the first event at or after
+	// before, keeping the last line whatever its age.
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	if len(lines) > 0 && len(bytes.TrimSpace(lines[len(lines)-1])) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	cut, moved := 0, 0
+	var last *Event
+	for i, line := range lines[:max(len(lines)-1, 0)] {
+		var event Event
+		if err := json.Unmarshal(line, &event); err == nil {
+			if !event.Timestamp.Before(before) {
+				break
+			}
+			moved++
+			if event.Hash != "" {
+				e := event
+				last = &e
+			}
+		}
+		cut = i + 1
+	}
+	if moved == 0 {
+		return 0, "", nil
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, "", fmt.Errorf("creating archive directory: %w", err)
+	}
+	// Two compactions in the same instant (a fake clock, or a coarse one)
+	// must not share an archive; the lock keeps them from racing for one.
+	now := l.clock()
+	stamp := now.UTC().Format("20060102-150405.000000000")
+	archive := filepath.Join(dir, "mq_events-"+stamp+".jsonl.gz")
+	for n := 2; ; n++ {
+		if _, err := os.Lstat(archive); os.IsNotExist(err) {
+			break
+		} else if err != nil {
+			return 0, "", fmt.Errorf("checking archive: %w", err)
+		}
+		archive = filepath.Join(dir, fmt.Sprintf("mq_events-%s-%d.jsonl.gz", stamp, n))
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	for _, line := range lines[:cut] {
+		if _, err := zw.Write(line); err != nil {
+			return 0, "", err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return 0, "", err
+	}
+	if err := util.AtomicWriteFile(archive, buf.Bytes(), 0600); err != nil {
+		return 0, "", fmt.Errorf("writing archive: %w", err)
+	}
+
+	// Anchor before shortening the log: should this stop between the two,
+	// the log still verifies from its original start.
+	if last != nil {
+		anchor := ChainAnchor{Seq: last.Seq, Hash: last.Hash, Archive: archive, At: now}
+		if l.rigPath != "" {
+			if key := RigAuditKey(l.rigPath); len(key) > 0 {
+				anchor.Sig = anchorSig(anchor, key)
+			}
+		}
+		if err := util.AtomicWriteJSON(l.anchorPath(), anchor); err != nil {
+			return 0, "", fmt.Errorf("writing chain anchor: %w", err)
+		}
+	}
+	if err := util.AtomicWriteFile(l.logPath, bytes.Join(lines[cut:], nil), 0600); err != nil {
+		return 0, "", fmt.Errorf("rewriting event log: %w", err)
+	}
+	return moved, archive, nil
+}
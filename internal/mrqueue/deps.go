@@ -0,0 +1,50 @@
+package mrqueue
+
+// WaitingOn returns the prerequisite issues (DependsOn) that still have an
+// MR in queued, so the MR must not merge before them.
+func (mr *MR) WaitingOn(queued []*MR) []string {
+	if len(mr.DependsOn) == 0 {
+		return nil
+	}
+	pending := make(map[string]bool)
+	for _, other := range queued {
+		if other.ID != mr.ID && other.SourceIssue != "" {
+			pending[other.SourceIssue] = true
+		}
+	}
+	var waiting []string
+	for _, issue := range mr.DependsOn {
+		if pending[issue] {
+			waiting = append(waiting, issue)
+		}
+	}
+	return waiting
+}
+
+// Stacked reports whether the MR the MR's branch is stacked on is still in
+// queued, so the MR must not merge before it.
+func (mr *MR) Stacked(queued []*MR) bool {
+	if mr.StackedOn == "" {
+		return false
+	}
+	for _, other := range queued {
+		if other.ID == mr.StackedOn {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitWaiting splits mrs into those free to merge and those waiting on a
+// prerequisite issue's MR, or the MR they are stacked on, still in queued,
+// keeping their order.
+func SplitWaiting(mrs, queued []*MR) (free, waiting []*MR) {
+	for _, mr := range mrs {
+		if len(mr.WaitingOn(queued)) > 0 || mr.Stacked(queued) {
+			waiting = append(waiting, mr)
+		} else {
+			free = append(free, mr)
+		}
+	}
+	return free, waiting
+}
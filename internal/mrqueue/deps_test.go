@@ -0,0 +1,40 @@
+package mrqueue
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitWaiting(t *testing.T) {
+	schema := &MR{ID: "mr-1", SourceIssue: "gt-schema"}
+	api := &MR{ID: "mr-2", SourceIssue: "gt-api", DependsOn: []string{"gt-schema", "gt-auth"}}
+	ui := &MR{ID: "mr-3", SourceIssue: "gt-ui", DependsOn: []string{"gt-auth"}}
+	queued := []*MR{schema, api, ui}
+
+	if got := api.WaitingOn(queued); !reflect.DeepEqual(got, []string{"gt-schema"}) {
+		t.Errorf("WaitingOn = %v, want [gt-schema] (gt-auth has no MR queued)", got)
+	}
+	free, waiting := SplitWaiting(queued, queued)
+	if len(free) != 2 || free[0] != schema || free[1] != ui || len(waiting) != 1 || waiting[0] != api {
+		t.Errorf("SplitWaiting = %v, %v; want schema and ui free, api waiting", free, waiting)
+	}
+
+	// Once the prerequisite merges, the dependent is free
+	if got := api.WaitingOn([]*MR{api, ui}); len(got) != 0 {
+		t.Errorf("WaitingOn after merge = %v, want none", got)
+	}
+}
+
+func TestSplitWaiting_Stacked(t *testing.T) {
+	base := &MR{ID: "mr-1", Branch: "polecat/nux/gt-1"}
+	top := &MR{ID: "mr-2", Branch: "polecat/nux/gt-2", StackedOn: "mr-1", StackBase: "abc123"}
+	queued := []*MR{base, top}
+
+	free, waiting := SplitWaiting(queued, queued)
+	if len(free) != 1 || free[0] != base || len(waiting) != 1 || waiting[0] != top {
+		t.Errorf("SplitWaiting = %v, %v; want base free, top waiting", free, waiting)
+	}
+	if top.Stacked([]*MR{top}) {
+		t.Error("top still stacked once its base left the queue")
+	}
+}
@@ -2,12 +2,19 @@
 package mrqueue
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/gofrs/flock"
+	"github.com/steveyegge/gastown/internal/clock"
+	"github.com/steveyegge/gastown/internal/util"
 )
 
 // EventType represents the type of MQ lifecycle event.
@@ -22,6 +29,33 @@ const (
 	EventMergeFailed EventType = "merge_failed"
 	// EventMergeSkipped indicates an MR was skipped (already merged, etc.).
 	EventMergeSkipped EventType = "merge_skipped"
+	// EventHeld indicates an operator put an MR on hold.
+	EventHeld EventType = "held"
+	// EventRequeued indicates an MR was returned to the ready pool.
+	EventRequeued EventType = "requeued"
+	// EventApprovalRequired indicates an MR from an untrusted worker is
+	// waiting for an operator.
+	EventApprovalRequired EventType = "approval_required"
+	// EventApproved indicates an operator approved an MR for merging.
+	EventApproved EventType = "approved"
+	// EventConflictResolved indicates the rig's resolver agent rebased an
+	// MR's branch through its conflicts.
+	EventConflictResolved EventType = "conflict_resolved"
+	// EventSwarmLanded indicates a swarm's integration branch merged into
+	// the rig's target, completing the swarm.
+	EventSwarmLanded EventType = "swarm_landed"
+	// EventSwarmAborted indicates an operator aborted a swarm, flushing its
+	// MRs from the queue.
+	EventSwarmAborted EventType = "swarm_aborted"
+	// EventQuarantined indicates the refinery paused the rig after a run
+	// of MRs failed their checks.
+	EventQuarantined EventType = "quarantined"
+	// EventDeployed indicates a refinery.deploy rule triggered a deployment
+	// of a merge.
+	EventDeployed EventType = "deployed"
+	// EventDeployFailed indicates a refinery.deploy rule failed to trigger
+	// a deployment of a merge.
+	EventDeployFailed EventType = "deploy_failed"
 )
 
 // Event represents a single MQ lifecycle event.
@@ -36,12 +70,41 @@ type Event struct {
 	Rig         string    `json:"rig,omitempty"`
 	MergeCommit string    `json:"merge_commit,omitempty"` // For merged events
 	Reason      string    `json:"reason,omitempty"`       // For failed/skipped events
+	FailureType string    `json:"failure_type,omitempty"` // For failed events: conflict, tests, build
+	FailedCheck string    `json:"failed_check,omitempty"` // For failed events: the check that failed
+	Files       int       `json:"files,omitempty"`        // For merged events: files changed on the target
+	Lines       int       `json:"lines,omitempty"`        // For merged events: lines added plus removed
+	Deploy      string    `json:"deploy,omitempty"`       // For deploy events: the refinery.deploy rule
+
+	// Hash chain (see Verify): Seq numbers chained events from 1, Prev is
+	// the previous event's Hash, and Sig is Hash signed with the rig's
+	// audit key, when one is set.
+	Seq  int64  `json:"seq,omitempty"`
+	Prev string `json:"prev,omitempty"`
+	Hash string `json:"hash,omitempty"`
+	Sig  string `json:"sig,omitempty"`
 }
 
-// EventLogger handles writing MQ events to the event log.
+// EventLogger handles writing MQ events to the event log. Each event is
+// hash-chained to the one before it, so the log is tamper-evident.
 type EventLogger struct {
 	logPath string
+	rigPath string // For the audit signing key; "" when unknown
 	mu      sync.Mutex
+
+	// clk dates events logged without a timestamp, and compactions; nil
+	// means the system clock.
+	clk clock.Clock
+}
+
+// SetClock makes the logger date events and compactions by c instead of
+// the system clock.
+func (l *EventLogger) SetClock(c clock.Clock) {
+	l.clk = c
+}
+
+func (l *EventLogger) clock() time.Time {
+	return clock.Or(l.clk).Now()
 }
 
 // NewEventLogger creates a new EventLogger for the given beads directory.
@@ -53,7 +116,9 @@ func NewEventLogger(beadsDir string) *EventLogger {
 
 // NewEventLoggerFromRig creates an EventLogger for the given rig path.
 func NewEventLoggerFromRig(rigPath string) *EventLogger {
-	return NewEventLogger(filepath.Join(rigPath, ".beads"))
+	l := NewEventLogger(filepath.Join(rigPath, ".beads"))
+	l.rigPath = rigPath
+	return l
 }
 
 // LogEvent writes an event to the MQ event log.
@@ -63,27 +128,44 @@ func (l *EventLogger) LogEvent(event Event) error {
 
 	// Ensure timestamp is set
 	if event.Timestamp.IsZero() {
-		event.Timestamp = time.Now()
+		event.Timestamp = l.clock()
 	}
+	// Failure reasons can quote check output; keep credentials out of history
+	event.Reason = util.Redact(event.Reason)
 
 	// Ensure log directory exists
 	if err := os.MkdirAll(filepath.Dir(l.logPath), 0755); err != nil {
 		return fmt.Errorf("creating log directory: %w", err)
 	}
 
-	// Marshal event to JSON
-	data, err := json.Marshal(event)
-	if err != nil {
-		return fmt.Errorf("marshaling event: %w", err)
+	// Lock across processes: the CLI and the refinery both append, and each
+	// entry chains to the one before it
+	lock := flock.New(l.logPath + ".lock")
+	if err := lock.Lock(); err != nil {
+		return fmt.Errorf("locking event log: %w", err)
 	}
+	defer func() { _ = lock.Unlock() }()
 
-	// Append to log file
-	f, err := os.OpenFile(l.logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	f, err := os.OpenFile(l.logPath, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0600)
 	if err != nil {
 		return fmt.Errorf("opening event log: %w", err)
 	}
 	defer f.Close()
 
+	var key []byte
+	if l.rigPath != "" {
+		key = RigAuditKey(l.rigPath)
+	}
+	if err := chainEvent(f, &event, key); err != nil {
+		return fmt.Errorf("chaining event: %w", err)
+	}
+
+	// Marshal event to JSON
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshaling event: %w", err)
+	}
+
 	if _, err := f.Write(append(data, '\n')); err != nil {
 		return fmt.Errorf("writing event: %w", err)
 	}
@@ -106,6 +188,12 @@ func (l *EventLogger) LogMergeStarted(mr *MR) error {
 
 // LogMerged logs a merged event.
 func (l *EventLogger) LogMerged(mr *MR, mergeCommit string) error {
+	return l.LogMergedChange(mr, mergeCommit, 0, 0)
+}
+
+// LogMergedChange logs a merged event recording how much the merge
+// changed on the target.
+func (l *EventLogger) LogMergedChange(mr *MR, mergeCommit string, files, lines int) error {
 	return l.LogEvent(Event{
 		Type:        EventMerged,
 		MRID:        mr.ID,
@@ -115,11 +203,19 @@ func (l *EventLogger) LogMerged(mr *MR, mergeCommit string) error {
 		SourceIssue: mr.SourceIssue,
 		Rig:         mr.Rig,
 		MergeCommit: mergeCommit,
+		Files:       files,
+		Lines:       lines,
 	})
 }
 
 // LogMergeFailed logs a merge_failed event.
 func (l *EventLogger) LogMergeFailed(mr *MR, reason string) error {
+	return l.LogMergeFailure(mr, reason, "", "")
+}
+
+// LogMergeFailure logs a merge_failed event classified by failure type and,
+// for check failures, the failing check.
+func (l *EventLogger) LogMergeFailure(mr *MR, reason, failureType, failedCheck string) error {
 	return l.LogEvent(Event{
 		Type:        EventMergeFailed,
 		MRID:        mr.ID,
@@ -129,6 +225,8 @@ func (l *EventLogger) LogMergeFailed(mr *MR, reason string) error {
 		SourceIssue: mr.SourceIssue,
 		Rig:         mr.Rig,
 		Reason:      reason,
+		FailureType: failureType,
+		FailedCheck: failedCheck,
 	})
 }
 
@@ -146,6 +244,222 @@ func (l *EventLogger) LogMergeSkipped(mr *MR, reason string) error {
 	})
 }
 
+// LogHeld logs a held event.
+func (l *EventLogger) LogHeld(mr *MR, reason string) error {
+	return l.LogEvent(Event{
+		Type:        EventHeld,
+		MRID:        mr.ID,
+		Branch:      mr.Branch,
+		Target:      mr.Target,
+		Worker:      mr.Worker,
+		SourceIssue: mr.SourceIssue,
+		Rig:         mr.Rig,
+		Reason:      reason,
+	})
+}
+
+// LogRequeued logs a requeued event.
+func (l *EventLogger) LogRequeued(mr *MR) error {
+	return l.LogEvent(Event{
+		Type:        EventRequeued,
+		MRID:        mr.ID,
+		Branch:      mr.Branch,
+		Target:      mr.Target,
+		Worker:      mr.Worker,
+		SourceIssue: mr.SourceIssue,
+		Rig:         mr.Rig,
+	})
+}
+
+// LogApprovalRequired logs an approval_required event.
+func (l *EventLogger) LogApprovalRequired(mr *MR, reason string) error {
+	return l.LogEvent(Event{
+		Type:        EventApprovalRequired,
+		MRID:        mr.ID,
+		Branch:      mr.Branch,
+		Target:      mr.Target,
+		Worker:      mr.Worker,
+		SourceIssue: mr.SourceIssue,
+		Rig:         mr.Rig,
+		Reason:      reason,
+	})
+}
+
+// LogApproved logs an approved event.
+func (l *EventLogger) LogApproved(mr *MR) error {
+	return l.LogEvent(Event{
+		Type:        EventApproved,
+		MRID:        mr.ID,
+		Branch:      mr.Branch,
+		Target:      mr.Target,
+		Worker:      mr.Worker,
+		SourceIssue: mr.SourceIssue,
+		Rig:         mr.Rig,
+		Reason:      mr.ApprovedBy,
+	})
+}
+
+// LogConflictResolved logs a conflict_resolved event. summary is the
+// resolver's account of what it did.
+func (l *EventLogger) LogConflictResolved(mr *MR, summary string) error {
+	return l.LogEvent(Event{
+		Type:        EventConflictResolved,
+		MRID:        mr.ID,
+		Branch:      mr.Branch,
+		Target:      mr.Target,
+		Worker:      mr.Worker,
+		SourceIssue: mr.SourceIssue,
+		Rig:         mr.Rig,
+		Reason:      summary,
+	})
+}
+
+// LogSwarmLanded logs a swarm_landed event for the MR that landed a swarm's
+// integration branch.
+func (l *EventLogger) LogSwarmLanded(mr *MR, mergeCommit string) error {
+	return l.LogEvent(Event{
+		Type:        EventSwarmLanded,
+		MRID:        mr.ID,
+		Branch:      mr.Branch,
+		Target:      mr.Target,
+		SourceIssue: mr.SourceIssue,
+		Rig:         mr.Rig,
+		MergeCommit: mergeCommit,
+	})
+}
+
+// LogSwarmAborted logs a swarm_aborted event. mr stands for the swarm's
+// integration branch.
+func (l *EventLogger) LogSwarmAborted(mr *MR, reason string) error {
+	return l.LogEvent(Event{
+		Type:        EventSwarmAborted,
+		MRID:        mr.ID,
+		Branch:      mr.Branch,
+		Target:      mr.Target,
+		SourceIssue: mr.SourceIssue,
+		Rig:         mr.Rig,
+		Reason:      reason,
+	})
+}
+
+// LogQuarantined logs a quarantined event for rigName: the refinery
+// paused it, for reason.
+func (l *EventLogger) LogQuarantined(rigName, reason string) error {
+	return l.LogEvent(Event{
+		Type:   EventQuarantined,
+		Rig:    rigName,
+		Reason: reason,
+	})
+}
+
+// LogDeploy logs the outcome of the refinery.deploy rule deploy for mr's
+// merge as mergeCommit: a deployed event, or with err a deploy_failed one.
+func (l *EventLogger) LogDeploy(mr *MR, mergeCommit, deploy, outcome string, err error) error {
+	ev := Event{
+		Type:        EventDeployed,
+		MRID:        mr.ID,
+		Branch:      mr.Branch,
+		Target:      mr.Target,
+		Worker:      mr.Worker,
+		SourceIssue: mr.SourceIssue,
+		Rig:         mr.Rig,
+		MergeCommit: mergeCommit,
+		Deploy:      deploy,
+		Reason:      outcome,
+	}
+	if err != nil {
+		ev.Type, ev.Reason = EventDeployFailed, err.Error()
+	}
+	return l.LogEvent(ev)
+}
+
+// ReadEvents returns the most recent events from the log, oldest first.
+// A limit of 0 or less returns every event. Malformed lines are skipped.
+func (l *EventLogger) ReadEvents(limit int) ([]Event, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	data, err := os.ReadFile(l.logPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading event log: %w", err)
+	}
+
+	var events []Event
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			continue
+		}
+		events = append(events, event)
+	}
+
+	if limit > 0 && len(events) > limit {
+		events = events[len(events)-limit:]
+	}
+	return events, nil
+}
+
+// ReadEventsFrom returns events appended at or after byte offset, along with
+// the offset to pass on the next call. Use it to tail the log: start from the
+// current Size() to receive only new events. A trailing partial line is left
+// for the next call.
+func (l *EventLogger) ReadEventsFrom(offset int64) ([]Event, int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.Open(l.logPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, offset, nil
+		}
+		return nil, offset, fmt.Errorf("opening event log: %w", err)
+	}
+	defer f.Close()
+
+	// The log shrank under the reader: Compact rewrote it. Carry on from
+	// its end.
+	if info, err := f.Stat(); err == nil && offset > info.Size() {
+		offset = info.Size()
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, offset, fmt.Errorf("seeking event log: %w", err)
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, offset, fmt.Errorf("reading event log: %w", err)
+	}
+
+	end := bytes.LastIndexByte(data, '\n')
+	if end < 0 {
+		return nil, offset, nil
+	}
+
+	var events []Event
+	for _, line := range bytes.Split(data[:end], []byte("\n")) {
+		var event Event
+		if err := json.Unmarshal(line, &event); err != nil {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, offset + int64(end) + 1, nil
+}
+
+// Size returns the current size of the event log in bytes (0 if absent).
+func (l *EventLogger) Size() int64 {
+	info, err := os.Stat(l.logPath)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
 // LogPath returns the path to the event log file.
 func (l *EventLogger) LogPath() string {
 	return l.logPath
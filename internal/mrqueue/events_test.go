@@ -112,3 +112,54 @@ func splitLines(s string) []string {
 	}
 	return lines
 }
+
+func TestEventLogger_ReadEvents(t *testing.T) {
+	logger := NewEventLogger(t.TempDir())
+
+	if events, err := logger.ReadEvents(0); err != nil || events != nil {
+		t.Fatalf("ReadEvents on missing log = %v, %v; want nil, nil", events, err)
+	}
+
+	mr := &MR{ID: "mr-1", Branch: "polecat/nux", Target: "main"}
+	_ = logger.LogMergeStarted(mr)
+	_ = logger.LogHeld(mr, "frozen")
+	_ = logger.LogRequeued(mr)
+
+	all, err := logger.ReadEvents(0)
+	if err != nil {
+		t.Fatalf("ReadEvents: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("got %d events, want 3", len(all))
+	}
+
+	last, _ := logger.ReadEvents(2)
+	if len(last) != 2 || last[0].Type != EventHeld || last[1].Type != EventRequeued {
+		t.Errorf("ReadEvents(2) = %+v, want held then requeued", last)
+	}
+}
+
+func TestEventLogger_ReadEventsFrom(t *testing.T) {
+	logger := NewEventLogger(t.TempDir())
+	mr := &MR{ID: "mr-1", Branch: "polecat/nux", Target: "main"}
+
+	_ = logger.LogMergeStarted(mr)
+	offset := logger.Size()
+	_ = logger.LogMerged(mr, "abc123")
+
+	events, next, err := logger.ReadEventsFrom(offset)
+	if err != nil {
+		t.Fatalf("ReadEventsFrom: %v", err)
+	}
+	if len(events) != 1 || events[0].Type != EventMerged {
+		t.Fatalf("got %+v, want only the merged event", events)
+	}
+	if next != logger.Size() {
+		t.Errorf("next offset = %d, want %d", next, logger.Size())
+	}
+
+	events, _, _ = logger.ReadEventsFrom(next)
+	if len(events) != 0 {
+		t.Errorf("expected no new events, got %d", len(events))
+	}
+}
@@ -0,0 +1,52 @@
+package mrqueue
+
+import "sort"
+
+// IsHandedOff reports whether the MR has been validated and handed to
+// GitHub's merge queue, which is now merging it.
+func (mr *MR) IsHandedOff() bool {
+	return mr.PullRequest != 0
+}
+
+// HandOff records that the MR's pull request number pr was added to
+// GitHub's merge queue. Like held MRs, it stays in the queue but is
+// skipped by ListReady and ListUnclaimed until TakeBack.
+func (q *Queue) HandOff(id string, pr int, url string) error {
+	return q.update(id, func(mr *MR) {
+		now := q.clock()
+		mr.PullRequest = pr
+		mr.PullRequestURL = url
+		mr.HandedOffAt = &now
+		mr.ClaimedBy = ""
+		mr.ClaimedAt = nil
+	})
+}
+
+// TakeBack returns a handed-off MR to the refinery, as when GitHub drops
+// its pull request from the merge queue.
+func (q *Queue) TakeBack(id string) error {
+	return q.update(id, func(mr *MR) {
+		mr.PullRequest = 0
+		mr.PullRequestURL = ""
+		mr.HandedOffAt = nil
+	})
+}
+
+// ListHandedOff returns the MRs waiting in GitHub's merge queue, oldest
+// hand-off first.
+func (q *Queue) ListHandedOff() ([]*MR, error) {
+	all, err := q.List()
+	if err != nil {
+		return nil, err
+	}
+	var out []*MR
+	for _, mr := range all {
+		if mr.IsHandedOff() {
+			out = append(out, mr)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].HandedOffAt.Before(*out[j].HandedOffAt)
+	})
+	return out, nil
+}
@@ -0,0 +1,54 @@
+package mrqueue
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// IsHeld reports whether an operator has put the MR on hold.
+func (mr *MR) IsHeld() bool {
+	return mr.HeldReason != ""
+}
+
+// Hold takes an MR out of processing until it is requeued.
+// Held MRs stay in the queue but are skipped by ListReady and ListUnclaimed.
+func (q *Queue) Hold(id, reason string) error {
+	if reason == "" {
+		reason = "held"
+	}
+	return q.update(id, func(mr *MR) {
+		now := q.clock()
+		mr.HeldReason = reason
+		mr.HeldAt = &now
+	})
+}
+
+// Requeue returns an MR to the ready pool: it clears any hold, claim, and
+// blocking task so the next refinery cycle picks it up again.
+func (q *Queue) Requeue(id string) error {
+	return q.update(id, func(mr *MR) {
+		mr.HeldReason = ""
+		mr.HeldAt = nil
+		mr.ClaimedBy = ""
+		mr.ClaimedAt = nil
+		mr.BlockedBy = ""
+	})
+}
+
+// update loads an MR, applies fn, and writes it back atomically.
+func (q *Queue) update(id string, fn func(mr *MR)) error {
+	path := filepath.Join(q.dir, id+".json")
+
+	mr, err := q.load(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("loading MR: %w", err)
+	}
+
+	fn(mr)
+
+	return q.write(path, mr)
+}
@@ -0,0 +1,56 @@
+package mrqueue
+
+import (
+	"testing"
+)
+
+func TestHoldAndRequeue(t *testing.T) {
+	q := New(t.TempDir())
+
+	mr := &MR{ID: "mr-hold-1", Branch: "polecat/nux", Target: "main"}
+	if err := q.Submit(mr); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if err := q.Claim(mr.ID, "refinery-1"); err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+
+	if err := q.Hold(mr.ID, "waiting on design review"); err != nil {
+		t.Fatalf("Hold: %v", err)
+	}
+	got, err := q.Get(mr.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !got.IsHeld() || got.HeldReason != "waiting on design review" || got.HeldAt == nil {
+		t.Errorf("expected MR to be held with reason, got %+v", got)
+	}
+
+	ready, err := q.ListReady(nil)
+	if err != nil {
+		t.Fatalf("ListReady: %v", err)
+	}
+	if len(ready) != 0 {
+		t.Errorf("held MR should not be ready, got %d ready", len(ready))
+	}
+
+	if err := q.Requeue(mr.ID); err != nil {
+		t.Fatalf("Requeue: %v", err)
+	}
+	got, _ = q.Get(mr.ID)
+	if got.IsHeld() || got.ClaimedBy != "" || got.BlockedBy != "" {
+		t.Errorf("requeue should clear hold, claim, and block: %+v", got)
+	}
+
+	ready, _ = q.ListReady(nil)
+	if len(ready) != 1 {
+		t.Errorf("requeued MR should be ready, got %d ready", len(ready))
+	}
+}
+
+func TestHold_NotFound(t *testing.T) {
+	q := New(t.TempDir())
+	if err := q.Hold("mr-missing", "x"); err != ErrNotFound {
+		t.Errorf("Hold on missing MR = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,16 @@
+package mrqueue
+
+import "time"
+
+// Lane returns the lane an MR is processed in: the branch it merges into.
+// A refinery running lanes merges one MR at a time per lane, and lanes
+// independently of each other.
+func (mr *MR) Lane() string {
+	return mr.Target
+}
+
+// IsClaimed reports whether, as of now, a worker holds a claim on the MR
+// that has not gone stale.
+func (mr *MR) IsClaimed(now time.Time) bool {
+	return mr.ClaimedBy != "" && mr.ClaimedAt != nil && now.Sub(*mr.ClaimedAt) < ClaimStaleTimeout
+}
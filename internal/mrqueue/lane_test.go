@@ -0,0 +1,44 @@
+package mrqueue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/clock"
+)
+
+func TestClaimExpiry_QueueClock(t *testing.T) {
+	q := New(t.TempDir())
+	// Far from the wall clock, so only the queue's clock can age the claim
+	clk := clock.NewFake(time.Date(2031, 5, 1, 9, 0, 0, 0, time.UTC))
+	q.SetClock(clk)
+
+	mr := &MR{ID: "mr-claim-1", Branch: "polecat/nux", Target: "main"}
+	if err := q.Submit(mr); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if err := q.Claim(mr.ID, "refinery-1"); err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+	got, _ := q.Get(mr.ID)
+	if !got.IsClaimed(clk.Now()) {
+		t.Error("fresh claim is not claimed")
+	}
+	if unclaimed, _ := q.ListUnclaimed(); len(unclaimed) != 0 {
+		t.Errorf("ListUnclaimed = %d MRs, want none while claimed", len(unclaimed))
+	}
+	if err := q.Claim(mr.ID, "refinery-2"); err != ErrAlreadyClaimed {
+		t.Errorf("second Claim = %v, want ErrAlreadyClaimed", err)
+	}
+
+	clk.Advance(ClaimStaleTimeout)
+	if got.IsClaimed(clk.Now()) {
+		t.Error("stale claim is still claimed")
+	}
+	if unclaimed, _ := q.ListUnclaimed(); len(unclaimed) != 1 {
+		t.Errorf("ListUnclaimed = %d MRs, want the stale claim", len(unclaimed))
+	}
+	if err := q.Claim(mr.ID, "refinery-2"); err != nil {
+		t.Errorf("reclaiming a stale claim: %v", err)
+	}
+}
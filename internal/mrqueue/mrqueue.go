@@ -7,12 +7,16 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/steveyegge/gastown/internal/clock"
+	"github.com/steveyegge/gastown/internal/util"
 )
 
 // MR represents a merge request in the queue.
@@ -33,17 +37,86 @@ type MR struct {
 	ConvoyID        string     `json:"convoy_id,omitempty"`         // Parent convoy ID if part of a convoy
 	ConvoyCreatedAt *time.Time `json:"convoy_created_at,omitempty"` // Convoy creation time for starvation prevention
 
+	// SwarmID is the swarm (epic ID) the work belongs to, when known from
+	// more than the branch names; see Swarm.
+	SwarmID string `json:"swarm_id,omitempty"`
+
+	// IssueStatus is the source issue's beads status when the refinery last
+	// looked, so a reopened issue can be told from one that was never closed.
+	IssueStatus string `json:"issue_status,omitempty"`
+
+	// DependsOn lists the source issue's prerequisite issues in beads. The
+	// MR waits while any of them still has an MR queued; see WaitingOn.
+	DependsOn []string `json:"depends_on,omitempty"`
+
+	// StackedOn is the queued MR whose branch this MR's branch was built
+	// on, and StackBase the commit of that branch it carries. The MR waits
+	// while StackedOn is queued; see Stacked.
+	StackedOn string `json:"stacked_on,omitempty"`
+	StackBase string `json:"stack_base,omitempty"`
+
+	// Routes are the [[refinery.routes]] the branch's diff matched when it
+	// was queued; they can skip checks or add them.
+	Routes []string `json:"routes,omitempty"`
+
+	// Targets are further branches the MR merges into after Target, in
+	// order, each with its own checks. As each merge lands, Target moves
+	// on to the next and Merged records it; see AdvanceTarget.
+	Targets []string      `json:"targets,omitempty"`
+	Merged  []TargetMerge `json:"merged,omitempty"`
+
+	// PullRequest is the GitHub pull request the MR was handed to GitHub's
+	// merge queue as, once it passed the refinery's own checks (see
+	// [refinery.github_queue]); zero while the refinery still owns it.
+	PullRequest    int        `json:"pull_request,omitempty"`
+	PullRequestURL string     `json:"pull_request_url,omitempty"`
+	HandedOffAt    *time.Time `json:"handed_off_at,omitempty"`
+
 	// Claiming fields for parallel refinery workers
 	ClaimedBy string     `json:"claimed_by,omitempty"` // Worker ID that claimed this MR
 	ClaimedAt *time.Time `json:"claimed_at,omitempty"` // When the MR was claimed
 
 	// Blocking fields for non-blocking delegation
-	BlockedBy string `json:"blocked_by,omitempty"` // Task ID that blocks this MR (e.g., conflict resolution task)
+	BlockedBy   string `json:"blocked_by,omitempty"`   // Task ID that blocks this MR (e.g., conflict resolution task)
+	EscalatedTo string `json:"escalated_to,omitempty"` // Issue filed after repeated failures (see refinery.escalation)
+
+	// Triage is the triage agent's diagnosis of the MR's latest check
+	// failure, "<classification>: <diagnosis>" (see refinery.triage).
+	Triage string `json:"triage,omitempty"`
+
+	// Hold fields for operator-paused MRs
+	HeldReason string     `json:"held_reason,omitempty"` // Why the MR is held (empty = not held)
+	HeldAt     *time.Time `json:"held_at,omitempty"`     // When the hold was placed
+
+	// Approval fields for branches from untrusted workers
+	ApprovalReason string     `json:"approval_reason,omitempty"` // Why approval is needed (empty = not waiting)
+	ApprovedBy     string     `json:"approved_by,omitempty"`     // Who approved the MR for merging
+	ApprovedAt     *time.Time `json:"approved_at,omitempty"`     // When the approval was given
+}
+
+// TargetMerge is a merge of an MR into one of its targets.
+type TargetMerge struct {
+	Target      string `json:"target"`
+	MergeCommit string `json:"merge_commit"`
 }
 
 // Queue manages the MR storage.
 type Queue struct {
 	dir string // .beads/mq/ directory
+
+	// clk is the clock MRs are dated, aged, and claimed by; nil means the
+	// system clock.
+	clk clock.Clock
+}
+
+// SetClock makes the queue date and age MRs by c instead of the system
+// clock, for tests and simulations running in virtual time.
+func (q *Queue) SetClock(c clock.Clock) {
+	q.clk = c
+}
+
+func (q *Queue) clock() time.Time {
+	return clock.Or(q.clk).Now()
 }
 
 // New creates a new MR queue for the given rig path.
@@ -93,19 +166,22 @@ func (q *Queue) Submit(mr *MR) error {
 		mr.ID = generateID()
 	}
 	if mr.CreatedAt.IsZero() {
-		mr.CreatedAt = time.Now()
+		mr.CreatedAt = q.clock()
 	}
 
+	return q.write(filepath.Join(q.dir, mr.ID+".json"), mr)
+}
+
+// write stores mr at path atomically, recording its checksum so edits made
+// outside the queue show up in Tampered.
+func (q *Queue) write(path string, mr *MR) error {
 	data, err := json.MarshalIndent(mr, "", "  ")
 	if err != nil {
 		return fmt.Errorf("marshaling MR: %w", err)
 	}
-
-	path := filepath.Join(q.dir, mr.ID+".json")
-	if err := os.WriteFile(path, data, 0644); err != nil {
+	if err := util.AtomicWriteFileChecksum(path, data, 0644); err != nil {
 		return fmt.Errorf("writing MR file: %w", err)
 	}
-
 	return nil
 }
 
@@ -159,7 +235,7 @@ func (q *Queue) ListByScore() ([]*MR, error) {
 		return nil, fmt.Errorf("reading mq directory: %w", err)
 	}
 
-	now := time.Now()
+	now := q.clock()
 	var mrs []*MR
 	for _, entry := range entries {
 		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
@@ -207,9 +283,53 @@ func (q *Queue) Remove(id string) error {
 	path := filepath.Join(q.dir, id+".json")
 	err := os.Remove(path)
 	if os.IsNotExist(err) {
-		return nil // Already removed
+		err = nil // Already removed
+	}
+	if err != nil {
+		return err
+	}
+	return util.RemoveChecksum(path)
+}
+
+// Tampered returns the IDs of MRs whose files were changed by something
+// other than the queue since it last wrote them. MRs written before
+// checksums were kept are not reported.
+func (q *Queue) Tampered() ([]string, error) {
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading mq directory: %w", err)
+	}
+
+	var ids []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		err := util.VerifyChecksum(filepath.Join(q.dir, entry.Name()))
+		if errors.Is(err, util.ErrChecksumMismatch) {
+			ids = append(ids, strings.TrimSuffix(entry.Name(), ".json"))
+		} else if err != nil {
+			return nil, err
+		}
 	}
-	return err
+	return ids, nil
+}
+
+// AcceptChanges records the current contents of MR id as its checksum,
+// accepting an edit made outside the queue.
+func (q *Queue) AcceptChanges(id string) error {
+	path := filepath.Join(q.dir, id+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ErrNotFound
+		}
+		return err
+	}
+	return util.WriteChecksum(path, data)
 }
 
 // Count returns the number of pending MRs.
@@ -257,34 +377,18 @@ func (q *Queue) Claim(id, workerID string) error {
 	// Check if already claimed by another worker
 	if mr.ClaimedBy != "" && mr.ClaimedBy != workerID {
 		// Check if claim is stale (worker may have crashed)
-		if mr.ClaimedAt != nil && time.Since(*mr.ClaimedAt) < ClaimStaleTimeout {
+		if mr.ClaimedAt != nil && q.clock().Sub(*mr.ClaimedAt) < ClaimStaleTimeout {
 			return ErrAlreadyClaimed
 		}
 		// Stale claim - allow reclaim
 	}
 
 	// Claim the MR
-	now := time.Now()
+	now := q.clock()
 	mr.ClaimedBy = workerID
 	mr.ClaimedAt = &now
 
-	// Write atomically
-	data, err := json.MarshalIndent(mr, "", "  ")
-	if err != nil {
-		return fmt.Errorf("marshaling MR: %w", err)
-	}
-
-	// Write to temp file first, then rename (atomic on most filesystems)
-	tmpPath := path + ".tmp"
-	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
-		return fmt.Errorf("writing temp file: %w", err)
-	}
-	if err := os.Rename(tmpPath, path); err != nil {
-		_ = os.Remove(tmpPath) // cleanup
-		return fmt.Errorf("renaming temp file: %w", err)
-	}
-
-	return nil
+	return q.write(path, mr)
 }
 
 // Release releases a claimed MR back to the queue.
@@ -304,12 +408,7 @@ func (q *Queue) Release(id string) error {
 	mr.ClaimedBy = ""
 	mr.ClaimedAt = nil
 
-	data, err := json.MarshalIndent(mr, "", "  ")
-	if err != nil {
-		return fmt.Errorf("marshaling MR: %w", err)
-	}
-
-	return os.WriteFile(path, data, 0644)
+	return q.write(path, mr)
 }
 
 // ListUnclaimed returns MRs that are not claimed or have stale claims.
@@ -322,12 +421,15 @@ func (q *Queue) ListUnclaimed() ([]*MR, error) {
 
 	var unclaimed []*MR
 	for _, mr := range all {
+		if mr.IsHeld() || mr.NeedsApproval() || mr.IsHandedOff() {
+			continue
+		}
 		if mr.ClaimedBy == "" {
 			unclaimed = append(unclaimed, mr)
 			continue
 		}
 		// Check if claim is stale
-		if mr.ClaimedAt != nil && time.Since(*mr.ClaimedAt) >= ClaimStaleTimeout {
+		if mr.ClaimedAt != nil && q.clock().Sub(*mr.ClaimedAt) >= ClaimStaleTimeout {
 			unclaimed = append(unclaimed, mr)
 		}
 	}
@@ -373,12 +475,7 @@ func (q *Queue) SetBlockedBy(mrID, taskID string) error {
 
 	mr.BlockedBy = taskID
 
-	data, err := json.MarshalIndent(mr, "", "  ")
-	if err != nil {
-		return fmt.Errorf("marshaling MR: %w", err)
-	}
-
-	return os.WriteFile(path, data, 0644)
+	return q.write(path, mr)
 }
 
 // ClearBlockedBy removes the blocking task from an MR.
@@ -386,6 +483,76 @@ func (q *Queue) ClearBlockedBy(mrID string) error {
 	return q.SetBlockedBy(mrID, "")
 }
 
+// SetTarget changes the branch an MR merges into, e.g. to route a swarm
+// member's MR to its epic's integration branch.
+func (q *Queue) SetTarget(mrID, target string) error {
+	return q.update(mrID, func(mr *MR) {
+		mr.Target = target
+	})
+}
+
+// SetSwarm records the swarm an MR belongs to.
+func (q *Queue) SetSwarm(mrID, swarm string) error {
+	return q.update(mrID, func(mr *MR) {
+		mr.SwarmID = swarm
+	})
+}
+
+// SetIssueStatus records the source issue's last seen beads status.
+func (q *Queue) SetIssueStatus(mrID, status string) error {
+	return q.update(mrID, func(mr *MR) {
+		mr.IssueStatus = status
+	})
+}
+
+// SetEscalation records the issue filed for an MR that keeps failing and
+// blocks the MR on it, so retries stop until someone closes the issue.
+func (q *Queue) SetEscalation(mrID, issueID string) error {
+	return q.update(mrID, func(mr *MR) {
+		mr.EscalatedTo = issueID
+		mr.BlockedBy = issueID
+	})
+}
+
+// SetTriage records the triage agent's diagnosis of an MR's latest failure.
+func (q *Queue) SetTriage(mrID, triage string) error {
+	return q.update(mrID, func(mr *MR) {
+		mr.Triage = triage
+	})
+}
+
+// SetDependsOn records the source issue's prerequisite issues.
+func (q *Queue) SetDependsOn(mrID string, issues []string) error {
+	return q.update(mrID, func(mr *MR) {
+		mr.DependsOn = issues
+	})
+}
+
+// SetStack records the MR an MR's branch is stacked on and the commit of
+// it the branch carries; empty values unstack it.
+func (q *Queue) SetStack(mrID, on, base string) error {
+	return q.update(mrID, func(mr *MR) {
+		mr.StackedOn = on
+		mr.StackBase = base
+	})
+}
+
+// AdvanceTarget records that an MR merged into its current target as
+// mergeCommit and moves it on to the next of its Targets, unclaimed and
+// with a fresh retry count. An MR with no further targets is left alone.
+func (q *Queue) AdvanceTarget(mrID, mergeCommit string) error {
+	return q.update(mrID, func(mr *MR) {
+		if len(mr.Targets) == 0 {
+			return
+		}
+		mr.Merged = append(mr.Merged, TargetMerge{Target: mr.Target, MergeCommit: mergeCommit})
+		mr.Target, mr.Targets = mr.Targets[0], mr.Targets[1:]
+		mr.RetryCount = 0
+		mr.ClaimedBy = ""
+		mr.ClaimedAt = nil
+	})
+}
+
 // IsBlocked checks if an MR is blocked by a task that is still open.
 // If blocked, returns true and the blocking task ID.
 // checkStatus is a function that checks if a bead is still open.
@@ -409,6 +576,7 @@ type BeadStatusChecker func(beadID string) (isOpen bool, err error)
 
 // ListReady returns MRs that are ready for processing:
 // - Not claimed by another worker (or claim is stale)
+// - Not held by an operator, waiting for approval, or handed off to GitHub
 // - Not blocked by an open task
 // Sorted by priority score (highest first).
 // The checkStatus function is used to check if blocking tasks are still open.
@@ -420,9 +588,15 @@ func (q *Queue) ListReady(checkStatus BeadStatusChecker) ([]*MR, error) {
 
 	var ready []*MR
 	for _, mr := range all {
+		// Skip if held by an operator, waiting for approval, or in
+		// GitHub's merge queue
+		if mr.IsHeld() || mr.NeedsApproval() || mr.IsHandedOff() {
+			continue
+		}
+
 		// Skip if claimed by another worker (and not stale)
 		if mr.ClaimedBy != "" {
-			if mr.ClaimedAt != nil && time.Since(*mr.ClaimedAt) < ClaimStaleTimeout {
+			if mr.ClaimedAt != nil && q.clock().Sub(*mr.ClaimedAt) < ClaimStaleTimeout {
 				continue
 			}
 			// Stale claim - include in ready list
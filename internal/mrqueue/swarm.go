@@ -0,0 +1,61 @@
+package mrqueue
+
+import (
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/constants"
+)
+
+// SwarmOf returns the swarm (epic ID) a branch merge belongs to: the epic
+// of the integration/<epic> branch it targets, or lands. Returns "" for
+// work outside a swarm.
+func SwarmOf(branch, target string) string {
+	for _, b := range []string{target, branch} {
+		if strings.HasPrefix(b, constants.BranchIntegrationPrefix) {
+			return strings.TrimPrefix(b, constants.BranchIntegrationPrefix)
+		}
+	}
+	return ""
+}
+
+// Swarm returns the swarm this MR belongs to, or "": SwarmID if it was
+// recorded at submission, else the swarm its branch names imply.
+func (mr *MR) Swarm() string {
+	if mr.SwarmID != "" {
+		return mr.SwarmID
+	}
+	return SwarmOf(mr.Branch, mr.Target)
+}
+
+// GroupBySwarm reorders mrs so each swarm's MRs run consecutively: a
+// swarm's later MRs move up to follow its first. Relative order is kept
+// otherwise, so a swarm's place is set by its best-ranked MR and MRs
+// outside a swarm do not move relative to each other.
+func GroupBySwarm(mrs []*MR) []*MR {
+	return GroupBy(mrs, (*MR).Swarm)
+}
+
+// GroupBy reorders items so those with the same non-empty key run
+// consecutively from where the key first appears, keeping relative order
+// otherwise. Items with an empty key stay ungrouped.
+func GroupBy[T any](items []T, key func(T) string) []T {
+	groups := make(map[string][]T)
+	for _, it := range items {
+		if k := key(it); k != "" {
+			groups[k] = append(groups[k], it)
+		}
+	}
+	out := make([]T, 0, len(items))
+	for _, it := range items {
+		k := key(it)
+		if k == "" {
+			out = append(out, it)
+			continue
+		}
+		if g, ok := groups[k]; ok {
+			out = append(out, g...)
+			delete(groups, k)
+		}
+	}
+	return out
+}
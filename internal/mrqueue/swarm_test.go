@@ -0,0 +1,40 @@
+package mrqueue
+
+import "testing"
+
+func TestSwarmOf(t *testing.T) {
+	tests := []struct {
+		branch, target, want string
+	}{
+		{"polecat/nux-abc", "integration/gt-epic", "gt-epic"},
+		{"integration/gt-epic", "main", "gt-epic"},
+		{"polecat/nux-abc", "main", ""},
+	}
+	for _, tt := range tests {
+		if got := SwarmOf(tt.branch, tt.target); got != tt.want {
+			t.Errorf("SwarmOf(%q, %q) = %q, want %q", tt.branch, tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestGroupBySwarm(t *testing.T) {
+	mrs := []*MR{
+		{ID: "a1", Target: "integration/a"},
+		{ID: "solo1", Target: "main"},
+		{ID: "b1", Target: "integration/b"},
+		{ID: "a2", Target: "integration/a"},
+		{ID: "solo2", Target: "main"},
+		{ID: "b2", Target: "integration/b"},
+		{ID: "a3", Target: "main", SwarmID: "a"}, // Recorded at submission
+	}
+	got := GroupBySwarm(mrs)
+	want := []string{"a1", "a2", "a3", "solo1", "b1", "b2", "solo2"}
+	if len(got) != len(want) {
+		t.Fatalf("got %d MRs, want %d", len(got), len(want))
+	}
+	for i, mr := range got {
+		if mr.ID != want[i] {
+			t.Errorf("position %d = %s, want %s", i, mr.ID, want[i])
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package mrqueue
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestTampered(t *testing.T) {
+	q := New(t.TempDir())
+
+	for _, id := range []string{"mr-ok", "mr-edited"} {
+		if err := q.Submit(&MR{ID: id, Branch: "polecat/nux", Target: "main"}); err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+	}
+	// Queue updates keep the checksum current.
+	if err := q.Hold("mr-ok", "review"); err != nil {
+		t.Fatalf("Hold: %v", err)
+	}
+
+	path := filepath.Join(q.Dir(), "mr-edited.json")
+	if err := os.WriteFile(path, []byte(`{"id":"mr-edited","branch":"evil","target":"main"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := q.Tampered()
+	if err != nil {
+		t.Fatalf("Tampered: %v", err)
+	}
+	if !slices.Equal(ids, []string{"mr-edited"}) {
+		t.Errorf("Tampered = %v, want [mr-edited]", ids)
+	}
+
+	if err := q.AcceptChanges("mr-edited"); err != nil {
+		t.Fatalf("AcceptChanges: %v", err)
+	}
+	if ids, _ := q.Tampered(); len(ids) != 0 {
+		t.Errorf("Tampered after accept = %v, want none", ids)
+	}
+
+	if err := q.Remove("mr-edited"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, err := os.Stat(path + ".sha256"); !os.IsNotExist(err) {
+		t.Errorf("checksum left behind after Remove: %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package polecat
+
+import "strings"
+
+// BranchPrefix is the namespace polecat branches live under.
+const BranchPrefix = "polecat/"
+
+// IsBranch reports whether branch is in the polecat namespace.
+func IsBranch(branch string) bool {
+	return strings.HasPrefix(branch, BranchPrefix) && len(branch) > len(BranchPrefix)
+}
+
+// IsBranchOf reports whether branch belongs to the named polecat. Polecat
+// branches are polecat/<name>-<timestamp> (see Add), or polecat/<name> and
+// polecat/<name>/<issue> from older workflows.
+func IsBranchOf(branch, name string) bool {
+	if name == "" || !IsBranch(branch) {
+		return false
+	}
+	seg, _, _ := strings.Cut(strings.TrimPrefix(branch, BranchPrefix), "/")
+	if seg == name {
+		return true
+	}
+	stamp, ok := strings.CutPrefix(seg, name+"-")
+	return ok && isBase36(stamp)
+}
+
+// isBase36 reports whether s is a non-empty lowercase base-36 number, as
+// strconv.FormatInt(n, 36) produces for branch timestamps.
+func isBase36(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'z') {
+			return false
+		}
+	}
+	return true
+}
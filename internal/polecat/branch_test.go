@@ -0,0 +1,25 @@
+package polecat
+
+import "testing"
+
+func TestIsBranchOf(t *testing.T) {
+	tests := []struct {
+		branch, name string
+		want         bool
+	}{
+		{"polecat/nux", "nux", true},
+		{"polecat/nux-lx4k2b9c", "nux", true},
+		{"polecat/nux/gt-abc", "nux", true},
+		{"polecat/nux-lx4k2b9c", "nu", false},
+		{"polecat/nux-Bad", "nux", false},
+		{"polecat/nux2", "nux", false},
+		{"polecat/toast", "nux", false},
+		{"crew/nux", "nux", false},
+		{"polecat/", "", false},
+	}
+	for _, tt := range tests {
+		if got := IsBranchOf(tt.branch, tt.name); got != tt.want {
+			t.Errorf("IsBranchOf(%q, %q) = %v, want %v", tt.branch, tt.name, got, tt.want)
+		}
+	}
+}
@@ -150,6 +150,9 @@ func (m *SessionManager) Start(polecat string, opts SessionStartOptions) error {
 	debugSession("SetEnvironment GT_RIG", m.tmux.SetEnvironment(sessionID, "GT_RIG", m.rig.Name))
 	debugSession("SetEnvironment GT_POLECAT", m.tmux.SetEnvironment(sessionID, "GT_POLECAT", polecat))
 
+	// Register with the rig so the refinery can track liveness (non-fatal)
+	debugSession("RegisterWorker", rig.RegisterWorker(m.rig.Path, polecat, sessionID))
+
 	// Set CLAUDE_CONFIG_DIR for account selection (non-fatal)
 	if opts.ClaudeConfigDir != "" {
 		debugSession("SetEnvironment CLAUDE_CONFIG_DIR", m.tmux.SetEnvironment(sessionID, "CLAUDE_CONFIG_DIR", opts.ClaudeConfigDir))
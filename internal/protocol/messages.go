@@ -92,7 +92,7 @@ func formatMergedBody(p MergedPayload) string {
 // NewMergeFailedMessage creates a MERGE_FAILED protocol message.
 // Sent by Refinery to Witness when merge fails (tests, build, etc.).
 func NewMergeFailedMessage(rig, polecat, branch, issue, targetBranch, failureType, errorMsg string) *mail.Message {
-	payload := MergeFailedPayload{
+	return NewMergeFailedMessageFromPayload(MergeFailedPayload{
 		Branch:       branch,
 		Issue:        issue,
 		Polecat:      polecat,
@@ -101,14 +101,19 @@ func NewMergeFailedMessage(rig, polecat, branch, issue, targetBranch, failureTyp
 		FailureType:  failureType,
 		Error:        errorMsg,
 		TargetBranch: targetBranch,
-	}
+	})
+}
 
+// NewMergeFailedMessageFromPayload creates a MERGE_FAILED protocol message
+// carrying the optional failure details (MR, target SHA, conflicting files,
+// failed check) so the Witness can hand them on to the polecat.
+func NewMergeFailedMessageFromPayload(payload MergeFailedPayload) *mail.Message {
 	body := formatMergeFailedBody(payload)
 
 	msg := mail.NewMessage(
-		fmt.Sprintf("%s/refinery", rig),
-		fmt.Sprintf("%s/witness", rig),
-		fmt.Sprintf("MERGE_FAILED %s", polecat),
+		fmt.Sprintf("%s/refinery", payload.Rig),
+		fmt.Sprintf("%s/witness", payload.Rig),
+		fmt.Sprintf("MERGE_FAILED %s", payload.Polecat),
 		body,
 	)
 	msg.Priority = mail.PriorityHigh
@@ -128,6 +133,18 @@ func formatMergeFailedBody(p MergeFailedPayload) string {
 	sb.WriteString(fmt.Sprintf("Failed-At: %s\n", p.FailedAt.Format(time.RFC3339)))
 	sb.WriteString(fmt.Sprintf("Failure-Type: %s\n", p.FailureType))
 	sb.WriteString(fmt.Sprintf("Error: %s\n", p.Error))
+	if p.MRID != "" {
+		sb.WriteString(fmt.Sprintf("MR: %s\n", p.MRID))
+	}
+	if p.TargetSHA != "" {
+		sb.WriteString(fmt.Sprintf("Target-SHA: %s\n", p.TargetSHA))
+	}
+	if len(p.ConflictFiles) > 0 {
+		sb.WriteString(fmt.Sprintf("Conflict-Files: %s\n", strings.Join(p.ConflictFiles, ", ")))
+	}
+	if p.FailedCheck != "" {
+		sb.WriteString(fmt.Sprintf("Failed-Check: %s\n", p.FailedCheck))
+	}
 	return sb.String()
 }
 
@@ -234,6 +251,14 @@ func ParseMergeFailedPayload(body string) *MergeFailedPayload {
 		TargetBranch: parseField(body, "Target"),
 		FailureType:  parseField(body, "Failure-Type"),
 		Error:        parseField(body, "Error"),
+		MRID:         parseField(body, "MR"),
+		TargetSHA:    parseField(body, "Target-SHA"),
+		FailedCheck:  parseField(body, "Failed-Check"),
+	}
+
+	// Parse conflict files
+	if files := parseField(body, "Conflict-Files"); files != "" {
+		payload.ConflictFiles = strings.Split(files, ", ")
 	}
 
 	// Parse timestamp
@@ -136,6 +136,31 @@ func TestNewMergeFailedMessage(t *testing.T) {
 	}
 }
 
+func TestNewMergeFailedMessageFromPayload(t *testing.T) {
+	msg := NewMergeFailedMessageFromPayload(MergeFailedPayload{
+		Branch:        "polecat/nux/gt-abc",
+		Polecat:       "nux",
+		Rig:           "gastown",
+		TargetBranch:  "main",
+		FailureType:   "conflict",
+		MRID:          "mr-123",
+		TargetSHA:     "abc123",
+		ConflictFiles: []string{"a.go", "b.go"},
+	})
+
+	if msg.From != "gastown/refinery" || msg.Subject != "MERGE_FAILED nux" {
+		t.Errorf("From/Subject = %q/%q", msg.From, msg.Subject)
+	}
+	if strings.Contains(msg.Body, "Failed-Check:") {
+		t.Errorf("Body has empty Failed-Check: %s", msg.Body)
+	}
+
+	p := ParseMergeFailedPayload(msg.Body)
+	if p.MRID != "mr-123" || p.TargetSHA != "abc123" || strings.Join(p.ConflictFiles, ",") != "a.go,b.go" {
+		t.Errorf("round trip = %+v", p)
+	}
+}
+
 func TestNewReworkRequestMessage(t *testing.T) {
 	conflicts := []string{"file1.go", "file2.go"}
 	msg := NewReworkRequestMessage("gastown", "nux", "polecat/nux/gt-abc", "gt-abc", "main", conflicts)
@@ -8,6 +8,7 @@ import (
 
 	"github.com/steveyegge/gastown/internal/mail"
 	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
 )
 
 // DefaultRefineryHandler provides the default implementation for Refinery protocol handlers.
@@ -63,6 +64,10 @@ func (h *DefaultRefineryHandler) HandleMergeReady(payload *MergeReadyPayload) er
 	if payload.Polecat == "" {
 		return fmt.Errorf("missing polecat in MERGE_READY payload")
 	}
+	if err := rig.CheckNotPaused(h.WorkDir); err != nil {
+		_, _ = fmt.Fprintf(h.Output, "[Refinery] Not queueing %s: %v\n", payload.Branch, err)
+		return err
+	}
 
 	// Create merge request (ID is generated by Submit if empty)
 	mr := &mrqueue.MR{
@@ -135,6 +135,18 @@ type MergeFailedPayload struct {
 
 	// TargetBranch is the branch we tried to merge into.
 	TargetBranch string `json:"target_branch"`
+
+	// MRID is the merge request that failed (if known).
+	MRID string `json:"mr_id,omitempty"`
+
+	// TargetSHA is the target commit the merge was attempted against.
+	TargetSHA string `json:"target_sha,omitempty"`
+
+	// ConflictFiles lists files that conflicted (conflict failures).
+	ConflictFiles []string `json:"conflict_files,omitempty"`
+
+	// FailedCheck names the quality check that failed (check failures).
+	FailedCheck string `json:"failed_check,omitempty"`
 }
 
 // ReworkRequestPayload contains the data for a REWORK_REQUEST message.
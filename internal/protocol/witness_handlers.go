@@ -150,6 +150,20 @@ Thank you for your contribution! Your worktree will be cleaned up shortly.`,
 
 // notifyPolecatFailed sends a merge failure notification to a polecat.
 func (h *DefaultWitnessHandler) notifyPolecatFailed(payload *MergeFailedPayload) error {
+	details := ""
+	if payload.TargetSHA != "" {
+		details += fmt.Sprintf("Target: %s @ %s\n", payload.TargetBranch, payload.TargetSHA)
+	}
+	if payload.FailedCheck != "" {
+		details += fmt.Sprintf("Failed check: %s\n", payload.FailedCheck)
+	}
+	if len(payload.ConflictFiles) > 0 {
+		details += "Conflicting files:\n"
+		for _, f := range payload.ConflictFiles {
+			details += fmt.Sprintf("  - %s\n", f)
+		}
+	}
+
 	msg := mail.NewMessage(
 		fmt.Sprintf("%s/witness", h.Rig),
 		fmt.Sprintf("%s/%s", h.Rig, payload.Polecat),
@@ -160,12 +174,13 @@ Branch: %s
 Issue: %s
 Failure: %s
 Error: %s
-
+%s
 Please fix the issue and resubmit your work with 'gt done'.`,
 			payload.Branch,
 			payload.Issue,
 			payload.FailureType,
 			payload.Error,
+			details,
 		),
 	)
 	msg.Priority = mail.PriorityHigh
@@ -0,0 +1,125 @@
+package refinery
+
+import (
+	"fmt"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// AbortSwarmOptions controls how a swarm is flushed from the queue.
+type AbortSwarmOptions struct {
+	Hold         bool   // Hold the swarm's MRs instead of dropping them
+	DeleteBranch bool   // Delete the integration branch from origin
+	Reason       string // Recorded on each MR and in the event log
+}
+
+// AbortedSwarm reports what aborting a swarm did.
+type AbortedSwarm struct {
+	Swarm         string   `json:"swarm"`
+	Branch        string   `json:"branch"` // The swarm's integration branch
+	Held          []string `json:"held,omitempty"`
+	Dropped       []string `json:"dropped,omitempty"`
+	Closed        []string `json:"closed,omitempty"` // Merge-request beads of dropped MRs
+	BranchDeleted bool     `json:"branch_deleted,omitempty"`
+}
+
+// SwarmMRs returns the queued MRs AbortSwarm would take out of the queue:
+// every MR into the swarm's integration branch, and its landing.
+func (m *Manager) SwarmMRs(swarm string) ([]*mrqueue.MR, error) {
+	queued, err := mrqueue.New(m.rig.Path).List()
+	if err != nil {
+		return nil, err
+	}
+	var members []*mrqueue.MR
+	for _, mr := range queued {
+		if mr.Swarm() == swarm {
+			members = append(members, mr)
+		}
+	}
+	return members, nil
+}
+
+// AbortSwarm takes a swarm's MRs out of the queue: every MR into its
+// integration branch, and its landing if one is queued. Dropped MRs are
+// removed and their merge-request beads closed; held MRs stay queued until
+// 'gt refinery requeue'. The abort is logged as a swarm_aborted event.
+func (m *Manager) AbortSwarm(swarm string, opts AbortSwarmOptions) (*AbortedSwarm, error) {
+	res := &AbortedSwarm{Swarm: swarm, Branch: constants.BranchIntegrationPrefix + swarm}
+	reason := opts.Reason
+	if reason == "" {
+		reason = "swarm " + swarm + " aborted"
+	}
+
+	q := mrqueue.New(m.rig.Path)
+	members, err := m.SwarmMRs(swarm)
+	if err != nil {
+		return nil, err
+	}
+	events := mrqueue.NewEventLoggerFromRig(m.rig.Path)
+	var dropped []*mrqueue.MR
+	for _, mr := range members {
+		if opts.Hold {
+			if err := q.Hold(mr.ID, reason); err != nil {
+				return res, fmt.Errorf("holding %s: %w", mr.ID, err)
+			}
+			_ = events.LogHeld(mr, reason) // Non-fatal: history is best-effort
+			res.Held = append(res.Held, mr.ID)
+			continue
+		}
+		if err := q.Remove(mr.ID); err != nil {
+			return res, fmt.Errorf("removing %s: %w", mr.ID, err)
+		}
+		_ = events.LogMergeSkipped(mr, reason)
+		res.Dropped = append(res.Dropped, mr.ID)
+		dropped = append(dropped, mr)
+	}
+
+	if len(dropped) > 0 {
+		b := beads.New(m.rig.BeadsPath())
+		mrIssues, err := b.List(beads.ListOptions{Type: "merge-request", Status: "open", Priority: -1})
+		if err != nil {
+			_, _ = fmt.Fprintf(m.output, "Warning: could not list merge-request issues: %v\n", err)
+		}
+		for _, issue := range mrIssues {
+			f := beads.ParseMRFields(issue)
+			if f == nil {
+				continue
+			}
+			for _, mr := range dropped {
+				if f.Branch == mr.Branch {
+					res.Closed = append(res.Closed, issue.ID)
+					break
+				}
+			}
+		}
+		if len(res.Closed) > 0 {
+			if err := b.CloseWithReason(reason, res.Closed...); err != nil {
+				_, _ = fmt.Fprintf(m.output, "Warning: could not close %v: %v\n", res.Closed, err)
+			}
+		}
+	}
+
+	if opts.DeleteBranch {
+		g := git.NewGit(m.workDir)
+		exists, err := g.RemoteBranchExists("origin", res.Branch)
+		if err != nil {
+			return res, fmt.Errorf("checking %s: %w", res.Branch, err)
+		}
+		if exists {
+			if err := g.DeleteRemoteBranch("origin", res.Branch); err != nil {
+				return res, fmt.Errorf("deleting %s: %w", res.Branch, err)
+			}
+			res.BranchDeleted = true
+		}
+		_ = g.DeleteBranch(res.Branch, true) // Local copy, if any
+	}
+
+	abort := &mrqueue.MR{Branch: res.Branch, Target: m.rig.DefaultBranch(), SourceIssue: swarm, Rig: m.rig.Name}
+	if err := events.LogSwarmAborted(abort, reason); err != nil {
+		_, _ = fmt.Fprintf(m.output, "Warning: could not log swarm_aborted event: %v\n", err)
+	}
+	return res, nil
+}
@@ -0,0 +1,73 @@
+package refinery
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func TestAbortSwarm(t *testing.T) {
+	rigPath := setupConflictRig(t)
+	cmd := exec.Command("git", "push", "origin", "main:refs/heads/integration/gt-epic")
+	cmd.Dir = rigPath
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git push: %v\n%s", err, out)
+	}
+
+	q := mrqueue.New(rigPath)
+	member := &mrqueue.MR{Branch: "polecat/nux/gt-1", Target: "integration/gt-epic", Worker: "nux"}
+	landing := &mrqueue.MR{Branch: "integration/gt-epic", Target: "main", Worker: landingWorker}
+	other := &mrqueue.MR{Branch: "polecat/slit/gt-2", Target: "integration/gt-other", Worker: "slit"}
+	for _, mr := range []*mrqueue.MR{member, landing, other} {
+		if err := q.Submit(mr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := NewManager(&rig.Rig{Name: "test-rig", Path: rigPath})
+	m.SetOutput(&strings.Builder{})
+	res, err := m.AbortSwarm("gt-epic", AbortSwarmOptions{DeleteBranch: true})
+	if err != nil {
+		t.Fatalf("AbortSwarm: %v", err)
+	}
+	if len(res.Dropped) != 2 || !res.BranchDeleted {
+		t.Errorf("AbortSwarm = %+v, want 2 dropped and the branch deleted", res)
+	}
+	if queued, _ := q.List(); len(queued) != 1 || queued[0].ID != other.ID {
+		t.Errorf("queue = %+v, want only the other swarm's MR", queued)
+	}
+	if exists, _ := git.NewGit(rigPath).RemoteBranchExists("origin", "integration/gt-epic"); exists {
+		t.Error("integration branch still on origin")
+	}
+
+	events, _ := mrqueue.NewEventLoggerFromRig(rigPath).ReadEvents(0)
+	if n := len(events); n != 3 || events[n-1].Type != mrqueue.EventSwarmAborted || events[n-1].SourceIssue != "gt-epic" {
+		t.Errorf("events = %+v, want two merge_skipped then swarm_aborted", events)
+	}
+}
+
+func TestAbortSwarm_Hold(t *testing.T) {
+	rigPath := t.TempDir()
+	q := mrqueue.New(rigPath)
+	mr := &mrqueue.MR{Branch: "polecat/nux/gt-1", Target: "integration/gt-epic", Worker: "nux"}
+	if err := q.Submit(mr); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewManager(&rig.Rig{Name: "test-rig", Path: rigPath})
+	m.SetOutput(&strings.Builder{})
+	res, err := m.AbortSwarm("gt-epic", AbortSwarmOptions{Hold: true, Reason: "bad plan"})
+	if err != nil {
+		t.Fatalf("AbortSwarm: %v", err)
+	}
+	if len(res.Held) != 1 || len(res.Dropped) != 0 {
+		t.Errorf("AbortSwarm = %+v, want one held", res)
+	}
+	if got, _ := q.Get(mr.ID); got == nil || got.HeldReason != "bad plan" {
+		t.Errorf("MR = %+v, want held for \"bad plan\"", got)
+	}
+}
@@ -0,0 +1,209 @@
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// ErrNotAdmitted means [refinery.admission] turned an MR away.
+var ErrNotAdmitted = errors.New("rejected by refinery.admission")
+
+// AdmissionRequest is what the admission policy is asked about: written to
+// $GT_ADMISSION_REQUEST for a command, POSTed to a URL.
+type AdmissionRequest struct {
+	Rig   string      `json:"rig"`
+	MR    *mrqueue.MR `json:"mr"`
+	Files []string    `json:"files"` // Files the branch changes against its target
+}
+
+// AdmissionDecision is the admission policy's answer. Priority, Title, and
+// Routes, when present, relabel an accepted MR; Routes replaces the routes
+// it matched and must name [[refinery.routes]].
+type AdmissionDecision struct {
+	Decision string    `json:"decision"` // config.AdmissionAccept or AdmissionReject
+	Reason   string    `json:"reason,omitempty"`
+	Priority *int      `json:"priority,omitempty"`
+	Title    *string   `json:"title,omitempty"`
+	Routes   *[]string `json:"routes,omitempty"`
+}
+
+// Admit consults the rig's [refinery.admission] policy, if any, about mr,
+// which is about to be queued, and applies any relabelling it asks for.
+// Returns an error wrapping ErrNotAdmitted if the policy rejects mr, and
+// other errors if the policy failed and on_error is reject.
+func (m *Manager) Admit(ctx context.Context, mr *mrqueue.MR) error {
+	if m.settings == nil || m.settings.Admission == nil {
+		return nil
+	}
+	return admit(ctx, m.settings, m.rig.Name, m.rig.Path, git.NewGit(m.rig.Path), m.output, mr)
+}
+
+func admit(ctx context.Context, s *config.RefinerySettings, rigName, rigPath string, g GitRunner, out io.Writer, mr *mrqueue.MR) error {
+	ac := s.Admission
+	files, _ := g.ChangedFiles(mr.Target, mr.Branch) // The policy decides what an undiffable branch means
+	req := AdmissionRequest{Rig: rigName, MR: mr, Files: files}
+	if req.Files == nil {
+		req.Files = []string{}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ac.TimeoutDuration())
+	defer cancel()
+	var d *AdmissionDecision
+	var err error
+	if ac.URL != "" {
+		d, err = admitByURL(ctx, ac, rigPath, &req)
+	} else {
+		d, err = admitByCommand(ctx, s, rigPath, &req)
+	}
+	if err == nil {
+		err = d.validate(s)
+	}
+	if err != nil {
+		if ac.FailOpen() {
+			_, _ = fmt.Fprintf(out, "Warning: admission policy: %v; queueing %s anyway (on_error = %q)\n", err, mr.Branch, config.AdmissionAccept)
+			return nil
+		}
+		return fmt.Errorf("admission policy: %w; not queued (on_error = %q)", err, config.AdmissionReject)
+	}
+
+	if d.Decision == config.AdmissionReject {
+		if d.Reason == "" {
+			return ErrNotAdmitted
+		}
+		return fmt.Errorf("%w: %s", ErrNotAdmitted, d.Reason)
+	}
+	if d.Priority != nil {
+		mr.Priority = *d.Priority
+	}
+	if d.Title != nil {
+		mr.Title = *d.Title
+	}
+	if d.Routes != nil {
+		mr.Routes = *d.Routes
+		if len(mr.Routes) == 0 {
+			mr.Routes = nil
+		}
+	}
+	return nil
+}
+
+// admitByCommand runs the admission command and parses what it prints.
+func admitByCommand(ctx context.Context, s *config.RefinerySettings, rigPath string, req *AdmissionRequest) (*AdmissionDecision, error) {
+	runtimeDir := filepath.Join(rigPath, ".runtime")
+	if err := os.MkdirAll(runtimeDir, 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.CreateTemp(runtimeDir, "admission-*.json")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+	err = json.NewEncoder(f).Encode(req)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	extra, err := s.ResolveEnv(rigPath)
+	if err != nil {
+		return nil, err
+	}
+	// The command comes from the rig's settings (trusted infrastructure config)
+	cmd := exec.CommandContext(ctx, "sh", "-c", s.Admission.Command) //nolint:gosec // G204: trusted rig config
+	cmd.Dir = rigPath
+	cmd.Env = append(append(os.Environ(), extra...), "GT_ADMISSION_REQUEST="+f.Name())
+	var stdout, output bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &output
+
+	started := time.Now()
+	err = cmd.Run()
+	util.TraceCommand(rigPath, "sh", cmd.Args[1:], started, err)
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("timed out after %s", s.Admission.TimeoutDuration())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%v\n%s", err, tailLines(output.String(), feedbackOutputLines))
+	}
+	var d AdmissionDecision
+	if err := json.Unmarshal(stdout.Bytes(), &d); err != nil {
+		return nil, fmt.Errorf("parsing decision: %w", err)
+	}
+	return &d, nil
+}
+
+// admitByURL POSTs req to the admission webhook and decodes its reply.
+func admitByURL(ctx context.Context, ac *config.AdmissionConfig, rigPath string, req *AdmissionRequest) (*AdmissionDecision, error) {
+	token, err := ac.ResolveToken(rigPath)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	hr, err := http.NewRequestWithContext(ctx, http.MethodPost, ac.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	hr.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		hr.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(hr)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s: %s", ac.URL, resp.Status)
+	}
+	var d AdmissionDecision
+	if err := json.NewDecoder(resp.Body).Decode(&d); err != nil {
+		return nil, fmt.Errorf("decoding decision: %w", err)
+	}
+	return &d, nil
+}
+
+// validate checks that a decision is one the refinery can apply.
+func (d *AdmissionDecision) validate(s *config.RefinerySettings) error {
+	switch d.Decision {
+	case config.AdmissionAccept, config.AdmissionReject:
+	default:
+		return fmt.Errorf("decision %q, want %q or %q", d.Decision, config.AdmissionAccept, config.AdmissionReject)
+	}
+	if d.Routes != nil {
+		known := make(map[string]bool)
+		for _, r := range s.Routes {
+			known[r.Name] = true
+		}
+		var unknown []string
+		for _, name := range *d.Routes {
+			if !known[name] {
+				unknown = append(unknown, name)
+			}
+		}
+		if len(unknown) > 0 {
+			return fmt.Errorf("unknown routes %s", strings.Join(unknown, ", "))
+		}
+	}
+	return nil
+}
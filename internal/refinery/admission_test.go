@@ -0,0 +1,82 @@
+package refinery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+func TestAdmit_Command(t *testing.T) {
+	e, repo := newFakeEngineer(t)
+	e.settings.Routes = []config.RouteConfig{{Name: "docs", Paths: []string{"docs/"}}}
+	branch := "polecat/nux/gt-1"
+	if err := e.git.CreateBranchFrom(branch, "main"); err != nil {
+		t.Fatal(err)
+	}
+	repo.Commit(branch, "nux work", map[string]string{"vendor/lib.go": "package lib\n"})
+	admitWith := func(command string, mr *mrqueue.MR) error {
+		t.Helper()
+		e.settings.Admission = &config.AdmissionConfig{Command: command}
+		return admit(context.Background(), e.settings, "test-rig", e.rig.Path, e.git, io.Discard, mr)
+	}
+
+	// The policy sees the branch's files and turns it away
+	reject := `grep -q vendor/lib.go "$GT_ADMISSION_REQUEST" && echo '{"decision": "reject", "reason": "vendor/ is frozen"}'`
+	err := admitWith(reject, &mrqueue.MR{Branch: branch, Target: "main"})
+	if !errors.Is(err, ErrNotAdmitted) || !strings.Contains(err.Error(), "vendor/ is frozen") {
+		t.Errorf("admit with a rejecting policy = %v", err)
+	}
+
+	mr := &mrqueue.MR{Branch: branch, Target: "main", Priority: 2}
+	relabel := `echo '{"decision": "accept", "priority": 0, "title": "Urgent fix", "routes": ["docs"]}'`
+	if err := admitWith(relabel, mr); err != nil {
+		t.Fatal(err)
+	}
+	if mr.Priority != 0 || mr.Title != "Urgent fix" || len(mr.Routes) != 1 || mr.Routes[0] != "docs" {
+		t.Errorf("relabelled MR = %+v", mr)
+	}
+
+	// A decision the refinery cannot apply is a failure, and failures
+	// reject unless on_error = "accept"
+	bad := `echo '{"decision": "accept", "routes": ["nope"]}'`
+	if err := admitWith(bad, &mrqueue.MR{Branch: branch, Target: "main"}); err == nil || errors.Is(err, ErrNotAdmitted) {
+		t.Errorf("admit with an unknown route = %v, want a policy failure", err)
+	}
+	e.settings.Admission = &config.AdmissionConfig{Command: "exit 1", OnError: config.AdmissionAccept}
+	if err := admit(context.Background(), e.settings, "test-rig", e.rig.Path, e.git, io.Discard, mr); err != nil {
+		t.Errorf("admit failing open = %v", err)
+	}
+}
+
+func TestAdmit_URL(t *testing.T) {
+	e, _ := newFakeEngineer(t)
+	t.Setenv("ADMISSION_TOKEN", "s3cret")
+	var got AdmissionRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = io.WriteString(w, `{"decision": "reject", "reason": "no issue"}`)
+	}))
+	defer ts.Close()
+	e.settings.Admission = &config.AdmissionConfig{URL: ts.URL, Token: "${env:ADMISSION_TOKEN}"}
+
+	mr := &mrqueue.MR{Branch: "polecat/nux/gt-1", Target: "main", Worker: "nux"}
+	err := admit(context.Background(), e.settings, "test-rig", e.rig.Path, e.git, io.Discard, mr)
+	if !errors.Is(err, ErrNotAdmitted) {
+		t.Fatalf("admit = %v, want rejected", err)
+	}
+	if got.Rig != "test-rig" || got.MR == nil || got.MR.Worker != "nux" {
+		t.Errorf("webhook got %+v", got)
+	}
+}
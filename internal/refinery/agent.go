@@ -0,0 +1,47 @@
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// runAgent runs an agent command from rig.toml (the summary writer,
+// reviewer, or triage agent) with sh -c in the engineer's worktree, and
+// returns what it printed to stdout. what names the agent in errors.
+func (e *Engineer) runAgent(ctx context.Context, what, command string, timeout time.Duration, env []string) (string, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	// Agent commands come from the rig's settings (trusted infrastructure config).
+	cmd := exec.CommandContext(ctx, "sh", "-c", command) //nolint:gosec // G204: trusted rig config
+	cmd.Dir = e.workDir
+	cmd.Env = env
+	// exec copies each stream in its own goroutine, so they get a buffer
+	// each; a failure reports both, stderr last.
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	started := time.Now()
+	err := cmd.Run()
+	util.TraceCommand(e.workDir, "sh", cmd.Args[1:], started, err)
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("%s timed out after %s", what, timeout)
+	}
+	if err != nil {
+		output := stdout.String()
+		if output != "" && !strings.HasSuffix(output, "\n") {
+			output += "\n"
+		}
+		return "", fmt.Errorf("%s failed: %v\n%s", what, err, tailLines(output+stderr.String(), feedbackOutputLines))
+	}
+	return stdout.String(), nil
+}
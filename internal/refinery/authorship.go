@@ -0,0 +1,43 @@
+package refinery
+
+import (
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/polecat"
+)
+
+// ErrAuthorMismatch means a polecat branch carries commits its polecat did
+// not author.
+var ErrAuthorMismatch = errors.New("branch namespace and commit author disagree")
+
+// authoredBy reports whether a commit author is the polecat that owns
+// branch. Polecats author as their bare name; an actor address such as
+// "greenplace/polecats/nux" is accepted too.
+func authoredBy(author, branch string) bool {
+	return author != "" && polecat.IsBranchOf(branch, path.Base(author))
+}
+
+// checkAuthorship verifies that every commit branch adds over target was
+// authored by the polecat the branch is named for. Returns nil when
+// verification is off or the branch is not a polecat branch.
+func checkAuthorship(g GitRunner, s *config.RefinerySettings, branch, target string) error {
+	if s == nil || !s.VerifyAuthorship {
+		return nil
+	}
+	if !polecat.IsBranch(branch) {
+		return nil
+	}
+	ids, err := g.CommitIdentities(target, branch)
+	if err != nil {
+		return fmt.Errorf("listing commits on %s: %w", branch, err)
+	}
+	for _, id := range ids {
+		if !authoredBy(id.AuthorName, branch) {
+			return fmt.Errorf("%w: %s commit %.8s is authored by %q", ErrAuthorMismatch, branch, id.SHA, id.AuthorName)
+		}
+	}
+	return nil
+}
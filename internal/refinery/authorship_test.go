@@ -0,0 +1,38 @@
+package refinery
+
+import (
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+func TestAuthoredBy(t *testing.T) {
+	tests := []struct {
+		author, branch string
+		want           bool
+	}{
+		{"nux", "polecat/nux-lx4k2b9c", true},
+		{"nux", "polecat/nux/gt-abc", true},
+		{"greenplace/polecats/nux", "polecat/nux", true},
+		{"furiosa", "polecat/nux-lx4k2b9c", false},
+		{"Test User", "polecat/nux", false},
+		{"nux", "polecat/nux2", false},
+		{"", "polecat/nux", false},
+	}
+	for _, tt := range tests {
+		if got := authoredBy(tt.author, tt.branch); got != tt.want {
+			t.Errorf("authoredBy(%q, %q) = %v, want %v", tt.author, tt.branch, got, tt.want)
+		}
+	}
+}
+
+func TestCheckAuthorship_Disabled(t *testing.T) {
+	// Off by default: no git calls, so a nil repo is fine.
+	if err := checkAuthorship(nil, &config.RefinerySettings{}, "polecat/nux/gt-abc", "main"); err != nil {
+		t.Errorf("checkAuthorship with verify_authorship off = %v, want nil", err)
+	}
+	s := &config.RefinerySettings{VerifyAuthorship: true}
+	if err := checkAuthorship(nil, s, "crew/max/fix", "main"); err != nil {
+		t.Errorf("checkAuthorship on a non-polecat branch = %v, want nil", err)
+	}
+}
@@ -0,0 +1,227 @@
+package refinery
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// BackportTrailer is the commit trailer that asks for a branch's merge to
+// be backported ("Backport: release/1.4"); each one names a branch.
+const BackportTrailer = "Backport"
+
+// backportPrefix starts the branches backports are queued from:
+// backport/<release>/<MR ID>.
+const backportPrefix = "backport/"
+
+// isBackport reports whether mr is a backport the refinery queued.
+func isBackport(mr *mrqueue.MR) bool {
+	return mr.Worker == landingWorker && strings.HasPrefix(mr.Branch, backportPrefix)
+}
+
+// queuedItself reports whether the refinery queued mr itself, as a swarm
+// landing or a backport. Its work passed the worker, issue, and signature
+// gates on its way into the target, so they let it through.
+func queuedItself(mr *mrqueue.MR) bool {
+	return isLanding(mr) || isBackport(mr)
+}
+
+// backportDir is where merges are picked onto release branches.
+func (e *Engineer) backportDir() string {
+	return filepath.Join(e.rig.Path, ".runtime", "backport")
+}
+
+// backportTargets returns the release branches mr's merge goes to: those
+// named by Backport trailers on its commits since before, then those
+// [refinery.backport] rules give its source issue's labels. Trailers
+// naming a branch outside backport.branches are ignored.
+func (e *Engineer) backportTargets(mr *mrqueue.MR, before string) []string {
+	bc := e.settings.Backport
+	var targets []string
+	seen := map[string]bool{mr.Target: true}
+	add := func(branch string) {
+		if !seen[branch] {
+			seen[branch] = true
+			targets = append(targets, branch)
+		}
+	}
+	trailers, _ := e.git.Trailers(before, mr.Branch, BackportTrailer)
+	for i := len(trailers) - 1; i >= 0; i-- { // Oldest commit first
+		for _, branch := range strings.Fields(strings.ReplaceAll(trailers[i], ",", " ")) {
+			if bc.Allowed(branch) {
+				add(branch)
+			} else {
+				_, _ = fmt.Fprintf(e.output, "[Engineer] Ignoring Backport: %s on %s: not in refinery.backport.branches\n", branch, mr.Branch)
+			}
+		}
+	}
+	if mr.SourceIssue != "" && len(bc.Rules) > 0 {
+		if issue, err := e.beads.Show(mr.SourceIssue); err == nil {
+			for _, branch := range bc.ForLabels(issue.Labels) {
+				add(branch)
+			}
+		}
+	}
+	return targets
+}
+
+// backport picks a merge into the rig's target onto each release branch
+// it is backported to, and queues each pick as an MR of its own, which
+// runs the checks against the release branch before merging. A pick that
+// conflicts is reported as a failure, with a task filed to backport the
+// change by hand.
+func (e *Engineer) backport(mr *mrqueue.MR, result ProcessResult) {
+	if e.settings == nil || e.settings.Backport == nil || result.TargetBefore == "" || mr.Target != e.config.TargetBranch {
+		return
+	}
+	for _, release := range e.backportTargets(mr, result.TargetBefore) {
+		if err := e.backportTo(mr, result, release); err != nil {
+			e.backportFailed(mr, result.MergeCommit, release, err)
+		}
+	}
+}
+
+// backportTo picks mr's merge onto release in a scratch worktree, pushes
+// the result to backport/<release>/<MR ID>, and queues it for release.
+func (e *Engineer) backportTo(mr *mrqueue.MR, result ProcessResult, release string) error {
+	if err := e.git.FetchRefs("origin", release); err != nil {
+		return fmt.Errorf("fetching origin/%s: %w", release, err)
+	}
+	dir := filepath.Join(e.backportDir(), mr.ID)
+	_ = e.git.WorktreeRemove(dir, true)
+	_ = os.RemoveAll(dir)
+	if err := e.git.WorktreeAddDetached(dir, "origin/"+release); err != nil {
+		return fmt.Errorf("creating worktree: %w", err)
+	}
+	defer func() {
+		_ = e.git.WorktreeRemove(dir, true)
+		_ = e.git.WorktreePrune()
+	}()
+	wg := e.gitAt(dir)
+
+	start, err := wg.Rev("HEAD")
+	if err != nil {
+		return err
+	}
+	title := mr.Title
+	if title == "" {
+		title = mr.Branch
+	}
+	msg := fmt.Sprintf("Backport %s to %s", title, release)
+	if mr.SourceIssue != "" {
+		msg = fmt.Sprintf("Backport %s to %s (%s)", title, release, mr.SourceIssue)
+	}
+	msg += fmt.Sprintf("\n\n(cherry picked from commit %s)", result.MergeCommit)
+	if err := wg.CherryPickRange(result.TargetBefore, result.MergeCommit, msg); err != nil {
+		return err // Removing the worktree discards a stopped pick
+	}
+	sha, err := wg.Rev("HEAD")
+	if err != nil {
+		return err
+	}
+	if sha == start {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] %s is already on %s; nothing to backport\n", mr.ID, release)
+		return nil
+	}
+
+	branch := backportPrefix + release + "/" + mr.ID
+	if exists, _ := e.git.BranchExists(branch); exists {
+		err = e.git.UpdateBranchRef(branch, sha)
+	} else {
+		err = e.git.CreateBranchFrom(branch, sha)
+	}
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", branch, err)
+	}
+	if err := e.git.Push("origin", branch, true); err != nil {
+		return fmt.Errorf("pushing %s: %w", branch, err)
+	}
+	follow := &mrqueue.MR{
+		Branch:      branch,
+		Target:      release,
+		SourceIssue: mr.SourceIssue,
+		Worker:      landingWorker,
+		Rig:         e.rig.Name,
+		Title:       fmt.Sprintf("Backport %s to %s", mr.ID, release),
+		Priority:    mr.Priority,
+	}
+	if err := e.mrQueue.Submit(follow); err != nil {
+		return fmt.Errorf("queueing %s: %w", branch, err)
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Queued backport of %s to %s (%s)\n", mr.ID, release, follow.ID)
+	return nil
+}
+
+// backportFailed reports a backport of mr to release that could not be
+// queued: as a merge_failed event for the backport branch, to the
+// on_failure recipients, and, for a conflict, as a task to backport by
+// hand.
+func (e *Engineer) backportFailed(mr *mrqueue.MR, mergeCommit, release string, err error) {
+	conflict := errors.Is(err, git.ErrMergeConflict)
+	reason := fmt.Sprintf("backport to %s failed: %v", release, err)
+	failureType := "build"
+	if conflict {
+		reason = fmt.Sprintf("backport to %s conflicts", release)
+		failureType = "conflict"
+	}
+	failed := *mr
+	failed.Branch = backportPrefix + release + "/" + mr.ID
+	failed.Target = release
+	if err := e.eventLogger.LogMergeFailure(&failed, reason, failureType, ""); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to log merge_failed event: %v\n", err)
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] ✗ Backport of %s: %s\n", mr.ID, reason)
+
+	if conflict {
+		task, err := e.beads.Create(beads.CreateOptions{
+			Title:       fmt.Sprintf("Backport %s to %s", mr.ID, release),
+			Type:        "task",
+			Priority:    mr.Priority,
+			Description: backportTaskDescription(mr, mergeCommit, release),
+			Actor:       e.rig.Name + "/refinery",
+		})
+		if err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to file backport task: %v\n", err)
+		} else {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Filed %s to backport %s to %s by hand\n", task.ID, mr.ID, release)
+		}
+	}
+
+	if e.settings.Notifications != nil {
+		e.notify(e.settings.Notifications.OnFailure,
+			fmt.Sprintf("Backport failed: %s to %s", mr.Branch, release),
+			fmt.Sprintf("MR %s (%s) merged into %s, but its %s.", mr.ID, mr.SourceIssue, mr.Target, reason))
+	}
+}
+
+// backportTaskDescription describes the task filed for a backport that
+// conflicts.
+func backportTaskDescription(mr *mrqueue.MR, mergeCommit, release string) string {
+	branch := backportPrefix + release + "/" + mr.ID
+	return fmt.Sprintf(`Backport MR %s to %s by hand; cherry-picking it conflicts.
+
+## Metadata
+- Original MR: %s
+- Branch: %s
+- Merged into: %s as %s
+- Original issue: %s
+
+## Instructions
+1. Branch from the release: git checkout -b %s origin/%s
+2. Cherry-pick the merge (-m 1 if it is a merge commit): git cherry-pick %s
+3. Resolve the conflicts and commit
+4. Push and queue the branch: gt refinery enqueue %s --target %s
+5. Close this task: bd close <this-task-id>`,
+		mr.ID, release,
+		mr.ID, mr.Branch, mr.Target, mergeCommit, mr.SourceIssue,
+		branch, release,
+		mergeCommit,
+		branch, release,
+	)
+}
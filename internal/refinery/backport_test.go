@@ -0,0 +1,112 @@
+package refinery
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/git/gitfake"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// queueBackported queues a branch whose commit asks for a backport.
+func queueBackported(t *testing.T, e *Engineer, repo *gitfake.Repo, changes map[string]string) *mrqueue.MR {
+	t.Helper()
+	branch := "polecat/nux/gt-1"
+	if err := e.git.CreateBranchFrom(branch, "main"); err != nil {
+		t.Fatal(err)
+	}
+	repo.Commit(branch, "Fix shared\n\nBackport: release/1.0, stable", changes)
+	mr := &mrqueue.MR{Branch: branch, Target: "main", Worker: "nux", Title: "Fix shared"}
+	if err := e.mrQueue.Submit(mr); err != nil {
+		t.Fatal(err)
+	}
+	return mr
+}
+
+func TestBackport_QueuesFollowUp(t *testing.T) {
+	e, repo := newFakeEngineer(t)
+	e.settings.Backport = &config.BackportConfig{Branches: []string{"release/*"}}
+	if err := e.git.CreateBranchFrom("release/1.0", "main"); err != nil {
+		t.Fatal(err)
+	}
+	repo.Publish("release/1.0")
+	repo.Commit("main", "unrelated", map[string]string{"main.txt": "main only\n"})
+	repo.Publish("main")
+	mr := queueBackported(t, e, repo, map[string]string{"shared.txt": "one\nfixed\n"})
+
+	res := e.doMerge(context.Background(), mr)
+	if !res.Success {
+		t.Fatalf("doMerge = %+v", res)
+	}
+	e.backport(mr, res)
+
+	queued, err := e.mrQueue.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var follow *mrqueue.MR
+	for _, q := range queued {
+		if q.ID != mr.ID {
+			follow = q
+		}
+	}
+	if follow == nil || len(queued) != 2 {
+		t.Fatalf("queue = %v, want the MR and one backport; stable is not in backport.branches", mrIDs(queued))
+	}
+	if want := "backport/release/1.0/" + mr.ID; follow.Branch != want || follow.Target != "release/1.0" || !isBackport(follow) {
+		t.Errorf("backport MR = %s -> %s, want %s -> release/1.0", follow.Branch, follow.Target, want)
+	}
+	f := repo.Lookup(repo.Origin(follow.Branch)).Files
+	if f["shared.txt"] != "one\nfixed\n" || f["main.txt"] != "" {
+		t.Errorf("backport branch = %v, want the fix without main's other work", f)
+	}
+
+	// The backport merges into the release without being backported again.
+	res = e.doMerge(context.Background(), follow)
+	if !res.Success {
+		t.Fatalf("doMerge(backport) = %+v", res)
+	}
+	e.backport(follow, res)
+	if queued, _ := e.mrQueue.List(); len(queued) != 2 {
+		t.Errorf("queue after the backport merged = %v, want nothing new", mrIDs(queued))
+	}
+}
+
+func TestBackport_Conflict(t *testing.T) {
+	e, repo := newFakeEngineer(t)
+	e.settings.Backport = &config.BackportConfig{Branches: []string{"release/*"}}
+	if err := e.git.CreateBranchFrom("release/1.0", "main"); err != nil {
+		t.Fatal(err)
+	}
+	repo.Commit("release/1.0", "release fix", map[string]string{"shared.txt": "one\nreleased\n"})
+	repo.Publish("release/1.0")
+	mr := queueBackported(t, e, repo, map[string]string{"shared.txt": "one\nfixed\n"})
+
+	res := e.doMerge(context.Background(), mr)
+	if !res.Success {
+		t.Fatalf("doMerge = %+v", res)
+	}
+	e.backport(mr, res)
+
+	if queued, _ := e.mrQueue.List(); len(queued) != 1 {
+		t.Errorf("queue = %v, want no backport MR for a conflicting pick", mrIDs(queued))
+	}
+	if out := e.output.(*strings.Builder).String(); !strings.Contains(out, "backport to release/1.0 conflicts") {
+		t.Errorf("output does not report the conflict:\n%s", out)
+	}
+	events, err := e.eventLogger.ReadEvents(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var failed bool
+	for _, ev := range events {
+		if ev.Type == mrqueue.EventMergeFailed && ev.Target == "release/1.0" && ev.FailureType == "conflict" {
+			failed = true
+		}
+	}
+	if !failed {
+		t.Errorf("no merge_failed event for release/1.0 in %+v", events)
+	}
+}
@@ -0,0 +1,198 @@
+package refinery
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+// Benchmarks for the merge queue run against generated rigs. Size the load
+// with flags after -args, and profile with the usual go test flags:
+//
+//	go test ./internal/refinery -run '^$' -bench . -benchmem \
+//	    -cpuprofile cpu.out -args -load.branches=200 -load.check=50ms
+
+var (
+	loadBranches = flag.Int("load.branches", 20, "queued branches in generated benchmark rigs")
+	loadCheck    = flag.Duration("load.check", 0, "how long each generated MR's check takes")
+	loadEvents   = flag.Int("load.events", 10000, "events in the generated merge history")
+)
+
+// syntheticLoad describes a generated rig: branches polecat branches, each
+// adding its own file so none conflict, all queued for main, and a check
+// that sleeps for check.
+type syntheticLoad struct {
+	branches int
+	check    time.Duration
+}
+
+func flagLoad() syntheticLoad {
+	return syntheticLoad{branches: *loadBranches, check: *loadCheck}
+}
+
+// newSyntheticRig generates a rig with a bare origin for load.
+func newSyntheticRig(tb testing.TB, load syntheticLoad) (*Engineer, []*mrqueue.MR) {
+	tb.Helper()
+	root := tb.TempDir()
+	origin := filepath.Join(root, "origin.git")
+	rigPath := filepath.Join(root, "rig")
+	run := func(dir string, args ...string) {
+		tb.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=load", "GIT_AUTHOR_EMAIL=load@example.com",
+			"GIT_COMMITTER_NAME=load", "GIT_COMMITTER_EMAIL=load@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			tb.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		tb.Helper()
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(rigPath, name)), 0755); err != nil {
+			tb.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(rigPath, name), []byte(content), 0644); err != nil {
+			tb.Fatal(err)
+		}
+	}
+
+	run(root, "init", "--bare", "-b", "main", origin)
+	run(root, "init", "-b", "main", rigPath)
+	run(rigPath, "config", "user.name", "load")
+	run(rigPath, "config", "user.email", "load@example.com")
+	run(rigPath, "remote", "add", "origin", origin)
+	write("README", "synthetic rig\n")
+	toml := fmt.Sprintf("[refinery]\n\n[[refinery.checks]]\nname = \"load\"\ncommand = \"sleep %g\"\n", load.check.Seconds())
+	write("settings/rig.toml", toml)
+	write(".gitignore", ".runtime/\n.beads/\n")
+	run(rigPath, "add", ".")
+	run(rigPath, "commit", "-m", "base")
+	run(rigPath, "push", "origin", "main")
+
+	e := NewEngineer(&rig.Rig{Name: "load-rig", Path: rigPath})
+	e.SetOutput(&strings.Builder{})
+	if err := e.LoadConfig(); err != nil {
+		tb.Fatalf("LoadConfig: %v", err)
+	}
+
+	mrs := make([]*mrqueue.MR, 0, load.branches)
+	for i := 0; i < load.branches; i++ {
+		worker := fmt.Sprintf("worker%d", i%8)
+		branch := fmt.Sprintf("polecat/%s/gt-%d", worker, i)
+		run(rigPath, "checkout", "-q", "-b", branch, "main")
+		write(fmt.Sprintf("work/%d.txt", i), fmt.Sprintf("change %d\n", i))
+		run(rigPath, "add", ".")
+		run(rigPath, "commit", "-q", "-m", branch)
+		mr := &mrqueue.MR{Branch: branch, Target: "main", Worker: worker, Priority: i % 4}
+		if err := e.mrQueue.Submit(mr); err != nil {
+			tb.Fatal(err)
+		}
+		mrs = append(mrs, mr)
+	}
+	run(rigPath, "checkout", "-q", "main")
+	return e, mrs
+}
+
+// BenchmarkProcessQueue merges a generated queue end to end and reports
+// throughput in MRs per second.
+func BenchmarkProcessQueue(b *testing.B) {
+	load := flagLoad()
+	var merged int
+	var elapsed time.Duration
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		e, _ := newSyntheticRig(b, load)
+		b.StartTimer()
+		started := time.Now()
+		for {
+			ready, err := e.readyMRs()
+			if err != nil {
+				b.Fatal(err)
+			}
+			if len(ready) == 0 {
+				break
+			}
+			mr := ready[0]
+			if res := e.doMerge(b.Context(), mr); !res.Success {
+				b.Fatalf("merging %s: %s", mr.Branch, res.Error)
+			}
+			if err := e.mrQueue.Remove(mr.ID); err != nil {
+				b.Fatal(err)
+			}
+			merged++
+		}
+		elapsed += time.Since(started)
+	}
+	b.ReportMetric(float64(merged)/elapsed.Seconds(), "mrs/s")
+}
+
+// BenchmarkReadyMRs measures the scheduler: deciding which queued MRs are
+// ready and in what order.
+func BenchmarkReadyMRs(b *testing.B) {
+	e, mrs := newSyntheticRig(b, flagLoad())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ready, err := e.readyMRs()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(ready) != len(mrs) {
+			b.Fatalf("%d ready, want %d", len(ready), len(mrs))
+		}
+	}
+}
+
+// BenchmarkMergeConflicts measures test-merging a branch in the mirror.
+func BenchmarkMergeConflicts(b *testing.B) {
+	e, mrs := newSyntheticRig(b, syntheticLoad{branches: 1})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if files, err := e.mergeConflicts(mrs[0].Branch, "main"); err != nil || len(files) > 0 {
+			b.Fatalf("mergeConflicts = %v, %v", files, err)
+		}
+	}
+}
+
+// BenchmarkCheckConflicts measures the worktree test merge the mirror
+// replaces, for comparison.
+func BenchmarkCheckConflicts(b *testing.B) {
+	e, mrs := newSyntheticRig(b, syntheticLoad{branches: 1})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if files, err := e.git.CheckConflicts(mrs[0].Branch, "main"); err != nil || len(files) > 0 {
+			b.Fatalf("CheckConflicts = %v, %v", files, err)
+		}
+	}
+}
+
+// BenchmarkComputeStats aggregates a generated merge history.
+func BenchmarkComputeStats(b *testing.B) {
+	now := time.Now()
+	events := make([]mrqueue.Event, *loadEvents)
+	for i := range events {
+		events[i] = mrqueue.Event{
+			Timestamp: now.Add(-time.Duration(len(events)-i) * time.Minute),
+			Type:      mrqueue.EventMerged,
+			MRID:      fmt.Sprintf("mr-%d", i),
+			Branch:    fmt.Sprintf("polecat/worker%d/gt-%d", i%8, i),
+			Target:    "main",
+			Worker:    fmt.Sprintf("worker%d", i%8),
+		}
+		if i%5 == 0 {
+			events[i].Type = mrqueue.EventMergeFailed
+			events[i].FailureType = "tests"
+		}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ComputeStats(events, now, DefaultStatsDays)
+	}
+}
@@ -0,0 +1,250 @@
+package refinery
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// DefaultBisectLimit is how many of a target's latest refinery merges
+// Bisect searches unless told otherwise.
+const DefaultBisectLimit = 20
+
+// Where a failure was found outside the merges Bisect searched.
+const (
+	BisectBefore = "before" // The checks fail before the oldest merge searched
+	BisectAfter  = "after"  // They pass at the last refinery merge; later commits broke it
+)
+
+// BisectStep is one commit Bisect ran the checks at.
+type BisectStep struct {
+	Commit      string `json:"commit"`
+	MRID        string `json:"mr_id,omitempty"` // Empty for the baseline and the tip
+	Branch      string `json:"branch,omitempty"`
+	Passed      bool   `json:"passed"`
+	FailedCheck string `json:"failed_check,omitempty"`
+}
+
+// BisectResult is what Bisect found.
+type BisectResult struct {
+	Target string       `json:"target"`
+	Tip    string       `json:"tip"`
+	Merges int          `json:"merges"` // Refinery merges searched
+	Steps  []BisectStep `json:"steps"`  // In the order run
+
+	// Passing is set when the checks pass at the tip: nothing is broken.
+	Passing bool `json:"passing,omitempty"`
+
+	// Culprit is the first merge the checks fail at. It is nil if they
+	// pass, or if the failure is Outside the merges searched.
+	Culprit *mrqueue.Event `json:"culprit,omitempty"`
+	Outside string         `json:"outside,omitempty"`
+}
+
+// bisectDir is the scratch worktree Bisect runs checks in.
+func (e *Engineer) bisectDir() string {
+	return filepath.Join(e.rig.Path, ".runtime", "bisect")
+}
+
+// bisectChecks returns the checks Bisect runs: command if set, else
+// refinery.checks, else the merge queue's test command.
+func (e *Engineer) bisectChecks(command string) ([]config.CheckConfig, error) {
+	switch {
+	case command != "":
+		return []config.CheckConfig{{Name: "bisect", Command: command}}, nil
+	case e.settings != nil && len(e.settings.Checks) > 0:
+		return e.settings.Checks, nil
+	case e.config.TestCommand != "":
+		return []config.CheckConfig{{Name: "test", Command: e.config.TestCommand}}, nil
+	}
+	return nil, fmt.Errorf("no checks to run; set refinery.checks or pass a command")
+}
+
+// recentMerges returns the last limit refinery merges into target that
+// are in tip's history, oldest first.
+func (e *Engineer) recentMerges(target, tip string, limit int) ([]mrqueue.Event, error) {
+	events, err := e.eventLogger.ReadEvents(0)
+	if err != nil {
+		return nil, fmt.Errorf("reading merge events: %w", err)
+	}
+	var merges []mrqueue.Event
+	seen := make(map[string]bool)
+	for i := len(events) - 1; i >= 0 && len(merges) < limit; i-- {
+		ev := events[i]
+		if ev.Type != mrqueue.EventMerged || ev.Target != target || ev.MergeCommit == "" || seen[ev.MergeCommit] {
+			continue
+		}
+		seen[ev.MergeCommit] = true
+		if ok, err := e.git.IsAncestor(ev.MergeCommit, tip); err != nil || !ok {
+			continue // Rewound, or merged by GitHub and not fetched
+		}
+		merges = append(merges, ev)
+	}
+	for i, j := 0, len(merges)-1; i < j; i, j = i+1, j-1 {
+		merges[i], merges[j] = merges[j], merges[i]
+	}
+	return merges, nil
+}
+
+// Bisect finds the refinery merge that broke target: it runs the checks
+// (command, or refinery.checks) at origin's tip and, if they fail there,
+// binary-searches the last limit merges the event log records into target
+// for the first one they fail at, starting from the target before the
+// oldest of them. Checks run in a scratch worktree; nothing is changed.
+func (e *Engineer) Bisect(ctx context.Context, target string, limit int, command string) (*BisectResult, error) {
+	if target == "" {
+		target = e.config.TargetBranch
+	}
+	if limit <= 0 {
+		limit = DefaultBisectLimit
+	}
+	checks, err := e.bisectChecks(command)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.git.FetchRefs("origin", target); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: fetch origin/%s: %v (bisecting it as last fetched)\n", target, err)
+	}
+	tip, err := e.git.Rev("origin/" + target)
+	if err != nil {
+		return nil, fmt.Errorf("target %s not found on origin", target)
+	}
+	merges, err := e.recentMerges(target, tip, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(merges) == 0 {
+		return nil, fmt.Errorf("no refinery merges into %s in its history", target)
+	}
+
+	dir := e.bisectDir()
+	_ = e.git.WorktreePrune()
+	_ = os.RemoveAll(dir)
+	if err := e.git.WorktreeAddDetached(dir, tip); err != nil {
+		return nil, fmt.Errorf("creating bisect worktree: %w", err)
+	}
+	defer func() { _ = e.git.WorktreeRemove(dir, true) }()
+	tester := *e
+	tester.git = e.gitAt(dir)
+	tester.workDir = dir
+
+	result := &BisectResult{Target: target, Tip: tip, Merges: len(merges)}
+	test := func(commit string, ev *mrqueue.Event) (bool, error) {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		if err := tester.git.Checkout(commit); err != nil {
+			return false, fmt.Errorf("checking out %s: %w", shortSHA(commit), err)
+		}
+		step := BisectStep{Commit: commit}
+		if ev != nil {
+			step.MRID, step.Branch = ev.MRID, ev.Branch
+		}
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Bisect: checking %s\n", shortSHA(commit))
+		res := tester.runChecks(ctx, checks)
+		step.Passed, step.FailedCheck = res.Success, res.FailedCheck
+		result.Steps = append(result.Steps, step)
+		return res.Success, nil
+	}
+
+	// good and bad index merges, with -1 the target before the oldest of
+	// them and len(merges) the tip
+	good, bad := -1, len(merges)
+	if tip == merges[len(merges)-1].MergeCommit {
+		bad = len(merges) - 1
+	}
+	var tipEvent *mrqueue.Event
+	if bad < len(merges) {
+		tipEvent = &merges[bad]
+	}
+	if ok, err := test(tip, tipEvent); err != nil || ok {
+		result.Passing = ok
+		return result, err
+	}
+	baseline, err := e.git.Rev(merges[0].MergeCommit + "^")
+	if err != nil {
+		return nil, fmt.Errorf("finding the target before %s: %w", shortSHA(merges[0].MergeCommit), err)
+	}
+	if ok, err := test(baseline, nil); err != nil {
+		return result, err
+	} else if !ok {
+		result.Outside = BisectBefore
+		return result, nil
+	}
+
+	for bad-good > 1 {
+		mid := (good + bad) / 2
+		ok, err := test(merges[mid].MergeCommit, &merges[mid])
+		if err != nil {
+			return result, err
+		}
+		if ok {
+			good = mid
+		} else {
+			bad = mid
+		}
+	}
+	if bad == len(merges) {
+		result.Outside = BisectAfter
+	} else {
+		result.Culprit = &merges[bad]
+	}
+	return result, nil
+}
+
+// FindMerge returns the refinery merge ref names: an MR ID, or its merge
+// commit, full or abbreviated. The latest match wins.
+func (e *Engineer) FindMerge(ref string) (*mrqueue.Event, error) {
+	events, err := e.eventLogger.ReadEvents(0)
+	if err != nil {
+		return nil, fmt.Errorf("reading merge events: %w", err)
+	}
+	for i := len(events) - 1; i >= 0; i-- {
+		ev := events[i]
+		if ev.Type != mrqueue.EventMerged || ev.MergeCommit == "" {
+			continue
+		}
+		if ev.MRID == ref || (len(ref) >= 7 && strings.HasPrefix(ev.MergeCommit, ref)) {
+			return &ev, nil
+		}
+	}
+	return nil, fmt.Errorf("no refinery merge %q in the event log", ref)
+}
+
+// Revert reverts merge on its target as origin has it, in a scratch
+// worktree, and pushes the revert. It returns the revert commit.
+func (e *Engineer) Revert(merge *mrqueue.Event) (string, error) {
+	target := merge.Target
+	if err := e.git.FetchRefs("origin", target); err != nil {
+		return "", fmt.Errorf("fetching %s: %w", target, err)
+	}
+	if ok, err := e.git.IsAncestor(merge.MergeCommit, "origin/"+target); err != nil || !ok {
+		return "", fmt.Errorf("%s is not on origin/%s", shortSHA(merge.MergeCommit), target)
+	}
+
+	dir := filepath.Join(e.rig.Path, ".runtime", "revert")
+	_ = e.git.WorktreePrune()
+	_ = os.RemoveAll(dir)
+	if err := e.git.WorktreeAddDetached(dir, "origin/"+target); err != nil {
+		return "", fmt.Errorf("creating revert worktree: %w", err)
+	}
+	defer func() { _ = e.git.WorktreeRemove(dir, true) }()
+	g := e.gitAt(dir)
+	if err := g.Revert(merge.MergeCommit); err != nil {
+		return "", err
+	}
+	if err := g.PushHead("origin", target); err != nil {
+		return "", fmt.Errorf("pushing revert to %s: %w", target, err)
+	}
+	sha, err := g.Rev("HEAD")
+	if err != nil {
+		return "", err
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Reverted %s (%s) on %s as %s\n", merge.Branch, shortSHA(merge.MergeCommit), target, shortSHA(sha))
+	return sha, nil
+}
@@ -0,0 +1,94 @@
+package refinery
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func TestBisect_FindsAndRevertsCulprit(t *testing.T) {
+	root := t.TempDir()
+	origin := filepath.Join(root, "origin.git")
+	rigPath := filepath.Join(root, "rig")
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = rigPath
+		if args[0] == "init" {
+			cmd.Dir = root
+		}
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "--bare", "-b", "main", origin)
+	git("init", "-b", "main", rigPath)
+	git("config", "user.name", "test")
+	git("config", "user.email", "test@example.com")
+	git("remote", "add", "origin", origin)
+	if err := os.WriteFile(filepath.Join(rigPath, ".gitignore"), []byte(".runtime/\n.beads/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", ".gitignore")
+	git("commit", "-m", "base")
+
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: rigPath})
+	e.SetOutput(&strings.Builder{})
+	var merges []string
+	for i, file := range []string{"one", "two", "broken", "three", "four"} {
+		branch := "polecat/nux/gt-" + file
+		git("checkout", "-q", "-b", branch, "main")
+		if err := os.WriteFile(filepath.Join(rigPath, file), []byte(file+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		git("add", file)
+		git("commit", "-m", "add "+file)
+		git("checkout", "-q", "main")
+		git("merge", "--no-ff", "-m", "Merge "+branch, branch)
+		merges = append(merges, git("rev-parse", "HEAD"))
+		mr := &mrqueue.MR{ID: fmt.Sprintf("mr-%c", 'a'+i), Branch: branch, Target: "main"}
+		if err := e.eventLogger.LogMerged(mr, merges[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("push", "-q", "origin", "main")
+
+	res, err := e.Bisect(t.Context(), "main", 0, "test ! -f broken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Culprit == nil || res.Culprit.MergeCommit != merges[2] || res.Culprit.MRID != "mr-c" {
+		t.Fatalf("Bisect = %+v, want the merge of polecat/nux/gt-broken", res)
+	}
+	if len(res.Steps) > 4 {
+		t.Errorf("Bisect ran %d steps, want a binary search of 5 merges", len(res.Steps))
+	}
+
+	culprit, err := e.FindMerge(merges[2][:10])
+	if err != nil || culprit.MRID != "mr-c" {
+		t.Fatalf("FindMerge = %+v, %v", culprit, err)
+	}
+	if _, err := e.Revert(culprit); err != nil {
+		t.Fatal(err)
+	}
+	res, err = e.Bisect(t.Context(), "main", 0, "test ! -f broken")
+	if err != nil || !res.Passing {
+		t.Errorf("Bisect after the revert = %+v, %v; want the target passing", res, err)
+	}
+
+	// A failure older than every merge searched is reported, not blamed
+	res, err = e.Bisect(t.Context(), "main", 1, "test -f missing")
+	if err != nil || res.Culprit != nil || res.Outside != BisectBefore {
+		t.Errorf("Bisect of an old failure = %+v, %v; want it outside, before", res, err)
+	}
+}
@@ -0,0 +1,91 @@
+package refinery
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// changeSpend is how much has merged in a day.
+type changeSpend struct {
+	Files int
+	Lines int
+}
+
+// budgetSpend sums the merges logged on now's local day: for the rig as a
+// whole, and per worker. Landings are left out, as their changes were
+// counted when each swarm member merged.
+func (e *Engineer) budgetSpend(now time.Time) (changeSpend, map[string]changeSpend, error) {
+	var total changeSpend
+	byWorker := make(map[string]changeSpend)
+	events, err := e.eventLogger.ReadEvents(0)
+	if err != nil {
+		return total, nil, err
+	}
+	y, m, d := now.Local().Date()
+	dayStart := time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	for _, ev := range events {
+		if ev.Type != mrqueue.EventMerged || ev.Timestamp.Before(dayStart) || integrationEpic(ev.Branch) != "" {
+			continue
+		}
+		total.Files += ev.Files
+		total.Lines += ev.Lines
+		w := byWorker[ev.Worker]
+		w.Files += ev.Files
+		w.Lines += ev.Lines
+		byWorker[ev.Worker] = w
+	}
+	return total, byWorker, nil
+}
+
+// splitOverBudget separates MRs that refinery.budget lets merge today from
+// those that must wait for tomorrow, with why each waits, keyed by MR ID.
+// Landings are never held back. If the event log cannot be read, nothing
+// is held back.
+func (e *Engineer) splitOverBudget(mrs []*mrqueue.MR, now time.Time) ([]*mrqueue.MR, map[string]string) {
+	if e.settings == nil || e.settings.Budget == nil {
+		return mrs, nil
+	}
+	budget := e.settings.Budget
+	total, byWorker, err := e.budgetSpend(now)
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: cannot check refinery.budget: %v\n", err)
+		return mrs, nil
+	}
+
+	ready := make([]*mrqueue.MR, 0, len(mrs))
+	over := make(map[string]string)
+	for _, mr := range mrs {
+		spend, who := total, "rig"
+		if budget.PerWorker {
+			spend, who = byWorker[mr.Worker], mr.Worker
+		}
+		if done, why := budget.Exhausted(spend.Files, spend.Lines); done && !isLanding(mr) {
+			over[mr.ID] = fmt.Sprintf("%s: %s", who, why)
+			continue
+		}
+		ready = append(ready, mr)
+	}
+	return ready, over
+}
+
+// ListOverBudgetMRs returns MRs otherwise ready that wait for tomorrow's
+// refinery.budget, with why each waits, keyed by MR ID.
+func (e *Engineer) ListOverBudgetMRs() ([]*mrqueue.MR, map[string]string, error) {
+	if e.settings == nil || e.settings.Budget == nil {
+		return nil, nil, nil
+	}
+	mrs, err := e.mrQueue.ListReady(e.IsBeadOpen)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, over := e.splitOverBudget(mrs, e.clock())
+	var waiting []*mrqueue.MR
+	for _, mr := range mrs {
+		if over[mr.ID] != "" {
+			waiting = append(waiting, mr)
+		}
+	}
+	return waiting, over, nil
+}
@@ -0,0 +1,61 @@
+package refinery
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func TestSplitOverBudget(t *testing.T) {
+	e := NewEngineer(&rig.Rig{Name: "testrig", Path: t.TempDir()})
+	e.SetOutput(io.Discard)
+	now := time.Now()
+
+	for _, ev := range []mrqueue.Event{
+		{Timestamp: now.Add(-48 * time.Hour), Type: mrqueue.EventMerged, Worker: "nux", Files: 50, Lines: 5000},
+		{Timestamp: now, Type: mrqueue.EventMerged, Worker: "nux", Branch: "polecat/nux", Files: 3, Lines: 120},
+		{Timestamp: now, Type: mrqueue.EventMerged, Worker: "furiosa", Branch: "polecat/furiosa", Files: 1, Lines: 10},
+		{Timestamp: now, Type: mrqueue.EventMerged, Worker: landingWorker, Branch: "integration/gt-epic", Files: 40, Lines: 900},
+	} {
+		if err := e.eventLogger.LogEvent(ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	nux := &mrqueue.MR{ID: "mr-nux", Worker: "nux", Branch: "polecat/nux-2"}
+	furiosa := &mrqueue.MR{ID: "mr-furiosa", Worker: "furiosa", Branch: "polecat/furiosa-2"}
+	landing := &mrqueue.MR{ID: "mr-land", Worker: landingWorker, Branch: "integration/gt-other"}
+	mrs := func() []*mrqueue.MR { return []*mrqueue.MR{nux, furiosa, landing} }
+
+	tests := []struct {
+		name   string
+		budget *config.BudgetConfig
+		ready  int
+		over   []string
+	}{
+		{"under budget", &config.BudgetConfig{Lines: 1000}, 3, nil},
+		// Yesterday's merges and the landing do not count: 130 lines today.
+		{"rig lines spent", &config.BudgetConfig{Lines: 130}, 1, []string{"mr-nux", "mr-furiosa"}},
+		{"rig files spent", &config.BudgetConfig{Files: 4}, 1, []string{"mr-nux", "mr-furiosa"}},
+		{"per worker", &config.BudgetConfig{Lines: 100, PerWorker: true}, 2, []string{"mr-nux"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e.settings = &config.RefinerySettings{Budget: tt.budget}
+			ready, over := e.splitOverBudget(mrs(), now)
+			if len(ready) != tt.ready || len(over) != len(tt.over) {
+				t.Fatalf("ready = %d, over = %v; want %d ready, %v over", len(ready), over, tt.ready, tt.over)
+			}
+			for _, id := range tt.over {
+				if !strings.Contains(over[id], "merged today") {
+					t.Errorf("over[%s] = %q", id, over[id])
+				}
+			}
+		})
+	}
+}
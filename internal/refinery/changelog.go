@@ -0,0 +1,55 @@
+package refinery
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// addChangelogEntry folds mr's [refinery.changelog] entry into the merge
+// commit just made, so the entry lands, or fails, with the merge itself.
+func (e *Engineer) addChangelogEntry(mr *mrqueue.MR) error {
+	if e.settings == nil || e.settings.Changelog == nil {
+		return nil
+	}
+	cc := e.settings.Changelog
+	title := mr.Title
+	if title == "" {
+		title = mr.Branch
+	}
+	entry, err := cc.Render(config.ChangelogEntry{
+		Title:  title,
+		Issue:  mr.SourceIssue,
+		MR:     mr.ID,
+		Branch: mr.Branch,
+		Worker: mr.Worker,
+		Target: mr.Target,
+		Date:   e.clock().Format("2006-01-02"),
+	})
+	if err != nil {
+		return fmt.Errorf("rendering changelog entry: %w", err)
+	}
+
+	head, err := e.git.Rev("HEAD")
+	if err != nil {
+		return err
+	}
+	path := cc.Path(mr.ID)
+	current, err := e.git.ShowFile(head, path)
+	if err != nil {
+		// A missing file is started; one that cannot be read is not
+		// overwritten
+		files, lerr := e.git.ListFiles(head)
+		if lerr != nil || slices.Contains(files, path) {
+			return fmt.Errorf("reading %s: %w", path, err)
+		}
+		current = ""
+	}
+	if err := e.git.AmendFile(path, cc.Apply(current, entry)); err != nil {
+		return fmt.Errorf("adding changelog entry to %s: %w", path, err)
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Added changelog entry to %s\n", path)
+	return nil
+}
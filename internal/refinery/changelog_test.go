@@ -0,0 +1,44 @@
+package refinery
+
+import (
+	"context"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+func TestChangelog_InMergeCommit(t *testing.T) {
+	e, repo := newFakeEngineer(t)
+	e.settings.Changelog = &config.ChangelogConfig{}
+	repo.Commit("main", "changelog", map[string]string{"CHANGELOG.md": "# Changelog\n\n## Unreleased\n\n- Older fix\n"})
+	repo.Publish("main")
+	mr := queueBranch(t, e, repo, "nux", map[string]string{"nux.txt": "nux\n"})
+	mr.Title = "Add nux"
+	mr.SourceIssue = "gt-7"
+
+	res := e.doMerge(context.Background(), mr)
+	if !res.Success {
+		t.Fatalf("doMerge = %+v", res)
+	}
+	merge := repo.Lookup(repo.Origin("main"))
+	if merge.SHA != res.MergeCommit || len(merge.Parents) != 2 {
+		t.Fatalf("origin/main = %s with parents %v, want the merge commit %s", merge.SHA, merge.Parents, res.MergeCommit)
+	}
+	if got, want := merge.Files["CHANGELOG.md"], "# Changelog\n\n## Unreleased\n\n- Add nux (gt-7)\n- Older fix\n"; got != want {
+		t.Errorf("CHANGELOG.md = %q, want %q", got, want)
+	}
+	if merge.Files["nux.txt"] != "nux\n" {
+		t.Error("merge commit lost the branch's change")
+	}
+
+	// Fragments go in a file of their own
+	e.settings.Changelog = &config.ChangelogConfig{Fragments: "changes", Entry: "{{.Title}} by {{.Worker}}"}
+	slit := queueBranch(t, e, repo, "slit", map[string]string{"slit.txt": "slit\n"})
+	slit.Title = "Add slit"
+	if res := e.doMerge(context.Background(), slit); !res.Success {
+		t.Fatalf("doMerge(slit) = %+v", res)
+	}
+	if got := repo.Lookup(repo.Origin("main")).Files["changes/"+slit.ID+".md"]; got != "Add slit by slit\n" {
+		t.Errorf("fragment = %q", got)
+	}
+}
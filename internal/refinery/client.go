@@ -0,0 +1,199 @@
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// TokenEnvVar supplies the API token for remote refinery commands.
+const TokenEnvVar = "GT_REFINERY_TOKEN"
+
+// Client talks to a refinery API served by `gt refinery serve`.
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// NewClient creates a client for the API at baseURL (e.g. "https://rig-host:8080").
+// A bare host:port is treated as http://host:port.
+func NewClient(baseURL, token string) (*Client, error) {
+	if !strings.Contains(baseURL, "://") {
+		baseURL = "http://" + baseURL
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid remote URL %q", baseURL)
+	}
+	return &Client{
+		baseURL: strings.TrimRight(u.String(), "/"),
+		token:   token,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Status returns the remote refinery state.
+func (c *Client) Status() (*Refinery, error) {
+	var ref Refinery
+	return &ref, c.do("GET", "/api/status", nil, &ref)
+}
+
+// Queue returns the remote queue, highest score first.
+func (c *Client) Queue() ([]*mrqueue.MR, error) {
+	var mrs []*mrqueue.MR
+	return mrs, c.do("GET", "/api/queue", nil, &mrs)
+}
+
+// Enqueue submits a branch to the remote queue.
+func (c *Client) Enqueue(req EnqueueRequest) (*mrqueue.MR, error) {
+	var mr mrqueue.MR
+	return &mr, c.do("POST", "/api/queue", req, &mr)
+}
+
+// Hold puts a remote MR on hold.
+func (c *Client) Hold(id, reason string) (*mrqueue.MR, error) {
+	var mr mrqueue.MR
+	return &mr, c.do("POST", "/api/queue/"+url.PathEscape(id)+"/hold", HoldRequest{Reason: reason}, &mr)
+}
+
+// Requeue clears hold, claim, and block on a remote MR.
+func (c *Client) Requeue(id string) (*mrqueue.MR, error) {
+	var mr mrqueue.MR
+	return &mr, c.do("POST", "/api/queue/"+url.PathEscape(id)+"/requeue", nil, &mr)
+}
+
+// Approve releases a remote MR waiting for approval.
+func (c *Client) Approve(id, by string) (*mrqueue.MR, error) {
+	var mr mrqueue.MR
+	return &mr, c.do("POST", "/api/queue/"+url.PathEscape(id)+"/approve", ApproveRequest{By: by}, &mr)
+}
+
+// Pause pauses the remote refinery.
+func (c *Client) Pause() (*Refinery, error) {
+	var ref Refinery
+	return &ref, c.do("POST", "/api/pause", nil, &ref)
+}
+
+// PauseUntil pauses the remote refinery until the given time, when it
+// resumes on its own.
+func (c *Client) PauseUntil(until time.Time) (*Refinery, error) {
+	var ref Refinery
+	return &ref, c.do("POST", "/api/pause", PauseRequest{Until: until}, &ref)
+}
+
+// Resume resumes the remote refinery.
+func (c *Client) Resume() (*Refinery, error) {
+	var ref Refinery
+	return &ref, c.do("POST", "/api/resume", nil, &ref)
+}
+
+// History returns up to limit recent events from the remote event log.
+func (c *Client) History(limit int) ([]mrqueue.Event, error) {
+	var events []mrqueue.Event
+	return events, c.do("GET", "/api/history?limit="+strconv.Itoa(limit), nil, &events)
+}
+
+// Access returns what the client's token may do on the remote refinery.
+func (c *Client) Access() (*Access, error) {
+	var access Access
+	return &access, c.do("GET", "/api/access", nil, &access)
+}
+
+// WatchMerges calls fn with each merge queue event the remote refinery
+// logs from now on, until ctx is done or the stream ends.
+func (c *Client) WatchMerges(ctx context.Context, fn func(mrqueue.Event)) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/merges/watch", nil)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	// The stream is long-lived; only the request's context ends it
+	resp, err := (&http.Client{Transport: c.http.Transport}).Do(req)
+	if err != nil {
+		return fmt.Errorf("contacting refinery at %s: %w", c.baseURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		var apiErr apiError
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("remote refinery: %s (%s)", apiErr.Error, resp.Status)
+		}
+		return fmt.Errorf("remote refinery: %s", resp.Status)
+	}
+
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var ev mrqueue.Event
+		if err := dec.Decode(&ev); err != nil {
+			if err == io.EOF || ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("reading event stream: %w", err)
+		}
+		fn(ev)
+	}
+}
+
+// Stats returns merge totals, daily counts, and per-worker quality for the
+// last days days.
+func (c *Client) Stats(days int) (*Stats, error) {
+	var stats Stats
+	return &stats, c.do("GET", "/api/stats?days="+strconv.Itoa(days), nil, &stats)
+}
+
+// do performs a JSON request and decodes the response into out.
+// API errors are returned with the server's message.
+func (c *Client) do(method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("contacting refinery at %s: %w", c.baseURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		var apiErr apiError
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("remote refinery: %s (%s)", apiErr.Error, resp.Status)
+		}
+		return fmt.Errorf("remote refinery: %s", resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response from %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package refinery
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+func TestClient_RoundTrip(t *testing.T) {
+	srv := newTestServer(t)
+	secret := newToken(t, srv, "laptop", ScopeOperate)
+	if err := srv.mgr.saveState(&Refinery{RigName: "testrig", State: StateRunning}); err != nil {
+		t.Fatalf("saveState: %v", err)
+	}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	c, err := NewClient(ts.URL, secret)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	mr, err := c.Enqueue(EnqueueRequest{Branch: "polecat/nux/gt-1", Worker: "nux"})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if _, err := c.Hold(mr.ID, "demo"); err != nil {
+		t.Fatalf("Hold: %v", err)
+	}
+
+	queue, err := c.Queue()
+	if err != nil {
+		t.Fatalf("Queue: %v", err)
+	}
+	if len(queue) != 1 || queue[0].HeldReason != "demo" {
+		t.Errorf("queue = %+v, want one held MR", queue)
+	}
+
+	ref, err := c.Pause()
+	if err != nil || ref.State != StatePaused {
+		t.Errorf("Pause = %+v, %v", ref, err)
+	}
+	if _, err := c.Pause(); err == nil || !strings.Contains(err.Error(), "not running") {
+		t.Errorf("second Pause error = %v, want server message", err)
+	}
+}
+
+func TestClient_Unauthorized(t *testing.T) {
+	srv := newTestServer(t)
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	c, _ := NewClient(ts.URL, "")
+	if _, err := c.Enqueue(EnqueueRequest{Branch: "b"}); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Enqueue without token = %v, want 401", err)
+	}
+}
+
+func TestClient_Observer(t *testing.T) {
+	orig := WatchInterval
+	WatchInterval = 10 * time.Millisecond
+	defer func() { WatchInterval = orig }()
+
+	srv := newTestServer(t)
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	// While reads are open, anyone can look but not touch
+	anon, _ := NewClient(ts.URL, "")
+	if access, err := anon.Access(); err != nil || !access.ReadOnly || access.Token != "" {
+		t.Errorf("anonymous Access = %+v, %v; want read-only", access, err)
+	}
+
+	secret := newToken(t, srv, "stakeholder", ScopeRead)
+	c, _ := NewClient(ts.URL, secret)
+
+	access, err := c.Access()
+	if err != nil || !access.ReadOnly || access.Token != "stakeholder" || access.Scope != ScopeRead {
+		t.Errorf("Access = %+v, %v; want read-only", access, err)
+	}
+	if _, err := c.Pause(); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Pause with a read token = %v, want 403", err)
+	}
+
+	// Live events reach the observer as they are logged
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	got := make(chan mrqueue.Event, 1)
+	go func() {
+		_ = c.WatchMerges(ctx, func(ev mrqueue.Event) {
+			select {
+			case got <- ev:
+			default:
+			}
+		})
+	}()
+	deadline := time.After(5 * time.Second)
+	for {
+		// The stream starts from when it opens; log until one arrives
+		if err := srv.events.LogMerged(&mrqueue.MR{ID: "mr-1", Branch: "polecat/nux/gt-1"}, "abc123"); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case ev := <-got:
+			if ev.Type != mrqueue.EventMerged || ev.MRID != "mr-1" {
+				t.Errorf("watched %+v", ev)
+			}
+			return
+		case <-time.After(50 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("no event streamed")
+		}
+	}
+}
+
+func TestNewClient_URL(t *testing.T) {
+	c, err := NewClient("rig-host:8080/", "")
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	if c.baseURL != "http://rig-host:8080" {
+		t.Errorf("baseURL = %q", c.baseURL)
+	}
+	if _, err := NewClient("://", ""); err == nil {
+		t.Error("expected error for invalid URL")
+	}
+}
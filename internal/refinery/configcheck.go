@@ -0,0 +1,175 @@
+package refinery
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// shellBuiltins are command words sh runs itself, so there is nothing on
+// disk to find for them.
+var shellBuiltins = map[string]bool{
+	":": true, ".": true, "[": true, "cd": true, "command": true, "echo": true,
+	"eval": true, "exec": true, "exit": true, "export": true, "false": true,
+	"printf": true, "set": true, "test": true, "true": true, "umask": true,
+}
+
+// CheckConfig checks the rig's settings against the machine the refinery
+// runs on, beyond what loading rig.toml validates: that the commands it
+// runs can be found, that the sandbox runtime is installed, that secrets
+// in [refinery.env] resolve, and that origin answers with the target
+// branch, and the branches backport rules name, on it. Problems with a rig.toml key are *config.KeyError, with
+// the key's line.
+func (e *Engineer) CheckConfig() []error {
+	var problems []error
+	file := config.RigFilePath(e.rig.Path)
+	data, _ := os.ReadFile(file) //nolint:gosec // G304: path is constructed internally
+	keyErr := func(key, format string, args ...interface{}) {
+		problems = append(problems, &config.KeyError{
+			File: file, Line: config.KeyLine(data, key), Key: key, Msg: fmt.Sprintf(format, args...),
+		})
+	}
+
+	if s := e.settings; s != nil {
+		container := s.Sandbox != nil && s.Sandbox.IsContainer()
+		checks := func(key string, checks []config.CheckConfig) {
+			for i, c := range checks {
+				// In a container, only the repository is the same as here.
+				if err := e.findCommand(c.Command, s.CheckDir(c), container); err != nil {
+					keyErr(fmt.Sprintf("%s[%d].command", key, i), "%v", err)
+				}
+			}
+		}
+		checks("refinery.checks", s.Checks)
+		tiers := make([]string, 0, len(s.Tiers))
+		for tier := range s.Tiers {
+			tiers = append(tiers, tier)
+		}
+		sort.Strings(tiers)
+		for _, tier := range tiers {
+			if policy := s.Tiers[tier]; policy != nil {
+				checks("refinery.tiers."+tier+".checks", policy.Checks)
+			}
+		}
+		if s.Integration != nil {
+			checks("refinery.integration.checks", s.Integration.Checks)
+		}
+		for i, r := range s.Routes {
+			checks(fmt.Sprintf("refinery.routes[%d].checks", i), r.Checks)
+		}
+
+		// Agent commands run unsandboxed from the work directory.
+		agent := func(key, command string) {
+			if command == "" {
+				return
+			}
+			if err := e.findCommand(command, "", false); err != nil {
+				keyErr(key, "%v", err)
+			}
+		}
+		if s.Notifications != nil {
+			agent("refinery.notifications.digest_command", s.Notifications.DigestCommand)
+		}
+		if s.Resolver != nil {
+			agent("refinery.resolver.command", s.Resolver.Command)
+		}
+		if s.Summary != nil {
+			agent("refinery.summary.command", s.Summary.Command)
+		}
+		if s.Review != nil {
+			agent("refinery.review.command", s.Review.Command)
+		}
+		if s.Triage != nil {
+			agent("refinery.triage.command", s.Triage.Command)
+		}
+
+		if s.Sandbox != nil {
+			if _, err := exec.LookPath(s.Sandbox.Runtime); err != nil {
+				keyErr("refinery.sandbox.runtime", "%s is not installed", s.Sandbox.Runtime)
+			}
+		}
+		names := make([]string, 0, len(s.Env))
+		for name := range s.Env {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			one := &config.RefinerySettings{Env: map[string]string{name: s.Env[name]}}
+			if _, err := one.ResolveEnv(e.rig.Path); err != nil {
+				keyErr("refinery.env."+name, "%v", errors.Unwrap(err))
+			}
+		}
+		if s.GitHubQueue != nil {
+			if _, err := s.GitHubQueue.ResolveToken(e.rig.Path); err != nil {
+				keyErr("refinery.github_queue.token", "%v", errors.Unwrap(err))
+			}
+		}
+	}
+
+	target := e.config.TargetBranch
+	if ok, err := e.git.RemoteBranchExists("origin", target); err != nil {
+		problems = append(problems, fmt.Errorf("origin: %v", err))
+	} else if !ok {
+		problems = append(problems, fmt.Errorf("origin: no branch %s to merge into", target))
+	} else if s := e.settings; s != nil && s.Backport != nil {
+		for i, rule := range s.Backport.Rules {
+			for j, branch := range rule.Branches {
+				if ok, err := e.git.RemoteBranchExists("origin", branch); err == nil && !ok {
+					keyErr(fmt.Sprintf("refinery.backport.rules[%d].branches[%d]", i, j), "origin has no branch %s", branch)
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// findCommand checks that the program a shell command line starts with
+// exists: relative to dir under the work directory if it is a path, else
+// on PATH unless onlyPaths. Lines it cannot take apart without a shell
+// pass.
+func (e *Engineer) findCommand(command, dir string, onlyPaths bool) error {
+	program := commandProgram(command)
+	switch {
+	case program == "" || shellBuiltins[program]:
+		return nil
+	case strings.Contains(program, "/"):
+		path := program
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(e.workDir, dir, path)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("%s not found", program)
+		}
+		if info.IsDir() || info.Mode()&0111 == 0 {
+			return fmt.Errorf("%s is not executable", program)
+		}
+	case !onlyPaths:
+		if _, err := exec.LookPath(program); err != nil {
+			return fmt.Errorf("%s not found on PATH", program)
+		}
+	}
+	return nil
+}
+
+// commandProgram returns the program a shell command line runs first,
+// past any VAR=value assignments, or "" if quoting, expansion, or
+// grouping make that a question for the shell.
+func commandProgram(command string) string {
+	for _, word := range strings.Fields(command) {
+		if strings.ContainsAny(word, "\"'`$(){}\\") {
+			return ""
+		}
+		if name, _, ok := strings.Cut(word, "="); ok && name != "" && !strings.Contains(name, "/") {
+			continue
+		}
+		return word
+	}
+	return ""
+}
@@ -0,0 +1,96 @@
+package refinery
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+func TestCheckConfig(t *testing.T) {
+	e, _ := newFakeEngineer(t)
+	e.config.TargetBranch = "main"
+	rigFile := `[refinery]
+
+[[refinery.checks]]
+name = "test"
+command = "GOFLAGS=-count=1 sh -c 'exit 0'"
+
+[[refinery.checks]]
+name = "lint"
+command = "./scripts/lint.sh --strict"
+
+[[refinery.checks]]
+name = "e2e"
+command = "gt-no-such-tool run"
+
+[refinery.review]
+command = "./scripts/review.sh"
+
+[refinery.env]
+TOKEN = "${env:GT_CHECK_CONFIG_UNSET}"
+`
+	path := config.RigFilePath(e.rig.Path)
+	for _, dir := range []string{filepath.Dir(path), filepath.Join(e.workDir, "scripts")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(e.workDir, "scripts", "review.sh"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(rigFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.loadRigSettings(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]int{ // Key to line
+		"refinery.checks[1].command": 9,
+		"refinery.checks[2].command": 13,
+		"refinery.env.TOKEN":         19,
+	}
+	for _, p := range e.CheckConfig() {
+		var ke *config.KeyError
+		if !errors.As(p, &ke) {
+			t.Errorf("unexpected problem: %v", p)
+			continue
+		}
+		line, ok := want[ke.Key]
+		if !ok || ke.Line != line {
+			t.Errorf("problem %v, want one of %v", p, want)
+		}
+		delete(want, ke.Key)
+	}
+	if len(want) > 0 {
+		t.Errorf("missing problems at %v", want)
+	}
+
+	e.config.TargetBranch = "release"
+	problems := e.CheckConfig()
+	if len(problems) == 0 || !strings.Contains(problems[len(problems)-1].Error(), "no branch release") {
+		t.Errorf("problems = %v, want origin lacking release", problems)
+	}
+}
+
+func TestCommandProgram(t *testing.T) {
+	tests := map[string]string{
+		"go test ./...":                  "go",
+		"GOFLAGS=-mod=mod go vet ./...":  "go",
+		"./scripts/x.sh --flag":          "./scripts/x.sh",
+		"\"$HOME/bin/tool\"":             "",
+		"(cd web && npm test)":           "",
+		"":                               "",
+		"bin/check --opt=value":          "bin/check",
+		"PATH=/opt/bin:$PATH make check": "",
+	}
+	for command, want := range tests {
+		if got := commandProgram(command); got != want {
+			t.Errorf("commandProgram(%q) = %q, want %q", command, got, want)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package refinery
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// Swarms merge into their own integration branches, so two swarms touching
+// the same files only find out when the second one lands. A conflict scan
+// test-merges queued branches from different swarms against each other
+// ahead of time, so an operator can reorder or intervene while both are
+// still in flight.
+
+// SwarmConflict is a pair of queued MRs from different swarms whose
+// branches conflict with each other.
+type SwarmConflict struct {
+	MRs      [2]string `json:"mrs"`
+	Branches [2]string `json:"branches"`
+	Swarms   [2]string `json:"swarms"`
+	Files    []string  `json:"files"`
+}
+
+// ConflictScan is the result of the last cross-swarm conflict scan, kept
+// in .runtime/swarm_conflicts.json.
+type ConflictScan struct {
+	ScannedAt time.Time       `json:"scanned_at"`
+	Pairs     int             `json:"pairs"` // Cross-swarm pairs compared
+	Conflicts []SwarmConflict `json:"conflicts,omitempty"`
+
+	// Overlaps are the pairs of queued MRs, from any swarm or none, that
+	// change the same Go declarations; only with semantic_conflicts set.
+	Overlaps []SemanticOverlap `json:"overlaps,omitempty"`
+
+	// Tested maps "<sha>..<sha>" to the files a pair of branch heads
+	// conflicts in (empty if they merge cleanly), so a rescan only
+	// test-merges branches that have moved.
+	Tested map[string][]string `json:"tested,omitempty"`
+
+	// Symbols maps "<merge base>..<sha>" to the declarations a branch head
+	// changes, so a rescan only parses branches that have moved.
+	Symbols map[string][]string `json:"symbols,omitempty"`
+}
+
+// queuedHead is a queued MR with its branch head at scan time.
+type queuedHead struct {
+	mr    *mrqueue.MR
+	swarm string
+	sha   string
+}
+
+// ConflictsWith returns the conflicts involving branch.
+func (s *ConflictScan) ConflictsWith(branch string) []SwarmConflict {
+	if s == nil {
+		return nil
+	}
+	var out []SwarmConflict
+	for _, c := range s.Conflicts {
+		if c.Branches[0] == branch || c.Branches[1] == branch {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// ConflictScanPath returns where a rig's last conflict scan is kept.
+func ConflictScanPath(rigPath string) string {
+	return filepath.Join(rigPath, ".runtime", "swarm_conflicts.json")
+}
+
+// LoadConflictScan reads a rig's last conflict scan. It returns nil if the
+// rig has never been scanned.
+func LoadConflictScan(rigPath string) (*ConflictScan, error) {
+	data, err := os.ReadFile(ConflictScanPath(rigPath))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var scan ConflictScan
+	if err := json.Unmarshal(data, &scan); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", ConflictScanPath(rigPath), err)
+	}
+	return &scan, nil
+}
+
+// ScanSwarmConflicts test-merges every pair of queued branches that belong
+// to different swarms and records the pairs that conflict. With
+// semantic_conflicts set it also records every pair of queued branches
+// that change the same Go declarations. Unless force is set, a scan newer
+// than [refinery.integration] conflict_scan_interval is returned as is.
+func (e *Engineer) ScanSwarmConflicts(force bool) (*ConflictScan, error) {
+	prev, err := LoadConflictScan(e.rig.Path)
+	if err != nil {
+		return nil, err
+	}
+	var ic *config.IntegrationConfig
+	if e.settings != nil {
+		ic = e.settings.Integration
+	}
+	// A scan dated in the future is from before the clock was set back;
+	// it does not say how long ago it ran, so scan again.
+	if prev != nil && !force {
+		if age := e.clock().Sub(prev.ScannedAt); age >= 0 && age < ic.ScanInterval() {
+			return prev, nil
+		}
+	}
+
+	queued, err := e.mrQueue.List()
+	if err != nil {
+		return nil, err
+	}
+	semantic := e.semanticConflicts() != ""
+	var heads []queuedHead
+	for _, mr := range queued {
+		swarm := mr.Swarm()
+		if swarm == "" && !semantic {
+			continue
+		}
+		sha, err := e.git.Rev(mr.Branch)
+		if err != nil {
+			continue // Branch gone; the refinery reports it when the MR comes up
+		}
+		heads = append(heads, queuedHead{mr, swarm, sha})
+	}
+
+	scan := &ConflictScan{ScannedAt: e.clock(), Tested: make(map[string][]string)}
+	tm := e.newTestMerger()
+	defer tm.close()
+	for i, a := range heads {
+		for _, b := range heads[i+1:] {
+			if a.swarm == "" || b.swarm == "" || a.swarm == b.swarm || a.sha == b.sha {
+				continue
+			}
+			scan.Pairs++
+			key := a.sha + ".." + b.sha
+			files, ok := prev.tested(key)
+			if !ok {
+				if files, err = tm.conflicts(b.sha, a.sha); err != nil {
+					return nil, fmt.Errorf("test-merging %s into %s: %w", b.mr.Branch, a.mr.Branch, err)
+				}
+			}
+			scan.Tested[key] = files
+			if len(files) > 0 {
+				scan.Conflicts = append(scan.Conflicts, SwarmConflict{
+					MRs:      [2]string{a.mr.ID, b.mr.ID},
+					Branches: [2]string{a.mr.Branch, b.mr.Branch},
+					Swarms:   [2]string{a.swarm, b.swarm},
+					Files:    files,
+				})
+			}
+		}
+	}
+
+	if semantic {
+		e.scanOverlaps(scan, prev, heads)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(ConflictScanPath(e.rig.Path)), 0755); err != nil {
+		return nil, err
+	}
+	if err := util.AtomicWriteJSON(ConflictScanPath(e.rig.Path), scan); err != nil {
+		return nil, fmt.Errorf("saving conflict scan: %w", err)
+	}
+	return scan, nil
+}
+
+// testMerger test-merges commits in the mirror, falling back to a scratch
+// worktree if the mirror cannot be used, for scans that compare many
+// pairs. close removes the worktree, if one was made.
+type testMerger struct {
+	e         *Engineer
+	mirror    *git.Git
+	sg        GitRunner
+	tryMirror bool
+}
+
+func (e *Engineer) newTestMerger() *testMerger {
+	return &testMerger{e: e, tryMirror: true}
+}
+
+// conflicts returns the files merging source into target conflicts in,
+// never nil.
+func (t *testMerger) conflicts(source, target string) ([]string, error) {
+	var files []string
+	var err error
+	if t.tryMirror && t.mirror == nil {
+		t.mirror, _ = t.e.mirror()
+		t.tryMirror = t.mirror != nil
+	}
+	if t.tryMirror {
+		if files, err = t.mirror.MergeTreeConflicts(source, target); err != nil {
+			t.tryMirror = false
+		}
+	}
+	if !t.tryMirror {
+		if t.sg == nil {
+			if t.sg, err = t.e.conflictScanWorktree(); err != nil {
+				return nil, err
+			}
+		}
+		if files, err = t.sg.CheckConflicts(source, target); err != nil {
+			return nil, err
+		}
+	}
+	if files == nil {
+		files = []string{}
+	}
+	return files, nil
+}
+
+func (t *testMerger) close() {
+	if t.sg != nil {
+		_ = t.e.git.WorktreeRemove(t.e.conflictScanDir(), true)
+		_ = t.e.git.WorktreePrune()
+	}
+}
+
+// tested returns the files a pair conflicted in at the last scan, and
+// whether the pair was tested at all.
+func (s *ConflictScan) tested(key string) ([]string, bool) {
+	if s == nil {
+		return nil, false
+	}
+	files, ok := s.Tested[key]
+	return files, ok
+}
+
+// conflictScanDir is the scratch worktree conflict scans test-merge in, so
+// the refinery's own checkout is left alone.
+func (e *Engineer) conflictScanDir() string {
+	return filepath.Join(e.rig.Path, ".runtime", "conflict-scan")
+}
+
+func (e *Engineer) conflictScanWorktree() (GitRunner, error) {
+	dir := e.conflictScanDir()
+	_ = e.git.WorktreeRemove(dir, true)
+	_ = os.RemoveAll(dir)
+	if err := e.git.WorktreeAddDetached(dir, "HEAD"); err != nil {
+		return nil, fmt.Errorf("creating conflict scan worktree: %w", err)
+	}
+	return e.gitAt(dir), nil
+}
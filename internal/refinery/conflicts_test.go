@@ -0,0 +1,88 @@
+package refinery
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/clock"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func TestScanSwarmConflicts(t *testing.T) {
+	rigPath := setupConflictRig(t)
+	// polecat/nux/gt-1 (swarm a) conflicts with main; branch two more off
+	// main for swarm b, which then conflict with nux's branch but are
+	// never compared with each other.
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = rigPath
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	for _, name := range []string{"furiosa", "slit"} {
+		run("checkout", "-b", "polecat/"+name+"/gt-2", "main")
+		if err := os.WriteFile(filepath.Join(rigPath, "file.txt"), []byte(name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		run("commit", "-qam", name)
+	}
+
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: rigPath})
+	e.SetOutput(&strings.Builder{})
+	for _, mr := range []*mrqueue.MR{
+		{Branch: "polecat/nux/gt-1", Target: "integration/a", Worker: "nux"},
+		{Branch: "polecat/furiosa/gt-2", Target: "integration/b", Worker: "furiosa"},
+		{Branch: "polecat/slit/gt-2", Target: "integration/b", Worker: "slit"},
+		{Branch: "main", Target: "release", Worker: "crew"}, // Not in a swarm
+	} {
+		if err := e.mrQueue.Submit(mr); err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+	}
+
+	scan, err := e.ScanSwarmConflicts(true)
+	if err != nil {
+		t.Fatalf("ScanSwarmConflicts: %v", err)
+	}
+	if scan.Pairs != 2 || len(scan.Conflicts) != 2 {
+		t.Fatalf("scan = %+v, want 2 pairs, both conflicting", scan)
+	}
+	for _, c := range scan.Conflicts {
+		if c.Swarms[0] == c.Swarms[1] || len(c.Files) != 1 || c.Files[0] != "file.txt" {
+			t.Errorf("conflict = %+v, want file.txt across swarms", c)
+		}
+	}
+	if got := scan.ConflictsWith("polecat/slit/gt-2"); len(got) != 1 || got[0].Branches[0] != "polecat/nux/gt-1" {
+		t.Errorf("ConflictsWith(slit) = %+v, want nux's branch", got)
+	}
+	if _, err := os.Stat(e.conflictScanDir()); !os.IsNotExist(err) {
+		t.Errorf("scan worktree left behind: %v", err)
+	}
+
+	// Within the interval the saved scan is returned without rescanning.
+	again, err := e.ScanSwarmConflicts(false)
+	if err != nil {
+		t.Fatalf("ScanSwarmConflicts: %v", err)
+	}
+	if !again.ScannedAt.Equal(scan.ScannedAt) || len(again.Conflicts) != 2 {
+		t.Errorf("rescan = %+v, want the saved scan", again)
+	}
+
+	// With the clock set back, the saved scan's age is unknown: rescan.
+	back := clock.NewFake(scan.ScannedAt.Add(-time.Hour))
+	e.SetClock(back)
+	again, err = e.ScanSwarmConflicts(false)
+	if err != nil {
+		t.Fatalf("ScanSwarmConflicts: %v", err)
+	}
+	if !again.ScannedAt.Equal(back.Now()) {
+		t.Errorf("after the clock was set back, ScannedAt = %v, want a rescan at %v", again.ScannedAt, back.Now())
+	}
+}
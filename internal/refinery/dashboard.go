@@ -0,0 +1,21 @@
+package refinery
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// dashboardFS holds the static web UI served at / by the refinery API server.
+//
+//go:embed dashboard/*
+var dashboardFS embed.FS
+
+// dashboardHandler serves the embedded dashboard assets.
+func dashboardHandler() http.Handler {
+	sub, err := fs.Sub(dashboardFS, "dashboard")
+	if err != nil {
+		panic(err) // Embedded path is fixed at compile time
+	}
+	return http.FileServerFS(sub)
+}
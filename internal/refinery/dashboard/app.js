@@ -0,0 +1,239 @@
+// Refinery dashboard: renders api/* and follows events for live updates.
+// Paths are relative so the page works both at / and under /rigs/{name}/.
+(function () {
+    'use strict';
+
+    const CLAIM_STALE_MS = 10 * 60 * 1000; // Matches mrqueue.ClaimStaleTimeout
+    const $ = (id) => document.getElementById(id);
+
+    // Tokens are per rig, so key them by the page's location.
+    const TOKEN_KEY = 'gt-refinery-token:' + window.location.pathname;
+
+    let status = null;
+    let readOnly = false; // Observer: the token cannot operate the refinery
+
+    // Tokens come from `gt refinery token create`; the user is asked once
+    // and the token is kept in localStorage.
+    function token() {
+        return window.localStorage.getItem(TOKEN_KEY) || '';
+    }
+
+    function askForToken(message) {
+        const entered = window.prompt(message + '\nAPI token (gt refinery token create):', '');
+        if (entered) {
+            window.localStorage.setItem(TOKEN_KEY, entered.trim());
+            return true;
+        }
+        return false;
+    }
+
+    async function api(method, path, body, retried) {
+        const headers = body ? { 'Content-Type': 'application/json' } : {};
+        if (token()) headers['Authorization'] = 'Bearer ' + token();
+        const resp = await fetch(path, {
+            method: method,
+            headers: headers,
+            body: body ? JSON.stringify(body) : undefined,
+        });
+        const data = await resp.json();
+        if ((resp.status === 401 || resp.status === 403) && !retried && askForToken(data.error)) {
+            return api(method, path, body, true);
+        }
+        if (!resp.ok) {
+            throw new Error(data.error || resp.statusText);
+        }
+        return data;
+    }
+
+    function showError(err) {
+        const el = $('error');
+        el.textContent = err ? String(err.message || err) : '';
+        el.hidden = !err;
+    }
+
+    function age(ts) {
+        const s = Math.max(0, (Date.now() - new Date(ts).getTime()) / 1000);
+        if (s < 60) return Math.floor(s) + 's';
+        if (s < 3600) return Math.floor(s / 60) + 'm';
+        if (s < 86400) return Math.floor(s / 3600) + 'h';
+        return Math.floor(s / 86400) + 'd';
+    }
+
+    function isClaimed(mr) {
+        return mr.claimed_by && Date.now() - new Date(mr.claimed_at).getTime() < CLAIM_STALE_MS;
+    }
+
+    function cell(text, className) {
+        const td = document.createElement('td');
+        td.textContent = text;
+        if (className) td.className = className;
+        return td;
+    }
+
+    function button(label, onClick) {
+        const b = document.createElement('button');
+        b.type = 'button';
+        b.textContent = label;
+        b.addEventListener('click', () => onClick().then(refresh).catch(showError));
+        return b;
+    }
+
+    function renderStatus(ref) {
+        status = ref;
+        $('rig').textContent = ref.rig_name;
+        const state = $('state');
+        state.textContent = ref.state;
+        state.className = 'state ' + ref.state;
+
+        const toggle = $('toggle-pause');
+        toggle.hidden = readOnly || ref.state === 'stopped';
+        toggle.textContent = ref.state === 'paused' ? 'Resume' : 'Pause';
+    }
+
+    function renderQueue(mrs) {
+        const body = $('queue');
+        body.replaceChildren();
+        $('queue-count').textContent = mrs.length ? '(' + mrs.length + ')' : '';
+        $('queue-empty').hidden = mrs.length > 0;
+
+        const inflight = mrs.find(isClaimed);
+        const box = $('inflight');
+        if (inflight) {
+            box.className = '';
+            box.textContent = inflight.id + '  ' + inflight.branch + ' → ' + inflight.target +
+                '  (' + inflight.claimed_by + ', ' + age(inflight.claimed_at) + ')';
+        } else {
+            box.className = 'empty';
+            box.textContent = 'Nothing merging';
+        }
+
+        for (const mr of mrs) {
+            const tr = document.createElement('tr');
+            tr.append(cell(mr.id), cell(mr.branch), cell(mr.target), cell(mr.worker || ''), cell(age(mr.created_at)));
+
+            let tag = cell('ready', 'tag');
+            if (mr.held_reason) tag = cell('held: ' + mr.held_reason, 'tag held');
+            else if (isClaimed(mr)) tag = cell('merging', 'tag claimed');
+            else if (mr.blocked_by) tag = cell('blocked: ' + mr.blocked_by, 'tag blocked');
+            tr.append(tag);
+
+            const actions = document.createElement('td');
+            actions.className = 'actions';
+            if (!readOnly && !mr.held_reason) {
+                actions.append(button('Hold', () => {
+                    const reason = window.prompt('Hold reason', 'held from dashboard');
+                    return reason === null ? Promise.resolve() :
+                        api('POST', 'api/queue/' + encodeURIComponent(mr.id) + '/hold', { reason: reason });
+                }));
+            }
+            if (!readOnly) {
+                actions.append(' ', button('Requeue', () =>
+                    api('POST', 'api/queue/' + encodeURIComponent(mr.id) + '/requeue')));
+            }
+            tr.append(actions);
+            body.append(tr);
+        }
+    }
+
+    function renderStats(stats) {
+        const chart = $('chart');
+        chart.replaceChildren();
+        const max = Math.max(1, ...stats.daily.map((d) => d.merged + d.failed));
+        for (const d of stats.daily) {
+            const day = document.createElement('div');
+            day.className = 'day';
+            day.title = d.date + ': ' + d.merged + ' merged, ' + d.failed + ' failed';
+            for (const kind of ['failed', 'merged']) {
+                const bar = document.createElement('div');
+                bar.className = 'bar ' + kind;
+                bar.style.height = (d[kind] / max * 100) + '%';
+                day.append(bar);
+            }
+            const label = document.createElement('div');
+            label.className = 'label';
+            label.textContent = d.date.slice(5);
+            day.append(label);
+            chart.append(day);
+        }
+        $('totals').textContent = stats.merged + ' merged · ' + stats.failed + ' failed · ' +
+            Math.round(stats.success_rate * 100) + '% success';
+    }
+
+    function log(type, ev) {
+        const line = document.createElement('div');
+        line.className = type;
+        const when = new Date(ev.timestamp || Date.now()).toLocaleTimeString();
+        let text = when + '  ' + type + '  ' + (ev.mr_id || '') + '  ' + (ev.branch || '');
+        if (ev.merge_commit) text += '  ' + ev.merge_commit.slice(0, 8);
+        if (ev.reason) text += '  — ' + ev.reason;
+        line.textContent = text;
+        const el = $('log');
+        el.append(line);
+        el.scrollTop = el.scrollHeight;
+    }
+
+    async function refresh() {
+        try {
+            const [ref, queue, stats] = await Promise.all([
+                api('GET', 'api/status'),
+                api('GET', 'api/queue'),
+                api('GET', 'api/stats'),
+            ]);
+            renderStatus(ref);
+            renderQueue(queue);
+            renderStats(stats);
+            showError(null);
+        } catch (err) {
+            showError(err);
+        }
+    }
+
+    $('toggle-pause').addEventListener('click', () => {
+        const path = status && status.state === 'paused' ? 'api/resume' : 'api/pause';
+        api('POST', path).then(refresh).catch(showError);
+    });
+
+    async function loadHistory() {
+        try {
+            const events = await api('GET', 'api/history?limit=50');
+            for (const ev of events) log(ev.type, ev);
+        } catch (err) {
+            showError(err);
+        }
+    }
+
+    const MERGE_EVENTS = ['merge_started', 'merged', 'merge_failed', 'merge_skipped', 'held', 'requeued'];
+
+    function follow() {
+        const source = new EventSource(token() ? 'events?token=' + encodeURIComponent(token()) : 'events');
+        source.addEventListener('status', (e) => renderStatus(JSON.parse(e.data)));
+        source.addEventListener('queue', () => refresh());
+        for (const type of MERGE_EVENTS) {
+            source.addEventListener(type, (e) => {
+                log(type, JSON.parse(e.data));
+                refresh();
+            });
+        }
+    }
+
+    // Observers holding a read token get the dashboard without its
+    // controls. Anonymous visitors keep them, so a control can still ask
+    // for an operate token.
+    async function loadAccess() {
+        try {
+            const access = await api('GET', 'api/access');
+            readOnly = Boolean(access.token) && access.read_only;
+            $('observer').hidden = !readOnly;
+        } catch (err) {
+            showError(err);
+        }
+    }
+
+    // Probe once first so a missing token is requested a single time,
+    // before the parallel loads and the event stream start.
+    api('GET', 'api/status').catch(showError).then(loadAccess).then(() => {
+        refresh();
+        loadHistory();
+        follow();
+    });
+})();
@@ -0,0 +1,167 @@
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// DeployRequest is what a refinery.deploy webhook is POSTed.
+type DeployRequest struct {
+	Deploy      string `json:"deploy"`
+	Rig         string `json:"rig"`
+	MRID        string `json:"mr_id"`
+	Branch      string `json:"branch"`
+	Target      string `json:"target"`
+	SourceIssue string `json:"source_issue,omitempty"`
+	MergeCommit string `json:"merge_commit"`
+}
+
+// deploy triggers each refinery.deploy rule for mr's target with the merge
+// it just landed as mergeCommit, and records each outcome in the event log
+// beside the merge. Best-effort: the merge stands either way, so a failure
+// is logged and reported to on_failure recipients.
+func (e *Engineer) deploy(ctx context.Context, mr *mrqueue.MR, mergeCommit string) {
+	if e.settings == nil {
+		return
+	}
+	for i := range e.settings.Deploy {
+		d := &e.settings.Deploy[i]
+		if !d.Matches(mr.Target) {
+			continue
+		}
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Deploying %s to %s (%s)...\n", shortSHA(mergeCommit), d.Name, d.Kind())
+		outcome, err := e.triggerDeploy(ctx, d, mr, mergeCommit)
+		if lerr := e.eventLogger.LogDeploy(mr, mergeCommit, d.Name, outcome, err); lerr != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to log deploy event: %v\n", lerr)
+		}
+		if err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: deploy %s failed: %v\n", d.Name, err)
+			if e.settings.Notifications != nil {
+				e.notify(e.settings.Notifications.OnFailure,
+					fmt.Sprintf("Deploy %s of %s failed", d.Name, mr.Target),
+					fmt.Sprintf("MR %s (%s) merged into %s as %s, but deploy %s failed:\n\n%v",
+						mr.ID, mr.Branch, mr.Target, mergeCommit, d.Name, err))
+			}
+			continue
+		}
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Deploy %s: %s\n", d.Name, outcome)
+	}
+}
+
+// triggerDeploy runs one rule and returns what it reported.
+func (e *Engineer) triggerDeploy(ctx context.Context, d *config.DeployConfig, mr *mrqueue.MR, mergeCommit string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.TimeoutDuration())
+	defer cancel()
+
+	switch d.Kind() {
+	case config.DeployWebhook:
+		return e.deployWebhook(ctx, d, mr, mergeCommit)
+	case config.DeployArgo:
+		return e.deployKubectl(ctx, d, "applications.argoproj.io/"+d.Argo, "argocd.argoproj.io/refresh=normal")
+	case config.DeployFlux:
+		return e.deployKubectl(ctx, d, "kustomizations.kustomize.toolkit.fluxcd.io/"+d.Flux,
+			"reconcile.fluxcd.io/requestedAt="+e.clock().UTC().Format(time.RFC3339Nano))
+	default:
+		env, err := e.processEnv()
+		if err != nil {
+			return "", err
+		}
+		env = append(env,
+			"GT_DEPLOY="+d.Name,
+			"GT_RIG="+e.rig.Name,
+			"GT_MR_ID="+mr.ID,
+			"GT_MR_BRANCH="+mr.Branch,
+			"GT_MR_TARGET="+mr.Target,
+			"GT_MR_ISSUE="+mr.SourceIssue,
+			"GT_MERGE_COMMIT="+mergeCommit,
+		)
+		out, err := e.runAgent(ctx, "deploy "+d.Name, d.Command, d.TimeoutDuration(), env)
+		if err != nil {
+			return "", err
+		}
+		return deployOutcome(out, "triggered"), nil
+	}
+}
+
+// deployWebhook POSTs the merge to the rule's URL.
+func (e *Engineer) deployWebhook(ctx context.Context, d *config.DeployConfig, mr *mrqueue.MR, mergeCommit string) (string, error) {
+	token, err := d.ResolveToken(e.rig.Path)
+	if err != nil {
+		return "", err
+	}
+	body, err := json.Marshal(DeployRequest{
+		Deploy:      d.Name,
+		Rig:         e.rig.Name,
+		MRID:        mr.ID,
+		Branch:      mr.Branch,
+		Target:      mr.Target,
+		SourceIssue: mr.SourceIssue,
+		MergeCommit: mergeCommit,
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("%s: %s", d.URL, resp.Status)
+	}
+	return resp.Status, nil
+}
+
+// deployKubectl annotates resource so Argo CD or Flux syncs it now.
+func (e *Engineer) deployKubectl(ctx context.Context, d *config.DeployConfig, resource, annotation string) (string, error) {
+	args := []string{"annotate", "--overwrite", resource, annotation}
+	if d.Namespace != "" {
+		args = append([]string{"--namespace", d.Namespace}, args...)
+	}
+	env, err := e.processEnv()
+	if err != nil {
+		return "", err
+	}
+	cmd := exec.CommandContext(ctx, "kubectl", args...) //nolint:gosec // G204: args come from trusted rig config
+	cmd.Dir = e.workDir
+	cmd.Env = env
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	started := time.Now()
+	err = cmd.Run()
+	util.TraceCommand(e.workDir, "kubectl", args, started, err)
+	if err != nil {
+		return "", fmt.Errorf("kubectl annotate %s: %v\n%s", resource, err, tailLines(output.String(), feedbackOutputLines))
+	}
+	return deployOutcome(output.String(), "sync requested"), nil
+}
+
+// deployOutcome is the last line a trigger printed, or fallback.
+func deployOutcome(out, fallback string) string {
+	out = strings.TrimSpace(out)
+	if out == "" {
+		return fallback
+	}
+	lines := strings.Split(out, "\n")
+	return util.Redact(strings.TrimSpace(lines[len(lines)-1]))
+}
@@ -0,0 +1,66 @@
+package refinery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+func TestDeploy(t *testing.T) {
+	e, _ := newFakeEngineer(t)
+
+	// A stand-in kubectl that records how it was called
+	bin := t.TempDir()
+	argsFile := filepath.Join(bin, "args")
+	kubectl := "#!/bin/sh\necho \"$@\" > " + argsFile + "\necho annotated\n"
+	if err := os.WriteFile(filepath.Join(bin, "kubectl"), []byte(kubectl), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	e.settings.Deploy = []config.DeployConfig{
+		{Name: "staging", Branches: []string{"main"}, Command: `echo "deployed $GT_MERGE_COMMIT"`},
+		{Name: "gitops", Branches: []string{"main"}, Flux: "api", Namespace: "flux-system"},
+		{Name: "cd", Branches: []string{"main"}, URL: ts.URL},
+		{Name: "prod", Branches: []string{"release/*"}, Command: "false"},
+	}
+	mr := &mrqueue.MR{ID: "mr-1", Branch: "polecat/nux/gt-1", Target: "main"}
+	e.deploy(context.Background(), mr, "abc123")
+
+	events, err := e.eventLogger.ReadEvents(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]mrqueue.Event)
+	for _, ev := range events {
+		got[ev.Deploy] = ev
+	}
+	if ev := got["staging"]; ev.Type != mrqueue.EventDeployed || ev.Reason != "deployed abc123" || ev.MRID != "mr-1" {
+		t.Errorf("staging deploy = %+v", ev)
+	}
+	if ev := got["gitops"]; ev.Type != mrqueue.EventDeployed || ev.Reason != "annotated" {
+		t.Errorf("gitops deploy = %+v", ev)
+	}
+	args, _ := os.ReadFile(argsFile)
+	if !strings.HasPrefix(string(args), "--namespace flux-system annotate --overwrite kustomizations.kustomize.toolkit.fluxcd.io/api reconcile.fluxcd.io/requestedAt=") {
+		t.Errorf("kubectl called with %q", args)
+	}
+	if ev := got["cd"]; ev.Type != mrqueue.EventDeployFailed || !strings.Contains(ev.Reason, "503") {
+		t.Errorf("failing webhook deploy = %+v", ev)
+	}
+	if _, ok := got["prod"]; ok {
+		t.Error("deployed a merge into main with a release/* rule")
+	}
+}
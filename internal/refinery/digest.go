@@ -0,0 +1,291 @@
+package refinery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// DigestItem is one MR in a digest, with how many times the day's events
+// recorded it.
+type DigestItem struct {
+	MRID        string `json:"mr_id"`
+	Branch      string `json:"branch"`
+	Target      string `json:"target"`
+	Worker      string `json:"worker,omitempty"`
+	SourceIssue string `json:"source_issue,omitempty"`
+	Count       int    `json:"count"`
+	Detail      string `json:"detail,omitempty"` // The latest failure, or the merge commit
+}
+
+// Digest is a day of merge queue activity on a rig.
+type Digest struct {
+	Rig  string `json:"rig"`
+	Date string `json:"date"` // YYYY-MM-DD, local time
+
+	// Stats covers the day: totals and per-worker quality.
+	Stats Stats `json:"stats"`
+
+	Merged []DigestItem `json:"merged"`
+	Failed []DigestItem `json:"failed"` // MRs that failed for reasons other than conflicts
+
+	// Conflicts are MRs that hit merge conflicts, and Resolved those whose
+	// conflicts the resolver agent fixed.
+	Conflicts []DigestItem `json:"conflicts"`
+	Resolved  []DigestItem `json:"resolved"`
+
+	// Tag is set when the digest is a milestone's release notes, covering
+	// the merges since the tag Since, or since the rule began if empty.
+	Tag   string `json:"tag,omitempty"`
+	Since string `json:"since,omitempty"`
+}
+
+// BuildDigest collects the events on day (local time) into a digest.
+func BuildDigest(rigName string, events []mrqueue.Event, day time.Time) *Digest {
+	date := day.Local().Format("2006-01-02")
+	var today []mrqueue.Event
+	for _, ev := range events {
+		if ev.Timestamp.Local().Format("2006-01-02") == date {
+			today = append(today, ev)
+		}
+	}
+	return buildDigest(rigName, today, day)
+}
+
+// BuildReleaseNotes collects events, those between a milestone's tags,
+// into the release notes for tag, made at.
+func BuildReleaseNotes(rigName, tag, since string, events []mrqueue.Event, at time.Time) *Digest {
+	d := buildDigest(rigName, events, at)
+	d.Tag, d.Since = tag, since
+	return d
+}
+
+func buildDigest(rigName string, events []mrqueue.Event, day time.Time) *Digest {
+	d := &Digest{Rig: rigName, Date: day.Local().Format("2006-01-02"), Stats: ComputeStats(events, day, 1)}
+	d.Stats.Swarms = nil // Swarms span days; stats show them in full
+
+	merged := newDigestList()
+	failed := newDigestList()
+	conflicts := newDigestList()
+	resolved := newDigestList()
+	for _, ev := range events {
+		switch ev.Type {
+		case mrqueue.EventMerged:
+			merged.add(ev, shortSHA(ev.MergeCommit))
+		case mrqueue.EventMergeFailed:
+			if ev.FailureType == "conflict" {
+				conflicts.add(ev, firstLine(ev.Reason))
+				continue
+			}
+			kind := ev.FailureType
+			if ev.FailedCheck != "" {
+				kind += " (check " + ev.FailedCheck + ")"
+			}
+			failed.add(ev, strings.TrimSpace(kind+": "+firstLine(ev.Reason)))
+		case mrqueue.EventConflictResolved:
+			resolved.add(ev, firstLine(ev.Reason))
+		}
+	}
+	d.Merged, d.Failed, d.Conflicts, d.Resolved = merged.items, failed.items, conflicts.items, resolved.items
+	return d
+}
+
+// digestList collects DigestItems by MR, in order of first appearance.
+type digestList struct {
+	items []DigestItem
+	index map[string]int
+}
+
+func newDigestList() *digestList {
+	return &digestList{items: []DigestItem{}, index: make(map[string]int)}
+}
+
+func (l *digestList) add(ev mrqueue.Event, detail string) {
+	key := ev.MRID
+	if key == "" {
+		key = ev.Branch
+	}
+	if i, ok := l.index[key]; ok {
+		l.items[i].Count++
+		l.items[i].Detail = detail
+		return
+	}
+	l.index[key] = len(l.items)
+	l.items = append(l.items, DigestItem{
+		MRID:        ev.MRID,
+		Branch:      ev.Branch,
+		Target:      ev.Target,
+		Worker:      ev.Worker,
+		SourceIssue: ev.SourceIssue,
+		Count:       1,
+		Detail:      detail,
+	})
+}
+
+// Subject is the digest's one-line mail subject.
+func (d *Digest) Subject() string {
+	if d.Tag != "" {
+		return fmt.Sprintf("Release notes: %s %s (%d merged)", d.Rig, d.Tag, d.Stats.Merged)
+	}
+	return fmt.Sprintf("Refinery digest: %s %s (%d merged, %d failed)", d.Rig, d.Date, d.Stats.Merged, d.Stats.Failed)
+}
+
+// Text renders the digest from the built-in template.
+func (d *Digest) Text() string {
+	var sb strings.Builder
+	switch {
+	case d.Tag != "" && d.Since != "":
+		fmt.Fprintf(&sb, "Changes in %s %s since %s.\n\n", d.Rig, d.Tag, d.Since)
+	case d.Tag != "":
+		fmt.Fprintf(&sb, "Changes in %s %s.\n\n", d.Rig, d.Tag)
+	default:
+		fmt.Fprintf(&sb, "Merge queue activity on %s for %s.\n\n", d.Rig, d.Date)
+	}
+	fmt.Fprintf(&sb, "Merged %d, failed %d, skipped %d", d.Stats.Merged, d.Stats.Failed, d.Stats.Skipped)
+	if d.Stats.Merged+d.Stats.Failed > 0 {
+		fmt.Fprintf(&sb, " (%.0f%% success)", d.Stats.SuccessRate*100)
+	}
+	sb.WriteString(".\n")
+
+	section := func(title string, items []DigestItem, times string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(&sb, "\n%s (%d):\n", title, len(items))
+		for _, it := range items {
+			sb.WriteString("- " + it.Branch)
+			if it.SourceIssue != "" {
+				fmt.Fprintf(&sb, " (%s)", it.SourceIssue)
+			}
+			if it.Worker != "" {
+				sb.WriteString(" by " + it.Worker)
+			}
+			if it.Count > 1 && times != "" {
+				fmt.Fprintf(&sb, ", %d %s", it.Count, times)
+			}
+			if it.Detail != "" {
+				sb.WriteString(": " + it.Detail)
+			}
+			sb.WriteString("\n")
+		}
+	}
+	section("Merged", d.Merged, "")
+	section("Failed", d.Failed, "failures")
+	section("Conflicts", d.Conflicts, "conflicts")
+	section("Resolved by agent", d.Resolved, "")
+
+	if len(d.Stats.Workers) > 0 {
+		sb.WriteString("\nWorkers:\n")
+		for _, w := range d.Stats.Workers {
+			fmt.Fprintf(&sb, "- %s: %d merged, %d failed attempts, %.0f%% first pass\n",
+				w.Worker, w.Merged, w.Failed, w.FirstPassRate*100)
+		}
+	}
+	return sb.String()
+}
+
+// digestState records the last day a rig's digest was sent.
+type digestState struct {
+	Sent string `json:"sent"` // YYYY-MM-DD
+}
+
+// DigestStatePath returns where a rig records its last digest.
+func DigestStatePath(rigPath string) string {
+	return filepath.Join(rigPath, ".runtime", "digest.json")
+}
+
+// Digest builds the digest for day from the rig's event log.
+func (e *Engineer) Digest(day time.Time) (*Digest, error) {
+	events, err := e.eventLogger.ReadEvents(0)
+	if err != nil {
+		return nil, err
+	}
+	return BuildDigest(e.rig.Name, events, day), nil
+}
+
+// SendDigest mails today's digest to refinery.notifications.digest, once
+// a day after digest_at, or now whatever the time and however often if
+// force is set. It reports whether a digest was sent.
+func (e *Engineer) SendDigest(ctx context.Context, now time.Time, force bool) (bool, error) {
+	if e.settings == nil || e.settings.Notifications == nil || len(e.settings.Notifications.Digest) == 0 {
+		return false, fmt.Errorf("no refinery.notifications.digest recipients configured")
+	}
+	n := e.settings.Notifications
+	now = now.Local()
+	today := now.Format("2006-01-02")
+	var state digestState
+	if data, err := os.ReadFile(DigestStatePath(e.rig.Path)); err == nil { //nolint:gosec // G304: path is constructed internally
+		_ = json.Unmarshal(data, &state)
+	}
+	if !force && (now.Hour()*60+now.Minute() < n.DigestMinute() || state.Sent == today) {
+		return false, nil
+	}
+	// A paused rig holds its mail; the digest goes out once it resumes
+	if rig.CheckNotPaused(e.rig.Path) != nil {
+		return false, nil
+	}
+
+	d, err := e.Digest(now)
+	if err != nil {
+		return false, fmt.Errorf("reading merge events: %w", err)
+	}
+	body := d.Text()
+	if n.DigestCommand != "" {
+		if written, err := e.writeDigest(ctx, d, body); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: %v (sending the templated digest)\n", err)
+		} else if written != "" {
+			body = written
+		}
+	}
+	e.notify(n.Digest, d.Subject(), body)
+
+	if err := os.MkdirAll(filepath.Dir(DigestStatePath(e.rig.Path)), 0755); err != nil {
+		return true, err
+	}
+	return true, util.AtomicWriteJSON(DigestStatePath(e.rig.Path), digestState{Sent: today})
+}
+
+// writeDigest runs refinery.notifications.digest_command over d.
+func (e *Engineer) writeDigest(ctx context.Context, d *Digest, text string) (string, error) {
+	n := e.settings.Notifications
+	runtimeDir := filepath.Join(e.rig.Path, ".runtime")
+	if err := os.MkdirAll(runtimeDir, 0755); err != nil {
+		return "", err
+	}
+	dir, err := os.MkdirTemp(runtimeDir, "digest-")
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	contextFile := filepath.Join(dir, "digest.json")
+	textFile := filepath.Join(dir, "digest.txt")
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(contextFile, data, 0644); err != nil { //nolint:gosec // G306: not sensitive
+		return "", err
+	}
+	if err := os.WriteFile(textFile, []byte(text), 0644); err != nil { //nolint:gosec // G306: not sensitive
+		return "", err
+	}
+
+	env, err := e.processEnv()
+	if err != nil {
+		return "", err
+	}
+	env = append(env, "GT_DIGEST_CONTEXT="+contextFile, "GT_DIGEST_TEXT="+textFile)
+	out, err := e.runAgent(ctx, "digest command", n.DigestCommand, n.DigestTimeoutDuration(), env)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
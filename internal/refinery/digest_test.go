@@ -0,0 +1,91 @@
+package refinery
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func TestBuildDigest(t *testing.T) {
+	day := time.Date(2026, 3, 2, 12, 0, 0, 0, time.Local)
+	at := func(h int) time.Time { return time.Date(2026, 3, 2, h, 0, 0, 0, time.Local) }
+	nux := mrqueue.Event{MRID: "mr-1", Branch: "polecat/nux/gt-1", Target: "main", Worker: "nux", SourceIssue: "gt-1"}
+	slit := mrqueue.Event{MRID: "mr-2", Branch: "polecat/slit/gt-2", Target: "main", Worker: "slit"}
+	with := func(ev mrqueue.Event, ts time.Time, typ mrqueue.EventType, mod func(*mrqueue.Event)) mrqueue.Event {
+		ev.Timestamp, ev.Type = ts, typ
+		if mod != nil {
+			mod(&ev)
+		}
+		return ev
+	}
+	events := []mrqueue.Event{
+		with(nux, day.AddDate(0, 0, -1), mrqueue.EventMerged, nil), // Yesterday
+		with(nux, at(9), mrqueue.EventMergeFailed, func(ev *mrqueue.Event) { ev.FailureType, ev.Reason = "conflict", "merge conflict" }),
+		with(nux, at(10), mrqueue.EventConflictResolved, func(ev *mrqueue.Event) { ev.Reason = "kept both" }),
+		with(nux, at(11), mrqueue.EventMerged, func(ev *mrqueue.Event) { ev.MergeCommit = "0123456789abcdef" }),
+		with(slit, at(13), mrqueue.EventMergeFailed, func(ev *mrqueue.Event) { ev.FailureType, ev.FailedCheck, ev.Reason = "tests", "lint", "exit status 1" }),
+		with(slit, at(14), mrqueue.EventMergeFailed, func(ev *mrqueue.Event) { ev.FailureType, ev.FailedCheck, ev.Reason = "tests", "lint", "exit status 2" }),
+	}
+
+	d := BuildDigest("greenplace", events, day)
+	if d.Stats.Merged != 1 || d.Stats.Failed != 3 || len(d.Merged) != 1 || len(d.Conflicts) != 1 || len(d.Resolved) != 1 {
+		t.Fatalf("digest = %+v", d)
+	}
+	if len(d.Failed) != 1 || d.Failed[0].Count != 2 || d.Failed[0].Detail != "tests (check lint): exit status 2" {
+		t.Errorf("Failed = %+v, want slit's two lint failures", d.Failed)
+	}
+	if got := d.Subject(); got != "Refinery digest: greenplace 2026-03-02 (1 merged, 3 failed)" {
+		t.Errorf("Subject = %q", got)
+	}
+	text := d.Text()
+	for _, want := range []string{
+		"Merged 1, failed 3, skipped 0 (25% success).",
+		"Merged (1):\n- polecat/nux/gt-1 (gt-1) by nux: 01234567\n",
+		"Failed (1):\n- polecat/slit/gt-2 by slit, 2 failures: tests (check lint): exit status 2\n",
+		"Conflicts (1):\n- polecat/nux/gt-1 (gt-1) by nux: merge conflict\n",
+		"Resolved by agent (1):\n- polecat/nux/gt-1 (gt-1) by nux: kept both\n",
+		"- slit: 0 merged, 2 failed attempts, 0% first pass\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Text missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestSendDigest_OncePerDayAfterDigestAt(t *testing.T) {
+	e := NewEngineer(&rig.Rig{Name: "testrig", Path: t.TempDir()})
+	e.SetOutput(io.Discard)
+	if _, err := e.SendDigest(t.Context(), time.Now(), false); err == nil {
+		t.Fatal("SendDigest without recipients succeeded")
+	}
+	e.settings = &config.RefinerySettings{Notifications: &config.NotificationsConfig{
+		Digest:        []string{"overseer"},
+		DigestAt:      "17:30",
+		DigestCommand: `echo "$(wc -l < "$GT_DIGEST_TEXT") lines"`,
+	}}
+
+	morning := time.Date(2026, 3, 2, 9, 0, 0, 0, time.Local)
+	evening := time.Date(2026, 3, 2, 18, 0, 0, 0, time.Local)
+	steps := []struct {
+		now   time.Time
+		force bool
+		want  bool
+	}{
+		{morning, false, false},
+		{evening, false, true},
+		{evening.Add(time.Hour), false, false}, // Already sent today
+		{evening.Add(time.Hour), true, true},
+		{evening.AddDate(0, 0, 1), false, true},
+	}
+	for i, s := range steps {
+		sent, err := e.SendDigest(t.Context(), s.now, s.force)
+		if err != nil || sent != s.want {
+			t.Errorf("step %d: SendDigest = %v, %v; want %v", i, sent, err, s.want)
+		}
+	}
+}
@@ -9,17 +9,19 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/clock"
+	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/git"
 	"github.com/steveyegge/gastown/internal/mail"
 	"github.com/steveyegge/gastown/internal/mrqueue"
 	"github.com/steveyegge/gastown/internal/protocol"
 	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/util"
 )
 
 // MergeQueueConfig holds configuration for the merge queue processor.
@@ -53,6 +55,9 @@ type MergeQueueConfig struct {
 
 	// MaxConcurrent is the maximum number of MRs to process concurrently.
 	MaxConcurrent int `json:"max_concurrent"`
+
+	// Hooks holds per-rig lifecycle hook commands (pre-merge, post-merge, on-failure).
+	Hooks *HooksConfig `json:"hooks,omitempty"`
 }
 
 // DefaultMergeQueueConfig returns sensible defaults for merge queue configuration.
@@ -77,13 +82,30 @@ type Engineer struct {
 	rig         *rig.Rig
 	beads       *beads.Beads
 	mrQueue     *mrqueue.Queue
-	git         *git.Git
+	git         GitRunner
 	config      *MergeQueueConfig
 	workDir     string
 	output      io.Writer // Output destination for user-facing messages
 	eventLogger *mrqueue.EventLogger
 	router      *mail.Router // Mail router for sending protocol messages
 
+	// settings is the [refinery] section of settings/rig.toml, applied by
+	// LoadConfig. Nil when the rig has no rig.toml.
+	settings *config.RefinerySettings
+
+	// fetched holds the targets FetchQueue fetched, which merges reuse.
+	fetched map[string]bool
+
+	// pipelines holds, per target, the next MR's checks started ahead
+	// with refinery.pipeline.
+	pipelines map[string]*pipelineRun
+
+	// gitAt opens the worktree at a directory the engineer created.
+	gitAt func(dir string) GitRunner
+
+	// clk is the scheduler's clock; see SetClock.
+	clk clock.Clock
+
 	// stopCh is used for graceful shutdown
 	stopCh chan struct{}
 }
@@ -99,23 +121,83 @@ func NewEngineer(r *rig.Rig) *Engineer {
 		beads:       beads.New(r.Path),
 		mrQueue:     mrqueue.New(r.Path),
 		git:         git.NewGit(r.Path),
+		gitAt:       func(dir string) GitRunner { return git.NewGit(dir) },
 		config:      cfg,
 		workDir:     r.Path,
 		output:      os.Stdout,
 		eventLogger: mrqueue.NewEventLoggerFromRig(r.Path),
 		router:      mail.NewRouter(r.Path),
+		pipelines:   make(map[string]*pipelineRun),
 		stopCh:      make(chan struct{}),
 	}
 }
 
+// SetClock makes the scheduler, and the queue and event log it reads,
+// tell the time by c: merge windows, budgets, risk history, milestones,
+// MR ages, and when events happened.
+// Simulations use it to run a scenario in virtual time.
+func (e *Engineer) SetClock(c clock.Clock) {
+	e.clk = c
+	e.mrQueue.SetClock(c)
+	e.eventLogger.SetClock(c)
+}
+
+// clock returns the scheduler's time.
+func (e *Engineer) clock() time.Time {
+	return clock.Or(e.clk).Now()
+}
+
 // SetOutput sets the output writer for user-facing messages.
 // This is useful for testing or redirecting output.
 func (e *Engineer) SetOutput(w io.Writer) {
 	e.output = w
 }
 
-// LoadConfig loads merge queue configuration from the rig's config.json.
+// LoadConfig loads merge queue configuration from the rig's config.json,
+// then applies settings/rig.toml on top.
 func (e *Engineer) LoadConfig() error {
+	if err := e.loadMergeQueueJSON(); err != nil {
+		return err
+	}
+	return e.loadRigSettings()
+}
+
+// loadRigSettings applies the [refinery] section of settings/rig.toml.
+func (e *Engineer) loadRigSettings() error {
+	settings, err := config.LoadRefinerySettings(e.rig.Path)
+	if err != nil {
+		return err
+	}
+	e.settings = settings
+	if settings == nil {
+		return nil
+	}
+
+	if settings.TargetBranch != "" {
+		e.config.TargetBranch = settings.TargetBranch
+	}
+	if settings.OnConflict != "" {
+		e.config.OnConflict = settings.OnConflict
+	}
+	if settings.Schedule != nil && settings.Schedule.PollInterval != "" {
+		e.config.PollInterval, _ = time.ParseDuration(settings.Schedule.PollInterval) // Validated on load
+	}
+	return nil
+}
+
+// MergeWindowOpen reports whether rig.toml's schedule allows merging at t.
+func (e *Engineer) MergeWindowOpen(t time.Time) bool {
+	return e.settings == nil || e.settings.Schedule.Allows(t)
+}
+
+// InMergeWindow reports whether rig.toml's schedule allows merging now, by
+// the engineer's clock.
+func (e *Engineer) InMergeWindow() bool {
+	return e.MergeWindowOpen(e.clock())
+}
+
+// loadMergeQueueJSON loads the merge_queue section of the rig's config.json.
+func (e *Engineer) loadMergeQueueJSON() error {
 	configPath := filepath.Join(e.rig.Path, "config.json")
 	data, err := os.ReadFile(configPath)
 	if err != nil {
@@ -142,16 +224,17 @@ func (e *Engineer) LoadConfig() error {
 	// Parse merge_queue section into our config struct
 	// We need special handling for poll_interval (string -> Duration)
 	var mqRaw struct {
-		Enabled              *bool   `json:"enabled"`
-		TargetBranch         *string `json:"target_branch"`
-		IntegrationBranches  *bool   `json:"integration_branches"`
-		OnConflict           *string `json:"on_conflict"`
-		RunTests             *bool   `json:"run_tests"`
-		TestCommand          *string `json:"test_command"`
-		DeleteMergedBranches *bool   `json:"delete_merged_branches"`
-		RetryFlakyTests      *int    `json:"retry_flaky_tests"`
-		PollInterval         *string `json:"poll_interval"`
-		MaxConcurrent        *int    `json:"max_concurrent"`
+		Enabled              *bool        `json:"enabled"`
+		TargetBranch         *string      `json:"target_branch"`
+		IntegrationBranches  *bool        `json:"integration_branches"`
+		OnConflict           *string      `json:"on_conflict"`
+		RunTests             *bool        `json:"run_tests"`
+		TestCommand          *string      `json:"test_command"`
+		DeleteMergedBranches *bool        `json:"delete_merged_branches"`
+		RetryFlakyTests      *int         `json:"retry_flaky_tests"`
+		PollInterval         *string      `json:"poll_interval"`
+		MaxConcurrent        *int         `json:"max_concurrent"`
+		Hooks                *HooksConfig `json:"hooks"`
 	}
 
 	if err := json.Unmarshal(rawConfig.MergeQueue, &mqRaw); err != nil {
@@ -186,6 +269,14 @@ func (e *Engineer) LoadConfig() error {
 	if mqRaw.MaxConcurrent != nil {
 		e.config.MaxConcurrent = *mqRaw.MaxConcurrent
 	}
+	if mqRaw.Hooks != nil {
+		if mqRaw.Hooks.Timeout != "" {
+			if _, err := time.ParseDuration(mqRaw.Hooks.Timeout); err != nil {
+				return fmt.Errorf("invalid hooks.timeout %q: %w", mqRaw.Hooks.Timeout, err)
+			}
+		}
+		e.config.Hooks = mqRaw.Hooks
+	}
 	if mqRaw.PollInterval != nil {
 		dur, err := time.ParseDuration(*mqRaw.PollInterval)
 		if err != nil {
@@ -209,6 +300,34 @@ type ProcessResult struct {
 	Error       string
 	Conflict    bool
 	TestsFailed bool
+
+	// Failure details handed back to the worker (see MergeFeedback)
+	ConflictFiles []string
+	FailedCheck   string
+	CheckOutput   string // Tail of the failing check's output
+
+	// Size of a successful merge on the target (see refinery.budget)
+	FilesChanged int
+	LinesChanged int
+
+	// TargetBefore is where the target was before a successful merge, so
+	// MergeCommit's change can be picked onto release branches (see
+	// refinery.backport).
+	TargetBefore string
+
+	// For the merge receipt: the checks that ran, in order, whether they
+	// ran ahead (see refinery.pipeline), when the merge started, and the
+	// branch commit merged
+	Checks     []CheckRun
+	Pipelined  bool
+	Started    time.Time
+	BranchHead string
+
+	// HandedOff is set, with Success false, when the MR passed and was
+	// handed to GitHub's merge queue as PullRequest instead of merged; it
+	// is finished by SyncGitHubQueue (see refinery.github_queue).
+	HandedOff   bool
+	PullRequest string
 }
 
 // ProcessMR processes a single merge request from a beads issue.
@@ -228,12 +347,84 @@ func (e *Engineer) ProcessMR(ctx context.Context, mr *beads.Issue) ProcessResult
 	_, _ = fmt.Fprintf(e.output, "  Target: %s\n", mrFields.Target)
 	_, _ = fmt.Fprintf(e.output, "  Worker: %s\n", mrFields.Worker)
 
-	return e.doMerge(ctx, mrFields.Branch, mrFields.Target, mrFields.SourceIssue)
+	return e.doMerge(ctx, mrFromIssue(mr, mrFields))
+}
+
+// mrFromIssue builds a queue-style MR from a merge-request bead so the
+// beads and wisp-queue code paths can share merge logic.
+func mrFromIssue(issue *beads.Issue, fields *beads.MRFields) *mrqueue.MR {
+	return &mrqueue.MR{
+		ID:          issue.ID,
+		Branch:      fields.Branch,
+		Target:      fields.Target,
+		SourceIssue: fields.SourceIssue,
+		Worker:      fields.Worker,
+		Rig:         fields.Rig,
+		Title:       issue.Title,
+		Priority:    issue.Priority,
+		AgentBead:   fields.AgentBead,
+		RetryCount:  fields.RetryCount,
+		SwarmID:     fields.SwarmID,
+	}
 }
 
 // doMerge performs the actual git merge operation.
 // This is the core merge logic shared by ProcessMR and ProcessMRFromQueue.
-func (e *Engineer) doMerge(ctx context.Context, branch, target, sourceIssue string) ProcessResult {
+func (e *Engineer) doMerge(ctx context.Context, mr *mrqueue.MR) ProcessResult {
+	started := e.clock()
+	e.discoverSwarm(mr)
+	e.routeToIntegration(mr)
+	lane, err := e.laneFor(mr.Target)
+	if err != nil {
+		return ProcessResult{Success: false, Error: err.Error()}
+	}
+	e = lane
+	branch, target, sourceIssue := mr.Branch, mr.Target, mr.SourceIssue
+
+	// Step 0: Enforce rig.toml branch_patterns
+	if !e.settings.BranchAllowed(branch) {
+		return ProcessResult{
+			Success: false,
+			Error:   fmt.Sprintf("branch %s does not match refinery.branch_patterns %v", branch, e.settings.BranchPatterns),
+		}
+	}
+
+	// Step 0.5: Enforce rig.toml paths (rigs sharing a monorepo)
+	if err := checkScope(e.git, e.settings, branch, target); err != nil {
+		return ProcessResult{
+			Success: false,
+			Error:   err.Error(),
+		}
+	}
+
+	// Step 0.6: Enforce rig.toml verify_authorship (polecat/<worker>/ branches)
+	if err := checkAuthorship(e.git, e.settings, branch, target); err != nil {
+		return ProcessResult{
+			Success: false,
+			Error:   err.Error(),
+		}
+	}
+
+	// Step 0.65: Enforce rig.toml [refinery.signatures]. Landings and
+	// backports carry the refinery's own commits, whose inputs were checked
+	// on the way in.
+	if !queuedItself(mr) {
+		if err := checkSignatures(e.git, e.settings, e.rig.Path, branch, target); err != nil {
+			return ProcessResult{
+				Success: false,
+				Error:   err.Error(),
+			}
+		}
+	}
+
+	// Step 0.7: Land a swarm's integration branch only once its epic is done
+	if err := e.checkSwarmComplete(branch); err != nil {
+		return ProcessResult{
+			Success: false,
+			Error:   err.Error(),
+		}
+	}
+
 	// Step 1: Verify source branch exists locally (shared .repo.git with polecats)
 	_, _ = fmt.Fprintf(e.output, "[Engineer] Checking local branch %s...\n", branch)
 	exists, err := e.git.BranchExists(branch)
@@ -260,50 +451,47 @@ func (e *Engineer) doMerge(ctx context.Context, branch, target, sourceIssue stri
 	}
 
 	// Make sure target is up to date with origin
-	if err := e.git.Pull("origin", target); err != nil {
-		// Pull might fail if nothing to pull, that's ok
+	if err := e.fetchTarget(target); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: fetch origin/%s: %v (continuing)\n", target, err)
+	} else if err := e.git.Merge("origin/" + target); err != nil {
 		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: pull from origin/%s: %v (continuing)\n", target, err)
 	}
 
-	// Step 3: Check for merge conflicts (using local branch)
-	_, _ = fmt.Fprintf(e.output, "[Engineer] Checking for conflicts...\n")
-	conflicts, err := e.git.CheckConflicts(branch, target)
-	if err != nil {
-		return ProcessResult{
-			Success:  false,
-			Conflict: true,
-			Error:    fmt.Sprintf("conflict check failed: %v", err),
-		}
+	// Steps 3-4: Check for conflicts and run checks, unless they already
+	// ran against this target while the previous MR was pushed
+	result, pipelined := e.pipelinedResult(mr)
+	if !pipelined {
+		result = e.checkMerge(ctx, mr, target)
 	}
-	if len(conflicts) > 0 {
+	if !result.Success {
+		return result
+	}
+
+	// Step 4.5: Run pre-merge hook (non-zero exit aborts the merge)
+	if err := e.runHook(ctx, HookPreMerge, HookContext{Rig: e.rig.Name, MR: mr}); err != nil {
 		return ProcessResult{
-			Success:  false,
-			Conflict: true,
-			Error:    fmt.Sprintf("merge conflicts in: %v", conflicts),
+			Success: false,
+			Error:   err.Error(),
 		}
 	}
 
-	// Step 4: Run tests if configured
-	if e.config.RunTests && e.config.TestCommand != "" {
-		_, _ = fmt.Fprintf(e.output, "[Engineer] Running tests: %s\n", e.config.TestCommand)
-		result := e.runTests(ctx)
-		if !result.Success {
-			return ProcessResult{
-				Success:     false,
-				TestsFailed: true,
-				Error:       result.Error,
-			}
-		}
-		_, _ = fmt.Fprintln(e.output, "[Engineer] Tests passed")
+	// Step 4.7: With refinery.github_queue, GitHub's merge queue merges
+	if e.settings != nil && e.settings.GitHubQueue != nil {
+		return e.handOff(ctx, mr)
 	}
 
-	// Step 5: Perform the actual merge
+	// Step 5: Perform the actual merge, noting where the target was so the
+	// size of the change can be measured for refinery.budget
+	before, _ := e.git.Rev("HEAD")
+	branchHead, _ := e.git.Rev(branch)
+	checks := result.Checks
 	mergeMsg := fmt.Sprintf("Merge %s into %s", branch, target)
 	if sourceIssue != "" {
 		mergeMsg = fmt.Sprintf("Merge %s into %s (%s)", branch, target, sourceIssue)
 	}
 	_, _ = fmt.Fprintf(e.output, "[Engineer] Merging with message: %s\n", mergeMsg)
-	if err := e.git.MergeNoFF(branch, mergeMsg); err != nil {
+	mergeMsg = e.squashMessage(ctx, mr, mergeMsg)
+	if err := e.merge(branch, mergeMsg); err != nil {
 		if errors.Is(err, git.ErrMergeConflict) {
 			_ = e.git.AbortMerge()
 			return ProcessResult{
@@ -318,6 +506,20 @@ func (e *Engineer) doMerge(ctx context.Context, branch, target, sourceIssue stri
 		}
 	}
 
+	// Step 5.5: Fold the refinery.changelog entry into the merge commit
+	if err := e.addChangelogEntry(mr); err != nil {
+		// Take the merge back off the local target so it is not pushed
+		// with the next MR
+		if before != "" && e.git.Checkout(before) == nil {
+			_ = e.git.UpdateBranchRef(target, before)
+			_ = e.git.Checkout(target)
+		}
+		return ProcessResult{
+			Success: false,
+			Error:   err.Error(),
+		}
+	}
+
 	// Step 6: Get the merge commit SHA
 	mergeCommit, err := e.git.Rev("HEAD")
 	if err != nil {
@@ -327,19 +529,220 @@ func (e *Engineer) doMerge(ctx context.Context, branch, target, sourceIssue stri
 		}
 	}
 
+	// Step 6.5: With refinery.pipeline, start on the next MR's checks while
+	// this one is pushed and finalized
+	e.startPipeline(ctx, mr, mergeCommit)
+
 	// Step 7: Push to origin
 	_, _ = fmt.Fprintf(e.output, "[Engineer] Pushing to origin/%s...\n", target)
-	if err := e.git.Push("origin", target, false); err != nil {
+	err = util.Fault(util.FaultPush)
+	if err == nil {
+		err = e.git.Push("origin", target, false)
+	}
+	if err != nil {
 		return ProcessResult{
 			Success: false,
 			Error:   fmt.Sprintf("failed to push to origin: %v", err),
 		}
 	}
 
+	// Step 8: Verify the target with refinery.post_merge; a failure reverts
+	// the merge
+	verified := e.verifyMerge(ctx, mr, target, mergeCommit)
+	if !verified.Success {
+		return verified
+	}
+
 	_, _ = fmt.Fprintf(e.output, "[Engineer] Successfully merged: %s\n", mergeCommit[:8])
-	return ProcessResult{
-		Success:     true,
-		MergeCommit: mergeCommit,
+	result = ProcessResult{
+		Success:      true,
+		MergeCommit:  mergeCommit,
+		TargetBefore: before,
+		Checks:       append(checks, verified.Checks...),
+		Pipelined:    pipelined,
+		Started:      started,
+		BranchHead:   branchHead,
+	}
+	if before != "" {
+		result.FilesChanged, result.LinesChanged, _ = e.git.DiffStat(before, mergeCommit)
+	}
+	return result
+}
+
+// checkMerge checks mr's branch for conflicts with onto, then runs the
+// merge checks with onto checked out and the review. onto is the MR's target, or for a
+// pipelined check, the commit the target is expected to be at.
+func (e *Engineer) checkMerge(ctx context.Context, mr *mrqueue.MR, onto string) ProcessResult {
+	// Step 3: Check for merge conflicts (using local branch)
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Checking for conflicts...\n")
+	conflicts, err := e.mergeConflicts(mr.Branch, onto)
+	if err != nil {
+		return ProcessResult{
+			Success:  false,
+			Conflict: true,
+			Error:    fmt.Sprintf("conflict check failed: %v", err),
+		}
+	}
+	if len(conflicts) > 0 {
+		return ProcessResult{
+			Success:       false,
+			Conflict:      true,
+			Error:         fmt.Sprintf("merge conflicts in: %v", conflicts),
+			ConflictFiles: conflicts,
+		}
+	}
+
+	// Step 4: Run the checks with onto checked out
+	if err := e.git.Checkout(onto); err != nil {
+		return ProcessResult{
+			Success: false,
+			Error:   fmt.Sprintf("failed to checkout %s: %v", onto, err),
+		}
+	}
+	checked := e.runMergeChecks(ctx, mr)
+	if !checked.Success {
+		return checked
+	}
+
+	// Step 4.1: Have the reviewer, if any, read the diff
+	result := e.reviewMerge(ctx, mr, onto)
+	result.Checks = checked.Checks
+	return result
+}
+
+// runMergeChecks runs the checks from rig.toml, less those the MR's routes
+// skip, or the test command if none are configured, then any extra checks
+// for the MR's routes, the worker's trust tier, and swarm integration
+// branches, in the engineer's worktree as it is.
+func (e *Engineer) runMergeChecks(ctx context.Context, mr *mrqueue.MR) ProcessResult {
+	base, checks := e.settings.RoutedChecks(mr.Routes)
+	checks = append(append(checks, e.settings.TierChecks(mr.Worker)...), e.integrationChecks(mr.Branch, mr.Target)...)
+	var runs []CheckRun
+	if e.settings != nil && len(e.settings.Checks) > 0 {
+		checks = append(base, checks...)
+	} else if e.config.RunTests && e.config.TestCommand != "" {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Running tests: %s\n", e.config.TestCommand)
+		started := time.Now()
+		result := e.runTests(ctx)
+		runs = append(runs, CheckRun{Name: "tests", Command: e.config.TestCommand, Passed: result.Success,
+			DurationMs: time.Since(started).Milliseconds()})
+		if !result.Success {
+			return ProcessResult{
+				Success:     false,
+				TestsFailed: true,
+				Error:       result.Error,
+				Checks:      runs,
+			}
+		}
+		_, _ = fmt.Fprintln(e.output, "[Engineer] Tests passed")
+	}
+	if len(checks) > 0 {
+		result := e.runChecks(ctx, checks)
+		result.Checks = append(runs, result.Checks...)
+		return result
+	}
+	return ProcessResult{Success: true, Checks: runs}
+}
+
+// merge lands branch on the checked-out target using the rig.toml strategy.
+func (e *Engineer) merge(branch, message string) error {
+	strategy := config.StrategyMerge
+	if e.settings != nil && e.settings.Strategy != "" {
+		strategy = e.settings.Strategy
+	}
+	switch strategy {
+	case config.StrategySquash:
+		return e.git.MergeSquash(branch, message)
+	case config.StrategyFFOnly:
+		return e.git.MergeFFOnly(branch)
+	default:
+		return e.git.MergeNoFF(branch, message)
+	}
+}
+
+// processEnv returns the environment for checks, tests, and hooks: the
+// refinery's own plus [refinery.env] from rig.toml.
+func (e *Engineer) processEnv() ([]string, error) {
+	extra, err := e.settings.ResolveEnv(e.rig.Path)
+	if err != nil {
+		return nil, err
+	}
+	return append(os.Environ(), extra...), nil
+}
+
+// runChecks runs checks in order, stopping at the first failure.
+func (e *Engineer) runChecks(ctx context.Context, checks []config.CheckConfig) ProcessResult {
+	env, err := e.processEnv()
+	if err != nil {
+		return ProcessResult{Success: false, TestsFailed: true, Error: err.Error()}
+	}
+
+	var runs []CheckRun
+	for _, check := range checks {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Running check %s: %s\n", check.Name, check.Command)
+
+		checkCtx, cancel := ctx, func() {}
+		if d := check.TimeoutDuration(); d > 0 {
+			checkCtx, cancel = context.WithTimeout(ctx, d)
+		}
+		// Check commands come from the rig's settings (trusted infrastructure
+		// config), but run code from the branch: sandbox them if configured.
+		cmd := e.shellCommand(checkCtx, filepath.Join(e.workDir, e.settings.CheckDir(check)), check.Command, env, true)
+		var output bytes.Buffer
+		cmd.Stdout = &output
+		cmd.Stderr = &output
+
+		started := time.Now()
+		err := util.Fault(util.FaultCheckTimeout)
+		timedOut := err != nil
+		if err == nil {
+			err = cmd.Run()
+			util.TraceCommand(e.workDir, cmd.Args[0], cmd.Args[1:], started, err)
+			timedOut = checkCtx.Err() == context.DeadlineExceeded
+		}
+		cancel()
+		runs = append(runs, CheckRun{Name: check.Name, Command: check.Command, Passed: err == nil,
+			DurationMs: time.Since(started).Milliseconds()})
+
+		if err != nil {
+			msg := fmt.Sprintf("check %s failed: %v", check.Name, err)
+			if timedOut {
+				msg = fmt.Sprintf("check %s timed out after %s", check.Name, check.Timeout)
+				if check.Timeout == "" {
+					msg = fmt.Sprintf("check %s timed out", check.Name)
+				}
+			}
+			return ProcessResult{
+				Success:     false,
+				TestsFailed: true,
+				Error:       msg,
+				FailedCheck: check.Name,
+				CheckOutput: util.Redact(tailLines(output.String(), feedbackOutputLines)),
+				Checks:      runs,
+			}
+		}
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Check %s passed\n", check.Name)
+	}
+	return ProcessResult{Success: true, Checks: runs}
+}
+
+// notify mails rig.toml notification recipients about a merge outcome.
+// Best-effort: failures are reported and otherwise ignored.
+func (e *Engineer) notify(recipients []string, subject, body string) {
+	if len(recipients) > 0 && rig.CheckNotPaused(e.rig.Path) != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Rig paused; holding notification: %s\n", subject)
+		return
+	}
+	for _, to := range recipients {
+		msg := &mail.Message{
+			From:    e.rig.Name + "/refinery",
+			To:      to,
+			Subject: subject,
+			Body:    body,
+		}
+		if err := e.router.Send(msg); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to notify %s: %v\n", to, err)
+		}
 	}
 }
 
@@ -349,6 +752,11 @@ func (e *Engineer) runTests(ctx context.Context) ProcessResult {
 		return ProcessResult{Success: true}
 	}
 
+	env, err := e.processEnv()
+	if err != nil {
+		return ProcessResult{Success: false, Error: err.Error()}
+	}
+
 	// Run the test command with retries for flaky tests
 	maxRetries := e.config.RetryFlakyTests
 	if maxRetries < 1 {
@@ -363,13 +771,14 @@ func (e *Engineer) runTests(ctx context.Context) ProcessResult {
 
 		// Note: TestCommand comes from rig's config.json (trusted infrastructure config),
 		// not from PR branches. Shell execution is intentional for flexibility (pipes, etc).
-		cmd := exec.CommandContext(ctx, "sh", "-c", e.config.TestCommand) //nolint:gosec // G204: TestCommand is from trusted rig config
-		cmd.Dir = e.workDir
+		cmd := e.shellCommand(ctx, e.workDir, e.config.TestCommand, env, true)
 		var stdout, stderr bytes.Buffer
 		cmd.Stdout = &stdout
 		cmd.Stderr = &stderr
 
+		started := time.Now()
 		err := cmd.Run()
+		util.TraceCommand(e.workDir, cmd.Args[0], cmd.Args[1:], started, err)
 		if err == nil {
 			return ProcessResult{Success: true}
 		}
@@ -395,7 +804,7 @@ func (e *Engineer) runTests(ctx context.Context) ProcessResult {
 // Steps:
 // 1. Update MR with merge_commit SHA
 // 2. Close MR with reason 'merged'
-// 3. Close source issue with reference to MR
+// 3. Move source issue to refinery.issue_status
 // 4. Delete source branch if configured
 // 5. Log success
 func (e *Engineer) handleSuccess(mr *beads.Issue, result ProcessResult) {
@@ -418,15 +827,15 @@ func (e *Engineer) handleSuccess(mr *beads.Issue, result ProcessResult) {
 		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to close MR %s: %v\n", mr.ID, err)
 	}
 
-	// 3. Close source issue with reference to MR
-	if mrFields.SourceIssue != "" {
-		closeReason := fmt.Sprintf("Merged in %s", mr.ID)
-		if err := e.beads.CloseWithReason(closeReason, mrFields.SourceIssue); err != nil {
-			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to close source issue %s: %v\n", mrFields.SourceIssue, err)
-		} else {
-			_, _ = fmt.Fprintf(e.output, "[Engineer] Closed source issue: %s\n", mrFields.SourceIssue)
-		}
-	}
+	// 2.5. Write the merge receipt and trigger refinery.deploy
+	merged := mrFromIssue(mr, mrFields)
+	e.writeReceipt(merged, result)
+	e.deploy(context.Background(), merged, result.MergeCommit)
+
+	// 3. Record the merge on the source issue and move it to
+	// refinery.issue_status
+	e.markIssue(merged, IssueMRMerged)
+	e.settleSourceIssue(merged, result.MergeCommit)
 
 	// 3.5. Clear agent bead's active_mr reference (traceability cleanup)
 	if mrFields.AgentBead != "" {
@@ -444,7 +853,19 @@ func (e *Engineer) handleSuccess(mr *beads.Issue, result ProcessResult) {
 		}
 	}
 
-	// 5. Log success
+	// 5. Run post-merge hook (best-effort: the merge already landed)
+	hc := HookContext{Rig: e.rig.Name, MR: mrFromIssue(mr, mrFields), MergeCommit: result.MergeCommit}
+	if err := e.runHook(context.Background(), HookPostMerge, hc); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: %v\n", err)
+	}
+
+	// 5.5. Tag any milestone this merge completes (best-effort, like the
+	// hook: a tag that fails is retried on the next check)
+	if _, err := e.CheckMilestones(context.Background(), e.clock()); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: %v\n", err)
+	}
+
+	// 6. Log success
 	_, _ = fmt.Fprintf(e.output, "[Engineer] ✓ Merged: %s (commit: %s)\n", mr.ID, result.MergeCommit)
 }
 
@@ -457,10 +878,33 @@ func (e *Engineer) handleFailure(mr *beads.Issue, result ProcessResult) {
 		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to reopen MR %s: %v\n", mr.ID, err)
 	}
 
+	// Run on-failure hook (best-effort)
+	mrFields := beads.ParseMRFields(mr)
+	if mrFields == nil {
+		mrFields = &beads.MRFields{}
+	}
+	hc := HookContext{Rig: e.rig.Name, MR: mrFromIssue(mr, mrFields), Error: result.Error, FailureType: failureTypeOf(result)}
+	if err := e.runHook(context.Background(), HookOnFailure, hc); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: %v\n", err)
+	}
+	e.markIssue(hc.MR, IssueMRFailed)
+
 	// Log the failure
 	_, _ = fmt.Fprintf(e.output, "[Engineer] ✗ Failed: %s - %s\n", mr.ID, result.Error)
 }
 
+// failureTypeOf classifies a failed result for notifications and hooks.
+func failureTypeOf(result ProcessResult) string {
+	switch {
+	case result.Conflict:
+		return "conflict"
+	case result.TestsFailed:
+		return "tests"
+	default:
+		return "build"
+	}
+}
+
 // ProcessMRFromQueue processes a merge request from wisp queue.
 func (e *Engineer) ProcessMRFromQueue(ctx context.Context, mr *mrqueue.MR) ProcessResult {
 	// MR fields are directly on the struct (no parsing needed)
@@ -470,19 +914,38 @@ func (e *Engineer) ProcessMRFromQueue(ctx context.Context, mr *mrqueue.MR) Proce
 	_, _ = fmt.Fprintf(e.output, "  Worker: %s\n", mr.Worker)
 	_, _ = fmt.Fprintf(e.output, "  Source: %s\n", mr.SourceIssue)
 
+	// Restack and gate the rest of the queue while we are at it
+	e.prepareQueue()
+
 	// Emit merge_started event
 	if err := e.eventLogger.LogMergeStarted(mr); err != nil {
 		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to log merge_started event: %v\n", err)
 	}
 
+	e.markIssue(mr, IssueMRMerging)
+
 	// Use the shared merge logic
-	return e.doMerge(ctx, mr.Branch, mr.Target, mr.SourceIssue)
+	return e.doMerge(ctx, mr)
 }
 
 // handleSuccessFromQueue handles a successful merge from wisp queue.
 func (e *Engineer) handleSuccessFromQueue(mr *mrqueue.MR, result ProcessResult) {
+	// Record the merge first and deploy it: every merge gets a receipt,
+	// and matching refinery.deploy rules, including those onto an MR's
+	// earlier targets. Once the rest is recorded, share the outcome with
+	// teammates
+	e.writeReceipt(mr, result)
+	e.deploy(context.Background(), mr, result.MergeCommit)
+	defer e.publishState()
+
+	// An MR with further targets is not done yet
+	if len(mr.Targets) > 0 {
+		e.advanceTarget(mr, result)
+		return
+	}
+
 	// Emit merged event
-	if err := e.eventLogger.LogMerged(mr, result.MergeCommit); err != nil {
+	if err := e.eventLogger.LogMergedChange(mr, result.MergeCommit, result.FilesChanged, result.LinesChanged); err != nil {
 		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to log merged event: %v\n", err)
 	}
 
@@ -528,15 +991,10 @@ func (e *Engineer) handleSuccessFromQueue(mr *mrqueue.MR, result ProcessResult)
 		}
 	}
 
-	// 1. Close source issue with reference to MR
-	if mr.SourceIssue != "" {
-		closeReason := fmt.Sprintf("Merged in %s", mr.ID)
-		if err := e.beads.CloseWithReason(closeReason, mr.SourceIssue); err != nil {
-			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to close source issue %s: %v\n", mr.SourceIssue, err)
-		} else {
-			_, _ = fmt.Fprintf(e.output, "[Engineer] Closed source issue: %s\n", mr.SourceIssue)
-		}
-	}
+	// 1. Record the merge on the source issue and move it to
+	// refinery.issue_status
+	e.markIssue(mr, IssueMRMerged)
+	e.settleSourceIssue(mr, result.MergeCommit)
 
 	// 1.5. Clear agent bead's active_mr reference (traceability cleanup)
 	if mr.AgentBead != "" {
@@ -545,6 +1003,10 @@ func (e *Engineer) handleSuccessFromQueue(mr *mrqueue.MR, result ProcessResult)
 		}
 	}
 
+	// 1.7. Pick the merge onto the release branches it is backported to;
+	// this reads the branch's trailers, so comes before it is deleted
+	e.backport(mr, result)
+
 	// 2. Delete source branch if configured (local only)
 	if e.config.DeleteMergedBranches && mr.Branch != "" {
 		if err := e.git.DeleteBranch(mr.Branch, true); err != nil {
@@ -554,38 +1016,104 @@ func (e *Engineer) handleSuccessFromQueue(mr *mrqueue.MR, result ProcessResult)
 		}
 	}
 
+	// 2.5. A landed integration branch completes its swarm; remove it from
+	// origin too
+	if epic := integrationEpic(mr.Branch); epic != "" {
+		if err := e.eventLogger.LogSwarmLanded(mr, result.MergeCommit); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to log swarm_landed event: %v\n", err)
+		}
+		if err := e.git.DeleteRemoteBranch("origin", mr.Branch); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to delete %s from origin: %v\n", mr.Branch, err)
+		}
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Swarm %s landed on %s\n", epic, mr.Target)
+	}
+
 	// 3. Remove MR from queue (ephemeral - just delete the file)
 	if err := e.mrQueue.Remove(mr.ID); err != nil {
 		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to remove MR from queue: %v\n", err)
 	}
 
-	// 4. Log success
-	_, _ = fmt.Fprintf(e.output, "[Engineer] ✓ Merged: %s (commit: %s)\n", mr.ID, result.MergeCommit)
+	// 3.2. Queue the swarm's landing if this was its last piece of work
+	e.queueIntegrationLanding(mr.Target)
+
+	// 3.5. Clear any failure handoff left from an earlier attempt
+	if dir := e.workerDir(mr); dir != "" {
+		if err := ClearFeedback(dir); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to clear merge feedback: %v\n", err)
+		}
+	}
+
+	// 4. Run post-merge hook (best-effort: the merge already landed)
+	hc := HookContext{Rig: e.rig.Name, MR: mr, MergeCommit: result.MergeCommit}
+	if err := e.runHook(context.Background(), HookPostMerge, hc); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: %v\n", err)
+	}
+
+	// 4.5. Tag any milestone this merge completes
+	if _, err := e.CheckMilestones(context.Background(), e.clock()); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: %v\n", err)
+	}
+
+	// 5. Notify rig.toml recipients
+	if e.settings != nil && e.settings.Notifications != nil {
+		e.notify(e.settings.Notifications.OnMerge,
+			fmt.Sprintf("Merged %s into %s", mr.Branch, mr.Target),
+			fmt.Sprintf("MR %s (%s) merged as %s.", mr.ID, mr.SourceIssue, mergedAs(mr, result.MergeCommit)))
+	}
+
+	// 6. Log success
+	_, _ = fmt.Fprintf(e.output, "[Engineer] ✓ Merged: %s (commit: %s)\n", mr.ID, mergedAs(mr, result.MergeCommit))
 }
 
 // handleFailureFromQueue handles a failed merge from wisp queue.
 // For conflicts, creates a resolution task and blocks the MR until resolved.
 // This enables non-blocking delegation: the queue continues to the next MR.
 func (e *Engineer) handleFailureFromQueue(mr *mrqueue.MR, result ProcessResult) {
+	// Share the outcome with teammates once everything below is recorded
+	defer e.publishState()
+
 	// Emit merge_failed event
-	if err := e.eventLogger.LogMergeFailed(mr, result.Error); err != nil {
+	if err := e.eventLogger.LogMergeFailure(mr, result.Error, failureTypeOf(result), result.FailedCheck); err != nil {
 		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to log merge_failed event: %v\n", err)
 	}
+	e.markIssue(mr, IssueMRFailed)
 
-	// Notify Witness of the failure so polecat can be alerted
-	// Determine failure type from result
-	failureType := "build"
-	if result.Conflict {
-		failureType = "conflict"
-	} else if result.TestsFailed {
-		failureType = "tests"
+	// Give conflicts to the rig's resolver agent first; only if it cannot
+	// produce a resolution does the failure go back to the polecat
+	if result.Conflict && e.settings != nil && e.settings.Resolver != nil {
+		res, err := e.resolveConflicts(context.Background(), mr)
+		if err == nil {
+			e.applyResolution(mr, res)
+			return
+		}
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Resolver could not resolve %s: %v\n", mr.Branch, err)
 	}
-	msg := protocol.NewMergeFailedMessage(e.rig.Name, mr.Worker, mr.Branch, mr.SourceIssue, mr.Target, failureType, result.Error)
+
+	// Notify Witness of the failure so polecat can be alerted, and hand the
+	// details straight to the polecat's workspace
+	fb := e.newMergeFeedback(mr, result)
+	e.triageFailure(context.Background(), mr, result, fb)
+	failureType := fb.FailureType
+	msg := protocol.NewMergeFailedMessageFromPayload(protocol.MergeFailedPayload{
+		Branch:        mr.Branch,
+		Issue:         mr.SourceIssue,
+		Polecat:       mr.Worker,
+		Rig:           e.rig.Name,
+		FailedAt:      fb.FailedAt,
+		FailureType:   failureType,
+		Error:         result.Error,
+		TargetBranch:  mr.Target,
+		MRID:          mr.ID,
+		TargetSHA:     fb.TargetSHA,
+		ConflictFiles: fb.ConflictFiles,
+		FailedCheck:   fb.FailedCheck,
+	})
 	if err := e.router.Send(msg); err != nil {
 		fmt.Fprintf(e.output, "[Engineer] Warning: failed to send MERGE_FAILED to witness: %v\n", err)
 	} else {
 		fmt.Fprintf(e.output, "[Engineer] Notified witness of merge failure for %s\n", mr.Worker)
 	}
+	e.handBackFailure(mr, fb)
 
 	// If this was a conflict, create a conflict-resolution task for dispatch
 	// and block the MR until the task is resolved (non-blocking delegation)
@@ -604,6 +1132,30 @@ func (e *Engineer) handleFailureFromQueue(mr *mrqueue.MR, result ProcessResult)
 		}
 	}
 
+	// After repeated failures, file an issue for a human and stop retrying
+	e.escalateIfStuck(mr, fb)
+
+	// Run on-failure hook (best-effort)
+	hc := HookContext{Rig: e.rig.Name, MR: mr, Error: result.Error, FailureType: failureType}
+	if err := e.runHook(context.Background(), HookOnFailure, hc); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: %v\n", err)
+	}
+
+	// Notify rig.toml recipients (best-effort)
+	if e.settings != nil && e.settings.Notifications != nil {
+		body := fmt.Sprintf("MR %s (%s) failed to merge into %s (%s):\n\n%s", mr.ID, mr.SourceIssue, mr.Target, failureType, result.Error)
+		if fb.Triage != nil {
+			body += "\n\nTriage: " + fb.Triage.String()
+		}
+		e.notify(e.settings.Notifications.OnFailure, fmt.Sprintf("Merge failed: %s", mr.Branch), body)
+	}
+
+	// After a run of MRs failing their checks, stop: the target itself is
+	// likely broken
+	if failureType == "tests" {
+		e.quarantineIfBroken()
+	}
+
 	// Log the failure - MR stays in queue but may be blocked
 	_, _ = fmt.Fprintf(e.output, "[Engineer] ✗ Failed: %s - %s\n", mr.ID, result.Error)
 	if mr.BlockedBy != "" {
@@ -746,9 +1298,124 @@ func (e *Engineer) IsBeadOpen(beadID string) (bool, error) {
 // ListReadyMRs returns MRs that are ready for processing:
 // - Not claimed by another worker (or claim is stale)
 // - Not blocked by an open task
-// Sorted by priority score (highest first).
+// - From a worker rig.toml's refinery.workers trusts (others are parked
+//   until an operator approves them)
+// - Not touching refinery.protected paths or content, unless approved
+// - Within its worker's refinery.workers.max_ready (the rest are backlogged)
+// - Within refinery.budget, if set: once the day's merges (the rig's, or
+//   with per_worker the MR's worker's) reach a limit, MRs wait for the
+//   next day
+// - With a source issue still open for merging (others are held), and
+//   past refinery.issue_gate if set
+// - Not waiting on a queued MR for one of its issue's prerequisites in beads
+// - With rig.toml's refinery.lanes, not in a lane already merging a
+//   claimed MR
+// Sorted by priority score (highest first), or by risk with rig.toml's
+// refinery.risk.order, with each swarm's MRs grouped behind its first so a
+// swarm merges consecutively, and MRs from dead workers last.
+// Returns nothing while rig.toml's schedule keeps the merge window closed,
+// or while the rig or the refinery is paused.
 func (e *Engineer) ListReadyMRs() ([]*mrqueue.MR, error) {
-	return e.mrQueue.ListReady(e.IsBeadOpen)
+	mrs, err := e.readyMRs()
+	if err != nil || !e.lanesEnabled() {
+		return mrs, err
+	}
+	all, err := e.mrQueue.List()
+	if err != nil {
+		return nil, err
+	}
+	return skipBusyLanes(mrs, all, e.clock()), nil
+}
+
+// Paused reports whether the refinery is paused ('gt refinery pause'), so
+// nothing is ready. A timed pause whose deadline has passed ends here.
+func (e *Engineer) Paused() bool {
+	m := &Manager{rig: e.rig, workDir: e.rig.Path, output: io.Discard, clk: e.clk}
+	ref, err := m.loadState()
+	return err == nil && ref.State == StatePaused
+}
+
+// halted reports whether nothing may merge now: the merge window is
+// closed, or the rig or the refinery is paused.
+func (e *Engineer) halted() bool {
+	return !e.InMergeWindow() || rig.CheckNotPaused(e.rig.Path) != nil || e.Paused()
+}
+
+// prepareQueue is the work on the queue the processing pass does before
+// taking an MR: it restacks stacked branches, and parks or holds the ready
+// MRs the gates turn away, logging each. Listings leave the queue alone;
+// readyMRs only filters out what this would park or hold.
+func (e *Engineer) prepareQueue() {
+	if e.halted() {
+		return
+	}
+	e.restack()
+	mrs, err := e.mrQueue.ListReady(e.IsBeadOpen)
+	if err != nil {
+		return
+	}
+	mrs = e.gateUntrustedWorkers(mrs)
+	mrs = e.gateProtectedChanges(mrs)
+	e.syncSourceIssues(mrs)
+}
+
+// admitGated returns the MRs prepareQueue's gates would let through,
+// changing nothing: those from trusted workers or approved, without
+// protected changes, and whose source issue rules nothing out.
+func (e *Engineer) admitGated(mrs []*mrqueue.MR) []*mrqueue.MR {
+	ready := mrs[:0]
+	for _, mr := range mrs {
+		if e.untrustedWorker(mr) != "" {
+			continue
+		}
+		if reason, err := e.protectedReason(mr); err != nil || reason != "" {
+			continue
+		}
+		ready = append(ready, mr)
+	}
+	return e.admitSourceIssues(ready)
+}
+
+// readyMRs is ListReadyMRs before busy lanes are skipped. It only reads
+// the queue; see prepareQueue.
+func (e *Engineer) readyMRs() ([]*mrqueue.MR, error) {
+	if e.halted() {
+		return nil, nil
+	}
+	mrs, err := e.mrQueue.ListReady(e.IsBeadOpen)
+	if err != nil {
+		return nil, err
+	}
+	mrs = e.admitGated(mrs)
+	if queued, err := e.mrQueue.List(); err == nil {
+		e.markStacked(mrs, queued)
+		mrs, _ = mrqueue.SplitWaiting(mrs, queued)
+		mrs = e.serializeOverlaps(mrs, queued)
+	}
+	mrs, _ = e.splitOverBudget(mrs, e.clock())
+	mrs, _ = backlogBusyWorkers(mrs, e.settings.MaxReadyPerWorker())
+	mrs = e.orderByRisk(mrs)
+	mrs = mrqueue.GroupBySwarm(mrs)
+	deprioritizeDeadWorkers(mrs, rig.WorkerLivenessMap(e.rig.Path))
+	return mrs, nil
+}
+
+// ListBacklogMRs returns MRs that would be ready but for their worker
+// already having refinery.workers.max_ready MRs ready. They move up as the
+// worker's earlier MRs merge.
+func (e *Engineer) ListBacklogMRs() ([]*mrqueue.MR, error) {
+	mrs, err := e.mrQueue.ListReady(e.IsBeadOpen)
+	if err != nil {
+		return nil, err
+	}
+	trusted := mrs[:0]
+	for _, mr := range mrs {
+		if ok, _ := e.settings.WorkerApproval(mr.Worker); ok || mr.IsApproved() {
+			trusted = append(trusted, mr)
+		}
+	}
+	_, backlog := backlogBusyWorkers(trusted, e.settings.MaxReadyPerWorker())
+	return backlog, nil
 }
 
 // ListBlockedMRs returns MRs that are blocked by open tasks.
@@ -756,3 +1423,29 @@ func (e *Engineer) ListReadyMRs() ([]*mrqueue.MR, error) {
 func (e *Engineer) ListBlockedMRs() ([]*mrqueue.MR, error) {
 	return e.mrQueue.ListBlocked(e.IsBeadOpen)
 }
+
+// ListWaitingMRs returns MRs held back until the MRs for their source
+// issue's prerequisites merge.
+func (e *Engineer) ListWaitingMRs() ([]*mrqueue.MR, error) {
+	queued, err := e.mrQueue.List()
+	if err != nil {
+		return nil, err
+	}
+	_, waiting := mrqueue.SplitWaiting(queued, queued)
+	return waiting, nil
+}
+
+// ListApprovalMRs returns MRs waiting for an operator's approval.
+func (e *Engineer) ListApprovalMRs() ([]*mrqueue.MR, error) {
+	all, err := e.mrQueue.List()
+	if err != nil {
+		return nil, err
+	}
+	var waiting []*mrqueue.MR
+	for _, mr := range all {
+		if mr.NeedsApproval() {
+			waiting = append(waiting, mr)
+		}
+	}
+	return waiting, nil
+}
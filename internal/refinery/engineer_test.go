@@ -4,9 +4,13 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/steveyegge/gastown/internal/clock"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
 	"github.com/steveyegge/gastown/internal/rig"
 )
 
@@ -215,3 +219,136 @@ func TestEngineer_DeleteMergedBranchesConfig(t *testing.T) {
 		t.Error("expected DeleteMergedBranches to be true by default")
 	}
 }
+
+func TestEngineer_LoadConfig_RigFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, "settings"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	rigFile := `
+[refinery]
+target_branch = "develop"
+strategy = "squash"
+branch_patterns = ["polecat/*"]
+
+[refinery.schedule]
+poll_interval = "2m"
+days = ["sat"]
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "settings", "rig.toml"), []byte(rigFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir})
+	if err := e.LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	if e.config.TargetBranch != "develop" {
+		t.Errorf("TargetBranch = %q, want develop", e.config.TargetBranch)
+	}
+	if e.config.PollInterval != 2*time.Minute {
+		t.Errorf("PollInterval = %v, want 2m", e.config.PollInterval)
+	}
+
+	saturday := time.Date(2026, 1, 10, 12, 0, 0, 0, time.Local)
+	if !e.MergeWindowOpen(saturday) || e.MergeWindowOpen(saturday.AddDate(0, 0, 1)) {
+		t.Error("MergeWindowOpen does not follow schedule.days")
+	}
+
+	result := e.doMerge(t.Context(), &mrqueue.MR{Branch: "feature/x", Target: "develop"})
+	if result.Success || !strings.Contains(result.Error, "branch_patterns") {
+		t.Errorf("doMerge on disallowed branch = %+v, want branch_patterns rejection", result)
+	}
+}
+
+func TestManager_InvalidRigFile(t *testing.T) {
+	mgr, rigPath := setupTestManager(t)
+	if err := os.MkdirAll(filepath.Join(rigPath, "settings"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(rigPath, "settings", "rig.toml"), []byte("[refinery]\nstrategy = \"octopus\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mgr = NewManager(mgr.rig)
+	if _, err := mgr.Settings(); err == nil || !strings.Contains(err.Error(), "refinery.strategy") {
+		t.Errorf("Settings error = %v, want it to name refinery.strategy", err)
+	}
+	if err := mgr.Start(false); err == nil || !strings.Contains(err.Error(), "invalid rig config") {
+		t.Errorf("Start error = %v, want invalid rig config", err)
+	}
+}
+
+func TestReadyMRs_Paused(t *testing.T) {
+	e, repo := newFakeEngineer(t)
+	mr := queueBranch(t, e, repo, "nux", map[string]string{"nux.txt": "nux\n"})
+	mgr := NewManager(e.rig)
+	if err := mgr.saveState(&Refinery{RigName: e.rig.Name, State: StateRunning}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mgr.Pause(); err != nil {
+		t.Fatalf("Pause: %v", err)
+	}
+	if ready, err := e.ListReadyMRs(); err != nil || len(ready) != 0 {
+		t.Errorf("paused, ListReadyMRs = %v, %v; want nothing to claim", ready, err)
+	}
+
+	if err := mgr.Resume(); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	if ready, err := e.ListReadyMRs(); err != nil || len(ready) != 1 || ready[0].ID != mr.ID {
+		t.Errorf("resumed, ListReadyMRs = %v, %v; want %s", ready, err, mr.ID)
+	}
+}
+
+func TestReadyMRs_TimedPause(t *testing.T) {
+	e, repo := newFakeEngineer(t)
+	mr := queueBranch(t, e, repo, "nux", map[string]string{"nux.txt": "nux\n"})
+	clk := clock.NewFake(time.Date(2026, 3, 6, 16, 0, 0, 0, time.UTC))
+	e.SetClock(clk)
+	mgr := NewManager(e.rig)
+	mgr.SetClock(clk)
+	if err := mgr.saveState(&Refinery{RigName: e.rig.Name, State: StateRunning}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mgr.PauseUntil(clk.Now().Add(2 * time.Hour)); err != nil {
+		t.Fatalf("PauseUntil: %v", err)
+	}
+	clk.Advance(2*time.Hour - time.Second)
+	if ready, err := e.ListReadyMRs(); err != nil || len(ready) != 0 {
+		t.Errorf("before the deadline, ListReadyMRs = %v, %v; want nothing", ready, err)
+	}
+
+	clk.Advance(time.Second)
+	if ready, err := e.ListReadyMRs(); err != nil || len(ready) != 1 || ready[0].ID != mr.ID {
+		t.Errorf("at the deadline, ListReadyMRs = %v, %v; want %s", ready, err, mr.ID)
+	}
+	if ref, _ := mgr.Status(); ref.State != StateRunning {
+		t.Errorf("after the deadline, state = %s, want running", ref.State)
+	}
+}
+
+func TestInMergeWindow_EngineerClock(t *testing.T) {
+	e, repo := newFakeEngineer(t)
+	mr := queueBranch(t, e, repo, "nux", map[string]string{"nux.txt": "nux\n"})
+	e.settings.Schedule = &config.ScheduleConfig{Windows: []string{"09:00-17:00"}}
+	clk := clock.NewFake(time.Date(2026, 3, 6, 23, 0, 0, 0, time.Local))
+	e.SetClock(clk)
+
+	if e.InMergeWindow() {
+		t.Error("InMergeWindow at 23:00 = true, want false")
+	}
+	if ready, _ := e.ListReadyMRs(); len(ready) != 0 {
+		t.Errorf("outside the window, ListReadyMRs = %v, want nothing", mrIDs(ready))
+	}
+	clk.Advance(11 * time.Hour)
+	if !e.InMergeWindow() {
+		t.Error("InMergeWindow at 10:00 = false, want true")
+	}
+	if ready, _ := e.ListReadyMRs(); len(ready) != 1 || ready[0].ID != mr.ID {
+		t.Errorf("inside the window, ListReadyMRs = %v, want %s", mrIDs(ready), mr.ID)
+	}
+}
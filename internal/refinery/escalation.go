@@ -0,0 +1,126 @@
+package refinery
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// escalateIfStuck files a beads issue for mr once it has failed more than
+// [refinery.escalation] after times, carrying the failure history and the
+// latest failure's artifacts, and blocks the MR on it. Closing the issue
+// returns the MR to the queue. Each MR is escalated once.
+func (e *Engineer) escalateIfStuck(mr *mrqueue.MR, fb *MergeFeedback) {
+	if e.settings == nil || e.settings.Escalation == nil || mr.EscalatedTo != "" {
+		return
+	}
+	failures := e.failureHistory(mr.ID)
+	if len(failures) <= e.settings.Escalation.Threshold() {
+		return
+	}
+
+	title := fmt.Sprintf("Merge keeps failing: %s", mr.Branch)
+	if mr.SourceIssue != "" {
+		title += " (" + mr.SourceIssue + ")"
+	}
+	issue, err := e.beads.Create(beads.CreateOptions{
+		Title:       title,
+		Type:        "bug",
+		Priority:    1,
+		Description: escalationDescription(e.rig.Name, mr, failures, fb),
+		Actor:       e.rig.Name + "/refinery",
+	})
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to escalate %s: %v\n", mr.ID, err)
+		return
+	}
+	if assignee := e.settings.Escalation.Assignee; assignee != "" {
+		if err := e.beads.Update(issue.ID, beads.UpdateOptions{Assignee: &assignee}); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to assign %s to %s: %v\n", issue.ID, assignee, err)
+		}
+	}
+	if err := e.mrQueue.SetEscalation(mr.ID, issue.ID); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to block %s on %s: %v\n", mr.ID, issue.ID, err)
+	}
+	mr.EscalatedTo, mr.BlockedBy = issue.ID, issue.ID
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Escalated %s after %d failures: %s\n", mr.ID, len(failures), issue.ID)
+}
+
+// failureHistory returns mrID's merge_failed events, oldest first.
+func (e *Engineer) failureHistory(mrID string) []mrqueue.Event {
+	events, err := e.eventLogger.ReadEvents(0)
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to read merge history: %v\n", err)
+		return nil
+	}
+	var failures []mrqueue.Event
+	for _, ev := range events {
+		if ev.Type == mrqueue.EventMergeFailed && ev.MRID == mrID {
+			failures = append(failures, ev)
+		}
+	}
+	return failures
+}
+
+// escalationDescription is the body of an escalation issue.
+func escalationDescription(rigName string, mr *mrqueue.MR, failures []mrqueue.Event, fb *MergeFeedback) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "MR %s has failed to merge %d times. The refinery has stopped retrying it until this issue is closed.\n\n", mr.ID, len(failures))
+
+	sb.WriteString("## Metadata\n")
+	fmt.Fprintf(&sb, "- MR: %s\n", mr.ID)
+	fmt.Fprintf(&sb, "- Branch: %s\n", mr.Branch)
+	fmt.Fprintf(&sb, "- Target: %s\n", mr.Target)
+	if mr.SourceIssue != "" {
+		fmt.Fprintf(&sb, "- Source issue: %s\n", mr.SourceIssue)
+	}
+	if mr.Worker != "" {
+		fmt.Fprintf(&sb, "- Worker: %s\n", mr.Worker)
+	}
+
+	sb.WriteString("\n## Failure history\n")
+	for _, ev := range failures {
+		kind := ev.FailureType
+		if kind == "" {
+			kind = "failed"
+		}
+		if ev.FailedCheck != "" {
+			kind += " (check " + ev.FailedCheck + ")"
+		}
+		fmt.Fprintf(&sb, "- %s %s: %s\n", ev.Timestamp.UTC().Format("2006-01-02 15:04"), kind, firstLine(ev.Reason))
+	}
+
+	if fb != nil {
+		sb.WriteString("\n## Last failure\n")
+		if fb.TargetSHA != "" {
+			fmt.Fprintf(&sb, "Target %s was at %s.\n", fb.Target, fb.TargetSHA)
+		}
+		if len(fb.ConflictFiles) > 0 {
+			sb.WriteString("\nConflicting files:\n")
+			for _, f := range fb.ConflictFiles {
+				fmt.Fprintf(&sb, "- %s\n", f)
+			}
+		}
+		if fb.CheckOutput != "" {
+			fmt.Fprintf(&sb, "\nOutput of %s:\n```\n%s\n```\n", fb.FailedCheck, strings.TrimRight(fb.CheckOutput, "\n"))
+		}
+		if fb.Triage != nil {
+			fmt.Fprintf(&sb, "\nTriage: %s\n", fb.Triage)
+		}
+	}
+
+	sb.WriteString("\n## Next steps\n")
+	sb.WriteString("Fix the branch or its target, push, and close this issue; the MR then re-enters the queue. ")
+	fmt.Fprintf(&sb, "To give up on it instead: gt mq reject %s %s\n", rigName, mr.ID)
+	return sb.String()
+}
+
+// firstLine returns s up to its first newline.
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
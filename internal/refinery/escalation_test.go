@@ -0,0 +1,37 @@
+package refinery
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+func TestEscalationDescription(t *testing.T) {
+	mr := &mrqueue.MR{ID: "mr-1", Branch: "polecat/nux/gt-1", Target: "main", SourceIssue: "gt-1", Worker: "nux"}
+	at := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	failures := []mrqueue.Event{
+		{Timestamp: at, Type: mrqueue.EventMergeFailed, FailureType: "conflict", Reason: "merge conflict\nin 2 files"},
+		{Timestamp: at.Add(time.Hour), Type: mrqueue.EventMergeFailed, FailureType: "tests", FailedCheck: "test", Reason: "exit status 1"},
+	}
+	fb := &MergeFeedback{Target: "main", TargetSHA: "abc123", FailedCheck: "test", CheckOutput: "--- FAIL: TestX\n", ConflictFiles: []string{"api.go"},
+		Triage: &TriageReport{Classification: "flaky", Diagnosis: "TestX times out under load"}}
+
+	got := escalationDescription("greenplace", mr, failures, fb)
+	for _, want := range []string{
+		"failed to merge 2 times",
+		"- Source issue: gt-1",
+		"- 2026-03-01 09:30 conflict: merge conflict\n",
+		"- 2026-03-01 10:30 tests (check test): exit status 1",
+		"Target main was at abc123",
+		"- api.go",
+		"--- FAIL: TestX",
+		"Triage: flaky: TestX times out under load",
+		"gt mq reject greenplace mr-1",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("description missing %q:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package refinery
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/tmux"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// feedbackOutputLines caps how much failing check output is handed back.
+const feedbackOutputLines = 50
+
+// FeedbackFileName is the merge failure handoff written into a polecat's
+// workspace, under .runtime/.
+const FeedbackFileName = "merge-feedback.json"
+
+// MergeFeedback describes a failed merge in enough detail for the worker to
+// fix it without asking: what conflicted or which check failed, and the
+// target commit the merge was attempted against.
+type MergeFeedback struct {
+	MRID          string    `json:"mr_id"`
+	Branch        string    `json:"branch"`
+	Target        string    `json:"target"`
+	TargetSHA     string    `json:"target_sha,omitempty"`
+	SourceIssue   string    `json:"source_issue,omitempty"`
+	FailureType   string    `json:"failure_type"`
+	Error         string    `json:"error"`
+	ConflictFiles []string  `json:"conflict_files,omitempty"`
+	FailedCheck   string    `json:"failed_check,omitempty"`
+	CheckOutput   string    `json:"check_output,omitempty"`
+	FailedAt      time.Time `json:"failed_at"`
+
+	// Triage is the triage agent's diagnosis of a failed check, if the rig
+	// has one (see refinery.triage).
+	Triage *TriageReport `json:"triage,omitempty"`
+}
+
+// FeedbackFile returns the handoff path in a worker's workspace.
+func FeedbackFile(workerDir string) string {
+	return filepath.Join(workerDir, ".runtime", FeedbackFileName)
+}
+
+// WriteFeedback writes fb into a worker's workspace, replacing any earlier
+// handoff.
+func WriteFeedback(workerDir string, fb *MergeFeedback) error {
+	p := FeedbackFile(workerDir)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	return util.AtomicWriteJSON(p, fb)
+}
+
+// LoadFeedback returns the pending handoff in a worker's workspace, or nil
+// if there is none.
+func LoadFeedback(workerDir string) (*MergeFeedback, error) {
+	data, err := os.ReadFile(FeedbackFile(workerDir)) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var fb MergeFeedback
+	if err := json.Unmarshal(data, &fb); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", FeedbackFileName, err)
+	}
+	return &fb, nil
+}
+
+// ClearFeedback removes a worker's handoff once its branch has merged.
+func ClearFeedback(workerDir string) error {
+	if err := os.Remove(FeedbackFile(workerDir)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// tailLines returns the last n lines of s.
+func tailLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// newMergeFeedback builds the handoff for a failed merge of mr.
+func (e *Engineer) newMergeFeedback(mr *mrqueue.MR, result ProcessResult) *MergeFeedback {
+	fb := &MergeFeedback{
+		MRID:          mr.ID,
+		Branch:        mr.Branch,
+		Target:        mr.Target,
+		SourceIssue:   mr.SourceIssue,
+		FailureType:   failureTypeOf(result),
+		Error:         result.Error,
+		ConflictFiles: result.ConflictFiles,
+		FailedCheck:   result.FailedCheck,
+		CheckOutput:   result.CheckOutput,
+		FailedAt:      e.clock(),
+	}
+	if sha, err := e.git.Rev("origin/" + mr.Target); err == nil {
+		fb.TargetSHA = sha
+	}
+	return fb
+}
+
+// workerDir returns the workspace of the polecat that submitted mr, or ""
+// if it has none in this rig (e.g. the branch came from crew).
+func (e *Engineer) workerDir(mr *mrqueue.MR) string {
+	if mr.Worker == "" {
+		return ""
+	}
+	dir := filepath.Join(e.rig.Path, "polecats", filepath.Base(mr.Worker))
+	if _, err := os.Stat(dir); err != nil {
+		return ""
+	}
+	return dir
+}
+
+// handBackFailure writes the failure handoff into the worker's workspace and
+// nudges its session, so the polecat picks up the fix without waiting for
+// someone to route the mail.
+func (e *Engineer) handBackFailure(mr *mrqueue.MR, fb *MergeFeedback) {
+	dir := e.workerDir(mr)
+	if dir == "" {
+		return
+	}
+	if err := WriteFeedback(dir, fb); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to write merge feedback for %s: %v\n", mr.Worker, err)
+		return
+	}
+
+	name := filepath.Base(mr.Worker)
+	session := fmt.Sprintf("gt-%s-%s", e.rig.Name, name)
+	if w, err := rig.LoadWorker(e.rig.Path, name); err == nil && w != nil && w.Session != "" {
+		session = w.Session
+	}
+	t := tmux.NewTmux()
+	if running, err := t.HasSession(session); err != nil || !running {
+		return
+	}
+	nudge := fmt.Sprintf("Merge of %s into %s failed (%s). Details are in .runtime/%s and your inbox. Fix it, push, and run 'gt done' to resubmit.",
+		mr.Branch, mr.Target, fb.FailureType, FeedbackFileName)
+	if err := t.NudgeSession(session, nudge); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to nudge %s: %v\n", session, err)
+		return
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Nudged %s to fix and resubmit\n", session)
+}
@@ -0,0 +1,72 @@
+package refinery
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func TestFeedbackRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+
+	if fb, err := LoadFeedback(dir); err != nil || fb != nil {
+		t.Fatalf("LoadFeedback(empty) = %v, %v", fb, err)
+	}
+
+	want := &MergeFeedback{
+		MRID:          "mr-1",
+		Branch:        "polecat/nux/gt-abc",
+		Target:        "main",
+		TargetSHA:     "abc123",
+		FailureType:   "conflict",
+		ConflictFiles: []string{"a.go"},
+	}
+	if err := WriteFeedback(dir, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadFeedback(dir)
+	if err != nil || got == nil {
+		t.Fatalf("LoadFeedback = %v, %v", got, err)
+	}
+	if got.MRID != want.MRID || got.TargetSHA != want.TargetSHA || len(got.ConflictFiles) != 1 {
+		t.Errorf("LoadFeedback = %+v", got)
+	}
+
+	if err := ClearFeedback(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(FeedbackFile(dir)); !os.IsNotExist(err) {
+		t.Errorf("feedback file still present after ClearFeedback")
+	}
+	if err := ClearFeedback(dir); err != nil {
+		t.Errorf("ClearFeedback(missing) = %v", err)
+	}
+}
+
+func TestTailLines(t *testing.T) {
+	if got := tailLines("a\nb\nc\n", 2); got != "b\nc" {
+		t.Errorf("tailLines = %q", got)
+	}
+	if got := tailLines("a\n", 5); got != "a" {
+		t.Errorf("tailLines = %q", got)
+	}
+}
+
+func TestEngineer_WorkerDir(t *testing.T) {
+	rigPath := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(rigPath, "polecats", "nux"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	e := &Engineer{rig: &rig.Rig{Name: "gastown", Path: rigPath}}
+
+	if got := e.workerDir(&mrqueue.MR{Worker: "gastown/nux"}); !strings.HasSuffix(got, filepath.Join("polecats", "nux")) {
+		t.Errorf("workerDir(gastown/nux) = %q", got)
+	}
+	if got := e.workerDir(&mrqueue.MR{Worker: "joe"}); got != "" {
+		t.Errorf("workerDir(joe) = %q, want empty for a worker without a workspace", got)
+	}
+}
@@ -0,0 +1,45 @@
+package refinery
+
+import (
+	"sort"
+
+	"github.com/steveyegge/gastown/internal/polecat"
+)
+
+// FetchQueue fetches from origin, in one call, everything the queued MRs
+// need: the polecat branches and each target they merge into. It returns
+// the targets fetched. MRs this engineer merges afterwards share the fetch
+// rather than fetching their target again, so a cycle costs one round
+// trip to origin however many MRs it processes.
+func (e *Engineer) FetchQueue() ([]string, error) {
+	mrs, err := e.mrQueue.List()
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{e.config.TargetBranch: true}
+	for _, mr := range mrs {
+		if mr.Target != "" {
+			seen[mr.Target] = true
+		}
+	}
+	targets := make([]string, 0, len(seen))
+	for t := range seen {
+		targets = append(targets, t)
+	}
+	sort.Strings(targets)
+
+	if err := e.git.FetchRefs("origin", append([]string{polecat.BranchPrefix + "*"}, targets...)...); err != nil {
+		return nil, err
+	}
+	e.fetched = seen
+	return targets, nil
+}
+
+// fetchTarget brings origin/target up to date, unless FetchQueue already
+// fetched it.
+func (e *Engineer) fetchTarget(target string) error {
+	if e.fetched[target] {
+		return nil
+	}
+	return e.git.FetchRefs("origin", target)
+}
@@ -0,0 +1,62 @@
+package refinery
+
+import (
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func TestFetchQueue(t *testing.T) {
+	rigPath := setupConflictRig(t)
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = rigPath
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	// Branches as if other clones pushed them, one of which no MR needs.
+	run("push", "origin", "main:refs/heads/integration/gt-epic", "main:refs/heads/polecat/slit/gt-2", "main:refs/heads/unrelated")
+	for _, b := range []string{"integration/gt-epic", "polecat/nux/gt-1", "polecat/slit/gt-2", "unrelated"} {
+		run("update-ref", "-d", "refs/remotes/origin/"+b)
+	}
+
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: rigPath})
+	e.SetOutput(&strings.Builder{})
+	if err := e.LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if err := e.mrQueue.Submit(&mrqueue.MR{Branch: "polecat/slit/gt-2", Target: "integration/gt-epic", Worker: "slit"}); err != nil {
+		t.Fatal(err)
+	}
+
+	targets, err := e.FetchQueue()
+	if err != nil {
+		t.Fatalf("FetchQueue: %v", err)
+	}
+	if want := []string{"integration/gt-epic", "main"}; !slices.Equal(targets, want) {
+		t.Errorf("targets = %v, want %v", targets, want)
+	}
+	tracking := run("branch", "-r", "--format=%(refname:short)")
+	for _, want := range []string{"origin/integration/gt-epic", "origin/polecat/nux/gt-1", "origin/polecat/slit/gt-2"} {
+		if !strings.Contains(tracking, want) {
+			t.Errorf("%s not fetched; tracking:\n%s", want, tracking)
+		}
+	}
+	if strings.Contains(tracking, "origin/unrelated") {
+		t.Errorf("fetched a branch no MR needs; tracking:\n%s", tracking)
+	}
+
+	// Fetched targets are not fetched again for each MR.
+	e.git = nil
+	if err := e.fetchTarget("integration/gt-epic"); err != nil {
+		t.Errorf("fetchTarget after FetchQueue: %v", err)
+	}
+}
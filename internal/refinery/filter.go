@@ -0,0 +1,151 @@
+package refinery
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/steveyegge/gastown/internal/beads"
+)
+
+// IssueFilter selects MRs by their source issue: those under an epic, and
+// those whose issue carries every one of a set of labels. It lets a whole
+// epic's work be listed, held, or requeued at once.
+type IssueFilter struct {
+	Epic   string
+	Labels []string
+
+	show   func(id string) (*beads.Issue, error)
+	mu     sync.Mutex
+	issues map[string]*beads.Issue
+}
+
+// lookupWorkers bounds how many issue lookups Prefetch runs at once.
+const lookupWorkers = 8
+
+// IssueFilter returns a filter that looks issues up in the rig's beads.
+func (m *Manager) IssueFilter(epic string, labels []string) *IssueFilter {
+	return &IssueFilter{
+		Epic:   epic,
+		Labels: labels,
+		show:   beads.New(m.rig.BeadsPath()).Show,
+		issues: make(map[string]*beads.Issue),
+	}
+}
+
+// IsEmpty reports whether the filter selects every MR.
+func (f *IssueFilter) IsEmpty() bool {
+	return f == nil || (f.Epic == "" && len(f.Labels) == 0)
+}
+
+// Match reports whether an MR with this source issue and swarm passes the
+// filter. An MR is under an epic if it belongs to the epic's swarm, lands
+// the epic, or its issue is a child of the epic. MRs without a source
+// issue, or whose issue cannot be found, match only by swarm.
+func (f *IssueFilter) Match(issueID, swarm string) (bool, error) {
+	if f.IsEmpty() {
+		return true, nil
+	}
+	if len(f.Labels) == 0 && (swarm == f.Epic || issueID == f.Epic) {
+		return true, nil
+	}
+	var issue *beads.Issue
+	if issueID != "" {
+		var err error
+		if issue, err = f.lookup(issueID); err != nil {
+			return false, err
+		}
+	}
+	return f.matches(issue, issueID, swarm), nil
+}
+
+// Prefetch looks up the source issues of the MRs about to be matched,
+// several at once, so that matching a long queue does not wait on one
+// bd call per MR in turn. It returns the first lookup error.
+func (f *IssueFilter) Prefetch(issueIDs []string) error {
+	if f.IsEmpty() {
+		return nil
+	}
+	var todo []string
+	f.mu.Lock()
+	for _, id := range issueIDs {
+		if _, ok := f.issues[id]; !ok && id != "" && !slices.Contains(todo, id) {
+			todo = append(todo, id)
+		}
+	}
+	f.mu.Unlock()
+
+	ids := make(chan string)
+	errs := make(chan error, len(todo))
+	var wg sync.WaitGroup
+	for i := 0; i < min(lookupWorkers, len(todo)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range ids {
+				if _, err := f.lookup(id); err != nil {
+					errs <- fmt.Errorf("looking up %s: %w", id, err)
+				}
+			}
+		}()
+	}
+	for _, id := range todo {
+		ids <- id
+	}
+	close(ids)
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
+
+// lookup returns the issue, caching it for MRs that share a source issue.
+// A missing issue is nil.
+func (f *IssueFilter) lookup(id string) (*beads.Issue, error) {
+	f.mu.Lock()
+	issue, ok := f.issues[id]
+	f.mu.Unlock()
+	if ok {
+		return issue, nil
+	}
+	issue, err := f.show(id)
+	if errors.Is(err, beads.ErrNotFound) {
+		issue, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	f.issues[id] = issue
+	f.mu.Unlock()
+	return issue, nil
+}
+
+// matches is Match against an already-fetched issue, which may be nil.
+func (f *IssueFilter) matches(issue *beads.Issue, issueID, swarm string) bool {
+	if f.Epic != "" && swarm != f.Epic && issueID != f.Epic && !isChildOf(issue, f.Epic) {
+		return false
+	}
+	for _, l := range f.Labels {
+		if issue == nil || !slices.Contains(issue.Labels, l) {
+			return false
+		}
+	}
+	return true
+}
+
+// isChildOf reports whether issue sits under epic in the beads hierarchy.
+func isChildOf(issue *beads.Issue, epic string) bool {
+	if issue == nil {
+		return false
+	}
+	if issue.Parent == epic {
+		return true
+	}
+	for _, d := range issue.Dependencies {
+		if d.ID == epic && d.DependencyType == "parent-child" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package refinery
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+)
+
+func TestIssueFilterMatches(t *testing.T) {
+	child := &beads.Issue{ID: "gt-1", Parent: "gt-payments", Labels: []string{"needs-design"}}
+	linked := &beads.Issue{ID: "gt-2", Dependencies: []beads.IssueDep{{ID: "gt-payments", DependencyType: "parent-child"}}}
+	blocker := &beads.Issue{ID: "gt-3", Dependencies: []beads.IssueDep{{ID: "gt-payments", DependencyType: "blocks"}}}
+
+	tests := []struct {
+		name   string
+		filter *IssueFilter
+		issue  *beads.Issue
+		swarm  string
+		want   bool
+	}{
+		{"parent", &IssueFilter{Epic: "gt-payments"}, child, "", true},
+		{"parent-child dependency", &IssueFilter{Epic: "gt-payments"}, linked, "", true},
+		{"blocks is not membership", &IssueFilter{Epic: "gt-payments"}, blocker, "", false},
+		{"swarm", &IssueFilter{Epic: "gt-payments"}, nil, "gt-payments", true},
+		{"other epic", &IssueFilter{Epic: "gt-search"}, child, "", false},
+		{"label", &IssueFilter{Labels: []string{"needs-design"}}, child, "", true},
+		{"every label", &IssueFilter{Labels: []string{"needs-design", "urgent"}}, child, "", false},
+		{"label without issue", &IssueFilter{Labels: []string{"needs-design"}}, nil, "gt-payments", false},
+		{"epic and label", &IssueFilter{Epic: "gt-payments", Labels: []string{"needs-design"}}, child, "", true},
+	}
+	for _, tt := range tests {
+		issueID := ""
+		if tt.issue != nil {
+			issueID = tt.issue.ID
+		}
+		if got := tt.filter.matches(tt.issue, issueID, tt.swarm); got != tt.want {
+			t.Errorf("%s: matches = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIssueFilterPrefetch(t *testing.T) {
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	running, peak := 0, 0
+	f := &IssueFilter{
+		Epic: "gt-payments",
+		show: func(id string) (*beads.Issue, error) {
+			mu.Lock()
+			calls[id]++
+			running++
+			peak = max(peak, running)
+			mu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+			if id == "gt-gone" {
+				return nil, beads.ErrNotFound
+			}
+			return &beads.Issue{ID: id, Parent: "gt-payments"}, nil
+		},
+		issues: make(map[string]*beads.Issue),
+	}
+
+	var ids []string
+	for i := 0; i < 40; i++ {
+		ids = append(ids, fmt.Sprintf("gt-%d", i%20))
+	}
+	ids = append(ids, "", "gt-gone")
+	if err := f.Prefetch(ids); err != nil {
+		t.Fatalf("Prefetch: %v", err)
+	}
+	if len(calls) != 21 {
+		t.Errorf("looked up %d issues, want 21", len(calls))
+	}
+	for id, n := range calls {
+		if n != 1 {
+			t.Errorf("%s looked up %d times, want once", id, n)
+		}
+	}
+	if peak > lookupWorkers {
+		t.Errorf("%d lookups at once, want at most %d", peak, lookupWorkers)
+	}
+
+	// Matching uses the prefetched issues without looking them up again.
+	if ok, err := f.Match("gt-3", ""); err != nil || !ok {
+		t.Errorf("Match(gt-3) = %v, %v; want true", ok, err)
+	}
+	if ok, err := f.Match("gt-gone", ""); err != nil || ok {
+		t.Errorf("Match(gt-gone) = %v, %v; want false", ok, err)
+	}
+	if calls["gt-3"] != 1 || calls["gt-gone"] != 1 {
+		t.Errorf("Match looked issues up again: %v", calls)
+	}
+}
+
+func TestIssueFilterPrefetchError(t *testing.T) {
+	f := &IssueFilter{
+		Labels: []string{"urgent"},
+		show: func(id string) (*beads.Issue, error) {
+			return nil, errors.New("bd unavailable")
+		},
+		issues: make(map[string]*beads.Issue),
+	}
+	if err := f.Prefetch([]string{"gt-1", "gt-2"}); err == nil {
+		t.Fatal("Prefetch succeeded with failing lookups")
+	}
+}
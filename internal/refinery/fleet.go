@@ -0,0 +1,106 @@
+package refinery
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+// RigSummary is one entry in the GET /rigs listing.
+type RigSummary struct {
+	Name   string `json:"name"`
+	State  State  `json:"state"`
+	Queued int    `json:"queued"` // MRs in the queue, including held
+	Held   int    `json:"held"`
+	URL    string `json:"url"` // Base path of the rig's API and dashboard
+}
+
+// Fleet serves the refinery API for several rigs from one endpoint.
+// Each rig's Server is mounted under /rigs/{name}/, keeping its own routes,
+// dashboard, and tokens:
+//
+//	GET  /rigs                    rigs the caller can read, with state and queue size
+//	*    /rigs/{name}/...         that rig's API (e.g. /rigs/greenplace/api/queue)
+//
+// A rig appears in the listing if its reads are open or the request's token
+// could read it.
+type Fleet struct {
+	names   []string
+	servers map[string]*Server
+	mux     *http.ServeMux
+}
+
+// NewFleet creates a multi-rig API server.
+func NewFleet(rigs []*rig.Rig) *Fleet {
+	f := &Fleet{
+		servers: make(map[string]*Server, len(rigs)),
+		mux:     http.NewServeMux(),
+	}
+	for _, r := range rigs {
+		s := NewServer(r)
+		f.names = append(f.names, r.Name)
+		f.servers[r.Name] = s
+		prefix := "/rigs/" + r.Name
+		f.mux.Handle(prefix+"/", http.StripPrefix(prefix, s))
+		f.mux.Handle(prefix, http.RedirectHandler(prefix+"/", http.StatusMovedPermanently))
+	}
+	sort.Strings(f.names)
+
+	f.mux.HandleFunc("GET /rigs", f.handleList)
+	f.mux.Handle("GET /{$}", http.RedirectHandler("/rigs", http.StatusFound))
+	f.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		name, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/rigs/"), "/")
+		writeError(w, http.StatusNotFound, fmt.Errorf("no rig %q on this server", name))
+	})
+	return f
+}
+
+// Rigs returns the names of the rigs served, sorted.
+func (f *Fleet) Rigs() []string {
+	return f.names
+}
+
+// ServeHTTP implements http.Handler.
+func (f *Fleet) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mux.ServeHTTP(w, r)
+}
+
+func (f *Fleet) handleList(w http.ResponseWriter, r *http.Request) {
+	rigs := []RigSummary{}
+	for _, name := range f.names {
+		s := f.servers[name]
+		if _, err := s.checkToken(r, ScopeRead); err != nil && !s.tokens.Empty() {
+			continue // Open rigs are listed even for a caller holding another rig's token
+		}
+		summary, err := s.summary()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Errorf("rig %s: %w", name, err))
+			return
+		}
+		rigs = append(rigs, summary)
+	}
+	writeJSON(w, http.StatusOK, rigs)
+}
+
+// summary reports the rig's state and queue size for the fleet listing.
+func (s *Server) summary() (RigSummary, error) {
+	ref, err := s.mgr.Status()
+	if err != nil {
+		return RigSummary{}, err
+	}
+	mrs, err := s.queue.List()
+	if err != nil {
+		return RigSummary{}, err
+	}
+
+	sum := RigSummary{Name: s.rig.Name, State: ref.State, Queued: len(mrs), URL: "/rigs/" + s.rig.Name + "/"}
+	for _, mr := range mrs {
+		if mr.IsHeld() {
+			sum.Held++
+		}
+	}
+	return sum, nil
+}
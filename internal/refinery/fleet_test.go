@@ -0,0 +1,85 @@
+package refinery
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func newTestFleet(t *testing.T, names ...string) *Fleet {
+	t.Helper()
+	tmpDir := t.TempDir()
+	var rigs []*rig.Rig
+	for _, name := range names {
+		rigPath := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Join(rigPath, ".runtime"), 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		rigs = append(rigs, &rig.Rig{Name: name, Path: rigPath})
+	}
+	return NewFleet(rigs)
+}
+
+func TestFleet_RoutesByRig(t *testing.T) {
+	f := newTestFleet(t, "beta", "alpha")
+	tok := newToken(t, f.servers["alpha"], "ops", ScopeOperate)
+
+	w := doRequest(t, f, tok, "POST", "/rigs/alpha/api/queue", `{"branch":"polecat/nux/gt-1","target":"main"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("enqueue on alpha = %d: %s", w.Code, w.Body)
+	}
+
+	// The token belongs to alpha; beta rejects it.
+	if w := doRequest(t, f, tok, "POST", "/rigs/beta/api/queue", `{"branch":"b"}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("enqueue on beta with alpha token = %d, want 401", w.Code)
+	}
+
+	w = doRequest(t, f, "", "GET", "/rigs/beta/api/queue", "")
+	var queue []json.RawMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &queue); err != nil || len(queue) != 0 {
+		t.Errorf("beta queue = %s, want empty", w.Body)
+	}
+
+	if w := doRequest(t, f, "", "GET", "/rigs/gamma/api/queue", ""); w.Code != http.StatusNotFound {
+		t.Errorf("unknown rig = %d, want 404", w.Code)
+	}
+	if w := doRequest(t, f, "", "GET", "/rigs/beta", ""); w.Code != http.StatusMovedPermanently {
+		t.Errorf("/rigs/beta = %d, want redirect", w.Code)
+	}
+}
+
+func TestFleet_List(t *testing.T) {
+	f := newTestFleet(t, "beta", "alpha")
+	tok := newToken(t, f.servers["alpha"], "ops", ScopeOperate)
+	doRequest(t, f, tok, "POST", "/rigs/alpha/api/queue", `{"branch":"b1","target":"main"}`)
+
+	list := func(token string) []RigSummary {
+		t.Helper()
+		w := doRequest(t, f, token, "GET", "/rigs", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET /rigs = %d: %s", w.Code, w.Body)
+		}
+		var rigs []RigSummary
+		if err := json.Unmarshal(w.Body.Bytes(), &rigs); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return rigs
+	}
+
+	// Anonymous callers only see rigs whose reads are still open.
+	if rigs := list(""); len(rigs) != 1 || rigs[0].Name != "beta" {
+		t.Errorf("anonymous list = %+v, want only beta", rigs)
+	}
+
+	rigs := list(tok)
+	if len(rigs) != 2 || rigs[0].Name != "alpha" || rigs[1].Name != "beta" {
+		t.Fatalf("list = %+v, want alpha and beta", rigs)
+	}
+	if rigs[0].Queued != 1 || rigs[0].URL != "/rigs/alpha/" || rigs[0].State != StateStopped {
+		t.Errorf("alpha = %+v", rigs[0])
+	}
+}
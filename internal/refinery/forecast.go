@@ -0,0 +1,232 @@
+package refinery
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// A forecast projects from merge history how long the queue as it stands
+// will take to clear. Each queued MR is expected to take a merge attempt
+// of the rig's mean length, plus the failed attempts its worker's failure
+// rate predicts before one lands. MRs keep arriving at the rate they did
+// over the history window, which eats into each lane's capacity.
+
+// Forecast tuning.
+const (
+	// DefaultForecastHistory is how far back a forecast looks by default.
+	DefaultForecastHistory = 14 * 24 * time.Hour
+
+	// forecastWorkerAttempts is how many attempts a worker needs in the
+	// window before its own failure rate is used over the rig's.
+	forecastWorkerAttempts = 5
+
+	// forecastMaxFailure caps the failure rate retries are predicted
+	// from, which would otherwise predict endless retries near 1.
+	forecastMaxFailure = 0.9
+)
+
+// Forecast is a projection of when a rig's queue will clear.
+type Forecast struct {
+	At    time.Time `json:"at"`
+	Since time.Time `json:"since"` // Start of the history it is drawn from
+
+	// From the history: merge attempts, the share that failed, how long
+	// merged and failed attempts took on average, and how many new MRs
+	// were attempted per hour.
+	Attempts        int           `json:"attempts"`
+	FailureRate     float64       `json:"failure_rate"`
+	MeanMerge       time.Duration `json:"mean_merge"`
+	MeanFailure     time.Duration `json:"mean_failure"`
+	ArrivalsPerHour float64       `json:"arrivals_per_hour"`
+
+	// Queued counts MRs the forecast expects to merge; Held counts those
+	// on hold or waiting for approval, which it leaves out.
+	Queued int `json:"queued"`
+	Held   int `json:"held"`
+
+	// Work is the merge time the queued MRs are expected to need,
+	// retries included.
+	Work time.Duration `json:"work"`
+
+	// Lanes are the queue's lanes, which merge side by side: one per
+	// target branch with [refinery] lanes set, else one for the rig.
+	Lanes []LaneForecast `json:"lanes"`
+
+	// ClearsIn is how long until the last lane clears; Clears is false if
+	// arrivals outpace some lane, which then never clears.
+	Clears   bool          `json:"clears"`
+	ClearsIn time.Duration `json:"clears_in,omitempty"`
+
+	// Target is the clearing time asked for, and SlotsNeeded how many
+	// merges would have to run side by side, with arrivals continuing, to
+	// clear the queue within it.
+	Target      time.Duration `json:"target,omitempty"`
+	SlotsNeeded int           `json:"slots_needed,omitempty"`
+}
+
+// LaneForecast is one lane's share of a Forecast.
+type LaneForecast struct {
+	Target          string        `json:"target"` // "" for the rig's one lane without lanes
+	Queued          int           `json:"queued"`
+	Work            time.Duration `json:"work"`
+	ArrivalsPerHour float64       `json:"arrivals_per_hour"`
+	Clears          bool          `json:"clears"`
+	ClearsIn        time.Duration `json:"clears_in,omitempty"`
+}
+
+// ComputeForecast projects from the events since since how long queue
+// will take to clear as of now. With lanes, each target branch merges
+// side by side; without, the rig merges one MR at a time. A target above
+// zero also works out how many merges at once would clear it in time.
+func ComputeForecast(events []mrqueue.Event, queue []*mrqueue.MR, since, now time.Time, lanes bool, target time.Duration) (*Forecast, error) {
+	f := &Forecast{At: now, Since: since, Target: target}
+
+	type tally struct{ attempts, failed int }
+	byWorker := make(map[string]*tally)
+	arrivals := make(map[string]int) // By lane
+	seen := make(map[string]bool)
+	started := make(map[string]mrqueue.Event)
+	var merged, failed int
+	var mergeTime, failTime time.Duration
+	for _, ev := range events {
+		if ev.Timestamp.Before(since) || ev.Timestamp.After(now) {
+			continue
+		}
+		switch ev.Type {
+		case mrqueue.EventMergeStarted:
+			started[ev.MRID] = ev
+			if !seen[ev.MRID] {
+				seen[ev.MRID] = true
+				arrivals[forecastLane(ev.Target, lanes)]++
+			}
+			continue
+		case mrqueue.EventMerged, mrqueue.EventMergeFailed:
+		default:
+			continue
+		}
+		begin, ok := started[ev.MRID]
+		if !ok {
+			continue
+		}
+		delete(started, ev.MRID)
+		took := ev.Timestamp.Sub(begin.Timestamp)
+		w := byWorker[ev.Worker]
+		if w == nil {
+			w = &tally{}
+			byWorker[ev.Worker] = w
+		}
+		w.attempts++
+		if ev.Type == mrqueue.EventMerged {
+			merged++
+			mergeTime += took
+		} else {
+			failed++
+			w.failed++
+			failTime += took
+		}
+	}
+	f.Attempts = merged + failed
+	if merged == 0 {
+		return nil, fmt.Errorf("no merges since %s to forecast from", since.Local().Format("2006-01-02 15:04"))
+	}
+	f.FailureRate = float64(failed) / float64(f.Attempts)
+	f.MeanMerge = mergeTime / time.Duration(merged)
+	f.MeanFailure = f.MeanMerge
+	if failed > 0 {
+		f.MeanFailure = failTime / time.Duration(failed)
+	}
+	hours := now.Sub(since).Hours()
+	if hours > 0 {
+		f.ArrivalsPerHour = float64(len(seen)) / hours
+	}
+
+	// work is the expected merge time for an MR whose attempts fail at
+	// rate: one that lands, and rate/(1-rate) that fail before it.
+	work := func(rate float64) time.Duration {
+		rate = min(rate, forecastMaxFailure)
+		return f.MeanMerge + time.Duration(rate/(1-rate)*float64(f.MeanFailure))
+	}
+	meanWork := work(f.FailureRate)
+
+	byLane := make(map[string]*LaneForecast)
+	for _, mr := range queue {
+		if mr.IsHeld() || mr.NeedsApproval() {
+			f.Held++
+			continue
+		}
+		rate := f.FailureRate
+		if w := byWorker[mr.Worker]; w != nil && w.attempts >= forecastWorkerAttempts {
+			rate = float64(w.failed) / float64(w.attempts)
+		}
+		key := forecastLane(mr.Lane(), lanes)
+		l := byLane[key]
+		if l == nil {
+			l = &LaneForecast{Target: key}
+			byLane[key] = l
+		}
+		l.Queued++
+		l.Work += work(rate)
+		f.Queued++
+		f.Work += work(rate)
+	}
+
+	// A lane busy with arrivals for a share of each hour clears its
+	// backlog only in the rest.
+	f.Clears = true
+	for key, l := range byLane {
+		if hours > 0 {
+			l.ArrivalsPerHour = float64(arrivals[key]) / hours
+		}
+		if spare := 1 - l.ArrivalsPerHour*meanWork.Hours(); spare > 0 {
+			l.Clears = true
+			l.ClearsIn = time.Duration(float64(l.Work) / spare)
+			f.ClearsIn = max(f.ClearsIn, l.ClearsIn)
+		} else {
+			f.Clears = false
+		}
+		f.Lanes = append(f.Lanes, *l)
+	}
+	if !f.Clears {
+		f.ClearsIn = 0
+	}
+	sort.Slice(f.Lanes, func(i, j int) bool { return f.Lanes[i].Target < f.Lanes[j].Target })
+
+	// Merges at once to clear the work in target while keeping up with
+	// arrivals: work/target for the backlog, plus arrivals' share.
+	if target > 0 && f.Queued > 0 {
+		slots := f.Work.Hours()/target.Hours() + f.ArrivalsPerHour*meanWork.Hours()
+		f.SlotsNeeded = max(int(math.Ceil(slots)), 1)
+	}
+	return f, nil
+}
+
+// forecastLane is the lane an MR for target merges in: its target with
+// lanes, the rig's single lane without.
+func forecastLane(target string, lanes bool) string {
+	if lanes {
+		return target
+	}
+	return ""
+}
+
+// Forecast projects when the rig's queue will clear from its merge
+// history over the last history; see ComputeForecast.
+func (e *Engineer) Forecast(history, target time.Duration) (*Forecast, error) {
+	if history <= 0 {
+		history = DefaultForecastHistory
+	}
+	events, err := e.eventLogger.ReadEvents(0)
+	if err != nil {
+		return nil, fmt.Errorf("reading merge events: %w", err)
+	}
+	queue, err := e.mrQueue.List()
+	if err != nil {
+		return nil, fmt.Errorf("listing queue: %w", err)
+	}
+	now := e.clock()
+	return ComputeForecast(events, queue, now.Add(-history), now, e.lanesEnabled(), target)
+}
@@ -0,0 +1,82 @@
+package refinery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+func TestComputeForecast(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.Local)
+	var events []mrqueue.Event
+	attempt := func(id, worker string, hoursAgo int, outcome mrqueue.EventType) {
+		end := now.Add(time.Duration(-hoursAgo) * time.Hour)
+		events = append(events,
+			mrqueue.Event{Timestamp: end.Add(-10 * time.Minute), Type: mrqueue.EventMergeStarted, MRID: id, Worker: worker, Target: "main"},
+			mrqueue.Event{Timestamp: end, Type: outcome, MRID: id, Worker: worker, Target: "main"})
+	}
+	attempt("old", "nux", 48, mrqueue.EventMergeFailed) // Outside the history
+	for i, id := range []string{"n1", "n2", "n3", "n4", "n5"} {
+		attempt(id, "nux", i+1, mrqueue.EventMerged)
+		attempt("s"+id, "slit", i+1, mrqueue.EventMergeFailed)
+	}
+	queue := []*mrqueue.MR{
+		{ID: "q1", Worker: "nux", Target: "main"},              // Never fails: 10m
+		{ID: "q2", Worker: "slit", Target: "integration/auth"}, // Always fails, capped at 0.9: 100m
+		{ID: "q3", Worker: "furiosa", Target: "main"},          // Too new, so the rig's 0.5: 20m
+		{ID: "q4", Worker: "nux", Target: "main", HeldReason: "demo"},
+	}
+
+	f, err := ComputeForecast(events, queue, now.Add(-10*time.Hour), now, false, 65*time.Minute)
+	if err != nil {
+		t.Fatalf("ComputeForecast: %v", err)
+	}
+	if f.Attempts != 10 || f.FailureRate != 0.5 || f.MeanMerge != 10*time.Minute || f.ArrivalsPerHour != 1 {
+		t.Errorf("history = %d attempts, %v failed, %s, %v/h; want 10, 0.5, 10m, 1/h",
+			f.Attempts, f.FailureRate, f.MeanMerge, f.ArrivalsPerHour)
+	}
+	if f.Queued != 3 || f.Held != 1 || f.Work != 130*time.Minute {
+		t.Errorf("queue = %d (%d held), %s of work; want 3 (1 held), 130m", f.Queued, f.Held, f.Work)
+	}
+	// Arrivals take 20m of each hour, so 130m takes 195m
+	if !f.Clears || f.ClearsIn.Round(time.Second) != 195*time.Minute || len(f.Lanes) != 1 {
+		t.Errorf("clears = %v in %s over %d lanes, want 195m in one", f.Clears, f.ClearsIn, len(f.Lanes))
+	}
+	// 130m/65m, plus a third of a merge keeping up with arrivals
+	if f.SlotsNeeded != 3 {
+		t.Errorf("SlotsNeeded = %d, want 3", f.SlotsNeeded)
+	}
+
+	// With lanes, integration/auth runs beside main and has no arrivals
+	f, err = ComputeForecast(events, queue, now.Add(-10*time.Hour), now, true, 0)
+	if err != nil {
+		t.Fatalf("ComputeForecast: %v", err)
+	}
+	if len(f.Lanes) != 2 || f.Lanes[0].Target != "integration/auth" || f.Lanes[1].ClearsIn.Round(time.Second) != 45*time.Minute {
+		t.Errorf("lanes = %+v, want integration/auth then main clearing in 45m", f.Lanes)
+	}
+	if f.ClearsIn != 100*time.Minute || f.SlotsNeeded != 0 {
+		t.Errorf("clears in %s, %d slots; want 100m and no target", f.ClearsIn, f.SlotsNeeded)
+	}
+
+	// Seven MRs in the last hour at 10m each: more than an hour holds
+	var busy []mrqueue.Event
+	for i := range 7 {
+		id := string(rune('a' + i))
+		busy = append(busy,
+			mrqueue.Event{Timestamp: now.Add(-20 * time.Minute), Type: mrqueue.EventMergeStarted, MRID: id, Worker: "nux", Target: "main"},
+			mrqueue.Event{Timestamp: now.Add(-10 * time.Minute), Type: mrqueue.EventMerged, MRID: id, Worker: "nux", Target: "main"})
+	}
+	f, err = ComputeForecast(busy, queue, now.Add(-time.Hour), now, false, 0)
+	if err != nil {
+		t.Fatalf("ComputeForecast: %v", err)
+	}
+	if f.Clears || f.Lanes[0].Clears || f.ClearsIn != 0 {
+		t.Errorf("forecast = %+v, want a queue that falls behind", f)
+	}
+
+	if _, err := ComputeForecast(events[:2], queue, now.Add(-10*time.Hour), now, false, 0); err == nil {
+		t.Error("ComputeForecast without merges succeeded")
+	}
+}
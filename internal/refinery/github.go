@@ -0,0 +1,307 @@
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// With [refinery.github_queue], GitHub's native merge queue does the
+// merging. The refinery still runs its gates, conflict check, checks, and
+// review against the target, then pushes the branch, opens a pull request
+// for it if there is none, and enqueues that pull request at the commit it
+// validated. SyncGitHubQueue later finishes each MR as GitHub reports its
+// pull request merged, or fails it if GitHub dropped it from the queue.
+
+// githubQueue talks to the GitHub GraphQL API for one repository.
+type githubQueue struct {
+	cfg   *config.GitHubQueueConfig
+	token string
+	http  *http.Client
+}
+
+func newGitHubQueue(cfg *config.GitHubQueueConfig, rigPath string) (*githubQueue, error) {
+	token, err := cfg.ResolveToken(rigPath)
+	if err != nil {
+		return nil, err
+	}
+	return &githubQueue{cfg: cfg, token: token, http: &http.Client{Timeout: 30 * time.Second}}, nil
+}
+
+// graphqlURL returns the GraphQL endpoint beside the REST API base: /graphql
+// on api.github.com, /api/graphql on GitHub Enterprise Server.
+func (g *githubQueue) graphqlURL() string {
+	api := g.cfg.APIURL()
+	if base, ok := strings.CutSuffix(api, "/api/v3"); ok {
+		return base + "/api/graphql"
+	}
+	return api + "/graphql"
+}
+
+// graphql runs query with vars and decodes its data into out.
+func (g *githubQueue) graphql(ctx context.Context, query string, vars map[string]interface{}, out interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": vars})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.graphqlURL(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+g.token)
+
+	resp, err := g.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("contacting GitHub: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("GitHub: %s", resp.Status)
+	}
+
+	var reply struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return fmt.Errorf("decoding GitHub response: %w", err)
+	}
+	if len(reply.Errors) > 0 {
+		return fmt.Errorf("GitHub: %s", reply.Errors[0].Message)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(reply.Data, out)
+}
+
+// pullRequest is an open pull request.
+type pullRequest struct {
+	ID     string `json:"id"`
+	Number int    `json:"number"`
+	URL    string `json:"url"`
+}
+
+const findPullRequestQuery = `query($owner: String!, $name: String!, $head: String!, $base: String!) {
+  repository(owner: $owner, name: $name) {
+    id
+    pullRequests(headRefName: $head, baseRefName: $base, states: OPEN, first: 1) {
+      nodes { id number url }
+    }
+  }
+}`
+
+const createPullRequestMutation = `mutation($repo: ID!, $head: String!, $base: String!, $title: String!, $body: String!) {
+  createPullRequest(input: {repositoryId: $repo, headRefName: $head, baseRefName: $base, title: $title, body: $body}) {
+    pullRequest { id number url }
+  }
+}`
+
+// pullRequestFor returns the open pull request of mr's branch into its
+// target, opening one if there is none.
+func (g *githubQueue) pullRequestFor(ctx context.Context, mr *mrqueue.MR) (*pullRequest, error) {
+	var found struct {
+		Repository struct {
+			ID           string `json:"id"`
+			PullRequests struct {
+				Nodes []pullRequest `json:"nodes"`
+			} `json:"pullRequests"`
+		} `json:"repository"`
+	}
+	vars := map[string]interface{}{"owner": g.cfg.Owner(), "name": g.cfg.Name(), "head": mr.Branch, "base": mr.Target}
+	if err := g.graphql(ctx, findPullRequestQuery, vars, &found); err != nil {
+		return nil, fmt.Errorf("finding pull request for %s: %w", mr.Branch, err)
+	}
+	if nodes := found.Repository.PullRequests.Nodes; len(nodes) > 0 {
+		return &nodes[0], nil
+	}
+
+	title := mr.Title
+	if title == "" {
+		title = fmt.Sprintf("Merge %s into %s", mr.Branch, mr.Target)
+	}
+	body := fmt.Sprintf("Queued by the %s refinery as MR %s.", mr.Rig, mr.ID)
+	if mr.SourceIssue != "" {
+		body += fmt.Sprintf(" Source issue: %s.", mr.SourceIssue)
+	}
+	var created struct {
+		CreatePullRequest struct {
+			PullRequest pullRequest `json:"pullRequest"`
+		} `json:"createPullRequest"`
+	}
+	vars = map[string]interface{}{"repo": found.Repository.ID, "head": mr.Branch, "base": mr.Target, "title": title, "body": body}
+	if err := g.graphql(ctx, createPullRequestMutation, vars, &created); err != nil {
+		return nil, fmt.Errorf("opening pull request for %s: %w", mr.Branch, err)
+	}
+	return &created.CreatePullRequest.PullRequest, nil
+}
+
+// enqueuePullRequestMutation passes expectedHeadOid so GitHub refuses the
+// pull request if its branch moved after the refinery validated it.
+const enqueuePullRequestMutation = `mutation($id: ID!, $head: GitObjectID!) {
+  enqueuePullRequest(input: {pullRequestId: $id, expectedHeadOid: $head}) {
+    mergeQueueEntry { position }
+  }
+}`
+
+// enqueue adds pr to the merge queue, provided its head is still head.
+func (g *githubQueue) enqueue(ctx context.Context, pr *pullRequest, head string) error {
+	if err := g.graphql(ctx, enqueuePullRequestMutation, map[string]interface{}{"id": pr.ID, "head": head}, nil); err != nil {
+		return fmt.Errorf("adding #%d to the merge queue: %w", pr.Number, err)
+	}
+	return nil
+}
+
+// pullRequestStatus is where a handed-off pull request stands.
+type pullRequestStatus struct {
+	State       string `json:"state"` // OPEN, CLOSED, or MERGED
+	Merged      bool   `json:"merged"`
+	MergeCommit *struct {
+		OID string `json:"oid"`
+	} `json:"mergeCommit"`
+	MergeQueueEntry *struct {
+		State string `json:"state"`
+	} `json:"mergeQueueEntry"`
+}
+
+// queued reports whether the pull request is still in the merge queue.
+func (s *pullRequestStatus) queued() bool {
+	return !s.Merged && s.State == "OPEN" && s.MergeQueueEntry != nil
+}
+
+const pullRequestStatusQuery = `query($owner: String!, $name: String!, $number: Int!) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      state merged
+      mergeCommit { oid }
+      mergeQueueEntry { state }
+    }
+  }
+}`
+
+// status returns where pull request number stands.
+func (g *githubQueue) status(ctx context.Context, number int) (*pullRequestStatus, error) {
+	var reply struct {
+		Repository struct {
+			PullRequest *pullRequestStatus `json:"pullRequest"`
+		} `json:"repository"`
+	}
+	vars := map[string]interface{}{"owner": g.cfg.Owner(), "name": g.cfg.Name(), "number": number}
+	if err := g.graphql(ctx, pullRequestStatusQuery, vars, &reply); err != nil {
+		return nil, fmt.Errorf("checking #%d: %w", number, err)
+	}
+	if reply.Repository.PullRequest == nil {
+		return nil, fmt.Errorf("checking #%d: no such pull request", number)
+	}
+	return reply.Repository.PullRequest, nil
+}
+
+// handOff hands mr, validated against its target, to GitHub's merge queue.
+func (e *Engineer) handOff(ctx context.Context, mr *mrqueue.MR) ProcessResult {
+	gh, err := newGitHubQueue(e.settings.GitHubQueue, e.rig.Path)
+	if err != nil {
+		return ProcessResult{Success: false, Error: err.Error()}
+	}
+	head, err := e.git.Rev(mr.Branch)
+	if err != nil {
+		return ProcessResult{Success: false, Error: fmt.Sprintf("failed to resolve %s: %v", mr.Branch, err)}
+	}
+
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Pushing %s for GitHub's merge queue...\n", mr.Branch)
+	if err := e.git.Push("origin", mr.Branch, false); err != nil {
+		return ProcessResult{Success: false, Error: fmt.Sprintf("failed to push %s: %v", mr.Branch, err)}
+	}
+	pr, err := gh.pullRequestFor(ctx, mr)
+	if err != nil {
+		return ProcessResult{Success: false, Error: err.Error()}
+	}
+	if err := gh.enqueue(ctx, pr, head); err != nil {
+		return ProcessResult{Success: false, Error: err.Error()}
+	}
+	if err := e.mrQueue.HandOff(mr.ID, pr.Number, pr.URL); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to record hand-off of %s: %v\n", mr.ID, err)
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Handed %s to GitHub's merge queue as #%d (%s)\n", mr.ID, pr.Number, pr.URL)
+	return ProcessResult{HandedOff: true, PullRequest: pr.URL}
+}
+
+// GitHubSync counts what SyncGitHubQueue found.
+type GitHubSync struct {
+	Merged  int // Finished as merged
+	Dropped int // Failed: GitHub closed the pull request or left it unqueued
+	Waiting int // Still in GitHub's merge queue
+}
+
+// SyncGitHubQueue asks GitHub about each MR handed to its merge queue. An
+// MR whose pull request merged is finished as if the refinery had merged
+// it; one that GitHub dropped from the queue, typically because its
+// checks failed there, is returned to the refinery as a failure.
+func (e *Engineer) SyncGitHubQueue(ctx context.Context) (GitHubSync, error) {
+	var out GitHubSync
+	if e.settings == nil || e.settings.GitHubQueue == nil {
+		return out, fmt.Errorf("[refinery.github_queue] is not configured")
+	}
+	handed, err := e.mrQueue.ListHandedOff()
+	if err != nil || len(handed) == 0 {
+		return out, err
+	}
+	gh, err := newGitHubQueue(e.settings.GitHubQueue, e.rig.Path)
+	if err != nil {
+		return out, err
+	}
+
+	for _, mr := range handed {
+		st, err := gh.status(ctx, mr.PullRequest)
+		if err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: %s: %v\n", mr.ID, err)
+			out.Waiting++
+			continue
+		}
+		if st.queued() {
+			out.Waiting++
+			continue
+		}
+		number := mr.PullRequest
+		if err := e.mrQueue.TakeBack(mr.ID); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to take back %s: %v\n", mr.ID, err)
+			continue
+		}
+		mr.PullRequest, mr.PullRequestURL, mr.HandedOffAt = 0, "", nil
+
+		if !st.Merged || st.MergeCommit == nil {
+			out.Dropped++
+			e.handleFailureFromQueue(mr, ProcessResult{
+				Success: false,
+				Error:   fmt.Sprintf("GitHub dropped #%d from its merge queue (%s)", number, strings.ToLower(st.State)),
+			})
+			continue
+		}
+		out.Merged++
+		e.handleSuccessFromQueue(mr, e.githubMerged(mr, st.MergeCommit.OID))
+	}
+	return out, nil
+}
+
+// githubMerged describes GitHub's merge of mr as mergeCommit, measured
+// against its first parent, the target before the merge.
+func (e *Engineer) githubMerged(mr *mrqueue.MR, mergeCommit string) ProcessResult {
+	result := ProcessResult{Success: true, MergeCommit: mergeCommit}
+	if err := e.git.FetchRefs("origin", mr.Target); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: fetch origin/%s: %v\n", mr.Target, err)
+	}
+	if before, err := e.git.Rev(mergeCommit + "^"); err == nil {
+		result.TargetBefore = before
+		result.FilesChanged, result.LinesChanged, _ = e.git.DiffStat(before, mergeCommit)
+	}
+	return result
+}
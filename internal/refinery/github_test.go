@@ -0,0 +1,107 @@
+package refinery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// fakeGitHub serves the GraphQL calls the refinery makes for one repo.
+type fakeGitHub struct {
+	mu       sync.Mutex
+	opened   []string          // Head branches pull requests were opened for
+	enqueued map[string]string // Pull request ID -> expected head
+	status   map[int]string    // Pull request number -> JSON status
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var req struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+	if r.Header.Get("Authorization") != "Bearer gh-token" || json.NewDecoder(r.Body).Decode(&req) != nil {
+		http.Error(w, "bad request", http.StatusUnauthorized)
+		return
+	}
+	var data string
+	switch {
+	case strings.Contains(req.Query, "createPullRequest"):
+		f.opened = append(f.opened, req.Variables["head"].(string))
+		n := len(f.opened)
+		data = fmt.Sprintf(`{"createPullRequest": {"pullRequest": {"id": "PR_%d", "number": %d, "url": "https://github.com/example/greenplace/pull/%d"}}}`, n, n, n)
+	case strings.Contains(req.Query, "enqueuePullRequest"):
+		f.enqueued[req.Variables["id"].(string)] = req.Variables["head"].(string)
+		data = `{"enqueuePullRequest": {"mergeQueueEntry": {"position": 1}}}`
+	case strings.Contains(req.Query, "pullRequests("):
+		data = `{"repository": {"id": "R_1", "pullRequests": {"nodes": []}}}`
+	case strings.Contains(req.Query, "pullRequest("):
+		data = `{"repository": {"pullRequest": ` + f.status[int(req.Variables["number"].(float64))] + `}}`
+	}
+	_, _ = w.Write([]byte(`{"data": ` + data + `}`))
+}
+
+func TestGitHubQueue(t *testing.T) {
+	gh := &fakeGitHub{enqueued: map[string]string{}, status: map[int]string{}}
+	srv := httptest.NewServer(gh)
+	defer srv.Close()
+	t.Setenv("GITHUB_TOKEN", "gh-token")
+
+	e, repo := newFakeEngineer(t)
+	e.settings.GitHubQueue = &config.GitHubQueueConfig{Repo: "example/greenplace", API: srv.URL}
+	nux := queueBranch(t, e, repo, "nux", map[string]string{"nux.txt": "nux\n"})
+	slit := queueBranch(t, e, repo, "slit", map[string]string{"slit.txt": "slit\n"})
+	base := repo.Origin("main")
+
+	for _, mr := range []string{nux.ID, slit.ID} {
+		queued, err := e.mrQueue.Get(mr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res := e.doMerge(context.Background(), queued)
+		if !res.HandedOff || res.Success {
+			t.Fatalf("doMerge(%s) = %+v, want it handed off", queued.Branch, res)
+		}
+	}
+	if repo.Origin("main") != base {
+		t.Error("the refinery pushed to main itself")
+	}
+	if head := repo.Origin(nux.Branch); head == "" || gh.enqueued["PR_1"] != head {
+		t.Errorf("enqueued %v, want PR_1 at the pushed head %s", gh.enqueued, head)
+	}
+	if ready, _ := e.mrQueue.ListReady(nil); len(ready) != 0 {
+		t.Errorf("ready = %v, want handed-off MRs left to GitHub", mrIDs(ready))
+	}
+
+	// GitHub merges nux and drops slit, whose checks failed there.
+	merge := repo.Commit("main", "Merge pull request #1", map[string]string{"nux.txt": "nux\n"})
+	repo.Publish("main")
+	gh.status[1] = `{"state": "MERGED", "merged": true, "mergeCommit": {"oid": "` + merge + `"}, "mergeQueueEntry": null}`
+	gh.status[2] = `{"state": "OPEN", "merged": false, "mergeCommit": null, "mergeQueueEntry": null}`
+
+	got, err := e.SyncGitHubQueue(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != (GitHubSync{Merged: 1, Dropped: 1}) {
+		t.Errorf("SyncGitHubQueue = %+v, want one merged and one dropped", got)
+	}
+	if _, err := e.mrQueue.Get(nux.ID); err == nil {
+		t.Error("merged MR still queued")
+	}
+	back, err := e.mrQueue.Get(slit.ID)
+	if err != nil || back.IsHandedOff() {
+		t.Errorf("dropped MR = %+v, %v; want it back with the refinery", back, err)
+	}
+	if out := e.output.(*strings.Builder).String(); !strings.Contains(out, "GitHub dropped #2") {
+		t.Errorf("output does not report the dropped pull request:\n%s", out)
+	}
+}
@@ -0,0 +1,75 @@
+package refinery
+
+import (
+	"github.com/steveyegge/gastown/internal/git"
+)
+
+// GitRunner is the git an Engineer drives: *git.Git in production, or an
+// in-memory gitfake.Repo worktree in unit tests of the scheduler, queue,
+// and merge logic. The conflict resolver, which hands a real worktree to
+// an agent, and the bare mirror always use git itself.
+type GitRunner interface {
+	// Refs and history
+	Rev(ref string) (string, error)
+	CurrentBranch() (string, error)
+	MergeBase(a, b string) (string, error)
+	IsAncestor(ancestor, descendant string) (bool, error)
+	CommitSubjects(base, branch string) ([]string, error)
+	CommitIdentities(base, branch string) ([]git.CommitIdentity, error)
+	CommitSignatures(base, branch, allowedSigners string) ([]git.CommitSignature, error)
+	Trailers(base, branch, key string) ([]string, error)
+
+	// Trees and diffs
+	ChangedFiles(base, branch string) ([]string, error)
+	Diff(base, branch string) (string, error)
+	DiffStat(from, to string) (files, lines int, err error)
+	AddedLines(base, branch string) ([]git.AddedLine, error)
+	ShowFile(ref, path string) (string, error)
+	ListFiles(ref string) ([]string, error)
+
+	// Branches
+	BranchExists(name string) (bool, error)
+	CreateBranchFrom(name, ref string) error
+	DeleteBranch(name string, force bool) error
+	UpdateBranchRef(name, sha string) error
+
+	// Checking out and merging
+	Checkout(ref string) error
+	Merge(branch string) error
+	MergeNoFF(branch, message string) error
+	MergeSquash(branch, message string) error
+	MergeFFOnly(branch string) error
+	AbortMerge() error
+	RebaseOnto(onto, upstream string) error
+	AbortRebase() error
+	CherryPickRange(base, head, message string) error
+	AmendFile(path, content string) error
+	Revert(commit string) error
+	CheckConflicts(source, target string) ([]string, error)
+
+	// Origin
+	FetchRefs(remote string, branches ...string) error
+	Push(remote, branch string, force bool) error
+	PushHead(remote, branch string) error
+	RemoteBranchExists(remote, branch string) (bool, error)
+	DeleteRemoteBranch(remote, branch string) error
+
+	// Tags
+	CreateTag(name, ref, message string) error
+	PushTag(remote, name string) error
+
+	// Worktrees
+	WorktreeAddDetached(path, ref string) error
+	WorktreeRemove(path string, force bool) error
+	WorktreePrune() error
+	CloneBare(url, dest string) error
+}
+
+var _ GitRunner = (*git.Git)(nil)
+
+// SetGit replaces the engineer's git with g, and at with how it opens the
+// worktrees it creates for lanes, pipelined checks, and conflict scans.
+func (e *Engineer) SetGit(g GitRunner, at func(dir string) GitRunner) {
+	e.git = g
+	e.gitAt = at
+}
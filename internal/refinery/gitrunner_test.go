@@ -0,0 +1,152 @@
+package refinery
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/git/gitfake"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// newFakeEngineer returns an engineer on an in-memory repository with main
+// published to origin. Only the queue and runtime state touch the disk.
+func newFakeEngineer(t *testing.T) (*Engineer, *gitfake.Repo) {
+	t.Helper()
+	repo := gitfake.New()
+	repo.Commit("main", "base", map[string]string{"README": "base\n", "shared.txt": "one\ntwo\n"})
+	repo.Publish("main")
+
+	rigPath := t.TempDir()
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: rigPath})
+	e.SetOutput(&strings.Builder{})
+	e.SetGit(repo.Git(rigPath), func(dir string) GitRunner { return repo.Git(dir) })
+	e.settings = &config.RefinerySettings{}
+	if err := e.git.Checkout("main"); err != nil {
+		t.Fatal(err)
+	}
+	return e, repo
+}
+
+// queueBranch commits changes on a new polecat branch and queues it.
+func queueBranch(t *testing.T, e *Engineer, repo *gitfake.Repo, worker string, changes map[string]string) *mrqueue.MR {
+	t.Helper()
+	branch := "polecat/" + worker + "/gt-1"
+	if err := e.git.CreateBranchFrom(branch, "main"); err != nil {
+		t.Fatal(err)
+	}
+	repo.Commit(branch, worker+" work", changes)
+	mr := &mrqueue.MR{Branch: branch, Target: "main", Worker: worker}
+	if err := e.mrQueue.Submit(mr); err != nil {
+		t.Fatal(err)
+	}
+	return mr
+}
+
+func TestDoMerge_FakeGit(t *testing.T) {
+	e, repo := newFakeEngineer(t)
+	nux := queueBranch(t, e, repo, "nux", map[string]string{"nux.txt": "nux\n"})
+	slit := queueBranch(t, e, repo, "slit", map[string]string{"slit.txt": "slit\n"})
+
+	res := e.doMerge(context.Background(), nux)
+	if !res.Success {
+		t.Fatalf("doMerge(nux) = %+v", res)
+	}
+	if repo.Origin("main") != res.MergeCommit {
+		t.Errorf("origin/main = %s, want the merge commit %s", repo.Origin("main"), res.MergeCommit)
+	}
+	if c := repo.Lookup(res.MergeCommit); len(c.Parents) != 2 || c.Message != "Merge polecat/nux/gt-1 into main" {
+		t.Errorf("merge commit = %+v", c)
+	}
+	if res.FilesChanged != 1 || res.LinesChanged != 1 {
+		t.Errorf("change = %d files, %d lines, want 1, 1", res.FilesChanged, res.LinesChanged)
+	}
+
+	e.settings.Strategy = config.StrategySquash
+	res = e.doMerge(context.Background(), slit)
+	if !res.Success {
+		t.Fatalf("doMerge(slit) = %+v", res)
+	}
+	if c := repo.Lookup(repo.Origin("main")); len(c.Parents) != 1 || c.Files["nux.txt"] == "" || c.Files["slit.txt"] == "" {
+		t.Errorf("squash commit = %+v, want one parent and both branches' files", c)
+	}
+}
+
+func TestDoMerge_FakeGitConflict(t *testing.T) {
+	e, repo := newFakeEngineer(t)
+	nux := queueBranch(t, e, repo, "nux", map[string]string{"shared.txt": "nux\n"})
+	slit := queueBranch(t, e, repo, "slit", map[string]string{"shared.txt": "slit\n"})
+
+	if res := e.doMerge(context.Background(), nux); !res.Success {
+		t.Fatalf("doMerge(nux) = %+v", res)
+	}
+	res := e.doMerge(context.Background(), slit)
+	if res.Success || !res.Conflict || len(res.ConflictFiles) != 1 || res.ConflictFiles[0] != "shared.txt" {
+		t.Errorf("doMerge(slit) = %+v, want a conflict in shared.txt", res)
+	}
+	if sha, _ := e.git.Rev("HEAD"); sha != repo.Origin("main") {
+		t.Errorf("HEAD = %s, want origin/main untouched", sha)
+	}
+}
+
+func TestReadyMRs_FakeGit(t *testing.T) {
+	e, repo := newFakeEngineer(t)
+	big := queueBranch(t, e, repo, "nux", map[string]string{"internal/auth/token.go": strings.Repeat("x\n", 400)})
+	small := queueBranch(t, e, repo, "slit", map[string]string{"docs/notes.md": "note\n"})
+	e.settings.Risk = &config.RiskConfig{Order: config.RiskOrderLowFirst, Paths: []string{"internal/auth/"}}
+
+	ready, err := e.readyMRs()
+	if err != nil {
+		t.Fatalf("readyMRs: %v", err)
+	}
+	if got := mrIDs(ready); len(got) != 2 || got[0] != small.ID || got[1] != big.ID {
+		t.Errorf("ready = %v, want %s before %s", got, small.ID, big.ID)
+	}
+}
+
+func TestDoMerge_PushRaceFault(t *testing.T) {
+	t.Cleanup(func() { _ = util.SetFaults("") })
+	e, repo := newFakeEngineer(t)
+	mr := queueBranch(t, e, repo, "nux", map[string]string{"nux.txt": "nux\n"})
+	base := repo.Origin("main")
+	if err := util.SetFaults("push:1"); err != nil {
+		t.Fatal(err)
+	}
+
+	res := e.doMerge(context.Background(), mr)
+	if res.Success || !strings.Contains(res.Error, "failed to push") {
+		t.Fatalf("doMerge with a lost push race = %+v, want a push failure", res)
+	}
+	if repo.Origin("main") != base {
+		t.Error("origin/main moved although the push failed")
+	}
+
+	// The retry starts again from origin and lands.
+	res = e.doMerge(context.Background(), mr)
+	if !res.Success {
+		t.Fatalf("retry = %+v", res)
+	}
+	if repo.Origin("main") != res.MergeCommit {
+		t.Errorf("origin/main = %s, want the retry's merge commit %s", repo.Origin("main"), res.MergeCommit)
+	}
+}
+
+func TestRunChecks_TimeoutFault(t *testing.T) {
+	t.Cleanup(func() { _ = util.SetFaults("") })
+	e, _ := newFakeEngineer(t)
+	if err := util.SetFaults("check-timeout:1"); err != nil {
+		t.Fatal(err)
+	}
+	checks := []config.CheckConfig{{Name: "test", Command: "true", Timeout: "10m"}}
+
+	res := e.runChecks(context.Background(), checks)
+	if res.Success || res.FailedCheck != "test" || res.Error != "check test timed out after 10m" {
+		t.Errorf("runChecks = %+v, want test timed out", res)
+	}
+	if res := e.runChecks(context.Background(), checks); !res.Success {
+		t.Errorf("runChecks after the fault = %+v", res)
+	}
+}
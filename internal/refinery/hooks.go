@@ -0,0 +1,157 @@
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// HookEvent identifies a point in the merge lifecycle where rig hooks run.
+type HookEvent string
+
+const (
+	// HookPreMerge runs after conflict checks and tests pass, before the merge
+	// commit is created. A failing pre-merge hook aborts the merge.
+	HookPreMerge HookEvent = "pre-merge"
+
+	// HookPostMerge runs after the merge has been pushed to the target branch.
+	HookPostMerge HookEvent = "post-merge"
+
+	// HookOnFailure runs after a merge attempt fails (conflict, tests, push).
+	HookOnFailure HookEvent = "on-failure"
+)
+
+// DefaultHookTimeout bounds how long a single hook script may run.
+const DefaultHookTimeout = 5 * time.Minute
+
+// HooksConfig holds per-rig lifecycle hook commands.
+// Each command is run with "sh -c" from the rig directory, so it may be an
+// inline command or a path to a script (e.g., "./hooks/post-merge.sh").
+type HooksConfig struct {
+	// PreMerge runs before merging. Non-zero exit aborts the merge.
+	PreMerge string `json:"pre_merge,omitempty"`
+
+	// PostMerge runs after a successful merge and push.
+	PostMerge string `json:"post_merge,omitempty"`
+
+	// OnFailure runs after a failed merge attempt.
+	OnFailure string `json:"on_failure,omitempty"`
+
+	// Timeout bounds each hook invocation (e.g., "2m"). Default: 5m.
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// Command returns the configured command for a hook event, or "" if unset.
+func (h *HooksConfig) Command(event HookEvent) string {
+	if h == nil {
+		return ""
+	}
+	switch event {
+	case HookPreMerge:
+		return h.PreMerge
+	case HookPostMerge:
+		return h.PostMerge
+	case HookOnFailure:
+		return h.OnFailure
+	default:
+		return ""
+	}
+}
+
+// timeout returns the parsed hook timeout, falling back to DefaultHookTimeout.
+func (h *HooksConfig) timeout() time.Duration {
+	if h == nil || h.Timeout == "" {
+		return DefaultHookTimeout
+	}
+	d, err := time.ParseDuration(h.Timeout)
+	if err != nil || d <= 0 {
+		return DefaultHookTimeout
+	}
+	return d
+}
+
+// HookContext carries the MR details exposed to hook scripts.
+type HookContext struct {
+	Rig         string
+	MR          *mrqueue.MR
+	MergeCommit string // Set for post-merge
+	Error       string // Set for on-failure
+	FailureType string // Set for on-failure: conflict, tests, build
+}
+
+// Env returns the environment variables describing this hook invocation.
+// Hooks see the refinery's environment plus these GT_* variables.
+func (c HookContext) Env(event HookEvent) []string {
+	env := []string{
+		"GT_HOOK=" + string(event),
+		"GT_RIG=" + c.Rig,
+	}
+	if c.MR != nil {
+		env = append(env,
+			"GT_MR_ID="+c.MR.ID,
+			"GT_MR_BRANCH="+c.MR.Branch,
+			"GT_MR_TARGET="+c.MR.Target,
+			"GT_MR_WORKER="+c.MR.Worker,
+			"GT_MR_ISSUE="+c.MR.SourceIssue,
+		)
+	}
+	if c.MergeCommit != "" {
+		env = append(env, "GT_MR_MERGE_COMMIT="+c.MergeCommit)
+	}
+	if c.Error != "" {
+		env = append(env, "GT_MR_ERROR="+c.Error)
+	}
+	if c.FailureType != "" {
+		env = append(env, "GT_MR_FAILURE_TYPE="+c.FailureType)
+	}
+	return env
+}
+
+// runHook runs the configured hook for event, if any.
+// Returns nil when no hook is configured. Hook output is echoed to the
+// engineer's output so it appears alongside the merge log.
+func (e *Engineer) runHook(ctx context.Context, event HookEvent, hc HookContext) error {
+	command := e.config.Hooks.Command(event)
+	if command == "" {
+		return nil
+	}
+
+	env, err := e.processEnv()
+	if err != nil {
+		return fmt.Errorf("%s hook: %w", event, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.config.Hooks.timeout())
+	defer cancel()
+
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Running %s hook: %s\n", event, command)
+
+	// Note: hook commands come from the rig's config.json (trusted infrastructure
+	// config), not from PR branches.
+	sandboxed := e.settings != nil && e.settings.Sandbox != nil && e.settings.Sandbox.Hooks
+	cmd := e.shellCommand(ctx, e.workDir, command, append(env, hc.Env(event)...), sandboxed)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	started := time.Now()
+	err = cmd.Run()
+	util.TraceCommand(e.workDir, cmd.Args[0], cmd.Args[1:], started, err)
+	if output := strings.TrimSpace(out.String()); output != "" {
+		for _, line := range strings.Split(output, "\n") {
+			_, _ = fmt.Fprintf(e.output, "  [%s] %s\n", event, line)
+		}
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%s hook timed out after %v", event, e.config.Hooks.timeout())
+	}
+	if err != nil {
+		return fmt.Errorf("%s hook failed: %w", event, err)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func newHookTestEngineer(t *testing.T, hooks *HooksConfig) (*Engineer, string) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir})
+	e.SetOutput(&bytes.Buffer{})
+	e.config.Hooks = hooks
+	return e, tmpDir
+}
+
+func TestHooksConfig_Command(t *testing.T) {
+	var nilHooks *HooksConfig
+	if got := nilHooks.Command(HookPreMerge); got != "" {
+		t.Errorf("nil config Command() = %q, want empty", got)
+	}
+
+	h := &HooksConfig{PreMerge: "pre", PostMerge: "post", OnFailure: "fail"}
+	tests := map[HookEvent]string{
+		HookPreMerge:  "pre",
+		HookPostMerge: "post",
+		HookOnFailure: "fail",
+		"unknown":     "",
+	}
+	for event, want := range tests {
+		if got := h.Command(event); got != want {
+			t.Errorf("Command(%s) = %q, want %q", event, got, want)
+		}
+	}
+}
+
+func TestRunHook_PassesMRContext(t *testing.T) {
+	e, dir := newHookTestEngineer(t, &HooksConfig{
+		PostMerge: `echo "$GT_HOOK $GT_MR_ID $GT_MR_BRANCH $GT_MR_TARGET $GT_MR_MERGE_COMMIT" > hook.out`,
+	})
+
+	mr := &mrqueue.MR{ID: "mr-1", Branch: "polecat/Nux/gt-abc", Target: "main"}
+	err := e.runHook(context.Background(), HookPostMerge, HookContext{Rig: "test-rig", MR: mr, MergeCommit: "abc123"})
+	if err != nil {
+		t.Fatalf("runHook: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "hook.out"))
+	if err != nil {
+		t.Fatalf("reading hook output: %v", err)
+	}
+	want := "post-merge mr-1 polecat/Nux/gt-abc main abc123"
+	if got := strings.TrimSpace(string(data)); got != want {
+		t.Errorf("hook saw %q, want %q", got, want)
+	}
+}
+
+func TestRunHook_FailureReturnsError(t *testing.T) {
+	e, _ := newHookTestEngineer(t, &HooksConfig{PreMerge: "exit 3"})
+
+	err := e.runHook(context.Background(), HookPreMerge, HookContext{MR: &mrqueue.MR{ID: "mr-1"}})
+	if err == nil {
+		t.Fatal("expected error from failing pre-merge hook")
+	}
+	if !strings.Contains(err.Error(), "pre-merge hook failed") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRunHook_Unconfigured(t *testing.T) {
+	e, _ := newHookTestEngineer(t, nil)
+	if err := e.runHook(context.Background(), HookOnFailure, HookContext{}); err != nil {
+		t.Errorf("unconfigured hook should be a no-op, got %v", err)
+	}
+}
+
+func TestEngineer_LoadConfig_Hooks(t *testing.T) {
+	tmpDir := t.TempDir()
+	config := map[string]interface{}{
+		"merge_queue": map[string]interface{}{
+			"hooks": map[string]interface{}{
+				"post_merge": "./hooks/warm-cache.sh",
+				"timeout":    "30s",
+			},
+		},
+	}
+	data, _ := json.Marshal(config)
+	if err := os.WriteFile(filepath.Join(tmpDir, "config.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir})
+	if err := e.LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if e.config.Hooks == nil || e.config.Hooks.PostMerge != "./hooks/warm-cache.sh" {
+		t.Errorf("expected post_merge hook to be loaded, got %+v", e.config.Hooks)
+	}
+}
+
+func TestRunHook_RigEnv(t *testing.T) {
+	e, dir := newHookTestEngineer(t, &HooksConfig{PreMerge: `echo "$TOOLCHAIN $API_KEY" > hook.out`})
+	if err := os.MkdirAll(filepath.Join(dir, "settings"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "settings", "api_key"), []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	e.settings = &config.RefinerySettings{Env: map[string]string{
+		"TOOLCHAIN": "/opt/go",
+		"API_KEY":   "${file:api_key}",
+	}}
+
+	if err := e.runHook(context.Background(), HookPreMerge, HookContext{MR: &mrqueue.MR{ID: "mr-1"}}); err != nil {
+		t.Fatalf("runHook: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "hook.out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "/opt/go s3cret" {
+		t.Errorf("hook saw %q, want rig env", got)
+	}
+
+	e.settings.Env["API_KEY"] = "${env:GT_TEST_SURELY_UNSET}"
+	err = e.runHook(context.Background(), HookPreMerge, HookContext{MR: &mrqueue.MR{ID: "mr-1"}})
+	if err == nil || !strings.Contains(err.Error(), "env API_KEY") {
+		t.Errorf("unresolvable secret: err = %v", err)
+	}
+}
@@ -0,0 +1,258 @@
+package refinery
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// A swarm's polecats work on the children of one epic. Their MRs merge into
+// the epic's integration/<epic> branch rather than the rig's target, so the
+// swarm's work is integrated and checked as a whole; the integration branch
+// lands on the target only once every child issue is closed.
+
+// integrationEpic returns the epic an integration branch belongs to, or ""
+// if branch is not an integration branch.
+func integrationEpic(branch string) string {
+	if !strings.HasPrefix(branch, constants.BranchIntegrationPrefix) {
+		return ""
+	}
+	return strings.TrimPrefix(branch, constants.BranchIntegrationPrefix)
+}
+
+// SwarmTrailer is the commit trailer that tags a branch's commits with the
+// swarm they belong to ("Swarm: gt-epic").
+const SwarmTrailer = "Swarm"
+
+// DetectSwarm works out the swarm a branch's MR belongs to: the epic of the
+// integration branch it targets or lands, else a Swarm trailer on the
+// branch's commits, else the epic its source issue is a child of. Returns
+// "" for work outside a swarm. g and b may be nil to skip those sources.
+func DetectSwarm(g GitRunner, b *beads.Beads, branch, target, sourceIssue string) string {
+	if id := mrqueue.SwarmOf(branch, target); id != "" {
+		return id
+	}
+	if g != nil {
+		for _, base := range []string{target, "origin/" + target} {
+			ids, err := g.Trailers(base, branch, SwarmTrailer)
+			if err != nil {
+				continue
+			}
+			if len(ids) > 0 {
+				return ids[0] // Newest commit wins
+			}
+			break
+		}
+	}
+	if b == nil || sourceIssue == "" {
+		return ""
+	}
+	issue, err := b.Show(sourceIssue)
+	if err != nil || issue.Parent == "" {
+		return ""
+	}
+	parent, err := b.Show(issue.Parent)
+	if err != nil || parent.Type != "epic" {
+		return ""
+	}
+	return parent.ID
+}
+
+// SwarmFor detects the swarm of a branch about to be queued in the rig.
+func (m *Manager) SwarmFor(branch, target, sourceIssue string) string {
+	return DetectSwarm(git.NewGit(m.workDir), beads.New(m.rig.BeadsPath()), branch, target, sourceIssue)
+}
+
+// discoverSwarm fills in and records the swarm of an MR queued without one,
+// e.g. by a submitter that predates swarm tracking.
+func (e *Engineer) discoverSwarm(mr *mrqueue.MR) {
+	if mr.SwarmID != "" {
+		return
+	}
+	mr.SwarmID = DetectSwarm(e.git, e.beads, mr.Branch, mr.Target, mr.SourceIssue)
+	if mr.SwarmID == "" {
+		return
+	}
+	if err := e.mrQueue.SetSwarm(mr.ID, mr.SwarmID); err != nil && !errors.Is(err, mrqueue.ErrNotFound) {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to record swarm of %s: %v\n", mr.ID, err)
+	}
+}
+
+// routeToIntegration retargets a swarm member's MR at its epic's
+// integration branch, if one exists on origin. Submitters normally route
+// MRs themselves (gt done, gt mq submit); this catches MRs queued against
+// the rig's target before the integration branch was created.
+func (e *Engineer) routeToIntegration(mr *mrqueue.MR) {
+	if !e.config.IntegrationBranches || mr.SwarmID == "" || mr.Target != e.config.TargetBranch ||
+		integrationEpic(mr.Branch) != "" {
+		return
+	}
+	branch := constants.BranchIntegrationPrefix + mr.SwarmID
+	if exists, err := e.git.RemoteBranchExists("origin", branch); err != nil || !exists {
+		return
+	}
+
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Routing %s to swarm integration branch %s\n", mr.Branch, branch)
+	mr.Target = branch
+	if err := e.mrQueue.SetTarget(mr.ID, branch); err != nil && !errors.Is(err, mrqueue.ErrNotFound) {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to retarget %s: %v\n", mr.ID, err)
+	}
+}
+
+// integrationChecks returns the [refinery.integration] checks if the merge
+// goes into, or lands, an integration branch.
+func (e *Engineer) integrationChecks(branch, target string) []config.CheckConfig {
+	if e.settings == nil || e.settings.Integration == nil {
+		return nil
+	}
+	if integrationEpic(branch) == "" && integrationEpic(target) == "" {
+		return nil
+	}
+	return e.settings.Integration.Checks
+}
+
+// SwarmProgress is how far a swarm's epic has got through the refinery.
+type SwarmProgress struct {
+	Epic   string   `json:"epic"`
+	Branch string   `json:"branch"`           // The epic's integration branch
+	Issues []string `json:"issues"`           // Child issues of the epic
+	Merged []string `json:"merged,omitempty"` // Children merged into Branch
+
+	// Pending are children neither merged into Branch nor closed. Children
+	// closed without a merge (e.g. won't-fix) do not hold the swarm up.
+	Pending []string `json:"pending,omitempty"`
+}
+
+// Complete reports whether every child issue is merged or closed.
+func (p *SwarmProgress) Complete() bool {
+	return len(p.Issues) > 0 && len(p.Pending) == 0
+}
+
+// SwarmProgress reports the progress of the swarm working on epic: its
+// child issues from beads, checked against the merges into its integration
+// branch recorded in the event log.
+func (e *Engineer) SwarmProgress(epic string) (*SwarmProgress, error) {
+	children, err := e.beads.List(beads.ListOptions{Parent: epic, Status: "all", Priority: -1})
+	if err != nil {
+		return nil, err
+	}
+	events, err := e.eventLogger.ReadEvents(0)
+	if err != nil {
+		return nil, err
+	}
+	return swarmProgress(epic, children, events), nil
+}
+
+func swarmProgress(epic string, children []*beads.Issue, events []mrqueue.Event) *SwarmProgress {
+	p := &SwarmProgress{Epic: epic, Branch: constants.BranchIntegrationPrefix + epic}
+	merged := make(map[string]bool)
+	for _, ev := range events {
+		if ev.Type == mrqueue.EventMerged && ev.Target == p.Branch && ev.SourceIssue != "" {
+			merged[ev.SourceIssue] = true
+		}
+	}
+	for _, issue := range children {
+		p.Issues = append(p.Issues, issue.ID)
+		switch {
+		case merged[issue.ID]:
+			p.Merged = append(p.Merged, issue.ID)
+		case issue.Status != "closed":
+			p.Pending = append(p.Pending, issue.ID)
+		}
+	}
+	return p
+}
+
+// checkSwarmComplete refuses to land an integration branch while its swarm
+// still has pending issues.
+func (e *Engineer) checkSwarmComplete(branch string) error {
+	epic := integrationEpic(branch)
+	if epic == "" {
+		return nil
+	}
+	p, err := e.SwarmProgress(epic)
+	if err != nil {
+		return fmt.Errorf("checking swarm %s: %w", epic, err)
+	}
+	if !p.Complete() {
+		if len(p.Issues) == 0 {
+			return fmt.Errorf("swarm %s has no issues", epic)
+		}
+		return fmt.Errorf("swarm %s is not complete: pending issues %s", epic, strings.Join(p.Pending, ", "))
+	}
+	return nil
+}
+
+// landingWorker is the worker recorded on MRs the refinery queues itself
+// to land a complete swarm.
+const landingWorker = "refinery"
+
+// isLanding reports whether mr is a swarm landing the refinery queued.
+func isLanding(mr *mrqueue.MR) bool {
+	return mr.Worker == landingWorker && integrationEpic(mr.Branch) != ""
+}
+
+// queueIntegrationLanding enqueues an integration branch for the rig's
+// target once [refinery.integration] auto_land is set and the swarm is
+// complete: no queued MR still targets the branch and every child issue of
+// its epic is merged or closed. With require_approval the landing waits
+// for 'gt refinery approve'; either way the integration checks run again
+// before it merges.
+func (e *Engineer) queueIntegrationLanding(branch string) {
+	epic := integrationEpic(branch)
+	if epic == "" || e.settings == nil || e.settings.Integration == nil || !e.settings.Integration.AutoLand {
+		return
+	}
+	queued, err := e.mrQueue.List()
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to list queue: %v\n", err)
+		return
+	}
+	for _, mr := range queued {
+		if mr.Target == branch || mr.Branch == branch {
+			return // More swarm work to merge, or the landing is already queued
+		}
+	}
+	if err := e.checkSwarmComplete(branch); err != nil {
+		return
+	}
+
+	landing := &mrqueue.MR{
+		Branch:      branch,
+		Target:      e.config.TargetBranch,
+		SourceIssue: epic,
+		SwarmID:     epic,
+		Worker:      landingWorker,
+		Rig:         e.rig.Name,
+		Title:       "Land swarm " + epic,
+		Priority:    2,
+	}
+	if issue, err := e.beads.Show(epic); err == nil {
+		landing.Title = fmt.Sprintf("Land swarm %s: %s", epic, issue.Title)
+		landing.Priority = issue.Priority
+	}
+	if err := e.mrQueue.Submit(landing); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to queue landing of %s: %v\n", branch, err)
+		return
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Swarm %s complete; queued %s to land on %s (%s)\n",
+		epic, branch, landing.Target, landing.ID)
+
+	if !e.settings.Integration.RequireApproval {
+		return
+	}
+	reason := fmt.Sprintf("swarm %s is complete; landing on %s needs approval", epic, landing.Target)
+	if err := e.mrQueue.RequireApproval(landing.ID, reason); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to park %s for approval: %v\n", landing.ID, err)
+		return
+	}
+	if err := e.eventLogger.LogApprovalRequired(landing, reason); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to log approval_required event: %v\n", err)
+	}
+}
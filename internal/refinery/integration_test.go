@@ -0,0 +1,122 @@
+package refinery
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func TestIntegrationEpic(t *testing.T) {
+	tests := map[string]string{
+		"integration/gt-epic": "gt-epic",
+		"polecat/nux-abc123":  "",
+		"main":                "",
+		"integration":         "",
+	}
+	for branch, want := range tests {
+		if got := integrationEpic(branch); got != want {
+			t.Errorf("integrationEpic(%q) = %q, want %q", branch, got, want)
+		}
+	}
+}
+
+func TestDetectSwarm(t *testing.T) {
+	rigPath := setupConflictRig(t)
+	g := git.NewGit(rigPath)
+	cmd := exec.Command("git", "commit", "--allow-empty", "-m", "tagged\n\nSwarm: gt-tagged")
+	cmd.Dir = rigPath
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git commit: %v\n%s", err, out)
+	}
+	if err := g.CreateBranch("polecat/slit-abc"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		branch, target, want string
+	}{
+		{"polecat/nux/gt-1", "integration/gt-epic", "gt-epic"},   // Branch naming
+		{"integration/gt-epic", "main", "gt-epic"},               // Landing
+		{"polecat/nux/gt-1", "main", ""},                         // No trailer
+		{"polecat/slit-abc", "origin/main", "gt-tagged"},         // Trailer
+		{"polecat/slit-abc", "integration/gt-other", "gt-other"}, // Target beats trailer
+	}
+	for _, tt := range tests {
+		if got := DetectSwarm(g, nil, tt.branch, tt.target, ""); got != tt.want {
+			t.Errorf("DetectSwarm(%q, %q) = %q, want %q", tt.branch, tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestSwarmProgress(t *testing.T) {
+	children := []*beads.Issue{
+		{ID: "gt-1", Status: "closed"},      // Merged and closed
+		{ID: "gt-2", Status: "in_progress"}, // Merged, bead not closed yet
+		{ID: "gt-3", Status: "closed"},      // Closed without a merge
+		{ID: "gt-4", Status: "open"},
+	}
+	events := []mrqueue.Event{
+		{Type: mrqueue.EventMerged, Target: "integration/gt-epic", SourceIssue: "gt-1"},
+		{Type: mrqueue.EventMerged, Target: "integration/gt-epic", SourceIssue: "gt-2"},
+		{Type: mrqueue.EventMerged, Target: "main", SourceIssue: "gt-4"},
+		{Type: mrqueue.EventMergeFailed, Target: "integration/gt-epic", SourceIssue: "gt-4"},
+	}
+
+	p := swarmProgress("gt-epic", children, events)
+	if !reflect.DeepEqual(p.Merged, []string{"gt-1", "gt-2"}) || !reflect.DeepEqual(p.Pending, []string{"gt-4"}) {
+		t.Errorf("progress = %+v, want gt-1 and gt-2 merged, gt-4 pending", p)
+	}
+	if p.Complete() {
+		t.Error("Complete() = true with gt-4 pending")
+	}
+
+	children[3].Status = "closed"
+	if p := swarmProgress("gt-epic", children, events); !p.Complete() {
+		t.Errorf("Complete() = false for %+v", p)
+	}
+	if p := swarmProgress("gt-epic", nil, events); p.Complete() {
+		t.Error("Complete() = true for an epic with no issues")
+	}
+}
+
+func TestIntegrationChecks(t *testing.T) {
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: t.TempDir()})
+	e.settings = &config.RefinerySettings{Integration: &config.IntegrationConfig{
+		Checks: []config.CheckConfig{{Name: "e2e", Command: "make e2e"}},
+	}}
+
+	if got := e.integrationChecks("polecat/nux-abc", "main"); len(got) != 0 {
+		t.Errorf("checks for a plain merge = %v, want none", got)
+	}
+	if got := e.integrationChecks("polecat/nux-abc", "integration/gt-epic"); len(got) != 1 {
+		t.Errorf("checks for a merge into integration = %v, want e2e", got)
+	}
+	if got := e.integrationChecks("integration/gt-epic", "main"); len(got) != 1 {
+		t.Errorf("checks for a landing = %v, want e2e", got)
+	}
+}
+
+func TestQueueIntegrationLanding_PendingWork(t *testing.T) {
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: t.TempDir()})
+	out := &strings.Builder{}
+	e.SetOutput(out)
+	e.settings = &config.RefinerySettings{Integration: &config.IntegrationConfig{AutoLand: true}}
+
+	pending := &mrqueue.MR{Branch: "polecat/nux-abc", Target: "integration/gt-epic", SourceIssue: "gt-2"}
+	if err := e.mrQueue.Submit(pending); err != nil {
+		t.Fatal(err)
+	}
+	e.queueIntegrationLanding("integration/gt-epic")
+
+	queued, _ := e.mrQueue.List()
+	if len(queued) != 1 {
+		t.Errorf("queue = %+v, want only the pending swarm MR", queued)
+	}
+}
@@ -0,0 +1,87 @@
+package refinery
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// ErrStateModified means refinery state was changed outside gt and
+// refinery.state_integrity is "refuse".
+var ErrStateModified = errors.New("refinery state was modified outside gt")
+
+// ModifiedState returns the refinery's state files, relative to the rig,
+// that no longer match the checksum recorded when gt last wrote them:
+// .runtime/refinery.json and the queued MRs in .beads/mq/.
+func (m *Manager) ModifiedState() ([]string, error) {
+	var modified []string
+	err := util.VerifyChecksum(m.stateFile())
+	if errors.Is(err, util.ErrChecksumMismatch) {
+		modified = append(modified, m.relPath(m.stateFile()))
+	} else if err != nil {
+		return nil, err
+	}
+
+	q := mrqueue.New(m.rig.Path)
+	ids, err := q.Tampered()
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		modified = append(modified, m.relPath(filepath.Join(q.Dir(), id+".json")))
+	}
+	return modified, nil
+}
+
+// AcceptState records the current contents of every modified state file
+// as its checksum, after someone has checked the edits are intended.
+// Returns the files accepted.
+func (m *Manager) AcceptState() ([]string, error) {
+	modified, err := m.ModifiedState()
+	if err != nil {
+		return nil, err
+	}
+	for _, rel := range modified {
+		path := filepath.Join(m.rig.Path, rel)
+		data, err := os.ReadFile(path) //nolint:gosec // G304: path is constructed internally
+		if err != nil {
+			return nil, err
+		}
+		if err := util.WriteChecksum(path, data); err != nil {
+			return nil, err
+		}
+	}
+	return modified, nil
+}
+
+// checkState applies refinery.state_integrity before the refinery starts:
+// modified state is reported, or with "refuse" stops the start.
+func (m *Manager) checkState() error {
+	mode := m.settings.StateIntegrityMode()
+	if mode == config.StateIntegrityOff {
+		return nil
+	}
+	modified, err := m.ModifiedState()
+	if err != nil || len(modified) == 0 {
+		return nil // Unreadable checksums are not evidence of tampering
+	}
+	files := strings.Join(modified, ", ")
+	if mode == config.StateIntegrityRefuse {
+		return fmt.Errorf("%w: %s (review, then 'gt refinery verify-state --accept')", ErrStateModified, files)
+	}
+	_, _ = fmt.Fprintf(m.output, "⚠ Refinery state modified outside gt: %s\n", files)
+	return nil
+}
+
+func (m *Manager) relPath(path string) string {
+	if rel, err := filepath.Rel(m.rig.Path, path); err == nil {
+		return rel
+	}
+	return path
+}
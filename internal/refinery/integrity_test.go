@@ -0,0 +1,56 @@
+package refinery
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+func TestManager_ModifiedState(t *testing.T) {
+	mgr, rigPath := setupTestManager(t)
+	var out bytes.Buffer
+	mgr.SetOutput(&out)
+
+	if err := mgr.saveState(&Refinery{RigName: "testrig", State: StateStopped}); err != nil {
+		t.Fatal(err)
+	}
+	mr := &mrqueue.MR{Branch: "polecat/nux", Target: "main"}
+	if err := mrqueue.New(rigPath).Submit(mr); err != nil {
+		t.Fatal(err)
+	}
+	if modified, err := mgr.ModifiedState(); err != nil || len(modified) != 0 {
+		t.Fatalf("ModifiedState before edits = %v, %v", modified, err)
+	}
+
+	statePath := filepath.Join(rigPath, ".runtime", "refinery.json")
+	if err := os.WriteFile(statePath, []byte(`{"rig_name":"testrig","state":"running"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(".runtime", "refinery.json")}
+	if modified, _ := mgr.ModifiedState(); !slices.Equal(modified, want) {
+		t.Errorf("ModifiedState = %v, want %v", modified, want)
+	}
+
+	mgr.settings = &config.RefinerySettings{StateIntegrity: config.StateIntegrityRefuse}
+	if err := mgr.checkState(); !errors.Is(err, ErrStateModified) {
+		t.Errorf("checkState with refuse = %v, want ErrStateModified", err)
+	}
+	mgr.settings = nil
+	if err := mgr.checkState(); err != nil || !strings.Contains(out.String(), "refinery.json") {
+		t.Errorf("checkState with warn = %v, output %q", err, out.String())
+	}
+
+	if accepted, err := mgr.AcceptState(); err != nil || !slices.Equal(accepted, want) {
+		t.Errorf("AcceptState = %v, %v", accepted, err)
+	}
+	if modified, _ := mgr.ModifiedState(); len(modified) != 0 {
+		t.Errorf("ModifiedState after accept = %v", modified)
+	}
+}
@@ -0,0 +1,327 @@
+package refinery
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// MR lifecycle states mirrored onto the source issue as an "mr:<state>"
+// label, so 'bd list --label mr:failed' finds work whose merge went wrong.
+const (
+	IssueMRQueued  = "queued"
+	IssueMRMerging = "merging"
+	IssueMRMerged  = "merged"
+	IssueMRFailed  = "failed"
+)
+
+// issueMRLabelPrefix prefixes the lifecycle state labels.
+const issueMRLabelPrefix = "mr:"
+
+// MarkIssue labels issue with the MR lifecycle state, replacing any earlier
+// state label.
+func MarkIssue(b *beads.Beads, issue *beads.Issue, state string) error {
+	add, remove := stateLabelChanges(issue.Labels, state)
+	if len(add)+len(remove) == 0 {
+		return nil
+	}
+	return b.Update(issue.ID, beads.UpdateOptions{AddLabels: add, RemoveLabels: remove})
+}
+
+// stateLabelChanges returns the labels to add and remove to move an issue
+// with labels to the lifecycle state.
+func stateLabelChanges(labels []string, state string) (add, remove []string) {
+	want := issueMRLabelPrefix + state
+	add = []string{want}
+	for _, l := range labels {
+		if l == want {
+			add = nil
+		} else if strings.HasPrefix(l, issueMRLabelPrefix) {
+			remove = append(remove, l)
+		}
+	}
+	return add, remove
+}
+
+// MarkMRIssue mirrors an MR's lifecycle state onto its source issue.
+// Landings, whose source is the swarm's epic, and backports of an issue
+// that already merged are left alone.
+func MarkMRIssue(b *beads.Beads, mr *mrqueue.MR, state string) error {
+	if mr.SourceIssue == "" || queuedItself(mr) {
+		return nil
+	}
+	issue, err := b.Show(mr.SourceIssue)
+	if err != nil {
+		return err
+	}
+	return MarkIssue(b, issue, state)
+}
+
+// markIssue is MarkMRIssue with a warning on failure; beads being
+// unreachable does not hold up the merge.
+func (e *Engineer) markIssue(mr *mrqueue.MR, state string) {
+	if err := MarkMRIssue(e.beads, mr, state); err != nil && !errors.Is(err, beads.ErrNotInstalled) {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to mark %s %s: %v\n", mr.SourceIssue, state, err)
+	}
+}
+
+// syncSourceIssues reconciles ready MRs with their source issues. MRs whose
+// issue is gone, blocked, rejected, reopened, or closed for good are held,
+// so cancelled work is not merged; they return to the queue with 'gt
+// refinery requeue' once the issue is sorted out. The rest have their
+// issue labelled mr:queued, and wait until it passes
+// refinery.issue_gate, if set.
+func (e *Engineer) syncSourceIssues(mrs []*mrqueue.MR) []*mrqueue.MR {
+	ready := mrs[:0]
+	for _, mr := range mrs {
+		issue, reason := e.sourceIssueProblem(mr)
+		if reason == "" {
+			if e.passesIssueGate(mr, issue) {
+				ready = append(ready, mr)
+			}
+			continue
+		}
+		if err := e.mrQueue.Hold(mr.ID, reason); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to hold %s: %v\n", mr.ID, err)
+			continue
+		}
+		if err := e.eventLogger.LogHeld(mr, reason); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to log held event: %v\n", err)
+		}
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Held %s: %s\n", mr.ID, reason)
+	}
+	return ready
+}
+
+// sourceIssueProblem is judgeSourceIssue, recording what it finds: the
+// issue's status and prerequisites on mr, and the issue labelled
+// mr:queued if it rules nothing out.
+func (e *Engineer) sourceIssueProblem(mr *mrqueue.MR) (*beads.Issue, string) {
+	lastSeen, lastDeps := mr.IssueStatus, mr.DependsOn
+	issue, reason := e.judgeSourceIssue(mr)
+	if issue == nil {
+		return nil, reason
+	}
+	if issue.Status != lastSeen {
+		if err := e.mrQueue.SetIssueStatus(mr.ID, issue.Status); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to record %s status on %s: %v\n", mr.SourceIssue, mr.ID, err)
+		}
+	}
+	if !slices.Equal(mr.DependsOn, lastDeps) {
+		if err := e.mrQueue.SetDependsOn(mr.ID, mr.DependsOn); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to record %s prerequisites on %s: %v\n", mr.SourceIssue, mr.ID, err)
+		}
+	}
+	if reason != "" {
+		return issue, reason
+	}
+	if err := MarkIssue(e.beads, issue, IssueMRQueued); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to mark %s queued: %v\n", issue.ID, err)
+	}
+	return issue, ""
+}
+
+// judgeSourceIssue returns mr's source issue and why it rules out merging
+// mr, or "" if it does not, changing nothing but mr.DependsOn in memory,
+// which it refreshes from the issue's prerequisites. A failed lookup other
+// than "not found" is not held against the MR; the issue is then nil.
+func (e *Engineer) judgeSourceIssue(mr *mrqueue.MR) (*beads.Issue, string) {
+	if mr.SourceIssue == "" || queuedItself(mr) {
+		return nil, ""
+	}
+	issue, err := e.beads.Show(mr.SourceIssue)
+	if errors.Is(err, beads.ErrNotFound) {
+		return nil, fmt.Sprintf("source issue %s not found", mr.SourceIssue)
+	}
+	if err != nil {
+		return nil, ""
+	}
+
+	mr.DependsOn = prerequisites(issue)
+	if mr.IssueStatus == "closed" && issue.Status != "closed" {
+		return issue, fmt.Sprintf("source issue %s was reopened", issue.ID)
+	}
+	return issue, issueProblem(issue)
+}
+
+// admitSourceIssues is syncSourceIssues without holding, labelling, or
+// recording anything: the MRs it would let through.
+func (e *Engineer) admitSourceIssues(mrs []*mrqueue.MR) []*mrqueue.MR {
+	ready := mrs[:0]
+	for _, mr := range mrs {
+		if issue, reason := e.judgeSourceIssue(mr); reason == "" && e.issueGateAdmits(mr, issue) {
+			ready = append(ready, mr)
+		}
+	}
+	return ready
+}
+
+// passesIssueGate reports whether refinery.issue_gate lets mr merge, given
+// its source issue (nil if the lookup failed, which fails the gate). MRs
+// the gate cannot judge, having no source issue, are parked for 'gt
+// refinery approve'; an approval also lets a gated MR through.
+func (e *Engineer) passesIssueGate(mr *mrqueue.MR, issue *beads.Issue) bool {
+	if e.issueGateAdmits(mr, issue) {
+		return true
+	}
+	if mr.SourceIssue == "" {
+		reason := "no source issue for refinery.issue_gate to check"
+		if err := e.mrQueue.RequireApproval(mr.ID, reason); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to mark %s as needing approval: %v\n", mr.ID, err)
+			return false
+		}
+		if err := e.eventLogger.LogApprovalRequired(mr, reason); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to log approval_required event: %v\n", err)
+		}
+	}
+	return false
+}
+
+// issueGateAdmits is passesIssueGate without parking anything.
+func (e *Engineer) issueGateAdmits(mr *mrqueue.MR, issue *beads.Issue) bool {
+	if e.settings == nil || e.settings.IssueGate == nil || queuedItself(mr) || mr.IsApproved() {
+		return true
+	}
+	if issue == nil {
+		return false
+	}
+	ok, _ := e.settings.IssueGate.Admits(issue.Status, issue.Labels)
+	return ok
+}
+
+// ListGatedMRs returns queued MRs whose source issue has not yet passed
+// refinery.issue_gate, with what each issue is waiting for, keyed by MR
+// ID.
+func (e *Engineer) ListGatedMRs() ([]*mrqueue.MR, map[string]string, error) {
+	if e.settings == nil || e.settings.IssueGate == nil {
+		return nil, nil, nil
+	}
+	mrs, err := e.mrQueue.ListReady(e.IsBeadOpen)
+	if err != nil {
+		return nil, nil, err
+	}
+	var gated []*mrqueue.MR
+	waits := make(map[string]string)
+	for _, mr := range mrs {
+		if mr.SourceIssue == "" || queuedItself(mr) || mr.IsApproved() {
+			continue
+		}
+		issue, err := e.beads.Show(mr.SourceIssue)
+		if err != nil {
+			continue
+		}
+		if ok, why := e.settings.IssueGate.Admits(issue.Status, issue.Labels); !ok {
+			gated = append(gated, mr)
+			waits[mr.ID] = fmt.Sprintf("%s %s", issue.ID, strings.TrimPrefix(why, "issue "))
+		}
+	}
+	return gated, waits, nil
+}
+
+// prerequisites returns the issues issue depends on, from its "blocks"
+// dependencies; parent-child and other links do not order merges.
+func prerequisites(issue *beads.Issue) []string {
+	var deps []string
+	for _, d := range issue.Dependencies {
+		if d.DependencyType == "" || d.DependencyType == "blocks" {
+			deps = append(deps, d.ID)
+		}
+	}
+	if len(issue.Dependencies) == 0 {
+		deps = append(deps, issue.DependsOn...)
+	}
+	return deps
+}
+
+// issueProblem judges a source issue. A closed issue passes if it was
+// closed without a reason, as 'gt done' closes the worker's hooked issue
+// on submitting; a reason means it was cancelled, superseded, or already
+// merged.
+func issueProblem(issue *beads.Issue) string {
+	for _, l := range issue.Labels {
+		if l == "rejected" {
+			return fmt.Sprintf("source issue %s was rejected", issue.ID)
+		}
+	}
+	switch issue.Status {
+	case "blocked":
+		return fmt.Sprintf("source issue %s is blocked", issue.ID)
+	case "rejected":
+		return fmt.Sprintf("source issue %s was rejected", issue.ID)
+	case "closed":
+		if r := strings.TrimSpace(issue.CloseReason); r != "" && !strings.EqualFold(r, "closed") {
+			return fmt.Sprintf("source issue %s is closed: %s", issue.ID, r)
+		}
+	}
+	return ""
+}
+
+// settleSourceIssue records where a merged MR landed on its source issue
+// (merge_commit, merged_into, ...; see beads.MergeFields) and moves the
+// issue to rig.toml's refinery.issue_status: closed with the merge in the
+// close reason, or another status with the merge added as a comment. A
+// backport only adds a comment; the issue settled when its MR merged.
+func (e *Engineer) settleSourceIssue(mr *mrqueue.MR, mergeCommit string) {
+	issueID := mr.SourceIssue
+	if issueID == "" {
+		return
+	}
+	if isBackport(mr) {
+		note := fmt.Sprintf("Backported to %s in %s as %s", mr.Target, mr.ID, mergeCommit)
+		if err := e.beads.Comment(issueID, note); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to record backport on %s: %v\n", issueID, err)
+		}
+		return
+	}
+	e.recordMerge(mr, mergeCommit)
+
+	status := e.settings.MergedIssueStatus()
+	if status == config.IssueStatusNone {
+		return
+	}
+	note := fmt.Sprintf("Merged in %s as %s", mr.ID, mergedAs(mr, mergeCommit))
+
+	if status == config.IssueStatusClosed {
+		if err := e.beads.CloseWithReason(note, issueID); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to close source issue %s: %v\n", issueID, err)
+			return
+		}
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Closed source issue: %s\n", issueID)
+		return
+	}
+
+	if err := e.beads.Update(issueID, beads.UpdateOptions{Status: &status}); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to set source issue %s to %s: %v\n", issueID, status, err)
+		return
+	}
+	if err := e.beads.Comment(issueID, note); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to record merge commit on %s: %v\n", issueID, err)
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Moved source issue %s to %s\n", issueID, status)
+}
+
+// recordMerge writes the merge provenance fields into the source issue's
+// description.
+func (e *Engineer) recordMerge(mr *mrqueue.MR, mergeCommit string) {
+	issue, err := e.beads.Show(mr.SourceIssue)
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to record merge on %s: %v\n", mr.SourceIssue, err)
+		return
+	}
+	desc := beads.SetMergeFields(issue, &beads.MergeFields{
+		MergeCommit: mergeCommit,
+		MergedInto:  mr.Target,
+		MergedFrom:  mr.Branch,
+		MergedMR:    mr.ID,
+		MergedAt:    e.clock().UTC().Format(time.RFC3339),
+	})
+	if err := e.beads.Update(issue.ID, beads.UpdateOptions{Description: &desc}); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to record merge on %s: %v\n", issue.ID, err)
+	}
+}
@@ -0,0 +1,71 @@
+package refinery
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+func TestIssueProblem(t *testing.T) {
+	tests := []struct {
+		issue beads.Issue
+		held  bool
+	}{
+		{beads.Issue{ID: "gt-1", Status: "open"}, false},
+		{beads.Issue{ID: "gt-1", Status: "in_progress"}, false},
+		{beads.Issue{ID: "gt-1", Status: "closed"}, false}, // gt done
+		{beads.Issue{ID: "gt-1", Status: "closed", CloseReason: "Closed"}, false},
+		{beads.Issue{ID: "gt-1", Status: "closed", CloseReason: "wontfix: superseded by gt-2"}, true},
+		{beads.Issue{ID: "gt-1", Status: "closed", CloseReason: "Merged in gt-mr1 as abc123"}, true},
+		{beads.Issue{ID: "gt-1", Status: "blocked"}, true},
+		{beads.Issue{ID: "gt-1", Status: "rejected"}, true},
+		{beads.Issue{ID: "gt-1", Status: "open", Labels: []string{"rejected"}}, true},
+	}
+	for _, tt := range tests {
+		got := issueProblem(&tt.issue)
+		if (got != "") != tt.held {
+			t.Errorf("issueProblem(%+v) = %q, want held=%v", tt.issue, got, tt.held)
+		}
+	}
+}
+
+func TestStateLabelChanges(t *testing.T) {
+	tests := []struct {
+		labels      []string
+		state       string
+		add, remove []string
+	}{
+		{nil, IssueMRQueued, []string{"mr:queued"}, nil},
+		{[]string{"backend", "mr:queued"}, IssueMRQueued, nil, nil},
+		{[]string{"backend", "mr:queued"}, IssueMRMerging, []string{"mr:merging"}, []string{"mr:queued"}},
+		{[]string{"mr:failed", "mr:merging"}, IssueMRFailed, nil, []string{"mr:merging"}},
+	}
+	for _, tt := range tests {
+		add, remove := stateLabelChanges(tt.labels, tt.state)
+		if !reflect.DeepEqual(add, tt.add) || !reflect.DeepEqual(remove, tt.remove) {
+			t.Errorf("stateLabelChanges(%v, %q) = %v, %v; want %v, %v", tt.labels, tt.state, add, remove, tt.add, tt.remove)
+		}
+	}
+}
+
+func TestIssueGate_OnlyParksWhenProcessing(t *testing.T) {
+	e, repo := newFakeEngineer(t)
+	e.settings.IssueGate = &config.IssueGateConfig{Label: "approved"}
+	mr := queueBranch(t, e, repo, "nux", map[string]string{"nux.txt": "nux\n"})
+
+	// No source issue for the gate to check: not ready either way, but
+	// only the processing pass parks it for approval
+	if ready, err := e.ListReadyMRs(); err != nil || len(ready) != 0 {
+		t.Fatalf("ListReadyMRs = %v, %v, want nothing", mrIDs(ready), err)
+	}
+	if got, _ := e.mrQueue.Get(mr.ID); got.NeedsApproval() {
+		t.Error("listing ready MRs parked the MR")
+	}
+
+	e.prepareQueue()
+	if got, _ := e.mrQueue.Get(mr.ID); !got.NeedsApproval() {
+		t.Errorf("after processing, %s = %+v, want it waiting for approval", mr.ID, got)
+	}
+}
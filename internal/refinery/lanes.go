@@ -0,0 +1,125 @@
+package refinery
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// With [refinery] lanes set, each target branch is a lane: the rig's
+// target, and each swarm's integration branch. A lane merges one MR at a
+// time, but lanes run side by side, so refinery workers claiming from the
+// ready list each take a different lane.
+
+// Lane is one target branch's share of the queue.
+type Lane struct {
+	Target  string      `json:"target"`
+	Current *mrqueue.MR `json:"current,omitempty"` // The claimed MR being merged
+	Ready   int         `json:"ready"`
+	Queued  int         `json:"queued"`
+}
+
+// lanesEnabled reports whether rig.toml turns on lanes.
+func (e *Engineer) lanesEnabled() bool {
+	return e.settings != nil && e.settings.Lanes
+}
+
+// Lanes reports each lane with queued MRs, the rig's target first. Ready
+// counts the lane's ready MRs, including those waiting on its current one.
+func (e *Engineer) Lanes() ([]Lane, error) {
+	all, err := e.mrQueue.List()
+	if err != nil {
+		return nil, err
+	}
+	ready, err := e.readyMRs()
+	if err != nil {
+		return nil, err
+	}
+
+	byTarget := make(map[string]*Lane)
+	lane := func(target string) *Lane {
+		l := byTarget[target]
+		if l == nil {
+			l = &Lane{Target: target}
+			byTarget[target] = l
+		}
+		return l
+	}
+	for _, mr := range all {
+		l := lane(mr.Lane())
+		l.Queued++
+		if mr.IsClaimed(e.clock()) && l.Current == nil {
+			l.Current = mr
+		}
+	}
+	for _, mr := range ready {
+		lane(mr.Lane()).Ready++
+	}
+
+	lanes := make([]Lane, 0, len(byTarget))
+	for _, l := range byTarget {
+		lanes = append(lanes, *l)
+	}
+	sort.Slice(lanes, func(i, j int) bool {
+		if (lanes[i].Target == e.config.TargetBranch) != (lanes[j].Target == e.config.TargetBranch) {
+			return lanes[i].Target == e.config.TargetBranch
+		}
+		return lanes[i].Target < lanes[j].Target
+	})
+	return lanes, nil
+}
+
+// skipBusyLanes drops ready MRs whose lane already has a claimed MR in
+// all as of now, leaving each busy lane to finish its current merge first.
+func skipBusyLanes(ready, all []*mrqueue.MR, now time.Time) []*mrqueue.MR {
+	busy := make(map[string]bool)
+	for _, mr := range all {
+		if mr.IsClaimed(now) {
+			busy[mr.Lane()] = true
+		}
+	}
+	if len(busy) == 0 {
+		return ready
+	}
+	out := ready[:0]
+	for _, mr := range ready {
+		if !busy[mr.Lane()] {
+			out = append(out, mr)
+		}
+	}
+	return out
+}
+
+// laneDir is the worktree a lane other than the rig's target merges in.
+func (e *Engineer) laneDir(target string) string {
+	return filepath.Join(e.rig.Path, ".runtime", "lanes", strings.ReplaceAll(target, "/", "-"))
+}
+
+// laneFor returns the engineer that merges into target. Without lanes, or
+// for the rig's own target, that is e itself; other lanes get a copy of e
+// working in the lane's own worktree, so merges into different targets
+// never share a checkout. A jj working copy is never merged in: every
+// target gets a lane worktree, which jj does not track.
+func (e *Engineer) laneFor(target string) (*Engineer, error) {
+	if !git.IsJJ(e.workDir) && (!e.lanesEnabled() || target == e.config.TargetBranch) {
+		return e, nil
+	}
+	dir := e.laneDir(target)
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		_ = e.git.WorktreePrune()
+		_ = os.RemoveAll(dir)
+		if err := e.git.WorktreeAddDetached(dir, "origin/"+target); err != nil {
+			return nil, fmt.Errorf("creating worktree for lane %s: %w", target, err)
+		}
+	}
+	lane := *e
+	lane.git = e.gitAt(dir)
+	lane.workDir = dir
+	return &lane, nil
+}
@@ -0,0 +1,58 @@
+package refinery
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+func TestSkipBusyLanes(t *testing.T) {
+	now := time.Now()
+	stale := now.Add(-2 * mrqueue.ClaimStaleTimeout)
+	merging := &mrqueue.MR{ID: "mr-1", Target: "integration/a", ClaimedBy: "refinery-1", ClaimedAt: &now}
+	abandoned := &mrqueue.MR{ID: "mr-2", Target: "integration/b", ClaimedBy: "refinery-2", ClaimedAt: &stale}
+	a := &mrqueue.MR{ID: "mr-3", Target: "integration/a"}
+	b := &mrqueue.MR{ID: "mr-4", Target: "integration/b"}
+	main := &mrqueue.MR{ID: "mr-5", Target: "main"}
+
+	got := skipBusyLanes([]*mrqueue.MR{a, b, main}, []*mrqueue.MR{merging, abandoned, a, b, main}, now)
+	if len(got) != 2 || got[0] != b || got[1] != main {
+		t.Errorf("skipBusyLanes = %v, want integration/b and main (integration/a is merging)", got)
+	}
+}
+
+func TestLaneFor_JJ(t *testing.T) {
+	e, repo := newFakeEngineer(t)
+	for _, dir := range []string{".jj", ".git"} {
+		if err := os.Mkdir(filepath.Join(e.workDir, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// jj keeps git's HEAD detached under its working-copy commit
+	base := repo.Origin("main")
+	if err := e.git.Checkout(base); err != nil {
+		t.Fatal(err)
+	}
+	mr := queueBranch(t, e, repo, "nux", map[string]string{"nux.txt": "nux\n"})
+
+	lane, err := e.laneFor("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lane.workDir != e.laneDir("main") {
+		t.Errorf("laneFor(main) works in %s, want its own worktree", lane.workDir)
+	}
+	if res := e.doMerge(context.Background(), mr); !res.Success {
+		t.Fatalf("doMerge = %+v", res)
+	}
+	if repo.Lookup(repo.Origin("main")).Files["nux.txt"] != "nux\n" {
+		t.Error("origin/main does not have the merge")
+	}
+	if head, _ := e.git.Rev("HEAD"); head != base {
+		t.Errorf("jj working copy moved to %s, want it left at %s", head, base)
+	}
+}
@@ -0,0 +1,308 @@
+package refinery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/polecat"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// MaintenanceStatePath returns where a rig records when each
+// refinery.maintenance job last ran.
+func MaintenanceStatePath(rigPath string) string {
+	return filepath.Join(rigPath, ".runtime", "maintenance.json")
+}
+
+// HistoryArchiveDir returns where compact_history archives a rig's old
+// merge queue events.
+func HistoryArchiveDir(rigPath string) string {
+	return filepath.Join(rigPath, ".runtime", "history")
+}
+
+// maintenanceState is when each maintenance job last ran, by job name.
+type maintenanceState struct {
+	LastRun map[string]time.Time `json:"last_run"`
+}
+
+// MaintenanceResult reports one maintenance job run.
+type MaintenanceResult struct {
+	Job    string `json:"job"`
+	Detail string `json:"detail,omitempty"` // What it did
+	Error  string `json:"error,omitempty"`
+}
+
+// Maintain runs the refinery.maintenance jobs that have come due since
+// they last ran, or every configured job with force, and returns what each
+// did. A job is first due at its first scheduled time after Maintain sees
+// it, so it is safe to call every patrol cycle. A job that fails is
+// reported, doesn't stop the others, and runs again at its next scheduled
+// time.
+func (e *Engineer) Maintain(ctx context.Context, now time.Time, force bool) ([]MaintenanceResult, error) {
+	if e.settings == nil || e.settings.Maintenance == nil || len(e.settings.Maintenance.Jobs()) == 0 {
+		return nil, fmt.Errorf("no refinery.maintenance jobs configured")
+	}
+	mc := e.settings.Maintenance
+	statePath := MaintenanceStatePath(e.rig.Path)
+	var state maintenanceState
+	if data, err := os.ReadFile(statePath); err == nil { //nolint:gosec // G304: path is constructed internally
+		_ = json.Unmarshal(data, &state)
+	}
+	if state.LastRun == nil {
+		state.LastRun = make(map[string]time.Time)
+	}
+
+	var results []MaintenanceResult
+	for _, job := range mc.Jobs() {
+		last, seen := state.LastRun[job.Name]
+		if !force {
+			if !seen {
+				state.LastRun[job.Name] = now // Start the schedule
+				continue
+			}
+			sched, err := config.ParseCron(job.Schedule) // Validated on load
+			if err != nil {
+				continue
+			}
+			if next := sched.Next(last); next.IsZero() || next.After(now) {
+				continue
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		res := MaintenanceResult{Job: job.Name}
+		var err error
+		switch job.Name {
+		case config.MaintenancePruneBranches:
+			res.Detail, err = e.pruneBranches(now)
+		case config.MaintenanceGCMirror:
+			res.Detail, err = e.gcMirror()
+		case config.MaintenanceCompactHistory:
+			res.Detail, err = e.compactHistory(now)
+		case config.MaintenanceRotateLogs:
+			res.Detail, err = e.rotateLogs()
+		}
+		if err != nil {
+			res.Error = err.Error()
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Maintenance: %s failed: %v\n", job.Name, err)
+		} else {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Maintenance: %s: %s\n", job.Name, res.Detail)
+		}
+		state.LastRun[job.Name] = now
+		results = append(results, res)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(statePath), 0755); err != nil {
+		return results, err
+	}
+	return results, util.AtomicWriteJSON(statePath, state)
+}
+
+// pruneBranches deletes the branches on origin matching
+// maintenance.branches that are on the default branch already, or whose
+// tip is older than stale_after. Targets, queued branches, branches checked
+// out in a worktree and branches of polecats still at work are kept: a
+// fresh branch has no commits past the target yet, and would otherwise
+// look merged.
+func (e *Engineer) pruneBranches(now time.Time) (string, error) {
+	mc := e.settings.Maintenance
+	g := git.NewGit(e.workDir)
+	target := e.config.TargetBranch
+	patterns := mc.BranchPatterns()
+	if err := g.FetchRefs("origin", append([]string{target}, patterns...)...); err != nil {
+		return "", fmt.Errorf("fetching origin: %w", err)
+	}
+
+	keep := map[string]bool{target: true}
+	queued, err := e.mrQueue.List()
+	if err != nil {
+		return "", err
+	}
+	for _, mr := range queued {
+		keep[mr.Branch], keep[mr.Target] = true, true
+	}
+	worktrees, err := g.WorktreeList()
+	if err != nil {
+		return "", fmt.Errorf("listing worktrees: %w", err)
+	}
+	for _, wt := range worktrees {
+		if wt.Branch != "" {
+			keep[wt.Branch] = true
+		}
+	}
+	working := e.workingPolecats()
+
+	var branches []string
+	seen := make(map[string]bool)
+	for _, p := range patterns {
+		matched, err := g.ListRemoteBranches("origin", p)
+		if err != nil {
+			return "", fmt.Errorf("listing branches: %w", err)
+		}
+		for _, b := range matched {
+			if !seen[b] && !keep[b] && !ownedBy(b, working) {
+				seen[b] = true
+				branches = append(branches, b)
+			}
+		}
+	}
+	sort.Strings(branches)
+
+	stale := mc.StaleDuration()
+	merged, old := 0, 0
+	for _, branch := range branches {
+		ref := "origin/" + branch
+		isMerged, err := g.IsAncestor(ref, "origin/"+target)
+		if err != nil {
+			return "", fmt.Errorf("checking %s: %w", branch, err)
+		}
+		isStale := false
+		if !isMerged && stale > 0 {
+			at, err := g.CommitTime(ref)
+			if err != nil {
+				return "", fmt.Errorf("checking %s: %w", branch, err)
+			}
+			isStale = now.Sub(at) > stale
+		}
+		if !isMerged && !isStale {
+			continue
+		}
+		if err := g.DeleteRemoteBranch("origin", branch); err != nil {
+			return "", fmt.Errorf("deleting %s: %w", branch, err)
+		}
+		if isMerged {
+			merged++
+		} else {
+			old++
+		}
+	}
+	return fmt.Sprintf("deleted %d merged and %d stale branches", merged, old), nil
+}
+
+// workingPolecats returns the polecats that may still push to their
+// branches: those with a worktree in the rig, and registered workers that
+// aren't dead.
+func (e *Engineer) workingPolecats() []string {
+	var names []string
+	if entries, err := os.ReadDir(filepath.Join(e.rig.Path, "polecats")); err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() {
+				names = append(names, entry.Name())
+			}
+		}
+	}
+	for name, l := range rig.WorkerLivenessMap(e.rig.Path) {
+		if l != rig.WorkerDead {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ownedBy reports whether branch belongs to any of the named polecats.
+func ownedBy(branch string, polecats []string) bool {
+	for _, name := range polecats {
+		if polecat.IsBranchOf(branch, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// gcMirror runs git gc in the bare mirror, if the refinery has made one.
+func (e *Engineer) gcMirror() (string, error) {
+	path := MirrorPath(e.rig.Path)
+	if _, err := os.Stat(filepath.Join(path, "HEAD")); err != nil {
+		return "no mirror yet", nil
+	}
+	if err := git.NewGitWithDir(path, "").GC(); err != nil {
+		return "", err
+	}
+	return "collected " + path, nil
+}
+
+// compactHistory archives merge queue events older than keep_history.
+func (e *Engineer) compactHistory(now time.Time) (string, error) {
+	moved, archive, err := e.eventLogger.Compact(now.Add(-e.settings.Maintenance.KeepHistoryDuration()), HistoryArchiveDir(e.rig.Path))
+	if err != nil {
+		return "", err
+	}
+	if moved == 0 {
+		return "nothing to archive", nil
+	}
+	return fmt.Sprintf("archived %d events to %s", moved, archive), nil
+}
+
+// rotateLogs rotates the non-empty log files matching maintenance.logs,
+// copy-and-truncate, keeping keep_logs old copies.
+func (e *Engineer) rotateLogs() (string, error) {
+	mc := e.settings.Maintenance
+	keep := mc.KeepLogCount()
+	rotated := 0
+	for _, p := range mc.LogPatterns() {
+		files, err := filepath.Glob(filepath.Join(e.rig.Path, p))
+		if err != nil {
+			return "", err
+		}
+		for _, file := range files {
+			info, err := os.Lstat(file)
+			if err != nil || !info.Mode().IsRegular() || info.Size() == 0 {
+				continue
+			}
+			if err := rotateLog(file, keep); err != nil {
+				return "", fmt.Errorf("rotating %s: %w", file, err)
+			}
+			rotated++
+		}
+	}
+	return fmt.Sprintf("rotated %d logs", rotated), nil
+}
+
+// rotateLog shifts file.1 .. file.<keep-1> up one, dropping file.<keep>,
+// copies file to file.1, and truncates it. Truncating in place, rather
+// than renaming, keeps processes that hold file open writing to it.
+func rotateLog(file string, keep int) error {
+	numbered := func(n int) string { return file + "." + strconv.Itoa(n) }
+	if err := os.Remove(numbered(keep)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for n := keep - 1; n >= 1; n-- {
+		if err := os.Rename(numbered(n), numbered(n+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	src, err := os.OpenFile(file, os.O_RDWR, 0) //nolint:gosec // G304: matched under the rig by configured patterns
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	dst, err := os.OpenFile(numbered(1), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return src.Truncate(0)
+}
@@ -0,0 +1,134 @@
+package refinery
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func TestMaintain(t *testing.T) {
+	rigPath := setupConflictRig(t)
+	git := func(args ...string) string {
+		t.Helper()
+		out, err := exec.Command("git", append([]string{"-C", rigPath}, args...)...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	// Already on main, and queued (so kept) though also on main
+	git("push", "origin", "main:polecat/furiosa/gt-2", "main:polecat/slit/gt-3")
+	// Just started, so nothing past main yet: one polecat has a worktree,
+	// the other is a registered worker
+	git("push", "origin", "main:polecat/toast/gt-4", "main:polecat/capable/gt-5")
+	if err := os.MkdirAll(filepath.Join(rigPath, "polecats", "toast"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := rig.RegisterWorker(rigPath, "capable", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: rigPath})
+	e.SetOutput(&strings.Builder{})
+	if err := e.LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	mc := &config.MaintenanceConfig{
+		PruneBranches:  "@hourly",
+		GCMirror:       "@weekly",
+		CompactHistory: "@monthly",
+		KeepHistory:    "1h",
+		RotateLogs:     "@daily",
+	}
+	e.settings = &config.RefinerySettings{Maintenance: mc}
+
+	mr := &mrqueue.MR{Branch: "polecat/slit/gt-3", Target: "main", Worker: "slit"}
+	if err := e.mrQueue.Submit(mr); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if err := e.eventLogger.LogMergeStarted(mr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := e.mirror(); err != nil {
+		t.Fatalf("mirror: %v", err)
+	}
+	logFile := filepath.Join(rigPath, ".runtime", "refinery.log")
+	if err := os.WriteFile(logFile, []byte("yesterday\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Jobs first come due at their next scheduled time. From midday, an
+	// hour on only @hourly is due.
+	y, m, d := time.Now().Date()
+	now := time.Date(y, m, d+1, 12, 10, 0, 0, time.Local)
+	if results, err := e.Maintain(context.Background(), now, false); err != nil || len(results) != 0 {
+		t.Fatalf("first Maintain ran %+v, %v; want nothing until scheduled", results, err)
+	}
+	results, err := e.Maintain(context.Background(), now.Add(61*time.Minute), false)
+	if err != nil {
+		t.Fatalf("Maintain: %v", err)
+	}
+	if len(results) != 1 || results[0].Job != config.MaintenancePruneBranches || results[0].Error != "" {
+		t.Fatalf("an hour later ran %+v, want prune_branches", results)
+	}
+	branches := git("ls-remote", "--heads", "origin")
+	if strings.Contains(branches, "furiosa/gt-2") {
+		t.Error("merged branch was not pruned")
+	}
+	if !strings.Contains(branches, "nux/gt-1") || !strings.Contains(branches, "slit/gt-3") {
+		t.Errorf("pruned an unmerged or queued branch:\n%s", branches)
+	}
+	if !strings.Contains(branches, "toast/gt-4") || !strings.Contains(branches, "capable/gt-5") {
+		t.Errorf("pruned a working polecat's new branch:\n%s", branches)
+	}
+
+	// Forced, two days on: the unmerged branch is stale now
+	mc.StaleAfter = "24h"
+	results, err = e.Maintain(context.Background(), now.Add(48*time.Hour), true)
+	if err != nil {
+		t.Fatalf("Maintain: %v", err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("forced Maintain ran %+v, want all 4 jobs", results)
+	}
+	for _, res := range results {
+		if res.Error != "" {
+			t.Errorf("%s failed: %s", res.Job, res.Error)
+		}
+	}
+	if branches := git("ls-remote", "--heads", "origin"); strings.Contains(branches, "nux/gt-1") {
+		t.Error("stale branch was not pruned")
+	}
+	if !strings.HasPrefix(results[1].Detail, "collected") {
+		t.Errorf("gc_mirror: %q", results[1].Detail)
+	}
+	if events, _ := e.eventLogger.ReadEvents(0); len(events) != 1 {
+		t.Errorf("%d events left after compacting, want the newest", len(events))
+	}
+	if report, err := e.eventLogger.Verify(nil); err != nil || !report.OK() {
+		t.Errorf("compacted log fails verification: %+v, %v", report, err)
+	}
+	if data, _ := os.ReadFile(logFile + ".1"); string(data) != "yesterday\n" {
+		t.Errorf("rotated log = %q", data)
+	}
+	if info, err := os.Stat(logFile); err != nil || info.Size() != 0 {
+		t.Errorf("log was not truncated: %v", err)
+	}
+}
+
+func TestMaintain_NotConfigured(t *testing.T) {
+	e, _ := newFakeEngineer(t)
+	if _, err := e.Maintain(context.Background(), time.Now(), false); err == nil {
+		t.Error("Maintain without refinery.maintenance succeeded")
+	}
+}
@@ -9,10 +9,12 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/claude"
+	"github.com/steveyegge/gastown/internal/clock"
 	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/constants"
 	"github.com/steveyegge/gastown/internal/events"
@@ -29,6 +31,7 @@ var (
 	ErrNotRunning     = errors.New("refinery not running")
 	ErrAlreadyRunning = errors.New("refinery already running")
 	ErrNoQueue        = errors.New("no items in queue")
+	ErrNotPaused      = errors.New("refinery not paused")
 )
 
 // Manager handles refinery lifecycle and queue operations.
@@ -36,17 +39,47 @@ type Manager struct {
 	rig     *rig.Rig
 	workDir string
 	output  io.Writer // Output destination for user-facing messages
+
+	// settings is the [refinery] section of settings/rig.toml (nil if absent).
+	// settingsErr holds the load/validation error so NewManager can stay
+	// infallible; Start refuses to run with an invalid file.
+	settings    *config.RefinerySettings
+	settingsErr error
+
+	// clk is what ages, timestamps, and push retry backoff go by; nil
+	// means the system clock.
+	clk clock.Clock
 }
 
 // NewManager creates a new refinery manager for a rig.
 func NewManager(r *rig.Rig) *Manager {
+	settings, err := config.LoadRefinerySettings(r.Path)
 	return &Manager{
-		rig:     r,
-		workDir: r.Path,
-		output:  os.Stdout,
+		rig:         r,
+		workDir:     r.Path,
+		output:      os.Stdout,
+		settings:    settings,
+		settingsErr: err,
 	}
 }
 
+// SetClock makes the manager tell the time, and wait between push
+// retries, by c.
+func (m *Manager) SetClock(c clock.Clock) {
+	m.clk = c
+}
+
+func (m *Manager) now() time.Time {
+	return clock.Or(m.clk).Now()
+}
+
+// Settings returns the rig's refinery settings from settings/rig.toml,
+// or an error pointing at the offending key if the file is invalid.
+// Returns nil settings when the file has no [refinery] section.
+func (m *Manager) Settings() (*config.RefinerySettings, error) {
+	return m.settings, m.settingsErr
+}
+
 // SetOutput sets the output writer for user-facing messages.
 // This is useful for testing or redirecting output.
 func (m *Manager) SetOutput(w io.Writer) {
@@ -81,6 +114,16 @@ func (m *Manager) loadState() (*Refinery, error) {
 		return nil, err
 	}
 
+	// A timed pause ends the first time anything looks once its deadline
+	// has passed; there is no timer to miss it while nothing is running.
+	if ref.State == StatePaused && ref.PausedUntil != nil && !m.now().Before(*ref.PausedUntil) {
+		ref.State = StateRunning
+		ref.PausedUntil = nil
+		if err := m.saveState(&ref); err != nil {
+			return nil, err
+		}
+	}
+
 	return &ref, nil
 }
 
@@ -91,20 +134,57 @@ func (m *Manager) saveState(ref *Refinery) error {
 		return err
 	}
 
-	return util.AtomicWriteJSON(m.stateFile(), ref)
+	return util.AtomicWriteJSONChecksum(m.stateFile(), redactState(ref))
+}
+
+// redactState returns ref with credentials removed from MR errors, which
+// can quote check output, so refinery.json never holds a secret.
+func redactState(ref *Refinery) *Refinery {
+	if ref.CurrentMR == nil && len(ref.PendingMRs) == 0 {
+		return ref
+	}
+	out := *ref
+	redact := func(mr *MergeRequest) *MergeRequest {
+		if mr == nil || mr.Error == "" {
+			return mr
+		}
+		c := *mr
+		c.Error = util.Redact(c.Error)
+		return &c
+	}
+	out.CurrentMR = redact(ref.CurrentMR)
+	if ref.PendingMRs != nil {
+		out.PendingMRs = make(map[string]*MergeRequest, len(ref.PendingMRs))
+		for id, mr := range ref.PendingMRs {
+			out.PendingMRs[id] = redact(mr)
+		}
+	}
+	return &out
 }
 
 // Status returns the current refinery status.
 // ZFC-compliant: trusts agent-reported state, no PID/tmux inference.
 // The daemon reads agent bead state for liveness checks.
 func (m *Manager) Status() (*Refinery, error) {
-	return m.loadState()
+	ref, err := m.loadState()
+	if err != nil {
+		return nil, err
+	}
+	ref.RigPaused, _ = rig.LoadPause(m.rig.Path)
+	return ref, nil
 }
 
 // Start starts the refinery.
 // If foreground is true, runs in the current process (blocking) using the Go-based polling loop.
 // Otherwise, spawns a Claude agent in a tmux session to process the merge queue.
 func (m *Manager) Start(foreground bool) error {
+	if m.settingsErr != nil {
+		return fmt.Errorf("invalid rig config: %w", m.settingsErr)
+	}
+	if err := m.checkState(); err != nil {
+		return err
+	}
+
 	ref, err := m.loadState()
 	if err != nil {
 		return err
@@ -121,7 +201,7 @@ func (m *Manager) Start(foreground bool) error {
 		}
 
 		// Running in foreground - update state and run the Go-based polling loop
-		now := time.Now()
+		now := m.now()
 		ref.State = StateRunning
 		ref.StartedAt = &now
 		ref.PID = os.Getpid()
@@ -196,7 +276,7 @@ func (m *Manager) Start(foreground bool) error {
 	_ = t.ConfigureGasTownSession(sessionID, theme, m.rig.Name, "refinery", "refinery")
 
 	// Update state to running
-	now := time.Now()
+	now := m.now()
 	ref.State = StateRunning
 	ref.StartedAt = &now
 	ref.PID = 0 // Claude agent doesn't have a PID we track
@@ -268,10 +348,7 @@ func (m *Manager) Stop() error {
 
 	// If we have a PID and it's a different process, try to stop it gracefully
 	if ref.PID > 0 && ref.PID != os.Getpid() && util.ProcessExists(ref.PID) {
-		// Send SIGTERM (best-effort graceful stop)
-		if proc, err := os.FindProcess(ref.PID); err == nil {
-			_ = proc.Signal(os.Interrupt)
-		}
+		stopProcess(ref.PID, stopTimeout)
 	}
 
 	ref.State = StateStopped
@@ -280,6 +357,72 @@ func (m *Manager) Stop() error {
 	return m.saveState(ref)
 }
 
+// stopTimeout is how long Stop waits for a refinery process to exit after
+// SIGTERM before killing it.
+const stopTimeout = 10 * time.Second
+
+// stopProcess sends pid SIGTERM and waits up to timeout for it to exit,
+// then kills it (best-effort: it may have exited already).
+func stopProcess(pid int, timeout time.Duration) {
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return
+	}
+	if err := proc.Signal(syscall.SIGTERM); err != nil {
+		return
+	}
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline); {
+		if !util.ProcessExists(pid) {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	_ = proc.Signal(syscall.SIGKILL)
+}
+
+// Pause suspends merge processing without stopping the refinery session.
+// The paused state is recorded in refinery.json; queued MRs are left untouched.
+func (m *Manager) Pause() error {
+	return m.PauseUntil(time.Time{})
+}
+
+// PauseUntil pauses like Pause, but only until the given time, after which
+// the refinery resumes on its own. A zero until pauses until Resume.
+func (m *Manager) PauseUntil(until time.Time) error {
+	ref, err := m.loadState()
+	if err != nil {
+		return err
+	}
+	if ref.State != StateRunning {
+		return ErrNotRunning
+	}
+	if !until.IsZero() && !until.After(m.now()) {
+		return fmt.Errorf("pause deadline %s has already passed", until.Local().Format(util.TimestampLayout))
+	}
+
+	ref.State = StatePaused
+	ref.PausedUntil = nil
+	if !until.IsZero() {
+		ref.PausedUntil = &until
+	}
+	return m.saveState(ref)
+}
+
+// Resume continues merge processing after Pause.
+func (m *Manager) Resume() error {
+	ref, err := m.loadState()
+	if err != nil {
+		return err
+	}
+	if ref.State != StatePaused {
+		return ErrNotPaused
+	}
+
+	ref.State = StateRunning
+	ref.PausedUntil = nil
+	return m.saveState(ref)
+}
+
 // Queue returns the current merge queue.
 // Uses beads merge-request issues as the source of truth (not git branches).
 func (m *Manager) Queue() ([]QueueItem, error) {
@@ -305,17 +448,20 @@ func (m *Manager) Queue() ([]QueueItem, error) {
 	var items []QueueItem
 	pos := 1
 
+	live := rig.WorkerLivenessMap(m.rig.Path)
+
 	// Add current processing item
 	if ref.CurrentMR != nil {
 		items = append(items, QueueItem{
-			Position: 0, // 0 = currently processing
-			MR:       ref.CurrentMR,
-			Age:      formatAge(ref.CurrentMR.CreatedAt),
+			Position:       0, // 0 = currently processing
+			MR:             ref.CurrentMR,
+			Age:            util.RelativeTime(ref.CurrentMR.CreatedAt, m.now()),
+			WorkerLiveness: workerLiveness(live, ref.CurrentMR.Worker),
 		})
 	}
 
 	// Score and sort issues by priority score (highest first)
-	now := time.Now()
+	now := m.now()
 	type scoredIssue struct {
 		issue *beads.Issue
 		score float64
@@ -331,6 +477,7 @@ func (m *Manager) Queue() ([]QueueItem, error) {
 	})
 
 	// Convert scored issues to queue items
+	var pending []QueueItem
 	for _, s := range scored {
 		mr := m.issueToMR(s.issue)
 		if mr != nil {
@@ -338,15 +485,21 @@ func (m *Manager) Queue() ([]QueueItem, error) {
 			if ref.CurrentMR != nil && ref.CurrentMR.ID == mr.ID {
 				continue
 			}
-			items = append(items, QueueItem{
-				Position: pos,
-				MR:       mr,
-				Age:      formatAge(mr.CreatedAt),
+			pending = append(pending, QueueItem{
+				MR:             mr,
+				Age:            util.RelativeTime(mr.CreatedAt, now),
+				WorkerLiveness: workerLiveness(live, mr.Worker),
 			})
-			pos++
 		}
 	}
 
+	// Each swarm's MRs merge consecutively, as in Engineer.ListReadyMRs
+	for _, item := range mrqueue.GroupBy(pending, func(it QueueItem) string { return it.MR.SwarmID }) {
+		item.Position = pos
+		items = append(items, item)
+		pos++
+	}
+
 	return items, nil
 }
 
@@ -410,11 +563,17 @@ func (m *Manager) issueToMR(issue *beads.Issue) *MergeRequest {
 		target = defaultBranch
 	}
 
+	swarmID := fields.SwarmID
+	if swarmID == "" {
+		swarmID = mrqueue.SwarmOf(fields.Branch, target)
+	}
+
 	return &MergeRequest{
 		ID:           issue.ID,
 		Branch:       fields.Branch,
 		Worker:       fields.Worker,
 		IssueID:      fields.SourceIssue,
+		SwarmID:      swarmID,
 		TargetBranch: target,
 		Status:       MROpen,
 		CreatedAt:    parseTime(issue.CreatedAt),
@@ -484,7 +643,7 @@ func (m *Manager) completeMR(mr *MergeRequest, closeReason CloseReason, errMsg s
 	mr.Error = errMsg
 	ref.CurrentMR = nil
 
-	now := time.Now()
+	now := m.now()
 	actor := fmt.Sprintf("%s/refinery", m.rig.Name)
 
 	if closeReason != "" {
@@ -556,7 +715,7 @@ func (m *Manager) pushWithRetry(targetBranch string, config MergeConfig) error {
 	for attempt := 0; attempt <= config.PushRetryCount; attempt++ {
 		if attempt > 0 {
 			_, _ = fmt.Fprintf(m.output, "Push retry %d/%d after %v\n", attempt, config.PushRetryCount, delay)
-			time.Sleep(delay)
+			<-clock.Or(m.clk).After(delay)
 			delay *= 2 // Exponential backoff
 		}
 
@@ -570,22 +729,6 @@ func (m *Manager) pushWithRetry(targetBranch string, config MergeConfig) error {
 	return fmt.Errorf("push failed after %d retries: %v", config.PushRetryCount, lastErr)
 }
 
-// formatAge formats a duration since the given time.
-func formatAge(t time.Time) string {
-	d := time.Since(t)
-
-	if d < time.Minute {
-		return fmt.Sprintf("%ds ago", int(d.Seconds()))
-	}
-	if d < time.Hour {
-		return fmt.Sprintf("%dm ago", int(d.Minutes()))
-	}
-	if d < 24*time.Hour {
-		return fmt.Sprintf("%dh ago", int(d.Hours()))
-	}
-	return fmt.Sprintf("%dd ago", int(d.Hours()/24))
-}
-
 // notifyWorkerConflict sends a conflict notification to a polecat.
 func (m *Manager) notifyWorkerConflict(mr *MergeRequest) {
 	router := mail.NewRouter(m.workDir)
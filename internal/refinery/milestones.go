@@ -0,0 +1,297 @@
+package refinery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// Milestone is a tag a [[refinery.milestones]] rule made.
+type Milestone struct {
+	Rule   string `json:"rule"`
+	Tag    string `json:"tag"`
+	Commit string `json:"commit"`
+	Merges int    `json:"merges"` // Merges since the rule's previous tag
+}
+
+// MilestoneStatus is where a rule stands: its last tag, and the merges
+// counting toward the next.
+type MilestoneStatus struct {
+	Rule     string    `json:"rule"`
+	When     string    `json:"when"` // The condition, e.g. "every 10 merges into main"
+	LastTag  string    `json:"last_tag,omitempty"`
+	TaggedAt time.Time `json:"tagged_at,omitempty"`
+	Merges   int       `json:"merges"`
+	Due      bool      `json:"due"`
+}
+
+// milestoneState is what a rule remembers between checks. Events after At
+// count toward its next tag.
+type milestoneState struct {
+	Tag string    `json:"tag,omitempty"`
+	At  time.Time `json:"at,omitempty"`  // When the event it last tagged happened
+	Day string    `json:"day,omitempty"` // YYYY-MM-DD of the last daily tag
+	Seq int       `json:"seq"`
+}
+
+// MilestonesStatePath returns where a rig records its milestone rules'
+// last tags.
+func MilestonesStatePath(rigPath string) string {
+	return filepath.Join(rigPath, ".runtime", "milestones.json")
+}
+
+// milestoneDue is a rule whose condition is met: the event whose merge
+// commit it tags, and the events in its range for the tag message and
+// release notes.
+type milestoneDue struct {
+	tagged *mrqueue.Event
+	events []mrqueue.Event
+	merges []mrqueue.Event
+}
+
+// milestoneTarget returns the branch whose merges m counts.
+func (e *Engineer) milestoneTarget(m *config.MilestoneConfig) string {
+	if m.Target != "" {
+		return m.Target
+	}
+	return e.config.TargetBranch
+}
+
+// dueMilestone returns what m would tag now, or nil if its condition is
+// not met, along with the merges counting toward its next tag.
+func (e *Engineer) dueMilestone(m *config.MilestoneConfig, st milestoneState, events []mrqueue.Event, now time.Time) (*milestoneDue, []mrqueue.Event) {
+	var since, merges []mrqueue.Event
+	target := e.milestoneTarget(m)
+	for _, ev := range events {
+		if !ev.Timestamp.After(st.At) {
+			continue
+		}
+		if m.Epic != "" {
+			if mrqueue.SwarmOf(ev.Branch, ev.Target) != m.Epic {
+				continue
+			}
+		} else if ev.Target != target {
+			continue
+		}
+		since = append(since, ev)
+		if ev.Type == mrqueue.EventMerged {
+			merges = append(merges, ev)
+		}
+	}
+
+	switch {
+	case m.Every > 0:
+		if len(merges) < m.Every {
+			return nil, merges
+		}
+		tagged := merges[m.Every-1]
+		var upTo []mrqueue.Event
+		for _, ev := range since {
+			if !ev.Timestamp.After(tagged.Timestamp) {
+				upTo = append(upTo, ev)
+			}
+		}
+		return &milestoneDue{tagged: &tagged, events: upTo, merges: merges[:m.Every]}, merges
+
+	case m.Daily != "":
+		now = now.Local()
+		if len(merges) == 0 || st.Day == now.Format("2006-01-02") || now.Hour()*60+now.Minute() < m.DailyMinute() {
+			return nil, merges
+		}
+		return &milestoneDue{tagged: &merges[len(merges)-1], events: since, merges: merges}, merges
+
+	default: // Epic: tagged once, when its swarm lands
+		if st.Tag != "" {
+			return nil, merges
+		}
+		for i := range since {
+			if since[i].Type == mrqueue.EventSwarmLanded {
+				return &milestoneDue{tagged: &since[i], events: since, merges: merges}, merges
+			}
+		}
+		return nil, merges
+	}
+}
+
+func (e *Engineer) loadMilestoneState() (map[string]milestoneState, error) {
+	state := make(map[string]milestoneState)
+	data, err := os.ReadFile(MilestonesStatePath(e.rig.Path)) //nolint:gosec // G304: path is constructed internally
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", MilestonesStatePath(e.rig.Path), err)
+	}
+	return state, nil
+}
+
+func (e *Engineer) saveMilestoneState(state map[string]milestoneState) error {
+	if err := os.MkdirAll(filepath.Dir(MilestonesStatePath(e.rig.Path)), 0755); err != nil {
+		return err
+	}
+	return util.AtomicWriteJSON(MilestonesStatePath(e.rig.Path), state)
+}
+
+// MilestoneStatuses reports where each of refinery.milestones stands.
+func (e *Engineer) MilestoneStatuses(now time.Time) ([]MilestoneStatus, error) {
+	if e.settings == nil || len(e.settings.Milestones) == 0 {
+		return nil, nil
+	}
+	events, err := e.eventLogger.ReadEvents(0)
+	if err != nil {
+		return nil, fmt.Errorf("reading merge events: %w", err)
+	}
+	state, err := e.loadMilestoneState()
+	if err != nil {
+		return nil, err
+	}
+	var out []MilestoneStatus
+	for i := range e.settings.Milestones {
+		m := &e.settings.Milestones[i]
+		st := state[m.Name]
+		due, merges := e.dueMilestone(m, st, events, now)
+		s := MilestoneStatus{Rule: m.Name, LastTag: st.Tag, TaggedAt: st.At, Merges: len(merges), Due: due != nil}
+		switch {
+		case m.Every > 0:
+			s.When = fmt.Sprintf("every %d merges into %s", m.Every, e.milestoneTarget(m))
+		case m.Daily != "":
+			s.When = fmt.Sprintf("daily at %s if anything merged into %s", m.Daily, e.milestoneTarget(m))
+		default:
+			s.When = fmt.Sprintf("when epic %s lands", m.Epic)
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// CheckMilestones makes the tags refinery.milestones call for as of now,
+// pushing each to origin and, for rules with notes set, mailing the
+// release notes for its range. A tag that cannot be made is reported and
+// retried on the next check; the rule's state only moves on once its tag
+// is pushed.
+func (e *Engineer) CheckMilestones(ctx context.Context, now time.Time) ([]Milestone, error) {
+	if e.settings == nil || len(e.settings.Milestones) == 0 {
+		return nil, nil
+	}
+	events, err := e.eventLogger.ReadEvents(0)
+	if err != nil {
+		return nil, fmt.Errorf("reading merge events: %w", err)
+	}
+	state, err := e.loadMilestoneState()
+	if err != nil {
+		return nil, err
+	}
+
+	var made []Milestone
+	for i := range e.settings.Milestones {
+		m := &e.settings.Milestones[i]
+		for {
+			st := state[m.Name]
+			due, _ := e.dueMilestone(m, st, events, now)
+			if due == nil {
+				break
+			}
+			tag, err := e.makeMilestone(ctx, m, st, due, now)
+			if err != nil {
+				_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: milestone %s: %v\n", m.Name, err)
+				break
+			}
+			made = append(made, Milestone{Rule: m.Name, Tag: tag, Commit: due.tagged.MergeCommit, Merges: len(due.merges)})
+
+			st.Tag, st.At, st.Seq = tag, due.tagged.Timestamp, st.Seq+1
+			if m.Daily != "" {
+				st.Day = now.Local().Format("2006-01-02")
+			}
+			state[m.Name] = st
+			if err := e.saveMilestoneState(state); err != nil {
+				return made, fmt.Errorf("recording milestone %s: %w", tag, err)
+			}
+			if m.Every == 0 {
+				break // Daily and epic rules tag at most once per check
+			}
+		}
+	}
+	return made, nil
+}
+
+// makeMilestone creates and pushes the tag for due, then sends its release
+// notes if the rule asks for them.
+func (e *Engineer) makeMilestone(ctx context.Context, m *config.MilestoneConfig, st milestoneState, due *milestoneDue, now time.Time) (string, error) {
+	target := e.milestoneTarget(m)
+	if m.Epic != "" {
+		target = due.tagged.Target
+	}
+	tag, err := m.TagName(config.MilestoneTag{
+		Name:   m.Name,
+		Seq:    st.Seq + 1,
+		Date:   now.Local().Format("2006-01-02"),
+		Epic:   m.Epic,
+		Target: target,
+		Merges: len(due.merges),
+	})
+	if err != nil {
+		return "", fmt.Errorf("tag name: %w", err)
+	}
+	if due.tagged.MergeCommit == "" {
+		return "", fmt.Errorf("no merge commit recorded for %s", due.tagged.Branch)
+	}
+
+	var msg strings.Builder
+	if m.Epic != "" {
+		fmt.Fprintf(&msg, "%s: epic %s landed on %s\n", m.Name, m.Epic, target)
+	} else {
+		fmt.Fprintf(&msg, "%s: %d merges into %s\n", m.Name, len(due.merges), target)
+	}
+	if len(due.merges) > 0 {
+		msg.WriteString("\n")
+	}
+	for _, ev := range due.merges {
+		msg.WriteString("- " + ev.Branch)
+		if ev.SourceIssue != "" {
+			fmt.Fprintf(&msg, " (%s)", ev.SourceIssue)
+		}
+		msg.WriteString("\n")
+	}
+
+	if err := e.git.CreateTag(tag, due.tagged.MergeCommit, msg.String()); err != nil {
+		return "", fmt.Errorf("creating tag %s: %w", tag, err)
+	}
+	if err := e.git.PushTag("origin", tag); err != nil {
+		return "", fmt.Errorf("pushing tag %s: %w", tag, err)
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Tagged %s at %s (milestone %s)\n", tag, shortSHA(due.tagged.MergeCommit), m.Name)
+
+	if m.Notes {
+		e.sendReleaseNotes(ctx, BuildReleaseNotes(e.rig.Name, tag, st.Tag, due.events, now))
+	}
+	return tag, nil
+}
+
+// sendReleaseNotes mails d to refinery.notifications.digest, written by
+// digest_command if one is set.
+func (e *Engineer) sendReleaseNotes(ctx context.Context, d *Digest) {
+	n := e.settings.Notifications
+	if n == nil || len(n.Digest) == 0 {
+		return
+	}
+	body := d.Text()
+	if n.DigestCommand != "" {
+		if written, err := e.writeDigest(ctx, d, body); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: %v (sending the templated release notes)\n", err)
+		} else if written != "" {
+			body = written
+		}
+	}
+	e.notify(n.Digest, d.Subject(), body)
+}
@@ -0,0 +1,87 @@
+package refinery
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+func TestCheckMilestones(t *testing.T) {
+	e, repo := newFakeEngineer(t)
+	e.settings.Milestones = []config.MilestoneConfig{
+		{Name: "build", Every: 2},
+		{Name: "nightly", Daily: "18:00", Tag: "nightly-{{.Date}}"},
+		{Name: "search", Epic: "gt-epic"},
+	}
+	day := time.Date(2026, 3, 2, 9, 0, 0, 0, time.Local)
+	merge := func(at time.Time, branch, target string) string {
+		sha := repo.Commit(target, "Merge "+branch, map[string]string{branch + ".txt": branch + "\n"})
+		ev := mrqueue.Event{Timestamp: at, Type: mrqueue.EventMerged, Branch: branch, Target: target, MergeCommit: sha}
+		if err := e.eventLogger.LogEvent(ev); err != nil {
+			t.Fatal(err)
+		}
+		return sha
+	}
+	check := func(now time.Time) map[string]string {
+		t.Helper()
+		made, err := e.CheckMilestones(context.Background(), now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tags := make(map[string]string)
+		for _, m := range made {
+			tags[m.Tag] = m.Commit
+		}
+		return tags
+	}
+
+	var shas []string
+	for i := 0; i < 5; i++ {
+		shas = append(shas, merge(day.Add(time.Duration(i)*time.Minute), fmt.Sprintf("polecat/nux/gt-%d", i), "main"))
+	}
+	want := map[string]string{"build-1": shas[1], "build-2": shas[3]}
+	if got := check(day.Add(time.Hour)); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("morning tags = %v, want %v", got, want)
+	}
+
+	// The nightly tag goes on the day's last merge, once, after 18:00
+	evening := day.Add(9 * time.Hour)
+	if got := check(evening); got["nightly-2026-03-02"] != shas[4] || len(got) != 1 {
+		t.Errorf("evening tags = %v, want nightly-2026-03-02 at %s", got, shas[4])
+	}
+	if got := check(evening.Add(time.Hour)); len(got) != 0 {
+		t.Errorf("second evening check tagged %v", got)
+	}
+
+	// The epic's tag goes on its swarm's landing, with its members' merges
+	// in the message
+	merge(day.Add(10*time.Minute), "polecat/slit/gt-7", "integration/gt-epic")
+	landing := merge(day.Add(11*time.Minute), "integration/gt-epic", "main")
+	if err := e.eventLogger.LogEvent(mrqueue.Event{Timestamp: day.Add(11 * time.Minute), Type: mrqueue.EventSwarmLanded,
+		Branch: "integration/gt-epic", Target: "main", MergeCommit: landing}); err != nil {
+		t.Fatal(err)
+	}
+	if got := check(evening.Add(2 * time.Hour)); got["search-1"] != landing {
+		t.Errorf("tags after landing = %v, want search-1 at %s", got, landing)
+	}
+
+	tags := repo.Tags()
+	if tag := tags["search-1"]; !tag.Pushed || !strings.Contains(tag.Message, "- polecat/slit/gt-7\n") {
+		t.Errorf("search-1 = %+v, want it pushed, listing the swarm's merges", tag)
+	}
+	if tag := tags["build-2"]; !strings.HasPrefix(tag.Message, "build: 2 merges into main\n\n- polecat/nux/gt-2\n- polecat/nux/gt-3\n") {
+		t.Errorf("build-2 message = %q", tag.Message)
+	}
+	statuses, err := e.MilestoneStatuses(evening)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := statuses[0]; s.LastTag != "build-3" || s.Merges != 0 {
+		t.Errorf("build status = %+v, want build-3 with nothing toward the next tag", s)
+	}
+}
@@ -0,0 +1,54 @@
+package refinery
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/steveyegge/gastown/internal/git"
+)
+
+// The refinery test-merges branches (conflict checks, cross-swarm scans)
+// in a bare mirror of the rig's repository rather than in its checkout.
+// Without a working tree the mirror has no index or HEAD for that work to
+// fight over, and the trees merges write stay out of the rig's object
+// store. Each use fetches the rig's branches into it, so it is only ever
+// as far behind as the branches that moved since.
+
+// MirrorPath returns where a rig's bare mirror is kept.
+func MirrorPath(rigPath string) string {
+	return filepath.Join(rigPath, ".runtime", "mirror.git")
+}
+
+// mirror returns the rig's bare mirror with its branches brought up to
+// date with the rig's repository, cloning it on first use.
+func (e *Engineer) mirror() (*git.Git, error) {
+	path := MirrorPath(e.rig.Path)
+	if _, err := os.Stat(filepath.Join(path, "HEAD")); err != nil {
+		_ = os.RemoveAll(path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, err
+		}
+		if err := e.git.CloneBare(e.workDir, path); err != nil {
+			return nil, fmt.Errorf("creating mirror: %w", err)
+		}
+		return git.NewGitWithDir(path, ""), nil
+	}
+	m := git.NewGitWithDir(path, "")
+	if err := m.FetchPrune("origin", "+refs/heads/*:refs/heads/*"); err != nil {
+		return nil, fmt.Errorf("updating mirror: %w", err)
+	}
+	return m, nil
+}
+
+// mergeConflicts returns the files source conflicts with target in. It
+// test-merges in the mirror, or if the mirror cannot be used (git older
+// than 2.38, say), in the engineer's worktree, which is left on target.
+func (e *Engineer) mergeConflicts(source, target string) ([]string, error) {
+	if m, err := e.mirror(); err == nil {
+		if files, err := m.MergeTreeConflicts(source, target); err == nil {
+			return files, nil
+		}
+	}
+	return e.git.CheckConflicts(source, target)
+}
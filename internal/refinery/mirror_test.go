@@ -0,0 +1,49 @@
+package refinery
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func TestMergeConflicts_UsesMirror(t *testing.T) {
+	rigPath := setupConflictRig(t)
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: rigPath})
+	e.SetOutput(&strings.Builder{})
+	if err := e.git.Checkout("polecat/nux/gt-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := e.mergeConflicts("polecat/nux/gt-1", "main")
+	if err != nil {
+		t.Fatalf("mergeConflicts: %v", err)
+	}
+	if !slices.Equal(files, []string{"file.txt"}) {
+		t.Errorf("conflicts = %v, want [file.txt]", files)
+	}
+	if _, err := os.Stat(MirrorPath(rigPath)); err != nil {
+		t.Errorf("mirror not created: %v", err)
+	}
+	// The test merge happened in the mirror, not the checkout.
+	if branch, _ := e.git.CurrentBranch(); branch != "polecat/nux/gt-1" {
+		t.Errorf("checkout moved to %s", branch)
+	}
+
+	// Later branches reach the mirror when it is next used.
+	if err := e.git.CreateBranchFrom("polecat/slit/gt-2", "main"); err != nil {
+		t.Fatal(err)
+	}
+	if files, err := e.mergeConflicts("polecat/slit/gt-2", "main"); err != nil || len(files) > 0 {
+		t.Errorf("mergeConflicts(new branch) = %v, %v; want no conflicts", files, err)
+	}
+	m, err := e.mirror()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Rev("polecat/slit/gt-2"); err != nil {
+		t.Errorf("new branch not in mirror: %v", err)
+	}
+}
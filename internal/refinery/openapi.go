@@ -0,0 +1,289 @@
+package refinery
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// OpenAPIVersion is the version reported in the generated API document.
+// Bump it when endpoints or payloads change incompatibly.
+const OpenAPIVersion = "1.0.0"
+
+// openAPIPath serves the generated document. It is public so client
+// generators can fetch it without a token.
+const openAPIPath = "/api/openapi.json"
+
+// apiRoute describes one API endpoint. The route table drives both handler
+// registration and the OpenAPI document, so the two cannot drift apart.
+type apiRoute struct {
+	Method   string
+	Path     string
+	Summary  string
+	Query    []apiParam
+	Request  interface{} // Zero value of the request body type, nil if none
+	Response interface{} // Zero value of the response type (per item for streams)
+	Status   int         // Success status (default 200)
+	Stream   string      // Content type for streaming responses
+	Public   bool        // No token required
+	Scope    TokenScope  // Scope required beyond the method default (admin routes)
+	handle   func(*Server, http.ResponseWriter, *http.Request)
+}
+
+// apiParam is a query string parameter.
+type apiParam struct {
+	Name        string
+	Type        string // "integer" or "string"
+	Description string
+}
+
+// apiRoutes returns every JSON API endpoint served by Server.
+func apiRoutes() []apiRoute {
+	return []apiRoute{
+		{Method: "GET", Path: "/api/status", Summary: "Refinery state", Response: Refinery{}, handle: (*Server).handleStatus},
+		{Method: "GET", Path: "/api/queue", Summary: "Pending MRs, highest score first", Response: []mrqueue.MR{}, handle: (*Server).handleQueue},
+		{Method: "POST", Path: "/api/queue", Summary: "Enqueue an MR", Request: EnqueueRequest{}, Response: mrqueue.MR{}, Status: http.StatusCreated, handle: (*Server).handleEnqueue},
+		{Method: "GET", Path: "/api/queue/watch", Summary: "Stream queue changes", Response: QueueChange{}, Stream: "application/x-ndjson", handle: (*Server).handleQueueWatch},
+		{Method: "GET", Path: "/api/queue/{id}", Summary: "Get a single MR", Response: mrqueue.MR{}, handle: (*Server).handleGetMR},
+		{Method: "POST", Path: "/api/queue/{id}/hold", Summary: "Hold an MR", Request: HoldRequest{}, Response: mrqueue.MR{}, handle: (*Server).handleHold},
+		{Method: "POST", Path: "/api/queue/{id}/requeue", Summary: "Clear hold, claim, and block so the MR is retried", Response: mrqueue.MR{}, handle: (*Server).handleRequeue},
+		{Method: "POST", Path: "/api/queue/{id}/approve", Summary: "Approve an MR past the refinery's gates", Request: ApproveRequest{}, Response: mrqueue.MR{}, Scope: ScopeAdmin, handle: (*Server).handleApprove},
+		{Method: "DELETE", Path: "/api/queue/{id}", Summary: "Drop an MR from the queue", Request: DropRequest{}, Response: mrqueue.MR{}, Scope: ScopeAdmin, handle: (*Server).handleDrop},
+		{Method: "POST", Path: "/api/pause", Summary: "Pause processing, until a time if given", Request: PauseRequest{}, Response: Refinery{}, handle: (*Server).handlePause},
+		{Method: "POST", Path: "/api/resume", Summary: "Resume processing", Response: Refinery{}, handle: (*Server).handleResume},
+		{Method: "GET", Path: "/api/history", Summary: "Recent merge queue events, oldest first",
+			Query:    []apiParam{{Name: "limit", Type: "integer", Description: "Maximum events to return (0 = all, default 100)"}},
+			Response: []mrqueue.Event{}, handle: (*Server).handleHistory},
+		{Method: "GET", Path: "/api/merges/watch", Summary: "Stream merge lifecycle events", Response: mrqueue.Event{}, Stream: "application/x-ndjson", handle: (*Server).handleMergeWatch},
+		{Method: "GET", Path: "/api/stats", Summary: "Merge totals, per-day counts, per-worker quality, and swarm runs",
+			Query:    []apiParam{{Name: "days", Type: "integer", Description: "Days in the daily breakdown (default 7)"}},
+			Response: Stats{}, handle: (*Server).handleStats},
+		{Method: "GET", Path: "/api/access", Summary: "What the request's token may do", Response: Access{}, handle: (*Server).handleAccess},
+		{Method: "GET", Path: openAPIPath, Summary: "This document", Public: true, handle: (*Server).handleOpenAPI},
+		{Method: "GET", Path: "/events", Summary: "Server-sent events: status, queue, and merge events", Stream: "text/event-stream", handle: (*Server).handleEvents},
+	}
+}
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, OpenAPIDocument())
+}
+
+// OpenAPIDocument generates the OpenAPI 3 description of the refinery API.
+func OpenAPIDocument() map[string]interface{} {
+	gen := &schemaGen{schemas: map[string]interface{}{}}
+	paths := map[string]interface{}{}
+
+	for _, rt := range apiRoutes() {
+		op := map[string]interface{}{
+			"summary":     rt.Summary,
+			"operationId": operationID(rt),
+		}
+
+		var params []interface{}
+		for _, name := range pathParams(rt.Path) {
+			params = append(params, map[string]interface{}{
+				"name": name, "in": "path", "required": true,
+				"schema": map[string]interface{}{"type": "string"},
+			})
+		}
+		for _, q := range rt.Query {
+			params = append(params, map[string]interface{}{
+				"name": q.Name, "in": "query", "description": q.Description,
+				"schema": map[string]interface{}{"type": q.Type},
+			})
+		}
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+
+		if rt.Request != nil {
+			op["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": gen.schema(reflect.TypeOf(rt.Request))},
+				},
+			}
+		}
+
+		status := rt.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		response := map[string]interface{}{"description": http.StatusText(status)}
+		contentType := "application/json"
+		if rt.Stream != "" {
+			contentType = rt.Stream
+			response["description"] = "Stream of records, one per line or event"
+		}
+		if rt.Response != nil {
+			response["content"] = map[string]interface{}{
+				contentType: map[string]interface{}{"schema": gen.schema(reflect.TypeOf(rt.Response))},
+			}
+		} else if rt.Stream != "" {
+			response["content"] = map[string]interface{}{contentType: map[string]interface{}{}}
+		}
+		responses := map[string]interface{}{strconv.Itoa(status): response}
+		errorRef := map[string]interface{}{"$ref": "#/components/responses/Error"}
+		if !rt.Public {
+			responses["401"] = errorRef
+			responses["403"] = errorRef
+		}
+		if rt.Method != "GET" || len(rt.Query) > 0 {
+			responses["400"] = errorRef
+		}
+		if len(pathParams(rt.Path)) > 0 {
+			responses["404"] = errorRef
+		}
+		op["responses"] = responses
+
+		switch {
+		case rt.Public:
+			op["security"] = []interface{}{}
+		case rt.Method == "GET":
+			// Open until the first token is issued.
+			op["security"] = []interface{}{map[string]interface{}{}, map[string]interface{}{"bearerAuth": []string{}}}
+		default:
+			op["security"] = []interface{}{map[string]interface{}{"bearerAuth": []string{}}}
+		}
+		if rt.Scope != "" {
+			op["description"] = "Requires a " + string(rt.Scope) + "-scoped token."
+		}
+
+		item, _ := paths[rt.Path].(map[string]interface{})
+		if item == nil {
+			item = map[string]interface{}{}
+			paths[rt.Path] = item
+		}
+		item[strings.ToLower(rt.Method)] = op
+	}
+
+	gen.schemas["Error"] = gen.structSchema(reflect.TypeOf(apiError{}))
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Gas Town Refinery API",
+			"version":     OpenAPIVersion,
+			"description": "Served by `gt refinery serve`. Mutations require an operate-scoped token from `gt refinery token create`; approving and dropping MRs require an admin token.",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": gen.schemas,
+			"responses": map[string]interface{}{
+				"Error": map[string]interface{}{
+					"description": "Error",
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{"schema": map[string]interface{}{"$ref": "#/components/schemas/Error"}},
+					},
+				},
+			},
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+// operationID derives a stable camelCase operation ID from method and path,
+// e.g. POST /api/queue/{id}/hold -> postQueueIdHold.
+func operationID(rt apiRoute) string {
+	id := strings.ToLower(rt.Method)
+	for _, part := range strings.Split(strings.TrimPrefix(rt.Path, "/api"), "/") {
+		part = strings.Trim(part, "{}")
+		part = strings.TrimSuffix(part, ".json")
+		if part == "" {
+			continue
+		}
+		id += strings.ToUpper(part[:1]) + part[1:]
+	}
+	return id
+}
+
+// pathParams returns the {name} segments of a route path.
+func pathParams(path string) []string {
+	var names []string
+	for _, part := range strings.Split(path, "/") {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			names = append(names, strings.Trim(part, "{}"))
+		}
+	}
+	return names
+}
+
+// schemaGen builds JSON schemas from Go types using their json tags.
+// Named struct types become shared components referenced by $ref.
+type schemaGen struct {
+	schemas map[string]interface{}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (g *schemaGen) schema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		name := t.Name()
+		if _, ok := g.schemas[name]; !ok {
+			g.schemas[name] = nil // Reserve to break cycles
+			g.schemas[name] = g.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	case t.Kind() == reflect.Struct:
+		return g.structSchema(t)
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func (g *schemaGen) structSchema(t reflect.Type) map[string]interface{} {
+	props := map[string]interface{}{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = g.schema(f.Type)
+		if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Ptr {
+			required = append(required, name)
+		}
+	}
+	s := map[string]interface{}{"type": "object", "properties": props}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
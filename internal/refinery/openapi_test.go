@@ -0,0 +1,109 @@
+package refinery
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPIDocument_CoversRoutes(t *testing.T) {
+	doc := OpenAPIDocument()
+	paths := doc["paths"].(map[string]interface{})
+
+	for _, rt := range apiRoutes() {
+		item, ok := paths[rt.Path].(map[string]interface{})
+		if !ok {
+			t.Errorf("path %s missing from document", rt.Path)
+			continue
+		}
+		if _, ok := item[strings.ToLower(rt.Method)]; !ok {
+			t.Errorf("%s %s missing from document", rt.Method, rt.Path)
+		}
+	}
+
+	schemas := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	for _, name := range []string{"MR", "Refinery", "Event", "EnqueueRequest", "Error"} {
+		if schemas[name] == nil {
+			t.Errorf("schema %s missing", name)
+		}
+	}
+
+	// The whole document must be valid JSON.
+	if _, err := json.Marshal(doc); err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+}
+
+func TestOperationID(t *testing.T) {
+	rt := apiRoute{Method: "POST", Path: "/api/queue/{id}/hold"}
+	if got := operationID(rt); got != "postQueueIdHold" {
+		t.Errorf("operationID = %q, want postQueueIdHold", got)
+	}
+}
+
+func TestServer_OpenAPIIsPublic(t *testing.T) {
+	srv := newTestServer(t)
+	newToken(t, srv, "viewer", ScopeRead) // Close read endpoints
+
+	w := doRequest(t, srv, "", "GET", "/api/openapi.json", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"openapi": "3.0.3"`) {
+		t.Errorf("GET /api/openapi.json = %d, body = %.200s", w.Code, w.Body.String())
+	}
+}
+
+// TestProto_CoversMutations keeps refinery.proto from falling behind the
+// route table: every mutation and NDJSON stream must be named in an RPC's
+// comment, and every request body field must be in the proto message of
+// the same name.
+func TestProto_CoversMutations(t *testing.T) {
+	data, err := os.ReadFile("refinery.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	proto := string(data)
+
+	for _, rt := range apiRoutes() {
+		if rt.Method == "GET" && rt.Stream != "application/x-ndjson" {
+			continue
+		}
+		if !protoNamesRoute(proto, rt) {
+			t.Errorf("%s %s has no RPC in refinery.proto", rt.Method, rt.Path)
+		}
+		if rt.Request == nil {
+			continue
+		}
+		typ := reflect.TypeOf(rt.Request)
+		m := regexp.MustCompile(`(?s)\nmessage ` + typ.Name() + ` \{(.*?)\n\}`).FindStringSubmatch(proto)
+		if m == nil {
+			t.Errorf("message %s missing from refinery.proto", typ.Name())
+			continue
+		}
+		for i := 0; i < typ.NumField(); i++ {
+			name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+			if !regexp.MustCompile(`\b` + name + ` = \d+;`).MatchString(m[1]) {
+				t.Errorf("%s.%s missing from refinery.proto", typ.Name(), name)
+			}
+		}
+	}
+}
+
+// protoNamesRoute reports whether an RPC comment in proto names rt's
+// method and path.
+func protoNamesRoute(proto string, rt apiRoute) bool {
+	for _, line := range strings.Split(proto, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "//") || !strings.Contains(line, rt.Method) {
+			continue
+		}
+		for _, field := range strings.FieldsFunc(line, func(r rune) bool { return r == ' ' || r == ',' }) {
+			if field == rt.Path {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package refinery
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// pipelineRun is the next MR's conflict check and checks, started with
+// refinery.pipeline while the MR ahead of it is pushed.
+type pipelineRun struct {
+	mrID      string
+	branchSHA string // The MR's branch when its checks started
+	tip       string // The target commit they ran against
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	log    strings.Builder // Check output, written until done
+	result ProcessResult
+}
+
+// stop cancels the run and waits for its checks to exit.
+func (run *pipelineRun) stop() {
+	run.cancel()
+	<-run.done
+}
+
+// pipelineDir is the scratch worktree pipelined checks for target run in.
+func (e *Engineer) pipelineDir(target string) string {
+	return filepath.Join(e.rig.Path, ".runtime", "pipeline", strings.ReplaceAll(target, "/", "-"))
+}
+
+// startPipeline starts checking the next ready MR for current's target
+// against tip, the merge commit about to be pushed, in the background.
+// Its result is kept for doMerge to pick up with pipelinedResult.
+func (e *Engineer) startPipeline(ctx context.Context, current *mrqueue.MR, tip string) {
+	if e.settings == nil || !e.settings.Pipeline {
+		return
+	}
+	if e.pipelines == nil {
+		e.pipelines = make(map[string]*pipelineRun)
+	}
+	if prev := e.pipelines[current.Target]; prev != nil {
+		prev.stop()
+		delete(e.pipelines, current.Target)
+	}
+
+	ready, err := e.readyMRs()
+	if err != nil {
+		return
+	}
+	var next *mrqueue.MR
+	for _, mr := range ready {
+		if mr.ID != current.ID && mr.Target == current.Target {
+			next = mr
+			break
+		}
+	}
+	if next == nil {
+		return
+	}
+	branchSHA, err := e.git.Rev(next.Branch)
+	if err != nil {
+		return
+	}
+
+	dir := e.pipelineDir(current.Target)
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		_ = e.git.WorktreePrune()
+		_ = os.RemoveAll(dir)
+		if err := e.git.WorktreeAddDetached(dir, tip); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: cannot pipeline %s: %v\n", next.ID, err)
+			return
+		}
+	}
+
+	run := &pipelineRun{mrID: next.ID, branchSHA: branchSHA, tip: tip, done: make(chan struct{})}
+	ctx, run.cancel = context.WithCancel(context.WithoutCancel(ctx))
+	ahead := *e
+	ahead.git = e.gitAt(dir)
+	ahead.workDir = dir
+	ahead.output = &run.log
+	go func() {
+		defer close(run.done)
+		run.result = ahead.checkMerge(ctx, next, tip)
+	}()
+	e.pipelines[current.Target] = run
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Checking %s ahead against %s\n", next.ID, shortSHA(tip))
+}
+
+// pipelinedResult returns the pipelined check result for mr, if its checks
+// ran ahead against the target and branch as they are now, waiting for
+// them to finish. A run against anything else is discarded, and the MR
+// must be checked again.
+func (e *Engineer) pipelinedResult(mr *mrqueue.MR) (ProcessResult, bool) {
+	run := e.pipelines[mr.Target]
+	if run == nil {
+		return ProcessResult{}, false
+	}
+	delete(e.pipelines, mr.Target)
+	if run.mrID != mr.ID {
+		run.stop()
+		return ProcessResult{}, false
+	}
+	tip, _ := e.git.Rev("HEAD")
+	branchSHA, _ := e.git.Rev(mr.Branch)
+	if tip != run.tip || branchSHA != run.branchSHA {
+		run.stop()
+		_, _ = fmt.Fprintf(e.output, "[Engineer] %s was checked ahead against %s, but the target or branch has moved; checking again\n",
+			mr.ID, shortSHA(run.tip))
+		return ProcessResult{}, false
+	}
+
+	<-run.done
+	_, _ = io.WriteString(e.output, run.log.String())
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Using checks run ahead against %s\n", shortSHA(run.tip))
+	return run.result, true
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 8 {
+		return sha[:8]
+	}
+	return sha
+}
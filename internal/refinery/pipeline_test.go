@@ -0,0 +1,143 @@
+package refinery
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+// setupPipelineRig makes a rig with two independent polecat branches
+// queued for main, and a check that logs the directory it ran in.
+func setupPipelineRig(t *testing.T) (e *Engineer, first, second *mrqueue.MR, checkLog string, run func(args ...string)) {
+	t.Helper()
+	root := t.TempDir()
+	origin := filepath.Join(root, "origin.git")
+	rigPath := filepath.Join(root, "rig")
+	checkLog = filepath.Join(root, "checks.log")
+
+	run = func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = rigPath
+		if len(args) > 0 && args[0] == "init" {
+			cmd.Dir = root
+		}
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	commitFile := func(name string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(rigPath, name), []byte(name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		run("add", name)
+		run("commit", "-m", "add "+name)
+	}
+
+	run("init", "--bare", "-b", "main", origin)
+	run("init", "-b", "main", rigPath)
+	run("config", "user.name", "test")
+	run("config", "user.email", "test@example.com")
+	run("remote", "add", "origin", origin)
+	commitFile("README")
+	run("push", "origin", "main")
+	for _, b := range []string{"polecat/nux/gt-1", "polecat/slit/gt-2"} {
+		run("checkout", "-b", b, "main")
+		commitFile(strings.ReplaceAll(b, "/", "-"))
+	}
+	run("checkout", "main")
+
+	if err := os.MkdirAll(filepath.Join(rigPath, "settings"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	toml := "[refinery]\npipeline = true\n\n[[refinery.checks]]\nname = \"where\"\ncommand = \"pwd >> " + checkLog + "\"\n"
+	if err := os.WriteFile(filepath.Join(rigPath, "settings", "rig.toml"), []byte(toml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run("add", "settings")
+	run("commit", "-m", "settings")
+	run("push", "origin", "main")
+
+	e = NewEngineer(&rig.Rig{Name: "test-rig", Path: rigPath})
+	e.SetOutput(&strings.Builder{})
+	if err := e.LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	first = &mrqueue.MR{Branch: "polecat/nux/gt-1", Target: "main", Worker: "nux", Priority: 1}
+	second = &mrqueue.MR{Branch: "polecat/slit/gt-2", Target: "main", Worker: "slit", Priority: 2}
+	for _, mr := range []*mrqueue.MR{first, second} {
+		if err := e.mrQueue.Submit(mr); err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+	}
+	return e, first, second, checkLog, run
+}
+
+// checkDirs returns the directories the check ran in, in order.
+func checkDirs(t *testing.T, checkLog string) []string {
+	t.Helper()
+	data, err := os.ReadFile(checkLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Fields(string(data))
+}
+
+func TestPipeline_UsesChecksRunAhead(t *testing.T) {
+	e, first, second, checkLog, _ := setupPipelineRig(t)
+
+	if res := e.doMerge(t.Context(), first); !res.Success {
+		t.Fatalf("merging first: %s", res.Error)
+	}
+	if e.pipelines["main"] == nil || e.pipelines["main"].mrID != second.ID {
+		t.Fatalf("second MR's checks were not started ahead")
+	}
+	if err := e.mrQueue.Remove(first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if res := e.doMerge(t.Context(), second); !res.Success {
+		t.Fatalf("merging second: %s", res.Error)
+	}
+
+	dirs := checkDirs(t, checkLog)
+	if len(dirs) != 2 {
+		t.Fatalf("check ran %d times, want 2 (once per MR): %v", len(dirs), dirs)
+	}
+	if want := e.pipelineDir("main"); !strings.HasSuffix(dirs[1], filepath.Base(want)) {
+		t.Errorf("second MR checked in %s, want the pipeline worktree %s", dirs[1], want)
+	}
+}
+
+func TestPipeline_DiscardsStalePrediction(t *testing.T) {
+	e, first, second, checkLog, run := setupPipelineRig(t)
+
+	if res := e.doMerge(t.Context(), first); !res.Success {
+		t.Fatalf("merging first: %s", res.Error)
+	}
+	if err := e.mrQueue.Remove(first.ID); err != nil {
+		t.Fatal(err)
+	}
+	// The worker pushes again after the checks started.
+	run("checkout", second.Branch)
+	run("commit", "--allow-empty", "-m", "more")
+	run("checkout", "main")
+
+	if res := e.doMerge(t.Context(), second); !res.Success {
+		t.Fatalf("merging second: %s", res.Error)
+	}
+	dirs := checkDirs(t, checkLog)
+	if len(dirs) != 3 {
+		t.Fatalf("check ran %d times, want 3 (first, ahead, again): %v", len(dirs), dirs)
+	}
+	if dirs[2] != dirs[0] {
+		t.Errorf("second MR rechecked in %s, want the rig checkout %s", dirs[2], dirs[0])
+	}
+}
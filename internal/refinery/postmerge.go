@@ -0,0 +1,47 @@
+package refinery
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// verifyMerge runs refinery.post_merge against mergeCommit, just pushed to
+// target and still checked out. If a check fails, the merge is reverted on
+// target and the revert pushed, and the failure is returned with the
+// check's output for the worker, so the MR fails as if a pre-merge check
+// had caught it.
+func (e *Engineer) verifyMerge(ctx context.Context, mr *mrqueue.MR, target, mergeCommit string) ProcessResult {
+	if e.settings == nil || len(e.settings.PostMerge) == 0 {
+		return ProcessResult{Success: true}
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Verifying %s after the merge...\n", target)
+	result := e.runChecks(ctx, e.settings.PostMerge)
+	for i := range result.Checks {
+		result.Checks[i].PostMerge = true
+	}
+	if result.Success {
+		return result
+	}
+
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Post-merge %s; reverting %s\n", result.Error, shortSHA(mergeCommit))
+	result.Error = "post-merge " + result.Error
+	if err := e.git.Revert(mergeCommit); err != nil {
+		result.Error += fmt.Sprintf("; reverting the merge failed, so it is still on %s: %v", target, err)
+		return result
+	}
+	revert, err := e.git.Rev("HEAD")
+	if err != nil {
+		revert = "HEAD"
+	}
+	if err := e.git.Push("origin", target, false); err != nil {
+		result.Error += fmt.Sprintf("; the merge was reverted as %s, but pushing the revert failed: %v", shortSHA(revert), err)
+		return result
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Reverted %s on %s as %s\n", mr.Branch, target, shortSHA(revert))
+	result.Error += fmt.Sprintf("; the merge was reverted as %s. Its commits are now in %s's history, so "+
+		"before resubmitting, merge origin/%s into the branch, revert the revert (git revert %s), and fix it there",
+		shortSHA(revert), target, target, revert)
+	return result
+}
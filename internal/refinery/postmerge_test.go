@@ -0,0 +1,36 @@
+package refinery
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+func TestVerifyMerge_RevertsOnFailure(t *testing.T) {
+	e, repo := newFakeEngineer(t)
+	e.settings.PostMerge = []config.CheckConfig{{Name: "smoke", Command: "false"}}
+	nux := queueBranch(t, e, repo, "nux", map[string]string{"nux.txt": "nux\n"})
+
+	res := e.doMerge(context.Background(), nux)
+	if res.Success || !res.TestsFailed || res.FailedCheck != "smoke" {
+		t.Fatalf("doMerge = %+v, want the smoke check failed", res)
+	}
+	if !strings.Contains(res.Error, "post-merge check smoke failed") || !strings.Contains(res.Error, "reverted as") {
+		t.Errorf("Error = %q, want the failure and the revert", res.Error)
+	}
+	head := repo.Lookup(repo.Origin("main"))
+	if _, ok := head.Files["nux.txt"]; ok || !strings.HasPrefix(head.Subject(), "Revert ") {
+		t.Errorf("origin/main = %q with %v, want the merge reverted", head.Subject(), head.Files)
+	}
+	if merge := repo.Lookup(head.Parents[0]); merge == nil || merge.Files["nux.txt"] != "nux\n" {
+		t.Error("the revert is not on top of the merge")
+	}
+
+	e.settings.PostMerge[0].Command = "true"
+	slit := queueBranch(t, e, repo, "slit", map[string]string{"slit.txt": "slit\n"})
+	if res := e.doMerge(context.Background(), slit); !res.Success || repo.Origin("main") != res.MergeCommit {
+		t.Errorf("doMerge with passing verification = %+v", res)
+	}
+}
@@ -0,0 +1,108 @@
+package refinery
+
+import (
+	"fmt"
+	"time"
+)
+
+// QueuePreview is a dry run of the queue: each pending MR test-merged into
+// its target as origin has it now, and with pairs set, into each MR queued
+// ahead of it for the same target. Nothing is merged or recorded.
+type QueuePreview struct {
+	PreviewedAt time.Time   `json:"previewed_at"`
+	MRs         []PreviewMR `json:"mrs"` // In queue order
+
+	// Pairwise is set when MRs were also test-merged against each other;
+	// Conflicts then lists the pairs that conflict.
+	Pairwise  bool              `json:"pairwise"`
+	Conflicts []PreviewConflict `json:"conflicts,omitempty"`
+}
+
+// PreviewMR is one pending MR in a QueuePreview.
+type PreviewMR struct {
+	ID     string `json:"id"`
+	Branch string `json:"branch"`
+	Target string `json:"target"`
+
+	// Files are those the branch conflicts with its target in.
+	Files []string `json:"files,omitempty"`
+
+	// Error is set if the branch or target could not be tested.
+	Error string `json:"error,omitempty"`
+}
+
+// PreviewConflict is a pair of pending MRs, by index into
+// QueuePreview.MRs, whose branches conflict with each other.
+type PreviewConflict struct {
+	MRs   [2]int   `json:"mrs"`
+	Files []string `json:"files"`
+}
+
+// Conflict returns the files MRs i and j conflict in, or nil if they merge
+// cleanly or were not compared.
+func (p *QueuePreview) Conflict(i, j int) []string {
+	for _, c := range p.Conflicts {
+		if (c.MRs[0] == i && c.MRs[1] == j) || (c.MRs[0] == j && c.MRs[1] == i) {
+			return c.Files
+		}
+	}
+	return nil
+}
+
+// PreviewQueue test-merges every queued MR that is not held, waiting for
+// approval, or handed off against its target, fetched from origin first.
+// With pairs it also test-merges each pair of those MRs that share a
+// target, since whichever lands second has to merge on top of the other.
+func (e *Engineer) PreviewQueue(pairs bool) (*QueuePreview, error) {
+	queued, err := e.mrQueue.ListByScore()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := e.FetchQueue(); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: fetch: %v (previewing against the targets as last fetched)\n", err)
+	}
+
+	preview := &QueuePreview{PreviewedAt: e.clock(), Pairwise: pairs}
+	tm := e.newTestMerger()
+	defer tm.close()
+	var heads []string
+	for _, mr := range queued {
+		if mr.IsHeld() || mr.NeedsApproval() || mr.IsHandedOff() {
+			continue
+		}
+		p := PreviewMR{ID: mr.ID, Branch: mr.Branch, Target: mr.Target}
+		head, err := e.git.Rev(mr.Branch)
+		if err != nil {
+			p.Error = fmt.Sprintf("branch %s not found", mr.Branch)
+		} else if target, err := e.git.Rev("origin/" + mr.Target); err != nil {
+			p.Error = fmt.Sprintf("target %s not found on origin", mr.Target)
+		} else if p.Files, err = tm.conflicts(head, target); err != nil {
+			return nil, fmt.Errorf("test-merging %s into %s: %w", mr.Branch, mr.Target, err)
+		}
+		if p.Error != "" {
+			head = ""
+		}
+		preview.MRs = append(preview.MRs, p)
+		heads = append(heads, head)
+	}
+
+	if !pairs {
+		return preview, nil
+	}
+	for i, a := range preview.MRs {
+		for j := i + 1; j < len(preview.MRs); j++ {
+			b := preview.MRs[j]
+			if a.Target != b.Target || heads[i] == "" || heads[j] == "" || heads[i] == heads[j] {
+				continue
+			}
+			files, err := tm.conflicts(heads[j], heads[i])
+			if err != nil {
+				return nil, fmt.Errorf("test-merging %s into %s: %w", b.Branch, a.Branch, err)
+			}
+			if len(files) > 0 {
+				preview.Conflicts = append(preview.Conflicts, PreviewConflict{MRs: [2]int{i, j}, Files: files})
+			}
+		}
+	}
+	return preview, nil
+}
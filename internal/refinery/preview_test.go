@@ -0,0 +1,53 @@
+package refinery
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPreviewQueue(t *testing.T) {
+	e, repo := newFakeEngineer(t)
+	nux := queueBranch(t, e, repo, "nux", map[string]string{"shared.txt": "one\nnux\n"})
+	furiosa := queueBranch(t, e, repo, "furiosa", map[string]string{"shared.txt": "one\nfuriosa\n"})
+	slit := queueBranch(t, e, repo, "slit", map[string]string{"README": "slit\n"})
+	repo.Commit("main", "main moves", map[string]string{"README": "main\n"})
+	repo.Publish("main")
+	if err := e.mrQueue.Hold(furiosa.ID, "parked"); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := e.PreviewQueue(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.MRs) != 2 || len(p.Conflicts) != 0 {
+		t.Fatalf("preview = %+v, want nux and slit only, with no pairs", p)
+	}
+	if err := e.mrQueue.Requeue(furiosa.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err = e.PreviewQueue(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := make(map[string]int)
+	for i, mr := range p.MRs {
+		index[mr.ID] = i
+	}
+	if len(index) != 3 {
+		t.Fatalf("preview covers %v, want all three MRs", index)
+	}
+	if got := p.MRs[index[slit.ID]].Files; !reflect.DeepEqual(got, []string{"README"}) {
+		t.Errorf("slit conflicts with main in %v, want README", got)
+	}
+	if got := p.MRs[index[nux.ID]].Files; len(got) != 0 {
+		t.Errorf("nux conflicts with main in %v, want none", got)
+	}
+	if got := p.Conflict(index[nux.ID], index[furiosa.ID]); !reflect.DeepEqual(got, []string{"shared.txt"}) {
+		t.Errorf("nux × furiosa = %v, want shared.txt", got)
+	}
+	if len(p.Conflicts) != 1 {
+		t.Errorf("conflicts = %+v, want only nux × furiosa", p.Conflicts)
+	}
+}
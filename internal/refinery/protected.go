@@ -0,0 +1,91 @@
+package refinery
+
+import (
+	"fmt"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// gateProtectedChanges parks MRs whose branch touches a path or adds a
+// line that rig.toml's [refinery.protected] guards, returning the MRs that
+// may merge now. Like untrusted workers' MRs, parked MRs wait for 'gt
+// refinery approve'. An MR whose diff cannot be computed sits out this
+// pass rather than merge unchecked.
+func (e *Engineer) gateProtectedChanges(mrs []*mrqueue.MR) []*mrqueue.MR {
+	if e.settings == nil || e.settings.Protected == nil {
+		return mrs
+	}
+	ready := mrs[:0]
+	for _, mr := range mrs {
+		reason, err := e.protectedReason(mr)
+		if err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to check %s for protected changes: %v\n", mr.ID, err)
+			continue
+		}
+		if reason == "" {
+			ready = append(ready, mr)
+			continue
+		}
+		if err := e.mrQueue.RequireApproval(mr.ID, reason); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to mark %s as needing approval: %v\n", mr.ID, err)
+			continue
+		}
+		if err := e.eventLogger.LogApprovalRequired(mr, reason); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to log approval_required event: %v\n", err)
+		}
+		_, _ = fmt.Fprintf(e.output, "[Engineer] %s needs approval: %s\n", mr.ID, reason)
+	}
+	return ready
+}
+
+// protectedReason returns why mr needs approval for touching protected
+// paths or content, or "" if it does not or is already approved.
+func (e *Engineer) protectedReason(mr *mrqueue.MR) (string, error) {
+	if e.settings == nil || e.settings.Protected == nil || mr.IsApproved() || queuedItself(mr) {
+		return "", nil
+	}
+	return protectedChange(e.git, e.settings.Protected, mr.Branch, mr.Target)
+}
+
+// protectedChange returns why branch's changes over target need approval,
+// naming the first protected path or content match, or "" if they do not.
+func protectedChange(g GitRunner, pc *config.ProtectedConfig, branch, target string) (string, error) {
+	if len(pc.Paths) > 0 {
+		files, err := g.ChangedFiles(target, branch)
+		if err != nil {
+			return "", fmt.Errorf("diffing %s against %s: %w", branch, target, err)
+		}
+		if reason := protectedPath(pc, files); reason != "" {
+			return reason, nil
+		}
+	}
+	if len(pc.Content) > 0 {
+		patterns, err := pc.ContentPatterns()
+		if err != nil {
+			return "", err
+		}
+		lines, err := g.AddedLines(target, branch)
+		if err != nil {
+			return "", fmt.Errorf("diffing %s against %s: %w", branch, target, err)
+		}
+		for _, l := range lines {
+			for _, re := range patterns {
+				if re.MatchString(l.Text) {
+					return fmt.Sprintf("adds a line to %s matching protected content %q", l.File, re.String()), nil
+				}
+			}
+		}
+	}
+	return "", nil
+}
+
+// protectedPath returns why changing files needs approval, or "".
+func protectedPath(pc *config.ProtectedConfig, files []string) string {
+	for _, f := range files {
+		if p, ok := pc.MatchPath(f); ok {
+			return fmt.Sprintf("touches protected path %s (%s)", f, p)
+		}
+	}
+	return ""
+}
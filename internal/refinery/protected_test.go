@@ -0,0 +1,42 @@
+package refinery
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+func TestProtectedPath(t *testing.T) {
+	pc := &config.ProtectedConfig{Paths: []string{"deploy/", "db/migrations"}}
+	if got := protectedPath(pc, []string{"src/main.go", "README.md"}); got != "" {
+		t.Errorf("protectedPath(unprotected files) = %q, want none", got)
+	}
+	got := protectedPath(pc, []string{"src/main.go", "db/migrations/002_users.sql"})
+	if !strings.Contains(got, "db/migrations/002_users.sql") {
+		t.Errorf("protectedPath = %q, want it to name the migration", got)
+	}
+}
+
+func TestGateProtectedChanges_OnlyWhenProcessing(t *testing.T) {
+	e, repo := newFakeEngineer(t)
+	e.settings.Protected = &config.ProtectedConfig{Paths: []string{"deploy/"}}
+	safe := queueBranch(t, e, repo, "nux", map[string]string{"nux.txt": "nux\n"})
+	risky := queueBranch(t, e, repo, "slit", map[string]string{"deploy/prod.yaml": "replicas: 0\n"})
+
+	ready, err := e.ListReadyMRs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := mrIDs(ready); len(got) != 1 || got[0] != safe.ID {
+		t.Errorf("ready = %v, want only %s", got, safe.ID)
+	}
+	if got, _ := e.mrQueue.Get(risky.ID); got.NeedsApproval() {
+		t.Error("listing ready MRs parked the protected change")
+	}
+
+	e.prepareQueue()
+	if got, _ := e.mrQueue.Get(risky.ID); !got.NeedsApproval() || !strings.Contains(got.ApprovalReason, "deploy/prod.yaml") {
+		t.Errorf("after processing, %s = %+v, want it waiting for approval", risky.ID, got)
+	}
+}
@@ -0,0 +1,98 @@
+package refinery
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/mail"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+// checkFailureStreak returns the latest check failures of the different
+// MRs that failed their checks in a row, most recent first: back to the
+// last merge or quarantine. Conflicts and other failures are about the
+// branch, not the target, so they neither count nor break the streak.
+func checkFailureStreak(events []mrqueue.Event) []mrqueue.Event {
+	var streak []mrqueue.Event
+	seen := make(map[string]bool)
+	for i := len(events) - 1; i >= 0; i-- {
+		ev := events[i]
+		switch {
+		case ev.Type == mrqueue.EventMerged || ev.Type == mrqueue.EventQuarantined:
+			return streak
+		case ev.Type == mrqueue.EventMergeFailed && ev.FailureType == "tests" && !seen[ev.MRID]:
+			seen[ev.MRID] = true
+			streak = append(streak, ev)
+		}
+	}
+	return streak
+}
+
+// quarantineIfBroken pauses the rig once [refinery.quarantine] after
+// different MRs in a row have failed their checks, and sends an urgent
+// alert. The rig stays paused until someone runs 'gt rig resume', so the
+// rest of the queue is not failed against a broken target.
+func (e *Engineer) quarantineIfBroken() {
+	if e.settings == nil || e.settings.Quarantine == nil {
+		return
+	}
+	q := e.settings.Quarantine
+	events, err := e.eventLogger.ReadEvents(0)
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to read merge history: %v\n", err)
+		return
+	}
+	streak := checkFailureStreak(events)
+	if len(streak) < q.Threshold() {
+		return
+	}
+
+	reason := fmt.Sprintf("quarantined: %d MRs in a row failed their checks", len(streak))
+	if err := rig.Pause(e.rig.Path, reason, e.rig.Name+"/refinery"); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to quarantine rig: %v\n", err)
+		return
+	}
+	if err := e.eventLogger.LogQuarantined(e.rig.Name, reason); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to log quarantined event: %v\n", err)
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] ⚠ Quarantined %s: %d MRs in a row failed their checks; resume with 'gt rig resume %s'\n",
+		e.rig.Name, len(streak), e.rig.Name)
+
+	// The pause holds refinery notifications back; this alert is why it
+	// was paused, so it goes out regardless
+	for _, to := range q.Recipients() {
+		msg := &mail.Message{
+			From:     e.rig.Name + "/refinery",
+			To:       to,
+			Subject:  fmt.Sprintf("[CRITICAL] Refinery quarantined %s", e.rig.Name),
+			Body:     quarantineAlert(e.rig.Name, streak),
+			Priority: mail.PriorityUrgent,
+		}
+		if err := e.router.Send(msg); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to alert %s: %v\n", to, err)
+		}
+	}
+}
+
+// quarantineAlert is the body of the alert sent when rigName is
+// quarantined after the check failures in streak, most recent first.
+func quarantineAlert(rigName string, streak []mrqueue.Event) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d MRs in a row failed their checks on %s, so the target or the\n", len(streak), rigName)
+	sb.WriteString("environment is likely broken. The rig is paused: nothing merges and\n")
+	sb.WriteString("enqueues are refused until it is resumed.\n\nFailures, most recent first:\n")
+	for _, ev := range streak {
+		fmt.Fprintf(&sb, "- %s (%s) into %s", ev.Branch, ev.MRID, ev.Target)
+		if ev.FailedCheck != "" {
+			fmt.Fprintf(&sb, ", check %s", ev.FailedCheck)
+		}
+		if ev.Reason != "" {
+			sb.WriteString(": " + firstLine(ev.Reason))
+		}
+		sb.WriteString("\n")
+	}
+	fmt.Fprintf(&sb, "\nOnce the target is fixed ('gt refinery bisect %s' finds the merge that\n", rigName)
+	fmt.Fprintf(&sb, "broke it), resume with: gt rig resume %s\n", rigName)
+	return sb.String()
+}
@@ -0,0 +1,80 @@
+package refinery
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func TestQuarantineIfBroken(t *testing.T) {
+	rigPath := t.TempDir()
+	e := NewEngineer(&rig.Rig{Name: "greenplace", Path: rigPath})
+	e.SetOutput(io.Discard)
+	e.settings = &config.RefinerySettings{Quarantine: &config.QuarantineConfig{After: 3}}
+	fail := func(mrID, failureType string) {
+		t.Helper()
+		ev := mrqueue.Event{Type: mrqueue.EventMergeFailed, MRID: mrID, Branch: "polecat/" + mrID, Target: "main", FailureType: failureType, FailedCheck: "test"}
+		if err := e.eventLogger.LogEvent(ev); err != nil {
+			t.Fatal(err)
+		}
+		e.quarantineIfBroken()
+	}
+	paused := func() bool {
+		t.Helper()
+		p, err := rig.LoadPause(rigPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p != nil
+	}
+
+	fail("mr-1", "tests")
+	if err := e.eventLogger.LogEvent(mrqueue.Event{Type: mrqueue.EventMerged, MRID: "mr-2", Target: "main"}); err != nil {
+		t.Fatal(err)
+	}
+	fail("mr-3", "tests")
+	fail("mr-3", "tests")    // The same MR again counts once
+	fail("mr-4", "conflict") // Conflicts are the branch's problem
+	if paused() {
+		t.Fatal("quarantined before three different MRs failed their checks since the last merge")
+	}
+	fail("mr-5", "tests")
+	if paused() {
+		t.Fatal("quarantined after two different MRs failed")
+	}
+	fail("mr-6", "tests")
+	p, err := rig.LoadPause(rigPath)
+	if err != nil || p == nil || !strings.Contains(p.Reason, "3 MRs in a row") {
+		t.Fatalf("pause after three failing MRs = %+v, %v", p, err)
+	}
+
+	// Once resumed, the streak starts over
+	if _, err := rig.Unpause(rigPath); err != nil {
+		t.Fatal(err)
+	}
+	fail("mr-7", "tests")
+	if paused() {
+		t.Error("quarantined again on the first failure after resuming")
+	}
+}
+
+func TestQuarantineAlert(t *testing.T) {
+	streak := []mrqueue.Event{
+		{MRID: "mr-2", Branch: "polecat/slit/gt-2", Target: "main", FailedCheck: "test", Reason: "exit status 1\nmore"},
+		{MRID: "mr-1", Branch: "polecat/nux/gt-1", Target: "main"},
+	}
+	body := quarantineAlert("greenplace", streak)
+	for _, want := range []string{
+		"2 MRs in a row failed their checks on greenplace",
+		"- polecat/slit/gt-2 (mr-2) into main, check test: exit status 1\n",
+		"resume with: gt rig resume greenplace",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("alert missing %q:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package refinery
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/polecat"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// Receipt is the record of one completed merge: what went in, what came
+// out, which checks ran and how long they took, and which policies let it
+// through. It is written once, as the merge lands, to
+// .runtime/receipts/<merge-commit>.json, and stands on its own: it can be
+// audited without the event log, git, or the forge.
+type Receipt struct {
+	Rig         string   `json:"rig"`
+	MRID        string   `json:"mr_id"`
+	Title       string   `json:"title,omitempty"`
+	SourceIssue string   `json:"source_issue,omitempty"`
+	Worker      string   `json:"worker,omitempty"`
+	Branch      string   `json:"branch"`
+	Routes      []string `json:"routes,omitempty"`
+
+	// BranchHead is the commit the branch was at when it merged;
+	// TargetBefore and MergeCommit the target before and after.
+	BranchHead   string `json:"branch_head,omitempty"`
+	Target       string `json:"target"`
+	TargetBefore string `json:"target_before,omitempty"`
+	MergeCommit  string `json:"merge_commit"`
+	FilesChanged int    `json:"files_changed"`
+	LinesChanged int    `json:"lines_changed"`
+
+	Checks []CheckRun       `json:"checks"`
+	Policy []PolicyDecision `json:"policy"`
+
+	QueuedAt   *time.Time `json:"queued_at,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	MergedAt   time.Time  `json:"merged_at"`
+	QueuedMs   int64      `json:"queued_ms"`   // From queued to started
+	DurationMs int64      `json:"duration_ms"` // From started to merged
+}
+
+// CheckRun is one check run for a merge, before it or, with PostMerge,
+// after it (see refinery.post_merge).
+type CheckRun struct {
+	Name       string `json:"name"`
+	Command    string `json:"command"`
+	Passed     bool   `json:"passed"`
+	PostMerge  bool   `json:"post_merge,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// PolicyDecision is what one rig.toml policy decided about a merge.
+type PolicyDecision struct {
+	Policy   string `json:"policy"`
+	Decision string `json:"decision"`
+}
+
+// ReceiptsDir is where a rig's merge receipts are kept.
+func ReceiptsDir(rigPath string) string {
+	return filepath.Join(rigPath, ".runtime", "receipts")
+}
+
+// ReceiptPath is the receipt for the merge that made mergeCommit.
+func ReceiptPath(rigPath, mergeCommit string) string {
+	return filepath.Join(ReceiptsDir(rigPath), mergeCommit+".json")
+}
+
+// LoadReceipt returns the receipt for the merge that made mergeCommit.
+func LoadReceipt(rigPath, mergeCommit string) (*Receipt, error) {
+	data, err := os.ReadFile(ReceiptPath(rigPath, mergeCommit)) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		return nil, err
+	}
+	var r Receipt
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parsing receipt for %s: %w", mergeCommit, err)
+	}
+	return &r, nil
+}
+
+// writeReceipt records the merge of mr that result describes. Best-effort,
+// like the rest of finishing a merge: it has already landed.
+func (e *Engineer) writeReceipt(mr *mrqueue.MR, result ProcessResult) {
+	if result.MergeCommit == "" {
+		return
+	}
+	r := e.receipt(mr, result)
+	if err := os.MkdirAll(ReceiptsDir(e.rig.Path), 0755); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to write merge receipt: %v\n", err)
+		return
+	}
+	if err := util.AtomicWriteJSON(ReceiptPath(e.rig.Path, result.MergeCommit), r); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to write merge receipt: %v\n", err)
+	}
+}
+
+// receipt builds the receipt for the merge of mr that result describes.
+func (e *Engineer) receipt(mr *mrqueue.MR, result ProcessResult) *Receipt {
+	r := &Receipt{
+		Rig:          e.rig.Name,
+		MRID:         mr.ID,
+		Title:        mr.Title,
+		SourceIssue:  mr.SourceIssue,
+		Worker:       mr.Worker,
+		Branch:       mr.Branch,
+		Routes:       mr.Routes,
+		BranchHead:   result.BranchHead,
+		Target:       mr.Target,
+		TargetBefore: result.TargetBefore,
+		MergeCommit:  result.MergeCommit,
+		FilesChanged: result.FilesChanged,
+		LinesChanged: result.LinesChanged,
+		Checks:       result.Checks,
+		Policy:       e.policyDecisions(mr, result),
+		StartedAt:    result.Started,
+		MergedAt:     e.clock(),
+	}
+	if r.Checks == nil {
+		r.Checks = []CheckRun{}
+	}
+	if !mr.CreatedAt.IsZero() {
+		queued := mr.CreatedAt
+		r.QueuedAt = &queued
+	}
+	if !r.StartedAt.IsZero() {
+		r.DurationMs = r.MergedAt.Sub(r.StartedAt).Milliseconds()
+		if r.QueuedAt != nil && r.StartedAt.After(*r.QueuedAt) {
+			r.QueuedMs = r.StartedAt.Sub(*r.QueuedAt).Milliseconds()
+		}
+	}
+	return r
+}
+
+// policyDecisions lists what each rig.toml policy that applies to mr
+// decided. The merge landed, so every gate configured let it through.
+func (e *Engineer) policyDecisions(mr *mrqueue.MR, result ProcessResult) []PolicyDecision {
+	s := e.settings
+	strategy := config.StrategyMerge
+	if s != nil && s.Strategy != "" {
+		strategy = s.Strategy
+	}
+	policy := []PolicyDecision{{"strategy", strategy}}
+	if s == nil {
+		return policy
+	}
+	add := func(name, decision string) {
+		policy = append(policy, PolicyDecision{name, decision})
+	}
+
+	if len(s.BranchPatterns) > 0 {
+		add("branch_patterns", "allowed")
+	}
+	if len(s.Paths) > 0 {
+		add("paths", "in scope")
+	}
+	if s.VerifyAuthorship {
+		if polecat.IsBranch(mr.Branch) {
+			add("verify_authorship", "verified")
+		} else {
+			add("verify_authorship", "not a polecat branch")
+		}
+	}
+	if s.Signatures != nil {
+		if queuedItself(mr) {
+			add("signatures", "skipped: the refinery's own commits")
+		} else {
+			add("signatures", "verified")
+		}
+	}
+	if tier := s.WorkerTier(mr.Worker); tier != "" {
+		add("workers", "tier "+tier)
+	}
+	if len(mr.Routes) > 0 {
+		add("routes", "matched "+strings.Join(mr.Routes, ", "))
+	}
+	if result.Pipelined {
+		add("pipeline", "checks run ahead")
+	}
+	if s.Review != nil {
+		add("review", "approved")
+	}
+	if len(s.PostMerge) > 0 {
+		add("post_merge", "passed")
+	}
+	return policy
+}
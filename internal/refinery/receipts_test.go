@@ -0,0 +1,57 @@
+package refinery
+
+import (
+	"context"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+func TestWriteReceipt(t *testing.T) {
+	e, repo := newFakeEngineer(t)
+	e.settings.Checks = []config.CheckConfig{{Name: "lint", Command: "true"}, {Name: "test", Command: "true"}}
+	e.settings.PostMerge = []config.CheckConfig{{Name: "smoke", Command: "true"}}
+	e.settings.BranchPatterns = []string{"polecat/*/*"}
+	mr := queueBranch(t, e, repo, "nux", map[string]string{"nux.txt": "nux\n"})
+	head, err := e.git.Rev(mr.Branch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := e.doMerge(context.Background(), mr)
+	if !res.Success {
+		t.Fatalf("doMerge = %+v", res)
+	}
+	e.handleSuccessFromQueue(mr, res)
+
+	r, err := LoadReceipt(e.rig.Path, res.MergeCommit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.MRID != mr.ID || r.Branch != mr.Branch || r.Target != "main" || r.Worker != "nux" {
+		t.Errorf("receipt inputs = %+v", r)
+	}
+	if r.MergeCommit != repo.Origin("main") || r.BranchHead != head || r.TargetBefore == "" {
+		t.Errorf("receipt SHAs = branch %s, %s -> %s", r.BranchHead, r.TargetBefore, r.MergeCommit)
+	}
+	var names []string
+	for _, c := range r.Checks {
+		if !c.Passed {
+			t.Errorf("check %s recorded as failed", c.Name)
+		}
+		names = append(names, c.Name)
+	}
+	if len(r.Checks) != 3 || names[0] != "lint" || names[2] != "smoke" || !r.Checks[2].PostMerge || r.Checks[0].PostMerge {
+		t.Errorf("receipt checks = %+v, want lint and test, then smoke after the merge", r.Checks)
+	}
+	decided := make(map[string]string)
+	for _, p := range r.Policy {
+		decided[p.Policy] = p.Decision
+	}
+	if decided["strategy"] != config.StrategyMerge || decided["branch_patterns"] != "allowed" || decided["post_merge"] != "passed" {
+		t.Errorf("receipt policy = %+v", r.Policy)
+	}
+	if r.StartedAt.IsZero() || r.MergedAt.Before(r.StartedAt) || r.QueuedAt == nil {
+		t.Errorf("receipt times = queued %v, started %v, merged %v", r.QueuedAt, r.StartedAt, r.MergedAt)
+	}
+}
@@ -0,0 +1,310 @@
+package refinery
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// maxResolverStops bounds how many conflicting commits one resolution may
+// work through before the refinery gives up on the agent.
+const maxResolverStops = 20
+
+// ErrNoResolver means the rig has no [refinery.resolver] configured.
+var ErrNoResolver = errors.New("no refinery.resolver configured")
+
+// ResolveContext is written to $GT_RESOLVE_CONTEXT for the resolver agent
+// at each conflicting commit of the rebase.
+type ResolveContext struct {
+	MRID          string   `json:"mr_id"`
+	Branch        string   `json:"branch"`
+	Target        string   `json:"target"`
+	TargetSHA     string   `json:"target_sha,omitempty"`
+	Worker        string   `json:"worker,omitempty"`
+	SourceIssue   string   `json:"source_issue,omitempty"`
+	Commit        string   `json:"commit,omitempty"` // The branch commit being replayed
+	ConflictFiles []string `json:"conflict_files"`
+	Stop          int      `json:"stop"` // 1 for the first conflicting commit
+}
+
+// ResolveReport is what the agent may write to $GT_RESOLVE_RESULT.
+type ResolveReport struct {
+	Confidence float64 `json:"confidence"`
+	Summary    string  `json:"summary,omitempty"`
+}
+
+// Resolution is the outcome of a successful conflict resolution.
+type Resolution struct {
+	SHA        string   // New head of the branch, rebased onto the target
+	Confidence float64  // Lowest confidence the agent reported; 0 if it never did
+	Summaries  []string // One per conflicting commit the agent reported on
+	AutoMerge  bool     // Whether the policy lets it merge without approval
+}
+
+// Summary joins the agent's summaries for logs and approval reasons.
+func (r *Resolution) Summary() string {
+	return strings.Join(r.Summaries, "; ")
+}
+
+// resolveDir is where resolution worktrees and their context files live.
+func (e *Engineer) resolveDir() string {
+	return filepath.Join(e.rig.Path, ".runtime", "resolve")
+}
+
+// resolveConflicts rebases mr's branch onto its target in a scratch worktree
+// and runs the rig's resolver agent at each conflicting commit, then the
+// merge checks on the result. Once they pass, the rebased branch is
+// force-pushed and the local branch moved to it; the MR itself is left for
+// the caller to requeue or park.
+func (e *Engineer) resolveConflicts(ctx context.Context, mr *mrqueue.MR) (*Resolution, error) {
+	if e.settings == nil || e.settings.Resolver == nil {
+		return nil, ErrNoResolver
+	}
+	rc := e.settings.Resolver
+
+	dir := filepath.Join(e.resolveDir(), mr.ID)
+	_ = e.git.WorktreeRemove(dir, true)
+	_ = os.RemoveAll(dir)
+	if err := e.git.WorktreeAddDetached(dir, mr.Branch); err != nil {
+		return nil, fmt.Errorf("creating resolution worktree: %w", err)
+	}
+	defer func() {
+		_ = e.git.WorktreeRemove(dir, true)
+		_ = e.git.WorktreePrune()
+	}()
+	wg := git.NewGit(dir)
+
+	env, err := e.processEnv()
+	if err != nil {
+		return nil, err
+	}
+	contextFile := dir + ".context.json"
+	resultFile := dir + ".result.json"
+	defer func() {
+		_ = os.Remove(contextFile)
+		_ = os.Remove(resultFile)
+	}()
+	env = append(env,
+		"GT_RESOLVE_CONTEXT="+contextFile,
+		"GT_RESOLVE_RESULT="+resultFile,
+		"GT_MR_ID="+mr.ID,
+		"GT_BRANCH="+mr.Branch,
+		"GT_TARGET="+mr.Target,
+	)
+
+	if err := e.fetchTarget(mr.Target); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: fetch origin/%s: %v (continuing)\n", mr.Target, err)
+	}
+	onto := "origin/" + mr.Target
+	targetSHA, _ := e.git.Rev(onto)
+	res := &Resolution{Confidence: math.Inf(1)}
+	rebaseErr := wg.Rebase(onto)
+	for stop := 1; rebaseErr != nil; stop++ {
+		files, err := wg.UnmergedFiles()
+		if err != nil || len(files) == 0 {
+			_ = wg.AbortRebase()
+			return nil, fmt.Errorf("rebasing onto %s: %w", mr.Target, rebaseErr)
+		}
+		if stop > maxResolverStops {
+			_ = wg.AbortRebase()
+			return nil, fmt.Errorf("gave up after %d conflicting commits", maxResolverStops)
+		}
+
+		rctx := ResolveContext{
+			MRID:          mr.ID,
+			Branch:        mr.Branch,
+			Target:        mr.Target,
+			TargetSHA:     targetSHA,
+			Worker:        mr.Worker,
+			SourceIssue:   mr.SourceIssue,
+			ConflictFiles: files,
+			Stop:          stop,
+		}
+		rctx.Commit, _ = wg.Rev("REBASE_HEAD")
+		if err := util.AtomicWriteJSON(contextFile, rctx); err != nil {
+			_ = wg.AbortRebase()
+			return nil, fmt.Errorf("writing resolve context: %w", err)
+		}
+		_ = os.Remove(resultFile)
+
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Resolver working on %d conflicted file(s) in %s (commit %d)\n", len(files), mr.Branch, stop)
+		if err := e.runResolver(ctx, rc.Command, rc.TimeoutDuration(), dir, env); err != nil {
+			_ = wg.AbortRebase()
+			return nil, err
+		}
+		if marked := filesWithConflictMarkers(dir, files); len(marked) > 0 {
+			_ = wg.AbortRebase()
+			return nil, fmt.Errorf("resolver left conflict markers in %v", marked)
+		}
+		if err := wg.Add(append([]string{"-A", "--"}, files...)...); err != nil {
+			_ = wg.AbortRebase()
+			return nil, fmt.Errorf("staging resolution: %w", err)
+		}
+
+		report, err := loadResolveReport(resultFile)
+		if err != nil {
+			_ = wg.AbortRebase()
+			return nil, err
+		}
+		if report.Confidence < res.Confidence {
+			res.Confidence = report.Confidence
+		}
+		if report.Summary != "" {
+			res.Summaries = append(res.Summaries, report.Summary)
+		}
+
+		rebaseErr = wg.RebaseContinue()
+	}
+	if math.IsInf(res.Confidence, 1) {
+		// The target moved and the branch now rebases cleanly.
+		res.Confidence = 1
+	}
+	res.AutoMerge = rc.AutoMerge(res.Confidence)
+
+	if res.SHA, err = wg.Rev("HEAD"); err != nil {
+		return nil, err
+	}
+
+	// Nothing is pushed until the resolution passes the merge checks.
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Checking resolution of %s\n", mr.Branch)
+	checker := *e
+	checker.git = wg
+	checker.workDir = dir
+	if result := checker.runMergeChecks(ctx, mr); !result.Success {
+		return nil, fmt.Errorf("resolution fails checks: %s", result.Error)
+	}
+	if err := wg.Push("origin", "HEAD:refs/heads/"+mr.Branch, true); err != nil {
+		return nil, fmt.Errorf("pushing resolution: %w", err)
+	}
+	if err := e.git.UpdateBranchRef(mr.Branch, res.SHA); err != nil {
+		return nil, fmt.Errorf("updating local %s: %w", mr.Branch, err)
+	}
+	return res, nil
+}
+
+// runResolver runs the resolver command in the worktree.
+func (e *Engineer) runResolver(ctx context.Context, command string, timeout time.Duration, dir string, env []string) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	// The resolver command comes from the rig's settings (trusted infrastructure config).
+	cmd := exec.CommandContext(ctx, "sh", "-c", command) //nolint:gosec // G204: trusted rig config
+	cmd.Dir = dir
+	cmd.Env = env
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	started := time.Now()
+	err := cmd.Run()
+	util.TraceCommand(dir, "sh", cmd.Args[1:], started, err)
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("resolver timed out after %s", timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("resolver failed: %v\n%s", err, tailLines(output.String(), feedbackOutputLines))
+	}
+	return nil
+}
+
+// loadResolveReport reads the agent's report. A missing report is a
+// resolution of unknown (zero) confidence.
+func loadResolveReport(p string) (*ResolveReport, error) {
+	data, err := os.ReadFile(p) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &ResolveReport{}, nil
+		}
+		return nil, err
+	}
+	var r ResolveReport
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parsing resolver report: %w", err)
+	}
+	if r.Confidence < 0 || r.Confidence > 1 {
+		return nil, fmt.Errorf("resolver reported confidence %g, want 0 to 1", r.Confidence)
+	}
+	return &r, nil
+}
+
+// filesWithConflictMarkers returns the files under dir that still hold a
+// conflict marker line. Deleted files are fine.
+func filesWithConflictMarkers(dir string, files []string) []string {
+	var marked []string
+	for _, f := range files {
+		fh, err := os.Open(filepath.Join(dir, f)) //nolint:gosec // G304: files come from git
+		if err != nil {
+			continue
+		}
+		sc := bufio.NewScanner(fh)
+		sc.Buffer(nil, 1024*1024)
+		for sc.Scan() {
+			line := sc.Text()
+			if strings.HasPrefix(line, "<<<<<<< ") || strings.HasPrefix(line, ">>>>>>> ") {
+				marked = append(marked, f)
+				break
+			}
+		}
+		_ = fh.Close()
+	}
+	return marked
+}
+
+// ResolveConflicts runs the rig's resolver on a queued MR and applies the
+// confidence policy: a resolution the policy trusts returns to the ready
+// queue, where the merge re-runs checks on it; any other waits for
+// 'gt refinery approve'.
+func (e *Engineer) ResolveConflicts(ctx context.Context, id string) (*Resolution, error) {
+	mr, err := e.mrQueue.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	res, err := e.resolveConflicts(ctx, mr)
+	if err != nil {
+		return nil, err
+	}
+	e.applyResolution(mr, res)
+	return res, nil
+}
+
+// applyResolution records a resolution and requeues or parks the MR.
+func (e *Engineer) applyResolution(mr *mrqueue.MR, res *Resolution) {
+	if err := e.eventLogger.LogConflictResolved(mr, res.Summary()); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to log conflict_resolved event: %v\n", err)
+	}
+	_ = e.mrQueue.ClearBlockedBy(mr.ID)
+
+	if res.AutoMerge {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Resolver rebased %s to %.8s (confidence %.2f); requeued for checks and merge\n",
+			mr.Branch, res.SHA, res.Confidence)
+		return
+	}
+	reason := fmt.Sprintf("conflicts resolved by agent (confidence %.2f, checks passed)", res.Confidence)
+	if s := res.Summary(); s != "" {
+		reason += ": " + s
+	}
+	if err := e.mrQueue.RequireApproval(mr.ID, reason); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to park %s for approval: %v\n", mr.ID, err)
+		return
+	}
+	if err := e.eventLogger.LogApprovalRequired(mr, reason); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to log approval_required event: %v\n", err)
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Resolver rebased %s to %.8s (confidence %.2f); waiting for approval\n",
+		mr.Branch, res.SHA, res.Confidence)
+}
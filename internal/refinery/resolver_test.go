@@ -0,0 +1,173 @@
+package refinery
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+// setupConflictRig makes a rig whose polecat/nux/gt-1 branch conflicts
+// with main in file.txt, with both pushed to a bare origin.
+func setupConflictRig(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	origin := filepath.Join(root, "origin.git")
+	rigPath := filepath.Join(root, "rig")
+
+	run := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=nux", "GIT_AUTHOR_EMAIL=nux@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(rigPath, "file.txt"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run(root, "init", "--bare", "-b", "main", origin)
+	run(root, "clone", origin, rigPath)
+	run(rigPath, "config", "user.name", "test")
+	run(rigPath, "config", "user.email", "test@example.com")
+	run(rigPath, "checkout", "-b", "main")
+	write("base\n")
+	run(rigPath, "add", ".")
+	run(rigPath, "commit", "-m", "base")
+	run(rigPath, "push", "origin", "main")
+
+	run(rigPath, "checkout", "-b", "polecat/nux/gt-1")
+	write("polecat\n")
+	run(rigPath, "commit", "-am", "polecat change")
+	run(rigPath, "push", "origin", "polecat/nux/gt-1")
+
+	run(rigPath, "checkout", "main")
+	write("main\n")
+	run(rigPath, "commit", "-am", "main change")
+	run(rigPath, "push", "origin", "main")
+	return rigPath
+}
+
+func writeResolverSettings(t *testing.T, rigPath, confidence string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(rigPath, "settings"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	toml := `
+[refinery.resolver]
+command = "printf 'main\npolecat\n' > file.txt && echo '{\"confidence\": ` + confidence + `, \"summary\": \"kept both\"}' > \"$GT_RESOLVE_RESULT\""
+auto_merge_confidence = 0.8
+`
+	if err := os.WriteFile(filepath.Join(rigPath, "settings", "rig.toml"), []byte(toml), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newResolverEngineer(t *testing.T, rigPath, confidence string) (*Engineer, *mrqueue.MR) {
+	t.Helper()
+	writeResolverSettings(t, rigPath, confidence)
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: rigPath})
+	e.SetOutput(&strings.Builder{})
+	if err := e.LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	mr := &mrqueue.MR{Branch: "polecat/nux/gt-1", Target: "main", Worker: "nux"}
+	if err := e.mrQueue.Submit(mr); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	return e, mr
+}
+
+func TestResolveConflicts_AutoMerge(t *testing.T) {
+	rigPath := setupConflictRig(t)
+	e, mr := newResolverEngineer(t, rigPath, "0.9")
+
+	res, err := e.ResolveConflicts(t.Context(), mr.ID)
+	if err != nil {
+		t.Fatalf("ResolveConflicts: %v", err)
+	}
+	if !res.AutoMerge || res.Confidence != 0.9 || res.Summary() != "kept both" {
+		t.Errorf("Resolution = %+v, want auto-merge at 0.9 with summary", res)
+	}
+
+	if local, _ := e.git.Rev("polecat/nux/gt-1"); local != res.SHA {
+		t.Errorf("local branch at %s, want %s", local, res.SHA)
+	}
+	if ok, _ := e.git.IsAncestor("origin/main", res.SHA); !ok {
+		t.Error("resolution is not rebased onto origin/main")
+	}
+	if conflicts, err := e.git.CheckConflicts("polecat/nux/gt-1", "main"); err != nil || len(conflicts) > 0 {
+		t.Errorf("CheckConflicts after resolution = %v, %v; want none", conflicts, err)
+	}
+
+	got, _ := e.mrQueue.Get(mr.ID)
+	if got.NeedsApproval() {
+		t.Errorf("MR parked for approval (%s), want it ready", got.ApprovalReason)
+	}
+	events, _ := e.eventLogger.ReadEvents(0)
+	if len(events) != 1 || events[0].Type != mrqueue.EventConflictResolved || events[0].Reason != "kept both" {
+		t.Errorf("events = %+v, want one conflict_resolved", events)
+	}
+	if _, err := os.Stat(filepath.Join(e.resolveDir(), mr.ID)); !os.IsNotExist(err) {
+		t.Errorf("resolution worktree left behind: %v", err)
+	}
+}
+
+func TestResolveConflicts_LowConfidenceNeedsApproval(t *testing.T) {
+	rigPath := setupConflictRig(t)
+	e, mr := newResolverEngineer(t, rigPath, "0.5")
+
+	res, err := e.ResolveConflicts(t.Context(), mr.ID)
+	if err != nil {
+		t.Fatalf("ResolveConflicts: %v", err)
+	}
+	if res.AutoMerge {
+		t.Error("AutoMerge = true at confidence 0.5, want false")
+	}
+	got, _ := e.mrQueue.Get(mr.ID)
+	if !got.NeedsApproval() || !strings.Contains(got.ApprovalReason, "confidence 0.50") {
+		t.Errorf("ApprovalReason = %q, want a low-confidence park", got.ApprovalReason)
+	}
+}
+
+func TestResolveConflicts_MarkersLeft(t *testing.T) {
+	rigPath := setupConflictRig(t)
+	e, mr := newResolverEngineer(t, rigPath, "0.9")
+	e.settings.Resolver.Command = "true"
+	before, _ := e.git.Rev("polecat/nux/gt-1")
+
+	if _, err := e.ResolveConflicts(t.Context(), mr.ID); err == nil || !strings.Contains(err.Error(), "conflict markers") {
+		t.Fatalf("ResolveConflicts = %v, want conflict marker error", err)
+	}
+	if after, _ := e.git.Rev("polecat/nux/gt-1"); after != before {
+		t.Error("failed resolution moved the branch")
+	}
+}
+
+func TestResolveConflicts_ChecksFail(t *testing.T) {
+	rigPath := setupConflictRig(t)
+	e, mr := newResolverEngineer(t, rigPath, "0.9")
+	e.settings.Checks = []config.CheckConfig{{Name: "no-polecat", Command: "! grep -q polecat file.txt"}}
+	before, _ := e.git.Rev("polecat/nux/gt-1")
+
+	if _, err := e.ResolveConflicts(t.Context(), mr.ID); err == nil || !strings.Contains(err.Error(), "no-polecat") {
+		t.Fatalf("ResolveConflicts = %v, want a failed check", err)
+	}
+	if after, _ := e.git.Rev("polecat/nux/gt-1"); after != before {
+		t.Error("resolution that failed its checks moved the branch")
+	}
+	if remote, _ := e.git.Rev("origin/polecat/nux/gt-1"); remote != before {
+		t.Error("resolution that failed its checks was pushed")
+	}
+}
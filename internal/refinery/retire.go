@@ -0,0 +1,213 @@
+package refinery
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/polecat"
+)
+
+// RetireAction is what a retirement sweep does with one of the worker's
+// branches.
+type RetireAction string
+
+const (
+	// RetireMerged means the branch was already on the target; it is deleted.
+	RetireMerged RetireAction = "merged"
+	// RetireEnqueued means the branch merges cleanly and was handed to the
+	// refinery, which merges it through the usual checks.
+	RetireEnqueued RetireAction = "enqueued"
+	// RetireArchived means the branch conflicts; it is kept as
+	// archive/<branch> and deleted.
+	RetireArchived RetireAction = "archived"
+	// RetireDeleted means the branch conflicts and was deleted outright.
+	RetireDeleted RetireAction = "deleted"
+)
+
+// ArchivePrefix is where a retirement sweep keeps unmergeable branches.
+const ArchivePrefix = "archive/"
+
+// RetireOptions controls a retirement sweep.
+type RetireOptions struct {
+	Delete bool // Delete unmergeable branches instead of archiving them
+	DryRun bool // Report what would happen without changing anything
+}
+
+// RetiredBranch reports what a sweep did with one branch.
+type RetiredBranch struct {
+	Branch string       `json:"branch"`
+	Action RetireAction `json:"action"`
+	MRID   string       `json:"mr_id,omitempty"` // Queue item enqueued, or closed
+	Closed []string     `json:"closed,omitempty"`
+	// Released are source issues reopened for another worker.
+	Released []string `json:"released,omitempty"`
+}
+
+// RetireWorker sweeps a retired worker's remaining branches on origin.
+// Branches already on the target are deleted, and their queue items and
+// issues are closed as merged. Branches that merge cleanly are enqueued so
+// the refinery merges them. Conflicting branches are archived (or deleted
+// with opts.Delete), their queue items closed, and their source issues
+// reopened unassigned so another worker can pick them up.
+func (m *Manager) RetireWorker(name string, opts RetireOptions) ([]RetiredBranch, error) {
+	g := git.NewGit(m.workDir)
+	target := m.rig.DefaultBranch()
+	if err := g.FetchRefs("origin", target, polecat.BranchPrefix+"*"); err != nil {
+		return nil, fmt.Errorf("fetching origin: %w", err)
+	}
+	all, err := g.ListRemoteBranches("origin", polecat.BranchPrefix+"*")
+	if err != nil {
+		return nil, fmt.Errorf("listing branches: %w", err)
+	}
+
+	q := mrqueue.New(m.rig.Path)
+	queued, err := q.List()
+	if err != nil {
+		return nil, err
+	}
+	b := beads.New(m.rig.BeadsPath())
+	mrIssues, err := b.List(beads.ListOptions{Type: "merge-request", Status: "open", Priority: -1})
+	if err != nil {
+		_, _ = fmt.Fprintf(m.output, "Warning: could not list merge-request issues: %v\n", err)
+	}
+
+	// Test merges run in a scratch worktree so a running refinery's
+	// checkout is left alone.
+	scratch := filepath.Join(m.rig.Path, ".runtime", "retire", name)
+	_ = g.WorktreeRemove(scratch, true)
+	_ = os.RemoveAll(scratch)
+	if err := g.WorktreeAddDetached(scratch, "origin/"+target); err != nil {
+		return nil, fmt.Errorf("creating scratch worktree: %w", err)
+	}
+	defer func() {
+		_ = g.WorktreeRemove(scratch, true)
+		_ = g.WorktreePrune()
+	}()
+	sg := git.NewGit(scratch)
+
+	var swept []RetiredBranch
+	for _, branch := range all {
+		if !polecat.IsBranchOf(branch, name) {
+			continue
+		}
+		ref := "origin/" + branch
+		rb := RetiredBranch{Branch: branch}
+
+		var mr *mrqueue.MR
+		for _, qm := range queued {
+			if qm.Branch == branch {
+				mr = qm
+				break
+			}
+		}
+		var issue *beads.Issue
+		var fields *beads.MRFields
+		for _, is := range mrIssues {
+			if f := beads.ParseMRFields(is); f != nil && f.Branch == branch {
+				issue, fields = is, f
+				break
+			}
+		}
+
+		merged, err := g.IsAncestor(ref, "origin/"+target)
+		if err != nil {
+			return swept, fmt.Errorf("checking %s: %w", branch, err)
+		}
+		switch {
+		case merged:
+			rb.Action = RetireMerged
+		default:
+			conflicts, err := sg.CheckConflicts(ref, "origin/"+target)
+			if err != nil {
+				return swept, fmt.Errorf("checking %s for conflicts: %w", branch, err)
+			}
+			switch {
+			case len(conflicts) == 0:
+				rb.Action = RetireEnqueued
+			case opts.Delete:
+				rb.Action = RetireDeleted
+			default:
+				rb.Action = RetireArchived
+			}
+		}
+		if mr != nil {
+			rb.MRID = mr.ID
+		}
+
+		if rb.Action == RetireEnqueued {
+			if mr == nil && !opts.DryRun {
+				mr = &mrqueue.MR{Branch: branch, Target: target, Worker: name, Rig: m.rig.Name, Priority: 2}
+				if fields != nil {
+					mr.SourceIssue = fields.SourceIssue
+				}
+				mr.SwarmID = DetectSwarm(g, b, ref, target, mr.SourceIssue)
+				if err := q.Submit(mr); err != nil {
+					return swept, fmt.Errorf("enqueueing %s: %w", branch, err)
+				}
+				rb.MRID = mr.ID
+			}
+			swept = append(swept, rb)
+			continue
+		}
+
+		sourceIssue := ""
+		if fields != nil {
+			sourceIssue = fields.SourceIssue
+		} else if mr != nil {
+			sourceIssue = mr.SourceIssue
+		}
+		if issue != nil {
+			rb.Closed = append(rb.Closed, issue.ID)
+		}
+		if sourceIssue != "" {
+			if rb.Action == RetireMerged {
+				rb.Closed = append(rb.Closed, sourceIssue)
+			} else {
+				rb.Released = append(rb.Released, sourceIssue)
+			}
+		}
+		if opts.DryRun {
+			swept = append(swept, rb)
+			continue
+		}
+
+		if rb.Action == RetireArchived {
+			if err := g.Push("origin", ref+":refs/heads/"+ArchivePrefix+branch, true); err != nil {
+				return swept, fmt.Errorf("archiving %s: %w", branch, err)
+			}
+		}
+		if err := g.DeleteRemoteBranch("origin", branch); err != nil {
+			return swept, fmt.Errorf("deleting %s: %w", branch, err)
+		}
+		_ = g.DeleteBranch(branch, true) // Local copy, if any
+
+		reason := fmt.Sprintf("worker %s retired; branch %s", name, rb.Action)
+		if mr != nil {
+			if err := q.Remove(mr.ID); err != nil {
+				_, _ = fmt.Fprintf(m.output, "Warning: could not remove %s from the queue: %v\n", mr.ID, err)
+			}
+			_ = mrqueue.NewEventLoggerFromRig(m.rig.Path).LogMergeSkipped(mr, reason) // Non-fatal: history is best-effort
+		}
+		if len(rb.Closed) > 0 {
+			closeReason := reason
+			if rb.Action == RetireMerged {
+				closeReason = "merged"
+			}
+			if err := b.CloseWithReason(closeReason, rb.Closed...); err != nil {
+				_, _ = fmt.Fprintf(m.output, "Warning: could not close %v: %v\n", rb.Closed, err)
+			}
+		}
+		open, unassigned := "open", ""
+		for _, id := range rb.Released {
+			if err := b.Update(id, beads.UpdateOptions{Status: &open, Assignee: &unassigned}); err != nil {
+				_, _ = fmt.Fprintf(m.output, "Warning: could not release %s: %v\n", id, err)
+			}
+		}
+		swept = append(swept, rb)
+	}
+	return swept, nil
+}
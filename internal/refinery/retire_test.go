@@ -0,0 +1,111 @@
+package refinery
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func TestRetireWorker(t *testing.T) {
+	// polecat/nux/gt-1 conflicts with main; add a merged branch, a clean
+	// branch, and another worker's branch that must be left alone.
+	rigPath := setupConflictRig(t)
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = rigPath
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	run("push", "origin", "main:refs/heads/polecat/nux-merged")
+	for _, branch := range []string{"polecat/nux-clean", "polecat/slit-clean"} {
+		run("checkout", "-b", branch, "main")
+		if err := os.WriteFile(filepath.Join(rigPath, branch[len("polecat/"):]+".txt"), []byte("new\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		run("add", ".")
+		run("commit", "-m", branch)
+		run("push", "origin", branch)
+	}
+	run("checkout", "main")
+
+	q := mrqueue.New(rigPath)
+	conflicted := &mrqueue.MR{Branch: "polecat/nux/gt-1", Target: "main", Worker: "nux"}
+	if err := q.Submit(conflicted); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewManager(&rig.Rig{Name: "test-rig", Path: rigPath})
+	m.SetOutput(&strings.Builder{})
+	swept, err := m.RetireWorker("nux", RetireOptions{})
+	if err != nil {
+		t.Fatalf("RetireWorker: %v", err)
+	}
+
+	got := make(map[string]RetireAction)
+	for _, rb := range swept {
+		got[rb.Branch] = rb.Action
+	}
+	want := map[string]RetireAction{
+		"polecat/nux-merged": RetireMerged,
+		"polecat/nux-clean":  RetireEnqueued,
+		"polecat/nux/gt-1":   RetireArchived,
+	}
+	if len(got) != len(want) {
+		t.Errorf("swept %v, want %v", got, want)
+	}
+	for branch, action := range want {
+		if got[branch] != action {
+			t.Errorf("%s: action %q, want %q", branch, got[branch], action)
+		}
+	}
+
+	remote, err := git.NewGit(rigPath).ListRemoteBranches("origin", "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	has := make(map[string]bool)
+	for _, b := range remote {
+		has[b] = true
+	}
+	for _, b := range []string{"polecat/nux-clean", "polecat/slit-clean", "archive/polecat/nux/gt-1"} {
+		if !has[b] {
+			t.Errorf("origin lost %s: %v", b, remote)
+		}
+	}
+	for _, b := range []string{"polecat/nux-merged", "polecat/nux/gt-1"} {
+		if has[b] {
+			t.Errorf("origin still has %s", b)
+		}
+	}
+
+	queued, _ := q.List()
+	if len(queued) != 1 || queued[0].Branch != "polecat/nux-clean" {
+		t.Errorf("queue = %+v, want only polecat/nux-clean", queued)
+	}
+}
+
+func TestRetireWorker_DryRun(t *testing.T) {
+	rigPath := setupConflictRig(t)
+	m := NewManager(&rig.Rig{Name: "test-rig", Path: rigPath})
+	m.SetOutput(&strings.Builder{})
+
+	swept, err := m.RetireWorker("nux", RetireOptions{DryRun: true, Delete: true})
+	if err != nil {
+		t.Fatalf("RetireWorker: %v", err)
+	}
+	if len(swept) != 1 || swept[0].Action != RetireDeleted {
+		t.Errorf("swept = %+v, want polecat/nux/gt-1 deleted", swept)
+	}
+	remote, _ := git.NewGit(rigPath).ListRemoteBranches("origin", "polecat/*")
+	if len(remote) != 1 {
+		t.Errorf("dry run changed origin: %v", remote)
+	}
+}
@@ -0,0 +1,188 @@
+package refinery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// reviewCheck is the check name a review is recorded under in an MR's
+// check results and failure feedback.
+const reviewCheck = "review"
+
+// ReviewContext is written to $GT_REVIEW_CONTEXT for the reviewer.
+type ReviewContext struct {
+	MRID        string   `json:"mr_id"`
+	Branch      string   `json:"branch"`
+	Target      string   `json:"target"`
+	Worker      string   `json:"worker,omitempty"`
+	SourceIssue string   `json:"source_issue,omitempty"`
+	Title       string   `json:"title,omitempty"`
+	Files       []string `json:"files"`
+}
+
+// ReviewFinding is one comment in a review.
+type ReviewFinding struct {
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Severity string `json:"severity,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (f ReviewFinding) String() string {
+	var sb strings.Builder
+	if f.File != "" {
+		sb.WriteString(f.File)
+		if f.Line > 0 {
+			fmt.Fprintf(&sb, ":%d", f.Line)
+		}
+		sb.WriteString(": ")
+	}
+	if f.Severity != "" {
+		fmt.Fprintf(&sb, "[%s] ", f.Severity)
+	}
+	sb.WriteString(f.Message)
+	return sb.String()
+}
+
+// ReviewReport is what the reviewer writes to $GT_REVIEW_RESULT.
+type ReviewReport struct {
+	Verdict  string          `json:"verdict"` // config.ReviewApprove, ReviewComment, or ReviewRequestChanges
+	Summary  string          `json:"summary,omitempty"`
+	Findings []ReviewFinding `json:"findings,omitempty"`
+}
+
+// format renders the report for check output and feedback.
+func (r *ReviewReport) format() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "verdict: %s\n", r.Verdict)
+	if r.Summary != "" {
+		sb.WriteString(r.Summary + "\n")
+	}
+	for _, f := range r.Findings {
+		sb.WriteString("- " + f.String() + "\n")
+	}
+	return sb.String()
+}
+
+// reviewMerge runs the rig's reviewer on mr's diff against onto. It
+// passes unless the reviewer requests changes; a reviewer that fails or
+// reports nothing fails it too, so the gate never opens by accident.
+func (e *Engineer) reviewMerge(ctx context.Context, mr *mrqueue.MR, onto string) ProcessResult {
+	if e.settings == nil || e.settings.Review == nil {
+		return ProcessResult{Success: true}
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Reviewing %s\n", mr.Branch)
+	report, err := e.review(ctx, mr, onto)
+	if err != nil {
+		return ProcessResult{
+			Success:     false,
+			Error:       fmt.Sprintf("review failed: %v", err),
+			FailedCheck: reviewCheck,
+		}
+	}
+	output := util.Redact(report.format())
+	if report.Verdict == config.ReviewRequestChanges {
+		return ProcessResult{
+			Success:     false,
+			TestsFailed: true,
+			Error:       fmt.Sprintf("review requested changes (%d findings)", len(report.Findings)),
+			FailedCheck: reviewCheck,
+			CheckOutput: output,
+		}
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Review passed:\n%s", output)
+	return ProcessResult{Success: true}
+}
+
+// review runs the reviewer command and returns its report.
+func (e *Engineer) review(ctx context.Context, mr *mrqueue.MR, onto string) (*ReviewReport, error) {
+	rc := e.settings.Review
+	diff, err := e.git.Diff(onto, mr.Branch)
+	if err != nil {
+		return nil, err
+	}
+	files, err := e.git.ChangedFiles(onto, mr.Branch)
+	if err != nil {
+		return nil, err
+	}
+
+	runtimeDir := filepath.Join(e.rig.Path, ".runtime")
+	if err := os.MkdirAll(runtimeDir, 0755); err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp(runtimeDir, "review-")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	diffFile := filepath.Join(dir, "diff.patch")
+	contextFile := filepath.Join(dir, "context.json")
+	resultFile := filepath.Join(dir, "result.json")
+	data, err := json.MarshalIndent(ReviewContext{
+		MRID:        mr.ID,
+		Branch:      mr.Branch,
+		Target:      mr.Target,
+		Worker:      mr.Worker,
+		SourceIssue: mr.SourceIssue,
+		Title:       mr.Title,
+		Files:       files,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(contextFile, data, 0644); err != nil { //nolint:gosec // G306: not sensitive
+		return nil, err
+	}
+	if err := os.WriteFile(diffFile, []byte(diff+"\n"), 0644); err != nil { //nolint:gosec // G306: not sensitive
+		return nil, err
+	}
+
+	env, err := e.processEnv()
+	if err != nil {
+		return nil, err
+	}
+	env = append(env,
+		"GT_REVIEW_DIFF="+diffFile,
+		"GT_REVIEW_GUIDELINES="+rc.GuidelinesFile(e.rig.Path),
+		"GT_REVIEW_CONTEXT="+contextFile,
+		"GT_REVIEW_RESULT="+resultFile,
+		"GT_MR_ID="+mr.ID,
+		"GT_BRANCH="+mr.Branch,
+		"GT_TARGET="+mr.Target,
+	)
+
+	if _, err := e.runAgent(ctx, "reviewer", rc.Command, rc.TimeoutDuration(), env); err != nil {
+		return nil, err
+	}
+	return loadReviewReport(resultFile)
+}
+
+// loadReviewReport reads the reviewer's report, which must carry a verdict.
+func loadReviewReport(p string) (*ReviewReport, error) {
+	data, err := os.ReadFile(p) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("reviewer wrote no report")
+		}
+		return nil, err
+	}
+	var r ReviewReport
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parsing review report: %w", err)
+	}
+	switch r.Verdict {
+	case config.ReviewApprove, config.ReviewComment, config.ReviewRequestChanges:
+	default:
+		return nil, fmt.Errorf("reviewer reported verdict %q, want %q, %q, or %q",
+			r.Verdict, config.ReviewApprove, config.ReviewComment, config.ReviewRequestChanges)
+	}
+	return &r, nil
+}
@@ -0,0 +1,73 @@
+package refinery
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+func TestReviewMerge(t *testing.T) {
+	e, first, _, _, _ := setupPipelineRig(t)
+	if err := e.git.Checkout("main"); err != nil {
+		t.Fatal(err)
+	}
+	guidelines := filepath.Join(e.rig.Path, "settings", "REVIEWING.md")
+	if err := os.WriteFile(guidelines, []byte("no new files\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// The reviewer requests changes if the guidelines forbid new files and
+	// the diff adds one.
+	reviewer := `if grep -q "no new files" "$GT_REVIEW_GUIDELINES" && grep -q "^new file" "$GT_REVIEW_DIFF"; then
+  echo '{"verdict": "request_changes", "summary": "adds a file", "findings": [{"file": "polecat-nux-gt-1", "line": 1, "severity": "major", "message": "new file"}]}' > "$GT_REVIEW_RESULT"
+else
+  echo '{"verdict": "approve"}' > "$GT_REVIEW_RESULT"
+fi`
+
+	tests := []struct {
+		name       string
+		review     *config.ReviewConfig
+		wantOK     bool
+		wantOutput string
+	}{
+		{"no reviewer", nil, true, ""},
+		{"approve", &config.ReviewConfig{Command: reviewer}, true, ""},
+		{"request changes", &config.ReviewConfig{Command: reviewer, Guidelines: "REVIEWING.md"}, false,
+			"- polecat-nux-gt-1:1: [major] new file"},
+		{"no report", &config.ReviewConfig{Command: "true"}, false, ""},
+		{"bad verdict", &config.ReviewConfig{Command: `echo '{"verdict": "lgtm"}' > "$GT_REVIEW_RESULT"`}, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e.settings.Review = tt.review
+			res := e.reviewMerge(t.Context(), first, "main")
+			if res.Success != tt.wantOK {
+				t.Fatalf("Success = %v (%s), want %v", res.Success, res.Error, tt.wantOK)
+			}
+			if !tt.wantOK && tt.review != nil && res.FailedCheck != reviewCheck {
+				t.Errorf("FailedCheck = %q, want %q", res.FailedCheck, reviewCheck)
+			}
+			if !strings.Contains(res.CheckOutput, tt.wantOutput) {
+				t.Errorf("CheckOutput = %q, want it to contain %q", res.CheckOutput, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestDoMerge_ReviewBlocks(t *testing.T) {
+	e, first, _, _, _ := setupPipelineRig(t)
+	e.settings.Review = &config.ReviewConfig{
+		Command: `echo '{"verdict": "request_changes", "summary": "not yet"}' > "$GT_REVIEW_RESULT"`,
+	}
+	before, _ := e.git.Rev("main")
+
+	res := e.doMerge(t.Context(), first)
+	if res.Success || res.FailedCheck != reviewCheck || !strings.Contains(res.CheckOutput, "not yet") {
+		t.Fatalf("doMerge = %+v, want a blocked review", res)
+	}
+	if after, _ := e.git.Rev("main"); after != before {
+		t.Error("blocked MR was merged")
+	}
+}
@@ -0,0 +1,181 @@
+package refinery
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// Weights of the parts of an MR's risk score. They sum to 1.
+const (
+	riskWeightSize     = 0.35
+	riskWeightPaths    = 0.25
+	riskWeightFailures = 0.25
+	riskWeightUntested = 0.15
+)
+
+// Risk is an MR's risk score and what went into it.
+type Risk struct {
+	MRID  string  `json:"mr_id"`
+	Score float64 `json:"score"` // 0 (safe) to 1
+	High  bool    `json:"high"`  // At or above refinery.risk.high
+
+	Lines       int      `json:"lines"`                 // Lines the branch changes
+	RiskyPaths  []string `json:"risky_paths,omitempty"` // Changed files under refinery.risk.paths
+	FailureRate float64  `json:"failure_rate"`          // The worker's failed share of recent merge attempts
+	Untested    float64  `json:"untested"`              // Share of changed source files with no tests beside them
+}
+
+// RiskScores scores mrs under [refinery.risk], by MR ID. It returns nil if
+// the rig has no [refinery.risk].
+func (e *Engineer) RiskScores(mrs []*mrqueue.MR) (map[string]Risk, error) {
+	if e.settings == nil || e.settings.Risk == nil {
+		return nil, nil
+	}
+	rates := e.workerFailureRates(e.clock())
+	risks := make(map[string]Risk, len(mrs))
+	for _, mr := range mrs {
+		r, err := e.scoreRisk(e.settings.Risk, mr, rates)
+		if err != nil {
+			return nil, fmt.Errorf("scoring %s: %w", mr.ID, err)
+		}
+		risks[mr.ID] = r
+	}
+	return risks, nil
+}
+
+// orderByRisk reorders ready MRs by refinery.risk.order. MRs that cannot
+// be scored count as high risk.
+func (e *Engineer) orderByRisk(mrs []*mrqueue.MR) []*mrqueue.MR {
+	if e.settings == nil || e.settings.Risk == nil || e.settings.Risk.Order == "" || len(mrs) < 2 {
+		return mrs
+	}
+	rc := e.settings.Risk
+	rates := e.workerFailureRates(e.clock())
+	risks := make(map[string]Risk, len(mrs))
+	for _, mr := range mrs {
+		r, err := e.scoreRisk(rc, mr, rates)
+		if err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: scoring risk of %s: %v\n", mr.ID, err)
+			r = Risk{MRID: mr.ID, Score: 1, High: true}
+		}
+		risks[mr.ID] = r
+	}
+	sort.SliceStable(mrs, func(i, j int) bool {
+		ri, rj := risks[mrs[i].ID], risks[mrs[j].ID]
+		if rc.Order == config.RiskOrderBatch {
+			return !ri.High && rj.High
+		}
+		return ri.Score < rj.Score
+	})
+	return mrs
+}
+
+// scoreRisk scores mr's branch against its target.
+func (e *Engineer) scoreRisk(rc *config.RiskConfig, mr *mrqueue.MR, failureRates map[string]float64) (Risk, error) {
+	r := Risk{MRID: mr.ID, FailureRate: failureRates[path.Base(mr.Worker)]}
+	target := "origin/" + mr.Target
+	if _, err := e.git.Rev(target); err != nil {
+		target = mr.Target
+	}
+	base, err := e.git.MergeBase(target, mr.Branch)
+	if err != nil {
+		return Risk{}, err
+	}
+	if _, r.Lines, err = e.git.DiffStat(base, mr.Branch); err != nil {
+		return Risk{}, err
+	}
+	files, err := e.git.ChangedFiles(target, mr.Branch)
+	if err != nil {
+		return Risk{}, err
+	}
+	for _, f := range files {
+		if _, ok := rc.MatchPath(f); ok {
+			r.RiskyPaths = append(r.RiskyPaths, f)
+		}
+	}
+	tree, err := e.git.ListFiles(mr.Branch)
+	if err != nil {
+		return Risk{}, err
+	}
+	r.Untested = untestedShare(files, tree)
+
+	size := float64(r.Lines) / float64(rc.LargeLines())
+	if size > 1 {
+		size = 1
+	}
+	risky := 0.0
+	if len(r.RiskyPaths) > 0 {
+		risky = 1
+	}
+	r.Score = riskWeightSize*size + riskWeightPaths*risky + riskWeightFailures*r.FailureRate + riskWeightUntested*r.Untested
+	r.High = r.Score >= rc.HighThreshold()
+	return r, nil
+}
+
+// workerFailureRates returns each worker's failed share of its merge
+// attempts over the default stats window, by bare name.
+func (e *Engineer) workerFailureRates(now time.Time) map[string]float64 {
+	stats, err := CachedStats(e.rig.Path, e.eventLogger, now, DefaultStatsDays)
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to read merge history: %v\n", err)
+		return nil
+	}
+	rates := make(map[string]float64, len(stats.Workers))
+	for _, ws := range stats.Workers {
+		if attempts := ws.Merged + ws.Failed; attempts > 0 {
+			rates[ws.Worker] = float64(ws.Failed) / float64(attempts)
+		}
+	}
+	return rates
+}
+
+// untestedShare returns the share of the changed source files (not tests
+// or docs) whose directory in tree holds no test files.
+func untestedShare(changed, tree []string) float64 {
+	tested := make(map[string]bool)
+	for _, f := range tree {
+		if isTestFile(f) {
+			tested[path.Dir(f)] = true
+		}
+	}
+	var sources, untested int
+	for _, f := range changed {
+		if isTestFile(f) || isDocFile(f) {
+			continue
+		}
+		sources++
+		if !tested[path.Dir(f)] {
+			untested++
+		}
+	}
+	if sources == 0 {
+		return 0
+	}
+	return float64(untested) / float64(sources)
+}
+
+// isTestFile reports whether f looks like a test by the usual naming
+// conventions (foo_test.go, test_foo.py, foo.test.ts, foo_spec.rb, ...).
+func isTestFile(f string) bool {
+	name := path.Base(f)
+	stem := strings.TrimSuffix(name, path.Ext(name))
+	return strings.HasPrefix(name, "test_") ||
+		strings.HasSuffix(stem, "_test") || strings.HasSuffix(stem, ".test") ||
+		strings.HasSuffix(stem, "_spec") || strings.HasSuffix(stem, ".spec") ||
+		strings.HasSuffix(stem, "Test")
+}
+
+// isDocFile reports whether f is documentation, which needs no tests.
+func isDocFile(f string) bool {
+	switch strings.ToLower(path.Ext(f)) {
+	case ".md", ".txt", ".rst", ".adoc":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package refinery
+
+import (
+	"math"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+func TestUntestedShare(t *testing.T) {
+	tree := []string{
+		"api/server.go", "api/server_test.go",
+		"web/app.ts", "web/app.test.ts",
+		"db/migrate.py",
+		"lib/util.rb", "spec/util_spec.rb",
+		"README.md",
+	}
+	tests := []struct {
+		changed []string
+		want    float64
+	}{
+		{nil, 0},
+		{[]string{"README.md", "api/server_test.go"}, 0},
+		{[]string{"api/server.go", "web/app.ts"}, 0},
+		{[]string{"api/server.go", "db/migrate.py"}, 0.5},
+		{[]string{"db/migrate.py", "lib/util.rb"}, 1},
+	}
+	for _, tt := range tests {
+		if got := untestedShare(tt.changed, tree); got != tt.want {
+			t.Errorf("untestedShare(%v) = %g, want %g", tt.changed, got, tt.want)
+		}
+	}
+}
+
+func TestOrderByRisk(t *testing.T) {
+	e, first, second, _, _ := setupPipelineRig(t)
+
+	ready, err := e.readyMRs()
+	if err != nil || len(ready) != 2 || ready[0].ID != first.ID {
+		t.Fatalf("readyMRs without risk = %v, %v; want %s first", ready, err, first.ID)
+	}
+
+	// first's branch touches a risky path: 0.25 for the path plus 0.15
+	// for its untested file, against second's 0.15.
+	e.settings.Risk = &config.RiskConfig{Order: config.RiskOrderBatch, High: 0.3, Paths: []string{"polecat-nux-*"}}
+	risks, err := e.RiskScores([]*mrqueue.MR{first, second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := risks[first.ID]; !r.High || math.Abs(r.Score-0.4) > 0.01 || len(r.RiskyPaths) != 1 || r.Lines != 1 {
+		t.Errorf("first's risk = %+v, want high at 0.4", r)
+	}
+	if r := risks[second.ID]; r.High || math.Abs(r.Score-0.15) > 0.01 {
+		t.Errorf("second's risk = %+v, want low at 0.15", r)
+	}
+
+	for _, order := range []string{config.RiskOrderBatch, config.RiskOrderLowFirst} {
+		e.settings.Risk.Order = order
+		ready, err := e.readyMRs()
+		if err != nil || len(ready) != 2 || ready[0].ID != second.ID {
+			t.Errorf("readyMRs with order %q = %v, %v; want %s first", order, ready, err, second.ID)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package refinery
+
+import (
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// routeMR applies [[refinery.routes]] to mr as it is queued: it records
+// the routes the branch's diff against its target matches and, if one of
+// them has a target, sends an MR queued for defaultBranch alone there
+// instead. A branch that cannot be diffed yet is queued unrouted.
+func routeMR(g GitRunner, s *config.RefinerySettings, defaultBranch string, mr *mrqueue.MR) {
+	if s == nil || len(s.Routes) == 0 {
+		return
+	}
+	files, err := g.ChangedFiles(mr.Target, mr.Branch)
+	if err != nil {
+		return
+	}
+	mr.Routes = nil
+	for _, r := range s.MatchRoutes(files) {
+		mr.Routes = append(mr.Routes, r.Name)
+		if r.Target != "" && mr.Target == defaultBranch && len(mr.Targets) == 0 {
+			mr.Target = r.Target
+		}
+	}
+}
+
+// Route applies the rig's [[refinery.routes]] to an MR about to be queued.
+func (m *Manager) Route(mr *mrqueue.MR) {
+	routeMR(git.NewGit(m.rig.Path), m.settings, m.rig.DefaultBranch(), mr)
+}
@@ -0,0 +1,53 @@
+package refinery
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+func TestRouteMR(t *testing.T) {
+	e, repo := newFakeEngineer(t)
+	e.settings.Checks = []config.CheckConfig{
+		{Name: "lint", Command: "true"},
+		{Name: "test", Command: "false"},
+	}
+	e.settings.Routes = []config.RouteConfig{
+		{Name: "docs", Paths: []string{"docs/**"}, SkipChecks: []string{"test"}},
+		{Name: "site", Paths: []string{"site/"}, Target: "site"},
+	}
+	route := func(branch string, changes map[string]string) *mrqueue.MR {
+		t.Helper()
+		if err := e.git.CreateBranchFrom(branch, "main"); err != nil {
+			t.Fatal(err)
+		}
+		repo.Commit(branch, branch, changes)
+		mr := &mrqueue.MR{Branch: branch, Target: "main", Worker: "nux"}
+		routeMR(e.git, e.settings, "main", mr)
+		return mr
+	}
+
+	docs := route("polecat/nux/gt-1", map[string]string{"docs/guide.md": "guide\n"})
+	if !reflect.DeepEqual(docs.Routes, []string{"docs"}) || docs.Target != "main" {
+		t.Fatalf("docs MR routed %v to %s, want [docs] on main", docs.Routes, docs.Target)
+	}
+	if res := e.doMerge(context.Background(), docs); !res.Success {
+		t.Errorf("doMerge(docs) = %+v, want the failing test check skipped", res)
+	}
+
+	code := route("polecat/nux/gt-2", map[string]string{"docs/guide.md": "more\n", "main.go": "package main\n"})
+	if len(code.Routes) != 0 {
+		t.Fatalf("mixed MR routed %v, want the full checks", code.Routes)
+	}
+	if res := e.doMerge(context.Background(), code); res.Success || res.FailedCheck != "test" {
+		t.Errorf("doMerge(mixed) = %+v, want the test check to fail it", res)
+	}
+
+	site := route("polecat/nux/gt-3", map[string]string{"site/index.html": "<p>\n"})
+	if site.Target != "site" {
+		t.Errorf("site MR target = %s, want the route's target", site.Target)
+	}
+}
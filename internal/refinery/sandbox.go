@@ -0,0 +1,143 @@
+package refinery
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// sandboxReadOnly are the paths under the merge worktree a sandboxed
+// command may read but not write: git metadata, and for the rig's own
+// checkout, the refinery's state and settings.
+var sandboxReadOnly = []string{".git", ".beads", ".runtime", "settings"}
+
+// shellCommand returns a command running command with sh -c in dir with
+// env. When sandboxed and rig.toml has [refinery.sandbox], it runs inside
+// the sandbox instead (see SandboxConfig).
+func (e *Engineer) shellCommand(ctx context.Context, dir, command string, env []string, sandboxed bool) *exec.Cmd {
+	var sc *config.SandboxConfig
+	if e.settings != nil && sandboxed {
+		sc = e.settings.Sandbox
+	}
+	if sc == nil {
+		cmd := exec.CommandContext(ctx, "sh", "-c", command) //nolint:gosec // G204: trusted rig config
+		cmd.Dir = dir
+		cmd.Env = env
+		return cmd
+	}
+
+	name := "gt-check-" + randomSuffix()
+	cmd := exec.CommandContext(ctx, sc.Runtime, sandboxArgs(sc, name, e.workDir, dir, command, env)...) //nolint:gosec // G204: trusted rig config
+	cmd.Dir = dir
+	cmd.Env = env
+	if sc.IsContainer() {
+		// Killing the client leaves the container running; stop it too.
+		runtime := sc.Runtime
+		cmd.Cancel = func() error {
+			_ = exec.Command(runtime, "kill", name).Run() //nolint:gosec // G204: name is generated
+			return cmd.Process.Kill()
+		}
+	}
+	return cmd
+}
+
+// sandboxArgs builds the sandbox runtime's arguments to run command in dir,
+// with workDir the only writable part of the host besides sc.Writable.
+// Containers are given name.
+func sandboxArgs(sc *config.SandboxConfig, name, workDir, dir, command string, env []string) []string {
+	var readOnly []string
+	for _, p := range sandboxReadOnly {
+		if _, err := os.Stat(filepath.Join(workDir, p)); err == nil {
+			readOnly = append(readOnly, filepath.Join(workDir, p))
+		}
+	}
+	writable := expandHome(sc.Writable)
+
+	if !sc.IsContainer() {
+		args := []string{
+			"--ro-bind", "/", "/",
+			"--dev", "/dev",
+			"--proc", "/proc",
+			"--tmpfs", "/tmp",
+			"--bind", workDir, workDir,
+		}
+		for _, p := range readOnly {
+			args = append(args, "--ro-bind", p, p)
+		}
+		for _, p := range writable {
+			args = append(args, "--bind", p, p)
+		}
+		args = append(args, "--unshare-all")
+		if sc.Network {
+			args = append(args, "--share-net")
+		}
+		return append(args, "--die-with-parent", "--new-session", "--chdir", dir, "sh", "-c", command)
+	}
+
+	args := []string{
+		"run", "--rm", "-i",
+		"--name", name,
+		"--cap-drop", "ALL",
+		"--security-opt", "no-new-privileges",
+		"--user", strconv.Itoa(os.Getuid()) + ":" + strconv.Itoa(os.Getgid()),
+		"-v", workDir + ":" + workDir,
+	}
+	if !sc.Network {
+		args = append(args, "--network", "none")
+	}
+	for _, p := range readOnly {
+		args = append(args, "-v", p+":"+p+":ro")
+	}
+	for _, p := range writable {
+		args = append(args, "-v", p+":"+p)
+	}
+	// The container does not inherit the refinery's environment; pass
+	// through what the refinery adds (rig env, hook variables) by name so
+	// values, secrets included, stay off the command line.
+	for _, name := range addedEnv(env) {
+		args = append(args, "-e", name)
+	}
+	return append(args, "-w", dir, sc.Image, "sh", "-c", command)
+}
+
+// addedEnv returns the names of variables in env that are not in the
+// refinery's own environment, or differ from it.
+func addedEnv(env []string) []string {
+	host := make(map[string]bool)
+	for _, kv := range os.Environ() {
+		host[kv] = true
+	}
+	var names []string
+	for _, kv := range env {
+		if name, _, ok := strings.Cut(kv, "="); ok && !host[kv] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// expandHome resolves "~/" prefixes against the home directory.
+func expandHome(paths []string) []string {
+	home, _ := os.UserHomeDir()
+	out := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if rest, ok := strings.CutPrefix(p, "~/"); ok {
+			p = filepath.Join(home, rest)
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func randomSuffix() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,81 @@
+package refinery
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+func TestSandboxArgs(t *testing.T) {
+	work := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(work, ".beads"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	env := append(os.Environ(), "GT_HOOK=post_merge", "API_KEY=s3cret")
+
+	docker := sandboxArgs(&config.SandboxConfig{Runtime: config.SandboxDocker, Image: "golang:1.24"},
+		"gt-check-1", work, work, "go test ./...", env)
+	joined := strings.Join(docker, " ")
+	for _, want := range []string{
+		"--name gt-check-1",
+		"--network none",
+		"-v " + work + ":" + work,
+		"-v " + filepath.Join(work, ".beads") + ":" + filepath.Join(work, ".beads") + ":ro",
+		"-e GT_HOOK", "-e API_KEY",
+		"golang:1.24 sh -c go test ./...",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("docker args missing %q: %s", want, joined)
+		}
+	}
+	if strings.Contains(joined, "s3cret") {
+		t.Error("docker args expose an env value")
+	}
+	if strings.Contains(joined, ".runtime") {
+		t.Error("read-only bind for a missing path")
+	}
+
+	bwrap := sandboxArgs(&config.SandboxConfig{Runtime: config.SandboxBwrap, Network: true, Writable: []string{"/var/cache/go"}},
+		"", work, work, "make test", env)
+	for _, want := range [][]string{
+		{"--ro-bind", "/", "/"},
+		{"--bind", work, work},
+		{"--ro-bind", filepath.Join(work, ".beads"), filepath.Join(work, ".beads")},
+		{"--bind", "/var/cache/go", "/var/cache/go"},
+		{"--share-net"},
+		{"--chdir", work, "sh", "-c", "make test"},
+	} {
+		if !containsRun(bwrap, want) {
+			t.Errorf("bwrap args missing %v: %v", want, bwrap)
+		}
+	}
+}
+
+func TestShellCommand_Unsandboxed(t *testing.T) {
+	e := &Engineer{workDir: t.TempDir(), settings: &config.RefinerySettings{
+		Sandbox: &config.SandboxConfig{Runtime: config.SandboxBwrap},
+	}}
+	cmd := e.shellCommand(context.Background(), e.workDir, "true", nil, false)
+	if !slices.Equal(cmd.Args, []string{"sh", "-c", "true"}) {
+		t.Errorf("Args = %v, want sh -c true", cmd.Args)
+	}
+	cmd = e.shellCommand(context.Background(), e.workDir, "true", nil, true)
+	if cmd.Args[0] != "bwrap" {
+		t.Errorf("sandboxed Args = %v, want bwrap", cmd.Args)
+	}
+}
+
+// containsRun reports whether want appears as a contiguous run in args.
+func containsRun(args, want []string) bool {
+	for i := 0; i+len(want) <= len(args); i++ {
+		if slices.Equal(args[i:i+len(want)], want) {
+			return true
+		}
+	}
+	return false
+}
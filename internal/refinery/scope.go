@@ -0,0 +1,37 @@
+package refinery
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/git"
+)
+
+// ErrOutOfScope means a branch changes nothing under the rig's
+// refinery.paths, so it belongs to another rig sharing the repository.
+var ErrOutOfScope = errors.New("branch does not touch refinery.paths")
+
+// checkScope reports whether branch touches the scoped paths. Returns nil
+// when the rig is not scoped, an error wrapping ErrOutOfScope when it does
+// not, and other errors when the diff could not be computed.
+func checkScope(g GitRunner, s *config.RefinerySettings, branch, target string) error {
+	if s == nil || len(s.Paths) == 0 {
+		return nil
+	}
+	files, err := g.ChangedFiles(target, branch)
+	if err != nil {
+		return fmt.Errorf("diffing %s against %s: %w", branch, target, err)
+	}
+	if !s.Touches(files) {
+		return fmt.Errorf("%w %v: %s", ErrOutOfScope, s.Paths, branch)
+	}
+	return nil
+}
+
+// CheckScope reports whether a branch belongs to this rig's slice of a
+// shared repository. Enqueue paths reject only ErrOutOfScope; a branch
+// that cannot be diffed yet is left for the merge-time check.
+func (m *Manager) CheckScope(branch, target string) error {
+	return checkScope(git.NewGit(m.rig.Path), m.settings, branch, target)
+}
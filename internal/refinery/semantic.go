@@ -0,0 +1,264 @@
+package refinery
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// Two branches can merge without a textual conflict and still break the
+// target together: one changes a function's signature while the other
+// edits its body, say. With [refinery] semantic_conflicts set, conflict
+// scans also parse the Go files each queued branch changes and compare the
+// top-level declarations it touches, flagging pairs that touch the same
+// ones.
+
+// SemanticOverlap is a pair of queued MRs whose branches change the same
+// Go declarations, whether or not they merge cleanly.
+type SemanticOverlap struct {
+	MRs      [2]string `json:"mrs"` // In queue order
+	Branches [2]string `json:"branches"`
+	Symbols  []string  `json:"symbols"` // e.g. "internal/refinery.Engineer.doMerge"
+}
+
+// OverlapsWith returns the semantic overlaps involving branch.
+func (s *ConflictScan) OverlapsWith(branch string) []SemanticOverlap {
+	if s == nil {
+		return nil
+	}
+	var out []SemanticOverlap
+	for _, o := range s.Overlaps {
+		if o.Branches[0] == branch || o.Branches[1] == branch {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+// semanticConflicts returns the rig's semantic_conflicts mode, "" if off.
+func (e *Engineer) semanticConflicts() string {
+	if e.settings == nil {
+		return ""
+	}
+	return e.settings.SemanticConflicts
+}
+
+// scanOverlaps records in scan every pair of heads whose branches change
+// a declaration in common. Each head's changed declarations are reused
+// from prev while its branch and merge base have not moved.
+func (e *Engineer) scanOverlaps(scan, prev *ConflictScan, heads []queuedHead) {
+	scan.Symbols = make(map[string][]string)
+	changed := make([][]string, len(heads))
+	for i, h := range heads {
+		target := "origin/" + h.mr.Target
+		if _, err := e.git.Rev(target); err != nil {
+			target = h.mr.Target
+		}
+		base, err := e.git.MergeBase(target, h.sha)
+		if err != nil {
+			continue // Unrelated or missing target; the merge reports it
+		}
+		key := base + ".." + h.sha
+		symbols, ok := prev.symbols(key)
+		if !ok {
+			if symbols, err = e.changedSymbols(base, h.sha); err != nil {
+				continue
+			}
+		}
+		scan.Symbols[key] = symbols
+		changed[i] = symbols
+	}
+
+	for i, a := range heads {
+		for j := i + 1; j < len(heads); j++ {
+			b := heads[j]
+			if a.sha == b.sha {
+				continue
+			}
+			if common := intersectSorted(changed[i], changed[j]); len(common) > 0 {
+				scan.Overlaps = append(scan.Overlaps, SemanticOverlap{
+					MRs:      [2]string{a.mr.ID, b.mr.ID},
+					Branches: [2]string{a.mr.Branch, b.mr.Branch},
+					Symbols:  common,
+				})
+			}
+		}
+	}
+}
+
+// symbols returns a head's changed declarations at the last scan, and
+// whether it was parsed at all.
+func (s *ConflictScan) symbols(key string) ([]string, bool) {
+	if s == nil {
+		return nil, false
+	}
+	symbols, ok := s.Symbols[key]
+	return symbols, ok
+}
+
+// changedSymbols returns, sorted, the top-level Go declarations that
+// differ between base and branch: added, removed, or with different
+// source. Files that do not parse on either side are skipped; a textual
+// conflict check still covers them.
+func (e *Engineer) changedSymbols(base, branch string) ([]string, error) {
+	files, err := e.git.ChangedFiles(base, branch)
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[string]bool)
+	for _, f := range files {
+		if !strings.HasSuffix(f, ".go") {
+			continue
+		}
+		before, _ := e.git.ShowFile(base, f) // Absent: the branch adds the file
+		after, _ := e.git.ShowFile(branch, f)
+		old, err := goDecls(f, before)
+		if err != nil {
+			continue
+		}
+		cur, err := goDecls(f, after)
+		if err != nil {
+			continue
+		}
+		for name, src := range cur {
+			if old[name] != src {
+				set[name] = true
+			}
+		}
+		for name := range old {
+			if _, ok := cur[name]; !ok {
+				set[name] = true
+			}
+		}
+	}
+	symbols := make([]string, 0, len(set))
+	for name := range set {
+		symbols = append(symbols, name)
+	}
+	sort.Strings(symbols)
+	return symbols, nil
+}
+
+// goDecls maps each top-level declaration in a Go file to its source,
+// doc comments aside. Names are qualified with the file's directory, and
+// methods with their receiver type, so "pkg/x.T.M" is method M on T in
+// pkg/x. An empty src has no declarations.
+func goDecls(file, src string) (map[string]string, error) {
+	decls := make(map[string]string)
+	if src == "" {
+		return decls, nil
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	dir := path.Dir(file)
+	text := func(n ast.Node) string {
+		return src[fset.Position(n.Pos()).Offset:fset.Position(n.End()).Offset]
+	}
+	for _, d := range f.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			name := d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				name = receiverType(d.Recv.List[0].Type) + "." + name
+			}
+			if name == "init" {
+				continue // Each init is its own function; editing two is no overlap
+			}
+			decls[dir+"."+name] = text(d)
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					decls[dir+"."+s.Name.Name] = text(s)
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						if n.Name != "_" {
+							decls[dir+"."+n.Name] = text(s)
+						}
+					}
+				}
+			}
+		}
+	}
+	return decls, nil
+}
+
+// receiverType returns the type name of a method receiver, without any
+// pointer or type parameters.
+func receiverType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverType(t.X)
+	case *ast.IndexExpr:
+		return receiverType(t.X)
+	case *ast.IndexListExpr:
+		return receiverType(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+// intersectSorted returns the strings in both sorted slices.
+func intersectSorted(a, b []string) []string {
+	var out []string
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	return out
+}
+
+// serializeOverlaps drops, with semantic_conflicts = "serialize", ready
+// MRs the last conflict scan found overlapping an MR ahead of them that is
+// ready or claimed. The later MR becomes ready once the earlier one has
+// merged, or failed back to its worker, so it is never checked alongside
+// or pipelined behind a change to the same declarations.
+func (e *Engineer) serializeOverlaps(mrs, queued []*mrqueue.MR) []*mrqueue.MR {
+	if e.semanticConflicts() != config.SemanticConflictsSerialize {
+		return mrs
+	}
+	scan, err := LoadConflictScan(e.rig.Path)
+	if err != nil || scan == nil || len(scan.Overlaps) == 0 {
+		return mrs
+	}
+	active := make(map[string]bool)
+	for _, mr := range mrs {
+		active[mr.ID] = true
+	}
+	for _, mr := range queued {
+		if mr.IsClaimed(e.clock()) {
+			active[mr.ID] = true
+		}
+	}
+	held := make(map[string]bool)
+	for _, o := range scan.Overlaps {
+		if active[o.MRs[0]] {
+			held[o.MRs[1]] = true
+		}
+	}
+	out := mrs[:0]
+	for _, mr := range mrs {
+		if !held[mr.ID] {
+			out = append(out, mr)
+		}
+	}
+	return out
+}
@@ -0,0 +1,127 @@
+package refinery
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+func TestGoDecls(t *testing.T) {
+	src := `package calc
+
+// Add adds.
+func Add(a, b int) int { return a + b }
+
+func (c *Calc[T]) Reset() {}
+
+func init() {}
+
+type Calc[T any] struct{ n T }
+
+var x, _ = 1, 2
+
+const (
+	A = 1
+	B = 2
+)
+`
+	decls, err := goDecls("pkg/calc/calc.go", src)
+	if err != nil {
+		t.Fatalf("goDecls: %v", err)
+	}
+	want := map[string]string{
+		"pkg/calc.Add":        "func Add(a, b int) int { return a + b }",
+		"pkg/calc.Calc.Reset": "func (c *Calc[T]) Reset() {}",
+		"pkg/calc.Calc":       "Calc[T any] struct{ n T }",
+		"pkg/calc.x":          "x, _ = 1, 2",
+		"pkg/calc.A":          "A = 1",
+		"pkg/calc.B":          "B = 2",
+	}
+	if !reflect.DeepEqual(decls, want) {
+		t.Errorf("goDecls = %#v, want %#v", decls, want)
+	}
+
+	if _, err := goDecls("bad.go", "package"); err == nil {
+		t.Error("goDecls(unparseable) succeeded")
+	}
+}
+
+func TestSemanticOverlaps(t *testing.T) {
+	e, first, second, _, run := setupPipelineRig(t)
+	rigPath := e.rig.Path
+	write := func(src string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(rigPath, "calc"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(rigPath, "calc", "calc.go"), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		run("add", "calc")
+		run("commit", "-m", "calc")
+	}
+	base := "package calc\n\n// Add adds.\nfunc Add(a, b int) int {\n\treturn a + b\n}\n\nfunc Sub(a, b int) int {\n\treturn a - b\n}\n"
+	write(base)
+	run("push", "origin", "main")
+	// Both branches change Add, and nux's changes Sub too; neither touches
+	// the same lines, so they merge cleanly.
+	run("checkout", first.Branch)
+	run("merge", "-q", "main")
+	write("package calc\n\n// Add adds two ints.\nfunc Add(a, b int) int {\n\treturn b + a\n}\n\nfunc Sub(a, b int) int {\n\treturn -(b - a)\n}\n")
+	run("checkout", second.Branch)
+	run("merge", "-q", "main")
+	write("package calc\n\n// Add adds.\nfunc Add(a, b int) int {\n\treturn a + b + 0\n}\n\nfunc Sub(a, b int) int {\n\treturn a - b\n}\n\nfunc Mul(a, b int) int { return a * b }\n")
+	run("checkout", "main")
+
+	e.settings.SemanticConflicts = config.SemanticConflictsSerialize
+	scan, err := e.ScanSwarmConflicts(true)
+	if err != nil {
+		t.Fatalf("ScanSwarmConflicts: %v", err)
+	}
+	if len(scan.Overlaps) != 1 {
+		t.Fatalf("overlaps = %+v, want one", scan.Overlaps)
+	}
+	o := scan.Overlaps[0]
+	if o.MRs != [2]string{first.ID, second.ID} || !reflect.DeepEqual(o.Symbols, []string{"calc.Add"}) {
+		t.Errorf("overlap = %+v, want %s then %s on calc.Add", o, first.ID, second.ID)
+	}
+	if got := scan.OverlapsWith(second.Branch); len(got) != 1 {
+		t.Errorf("OverlapsWith(second) = %+v, want the overlap", got)
+	}
+
+	// The later MR waits while the earlier one is still to merge.
+	ready, err := e.readyMRs()
+	if err != nil {
+		t.Fatalf("readyMRs: %v", err)
+	}
+	if len(ready) != 1 || ready[0].ID != first.ID {
+		t.Errorf("ready = %v, want only %s", mrIDs(ready), first.ID)
+	}
+	if err := e.mrQueue.Remove(first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if ready, _ = e.readyMRs(); len(ready) != 1 || ready[0].ID != second.ID {
+		t.Errorf("ready after merge = %v, want %s", mrIDs(ready), second.ID)
+	}
+
+	// With warn, overlaps are only reported.
+	if err := e.mrQueue.Submit(first); err != nil {
+		t.Fatal(err)
+	}
+	e.settings.SemanticConflicts = config.SemanticConflictsWarn
+	if ready, _ = e.readyMRs(); len(ready) != 2 {
+		t.Errorf("ready with warn = %v, want both", mrIDs(ready))
+	}
+}
+
+func mrIDs(mrs []*mrqueue.MR) []string {
+	ids := make([]string, len(mrs))
+	for i, mr := range mrs {
+		ids[i] = mr.ID
+	}
+	return ids
+}
@@ -0,0 +1,493 @@
+package refinery
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/clock"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+// DefaultHistoryLimit is the number of events returned by /api/history
+// when the request does not specify a limit.
+const DefaultHistoryLimit = 100
+
+// Server exposes a rig's refinery over HTTP as a JSON API.
+//
+// Read endpoints:
+//
+//	GET  /api/status              refinery state (same as gt refinery status --json)
+//	GET  /api/queue               pending MRs, highest score first
+//	GET  /api/queue/{id}          a single MR
+//	GET  /api/history?limit=N     recent merge queue events
+//	GET  /api/stats?days=N        merge totals, per-day counts, workers, swarms
+//	GET  /api/access              what the request's token may do
+//	GET  /api/openapi.json        OpenAPI document (generated from apiRoutes)
+//
+// Streaming endpoints (newline-delimited JSON, see refinery.proto):
+//
+//	GET  /api/queue/watch         queue changes (added, updated, removed)
+//	GET  /api/merges/watch        merge lifecycle events as they happen
+//	GET  /events                  every refinery event as server-sent events
+//
+// Mutation endpoints:
+//
+//	POST /api/queue               enqueue an MR
+//	POST /api/queue/{id}/hold     hold an MR ({"reason": "..."})
+//	POST /api/queue/{id}/requeue  clear hold/claim/block so the MR is retried
+//	POST /api/pause               pause processing ({"until": "..."} to resume then)
+//	POST /api/resume              resume processing
+//
+// Admin endpoints:
+//
+//	POST   /api/queue/{id}/approve  approve an MR past the refinery's gates ({"by": "..."})
+//	DELETE /api/queue/{id}          drop an MR from the queue ({"reason": "..."})
+//
+// Everything else under / is the embedded web dashboard.
+//
+// Requests authenticate with "Authorization: Bearer <token>" (or ?token= for
+// EventSource clients). Mutations always require an operate-scoped token,
+// and admin endpoints an admin token. Reads are open until the first token
+// is issued, then need a read token.
+type Server struct {
+	rig    *rig.Rig
+	mgr    *Manager
+	queue  *mrqueue.Queue
+	events *mrqueue.EventLogger
+	tokens *TokenStore
+	mux    *http.ServeMux
+	clk    clock.Clock // nil means the system clock
+}
+
+// NewServer creates an API server for the given rig.
+func NewServer(r *rig.Rig) *Server {
+	s := &Server{
+		rig:    r,
+		mgr:    NewManager(r),
+		queue:  mrqueue.New(r.Path),
+		events: mrqueue.NewEventLoggerFromRig(r.Path),
+		tokens: NewTokenStore(r.Path),
+		mux:    http.NewServeMux(),
+	}
+
+	for _, rt := range apiRoutes() {
+		handle, scope := rt.handle, rt.Scope
+		s.mux.HandleFunc(rt.Method+" "+rt.Path, func(w http.ResponseWriter, r *http.Request) {
+			if scope != "" && !s.authorize(w, r, scope) {
+				return
+			}
+			handle(s, w, r)
+		})
+	}
+	s.mux.Handle("GET /", dashboardHandler())
+
+	return s
+}
+
+// SetClock makes the server, and the manager, queue, and event log behind
+// it, tell the time by c: MR ages, and which day stats roll over on.
+func (s *Server) SetClock(c clock.Clock) {
+	s.clk = c
+	s.mgr.SetClock(c)
+	s.queue.SetClock(c)
+	s.events.SetClock(c)
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if want, ok := requiredScope(r); ok && !s.authorize(w, r, want) {
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// requiredScope returns the token scope a request needs.
+// Returns false for the public dashboard assets and API document.
+func requiredScope(r *http.Request) (TokenScope, bool) {
+	if r.URL.Path == openAPIPath && r.Method == http.MethodGet {
+		return "", false
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return ScopeOperate, true
+	}
+	if strings.HasPrefix(r.URL.Path, "/api/") || r.URL.Path == "/events" {
+		return ScopeRead, true
+	}
+	return "", false
+}
+
+// authorize checks the request's token against want, writing 401/403 on failure.
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request, want TokenScope) bool {
+	status, err := s.checkToken(r, want)
+	if err == nil {
+		return true
+	}
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="refinery"`)
+	}
+	writeError(w, status, err)
+	return false
+}
+
+// checkToken reports whether the request's token grants want, returning
+// the HTTP status to reject it with when it does not.
+func (s *Server) checkToken(r *http.Request, want TokenScope) (int, error) {
+	secret := requestToken(r)
+	if want == ScopeRead && secret == "" && s.tokens.Empty() {
+		return http.StatusOK, nil // No tokens issued yet: reads stay open
+	}
+
+	token, ok := s.tokens.Authenticate(secret)
+	if !ok {
+		return http.StatusUnauthorized, errors.New("missing or invalid API token")
+	}
+	if !token.Scope.Allows(want) {
+		return http.StatusForbidden, fmt.Errorf("token %q has %s scope; %s required", token.Name, token.Scope, want)
+	}
+	return http.StatusOK, nil
+}
+
+// requestToken extracts the API token from the Authorization header, falling
+// back to the token query parameter (EventSource cannot set headers).
+func requestToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		if token, ok := strings.CutPrefix(auth, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
+	return r.URL.Query().Get("token")
+}
+
+// Access is what a caller may do, from GET /api/access. Read-only callers
+// (read tokens, or anyone while reads are open) are observers: clients
+// hide the controls they cannot use.
+type Access struct {
+	Token    string     `json:"token,omitempty"` // Name of the request's token; empty while reads are open
+	Scope    TokenScope `json:"scope"`
+	ReadOnly bool       `json:"read_only"`
+}
+
+// EnqueueRequest is the body accepted by POST /api/queue.
+type EnqueueRequest struct {
+	Branch      string   `json:"branch"`
+	Target      string   `json:"target,omitempty"`  // Default: rig default branch
+	Targets     []string `json:"targets,omitempty"` // Further targets, merged in order after Target
+	SourceIssue string   `json:"source_issue,omitempty"`
+	Worker      string   `json:"worker,omitempty"`
+	Title       string   `json:"title,omitempty"`
+	Priority    int      `json:"priority,omitempty"`
+}
+
+// HoldRequest is the body accepted by POST /api/queue/{id}/hold.
+type HoldRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// PauseRequest is the body accepted by POST /api/pause.
+type PauseRequest struct {
+	Until time.Time `json:"until,omitempty"` // Resume on its own then; zero pauses until resumed
+}
+
+// DropRequest is the body accepted by DELETE /api/queue/{id}.
+type DropRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// ApproveRequest is the body accepted by POST /api/queue/{id}/approve.
+type ApproveRequest struct {
+	By string `json:"by,omitempty"` // Who approved; default "operator"
+}
+
+// apiError is the JSON body returned for failed requests.
+type apiError struct {
+	Error string `json:"error"`
+}
+
+func (s *Server) handleAccess(w http.ResponseWriter, r *http.Request) {
+	access := Access{Scope: ScopeRead}
+	if token, ok := s.tokens.Authenticate(requestToken(r)); ok {
+		access.Token, access.Scope = token.Name, token.Scope
+	}
+	access.ReadOnly = !access.Scope.Allows(ScopeOperate)
+	writeJSON(w, http.StatusOK, access)
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, _ *http.Request) {
+	ref, err := s.mgr.Status()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ref)
+}
+
+func (s *Server) handleQueue(w http.ResponseWriter, _ *http.Request) {
+	mrs, err := s.queue.ListByScore()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if mrs == nil {
+		mrs = []*mrqueue.MR{}
+	}
+	writeJSON(w, http.StatusOK, mrs)
+}
+
+func (s *Server) handleGetMR(w http.ResponseWriter, r *http.Request) {
+	mr, ok := s.loadMR(w, r.PathValue("id"))
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, mr)
+}
+
+func (s *Server) handleEnqueue(w http.ResponseWriter, r *http.Request) {
+	var req EnqueueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid JSON body"))
+		return
+	}
+	if req.Branch == "" {
+		writeError(w, http.StatusBadRequest, errors.New("branch is required"))
+		return
+	}
+	if req.Target == "" {
+		req.Target = s.rig.DefaultBranch()
+	}
+	if err := CheckTargets(req.Target, req.Targets); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := rig.CheckNotPaused(s.rig.Path); err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	for _, target := range append([]string{req.Target}, req.Targets...) {
+		if err := s.mgr.CheckScope(req.Branch, target); errors.Is(err, ErrOutOfScope) {
+			writeError(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+	}
+
+	mr := &mrqueue.MR{
+		Branch:      req.Branch,
+		Target:      req.Target,
+		Targets:     req.Targets,
+		SourceIssue: req.SourceIssue,
+		Worker:      req.Worker,
+		Rig:         s.rig.Name,
+		Title:       req.Title,
+		Priority:    req.Priority,
+	}
+	s.mgr.Route(mr)
+	mr.SwarmID = s.mgr.SwarmFor(mr.Branch, mr.Target, mr.SourceIssue)
+	if err := s.mgr.Admit(r.Context(), mr); err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, ErrNotAdmitted) {
+			status = http.StatusForbidden
+		}
+		writeError(w, status, err)
+		return
+	}
+	if err := s.queue.Submit(mr); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, mr)
+}
+
+func (s *Server) handleHold(w http.ResponseWriter, r *http.Request) {
+	var req HoldRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("invalid JSON body"))
+			return
+		}
+	}
+
+	id := r.PathValue("id")
+	if err := s.queue.Hold(id, req.Reason); err != nil {
+		writeQueueError(w, err)
+		return
+	}
+	mr, ok := s.loadMR(w, id)
+	if !ok {
+		return
+	}
+	_ = s.events.LogHeld(mr, mr.HeldReason) // Non-fatal: history is best-effort
+	writeJSON(w, http.StatusOK, mr)
+}
+
+func (s *Server) handleRequeue(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := s.queue.Requeue(id); err != nil {
+		writeQueueError(w, err)
+		return
+	}
+	mr, ok := s.loadMR(w, id)
+	if !ok {
+		return
+	}
+	_ = s.events.LogRequeued(mr) // Non-fatal: history is best-effort
+	writeJSON(w, http.StatusOK, mr)
+}
+
+func (s *Server) handleApprove(w http.ResponseWriter, r *http.Request) {
+	var req ApproveRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("invalid JSON body"))
+			return
+		}
+	}
+
+	id := r.PathValue("id")
+	if err := s.queue.Approve(id, req.By); err != nil {
+		writeQueueError(w, err)
+		return
+	}
+	mr, ok := s.loadMR(w, id)
+	if !ok {
+		return
+	}
+	_ = s.events.LogApproved(mr) // Non-fatal: history is best-effort
+	writeJSON(w, http.StatusOK, mr)
+}
+
+// handleDrop removes an MR from the queue without merging it. The branch
+// is left alone, so the work can be resubmitted.
+func (s *Server) handleDrop(w http.ResponseWriter, r *http.Request) {
+	var req DropRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("invalid JSON body"))
+			return
+		}
+	}
+
+	mr, ok := s.loadMR(w, r.PathValue("id"))
+	if !ok {
+		return
+	}
+	if err := s.queue.Remove(mr.ID); err != nil {
+		writeQueueError(w, err)
+		return
+	}
+	reason := "dropped by operator"
+	if req.Reason != "" {
+		reason += ": " + req.Reason
+	}
+	_ = s.events.LogMergeSkipped(mr, reason) // Non-fatal: history is best-effort
+	writeJSON(w, http.StatusOK, mr)
+}
+
+func (s *Server) handlePause(w http.ResponseWriter, r *http.Request) {
+	var req PauseRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("invalid JSON body"))
+			return
+		}
+	}
+	if !req.Until.IsZero() && !req.Until.After(clock.Or(s.clk).Now()) {
+		writeError(w, http.StatusBadRequest, errors.New("until has already passed"))
+		return
+	}
+	s.changeState(w, func() error { return s.mgr.PauseUntil(req.Until) })
+}
+
+func (s *Server) handleResume(w http.ResponseWriter, r *http.Request) {
+	s.changeState(w, s.mgr.Resume)
+}
+
+// changeState applies a pause/resume transition and returns the new status.
+func (s *Server) changeState(w http.ResponseWriter, transition func() error) {
+	if err := transition(); err != nil {
+		if errors.Is(err, ErrNotRunning) || errors.Is(err, ErrNotPaused) {
+			writeError(w, http.StatusConflict, err)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.handleStatus(w, nil)
+}
+
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	limit := DefaultHistoryLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, errors.New("limit must be a non-negative integer"))
+			return
+		}
+		limit = n
+	}
+
+	events, err := s.events.ReadEvents(limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if events == nil {
+		events = []mrqueue.Event{}
+	}
+	writeJSON(w, http.StatusOK, events)
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	days := DefaultStatsDays
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, errors.New("days must be a positive integer"))
+			return
+		}
+		days = n
+	}
+
+	stats, err := CachedStats(s.rig.Path, s.events, clock.Or(s.clk).Now(), days)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// loadMR fetches an MR by ID, writing a 404 if it does not exist.
+func (s *Server) loadMR(w http.ResponseWriter, id string) (*mrqueue.MR, bool) {
+	mr, err := s.queue.Get(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, mrqueue.ErrNotFound)
+		return nil, false
+	}
+	return mr, true
+}
+
+// writeQueueError maps mrqueue errors onto HTTP status codes.
+func writeQueueError(w http.ResponseWriter, err error) {
+	if errors.Is(err, mrqueue.ErrNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, apiError{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
@@ -0,0 +1,189 @@
+package refinery
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	_, rigPath := setupTestManager(t)
+	return NewServer(&rig.Rig{Name: "testrig", Path: rigPath})
+}
+
+// newToken issues an API token for tests.
+func newToken(t *testing.T, srv *Server, name string, scope TokenScope) string {
+	t.Helper()
+	secret, _, err := srv.tokens.Create(name, scope)
+	if err != nil {
+		t.Fatalf("creating token: %v", err)
+	}
+	return secret
+}
+
+func doRequest(t *testing.T, h http.Handler, token, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestServer_EnqueueHoldRequeue(t *testing.T) {
+	srv := newTestServer(t)
+	tok := newToken(t, srv, "ops", ScopeOperate)
+
+	w := doRequest(t, srv, tok, "POST", "/api/queue", `{"branch":"polecat/nux/gt-abc","worker":"nux"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("enqueue status = %d, body = %s", w.Code, w.Body.String())
+	}
+	var mr mrqueue.MR
+	if err := json.Unmarshal(w.Body.Bytes(), &mr); err != nil {
+		t.Fatalf("decoding MR: %v", err)
+	}
+	if mr.ID == "" || mr.Target != "main" || mr.Rig != "testrig" {
+		t.Errorf("unexpected enqueued MR: %+v", mr)
+	}
+
+	w = doRequest(t, srv, tok, "POST", "/api/queue/"+mr.ID+"/hold", `{"reason":"design review"}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "design review") {
+		t.Fatalf("hold status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	w = doRequest(t, srv, tok, "POST", "/api/queue/"+mr.ID+"/requeue", "")
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "held_reason") {
+		t.Fatalf("requeue status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	w = doRequest(t, srv, newToken(t, srv, "mayor", ScopeAdmin), "POST", "/api/queue/"+mr.ID+"/approve", `{"by":"mayor"}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"approved_by": "mayor"`) {
+		t.Fatalf("approve status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	w = doRequest(t, srv, tok, "GET", "/api/history", "")
+	var events []mrqueue.Event
+	if err := json.Unmarshal(w.Body.Bytes(), &events); err != nil {
+		t.Fatalf("decoding history: %v", err)
+	}
+	if len(events) != 3 || events[0].Type != mrqueue.EventHeld || events[1].Type != mrqueue.EventRequeued || events[2].Type != mrqueue.EventApproved {
+		t.Errorf("history = %+v, want held, requeued, then approved", events)
+	}
+}
+
+func TestServer_Drop(t *testing.T) {
+	srv := newTestServer(t)
+	ops := newToken(t, srv, "ops", ScopeOperate)
+	admin := newToken(t, srv, "mayor", ScopeAdmin)
+
+	w := doRequest(t, srv, ops, "POST", "/api/queue", `{"branch":"polecat/nux/gt-abc","worker":"nux"}`)
+	var mr mrqueue.MR
+	if err := json.Unmarshal(w.Body.Bytes(), &mr); err != nil {
+		t.Fatalf("decoding MR: %v", err)
+	}
+
+	w = doRequest(t, srv, admin, "DELETE", "/api/queue/"+mr.ID, `{"reason":"superseded"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("drop status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if _, err := srv.queue.Get(mr.ID); err == nil {
+		t.Error("dropped MR is still queued")
+	}
+
+	events, err := srv.events.ReadEvents(0)
+	if err != nil {
+		t.Fatalf("ReadEvents: %v", err)
+	}
+	if len(events) != 1 || events[0].Type != mrqueue.EventMergeSkipped || events[0].Reason != "dropped by operator: superseded" {
+		t.Errorf("events = %+v, want one merge_skipped with the drop reason", events)
+	}
+}
+
+func TestServer_Errors(t *testing.T) {
+	srv := newTestServer(t)
+	tok := newToken(t, srv, "ops", ScopeOperate)
+
+	tests := []struct {
+		method, path, body string
+		want               int
+	}{
+		{"POST", "/api/queue", `{}`, http.StatusBadRequest},
+		{"POST", "/api/queue", `not json`, http.StatusBadRequest},
+		{"GET", "/api/queue/mr-missing", "", http.StatusNotFound},
+		{"POST", "/api/queue/mr-missing/hold", "", http.StatusNotFound},
+		{"POST", "/api/pause", "", http.StatusConflict},
+		{"POST", "/api/resume", "", http.StatusConflict},
+		{"GET", "/api/history?limit=-1", "", http.StatusBadRequest},
+		{"DELETE", "/api/status", "", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		w := doRequest(t, srv, tok, tt.method, tt.path, tt.body)
+		if w.Code != tt.want {
+			t.Errorf("%s %s = %d, want %d (body %s)", tt.method, tt.path, w.Code, tt.want, w.Body.String())
+		}
+	}
+}
+
+func TestServer_PauseResume(t *testing.T) {
+	srv := newTestServer(t)
+	tok := newToken(t, srv, "ops", ScopeOperate)
+	if err := srv.mgr.saveState(&Refinery{RigName: "testrig", State: StateRunning}); err != nil {
+		t.Fatalf("saveState: %v", err)
+	}
+
+	w := doRequest(t, srv, tok, "POST", "/api/pause", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"paused"`) {
+		t.Fatalf("pause = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	w = doRequest(t, srv, tok, "POST", "/api/resume", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"running"`) {
+		t.Fatalf("resume = %d, body = %s", w.Code, w.Body.String())
+	}
+}
+
+func TestServer_Dashboard(t *testing.T) {
+	srv := newTestServer(t)
+	tok := newToken(t, srv, "ops", ScopeOperate)
+
+	w := doRequest(t, srv, tok, "GET", "/", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "app.js") {
+		t.Fatalf("GET / = %d, body = %.200s", w.Code, w.Body.String())
+	}
+	for _, asset := range []string{"/app.js", "/style.css"} {
+		if w := doRequest(t, srv, tok, "GET", asset, ""); w.Code != http.StatusOK {
+			t.Errorf("GET %s = %d", asset, w.Code)
+		}
+	}
+
+	w = doRequest(t, srv, tok, "GET", "/api/stats?days=3", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"daily"`) {
+		t.Errorf("GET /api/stats = %d, body = %s", w.Code, w.Body.String())
+	}
+}
+
+func TestServer_RigPaused(t *testing.T) {
+	srv := newTestServer(t)
+	tok := newToken(t, srv, "ops", ScopeOperate)
+	if err := rig.Pause(srv.rig.Path, "release freeze", "mayor/"); err != nil {
+		t.Fatal(err)
+	}
+
+	w := doRequest(t, srv, tok, "POST", "/api/queue", `{"branch":"polecat/nux/gt-abc"}`)
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "release freeze") {
+		t.Errorf("enqueue while paused = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	w = doRequest(t, srv, tok, "GET", "/api/status", "")
+	if !strings.Contains(w.Body.String(), `"rig_paused"`) {
+		t.Errorf("status does not report the pause: %s", w.Body.String())
+	}
+}
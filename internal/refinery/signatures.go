@@ -0,0 +1,50 @@
+package refinery
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// ErrBadSignature means a branch carries a commit that is unsigned or not
+// signed by an allowed key.
+var ErrBadSignature = errors.New("commit signature not accepted")
+
+// signatureProblems names why git's %G? codes other than G and U fail.
+var signatureProblems = map[string]string{
+	"N": "is unsigned",
+	"B": "has a bad signature",
+	"X": "has an expired signature",
+	"Y": "is signed by an expired key",
+	"R": "is signed by a revoked key",
+	"E": "has a signature that cannot be checked",
+	"U": "is signed by a key that is not allowed",
+}
+
+// checkSignatures verifies that every commit branch adds over target is
+// signed by a key rig.toml's [refinery.signatures] allows. Returns nil when
+// signature verification is off.
+func checkSignatures(g GitRunner, s *config.RefinerySettings, rigPath, branch, target string) error {
+	if s == nil || s.Signatures == nil {
+		return nil
+	}
+	sigs, err := g.CommitSignatures(target, branch, s.Signatures.SignersFile(rigPath))
+	if err != nil {
+		return fmt.Errorf("checking signatures on %s: %w", branch, err)
+	}
+	for _, sig := range sigs {
+		if s.Signatures.Accepts(sig.Status, sig.Fingerprint) {
+			continue
+		}
+		problem, ok := signatureProblems[sig.Status]
+		if !ok {
+			problem = "has signature status " + sig.Status
+		}
+		if sig.Fingerprint != "" {
+			problem += " (" + sig.Fingerprint + ")"
+		}
+		return fmt.Errorf("%w: %s commit %.8s %s", ErrBadSignature, branch, sig.SHA, problem)
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package refinery
+
+import (
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+func TestCheckSignatures_Disabled(t *testing.T) {
+	// Off unless [refinery.signatures] is set: no git calls, so a nil repo
+	// is fine.
+	if err := checkSignatures(nil, &config.RefinerySettings{}, "/rig", "polecat/nux", "main"); err != nil {
+		t.Errorf("checkSignatures without [refinery.signatures] = %v, want nil", err)
+	}
+	if err := checkSignatures(nil, nil, "/rig", "polecat/nux", "main"); err != nil {
+		t.Errorf("checkSignatures with no rig.toml = %v, want nil", err)
+	}
+}
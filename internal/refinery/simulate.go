@@ -0,0 +1,492 @@
+package refinery
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/steveyegge/gastown/internal/clock"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/git/gitfake"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+// A simulation runs the scheduler against a scenario in virtual time, so a
+// change to [refinery] ordering or scheduling settings (risk order, lanes,
+// budgets, windows, per-worker limits) can be tried on a recorded or
+// made-up load before a live rig runs it. Each MR's checks take the time
+// and end the way the scenario says; only when they start is up to the
+// scheduler. Pipelining is not modelled.
+
+// Outcomes of a scenario MR, besides a failure type from the event log
+// (tests, build, conflict, ...).
+const (
+	SimMerged     = "merged"
+	SimUnfinished = "unfinished" // Still queued when the simulation ended
+)
+
+// Scenario is a load to simulate, read from TOML:
+//
+//	start = 2026-01-05T09:00:00Z
+//
+//	[[mrs]]
+//	id = "gt-1"
+//	worker = "nux"
+//	at = "0s"
+//	duration = "6m"
+//	lines = 120
+//	files = ["internal/auth/token.go"]
+//
+//	[[mrs]]
+//	id = "gt-2"
+//	worker = "slit"
+//	at = "2m"
+//	duration = "4m"
+//	outcome = "tests"
+type Scenario struct {
+	// Start is when the scenario begins; now if unset.
+	Start time.Time `toml:"start" json:"start"`
+
+	// Step is how far time moves while nothing is running but MRs wait,
+	// for a merge window or budget say (default 5m). Horizon is how long
+	// the simulation waits like that before giving up on them (default
+	// 48h).
+	Step    string `toml:"step,omitempty" json:"step,omitempty"`
+	Horizon string `toml:"horizon,omitempty" json:"horizon,omitempty"`
+
+	MRs []ScenarioMR `toml:"mrs" json:"mrs"`
+}
+
+// ScenarioMR is one MR in a scenario.
+type ScenarioMR struct {
+	ID       string   `toml:"id" json:"id"`
+	Worker   string   `toml:"worker" json:"worker"`
+	Branch   string   `toml:"branch,omitempty" json:"branch,omitempty"` // Default polecat/<worker>/<id>
+	Target   string   `toml:"target,omitempty" json:"target,omitempty"` // Default main
+	Priority int      `toml:"priority,omitempty" json:"priority,omitempty"`
+	Swarm    string   `toml:"swarm,omitempty" json:"swarm,omitempty"`
+	At       string   `toml:"at" json:"at"`             // Queued this long after Start
+	Duration string   `toml:"duration" json:"duration"` // How long its merge takes
+	Outcome  string   `toml:"outcome,omitempty" json:"outcome,omitempty"`
+	Lines    int      `toml:"lines,omitempty" json:"lines,omitempty"`
+	Files    []string `toml:"files,omitempty" json:"files,omitempty"`
+}
+
+// LoadScenario reads and checks a scenario file.
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is the operator's scenario file
+	if err != nil {
+		return nil, err
+	}
+	var sc Scenario
+	md, err := toml.Decode(string(data), &sc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return nil, fmt.Errorf("%s: %s: unknown key", path, undecoded[0])
+	}
+	if err := sc.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &sc, nil
+}
+
+func (sc *Scenario) validate() error {
+	for _, key := range []struct{ name, value string }{{"step", sc.Step}, {"horizon", sc.Horizon}} {
+		if key.value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(key.value); err != nil || d <= 0 {
+			return fmt.Errorf("%s: invalid duration %q", key.name, key.value)
+		}
+	}
+	seen := make(map[string]bool)
+	for i, m := range sc.MRs {
+		where := fmt.Sprintf("mrs[%d]", i)
+		switch {
+		case m.ID == "" || strings.ContainsAny(m.ID, `/\`):
+			return fmt.Errorf("%s.id: invalid id %q", where, m.ID)
+		case seen[m.ID]:
+			return fmt.Errorf("%s.id: duplicate id %q", where, m.ID)
+		case m.Worker == "":
+			return fmt.Errorf("%s.worker: required", where)
+		}
+		seen[m.ID] = true
+		if d, err := time.ParseDuration(m.At); err != nil || d < 0 {
+			return fmt.Errorf("%s.at: invalid offset %q", where, m.At)
+		}
+		if d, err := time.ParseDuration(m.Duration); err != nil || d <= 0 {
+			return fmt.Errorf("%s.duration: invalid duration %q", where, m.Duration)
+		}
+	}
+	return nil
+}
+
+// ReplayScenario turns an event log into a scenario: each merge attempt
+// (a merge_started event and the merged or merge_failed event after it)
+// becomes an MR queued when the attempt started, taking as long and ending
+// as it did. The log does not record when MRs were submitted, so a replay
+// measures the recorded load under other settings, not the queueing it
+// already had.
+func ReplayScenario(events []mrqueue.Event) *Scenario {
+	sc := &Scenario{}
+	var begins []time.Time // When each of sc.MRs started
+	started := make(map[string]mrqueue.Event)
+	attempts := make(map[string]int)
+	for _, ev := range events {
+		switch ev.Type {
+		case mrqueue.EventMergeStarted:
+			started[ev.MRID] = ev
+			continue
+		case mrqueue.EventMerged, mrqueue.EventMergeFailed:
+		default:
+			continue
+		}
+		begin, ok := started[ev.MRID]
+		if !ok {
+			continue
+		}
+		delete(started, ev.MRID)
+		if sc.Start.IsZero() || begin.Timestamp.Before(sc.Start) {
+			sc.Start = begin.Timestamp
+		}
+		attempts[ev.MRID]++
+		id := ev.MRID
+		if n := attempts[ev.MRID]; n > 1 {
+			id = fmt.Sprintf("%s.%d", ev.MRID, n)
+		}
+		outcome := SimMerged
+		if ev.Type == mrqueue.EventMergeFailed {
+			outcome = ev.FailureType
+			if outcome == "" {
+				outcome = "failed"
+			}
+		}
+		duration := ev.Timestamp.Sub(begin.Timestamp)
+		if duration <= 0 {
+			duration = time.Second
+		}
+		sc.MRs = append(sc.MRs, ScenarioMR{
+			ID:       id,
+			Worker:   ev.Worker,
+			Branch:   ev.Branch,
+			Target:   ev.Target,
+			Duration: duration.String(),
+			Outcome:  outcome,
+			Lines:    ev.Lines,
+		})
+		begins = append(begins, begin.Timestamp)
+	}
+	for i := range sc.MRs {
+		sc.MRs[i].At = begins[i].Sub(sc.Start).String()
+		if sc.MRs[i].Worker == "" {
+			sc.MRs[i].Worker = "unknown"
+		}
+	}
+	return sc
+}
+
+// SimMR is how one scenario MR fared.
+type SimMR struct {
+	ID       string    `json:"id"`
+	Worker   string    `json:"worker"`
+	Target   string    `json:"target"`
+	Arrived  time.Time `json:"arrived"`
+	Started  time.Time `json:"started,omitempty"`
+	Finished time.Time `json:"finished,omitempty"`
+	Outcome  string    `json:"outcome"`
+}
+
+// Wait is how long the MR was queued before its merge started, or until
+// the simulation ended if it never did.
+func (m SimMR) Wait(end time.Time) time.Duration {
+	if m.Started.IsZero() {
+		return end.Sub(m.Arrived)
+	}
+	return m.Started.Sub(m.Arrived)
+}
+
+// SimResult is the outcome of a simulation.
+type SimResult struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"` // When the last merge finished, or the simulation gave up
+	MRs   []SimMR   `json:"mrs"` // In the order their merges started; unfinished last
+
+	Merged     int           `json:"merged"`
+	Failed     int           `json:"failed"`
+	Unfinished int           `json:"unfinished"`
+	MeanWait   time.Duration `json:"mean_wait"`
+	MaxWait    time.Duration `json:"max_wait"`
+	MeanLead   time.Duration `json:"mean_lead"` // Queued to merged, over merged MRs
+}
+
+// Simulate runs sc through the scheduler under settings, with an
+// in-memory repository and a scratch queue, and reports when each MR's
+// merge started and finished.
+func Simulate(sc *Scenario, settings *config.RefinerySettings) (*SimResult, error) {
+	if err := sc.validate(); err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "gt-simulate-")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	start := sc.Start
+	if start.IsZero() {
+		start = time.Now().Truncate(time.Minute)
+	}
+	step, horizon := 5*time.Minute, 48*time.Hour
+	if sc.Step != "" {
+		step, _ = time.ParseDuration(sc.Step)
+	}
+	if sc.Horizon != "" {
+		horizon, _ = time.ParseDuration(sc.Horizon)
+	}
+
+	repo := gitfake.New()
+	repo.Commit("main", "base", map[string]string{"README": "simulated rig\n"})
+	repo.Publish("main")
+	e := NewEngineer(&rig.Rig{Name: "simulation", Path: dir})
+	e.SetOutput(io.Discard)
+	e.SetGit(repo.Git(dir), func(d string) GitRunner { return repo.Git(d) })
+	now := start
+	clk := clock.NewFake(now)
+	e.SetClock(clk)
+	if settings == nil {
+		settings = &config.RefinerySettings{}
+	}
+	e.settings = settings
+
+	type arrival struct {
+		mr ScenarioMR
+		at time.Time
+	}
+	arrivals := make([]arrival, 0, len(sc.MRs))
+	for _, m := range sc.MRs {
+		d, _ := time.ParseDuration(m.At)
+		arrivals = append(arrivals, arrival{m, start.Add(d)})
+	}
+	sort.SliceStable(arrivals, func(i, j int) bool { return arrivals[i].at.Before(arrivals[j].at) })
+
+	type flight struct {
+		scenario ScenarioMR
+		mr       *mrqueue.MR
+		finish   time.Time
+	}
+	var (
+		flights  = make(map[string]*flight) // By lane
+		byID     = make(map[string]ScenarioMR)
+		records  = make(map[string]*SimMR)
+		order    []*SimMR
+		progress = start
+	)
+	lane := func(mr *mrqueue.MR) string {
+		if e.lanesEnabled() {
+			return mr.Lane()
+		}
+		return ""
+	}
+
+	for {
+		// Merges finishing now land or fail first, freeing their lanes.
+		for key, f := range flights {
+			if f.finish.After(now) {
+				continue
+			}
+			rec := records[f.mr.ID]
+			rec.Finished = f.finish
+			rec.Outcome = f.scenario.Outcome
+			if rec.Outcome == "" {
+				rec.Outcome = SimMerged
+			}
+			ev := mrqueue.Event{Timestamp: f.finish, MRID: f.mr.ID, Branch: f.mr.Branch, Target: f.mr.Target, Worker: f.mr.Worker}
+			if rec.Outcome == SimMerged {
+				ev.Type, ev.Files, ev.Lines = mrqueue.EventMerged, len(f.scenario.Files), f.scenario.Lines
+			} else {
+				ev.Type, ev.FailureType = mrqueue.EventMergeFailed, rec.Outcome
+			}
+			if err := e.eventLogger.LogEvent(ev); err != nil {
+				return nil, err
+			}
+			if err := e.mrQueue.Remove(f.mr.ID); err != nil {
+				return nil, err
+			}
+			delete(flights, key)
+			progress = now
+		}
+
+		for len(arrivals) > 0 && !arrivals[0].at.After(now) {
+			a := arrivals[0]
+			arrivals = arrivals[1:]
+			mr, err := simSubmit(e, repo, a.mr, a.at)
+			if err != nil {
+				return nil, fmt.Errorf("queueing %s: %w", a.mr.ID, err)
+			}
+			byID[mr.ID] = a.mr
+			records[mr.ID] = &SimMR{ID: mr.ID, Worker: mr.Worker, Target: mr.Target, Arrived: a.at}
+			progress = now
+		}
+
+		e.prepareQueue()
+		ready, err := e.readyMRs()
+		if err != nil {
+			return nil, err
+		}
+		for _, mr := range ready {
+			key := lane(mr)
+			if flights[key] != nil || !records[mr.ID].Started.IsZero() {
+				continue
+			}
+			if err := e.mrQueue.Claim(mr.ID, "simulator"); err != nil {
+				return nil, err
+			}
+			if err := e.eventLogger.LogEvent(mrqueue.Event{Timestamp: now, Type: mrqueue.EventMergeStarted,
+				MRID: mr.ID, Branch: mr.Branch, Target: mr.Target, Worker: mr.Worker}); err != nil {
+				return nil, err
+			}
+			s := byID[mr.ID]
+			d, _ := time.ParseDuration(s.Duration)
+			flights[key] = &flight{scenario: s, mr: mr, finish: now.Add(d)}
+			records[mr.ID].Started = now
+			order = append(order, records[mr.ID])
+			progress = now
+		}
+
+		// Jump to whatever happens next.
+		var next time.Time
+		for _, f := range flights {
+			if next.IsZero() || f.finish.Before(next) {
+				next = f.finish
+			}
+		}
+		if len(arrivals) > 0 && (next.IsZero() || arrivals[0].at.Before(next)) {
+			next = arrivals[0].at
+		}
+		if next.IsZero() {
+			queued, err := e.mrQueue.List()
+			if err != nil {
+				return nil, err
+			}
+			if len(queued) == 0 || now.Sub(progress) >= horizon {
+				break
+			}
+			next = now.Add(step) // MRs wait on something besides a merge
+		}
+		clk.Advance(next.Sub(now))
+		now = next
+	}
+
+	res := &SimResult{Start: start, End: progress}
+	var unfinished []*SimMR
+	for _, rec := range records {
+		if rec.Started.IsZero() {
+			rec.Outcome = SimUnfinished
+			unfinished = append(unfinished, rec)
+		}
+	}
+	sort.Slice(unfinished, func(i, j int) bool { return unfinished[i].Arrived.Before(unfinished[j].Arrived) })
+	order = append(order, unfinished...)
+
+	var waits, leads time.Duration
+	for _, rec := range order {
+		res.MRs = append(res.MRs, *rec)
+		wait := rec.Wait(res.End)
+		waits += wait
+		if wait > res.MaxWait {
+			res.MaxWait = wait
+		}
+		switch rec.Outcome {
+		case SimMerged:
+			res.Merged++
+			leads += rec.Finished.Sub(rec.Arrived)
+		case SimUnfinished:
+			res.Unfinished++
+		default:
+			res.Failed++
+		}
+	}
+	if n := len(res.MRs); n > 0 {
+		res.MeanWait = waits / time.Duration(n)
+	}
+	if res.Merged > 0 {
+		res.MeanLead = leads / time.Duration(res.Merged)
+	}
+	return res, nil
+}
+
+// simSubmit creates m's branch in repo, with its lines spread over its
+// files, and queues it as of at.
+func simSubmit(e *Engineer, repo *gitfake.Repo, m ScenarioMR, at time.Time) (*mrqueue.MR, error) {
+	target := m.Target
+	if target == "" {
+		target = "main"
+	}
+	branch := m.Branch
+	if branch == "" {
+		branch = "polecat/" + m.Worker + "/" + m.ID
+	}
+	if _, err := e.git.Rev(target); err != nil {
+		if err := e.git.CreateBranchFrom(target, "main"); err != nil {
+			return nil, err
+		}
+		repo.Publish(target)
+	}
+	if exists, _ := e.git.BranchExists(branch); !exists {
+		if err := e.git.CreateBranchFrom(branch, target); err != nil {
+			return nil, err
+		}
+	}
+	files := m.Files
+	if len(files) == 0 {
+		files = []string{"sim/" + m.ID + ".txt"}
+	}
+	changes := make(map[string]string, len(files))
+	for i, f := range files {
+		n := m.Lines / len(files)
+		if i < m.Lines%len(files) {
+			n++
+		}
+		changes[f] = strings.Repeat(m.ID+"\n", max(n, 1))
+	}
+	repo.Commit(branch, m.ID, changes)
+
+	mr := &mrqueue.MR{
+		ID:        m.ID,
+		Branch:    branch,
+		Target:    target,
+		Worker:    m.Worker,
+		Priority:  m.Priority,
+		SwarmID:   m.Swarm,
+		CreatedAt: at,
+	}
+	return mr, e.mrQueue.Submit(mr)
+}
+
+// Replay returns the rig's merge history since since as a scenario; see
+// ReplayScenario.
+func (e *Engineer) Replay(since time.Time) (*Scenario, error) {
+	events, err := e.eventLogger.ReadEvents(0)
+	if err != nil {
+		return nil, err
+	}
+	recent := events[:0]
+	for _, ev := range events {
+		if !ev.Timestamp.Before(since) {
+			recent = append(recent, ev)
+		}
+	}
+	return ReplayScenario(recent), nil
+}
+
+// Simulate runs sc under the rig's own [refinery] settings.
+func (e *Engineer) Simulate(sc *Scenario) (*SimResult, error) {
+	return Simulate(sc, e.settings)
+}
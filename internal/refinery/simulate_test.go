@@ -0,0 +1,121 @@
+package refinery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+var simStart = time.Date(2026, 1, 5, 9, 0, 0, 0, time.Local) // A Monday
+
+// simOrder returns the IDs of res's MRs in the order they started.
+func simOrder(res *SimResult) []string {
+	var ids []string
+	for _, m := range res.MRs {
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
+
+func TestSimulate(t *testing.T) {
+	sc := &Scenario{Start: simStart, MRs: []ScenarioMR{
+		{ID: "big", Worker: "nux", At: "0s", Duration: "10m", Lines: 900, Files: []string{"internal/auth/token.go"}},
+		{ID: "small", Worker: "slit", At: "0s", Duration: "5m", Lines: 3},
+		{ID: "late", Worker: "furiosa", At: "1m", Duration: "5m", Outcome: "tests"},
+	}}
+
+	res, err := Simulate(sc, nil)
+	if err != nil {
+		t.Fatalf("Simulate: %v", err)
+	}
+	if res.Merged != 2 || res.Failed != 1 || res.Unfinished != 0 {
+		t.Errorf("result = %+v, want 2 merged, 1 failed", res)
+	}
+	if got := res.End.Sub(simStart); got != 20*time.Minute {
+		t.Errorf("drained after %s, want 20m one merge at a time", got)
+	}
+	for i := 1; i < len(res.MRs); i++ {
+		if res.MRs[i].Started != res.MRs[i-1].Finished {
+			t.Errorf("%s started at %s, want when %s finished", res.MRs[i].ID, res.MRs[i].Started, res.MRs[i-1].ID)
+		}
+	}
+
+	// Low risk first moves the small change ahead of the big one.
+	risky := &config.RefinerySettings{Risk: &config.RiskConfig{Order: config.RiskOrderLowFirst, Paths: []string{"internal/auth/"}}}
+	if res, err = Simulate(sc, risky); err != nil {
+		t.Fatalf("Simulate(risk): %v", err)
+	}
+	if got := simOrder(res); got[0] != "small" {
+		t.Errorf("order with risk = %v, want small first", got)
+	}
+
+	// With lanes, an integration branch merges alongside main.
+	sc.MRs[1].Target = "integration/gt-epic"
+	if res, err = Simulate(sc, &config.RefinerySettings{Lanes: true}); err != nil {
+		t.Fatalf("Simulate(lanes): %v", err)
+	}
+	if got := res.End.Sub(simStart); got != 15*time.Minute {
+		t.Errorf("drained after %s with lanes, want 15m", got)
+	}
+}
+
+func TestSimulate_Window(t *testing.T) {
+	sc := &Scenario{Start: simStart.Add(-time.Hour), MRs: []ScenarioMR{
+		{ID: "early", Worker: "nux", At: "0s", Duration: "5m"},
+	}}
+	settings := &config.RefinerySettings{Schedule: &config.ScheduleConfig{Windows: []string{"09:00-17:00"}}}
+	res, err := Simulate(sc, settings)
+	if err != nil {
+		t.Fatalf("Simulate: %v", err)
+	}
+	if m := res.MRs[0]; !m.Started.Equal(simStart) || m.Wait(res.End) != time.Hour {
+		t.Errorf("early = %+v, want it held until the window opens at 09:00", m)
+	}
+
+	sc.Horizon = "30m"
+	if res, err = Simulate(sc, settings); err != nil {
+		t.Fatalf("Simulate: %v", err)
+	}
+	if res.Unfinished != 1 || res.MRs[0].Outcome != SimUnfinished {
+		t.Errorf("result = %+v, want the MR left unfinished past the horizon", res)
+	}
+}
+
+func TestReplayScenario(t *testing.T) {
+	at := func(min int) time.Time { return simStart.Add(time.Duration(min) * time.Minute) }
+	events := []mrqueue.Event{
+		{Timestamp: at(0), Type: mrqueue.EventMergeStarted, MRID: "mr-1", Worker: "nux", Target: "main"},
+		{Timestamp: at(2), Type: mrqueue.EventMergeStarted, MRID: "mr-2", Worker: "slit", Target: "main"},
+		{Timestamp: at(4), Type: mrqueue.EventMergeFailed, MRID: "mr-1", Worker: "nux", Target: "main", FailureType: "tests"},
+		{Timestamp: at(9), Type: mrqueue.EventMerged, MRID: "mr-2", Worker: "slit", Target: "main", Lines: 40},
+		{Timestamp: at(10), Type: mrqueue.EventMergeStarted, MRID: "mr-1", Worker: "nux", Target: "main"},
+		{Timestamp: at(13), Type: mrqueue.EventMerged, MRID: "mr-1", Worker: "nux", Target: "main"},
+		{Timestamp: at(14), Type: mrqueue.EventMergeStarted, MRID: "mr-3", Worker: "nux", Target: "main"}, // Unfinished
+	}
+	sc := ReplayScenario(events)
+	want := []ScenarioMR{
+		{ID: "mr-1", Worker: "nux", Target: "main", At: "0s", Duration: "4m0s", Outcome: "tests"},
+		{ID: "mr-2", Worker: "slit", Target: "main", At: "2m0s", Duration: "7m0s", Outcome: SimMerged, Lines: 40},
+		{ID: "mr-1.2", Worker: "nux", Target: "main", At: "10m0s", Duration: "3m0s", Outcome: SimMerged},
+	}
+	if !sc.Start.Equal(simStart) || len(sc.MRs) != len(want) {
+		t.Fatalf("scenario = %+v", sc)
+	}
+	for i := range want {
+		if got := sc.MRs[i]; got.ID != want[i].ID || got.At != want[i].At || got.Duration != want[i].Duration ||
+			got.Outcome != want[i].Outcome || got.Lines != want[i].Lines {
+			t.Errorf("mrs[%d] = %+v, want %+v", i, got, want[i])
+		}
+	}
+
+	// Replayed one at a time, mr-2 now waits for mr-1's first attempt.
+	res, err := Simulate(sc, nil)
+	if err != nil {
+		t.Fatalf("Simulate: %v", err)
+	}
+	if res.MaxWait != 2*time.Minute {
+		t.Errorf("max wait = %s, want 2m", res.MaxWait)
+	}
+}
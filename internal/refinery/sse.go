@@ -0,0 +1,96 @@
+package refinery
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// SSEKeepAlive is how often an idle /events stream sends a comment line so
+// proxies and load balancers don't close the connection.
+var SSEKeepAlive = 15 * time.Second
+
+// SSE event names emitted on /events in addition to the merge queue event
+// types (merge_started, merged, merge_failed, merge_skipped, held, requeued).
+const (
+	SSEEventStatus = "status" // data: Refinery, sent on connect and on state change
+	SSEEventQueue  = "queue"  // data: QueueChange
+)
+
+// sseEvent is one server-sent event. An empty Name with a Comment is sent
+// as a keep-alive comment line.
+type sseEvent struct {
+	Name    string
+	Data    interface{}
+	Comment string
+}
+
+// writeSSE encodes an sseEvent in text/event-stream format.
+func writeSSE(w io.Writer, rec interface{}) error {
+	ev, ok := rec.(sseEvent)
+	if !ok {
+		return fmt.Errorf("unexpected SSE record %T", rec)
+	}
+	if ev.Name == "" {
+		_, err := fmt.Fprintf(w, ": %s\n\n", ev.Comment)
+		return err
+	}
+	data, err := json.Marshal(ev.Data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Name, data)
+	return err
+}
+
+// handleEvents pushes every refinery event to the client as server-sent
+// events: the current status on connect, then status transitions, queue
+// changes, and merge lifecycle events as they happen. Browsers can consume
+// it with EventSource; bots can read the stream with any HTTP client.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	var (
+		lastState    State
+		prevQueue    []*mrqueue.MR
+		offset       = s.events.Size()
+		lastActivity = time.Now()
+	)
+
+	// Seed the queue snapshot so the stream reports changes, not the backlog;
+	// clients fetch the current queue from /api/queue.
+	prevQueue, _ = s.queue.ListByScore()
+
+	s.stream(w, r, "text/event-stream", writeSSE, func() ([]interface{}, error) {
+		var out []interface{}
+
+		if ref, err := s.mgr.Status(); err == nil && ref.State != lastState {
+			lastState = ref.State
+			out = append(out, sseEvent{Name: SSEEventStatus, Data: ref})
+		}
+
+		if next, err := s.queue.ListByScore(); err == nil {
+			for _, c := range diffQueue(prevQueue, next) {
+				out = append(out, sseEvent{Name: SSEEventQueue, Data: c})
+			}
+			prevQueue = next
+		}
+
+		if events, next, err := s.events.ReadEventsFrom(offset); err == nil {
+			offset = next
+			for _, e := range events {
+				out = append(out, sseEvent{Name: string(e.Type), Data: e})
+			}
+		}
+
+		if len(out) > 0 {
+			lastActivity = time.Now()
+		} else if time.Since(lastActivity) >= SSEKeepAlive {
+			lastActivity = time.Now()
+			out = append(out, sseEvent{Comment: "keep-alive"})
+		}
+		return out, nil
+	})
+}
@@ -0,0 +1,194 @@
+package refinery
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// A polecat may build a branch on another queued branch instead of on the
+// target, to keep working while the first waits to merge. The later branch
+// then carries the earlier one's commits, so with refinery.stacks it is
+// held until the earlier MR leaves the queue, and rebased as that branch
+// changes: onto the target once the earlier MR lands (or is dropped), or
+// onto the earlier branch's new head if it is rewritten, say to fix a
+// failure. Chains stack one MR on the next.
+
+// stacksEnabled reports whether refinery.stacks is on.
+func (e *Engineer) stacksEnabled() bool {
+	return e.settings != nil && e.settings.Stacks
+}
+
+// stackDir is where stacked branches are rebased.
+func (e *Engineer) stackDir() string {
+	return filepath.Join(e.rig.Path, ".runtime", "stack")
+}
+
+// restack records which queued MRs are stacked on another, and moves
+// stacked branches whose base has changed. MRs being merged, and held MRs,
+// are left alone.
+func (e *Engineer) restack() {
+	if !e.stacksEnabled() {
+		return
+	}
+	queued, err := e.mrQueue.List()
+	if err != nil {
+		return
+	}
+	heads := make(map[string]string) // MR ID to its branch head
+	byID := make(map[string]*mrqueue.MR)
+	for _, mr := range queued {
+		if sha, err := e.git.Rev(mr.Branch); err == nil {
+			heads[mr.ID] = sha
+		}
+		byID[mr.ID] = mr
+	}
+
+	for _, mr := range queued {
+		if mr.IsClaimed(e.clock()) || mr.IsHeld() || heads[mr.ID] == "" {
+			continue
+		}
+		if mr.StackedOn == "" {
+			base := e.stackBase(mr, queued, heads)
+			if base == nil {
+				continue
+			}
+			if err := e.mrQueue.SetStack(mr.ID, base.ID, heads[base.ID]); err != nil {
+				_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to record %s stacked on %s: %v\n", mr.ID, base.ID, err)
+				continue
+			}
+			_, _ = fmt.Fprintf(e.output, "[Engineer] %s is stacked on %s; it merges after it\n", mr.Branch, base.Branch)
+			continue
+		}
+
+		base := byID[mr.StackedOn]
+		switch {
+		case !e.carries(heads[mr.ID], mr.StackBase):
+			// The worker rebased the branch off the stack themselves.
+			if err := e.mrQueue.SetStack(mr.ID, "", ""); err != nil {
+				_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to unstack %s: %v\n", mr.ID, err)
+			}
+		case base == nil:
+			// Landed or dropped: keep only the MR's own commits.
+			e.moveStack(mr, "origin/"+mr.Target, mr.Target, "", "")
+		case heads[base.ID] != "" && heads[base.ID] != mr.StackBase:
+			e.moveStack(mr, heads[base.ID], base.Branch, base.ID, heads[base.ID])
+		}
+	}
+}
+
+// markStacked sets StackedOn, in memory only, on the MRs in mrs that
+// restack would find stacked on another in queued but has not recorded
+// yet, so a listing holds them back as it will.
+func (e *Engineer) markStacked(mrs, queued []*mrqueue.MR) {
+	if !e.stacksEnabled() {
+		return
+	}
+	heads := make(map[string]string)
+	for _, mr := range queued {
+		if sha, err := e.git.Rev(mr.Branch); err == nil {
+			heads[mr.ID] = sha
+		}
+	}
+	for _, mr := range mrs {
+		if mr.StackedOn != "" || heads[mr.ID] == "" {
+			continue
+		}
+		if base := e.stackBase(mr, queued, heads); base != nil {
+			mr.StackedOn = base.ID
+		}
+	}
+}
+
+// carries reports whether head has commit in its history.
+func (e *Engineer) carries(head, commit string) bool {
+	ok, _ := e.git.IsAncestor(commit, head)
+	return ok
+}
+
+// stackBase returns the queued MR, for the same target, whose branch mr's
+// branch was built on, or nil. Of several, the nearest wins: in a chain,
+// each MR stacks on the one below it. Branches already merged into the
+// target do not count.
+func (e *Engineer) stackBase(mr *mrqueue.MR, queued []*mrqueue.MR, heads map[string]string) *mrqueue.MR {
+	head := heads[mr.ID]
+	var best *mrqueue.MR
+	for _, other := range queued {
+		sha := heads[other.ID]
+		if other.ID == mr.ID || other.Target != mr.Target || sha == "" || sha == head {
+			continue
+		}
+		if ok, _ := e.git.IsAncestor(sha, head); !ok {
+			continue
+		}
+		if merged, _ := e.git.IsAncestor(sha, "origin/"+mr.Target); merged {
+			continue
+		}
+		if best == nil {
+			best = other
+		} else if nearer, _ := e.git.IsAncestor(heads[best.ID], sha); nearer {
+			best = other
+		}
+	}
+	return best
+}
+
+// moveStack rebases mr's commits after its stack base onto onto (named
+// label in messages) in a scratch worktree, and force-pushes the branch.
+// stackedOn and base are what to record afterwards: the MR and commit it
+// now builds on, or "" once it builds on the target. A conflict holds the
+// MR for its worker to rebase by hand.
+func (e *Engineer) moveStack(mr *mrqueue.MR, onto, label, stackedOn, base string) {
+	if stackedOn == "" {
+		if err := e.fetchTarget(mr.Target); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: fetch origin/%s: %v (continuing)\n", mr.Target, err)
+		}
+	}
+	dir := filepath.Join(e.stackDir(), mr.ID)
+	_ = e.git.WorktreeRemove(dir, true)
+	_ = os.RemoveAll(dir)
+	if err := e.git.WorktreeAddDetached(dir, mr.Branch); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to create worktree to restack %s: %v\n", mr.Branch, err)
+		return
+	}
+	defer func() {
+		_ = e.git.WorktreeRemove(dir, true)
+		_ = e.git.WorktreePrune()
+	}()
+	wg := e.gitAt(dir)
+
+	if err := wg.RebaseOnto(onto, mr.StackBase); err != nil {
+		_ = wg.AbortRebase()
+		reason := fmt.Sprintf("restacking onto %s conflicted; rebase the branch by hand, then requeue", label)
+		if err := e.mrQueue.Hold(mr.ID, reason); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to hold %s: %v\n", mr.ID, err)
+			return
+		}
+		if err := e.eventLogger.LogHeld(mr, reason); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to log held event: %v\n", err)
+		}
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Held %s: %s\n", mr.ID, reason)
+		return
+	}
+	sha, err := wg.Rev("HEAD")
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to restack %s: %v\n", mr.Branch, err)
+		return
+	}
+	old, _ := e.git.Rev(mr.Branch)
+	if err := e.git.UpdateBranchRef(mr.Branch, sha); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to update local %s: %v\n", mr.Branch, err)
+		return
+	}
+	if err := e.git.Push("origin", mr.Branch, true); err != nil {
+		_ = e.git.UpdateBranchRef(mr.Branch, old)
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to push restacked %s: %v\n", mr.Branch, err)
+		return
+	}
+	if err := e.mrQueue.SetStack(mr.ID, stackedOn, base); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to record %s's new stack base: %v\n", mr.ID, err)
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Restacked %s onto %s (%.8s)\n", mr.Branch, label, sha)
+}
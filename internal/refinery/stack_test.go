@@ -0,0 +1,124 @@
+package refinery
+
+import (
+	"context"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/git/gitfake"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// queueStacked commits changes on a new branch off from and queues it.
+func queueStacked(t *testing.T, e *Engineer, repo *gitfake.Repo, branch, from string, changes map[string]string) *mrqueue.MR {
+	t.Helper()
+	if err := e.git.CreateBranchFrom(branch, from); err != nil {
+		t.Fatal(err)
+	}
+	repo.Commit(branch, branch, changes)
+	repo.Publish(branch)
+	mr := &mrqueue.MR{Branch: branch, Target: "main", Worker: "nux"}
+	if err := e.mrQueue.Submit(mr); err != nil {
+		t.Fatal(err)
+	}
+	return mr
+}
+
+func TestRestack_Lands(t *testing.T) {
+	e, repo := newFakeEngineer(t)
+	e.settings.Stacks = true
+	e.settings.Strategy = config.StrategySquash
+	a := queueStacked(t, e, repo, "polecat/nux/gt-1", "main", map[string]string{"a.txt": "a\n"})
+	b := queueStacked(t, e, repo, "polecat/nux/gt-2", "polecat/nux/gt-1", map[string]string{"b.txt": "b\n"})
+	c := queueStacked(t, e, repo, "polecat/nux/gt-3", "polecat/nux/gt-2", map[string]string{"c.txt": "c\n"})
+
+	// Listing alone holds the stack back, recording nothing
+	ready, err := e.readyMRs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := mrIDs(ready); len(got) != 1 || got[0] != a.ID {
+		t.Fatalf("ready before restacking = %v, want only the bottom of the stack %s", got, a.ID)
+	}
+	if got, _ := e.mrQueue.Get(c.ID); got.StackedOn != "" {
+		t.Errorf("listing recorded %s stacked on %s", c.ID, got.StackedOn)
+	}
+
+	e.prepareQueue()
+	ready, err = e.readyMRs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := mrIDs(ready); len(got) != 1 || got[0] != a.ID {
+		t.Fatalf("ready = %v, want only the bottom of the stack %s", got, a.ID)
+	}
+	if got, _ := e.mrQueue.Get(c.ID); got.StackedOn != b.ID {
+		t.Errorf("%s stacked on %q, want the nearest branch %s", c.ID, got.StackedOn, b.ID)
+	}
+
+	if res := e.doMerge(context.Background(), a); !res.Success {
+		t.Fatalf("doMerge(a) = %+v", res)
+	}
+	if err := e.mrQueue.Remove(a.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Squashed, a's commits are not in main: b must drop them to merge.
+	e.prepareQueue()
+	ready, err = e.readyMRs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := mrIDs(ready); len(got) != 1 || got[0] != b.ID {
+		t.Fatalf("ready after a landed = %v, want %s", got, b.ID)
+	}
+	if subjects, _ := e.git.CommitSubjects("origin/main", "origin/"+b.Branch); len(subjects) != 1 || subjects[0] != b.Branch {
+		t.Errorf("restacked b carries %v, want only its own commit", subjects)
+	}
+	got, _ := e.mrQueue.Get(b.ID)
+	if got.StackedOn != "" {
+		t.Errorf("b still stacked on %s", got.StackedOn)
+	}
+	if res := e.doMerge(context.Background(), got); !res.Success {
+		t.Fatalf("doMerge(b) = %+v", res)
+	}
+	if f := repo.Lookup(repo.Origin("main")).Files; f["a.txt"] == "" || f["b.txt"] == "" || f["c.txt"] != "" {
+		t.Errorf("main = %v, want a and b but not c", f)
+	}
+}
+
+func TestRestack_BaseRewritten(t *testing.T) {
+	e, repo := newFakeEngineer(t)
+	e.settings.Stacks = true
+	a := queueStacked(t, e, repo, "polecat/nux/gt-1", "main", map[string]string{"a.txt": "a\n"})
+	b := queueStacked(t, e, repo, "polecat/nux/gt-2", "polecat/nux/gt-1", map[string]string{"b.txt": "b\n"})
+	e.prepareQueue()
+
+	// a fails and is fixed with a new commit; b moves onto the fix, but
+	// only when the queue is processed, not listed.
+	fix := repo.Commit(a.Branch, "fix a", map[string]string{"a.txt": "fixed\n"})
+	if _, err := e.readyMRs(); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := e.git.IsAncestor(fix, b.Branch); ok {
+		t.Error("listing ready MRs rebased b")
+	}
+	e.prepareQueue()
+	got, _ := e.mrQueue.Get(b.ID)
+	if got.StackedOn != a.ID || got.StackBase != fix {
+		t.Errorf("b stacked on %s at %.8s, want %s at the fix %.8s", got.StackedOn, got.StackBase, a.ID, fix)
+	}
+	if ok, _ := e.git.IsAncestor(fix, b.Branch); !ok {
+		t.Error("b was not rebased onto the fixed a")
+	}
+
+	// A rewrite b's commit cannot be replayed onto holds it.
+	if err := e.git.UpdateBranchRef(a.Branch, "main"); err != nil {
+		t.Fatal(err)
+	}
+	repo.Commit(a.Branch, "redo a", map[string]string{"b.txt": "not b\n"})
+	e.prepareQueue()
+	if got, _ := e.mrQueue.Get(b.ID); !got.IsHeld() {
+		t.Errorf("b = %+v, want held after a conflicting restack", got)
+	}
+}
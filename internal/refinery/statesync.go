@@ -0,0 +1,177 @@
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// StateFile is the name of the snapshot under a [refinery.state_sync]
+// bucket prefix or in its ref's tree.
+const StateFile = "state.json"
+
+// StateSnapshot is what [refinery.state_sync] publishes: enough of the
+// refinery for a teammate without access to its host to see what it is
+// doing and what it has done.
+type StateSnapshot struct {
+	Rig      string          `json:"rig"`
+	Host     string          `json:"host"`
+	SyncedAt time.Time       `json:"synced_at"`
+	Status   *Refinery       `json:"status"`
+	Queue    []*mrqueue.MR   `json:"queue"`
+	History  []mrqueue.Event `json:"history"` // Most recent events, oldest first
+}
+
+// PublishState writes a snapshot of the refinery to [refinery.state_sync].
+// Returns where it went, or "" if state_sync is not configured.
+func (e *Engineer) PublishState(ctx context.Context) (string, error) {
+	if e.settings == nil || e.settings.StateSync == nil {
+		return "", nil
+	}
+	sc := e.settings.StateSync
+	snap, err := e.stateSnapshot(sc.HistoryLimit())
+	if err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	// The snapshot leaves the host; check output in MR errors and events
+	// must not carry a secret with it.
+	content := util.Redact(string(data)) + "\n"
+
+	if sc.Ref != "" {
+		g := git.NewGit(e.workDir)
+		message := fmt.Sprintf("Refinery state for %s at %s", e.rig.Name, snap.SyncedAt.Format(time.RFC3339))
+		if _, err := g.WriteRefFile(sc.Ref, StateFile, content, message); err != nil {
+			return "", err
+		}
+		if err := g.PushRef(sc.RemoteName(), sc.Ref); err != nil {
+			return "", err
+		}
+		return sc.RemoteName() + " " + sc.Ref, nil
+	}
+	object := strings.TrimSuffix(sc.URL, "/") + "/" + StateFile
+	if _, err := bucketCommand(ctx, content, bucketPut, object); err != nil {
+		return "", err
+	}
+	return object, nil
+}
+
+// publishState publishes the refinery's state after an MR is handled.
+// Best-effort: a backend that is down costs teammates a fresh view, not
+// the merge.
+func (e *Engineer) publishState() {
+	if _, err := e.PublishState(context.Background()); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to publish refinery state: %v\n", err)
+	}
+}
+
+func (e *Engineer) stateSnapshot(history int) (*StateSnapshot, error) {
+	status, err := NewManager(e.rig).Status()
+	if err != nil {
+		return nil, fmt.Errorf("reading status: %w", err)
+	}
+	queue, err := e.mrQueue.List()
+	if err != nil {
+		return nil, fmt.Errorf("reading queue: %w", err)
+	}
+	events, err := e.eventLogger.ReadEvents(history)
+	if err != nil {
+		return nil, fmt.Errorf("reading history: %w", err)
+	}
+	host, _ := os.Hostname()
+	snap := &StateSnapshot{
+		Rig:      e.rig.Name,
+		Host:     host,
+		SyncedAt: e.clock().UTC(),
+		Status:   status,
+		Queue:    queue,
+		History:  events,
+	}
+	if snap.Queue == nil {
+		snap.Queue = []*mrqueue.MR{}
+	}
+	if snap.History == nil {
+		snap.History = []mrqueue.Event{}
+	}
+	return snap, nil
+}
+
+// FetchState reads a snapshot published by [refinery.state_sync] from
+// location: an s3:// or gs:// prefix, or a ref fetched from remote into
+// the repository at dir.
+func FetchState(ctx context.Context, location, remote, dir string) (*StateSnapshot, error) {
+	var data string
+	var err error
+	if strings.HasPrefix(location, "refs/") {
+		if remote == "" {
+			remote = config.DefaultStateSyncRemote
+		}
+		g := git.NewGit(dir)
+		if err := g.FetchPrune(remote, "+"+location+":"+location); err != nil {
+			return nil, err
+		}
+		data, err = g.ShowFile(location, StateFile)
+	} else if strings.HasPrefix(location, "s3://") || strings.HasPrefix(location, "gs://") {
+		data, err = bucketCommand(ctx, "", bucketGet, strings.TrimSuffix(location, "/")+"/"+StateFile)
+	} else {
+		return nil, fmt.Errorf("%q: want an s3:// or gs:// URL or a refs/ ref", location)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snap StateSnapshot
+	if err := json.Unmarshal([]byte(data), &snap); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", StateFile, err)
+	}
+	return &snap, nil
+}
+
+// Bucket operations, run with the provider's CLI so its own credential
+// discovery (profiles, instance roles, workload identity) applies.
+const (
+	bucketPut = "put"
+	bucketGet = "get"
+)
+
+// bucketCommand uploads input to object, or downloads it, with aws for
+// s3:// and gcloud for gs://.
+func bucketCommand(ctx context.Context, input, op, object string) (string, error) {
+	var name string
+	var args []string
+	switch {
+	case strings.HasPrefix(object, "s3://") && op == bucketPut:
+		name, args = "aws", []string{"s3", "cp", "-", object, "--content-type", "application/json"}
+	case strings.HasPrefix(object, "s3://"):
+		name, args = "aws", []string{"s3", "cp", object, "-"}
+	case op == bucketPut:
+		name, args = "gcloud", []string{"storage", "cp", "-", object}
+	default:
+		name, args = "gcloud", []string{"storage", "cat", object}
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = strings.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	started := time.Now()
+	err := cmd.Run()
+	util.TraceCommand("", name, args, started, err)
+	if err != nil {
+		return "", fmt.Errorf("%s %s: %v\n%s", name, strings.Join(args[:2], " "), err, tailLines(stderr.String(), feedbackOutputLines))
+	}
+	return stdout.String(), nil
+}
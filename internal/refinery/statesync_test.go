@@ -0,0 +1,68 @@
+package refinery
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+func TestPublishState_Ref(t *testing.T) {
+	rigPath := setupConflictRig(t)
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: rigPath})
+	e.SetOutput(&strings.Builder{})
+	if err := e.LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	e.settings = &config.RefinerySettings{StateSync: &config.StateSyncConfig{Ref: "refs/gastown/state"}}
+
+	mr := &mrqueue.MR{Branch: "polecat/nux/gt-1", Target: "main", Worker: "nux"}
+	if err := e.mrQueue.Submit(mr); err != nil {
+		t.Fatal(err)
+	}
+	util.RegisterSecret("hunter2-state-sync")
+	if err := e.eventLogger.LogMergeFailed(mr, "check printed hunter2-state-sync"); err != nil {
+		t.Fatal(err)
+	}
+	// Publishing again replaces the snapshot rather than failing to push
+	for range 2 {
+		if _, err := e.PublishState(context.Background()); err != nil {
+			t.Fatalf("PublishState: %v", err)
+		}
+	}
+
+	// A teammate's clone, which has never seen the rig's host
+	clone := filepath.Join(t.TempDir(), "clone")
+	origin, err := exec.Command("git", "-C", rigPath, "remote", "get-url", "origin").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, err := exec.Command("git", "clone", strings.TrimSpace(string(origin)), clone).CombinedOutput(); err != nil {
+		t.Fatalf("git clone: %v\n%s", err, out)
+	}
+	snap, err := FetchState(context.Background(), "refs/gastown/state", "origin", clone)
+	if err != nil {
+		t.Fatalf("FetchState: %v", err)
+	}
+	if snap.Rig != "test-rig" || snap.Status == nil || len(snap.Queue) != 1 || snap.Queue[0].Worker != "nux" {
+		t.Errorf("snapshot = %+v", snap)
+	}
+	if len(snap.History) != 1 || snap.History[0].Type != mrqueue.EventMergeFailed {
+		t.Fatalf("history = %+v", snap.History)
+	}
+	if strings.Contains(snap.History[0].Reason, "hunter2") {
+		t.Errorf("published a secret: %q", snap.History[0].Reason)
+	}
+}
+
+func TestFetchState_BadLocation(t *testing.T) {
+	if _, err := FetchState(context.Background(), "https://example.com/state", "", t.TempDir()); err == nil {
+		t.Error("FetchState accepted an https URL")
+	}
+}
@@ -0,0 +1,356 @@
+package refinery
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// DefaultStatsDays is the window used for daily stats when none is requested.
+const DefaultStatsDays = 7
+
+// DailyStats counts merge outcomes for a single calendar day (local time).
+type DailyStats struct {
+	Date   string `json:"date"` // YYYY-MM-DD
+	Merged int    `json:"merged"`
+	Failed int    `json:"failed"`
+}
+
+// Stats summarizes merge queue activity from the event log.
+type Stats struct {
+	Merged  int `json:"merged"`
+	Failed  int `json:"failed"`
+	Skipped int `json:"skipped"`
+
+	// SuccessRate is merged / (merged + failed), or 0 with no attempts.
+	SuccessRate float64 `json:"success_rate"`
+
+	// Daily holds one entry per day for the last N days, oldest first.
+	Daily []DailyStats `json:"daily"`
+
+	// Workers holds per-worker quality over the same N days, by name.
+	Workers []WorkerStats `json:"workers"`
+
+	// Swarms holds every swarm in the log, oldest first. Swarm runs span
+	// days, so they are not limited to the N-day window.
+	Swarms []SwarmStats `json:"swarms"`
+}
+
+// WorkerStats measures how cleanly one worker's branches land.
+type WorkerStats struct {
+	Worker string `json:"worker"`
+	MRs    int    `json:"mrs"`    // Distinct MRs with at least one outcome
+	Merged int    `json:"merged"` // Merged MRs
+	Failed int    `json:"failed"` // Failed attempts; an MR may fail several times
+
+	// FirstPassRate is the share of MRs that merged on their first attempt.
+	FirstPassRate float64 `json:"first_pass_rate"`
+
+	// ConflictRate is the share of attempts that failed on conflicts.
+	ConflictRate float64 `json:"conflict_rate"`
+
+	// CheckFailures counts failed attempts by check name, or by failure
+	// type ("tests", "build") when no named check failed. Conflicts are
+	// counted in ConflictRate instead.
+	CheckFailures map[string]int `json:"check_failures,omitempty"`
+}
+
+// SwarmStats measures one swarm run: the MRs merged into its integration
+// branch, from its first merge attempt to its landing.
+type SwarmStats struct {
+	Swarm  string `json:"swarm"`  // Epic ID
+	MRs    int    `json:"mrs"`    // Distinct member MRs with at least one outcome
+	Merged int    `json:"merged"` // Member MRs merged into the integration branch
+	Failed int    `json:"failed"` // Failed member attempts
+
+	// FailureRate is the share of member merge attempts that failed.
+	FailureRate float64 `json:"failure_rate"`
+
+	// Started is the swarm's first logged event, normally the first merge
+	// attempt of its first branch. Finished is its landing, or the latest
+	// merge into the integration branch while it has not landed or been
+	// aborted.
+	Started  time.Time  `json:"started"`
+	Finished *time.Time `json:"finished,omitempty"`
+	Landed   bool       `json:"landed"`
+	Aborted  bool       `json:"aborted,omitempty"`
+
+	// ElapsedSeconds is the wall-clock time from Started to Finished.
+	ElapsedSeconds int64 `json:"elapsed_seconds"`
+
+	// MergesPerHour is Merged over the elapsed time, or 0 if under a minute.
+	MergesPerHour float64 `json:"merges_per_hour"`
+}
+
+// ComputeStats aggregates events into totals and a per-day breakdown
+// covering the `days` days ending at now.
+func ComputeStats(events []mrqueue.Event, now time.Time, days int) Stats {
+	if days <= 0 {
+		days = DefaultStatsDays
+	}
+
+	var stats Stats
+	stats.Daily = make([]DailyStats, days)
+	index := make(map[string]int, days)
+	today := now.Local()
+	for i := 0; i < days; i++ {
+		date := today.AddDate(0, 0, i-days+1).Format("2006-01-02")
+		stats.Daily[i].Date = date
+		index[date] = i
+	}
+
+	for _, e := range events {
+		day, inWindow := index[e.Timestamp.Local().Format("2006-01-02")]
+		switch e.Type {
+		case mrqueue.EventMerged:
+			stats.Merged++
+			if inWindow {
+				stats.Daily[day].Merged++
+			}
+		case mrqueue.EventMergeFailed:
+			stats.Failed++
+			if inWindow {
+				stats.Daily[day].Failed++
+			}
+		case mrqueue.EventMergeSkipped:
+			stats.Skipped++
+		}
+	}
+
+	if attempts := stats.Merged + stats.Failed; attempts > 0 {
+		stats.SuccessRate = float64(stats.Merged) / float64(attempts)
+	}
+	stats.Workers = computeWorkerStats(events, index)
+	stats.Swarms = computeSwarmStats(events)
+	return stats
+}
+
+// statsCache holds the Stats last computed for each window size, with the
+// state of the event log and the day they were computed for. The log
+// changes only by growing or being compacted, so while its size and
+// modification time hold, so do the stats.
+type statsCache struct {
+	LogSize int64         `json:"log_size"`
+	LogMod  time.Time     `json:"log_mod"`
+	Date    string        `json:"date"`
+	Stats   map[int]Stats `json:"stats"` // By days
+}
+
+// StatsCachePath returns where a rig's computed stats are cached.
+func StatsCachePath(rigPath string) string {
+	return filepath.Join(rigPath, ".runtime", "stats_cache.json")
+}
+
+// CachedStats is ComputeStats over a rig's event log, computed only when
+// the log has grown or the day has turned since it was last computed for
+// this window, so repeated stats requests do not re-read a long history.
+func CachedStats(rigPath string, log *mrqueue.EventLogger, now time.Time, days int) (Stats, error) {
+	if days <= 0 {
+		days = DefaultStatsDays
+	}
+	var size int64
+	var mod time.Time
+	if info, err := os.Stat(log.LogPath()); err == nil {
+		size, mod = info.Size(), info.ModTime()
+	}
+	date := now.Local().Format("2006-01-02")
+
+	path := StatsCachePath(rigPath)
+	var cache statsCache
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &cache)
+	}
+	if cache.LogSize != size || !cache.LogMod.Equal(mod) || cache.Date != date {
+		cache = statsCache{LogSize: size, LogMod: mod, Date: date}
+	}
+	if stats, ok := cache.Stats[days]; ok {
+		return stats, nil
+	}
+
+	events, err := log.ReadEvents(0)
+	if err != nil {
+		return Stats{}, err
+	}
+	stats := ComputeStats(events, now, days)
+	if cache.Stats == nil {
+		cache.Stats = make(map[int]Stats)
+	}
+	cache.Stats[days] = stats
+	// The cache only saves time; failing to keep it is not an error.
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+		_ = util.AtomicWriteJSON(path, cache)
+	}
+	return stats, nil
+}
+
+// computeSwarmStats aggregates events by swarm. Member MRs are those
+// targeting the swarm's integration branch; the landing MR only marks the
+// end of the run.
+func computeSwarmStats(events []mrqueue.Event) []SwarmStats {
+	bySwarm := make(map[string]*SwarmStats)
+	mrs := make(map[string]bool)
+	for _, e := range events {
+		id := mrqueue.SwarmOf(e.Branch, e.Target)
+		if id == "" {
+			continue
+		}
+		ss := bySwarm[id]
+		if ss == nil {
+			ss = &SwarmStats{Swarm: id, Started: e.Timestamp}
+			bySwarm[id] = ss
+		}
+		if e.Timestamp.Before(ss.Started) {
+			ss.Started = e.Timestamp
+		}
+
+		switch e.Type {
+		case mrqueue.EventSwarmLanded:
+			at := e.Timestamp
+			ss.Landed, ss.Finished = true, &at
+			continue
+		case mrqueue.EventSwarmAborted:
+			at := e.Timestamp
+			ss.Aborted, ss.Finished = true, &at
+			continue
+		}
+		if integrationEpic(e.Target) == "" {
+			continue // The landing MR's own attempts
+		}
+		switch e.Type {
+		case mrqueue.EventMerged:
+			ss.Merged++
+			if !ss.Landed && !ss.Aborted && (ss.Finished == nil || e.Timestamp.After(*ss.Finished)) {
+				at := e.Timestamp
+				ss.Finished = &at
+			}
+		case mrqueue.EventMergeFailed:
+			ss.Failed++
+		default:
+			continue
+		}
+		key := e.MRID
+		if key == "" {
+			key = e.Branch
+		}
+		if !mrs[id+"\x00"+key] {
+			mrs[id+"\x00"+key] = true
+			ss.MRs++
+		}
+	}
+
+	out := make([]SwarmStats, 0, len(bySwarm))
+	for _, ss := range bySwarm {
+		if attempts := ss.Merged + ss.Failed; attempts > 0 {
+			ss.FailureRate = float64(ss.Failed) / float64(attempts)
+		}
+		if ss.Finished != nil && ss.Finished.After(ss.Started) {
+			elapsed := ss.Finished.Sub(ss.Started)
+			ss.ElapsedSeconds = int64(elapsed / time.Second)
+			if elapsed >= time.Minute {
+				ss.MergesPerHour = float64(ss.Merged) / elapsed.Hours()
+			}
+		}
+		out = append(out, *ss)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Started.Before(out[j].Started) })
+	return out
+}
+
+// computeWorkerStats aggregates merge outcomes per worker for events on the
+// days in window. Worker addresses are reduced to the bare name, matching
+// worker registrations.
+func computeWorkerStats(events []mrqueue.Event, window map[string]int) []WorkerStats {
+	type mrOutcome struct {
+		worker string
+		first  mrqueue.EventType // First outcome seen for the MR
+	}
+	byWorker := make(map[string]*WorkerStats)
+	conflicts := make(map[string]int)
+	mrs := make(map[string]*mrOutcome)
+	var mrOrder []string
+
+	for _, e := range events {
+		if e.Worker == "" || (e.Type != mrqueue.EventMerged && e.Type != mrqueue.EventMergeFailed) {
+			continue
+		}
+		if _, ok := window[e.Timestamp.Local().Format("2006-01-02")]; !ok {
+			continue
+		}
+		name := path.Base(e.Worker)
+		ws := byWorker[name]
+		if ws == nil {
+			ws = &WorkerStats{Worker: name}
+			byWorker[name] = ws
+		}
+
+		if e.Type == mrqueue.EventMerged {
+			ws.Merged++
+		} else {
+			ws.Failed++
+			switch kind := failureKind(e); kind {
+			case "conflict":
+				conflicts[name]++
+			default:
+				if ws.CheckFailures == nil {
+					ws.CheckFailures = make(map[string]int)
+				}
+				ws.CheckFailures[kind]++
+			}
+		}
+
+		key := e.MRID
+		if key == "" {
+			key = e.Branch
+		}
+		if _, seen := mrs[key]; !seen {
+			mrs[key] = &mrOutcome{worker: name, first: e.Type}
+			mrOrder = append(mrOrder, key)
+		}
+	}
+
+	firstPass := make(map[string]int)
+	for _, key := range mrOrder {
+		o := mrs[key]
+		byWorker[o.worker].MRs++
+		if o.first == mrqueue.EventMerged {
+			firstPass[o.worker]++
+		}
+	}
+
+	out := make([]WorkerStats, 0, len(byWorker))
+	for name, ws := range byWorker {
+		if ws.MRs > 0 {
+			ws.FirstPassRate = float64(firstPass[name]) / float64(ws.MRs)
+		}
+		if attempts := ws.Merged + ws.Failed; attempts > 0 {
+			ws.ConflictRate = float64(conflicts[name]) / float64(attempts)
+		}
+		out = append(out, *ws)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Worker < out[j].Worker })
+	return out
+}
+
+// failureKind classifies a merge_failed event: "conflict", the failing
+// check's name, or the failure type. Events logged before failures were
+// classified fall back to the reason text.
+func failureKind(e mrqueue.Event) string {
+	switch {
+	case e.FailureType == "conflict":
+		return "conflict"
+	case e.FailedCheck != "":
+		return e.FailedCheck
+	case e.FailureType != "":
+		return e.FailureType
+	case strings.Contains(e.Reason, "conflict"):
+		return "conflict"
+	}
+	return "other"
+}
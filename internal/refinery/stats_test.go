@@ -0,0 +1,180 @@
+package refinery
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+func TestComputeStats(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.Local)
+	events := []mrqueue.Event{
+		{Timestamp: now.Add(-1 * time.Hour), Type: mrqueue.EventMerged},
+		{Timestamp: now.Add(-2 * time.Hour), Type: mrqueue.EventMergeFailed},
+		{Timestamp: now.AddDate(0, 0, -1), Type: mrqueue.EventMerged},
+		{Timestamp: now.AddDate(0, 0, -30), Type: mrqueue.EventMerged}, // Outside window
+		{Timestamp: now, Type: mrqueue.EventMergeSkipped},
+		{Timestamp: now, Type: mrqueue.EventMergeStarted}, // Not counted
+	}
+
+	stats := ComputeStats(events, now, 3)
+
+	if stats.Merged != 3 || stats.Failed != 1 || stats.Skipped != 1 {
+		t.Errorf("totals = %d/%d/%d, want 3/1/1", stats.Merged, stats.Failed, stats.Skipped)
+	}
+	if stats.SuccessRate != 0.75 {
+		t.Errorf("SuccessRate = %v, want 0.75", stats.SuccessRate)
+	}
+	if len(stats.Daily) != 3 {
+		t.Fatalf("len(Daily) = %d, want 3", len(stats.Daily))
+	}
+	if got := stats.Daily[2]; got.Date != "2025-03-10" || got.Merged != 1 || got.Failed != 1 {
+		t.Errorf("today = %+v, want 2025-03-10 merged=1 failed=1", got)
+	}
+	if got := stats.Daily[1]; got.Merged != 1 {
+		t.Errorf("yesterday merged = %d, want 1", got.Merged)
+	}
+}
+
+func TestComputeStats_Workers(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.Local)
+	at := func(h int) time.Time { return now.Add(time.Duration(-h) * time.Hour) }
+	events := []mrqueue.Event{
+		// nux: mr-1 merges first time; mr-2 fails a check, conflicts, then merges
+		{Timestamp: at(9), Type: mrqueue.EventMerged, MRID: "mr-1", Worker: "nux"},
+		{Timestamp: at(8), Type: mrqueue.EventMergeFailed, MRID: "mr-2", Worker: "greenplace/polecats/nux", FailureType: "tests", FailedCheck: "lint"},
+		{Timestamp: at(7), Type: mrqueue.EventMergeFailed, MRID: "mr-2", Worker: "nux", FailureType: "conflict"},
+		{Timestamp: at(6), Type: mrqueue.EventMerged, MRID: "mr-2", Worker: "nux"},
+		// toast: a legacy failure without a type
+		{Timestamp: at(5), Type: mrqueue.EventMergeFailed, MRID: "mr-3", Worker: "toast", Reason: "merge conflicts in: [a.go]"},
+		{Timestamp: now.AddDate(0, 0, -30), Type: mrqueue.EventMerged, MRID: "mr-old", Worker: "toast"}, // Outside window
+		{Timestamp: at(1), Type: mrqueue.EventMerged, MRID: "mr-4"},                                     // No worker
+	}
+
+	workers := ComputeStats(events, now, 3).Workers
+	if len(workers) != 2 || workers[0].Worker != "nux" || workers[1].Worker != "toast" {
+		t.Fatalf("workers = %+v, want nux and toast", workers)
+	}
+
+	nux := workers[0]
+	if nux.MRs != 2 || nux.Merged != 2 || nux.Failed != 2 {
+		t.Errorf("nux totals = %d MRs, %d merged, %d failed; want 2/2/2", nux.MRs, nux.Merged, nux.Failed)
+	}
+	if nux.FirstPassRate != 0.5 || nux.ConflictRate != 0.25 {
+		t.Errorf("nux rates = first pass %v, conflict %v; want 0.5, 0.25", nux.FirstPassRate, nux.ConflictRate)
+	}
+	if len(nux.CheckFailures) != 1 || nux.CheckFailures["lint"] != 1 {
+		t.Errorf("nux CheckFailures = %v, want lint:1", nux.CheckFailures)
+	}
+
+	toast := workers[1]
+	if toast.MRs != 1 || toast.FirstPassRate != 0 || toast.ConflictRate != 1 {
+		t.Errorf("toast = %+v, want one MR that conflicted", toast)
+	}
+}
+
+func TestComputeStats_Swarms(t *testing.T) {
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.Local)
+	at := func(m int) time.Time { return start.Add(time.Duration(m) * time.Minute) }
+	events := []mrqueue.Event{
+		// Swarm a: two members (one fails once), then the landing
+		{Timestamp: at(0), Type: mrqueue.EventMergeStarted, MRID: "mr-1", Target: "integration/a"},
+		{Timestamp: at(10), Type: mrqueue.EventMerged, MRID: "mr-1", Target: "integration/a"},
+		{Timestamp: at(20), Type: mrqueue.EventMergeFailed, MRID: "mr-2", Target: "integration/a"},
+		{Timestamp: at(60), Type: mrqueue.EventMerged, MRID: "mr-2", Target: "integration/a"},
+		{Timestamp: at(100), Type: mrqueue.EventMerged, MRID: "mr-land", Branch: "integration/a", Target: "main"},
+		{Timestamp: at(120), Type: mrqueue.EventSwarmLanded, MRID: "mr-land", Branch: "integration/a", Target: "main"},
+		// Swarm b: still running
+		{Timestamp: at(30), Type: mrqueue.EventMerged, MRID: "mr-3", Target: "integration/b"},
+		// Swarm c: aborted after one merge
+		{Timestamp: at(40), Type: mrqueue.EventMerged, MRID: "mr-5", Target: "integration/c"},
+		{Timestamp: at(50), Type: mrqueue.EventSwarmAborted, Branch: "integration/c", Target: "main"},
+		{Timestamp: at(70), Type: mrqueue.EventMergeSkipped, MRID: "mr-6", Target: "integration/c"},
+		// Not a swarm
+		{Timestamp: at(5), Type: mrqueue.EventMerged, MRID: "mr-4", Target: "main"},
+	}
+
+	swarms := ComputeStats(events, start, 1).Swarms
+	if len(swarms) != 3 || swarms[0].Swarm != "a" || swarms[1].Swarm != "b" || swarms[2].Swarm != "c" {
+		t.Fatalf("swarms = %+v, want a, b, c", swarms)
+	}
+
+	a := swarms[0]
+	if a.MRs != 2 || a.Merged != 2 || a.Failed != 1 || !a.Landed {
+		t.Errorf("a = %+v, want 2 MRs, 2 merged, 1 failed, landed", a)
+	}
+	if a.ElapsedSeconds != 2*60*60 || a.MergesPerHour != 1 {
+		t.Errorf("a elapsed %ds at %v/h, want 2h at 1/h", a.ElapsedSeconds, a.MergesPerHour)
+	}
+	if got := a.FailureRate; got < 0.33 || got > 0.34 {
+		t.Errorf("a FailureRate = %v, want 1/3", got)
+	}
+
+	b := swarms[1]
+	if b.Landed || b.Merged != 1 || b.ElapsedSeconds != 0 || b.Finished == nil {
+		t.Errorf("b = %+v, want an active swarm with one merge", b)
+	}
+
+	if c := swarms[2]; !c.Aborted || c.Landed || c.ElapsedSeconds != 10*60 {
+		t.Errorf("c = %+v, want aborted 10m after it started", c)
+	}
+}
+
+func TestCachedStats(t *testing.T) {
+	rigPath := t.TempDir()
+	log := mrqueue.NewEventLoggerFromRig(rigPath)
+	mr := &mrqueue.MR{ID: "mr-1", Branch: "polecat/nux/gt-1", Target: "main", Worker: "nux"}
+	if err := log.LogMerged(mr, "abc123"); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+
+	stats, err := CachedStats(rigPath, log, now, 7)
+	if err != nil {
+		t.Fatalf("CachedStats: %v", err)
+	}
+	if stats.Merged != 1 {
+		t.Fatalf("Merged = %d, want 1", stats.Merged)
+	}
+
+	// Mark the cached result so a reuse can be told from a recompute.
+	var cache statsCache
+	data, err := os.ReadFile(StatsCachePath(rigPath))
+	if err != nil {
+		t.Fatalf("cache not written: %v", err)
+	}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		t.Fatal(err)
+	}
+	marked := cache.Stats[7]
+	marked.Skipped = 42
+	cache.Stats[7] = marked
+	if err := util.AtomicWriteJSON(StatsCachePath(rigPath), cache); err != nil {
+		t.Fatal(err)
+	}
+
+	if stats, _ := CachedStats(rigPath, log, now, 7); stats.Skipped != 42 {
+		t.Errorf("unchanged log: Skipped = %d, want the cached 42", stats.Skipped)
+	}
+	if stats, _ := CachedStats(rigPath, log, now, 30); stats.Skipped != 0 || stats.Merged != 1 {
+		t.Errorf("other window: got %+v, want computed afresh", stats)
+	}
+	if stats, _ := CachedStats(rigPath, log, now.AddDate(0, 0, 1), 7); stats.Skipped != 0 {
+		t.Errorf("next day: Skipped = %d, want recomputed 0", stats.Skipped)
+	}
+
+	if err := log.LogMergeFailed(mr, "tests failed"); err != nil {
+		t.Fatal(err)
+	}
+	stats, err = CachedStats(rigPath, log, now, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Failed != 1 || stats.Skipped != 0 {
+		t.Errorf("after a new event: got %+v, want recomputed with 1 failure", stats)
+	}
+}
@@ -0,0 +1,95 @@
+package refinery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// SummaryContext is written to $GT_SUMMARY_CONTEXT for the summary command.
+type SummaryContext struct {
+	MRID        string   `json:"mr_id"`
+	Branch      string   `json:"branch"`
+	Target      string   `json:"target"`
+	Worker      string   `json:"worker,omitempty"`
+	SourceIssue string   `json:"source_issue,omitempty"`
+	Title       string   `json:"title,omitempty"`
+	Commits     []string `json:"commits"` // Subject lines, oldest first
+	Files       []string `json:"files"`
+}
+
+// squashMessage returns the commit message for squashing mr onto the
+// checked-out target: subject, plus a body from [refinery.summary] when
+// the rig has one. A summary that fails or prints nothing is left out
+// rather than holding up the merge.
+func (e *Engineer) squashMessage(ctx context.Context, mr *mrqueue.MR, subject string) string {
+	if e.settings == nil || e.settings.Strategy != config.StrategySquash || e.settings.Summary == nil {
+		return subject
+	}
+	body, err := e.summarize(ctx, mr)
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: summarizing %s: %v (merging without a summary)\n", mr.Branch, err)
+		return subject
+	}
+	if body == "" {
+		return subject
+	}
+	return subject + "\n\n" + body
+}
+
+// summarize runs the summary command for mr and returns what it printed.
+func (e *Engineer) summarize(ctx context.Context, mr *mrqueue.MR) (string, error) {
+	sc := e.settings.Summary
+	commits, err := e.git.CommitSubjects("HEAD", mr.Branch)
+	if err != nil {
+		return "", err
+	}
+	files, err := e.git.ChangedFiles("HEAD", mr.Branch)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(SummaryContext{
+		MRID:        mr.ID,
+		Branch:      mr.Branch,
+		Target:      mr.Target,
+		Worker:      mr.Worker,
+		SourceIssue: mr.SourceIssue,
+		Title:       mr.Title,
+		Commits:     commits,
+		Files:       files,
+	}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	contextFile := filepath.Join(e.rig.Path, ".runtime", "summary-"+mr.ID+".json")
+	if err := os.MkdirAll(filepath.Dir(contextFile), 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(contextFile, data, 0644); err != nil { //nolint:gosec // G306: not sensitive
+		return "", err
+	}
+	defer func() { _ = os.Remove(contextFile) }()
+
+	env, err := e.processEnv()
+	if err != nil {
+		return "", err
+	}
+	env = append(env,
+		"GT_SUMMARY_CONTEXT="+contextFile,
+		"GT_MR_ID="+mr.ID,
+		"GT_BRANCH="+mr.Branch,
+		"GT_TARGET="+mr.Target,
+	)
+
+	out, err := e.runAgent(ctx, "summary", sc.Command, sc.TimeoutDuration(), env)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
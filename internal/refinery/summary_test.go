@@ -0,0 +1,40 @@
+package refinery
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+func TestSquashMessage(t *testing.T) {
+	e, first, _, _, _ := setupPipelineRig(t)
+	if err := e.git.Checkout("main"); err != nil {
+		t.Fatal(err)
+	}
+	subject := "Merge polecat/nux/gt-1 into main"
+
+	tests := []struct {
+		name     string
+		strategy string
+		command  string
+		want     string
+	}{
+		{"summarized", config.StrategySquash, `sed -n 's/.*"add \(.*\)".*/Adds \1./p' "$GT_SUMMARY_CONTEXT"; echo`, subject + "\n\nAdds polecat-nux-gt-1."},
+		{"merge strategy", config.StrategyMerge, "echo unused", subject},
+		{"command fails", config.StrategySquash, "echo partial; exit 1", subject},
+		{"nothing printed", config.StrategySquash, "true", subject},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e.settings.Strategy = tt.strategy
+			e.settings.Summary = &config.SummaryConfig{Command: tt.command}
+			if got := e.squashMessage(t.Context(), first, subject); got != tt.want {
+				t.Errorf("squashMessage = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if !strings.Contains(e.output.(*strings.Builder).String(), "merging without a summary") {
+		t.Error("failed summary was not warned about")
+	}
+}
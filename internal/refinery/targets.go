@@ -0,0 +1,61 @@
+package refinery
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// An MR can be queued for several targets, say main and release/2.3 for a
+// fix branched from the release. It merges into each in turn, with that
+// target's checks, and stays queued until the last lands; a failure on a
+// later target retries from that target without merging the earlier ones
+// again.
+
+// CheckTargets rejects further targets for an MR that are empty or repeat
+// target or each other.
+func CheckTargets(target string, more []string) error {
+	seen := map[string]bool{target: true}
+	for _, t := range more {
+		if strings.TrimSpace(t) == "" {
+			return fmt.Errorf("empty target")
+		}
+		if seen[t] {
+			return fmt.Errorf("target %s given twice", t)
+		}
+		seen[t] = true
+	}
+	return nil
+}
+
+// advanceTarget finishes a merge into a target of mr's other than its last:
+// the merge is logged and picked onto release branches like any other, and
+// mr moves on to its next target, staying queued with its branch.
+func (e *Engineer) advanceTarget(mr *mrqueue.MR, result ProcessResult) {
+	if err := e.eventLogger.LogMergedChange(mr, result.MergeCommit, result.FilesChanged, result.LinesChanged); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to log merged event: %v\n", err)
+	}
+	e.backport(mr, result)
+	if err := e.mrQueue.AdvanceTarget(mr.ID, result.MergeCommit); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to move %s on to %s: %v\n", mr.ID, mr.Targets[0], err)
+		return
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Merged %s into %s (commit: %s); next target %s\n",
+		mr.ID, mr.Target, result.MergeCommit, mr.Targets[0])
+}
+
+// mergedAs describes where mr has merged, given the commit its last merge
+// made: that commit for an MR with one target, else each target it merged
+// into with its commit ("main@<sha>, release/2.3@<sha>").
+func mergedAs(mr *mrqueue.MR, mergeCommit string) string {
+	if len(mr.Merged) == 0 {
+		return mergeCommit
+	}
+	parts := make([]string, 0, len(mr.Merged)+1)
+	for _, m := range mr.Merged {
+		parts = append(parts, m.Target+"@"+m.MergeCommit)
+	}
+	parts = append(parts, mr.Target+"@"+mergeCommit)
+	return strings.Join(parts, ", ")
+}
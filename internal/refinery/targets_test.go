@@ -0,0 +1,80 @@
+package refinery
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+func TestMultipleTargets(t *testing.T) {
+	e, repo := newFakeEngineer(t)
+	if err := e.git.CreateBranchFrom("release/2.3", "main"); err != nil {
+		t.Fatal(err)
+	}
+	repo.Publish("release/2.3")
+	repo.Commit("main", "main work", map[string]string{"main.txt": "main\n"})
+	repo.Publish("main")
+
+	branch := "polecat/nux/gt-1"
+	if err := e.git.CreateBranchFrom(branch, "release/2.3"); err != nil {
+		t.Fatal(err)
+	}
+	repo.Commit(branch, "fix", map[string]string{"shared.txt": "one\nfixed\n"})
+	mr := &mrqueue.MR{Branch: branch, Target: "release/2.3", Targets: []string{"main"}, Worker: "nux"}
+	if err := e.mrQueue.Submit(mr); err != nil {
+		t.Fatal(err)
+	}
+
+	res := e.doMerge(context.Background(), mr)
+	if !res.Success {
+		t.Fatalf("doMerge(release/2.3) = %+v", res)
+	}
+	e.handleSuccessFromQueue(mr, res)
+	next, err := e.mrQueue.Get(mr.ID)
+	if err != nil {
+		t.Fatalf("MR left the queue after its first target: %v", err)
+	}
+	if next.Target != "main" || len(next.Targets) != 0 || len(next.Merged) != 1 || next.Merged[0].MergeCommit != res.MergeCommit {
+		t.Fatalf("MR after first target = %s %v %+v, want main next and release/2.3 merged", next.Target, next.Targets, next.Merged)
+	}
+	if f := repo.Lookup(repo.Origin("release/2.3")).Files; f["main.txt"] != "" || f["shared.txt"] != "one\nfixed\n" {
+		t.Errorf("release/2.3 = %v, want the fix and nothing from main", f)
+	}
+
+	res = e.doMerge(context.Background(), next)
+	if !res.Success {
+		t.Fatalf("doMerge(main) = %+v", res)
+	}
+	e.handleSuccessFromQueue(next, res)
+	if _, err := e.mrQueue.Get(mr.ID); err == nil {
+		t.Error("MR still queued after merging into every target")
+	}
+	if f := repo.Lookup(repo.Origin("main")).Files; f["main.txt"] != "main\n" || f["shared.txt"] != "one\nfixed\n" {
+		t.Errorf("main = %v, want its own work and the fix", f)
+	}
+	out := e.output.(*strings.Builder).String()
+	if want := "release/2.3@" + next.Merged[0].MergeCommit + ", main@" + res.MergeCommit; !strings.Contains(out, want) {
+		t.Errorf("output does not record the composite result %q:\n%s", want, out)
+	}
+}
+
+func TestCheckTargets(t *testing.T) {
+	tests := []struct {
+		target string
+		more   []string
+		ok     bool
+	}{
+		{"main", nil, true},
+		{"release/2.3", []string{"main"}, true},
+		{"main", []string{"main"}, false},
+		{"main", []string{"release/2.3", "release/2.3"}, false},
+		{"main", []string{" "}, false},
+	}
+	for _, tt := range tests {
+		if err := CheckTargets(tt.target, tt.more); (err == nil) != tt.ok {
+			t.Errorf("CheckTargets(%q, %v) = %v, want ok=%v", tt.target, tt.more, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package refinery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// ErrDrainTimeout means a merge was still in flight when Drain gave up.
+var ErrDrainTimeout = errors.New("refinery still merging")
+
+// Busy reports whether a merge is in flight: refinery.json names a current
+// MR, or a queued MR holds a claim younger than mrqueue.ClaimStaleTimeout.
+func (m *Manager) Busy() (bool, error) {
+	ref, err := m.loadState()
+	if err != nil {
+		return false, err
+	}
+	if ref.CurrentMR != nil {
+		return true, nil
+	}
+
+	mrs, err := mrqueue.New(m.rig.Path).List()
+	if err != nil {
+		return false, err
+	}
+	for _, mr := range mrs {
+		if mr.IsClaimed(m.now()) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Drain pauses a running refinery so it takes no new MRs, then waits until
+// the in-flight merge (if any) finishes. Returns ErrDrainTimeout if ctx
+// ends first. A refinery that is not running drains immediately.
+func (m *Manager) Drain(ctx context.Context, poll time.Duration) error {
+	if err := m.Pause(); err != nil && !errors.Is(err, ErrNotRunning) {
+		return err
+	}
+
+	for {
+		busy, err := m.Busy()
+		if err != nil {
+			return err
+		}
+		if !busy {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ErrDrainTimeout
+		case <-time.After(poll):
+		}
+	}
+}
+
+// ArchiveHistory copies the refinery's durable records — state, merge
+// event history with any compacted archives, and the queue — into
+// destDir, so they outlive the rig. Returns the paths written; missing
+// sources are skipped.
+func (m *Manager) ArchiveHistory(destDir string) ([]string, error) {
+	beadsDir := filepath.Join(m.rig.Path, ".beads")
+	sources := []string{
+		m.stateFile(),
+		filepath.Join(beadsDir, "mq_events.jsonl"),
+		filepath.Join(beadsDir, "mq_events.jsonl.anchor"),
+	}
+	if entries, err := os.ReadDir(HistoryArchiveDir(m.rig.Path)); err == nil {
+		for _, e := range entries {
+			if !e.IsDir() {
+				sources = append(sources, filepath.Join(HistoryArchiveDir(m.rig.Path), e.Name()))
+			}
+		}
+	}
+
+	// Compacted event archives, wherever in the rig they were written:
+	// beside the log, or where the latest compaction put them. Those
+	// outside the rig outlive it anyway.
+	archiveDirs := []string{beadsDir}
+	if anchor, err := mrqueue.NewEventLoggerFromRig(m.rig.Path).Anchor(); err == nil && anchor != nil && anchor.Archive != "" {
+		archiveDirs = append(archiveDirs, filepath.Dir(anchor.Archive))
+	}
+	seen := make(map[string]bool)
+	for _, src := range sources {
+		seen[src] = true
+	}
+	for _, dir := range archiveDirs {
+		if rel, err := filepath.Rel(m.rig.Path, dir); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		archives, _ := filepath.Glob(filepath.Join(dir, "mq_events-*.jsonl.gz"))
+		for _, src := range archives {
+			if !seen[src] {
+				seen[src] = true
+				sources = append(sources, src)
+			}
+		}
+	}
+	queueDir := mrqueue.New(m.rig.Path).Dir()
+	if entries, err := os.ReadDir(queueDir); err == nil {
+		for _, e := range entries {
+			if !e.IsDir() && filepath.Ext(e.Name()) == ".json" {
+				sources = append(sources, filepath.Join(queueDir, e.Name()))
+			}
+		}
+	}
+
+	var written []string
+	for _, src := range sources {
+		rel, err := filepath.Rel(m.rig.Path, src)
+		if err != nil {
+			return written, err
+		}
+		dst := filepath.Join(destDir, rel)
+		if err := copyFile(src, dst); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return written, fmt.Errorf("archiving %s: %w", rel, err)
+		}
+		written = append(written, dst)
+	}
+	return written, nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(dst) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,97 @@
+package refinery
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+func TestManager_Drain(t *testing.T) {
+	mgr, rigPath := setupTestManager(t)
+	if err := mgr.saveState(&Refinery{RigName: "testrig", State: StateRunning}); err != nil {
+		t.Fatal(err)
+	}
+
+	q := mrqueue.New(rigPath)
+	mr := &mrqueue.MR{Branch: "polecat/nux", Target: "main"}
+	if err := q.Submit(mr); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Claim(mr.ID, "refinery"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := mgr.Drain(ctx, 10*time.Millisecond); !errors.Is(err, ErrDrainTimeout) {
+		t.Errorf("Drain with claimed MR = %v, want ErrDrainTimeout", err)
+	}
+	if ref, _ := mgr.Status(); ref.State != StatePaused {
+		t.Errorf("state after Drain = %s, want paused", ref.State)
+	}
+
+	if err := q.Release(mr.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := mgr.Drain(context.Background(), 10*time.Millisecond); err != nil {
+		t.Errorf("Drain with idle queue = %v", err)
+	}
+}
+
+func TestManager_ArchiveHistory(t *testing.T) {
+	mgr, rigPath := setupTestManager(t)
+	if err := mgr.saveState(&Refinery{RigName: "testrig", State: StateStopped}); err != nil {
+		t.Fatal(err)
+	}
+	if err := mrqueue.New(rigPath).Submit(&mrqueue.MR{Branch: "polecat/nux", Target: "main"}); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := filepath.Join(t.TempDir(), "archive")
+	written, err := mgr.ArchiveHistory(dest)
+	if err != nil {
+		t.Fatalf("ArchiveHistory: %v", err)
+	}
+	// State and the one queued MR; the event log does not exist yet.
+	if len(written) != 2 {
+		t.Errorf("written = %v, want state + 1 MR", written)
+	}
+	if _, err := os.Stat(filepath.Join(dest, ".runtime", "refinery.json")); err != nil {
+		t.Errorf("state not archived: %v", err)
+	}
+}
+
+func TestManager_ArchiveHistory_CompactedEvents(t *testing.T) {
+	mgr, rigPath := setupTestManager(t)
+	logger := mrqueue.NewEventLoggerFromRig(rigPath)
+	mr := &mrqueue.MR{ID: "mr-1", Branch: "polecat/nux", Target: "main"}
+	for _, log := range []func() error{
+		func() error { return logger.LogMergeStarted(mr) },
+		func() error { return logger.LogMerged(mr, "abc123") },
+	} {
+		if err := log(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Compacted by hand somewhere other than the maintenance job's directory
+	_, archive, err := logger.Compact(time.Now().Add(time.Hour), filepath.Join(rigPath, "mq-archive"))
+	if err != nil || archive == "" {
+		t.Fatalf("Compact = %q, %v", archive, err)
+	}
+
+	dest := filepath.Join(t.TempDir(), "archive")
+	if _, err := mgr.ArchiveHistory(dest); err != nil {
+		t.Fatalf("ArchiveHistory: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "mq-archive", filepath.Base(archive))); err != nil {
+		t.Errorf("compacted events not archived: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, ".beads", "mq_events.jsonl.anchor")); err != nil {
+		t.Errorf("chain anchor not archived: %v", err)
+	}
+}
@@ -0,0 +1,232 @@
+package refinery
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TokenScope limits what an API token may do. Scopes are roles, each
+// allowing everything the one before it does: viewer (read), operator
+// (operate), admin.
+type TokenScope string
+
+const (
+	// ScopeRead allows read-only endpoints (status, queue, history, streams).
+	ScopeRead TokenScope = "read"
+
+	// ScopeOperate allows everything ScopeRead does plus mutations
+	// (enqueue, hold, requeue, pause, resume).
+	ScopeOperate TokenScope = "operate"
+
+	// ScopeAdmin allows everything ScopeOperate does plus operations that
+	// bypass the refinery's gates or discard work (approve, drop).
+	ScopeAdmin TokenScope = "admin"
+)
+
+// tokenPrefix marks refinery API secrets so they are recognizable in configs.
+const tokenPrefix = "gtr_"
+
+var (
+	ErrTokenExists   = errors.New("token already exists")
+	ErrTokenNotFound = errors.New("token not found")
+	ErrInvalidScope  = errors.New("invalid token scope (use read, operate, or admin)")
+)
+
+// Allows reports whether a token with scope s may perform an action requiring want.
+func (s TokenScope) Allows(want TokenScope) bool {
+	have, need := s.rank(), want.rank()
+	return have > 0 && need > 0 && have >= need
+}
+
+// rank orders scopes by what they allow; unknown scopes rank 0.
+func (s TokenScope) rank() int {
+	switch s {
+	case ScopeRead:
+		return 1
+	case ScopeOperate:
+		return 2
+	case ScopeAdmin:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// ParseTokenScope validates a scope name. The role names viewer (or
+// observer) and operator are accepted for read and operate.
+func ParseTokenScope(name string) (TokenScope, error) {
+	switch name {
+	case "viewer", "observer":
+		return ScopeRead, nil
+	case "operator":
+		return ScopeOperate, nil
+	}
+	switch TokenScope(name) {
+	case ScopeRead, ScopeOperate, ScopeAdmin:
+		return TokenScope(name), nil
+	default:
+		return "", ErrInvalidScope
+	}
+}
+
+// APIToken is a named API credential. Only the SHA-256 hash of the secret
+// is stored; the secret itself is shown once at creation.
+type APIToken struct {
+	Name      string     `json:"name"`
+	Scope     TokenScope `json:"scope"`
+	Hash      string     `json:"hash"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TokenStore persists API tokens in <rig>/.runtime/api-tokens.json.
+type TokenStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewTokenStore creates a token store for the given rig path.
+func NewTokenStore(rigPath string) *TokenStore {
+	return &TokenStore{path: filepath.Join(rigPath, ".runtime", "api-tokens.json")}
+}
+
+// Create issues a new token and returns its secret.
+func (s *TokenStore) Create(name string, scope TokenScope) (string, *APIToken, error) {
+	scope, err := ParseTokenScope(string(scope))
+	if err != nil {
+		return "", nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.load()
+	if err != nil {
+		return "", nil, err
+	}
+	for _, t := range tokens {
+		if t.Name == name {
+			return "", nil, fmt.Errorf("%w: %s", ErrTokenExists, name)
+		}
+	}
+
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, fmt.Errorf("generating token: %w", err)
+	}
+	secret := tokenPrefix + hex.EncodeToString(b)
+
+	token := APIToken{
+		Name:      name,
+		Scope:     scope,
+		Hash:      hashToken(secret),
+		CreatedAt: time.Now(),
+	}
+	tokens = append(tokens, token)
+	if err := s.save(tokens); err != nil {
+		return "", nil, err
+	}
+	return secret, &token, nil
+}
+
+// List returns all tokens sorted by name.
+func (s *TokenStore) List() ([]APIToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].Name < tokens[j].Name })
+	return tokens, nil
+}
+
+// Revoke deletes a token by name.
+func (s *TokenStore) Revoke(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.load()
+	if err != nil {
+		return err
+	}
+	for i, t := range tokens {
+		if t.Name == name {
+			return s.save(append(tokens[:i], tokens[i+1:]...))
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrTokenNotFound, name)
+}
+
+// Authenticate returns the token matching secret, if any.
+func (s *TokenStore) Authenticate(secret string) (*APIToken, bool) {
+	if secret == "" {
+		return nil, false
+	}
+	tokens, err := s.List()
+	if err != nil {
+		return nil, false
+	}
+	hash := hashToken(secret)
+	for i := range tokens {
+		if subtle.ConstantTimeCompare([]byte(tokens[i].Hash), []byte(hash)) == 1 {
+			return &tokens[i], true
+		}
+	}
+	return nil, false
+}
+
+// Empty reports whether no tokens have been issued.
+func (s *TokenStore) Empty() bool {
+	tokens, err := s.List()
+	return err == nil && len(tokens) == 0
+}
+
+func (s *TokenStore) load() ([]APIToken, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading tokens: %w", err)
+	}
+	var tokens []APIToken
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", s.path, err)
+	}
+	return tokens, nil
+}
+
+func (s *TokenStore) save(tokens []APIToken) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	if tokens == nil {
+		tokens = []APIToken{}
+	}
+	data, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Hashes only, but still credentials-adjacent: keep the file private.
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,124 @@
+package refinery
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestTokenStore(t *testing.T) {
+	store := NewTokenStore(t.TempDir())
+
+	if !store.Empty() {
+		t.Fatal("new store should be empty")
+	}
+
+	secret, token, err := store.Create("dashboard", ScopeRead)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if !strings.HasPrefix(secret, tokenPrefix) || strings.Contains(token.Hash, secret) {
+		t.Errorf("unexpected secret/hash: %q / %q", secret, token.Hash)
+	}
+	if _, _, err := store.Create("dashboard", ScopeRead); !errors.Is(err, ErrTokenExists) {
+		t.Errorf("duplicate Create = %v, want ErrTokenExists", err)
+	}
+	if _, _, err := store.Create("bad", TokenScope("root")); !errors.Is(err, ErrInvalidScope) {
+		t.Errorf("Create with bad scope = %v, want ErrInvalidScope", err)
+	}
+
+	data, _ := os.ReadFile(store.path)
+	if strings.Contains(string(data), secret) {
+		t.Error("token file must not contain the secret")
+	}
+
+	if got, ok := store.Authenticate(secret); !ok || got.Name != "dashboard" {
+		t.Errorf("Authenticate(secret) = %v, %v", got, ok)
+	}
+	if _, ok := store.Authenticate("gtr_wrong"); ok {
+		t.Error("Authenticate accepted a wrong secret")
+	}
+
+	if err := store.Revoke("dashboard"); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if _, ok := store.Authenticate(secret); ok {
+		t.Error("revoked token still authenticates")
+	}
+	if err := store.Revoke("dashboard"); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("second Revoke = %v, want ErrTokenNotFound", err)
+	}
+}
+
+func TestTokenScope_Allows(t *testing.T) {
+	tests := []struct {
+		have, want TokenScope
+		ok         bool
+	}{
+		{ScopeRead, ScopeRead, true},
+		{ScopeRead, ScopeOperate, false},
+		{ScopeOperate, ScopeRead, true},
+		{ScopeOperate, ScopeAdmin, false},
+		{ScopeAdmin, ScopeOperate, true},
+		{ScopeAdmin, ScopeAdmin, true},
+		{TokenScope("root"), ScopeRead, false},
+		{ScopeAdmin, TokenScope("root"), false},
+	}
+	for _, tt := range tests {
+		if got := tt.have.Allows(tt.want); got != tt.ok {
+			t.Errorf("%s.Allows(%s) = %v, want %v", tt.have, tt.want, got, tt.ok)
+		}
+	}
+
+	for name, want := range map[string]TokenScope{"viewer": ScopeRead, "observer": ScopeRead, "operator": ScopeOperate, "admin": ScopeAdmin} {
+		if got, err := ParseTokenScope(name); err != nil || got != want {
+			t.Errorf("ParseTokenScope(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+}
+
+func TestServer_Auth(t *testing.T) {
+	srv := newTestServer(t)
+
+	// No tokens issued: reads are open, mutations are not.
+	if w := doRequest(t, srv, "", "GET", "/api/queue", ""); w.Code != http.StatusOK {
+		t.Errorf("open read = %d, want 200", w.Code)
+	}
+	if w := doRequest(t, srv, "", "POST", "/api/queue", `{"branch":"b"}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous mutation = %d, want 401", w.Code)
+	}
+
+	reader := newToken(t, srv, "viewer", ScopeRead)
+	operator := newToken(t, srv, "ops", ScopeOperate)
+	admin := newToken(t, srv, "mayor", ScopeAdmin)
+
+	tests := []struct {
+		name, token, method, path, body string
+		want                            int
+	}{
+		{"anonymous read once tokens exist", "", "GET", "/api/queue", "", http.StatusUnauthorized},
+		{"bad token", "gtr_nope", "GET", "/api/queue", "", http.StatusUnauthorized},
+		{"read token reads", reader, "GET", "/api/queue", "", http.StatusOK},
+		{"read token cannot mutate", reader, "POST", "/api/queue", `{"branch":"b"}`, http.StatusForbidden},
+		{"operate token mutates", operator, "POST", "/api/queue", `{"branch":"b"}`, http.StatusCreated},
+		{"operate token cannot approve", operator, "POST", "/api/queue/mr-missing/approve", "", http.StatusForbidden},
+		{"operate token cannot drop", operator, "DELETE", "/api/queue/mr-missing", "", http.StatusForbidden},
+		{"admin token reads", admin, "GET", "/api/queue", "", http.StatusOK},
+		{"admin token drops", admin, "DELETE", "/api/queue/mr-missing", "", http.StatusNotFound},
+		{"dashboard is public", "", "GET", "/", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := doRequest(t, srv, tt.token, tt.method, tt.path, tt.body); w.Code != tt.want {
+				t.Errorf("%s %s = %d, want %d", tt.method, tt.path, w.Code, tt.want)
+			}
+		})
+	}
+
+	// EventSource clients pass the token as a query parameter.
+	if w := doRequest(t, srv, "", "GET", "/api/status?token="+reader, ""); w.Code != http.StatusOK {
+		t.Errorf("query token = %d, want 200", w.Code)
+	}
+}
@@ -0,0 +1,134 @@
+package refinery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// TriageContext is written to $GT_TRIAGE_CONTEXT for the triage agent.
+type TriageContext struct {
+	*MergeFeedback
+	Worker string   `json:"worker,omitempty"`
+	Files  []string `json:"files"` // Files the branch changes
+}
+
+// TriageReport is what the triage agent writes to $GT_TRIAGE_RESULT.
+type TriageReport struct {
+	Classification string `json:"classification"` // config.TriageFlaky, TriageRegression, or TriageEnvironment
+	Diagnosis      string `json:"diagnosis,omitempty"`
+}
+
+func (r *TriageReport) String() string {
+	if r.Diagnosis == "" {
+		return r.Classification
+	}
+	return r.Classification + ": " + r.Diagnosis
+}
+
+// triageFailure has the rig's triage agent, if any, diagnose a failed
+// check, and attaches the diagnosis to fb and to the queued MR. A triage
+// that fails is reported and otherwise ignored.
+func (e *Engineer) triageFailure(ctx context.Context, mr *mrqueue.MR, result ProcessResult, fb *MergeFeedback) {
+	var diagnosis string
+	if e.settings != nil && e.settings.Triage != nil && result.TestsFailed && result.FailedCheck != reviewCheck {
+		report, err := e.triage(ctx, mr, fb)
+		if err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: triaging %s: %v\n", mr.ID, err)
+		} else {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Triage: %s\n", report)
+			fb.Triage, diagnosis = report, report.String()
+		}
+	}
+	// An earlier failure's diagnosis does not describe this one
+	if diagnosis == mr.Triage {
+		return
+	}
+	if err := e.mrQueue.SetTriage(mr.ID, diagnosis); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to record triage on %s: %v\n", mr.ID, err)
+	}
+	mr.Triage = diagnosis
+}
+
+// triage runs the triage agent over fb and the branch's diff.
+func (e *Engineer) triage(ctx context.Context, mr *mrqueue.MR, fb *MergeFeedback) (*TriageReport, error) {
+	tc := e.settings.Triage
+	base := "origin/" + mr.Target
+	diff, err := e.git.Diff(base, mr.Branch)
+	if err != nil {
+		return nil, err
+	}
+	files, err := e.git.ChangedFiles(base, mr.Branch)
+	if err != nil {
+		return nil, err
+	}
+
+	runtimeDir := filepath.Join(e.rig.Path, ".runtime")
+	if err := os.MkdirAll(runtimeDir, 0755); err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp(runtimeDir, "triage-")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	diffFile := filepath.Join(dir, "diff.patch")
+	contextFile := filepath.Join(dir, "context.json")
+	resultFile := filepath.Join(dir, "result.json")
+	data, err := json.MarshalIndent(TriageContext{MergeFeedback: fb, Worker: mr.Worker, Files: files}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(contextFile, data, 0644); err != nil { //nolint:gosec // G306: not sensitive
+		return nil, err
+	}
+	if err := os.WriteFile(diffFile, []byte(diff+"\n"), 0644); err != nil { //nolint:gosec // G306: not sensitive
+		return nil, err
+	}
+
+	env, err := e.processEnv()
+	if err != nil {
+		return nil, err
+	}
+	env = append(env,
+		"GT_TRIAGE_CONTEXT="+contextFile,
+		"GT_TRIAGE_DIFF="+diffFile,
+		"GT_TRIAGE_RESULT="+resultFile,
+		"GT_MR_ID="+mr.ID,
+		"GT_BRANCH="+mr.Branch,
+		"GT_TARGET="+mr.Target,
+	)
+	if _, err := e.runAgent(ctx, "triage agent", tc.Command, tc.TimeoutDuration(), env); err != nil {
+		return nil, err
+	}
+	return loadTriageReport(resultFile)
+}
+
+// loadTriageReport reads the triage agent's report.
+func loadTriageReport(p string) (*TriageReport, error) {
+	data, err := os.ReadFile(p) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("triage agent wrote no report")
+		}
+		return nil, err
+	}
+	var r TriageReport
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parsing triage report: %w", err)
+	}
+	switch r.Classification {
+	case config.TriageFlaky, config.TriageRegression, config.TriageEnvironment:
+	default:
+		return nil, fmt.Errorf("triage agent reported classification %q, want %q, %q, or %q",
+			r.Classification, config.TriageFlaky, config.TriageRegression, config.TriageEnvironment)
+	}
+	r.Diagnosis = util.Redact(r.Diagnosis)
+	return &r, nil
+}
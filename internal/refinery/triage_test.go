@@ -0,0 +1,46 @@
+package refinery
+
+import (
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+func TestTriageFailure(t *testing.T) {
+	e, first, _, _, _ := setupPipelineRig(t)
+	// Classifies the failure from the check output and the diff it is given.
+	e.settings.Triage = &config.TriageConfig{Command: `
+if grep -q "connection refused" "$GT_TRIAGE_CONTEXT" && grep -q polecat-nux-gt-1 "$GT_TRIAGE_DIFF"; then
+  echo '{"classification": "environment", "diagnosis": "database was down"}' > "$GT_TRIAGE_RESULT"
+fi`}
+
+	failed := ProcessResult{TestsFailed: true, FailedCheck: "test", CheckOutput: "dial tcp: connection refused"}
+	fb := e.newMergeFeedback(first, failed)
+	e.triageFailure(t.Context(), first, failed, fb)
+	if fb.Triage == nil || fb.Triage.Classification != config.TriageEnvironment {
+		t.Fatalf("Triage = %+v, want environment", fb.Triage)
+	}
+	got, _ := e.mrQueue.Get(first.ID)
+	if got.Triage != "environment: database was down" {
+		t.Errorf("queued MR Triage = %q", got.Triage)
+	}
+
+	// A conflict is not triaged, and clears the earlier diagnosis.
+	conflict := ProcessResult{Conflict: true}
+	fb = e.newMergeFeedback(first, conflict)
+	e.triageFailure(t.Context(), first, conflict, fb)
+	if fb.Triage != nil {
+		t.Errorf("conflict triaged: %+v", fb.Triage)
+	}
+	if got, _ := e.mrQueue.Get(first.ID); got.Triage != "" {
+		t.Errorf("queued MR Triage = %q after a conflict, want it cleared", got.Triage)
+	}
+
+	// No report is no diagnosis.
+	other := ProcessResult{TestsFailed: true, FailedCheck: "test", CheckOutput: "assertion failed"}
+	fb = e.newMergeFeedback(first, other)
+	e.triageFailure(t.Context(), first, other, fb)
+	if fb.Triage != nil {
+		t.Errorf("Triage = %+v without a report", fb.Triage)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/steveyegge/gastown/internal/agent"
+	"github.com/steveyegge/gastown/internal/rig"
 )
 
 // State is an alias for agent.State for backwards compatibility.
@@ -33,6 +34,10 @@ type Refinery struct {
 	// StartedAt is when the refinery was started.
 	StartedAt *time.Time `json:"started_at,omitempty"`
 
+	// PausedUntil is when a timed pause ends and processing resumes on its
+	// own. Nil for a pause that lasts until Resume.
+	PausedUntil *time.Time `json:"paused_until,omitempty"`
+
 	// CurrentMR is the merge request currently being processed.
 	CurrentMR *MergeRequest `json:"current_mr,omitempty"`
 
@@ -42,6 +47,10 @@ type Refinery struct {
 
 	// LastMergeAt is when the last successful merge happened.
 	LastMergeAt *time.Time `json:"last_merge_at,omitempty"`
+
+	// RigPaused is set when the whole rig is paused ('gt rig pause').
+	// It is read from the rig's pause marker, not stored in refinery.json.
+	RigPaused *rig.PauseInfo `json:"rig_paused,omitempty"`
 }
 
 // MergeRequest represents a branch waiting to be merged.
@@ -147,9 +156,12 @@ func DefaultMergeConfig() MergeConfig {
 
 // QueueItem represents an item in the merge queue for display.
 type QueueItem struct {
-	Position  int       `json:"position"`
-	MR        *MergeRequest `json:"mr"`
-	Age       string    `json:"age"`
+	Position int           `json:"position"`
+	MR       *MergeRequest `json:"mr"`
+	Age      string        `json:"age"`
+
+	// WorkerLiveness is the heartbeat state of the MR's worker.
+	WorkerLiveness rig.WorkerLiveness `json:"worker_liveness"`
 }
 
 // State transition errors.
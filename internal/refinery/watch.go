@@ -0,0 +1,144 @@
+package refinery
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+var errStreamingUnsupported = errors.New("streaming unsupported by connection")
+
+// WatchInterval is how often streaming endpoints poll the queue and event log.
+var WatchInterval = time.Second
+
+// QueueChangeType describes how an MR changed between two queue snapshots.
+type QueueChangeType string
+
+const (
+	QueueAdded   QueueChangeType = "added"
+	QueueUpdated QueueChangeType = "updated"
+	QueueRemoved QueueChangeType = "removed"
+)
+
+// QueueChange is one entry in the queue watch stream.
+// For removals, MR holds the last known state of the MR.
+type QueueChange struct {
+	Type QueueChangeType `json:"type"`
+	MR   *mrqueue.MR     `json:"mr"`
+}
+
+// diffQueue returns the changes that turn prev into next.
+// Changes are reported in next's order, followed by removals.
+func diffQueue(prev, next []*mrqueue.MR) []QueueChange {
+	before := make(map[string]*mrqueue.MR, len(prev))
+	for _, mr := range prev {
+		before[mr.ID] = mr
+	}
+
+	var changes []QueueChange
+	seen := make(map[string]bool, len(next))
+	for _, mr := range next {
+		seen[mr.ID] = true
+		old, ok := before[mr.ID]
+		switch {
+		case !ok:
+			changes = append(changes, QueueChange{Type: QueueAdded, MR: mr})
+		case !reflect.DeepEqual(old, mr):
+			changes = append(changes, QueueChange{Type: QueueUpdated, MR: mr})
+		}
+	}
+	for _, mr := range prev {
+		if !seen[mr.ID] {
+			changes = append(changes, QueueChange{Type: QueueRemoved, MR: mr})
+		}
+	}
+	return changes
+}
+
+// handleQueueWatch streams QueueChange records as newline-delimited JSON.
+// The stream opens with an "added" change for every MR already queued.
+func (s *Server) handleQueueWatch(w http.ResponseWriter, r *http.Request) {
+	var prev []*mrqueue.MR
+	s.stream(w, r, "application/x-ndjson", writeNDJSON, func() ([]interface{}, error) {
+		next, err := s.queue.ListByScore()
+		if err != nil {
+			return nil, err
+		}
+		var out []interface{}
+		for _, c := range diffQueue(prev, next) {
+			out = append(out, c)
+		}
+		prev = next
+		return out, nil
+	})
+}
+
+// handleMergeWatch streams merge lifecycle events (started, merged, failed,
+// held, ...) as newline-delimited JSON, starting from the moment of the request.
+func (s *Server) handleMergeWatch(w http.ResponseWriter, r *http.Request) {
+	offset := s.events.Size()
+	s.stream(w, r, "application/x-ndjson", writeNDJSON, func() ([]interface{}, error) {
+		events, next, err := s.events.ReadEventsFrom(offset)
+		if err != nil {
+			return nil, err
+		}
+		offset = next
+		out := make([]interface{}, 0, len(events))
+		for _, e := range events {
+			out = append(out, e)
+		}
+		return out, nil
+	})
+}
+
+// stream polls next every WatchInterval and writes each returned record with
+// encode, flushing after every batch, until the client disconnects.
+func (s *Server) stream(w http.ResponseWriter, r *http.Request, contentType string,
+	encode func(w io.Writer, rec interface{}) error, next func() ([]interface{}, error)) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errStreamingUnsupported)
+		return
+	}
+
+	// Streams outlive the server's write timeout; lift it for this response.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(WatchInterval)
+	defer ticker.Stop()
+
+	for {
+		records, err := next()
+		if err == nil {
+			for _, rec := range records {
+				if err := encode(w, rec); err != nil {
+					return // Client went away
+				}
+			}
+			if len(records) > 0 {
+				flusher.Flush()
+			}
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// writeNDJSON encodes rec as a single JSON line.
+func writeNDJSON(w io.Writer, rec interface{}) error {
+	return json.NewEncoder(w).Encode(rec)
+}
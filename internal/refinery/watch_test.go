@@ -0,0 +1,141 @@
+package refinery
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+func TestDiffQueue(t *testing.T) {
+	a := &mrqueue.MR{ID: "a", Branch: "polecat/a"}
+	b := &mrqueue.MR{ID: "b", Branch: "polecat/b"}
+	b2 := &mrqueue.MR{ID: "b", Branch: "polecat/b", HeldReason: "frozen"}
+	c := &mrqueue.MR{ID: "c", Branch: "polecat/c"}
+
+	changes := diffQueue([]*mrqueue.MR{a, b}, []*mrqueue.MR{b2, c})
+	want := []struct {
+		typ QueueChangeType
+		id  string
+	}{
+		{QueueUpdated, "b"},
+		{QueueAdded, "c"},
+		{QueueRemoved, "a"},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("got %d changes, want %d: %+v", len(changes), len(want), changes)
+	}
+	for i, w := range want {
+		if changes[i].Type != w.typ || changes[i].MR.ID != w.id {
+			t.Errorf("change %d = %s %s, want %s %s", i, changes[i].Type, changes[i].MR.ID, w.typ, w.id)
+		}
+	}
+
+	if got := diffQueue([]*mrqueue.MR{a}, []*mrqueue.MR{a}); len(got) != 0 {
+		t.Errorf("identical snapshots produced changes: %+v", got)
+	}
+}
+
+func TestServer_QueueWatchStreams(t *testing.T) {
+	orig := WatchInterval
+	WatchInterval = 10 * time.Millisecond
+	defer func() { WatchInterval = orig }()
+
+	srv := newTestServer(t)
+	if err := srv.queue.Submit(&mrqueue.MR{ID: "mr-1", Branch: "polecat/nux"}); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", ts.URL+"/api/queue/watch", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET watch: %v", err)
+	}
+	defer resp.Body.Close()
+
+	lines := bufio.NewScanner(resp.Body)
+	next := func() QueueChange {
+		t.Helper()
+		if !lines.Scan() {
+			t.Fatalf("stream ended early: %v", lines.Err())
+		}
+		var c QueueChange
+		if err := json.Unmarshal(lines.Bytes(), &c); err != nil {
+			t.Fatalf("decoding change: %v", err)
+		}
+		return c
+	}
+
+	if c := next(); c.Type != QueueAdded || c.MR.ID != "mr-1" {
+		t.Errorf("first change = %+v, want added mr-1", c)
+	}
+
+	if err := srv.queue.Remove("mr-1"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if c := next(); c.Type != QueueRemoved || c.MR.ID != "mr-1" {
+		t.Errorf("second change = %+v, want removed mr-1", c)
+	}
+}
+
+func TestServer_EventsSSE(t *testing.T) {
+	orig := WatchInterval
+	WatchInterval = 10 * time.Millisecond
+	defer func() { WatchInterval = orig }()
+
+	srv := newTestServer(t)
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", ts.URL+"/events", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /events: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+
+	lines := bufio.NewScanner(resp.Body)
+	nextEvent := func() string {
+		t.Helper()
+		for lines.Scan() {
+			if name, ok := strings.CutPrefix(lines.Text(), "event: "); ok {
+				return name
+			}
+		}
+		t.Fatalf("stream ended early: %v", lines.Err())
+		return ""
+	}
+
+	if got := nextEvent(); got != SSEEventStatus {
+		t.Fatalf("first event = %q, want %q", got, SSEEventStatus)
+	}
+
+	mr := &mrqueue.MR{ID: "mr-1", Branch: "polecat/nux"}
+	if err := srv.queue.Submit(mr); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if got := nextEvent(); got != SSEEventQueue {
+		t.Errorf("after submit got %q, want %q", got, SSEEventQueue)
+	}
+
+	_ = srv.events.LogMerged(mr, "abc123")
+	if got := nextEvent(); got != string(mrqueue.EventMerged) {
+		t.Errorf("after merge got %q, want %q", got, mrqueue.EventMerged)
+	}
+}
@@ -0,0 +1,96 @@
+package refinery
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+// workerLiveness looks up the liveness of the worker named on an MR.
+// Worker fields may be a bare polecat name or an address such as
+// "greenplace/polecats/nux"; the last segment is the registered name.
+func workerLiveness(live map[string]rig.WorkerLiveness, worker string) rig.WorkerLiveness {
+	if worker == "" {
+		return rig.WorkerUnknown
+	}
+	if l, ok := live[path.Base(worker)]; ok {
+		return l
+	}
+	return rig.WorkerUnknown
+}
+
+// deprioritizeDeadWorkers moves MRs from dead workers behind the rest,
+// keeping score order within each group. Nobody is left to fix a branch
+// whose worker is gone, so it should not block live work.
+func deprioritizeDeadWorkers(mrs []*mrqueue.MR, live map[string]rig.WorkerLiveness) {
+	sort.SliceStable(mrs, func(i, j int) bool {
+		di := workerLiveness(live, mrs[i].Worker) == rig.WorkerDead
+		dj := workerLiveness(live, mrs[j].Worker) == rig.WorkerDead
+		return !di && dj
+	})
+}
+
+// gateUntrustedWorkers parks MRs whose worker refinery.workers does not
+// trust, returning the MRs that may merge now. Parked MRs wait in the queue
+// until 'gt refinery approve'; an approval sticks across retries. Swarm
+// landings and backports the refinery queued itself have no worker to
+// trust and pass.
+func (e *Engineer) gateUntrustedWorkers(mrs []*mrqueue.MR) []*mrqueue.MR {
+	ready := mrs[:0]
+	for _, mr := range mrs {
+		reason := e.untrustedWorker(mr)
+		if reason == "" {
+			ready = append(ready, mr)
+			continue
+		}
+		if err := e.mrQueue.RequireApproval(mr.ID, reason); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to mark %s as needing approval: %v\n", mr.ID, err)
+			continue
+		}
+		if err := e.eventLogger.LogApprovalRequired(mr, reason); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to log approval_required event: %v\n", err)
+		}
+		_, _ = fmt.Fprintf(e.output, "[Engineer] %s needs approval: %s\n", mr.ID, reason)
+	}
+	return ready
+}
+
+// untrustedWorker returns why mr's worker needs an operator's approval to
+// merge, or "" if it does not.
+func (e *Engineer) untrustedWorker(mr *mrqueue.MR) string {
+	if mr.IsApproved() || queuedItself(mr) {
+		return ""
+	}
+	if ok, reason := e.settings.WorkerApproval(mr.Worker); !ok {
+		return reason
+	}
+	return ""
+}
+
+// backlogBusyWorkers splits score-ordered mrs into those within each
+// worker's cap of max ready MRs and the backlog beyond it, so one worker
+// pushing dozens of branches cannot crowd out the rest of the fleet. MRs
+// without a worker are never backlogged; max <= 0 disables the cap.
+func backlogBusyWorkers(mrs []*mrqueue.MR, max int) (ready, backlog []*mrqueue.MR) {
+	if max <= 0 {
+		return mrs, nil
+	}
+	counts := make(map[string]int)
+	for _, mr := range mrs {
+		if mr.Worker == "" {
+			ready = append(ready, mr)
+			continue
+		}
+		w := path.Base(mr.Worker)
+		if counts[w] >= max {
+			backlog = append(backlog, mr)
+			continue
+		}
+		counts[w]++
+		ready = append(ready, mr)
+	}
+	return ready, backlog
+}
@@ -0,0 +1,120 @@
+package refinery
+
+import (
+	"io"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func TestDeprioritizeDeadWorkers(t *testing.T) {
+	live := map[string]rig.WorkerLiveness{
+		"nux":   rig.WorkerDead,
+		"toast": rig.WorkerAlive,
+		"ace":   rig.WorkerStale,
+	}
+	mrs := []*mrqueue.MR{
+		{ID: "a", Worker: "nux"},
+		{ID: "b", Worker: "greenplace/polecats/toast"},
+		{ID: "c", Worker: "greenplace/nux"},
+		{ID: "d", Worker: "ace"},
+		{ID: "e", Worker: ""},
+	}
+
+	deprioritizeDeadWorkers(mrs, live)
+
+	var got string
+	for _, mr := range mrs {
+		got += mr.ID
+	}
+	if got != "bdeac" {
+		t.Errorf("order = %s, want bdeac (live in score order, then dead)", got)
+	}
+	if l := workerLiveness(live, "someone"); l != rig.WorkerUnknown {
+		t.Errorf("unregistered worker = %s, want unknown", l)
+	}
+}
+
+func TestGateUntrustedWorkers(t *testing.T) {
+	rigPath := t.TempDir()
+	q := mrqueue.New(rigPath)
+	e := &Engineer{
+		mrQueue:     q,
+		eventLogger: mrqueue.NewEventLoggerFromRig(rigPath),
+		output:      io.Discard,
+		settings:    &config.RefinerySettings{Workers: &config.WorkerPolicyConfig{Allow: []string{"nux"}}},
+	}
+	for _, mr := range []*mrqueue.MR{
+		{ID: "mr-nux", Worker: "nux"},
+		{ID: "mr-toast", Worker: "toast"},
+		{ID: "mr-approved", Worker: "toast", ApprovedBy: "mayor"},
+	} {
+		if err := q.Submit(mr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mrs, err := q.ListReady(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if admitted := e.admitGated(mrs); len(admitted) != 2 {
+		t.Errorf("admitGated = %d MRs, want 2", len(admitted))
+	}
+	if listed, _ := q.Get("mr-toast"); listed.NeedsApproval() {
+		t.Error("admitGated parked mr-toast; only the processing pass may")
+	}
+
+	mrs, err = q.ListReady(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ready := e.gateUntrustedWorkers(mrs)
+
+	var got []string
+	for _, mr := range ready {
+		got = append(got, mr.ID)
+	}
+	if len(got) != 2 || got[0] == "mr-toast" || got[1] == "mr-toast" {
+		t.Errorf("ready = %v, want mr-nux and mr-approved", got)
+	}
+	parked, _ := q.Get("mr-toast")
+	if !parked.NeedsApproval() {
+		t.Errorf("mr-toast = %+v, want it waiting for approval", parked)
+	}
+	if again, _ := q.ListReady(nil); len(again) != 2 {
+		t.Errorf("ListReady after gating = %d MRs, want 2", len(again))
+	}
+}
+
+func TestBacklogBusyWorkers(t *testing.T) {
+	mrs := []*mrqueue.MR{
+		{ID: "a", Worker: "nux"},
+		{ID: "b", Worker: "greenplace/polecats/nux"},
+		{ID: "c", Worker: "toast"},
+		{ID: "d", Worker: "nux"},
+		{ID: "e", Worker: ""},
+		{ID: "f", Worker: ""},
+	}
+	ids := func(mrs []*mrqueue.MR) string {
+		var s string
+		for _, mr := range mrs {
+			s += mr.ID
+		}
+		return s
+	}
+
+	ready, backlog := backlogBusyWorkers(mrs, 1)
+	if ids(ready) != "acef" || ids(backlog) != "bd" {
+		t.Errorf("max 1: ready %s, backlog %s; want acef, bd", ids(ready), ids(backlog))
+	}
+	ready, backlog = backlogBusyWorkers(mrs, 2)
+	if ids(ready) != "abcef" || ids(backlog) != "d" {
+		t.Errorf("max 2: ready %s, backlog %s; want abcef, d", ids(ready), ids(backlog))
+	}
+	if ready, backlog = backlogBusyWorkers(mrs, 0); len(ready) != len(mrs) || len(backlog) != 0 {
+		t.Error("max 0 should not backlog anything")
+	}
+}
@@ -0,0 +1,266 @@
+package rig
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/lock"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// HealthStatus is the outcome of one health check.
+type HealthStatus string
+
+// Health statuses, in increasing severity.
+const (
+	HealthOK      HealthStatus = "ok"
+	HealthWarning HealthStatus = "warning"
+	HealthError   HealthStatus = "error"
+)
+
+func (s HealthStatus) severity() int {
+	switch s {
+	case HealthWarning:
+		return 1
+	case HealthError:
+		return 2
+	}
+	return 0
+}
+
+// HealthFinding is one check's result.
+type HealthFinding struct {
+	Check   string       `json:"check"`
+	Status  HealthStatus `json:"status"`
+	Message string       `json:"message"`
+}
+
+// HealthReport is the latest evaluation of a rig, stored in
+// .runtime/health.json so status commands can show it without re-running.
+type HealthReport struct {
+	CheckedAt time.Time       `json:"checked_at"`
+	Findings  []HealthFinding `json:"findings"`
+}
+
+// Worst returns the most severe status in the report.
+func (r *HealthReport) Worst() HealthStatus {
+	worst := HealthOK
+	for _, f := range r.Findings {
+		if f.Status.severity() > worst.severity() {
+			worst = f.Status
+		}
+	}
+	return worst
+}
+
+// Problems returns the findings that are not ok.
+func (r *HealthReport) Problems() []HealthFinding {
+	var out []HealthFinding
+	for _, f := range r.Findings {
+		if f.Status != HealthOK {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// NewProblems returns findings that are failing in r but were ok (or
+// absent) in prev — the ones worth notifying about.
+func (r *HealthReport) NewProblems(prev *HealthReport) []HealthFinding {
+	was := make(map[string]HealthStatus)
+	if prev != nil {
+		for _, f := range prev.Findings {
+			was[f.Check] = f.Status
+		}
+	}
+	var out []HealthFinding
+	for _, f := range r.Problems() {
+		if f.Status.severity() > was[f.Check].severity() {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// HealthFile returns the path of a rig's stored health report.
+func HealthFile(rigPath string) string {
+	return filepath.Join(rigPath, ".runtime", "health.json")
+}
+
+// LoadHealth returns the stored report, or nil if the rig was never evaluated.
+func LoadHealth(rigPath string) (*HealthReport, error) {
+	data, err := os.ReadFile(HealthFile(rigPath)) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var r HealthReport
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// SaveHealth stores a report as the rig's latest.
+func SaveHealth(rigPath string, r *HealthReport) error {
+	if err := os.MkdirAll(filepath.Dir(HealthFile(rigPath)), 0755); err != nil {
+		return err
+	}
+	return util.AtomicWriteJSON(HealthFile(rigPath), r)
+}
+
+// HealthEvaluator checks the environment a rig depends on: disk space for
+// worktrees, remote reachability, clock skew, and stale agent locks.
+type HealthEvaluator struct {
+	MinFreeWarn   uint64        // Free bytes below which disk is a warning
+	MinFreeError  uint64        // Free bytes below which disk is an error
+	RemoteTimeout time.Duration // Limit for ls-remote and the clock probe
+	MaxClockSkew  time.Duration // Skew against the remote's HTTP Date header
+}
+
+// NewHealthEvaluator returns an evaluator with default thresholds.
+func NewHealthEvaluator() *HealthEvaluator {
+	return &HealthEvaluator{
+		MinFreeWarn:   5 << 30,
+		MinFreeError:  1 << 30,
+		RemoteTimeout: 15 * time.Second,
+		MaxClockSkew:  time.Minute,
+	}
+}
+
+// Evaluate runs every check against the rig at rigPath.
+func (e *HealthEvaluator) Evaluate(ctx context.Context, rigPath string) *HealthReport {
+	report := &HealthReport{CheckedAt: time.Now()}
+	report.Findings = append(report.Findings,
+		e.checkDisk(rigPath),
+		e.checkRemote(ctx, rigPath),
+		e.checkClock(ctx, rigPath),
+		e.checkLocks(rigPath),
+	)
+	return report
+}
+
+func (e *HealthEvaluator) checkDisk(rigPath string) HealthFinding {
+	f := HealthFinding{Check: "disk-space", Status: HealthOK}
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(rigPath, &st); err != nil {
+		f.Status, f.Message = HealthWarning, fmt.Sprintf("cannot stat filesystem: %v", err)
+		return f
+	}
+	free := uint64(st.Bavail) * uint64(st.Bsize) //nolint:gosec,unconvert // G115: sizes are non-negative; field types vary by OS
+	f.Message = fmt.Sprintf("%.1f GiB free", float64(free)/(1<<30))
+	switch {
+	case free < e.MinFreeError:
+		f.Status = HealthError
+	case free < e.MinFreeWarn:
+		f.Status = HealthWarning
+	}
+	return f
+}
+
+// healthGitDir is the clone whose origin the remote checks use.
+func healthGitDir(rigPath string) string {
+	return filepath.Join(rigPath, "mayor", "rig")
+}
+
+func (e *HealthEvaluator) checkRemote(ctx context.Context, rigPath string) HealthFinding {
+	f := HealthFinding{Check: "remote-reachable", Status: HealthOK, Message: "origin reachable"}
+	ctx, cancel := context.WithTimeout(ctx, e.RemoteTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "-C", healthGitDir(rigPath), "ls-remote", "--heads", "origin") //nolint:gosec // G204: args are constructed internally
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if ctx.Err() == context.DeadlineExceeded {
+			msg = fmt.Sprintf("timed out after %s", e.RemoteTimeout)
+		} else if msg == "" {
+			msg = err.Error()
+		}
+		f.Status, f.Message = HealthError, "origin unreachable: "+msg
+	}
+	return f
+}
+
+// checkClock compares local time with the Date header of the remote's
+// HTTPS host. Remotes reached over ssh or the filesystem are not probed.
+func (e *HealthEvaluator) checkClock(ctx context.Context, rigPath string) HealthFinding {
+	f := HealthFinding{Check: "clock-skew", Status: HealthOK}
+
+	out, err := exec.Command("git", "-C", healthGitDir(rigPath), "remote", "get-url", "origin").Output() //nolint:gosec // G204: args are constructed internally
+	if err != nil {
+		f.Message = "skipped: no origin"
+		return f
+	}
+	u, err := url.Parse(strings.TrimSpace(string(out)))
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		f.Message = "skipped: origin is not an HTTP(S) remote"
+		return f
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.RemoteTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, u.Scheme+"://"+u.Host+"/", nil)
+	if err != nil {
+		f.Message = "skipped: " + err.Error()
+		return f
+	}
+	sent := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		f.Message = "skipped: " + err.Error() // Reachability is checked separately
+		return f
+	}
+	_ = resp.Body.Close()
+	remote, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		f.Message = "skipped: remote sent no Date header"
+		return f
+	}
+
+	// Date has one-second resolution; compare against the request midpoint
+	local := sent.Add(time.Since(sent) / 2)
+	skew := local.Sub(remote).Round(time.Second)
+	f.Message = fmt.Sprintf("skew %s against %s", skew, u.Host)
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > e.MaxClockSkew {
+		f.Status = HealthWarning
+	}
+	return f
+}
+
+func (e *HealthEvaluator) checkLocks(rigPath string) HealthFinding {
+	f := HealthFinding{Check: "stale-locks", Status: HealthOK, Message: "no stale agent locks"}
+	locks, err := lock.FindAllLocks(rigPath)
+	if err != nil {
+		f.Status, f.Message = HealthWarning, fmt.Sprintf("scanning locks: %v", err)
+		return f
+	}
+	var stale []string
+	for dir, info := range locks {
+		if info.IsStale() {
+			rel, _ := filepath.Rel(rigPath, dir)
+			stale = append(stale, rel)
+		}
+	}
+	if len(stale) > 0 {
+		f.Status = HealthWarning
+		f.Message = fmt.Sprintf("%d stale lock(s): %s (clean with 'gt agents fix')", len(stale), strings.Join(stale, ", "))
+	}
+	return f
+}
@@ -0,0 +1,103 @@
+package rig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHealthReport_NewProblems(t *testing.T) {
+	prev := &HealthReport{Findings: []HealthFinding{
+		{Check: "disk-space", Status: HealthWarning},
+		{Check: "remote-reachable", Status: HealthOK},
+		{Check: "stale-locks", Status: HealthWarning},
+	}}
+	cur := &HealthReport{Findings: []HealthFinding{
+		{Check: "disk-space", Status: HealthError},       // Escalated
+		{Check: "remote-reachable", Status: HealthError}, // Newly failing
+		{Check: "stale-locks", Status: HealthWarning},    // Unchanged
+		{Check: "clock-skew", Status: HealthOK},
+	}}
+
+	if got := cur.Worst(); got != HealthError {
+		t.Errorf("Worst = %s, want error", got)
+	}
+	if got := len(cur.Problems()); got != 3 {
+		t.Errorf("Problems = %d, want 3", got)
+	}
+
+	var checks []string
+	for _, f := range cur.NewProblems(prev) {
+		checks = append(checks, f.Check)
+	}
+	if got := strings.Join(checks, ","); got != "disk-space,remote-reachable" {
+		t.Errorf("NewProblems = %s, want disk-space,remote-reachable", got)
+	}
+	if got := len(cur.NewProblems(nil)); got != 3 {
+		t.Errorf("NewProblems(nil) = %d, want 3", got)
+	}
+}
+
+func TestHealth_SaveLoad(t *testing.T) {
+	rigPath := t.TempDir()
+
+	if r, err := LoadHealth(rigPath); r != nil || err != nil {
+		t.Fatalf("fresh rig LoadHealth = %+v, %v; want nil", r, err)
+	}
+
+	want := &HealthReport{
+		CheckedAt: time.Now().Truncate(time.Second),
+		Findings:  []HealthFinding{{Check: "disk-space", Status: HealthWarning, Message: "2.0 GiB free"}},
+	}
+	if err := SaveHealth(rigPath, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadHealth(rigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.CheckedAt.Equal(want.CheckedAt) || len(got.Findings) != 1 || got.Findings[0] != want.Findings[0] {
+		t.Errorf("LoadHealth = %+v, want %+v", got, want)
+	}
+}
+
+func TestHealthEvaluator_Checks(t *testing.T) {
+	rigPath := t.TempDir()
+	e := NewHealthEvaluator()
+
+	if f := e.checkLocks(rigPath); f.Status != HealthOK {
+		t.Errorf("checkLocks with no locks = %+v", f)
+	}
+
+	lockDir := filepath.Join(rigPath, "polecats", "toast", ".runtime")
+	if err := os.MkdirAll(lockDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	data := `{"pid": 2147483647, "acquired_at": "2026-01-01T00:00:00Z"}`
+	if err := os.WriteFile(filepath.Join(lockDir, "agent.lock"), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f := e.checkLocks(rigPath)
+	if f.Status != HealthWarning || !strings.Contains(f.Message, filepath.Join("polecats", "toast")) {
+		t.Errorf("checkLocks with dead PID = %+v, want warning naming polecats/toast", f)
+	}
+
+	e.MinFreeWarn, e.MinFreeError = 0, 0
+	if f := e.checkDisk(rigPath); f.Status != HealthOK {
+		t.Errorf("checkDisk with zero thresholds = %+v", f)
+	}
+	e.MinFreeError = ^uint64(0)
+	if f := e.checkDisk(rigPath); f.Status != HealthError {
+		t.Errorf("checkDisk with impossible threshold = %+v, want error", f)
+	}
+
+	// No mayor clone: the remote is unreachable and the clock probe skips.
+	if f := e.checkRemote(t.Context(), rigPath); f.Status != HealthError {
+		t.Errorf("checkRemote without a clone = %+v, want error", f)
+	}
+	if f := e.checkClock(t.Context(), rigPath); f.Status != HealthOK || !strings.HasPrefix(f.Message, "skipped") {
+		t.Errorf("checkClock without a clone = %+v, want skipped", f)
+	}
+}
@@ -164,6 +164,7 @@ type AddRigOptions struct {
 	BeadsPrefix   string // Beads issue prefix (defaults to derived from name)
 	LocalRepo     string // Optional local repo for reference clones
 	DefaultBranch string // Default branch (defaults to auto-detected from remote)
+	Template      string // Optional settings/rig.toml preset (see config.RigTemplateNames)
 }
 
 func resolveLocalRepo(path, gitURL string) (string, string) {
@@ -221,6 +222,10 @@ func (m *Manager) AddRig(opts AddRigOptions) (*Rig, error) {
 		return nil, fmt.Errorf("rig name %q contains invalid characters; hyphens, dots, and spaces are reserved for agent ID parsing. Try %q instead (underscores are allowed)", opts.Name, sanitized)
 	}
 
+	if opts.Template != "" && !config.IsRigTemplate(opts.Template) {
+		return nil, fmt.Errorf("unknown rig template %q (available: %s)", opts.Template, strings.Join(config.RigTemplateNames(), ", "))
+	}
+
 	rigPath := filepath.Join(m.townRoot, opts.Name)
 
 	// Check if directory already exists
@@ -483,6 +488,14 @@ Use crew for your own workspace. Polecats are for batch work dispatch.
 		fmt.Fprintf(os.Stderr, "  Warning: Could not create plugin directories: %v\n", err)
 	}
 
+	// Write the refinery preset, if one was requested
+	if opts.Template != "" {
+		if err := config.WriteRigTemplate(rigPath, opts.Template); err != nil {
+			return nil, fmt.Errorf("writing rig template: %w", err)
+		}
+		fmt.Printf("   ✓ Wrote settings/%s from template %s\n", config.RigFileName, opts.Template)
+	}
+
 	// Register in town config
 	m.config.Rigs[opts.Name] = config.RigEntry{
 		GitURL:    opts.GitURL,
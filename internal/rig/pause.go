@@ -0,0 +1,91 @@
+package rig
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// ErrPaused is returned by operations a rig-wide pause suspends, such as
+// accepting new merge requests.
+var ErrPaused = errors.New("rig is paused")
+
+// PauseInfo records a rig-wide pause. While it exists the refinery takes no
+// new work, enqueues are refused, and refinery notifications are held back.
+// Agents keep running; unlike parking, nothing is stopped.
+type PauseInfo struct {
+	Reason string    `json:"reason,omitempty"`
+	By     string    `json:"by,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// String describes the pause for status output.
+func (p *PauseInfo) String() string {
+	s := "paused"
+	if p.Reason != "" {
+		s += ": " + p.Reason
+	}
+	if p.By != "" {
+		s += " (by " + p.By + ")"
+	}
+	return s
+}
+
+// PauseFile returns the path of the rig-wide pause marker.
+func PauseFile(rigPath string) string {
+	return filepath.Join(rigPath, ".runtime", "paused.json")
+}
+
+// Pause marks the rig paused. Pausing an already-paused rig replaces the reason.
+func Pause(rigPath, reason, by string) error {
+	if err := os.MkdirAll(filepath.Dir(PauseFile(rigPath)), 0755); err != nil {
+		return err
+	}
+	return util.AtomicWriteJSON(PauseFile(rigPath), &PauseInfo{Reason: reason, By: by, At: time.Now()})
+}
+
+// Unpause clears the pause. Returns false if the rig was not paused.
+func Unpause(rigPath string) (bool, error) {
+	err := os.Remove(PauseFile(rigPath))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// LoadPause returns the rig's pause, or nil if it is not paused.
+func LoadPause(rigPath string) (*PauseInfo, error) {
+	data, err := os.ReadFile(PauseFile(rigPath)) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var p PauseInfo
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", PauseFile(rigPath), err)
+	}
+	return &p, nil
+}
+
+// CheckNotPaused returns an error wrapping ErrPaused, with the reason, if
+// the rig is paused. An unreadable marker counts as paused.
+func CheckNotPaused(rigPath string) error {
+	p, err := LoadPause(rigPath)
+	if err != nil {
+		return fmt.Errorf("%w (%v)", ErrPaused, err)
+	}
+	if p != nil {
+		if p.Reason != "" {
+			return fmt.Errorf("%w: %s", ErrPaused, p.Reason)
+		}
+		return ErrPaused
+	}
+	return nil
+}
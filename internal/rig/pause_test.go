@@ -0,0 +1,36 @@
+package rig
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPause(t *testing.T) {
+	rigPath := t.TempDir()
+
+	if p, err := LoadPause(rigPath); p != nil || err != nil {
+		t.Fatalf("fresh rig LoadPause = %+v, %v; want nil", p, err)
+	}
+	if err := CheckNotPaused(rigPath); err != nil {
+		t.Errorf("fresh rig CheckNotPaused = %v", err)
+	}
+
+	if err := Pause(rigPath, "release freeze", "mayor/"); err != nil {
+		t.Fatal(err)
+	}
+	err := CheckNotPaused(rigPath)
+	if !errors.Is(err, ErrPaused) || !strings.Contains(err.Error(), "release freeze") {
+		t.Errorf("CheckNotPaused = %v, want ErrPaused with reason", err)
+	}
+	if p, _ := LoadPause(rigPath); p == nil || p.String() != "paused: release freeze (by mayor/)" {
+		t.Errorf("LoadPause = %v", p)
+	}
+
+	if ok, err := Unpause(rigPath); !ok || err != nil {
+		t.Errorf("Unpause = %v, %v", ok, err)
+	}
+	if ok, _ := Unpause(rigPath); ok {
+		t.Error("second Unpause reported a change")
+	}
+}
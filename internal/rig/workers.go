@@ -0,0 +1,151 @@
+package rig
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// Worker liveness thresholds. A heartbeat is sent at least every agent turn,
+// but a single turn can run long builds, so the margins are generous.
+const (
+	WorkerStaleAfter = 10 * time.Minute
+	WorkerDeadAfter  = time.Hour
+)
+
+// WorkerLiveness classifies a worker by the age of its last heartbeat.
+type WorkerLiveness string
+
+// Worker liveness values.
+const (
+	WorkerAlive   WorkerLiveness = "alive"
+	WorkerStale   WorkerLiveness = "stale"
+	WorkerDead    WorkerLiveness = "dead"
+	WorkerUnknown WorkerLiveness = "unknown" // Never registered
+)
+
+// WorkerInfo is a worker's registration with its rig, kept in
+// .runtime/workers/<name>.json and refreshed by heartbeats.
+type WorkerInfo struct {
+	Name         string    `json:"name"`
+	Session      string    `json:"session,omitempty"`
+	RegisteredAt time.Time `json:"registered_at"`
+	LastSeen     time.Time `json:"last_seen"`
+}
+
+// LivenessAt classifies the worker at time now.
+func (w *WorkerInfo) LivenessAt(now time.Time) WorkerLiveness {
+	switch age := now.Sub(w.LastSeen); {
+	case age >= WorkerDeadAfter:
+		return WorkerDead
+	case age >= WorkerStaleAfter:
+		return WorkerStale
+	}
+	return WorkerAlive
+}
+
+// WorkersDir returns the directory holding a rig's worker registrations.
+func WorkersDir(rigPath string) string {
+	return filepath.Join(rigPath, ".runtime", "workers")
+}
+
+func workerFile(rigPath, name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid worker name %q", name)
+	}
+	return filepath.Join(WorkersDir(rigPath), name+".json"), nil
+}
+
+// RegisterWorker records a worker as started, replacing any earlier
+// registration under the same name.
+func RegisterWorker(rigPath, name, session string) error {
+	now := time.Now()
+	return saveWorker(rigPath, &WorkerInfo{Name: name, Session: session, RegisteredAt: now, LastSeen: now})
+}
+
+// HeartbeatWorker refreshes a worker's last-seen time, registering it if
+// needed.
+func HeartbeatWorker(rigPath, name string) error {
+	w, err := LoadWorker(rigPath, name)
+	if err != nil {
+		return err
+	}
+	if w == nil {
+		return RegisterWorker(rigPath, name, "")
+	}
+	w.LastSeen = time.Now()
+	return saveWorker(rigPath, w)
+}
+
+func saveWorker(rigPath string, w *WorkerInfo) error {
+	p, err := workerFile(rigPath, w.Name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	return util.AtomicWriteJSON(p, w)
+}
+
+// LoadWorker returns a worker's registration, or nil if it never registered.
+func LoadWorker(rigPath, name string) (*WorkerInfo, error) {
+	p, err := workerFile(rigPath, name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(p) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var w WorkerInfo
+	if err := json.Unmarshal(data, &w); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", p, err)
+	}
+	return &w, nil
+}
+
+// LoadWorkers returns every registered worker, sorted by name. Unreadable
+// registrations are skipped.
+func LoadWorkers(rigPath string) ([]*WorkerInfo, error) {
+	entries, err := os.ReadDir(WorkersDir(rigPath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var workers []*WorkerInfo
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() {
+			continue
+		}
+		if w, err := LoadWorker(rigPath, name); err == nil && w != nil {
+			workers = append(workers, w)
+		}
+	}
+	sort.Slice(workers, func(i, j int) bool { return workers[i].Name < workers[j].Name })
+	return workers, nil
+}
+
+// WorkerLivenessMap returns the liveness of every registered worker by name.
+// Workers missing from the map are WorkerUnknown.
+func WorkerLivenessMap(rigPath string) map[string]WorkerLiveness {
+	workers, _ := LoadWorkers(rigPath)
+	now := time.Now()
+	m := make(map[string]WorkerLiveness, len(workers))
+	for _, w := range workers {
+		m[w.Name] = w.LivenessAt(now)
+	}
+	return m
+}
@@ -0,0 +1,57 @@
+package rig
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWorkerRegistration(t *testing.T) {
+	rigPath := t.TempDir()
+
+	if w, err := LoadWorker(rigPath, "nux"); w != nil || err != nil {
+		t.Fatalf("unregistered LoadWorker = %+v, %v; want nil", w, err)
+	}
+	if err := RegisterWorker(rigPath, "../evil", ""); err == nil {
+		t.Error("RegisterWorker accepted a path in the name")
+	}
+
+	if err := RegisterWorker(rigPath, "nux", "gt-greenplace-nux"); err != nil {
+		t.Fatal(err)
+	}
+	if err := HeartbeatWorker(rigPath, "toast"); err != nil { // Registers on first beat
+		t.Fatal(err)
+	}
+
+	workers, err := LoadWorkers(rigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(workers) != 2 || workers[0].Name != "nux" || workers[0].Session != "gt-greenplace-nux" || workers[1].Name != "toast" {
+		t.Fatalf("LoadWorkers = %+v", workers)
+	}
+
+	live := WorkerLivenessMap(rigPath)
+	if live["nux"] != WorkerAlive || live["toast"] != WorkerAlive {
+		t.Errorf("WorkerLivenessMap = %v", live)
+	}
+}
+
+func TestWorkerInfo_LivenessAt(t *testing.T) {
+	seen := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	w := &WorkerInfo{Name: "nux", LastSeen: seen}
+
+	tests := []struct {
+		after time.Duration
+		want  WorkerLiveness
+	}{
+		{time.Minute, WorkerAlive},
+		{WorkerStaleAfter, WorkerStale},
+		{WorkerDeadAfter - time.Second, WorkerStale},
+		{WorkerDeadAfter, WorkerDead},
+	}
+	for _, tt := range tests {
+		if got := w.LivenessAt(seen.Add(tt.after)); got != tt.want {
+			t.Errorf("LivenessAt(+%s) = %s, want %s", tt.after, got, tt.want)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"os/exec"
 	"strings"
 
+	"github.com/steveyegge/gastown/internal/constants"
 	"github.com/steveyegge/gastown/internal/rig"
 )
 
@@ -87,7 +88,7 @@ func (m *Manager) LoadSwarm(epicID string) (*Swarm, error) {
 		RigName:      m.rig.Name,
 		EpicID:       epicID,
 		BaseCommit:   baseCommit,
-		Integration:  fmt.Sprintf("swarm/%s", epicID),
+		Integration:  constants.BranchIntegrationPrefix + epicID,
 		TargetBranch: m.rig.DefaultBranch(),
 		State:        state,
 		Workers:      []string{}, // Discovered from active tasks
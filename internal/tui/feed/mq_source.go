@@ -183,6 +183,14 @@ func formatMQEventMessage(e mrqueue.Event) string {
 			msg += " - " + e.Reason
 		}
 		return msg
+	case mrqueue.EventDeployed:
+		return "Deployed: " + e.Target + " to " + e.Deploy
+	case mrqueue.EventDeployFailed:
+		msg := "Deploy failed: " + e.Target + " to " + e.Deploy
+		if e.Reason != "" {
+			msg += " - " + e.Reason
+		}
+		return msg
 	default:
 		return string(e.Type) + ": " + branchInfo
 	}
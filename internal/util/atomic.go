@@ -3,6 +3,7 @@ package util
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 )
 
@@ -23,6 +24,9 @@ func AtomicWriteJSON(path string, v interface{}) error {
 // This prevents data corruption if the process crashes during write.
 // The rename operation is atomic on POSIX systems.
 func AtomicWriteFile(path string, data []byte, perm os.FileMode) error {
+	if err := Fault(FaultStateWrite); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
 	tmpFile := path + ".tmp"
 
 	// Write to temp file
@@ -0,0 +1,83 @@
+package util
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrChecksumMismatch means a state file no longer matches the checksum
+// recorded when it was last written, so something else has changed it.
+var ErrChecksumMismatch = errors.New("modified outside gt")
+
+// ChecksumPath returns the path of the checksum file kept beside path.
+func ChecksumPath(path string) string {
+	return path + ".sha256"
+}
+
+// AtomicWriteJSONChecksum is AtomicWriteJSON that also records the file's
+// checksum, so VerifyChecksum can later tell if it was edited by hand.
+func AtomicWriteJSONChecksum(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return AtomicWriteFileChecksum(path, data, 0644)
+}
+
+// AtomicWriteFileChecksum is AtomicWriteFile that also records the file's
+// checksum. A crash between the two writes leaves a stale checksum, which
+// reads as a modification until WriteChecksum accepts the file.
+func AtomicWriteFileChecksum(path string, data []byte, perm os.FileMode) error {
+	if err := AtomicWriteFile(path, data, perm); err != nil {
+		return err
+	}
+	return WriteChecksum(path, data)
+}
+
+// WriteChecksum records data as the expected contents of path.
+func WriteChecksum(path string, data []byte) error {
+	return AtomicWriteFile(ChecksumPath(path), []byte(checksum(data)+"\n"), 0644)
+}
+
+// VerifyChecksum reports whether path still holds what was last written
+// through AtomicWriteFileChecksum. It returns ErrChecksumMismatch if not,
+// and nil if path or its checksum is missing: files written before
+// checksums were kept, or already removed, cannot be judged.
+func VerifyChecksum(path string) error {
+	want, err := os.ReadFile(ChecksumPath(path)) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if checksum(data) != string(bytes.TrimSpace(want)) {
+		return fmt.Errorf("%s: %w", path, ErrChecksumMismatch)
+	}
+	return nil
+}
+
+// RemoveChecksum deletes path's checksum file, if any.
+func RemoveChecksum(path string) error {
+	if err := os.Remove(ChecksumPath(path)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,49 @@
+package util
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyChecksum(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	// Files written without a checksum cannot be judged.
+	if err := os.WriteFile(path, []byte(`{"a":1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyChecksum(path); err != nil {
+		t.Errorf("legacy file = %v, want nil", err)
+	}
+
+	if err := AtomicWriteJSONChecksum(path, map[string]int{"a": 1}); err != nil {
+		t.Fatalf("AtomicWriteJSONChecksum: %v", err)
+	}
+	if err := VerifyChecksum(path); err != nil {
+		t.Errorf("fresh file = %v, want nil", err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"a": 2}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyChecksum(path); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("edited file = %v, want ErrChecksumMismatch", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if err := WriteChecksum(path, data); err != nil {
+		t.Fatalf("WriteChecksum: %v", err)
+	}
+	if err := VerifyChecksum(path); err != nil {
+		t.Errorf("accepted file = %v, want nil", err)
+	}
+
+	if err := RemoveChecksum(path); err != nil {
+		t.Fatalf("RemoveChecksum: %v", err)
+	}
+	if _, err := os.Stat(ChecksumPath(path)); !os.IsNotExist(err) {
+		t.Errorf("checksum file still exists: %v", err)
+	}
+}
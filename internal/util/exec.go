@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os/exec"
 	"strings"
+	"time"
 )
 
 // ExecWithOutput runs a command in the specified directory and returns stdout.
@@ -17,7 +18,10 @@ func ExecWithOutput(workDir, cmd string, args ...string) (string, error) {
 	c.Stdout = &stdout
 	c.Stderr = &stderr
 
-	if err := c.Run(); err != nil {
+	started := time.Now()
+	err := c.Run()
+	TraceCommand(workDir, cmd, args, started, err)
+	if err != nil {
 		errMsg := strings.TrimSpace(stderr.String())
 		if errMsg != "" {
 			return "", fmt.Errorf("%s", errMsg)
@@ -37,7 +41,10 @@ func ExecRun(workDir, cmd string, args ...string) error {
 	var stderr bytes.Buffer
 	c.Stderr = &stderr
 
-	if err := c.Run(); err != nil {
+	started := time.Now()
+	err := c.Run()
+	TraceCommand(workDir, cmd, args, started, err)
+	if err != nil {
 		errMsg := strings.TrimSpace(stderr.String())
 		if errMsg != "" {
 			return fmt.Errorf("%s", errMsg)
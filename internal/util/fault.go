@@ -0,0 +1,91 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FaultEnvVar names fault points to trip, so integration tests can drive
+// gt down its recovery paths. It is a comma-separated list of points, each
+// optionally followed by ":N" to trip only its first N hits in a process:
+//
+//	GT_FAULTS=push:1,state-write
+//
+// Leave it unset outside tests.
+const FaultEnvVar = "GT_FAULTS"
+
+// Fault points.
+const (
+	FaultPush         = "push"          // The refinery's push to origin loses a race
+	FaultCheckTimeout = "check-timeout" // A merge check times out before it runs
+	FaultStateWrite   = "state-write"   // An atomic state file write fails
+)
+
+// ErrInjectedFault is returned, wrapped, by a tripped fault point.
+var ErrInjectedFault = errors.New("injected fault")
+
+var (
+	faultMu sync.Mutex
+	faults  map[string]int // Hits left per point; -1 for unlimited
+)
+
+func init() {
+	if spec := os.Getenv(FaultEnvVar); spec != "" {
+		if err := SetFaults(spec); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s: %v\n", FaultEnvVar, err)
+		}
+	}
+}
+
+// SetFaults replaces the armed fault points with spec, in the FaultEnvVar
+// format. An empty spec disarms them all.
+func SetFaults(spec string) error {
+	armed := make(map[string]int)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		point, count, hasCount := strings.Cut(item, ":")
+		switch point {
+		case FaultPush, FaultCheckTimeout, FaultStateWrite:
+		default:
+			return fmt.Errorf("unknown fault point %q", point)
+		}
+		armed[point] = -1
+		if hasCount {
+			n, err := strconv.Atoi(count)
+			if err != nil || n <= 0 {
+				return fmt.Errorf("%s: invalid count %q", point, count)
+			}
+			armed[point] = n
+		}
+	}
+
+	faultMu.Lock()
+	defer faultMu.Unlock()
+	faults = nil
+	if len(armed) > 0 {
+		faults = armed
+	}
+	return nil
+}
+
+// Fault reports whether point trips: nil normally, or an error wrapping
+// ErrInjectedFault while the point is armed.
+func Fault(point string) error {
+	faultMu.Lock()
+	defer faultMu.Unlock()
+	left, ok := faults[point]
+	if !ok || left == 0 {
+		return nil
+	}
+	if left > 0 {
+		faults[point] = left - 1
+	}
+	return fmt.Errorf("%w: %s", ErrInjectedFault, point)
+}
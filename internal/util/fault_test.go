@@ -0,0 +1,59 @@
+package util
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFault(t *testing.T) {
+	t.Cleanup(func() { _ = SetFaults("") })
+
+	if err := Fault(FaultPush); err != nil {
+		t.Fatalf("Fault with nothing armed = %v", err)
+	}
+	if err := SetFaults("push:2, check-timeout"); err != nil {
+		t.Fatalf("SetFaults: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := Fault(FaultPush); !errors.Is(err, ErrInjectedFault) {
+			t.Errorf("push hit %d = %v, want an injected fault", i+1, err)
+		}
+	}
+	if err := Fault(FaultPush); err != nil {
+		t.Errorf("push hit 3 = %v, want nil once its count is spent", err)
+	}
+	for i := 0; i < 5; i++ {
+		if err := Fault(FaultCheckTimeout); err == nil {
+			t.Fatal("unlimited check-timeout stopped tripping")
+		}
+	}
+	if err := Fault(FaultStateWrite); err != nil {
+		t.Errorf("unarmed state-write = %v", err)
+	}
+
+	for _, bad := range []string{"pushh", "push:0", "push:x"} {
+		if err := SetFaults(bad); err == nil {
+			t.Errorf("SetFaults(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestAtomicWriteFile_Fault(t *testing.T) {
+	t.Cleanup(func() { _ = SetFaults("") })
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := SetFaults("state-write:1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := AtomicWriteFile(path, []byte("{}"), 0644); !errors.Is(err, ErrInjectedFault) {
+		t.Fatalf("first write = %v, want an injected fault", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("failed write left a file behind")
+	}
+	if err := AtomicWriteFile(path, []byte("{}"), 0644); err != nil {
+		t.Fatalf("second write: %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package util
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces secrets removed by Redact.
+const Redacted = "[redacted]"
+
+// minSecretLen is the shortest value RegisterSecret accepts; shorter
+// values would redact ordinary words.
+const minSecretLen = 6
+
+var (
+	secretsMu sync.RWMutex
+	secrets   []string // longest first, so a secret containing another is replaced whole
+)
+
+// credentialPatterns match credentials by shape, for secrets the process
+// never registered: URL passwords, authorization headers, and well-known
+// token formats.
+var credentialPatterns = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`(://[^/\s:@]+:)[^/\s@]+@`), "${1}" + Redacted + "@"},
+	{regexp.MustCompile(`(?i)(authorization:\s*(?:bearer|basic|token)\s+)\S+`), "${1}" + Redacted},
+	{regexp.MustCompile(`\bgtr_[A-Za-z0-9_-]{8,}`), Redacted},
+	{regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{20,}`), Redacted},
+	{regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{20,}`), Redacted},
+	{regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`), Redacted},
+	{regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`), Redacted},
+}
+
+// RegisterSecret records values, such as resolved secret references, that
+// Redact must remove wherever they appear. Values shorter than six
+// characters are ignored.
+func RegisterSecret(values ...string) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if len(v) < minSecretLen || containsString(secrets, v) {
+			continue
+		}
+		secrets = append(secrets, v)
+	}
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+}
+
+// Redact returns s with registered secrets and credential-shaped strings
+// replaced by Redacted. Anything written to state files, the event log, or
+// trace output passes through it.
+func Redact(s string) string {
+	if s == "" {
+		return s
+	}
+	secretsMu.RLock()
+	for _, v := range secrets {
+		s = strings.ReplaceAll(s, v, Redacted)
+	}
+	secretsMu.RUnlock()
+	for _, p := range credentialPatterns {
+		s = p.re.ReplaceAllString(s, p.repl)
+	}
+	return s
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
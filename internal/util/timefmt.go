@@ -0,0 +1,68 @@
+package util
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimestampLayout is the layout used when printing exact timestamps.
+const TimestampLayout = "2006-01-02 15:04:05"
+
+// timestampFormats are the layouts accepted by ParseTimestamp, most specific first.
+var timestampFormats = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// ParseTimestamp parses a timestamp in any of the formats used by beads and
+// the merge queue. Returns false if the string matches none of them.
+func ParseTimestamp(s string) (time.Time, bool) {
+	for _, layout := range timestampFormats {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// HumanizeDuration renders d in its largest whole unit: "45s", "12m", "3h", "2d".
+// Negative durations are rendered by magnitude.
+func HumanizeDuration(d time.Duration) string {
+	if d < 0 {
+		d = -d
+	}
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+// RelativeTime describes t relative to now, e.g. "5m ago" or "in 2h".
+func RelativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	if d < 0 {
+		return "in " + HumanizeDuration(d)
+	}
+	return HumanizeDuration(d) + " ago"
+}
+
+// FormatTime renders t for display: an exact local timestamp when exact is
+// set (--timestamps), otherwise a relative age. Zero times render as "-".
+func FormatTime(t time.Time, exact bool) string {
+	if t.IsZero() {
+		return "-"
+	}
+	if exact {
+		return t.Local().Format(TimestampLayout)
+	}
+	return RelativeTime(t, time.Now())
+}
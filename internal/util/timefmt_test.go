@@ -0,0 +1,56 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHumanizeDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0s"},
+		{45 * time.Second, "45s"},
+		{12 * time.Minute, "12m"},
+		{3*time.Hour + 59*time.Minute, "3h"},
+		{50 * time.Hour, "2d"},
+		{-5 * time.Minute, "5m"},
+	}
+	for _, tt := range tests {
+		if got := HumanizeDuration(tt.d); got != tt.want {
+			t.Errorf("HumanizeDuration(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC)
+	if got := RelativeTime(now.Add(-90*time.Minute), now); got != "1h ago" {
+		t.Errorf("past: got %q, want %q", got, "1h ago")
+	}
+	if got := RelativeTime(now.Add(10*time.Minute), now); got != "in 10m" {
+		t.Errorf("future: got %q, want %q", got, "in 10m")
+	}
+}
+
+func TestFormatTime(t *testing.T) {
+	ts := time.Date(2025, 1, 2, 12, 30, 45, 0, time.Local)
+	if got := FormatTime(ts, true); got != "2025-01-02 12:30:45" {
+		t.Errorf("exact: got %q", got)
+	}
+	if got := FormatTime(time.Time{}, false); got != "-" {
+		t.Errorf("zero: got %q, want %q", got, "-")
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	for _, s := range []string{"2025-01-02T12:00:00Z", "2025-01-02T12:00:00+02:00", "2025-01-02 12:00:00", "2025-01-02"} {
+		if _, ok := ParseTimestamp(s); !ok {
+			t.Errorf("ParseTimestamp(%q) failed", s)
+		}
+	}
+	if _, ok := ParseTimestamp("yesterday"); ok {
+		t.Error("ParseTimestamp(\"yesterday\") should fail")
+	}
+}
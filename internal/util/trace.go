@@ -0,0 +1,79 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceEnvVar enables subprocess tracing when set to a non-empty value.
+const TraceEnvVar = "GT_DEBUG"
+
+var (
+	traceMu  sync.Mutex
+	traceOut io.Writer
+)
+
+func init() {
+	if os.Getenv(TraceEnvVar) != "" {
+		traceOut = os.Stderr
+	}
+}
+
+// SetTraceWriter enables subprocess tracing to w. Pass nil to disable.
+// Tracing logs every traced command with its arguments, duration, and exit status.
+func SetTraceWriter(w io.Writer) {
+	traceMu.Lock()
+	defer traceMu.Unlock()
+	traceOut = w
+}
+
+// TraceEnabled reports whether subprocess tracing is active.
+func TraceEnabled() bool {
+	traceMu.Lock()
+	defer traceMu.Unlock()
+	return traceOut != nil
+}
+
+// TraceCommand records a finished subprocess invocation, with credentials
+// redacted (see Redact). It is a no-op unless tracing has been enabled.
+func TraceCommand(dir, name string, args []string, started time.Time, err error) {
+	traceMu.Lock()
+	defer traceMu.Unlock()
+	if traceOut == nil {
+		return
+	}
+
+	cmdline := name
+	if len(args) > 0 {
+		cmdline += " " + strings.Join(args, " ")
+	}
+	line := fmt.Sprintf("[trace] %s (%s) exit=%d",
+		cmdline, time.Since(started).Round(time.Millisecond), ExitCode(err))
+	if dir != "" {
+		line += " dir=" + dir
+	}
+	if err != nil && ExitCode(err) < 0 {
+		line += " err=" + err.Error()
+	}
+	_, _ = fmt.Fprintln(traceOut, Redact(line))
+}
+
+// ExitCode extracts a process exit code from an exec error.
+// Returns 0 for nil, the exit status for *exec.ExitError, and -1 otherwise
+// (e.g., the command could not be started).
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
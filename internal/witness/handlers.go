@@ -319,7 +319,23 @@ func HandleMergeFailed(workDir, rigName string, msg *mail.Message, router *mail.
 		return result
 	}
 
-	// Notify the polecat about the failure
+	// Notify the polecat about the failure, passing on whatever detail the
+	// refinery supplied. The full handoff (including failing check output)
+	// is in the polecat's .runtime/merge-feedback.json.
+	var details strings.Builder
+	if payload.TargetSHA != "" {
+		fmt.Fprintf(&details, "Target: %s @ %s\n", payload.TargetBranch, payload.TargetSHA)
+	}
+	if payload.FailedCheck != "" {
+		fmt.Fprintf(&details, "Failed check: %s\n", payload.FailedCheck)
+	}
+	if len(payload.ConflictFiles) > 0 {
+		details.WriteString("Conflicting files:\n")
+		for _, f := range payload.ConflictFiles {
+			fmt.Fprintf(&details, "  - %s\n", f)
+		}
+	}
+
 	polecatAddr := fmt.Sprintf("%s/polecats/%s", rigName, payload.PolecatName)
 	notification := &mail.Message{
 		From:     fmt.Sprintf("%s/witness", rigName),
@@ -333,12 +349,15 @@ Branch: %s
 Issue: %s
 Failure: %s
 Error: %s
+%s
+Details: .runtime/merge-feedback.json in your workspace.
 
 Please fix the issue and resubmit with 'gt done'.`,
 			payload.Branch,
 			payload.IssueID,
 			payload.FailureType,
 			payload.Error,
+			details.String(),
 		),
 	}
 
@@ -76,12 +76,17 @@ type MergedPayload struct {
 
 // MergeFailedPayload contains parsed data from a MERGE_FAILED message.
 type MergeFailedPayload struct {
-	PolecatName string
-	Branch      string
-	IssueID     string
-	FailureType string // "build", "test", "lint", etc.
-	Error       string
-	FailedAt    time.Time
+	PolecatName   string
+	Branch        string
+	IssueID       string
+	FailureType   string // "build", "test", "lint", etc.
+	Error         string
+	FailedAt      time.Time
+	MRID          string
+	TargetBranch  string
+	TargetSHA     string
+	ConflictFiles []string
+	FailedCheck   string
 }
 
 // SwarmStartPayload contains parsed data from a SWARM_START message.
@@ -229,8 +234,15 @@ func ParseMerged(subject, body string) (*MergedPayload, error) {
 //
 //	Branch: <branch>
 //	Issue: <issue-id>
-//	FailureType: <type>
+//	Failure-Type: <type>
 //	Error: <error-message>
+//	MR: <mr-id>                          (optional)
+//	Target: <target-branch>              (optional)
+//	Target-SHA: <sha>                    (optional)
+//	Conflict-Files: <file>, <file>       (optional)
+//	Failed-Check: <check-name>           (optional)
+//
+// "FailureType:" is also accepted for older senders.
 func ParseMergeFailed(subject, body string) (*MergeFailedPayload, error) {
 	matches := PatternMergeFailed.FindStringSubmatch(subject)
 	if len(matches) < 2 {
@@ -252,8 +264,22 @@ func ParseMergeFailed(subject, body string) (*MergeFailedPayload, error) {
 			payload.IssueID = strings.TrimSpace(strings.TrimPrefix(line, "Issue:"))
 		case strings.HasPrefix(line, "FailureType:"):
 			payload.FailureType = strings.TrimSpace(strings.TrimPrefix(line, "FailureType:"))
+		case strings.HasPrefix(line, "Failure-Type:"):
+			payload.FailureType = strings.TrimSpace(strings.TrimPrefix(line, "Failure-Type:"))
 		case strings.HasPrefix(line, "Error:"):
 			payload.Error = strings.TrimSpace(strings.TrimPrefix(line, "Error:"))
+		case strings.HasPrefix(line, "MR:"):
+			payload.MRID = strings.TrimSpace(strings.TrimPrefix(line, "MR:"))
+		case strings.HasPrefix(line, "Target-SHA:"):
+			payload.TargetSHA = strings.TrimSpace(strings.TrimPrefix(line, "Target-SHA:"))
+		case strings.HasPrefix(line, "Target:"):
+			payload.TargetBranch = strings.TrimSpace(strings.TrimPrefix(line, "Target:"))
+		case strings.HasPrefix(line, "Conflict-Files:"):
+			if files := strings.TrimSpace(strings.TrimPrefix(line, "Conflict-Files:")); files != "" {
+				payload.ConflictFiles = strings.Split(files, ", ")
+			}
+		case strings.HasPrefix(line, "Failed-Check:"):
+			payload.FailedCheck = strings.TrimSpace(strings.TrimPrefix(line, "Failed-Check:"))
 		}
 	}
 
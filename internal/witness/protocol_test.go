@@ -191,6 +191,34 @@ Error: unit tests failed with 3 errors`
 	}
 }
 
+func TestParseMergeFailed_Details(t *testing.T) {
+	body := `Branch: polecat/nux/gt-abc
+Target: main
+Failure-Type: tests
+Error: check test failed: exit status 1
+MR: mr-123
+Target-SHA: 0123abcd
+Conflict-Files: a.go, b.go
+Failed-Check: test`
+
+	payload, err := ParseMergeFailed("MERGE_FAILED nux", body)
+	if err != nil {
+		t.Fatalf("ParseMergeFailed() error = %v", err)
+	}
+	if payload.FailureType != "tests" {
+		t.Errorf("FailureType = %q, want %q", payload.FailureType, "tests")
+	}
+	if payload.MRID != "mr-123" || payload.TargetBranch != "main" || payload.TargetSHA != "0123abcd" {
+		t.Errorf("MRID/TargetBranch/TargetSHA = %q/%q/%q", payload.MRID, payload.TargetBranch, payload.TargetSHA)
+	}
+	if len(payload.ConflictFiles) != 2 || payload.ConflictFiles[1] != "b.go" {
+		t.Errorf("ConflictFiles = %v", payload.ConflictFiles)
+	}
+	if payload.FailedCheck != "test" {
+		t.Errorf("FailedCheck = %q, want %q", payload.FailedCheck, "test")
+	}
+}
+
 func TestParseMergeFailed_MinimalBody(t *testing.T) {
 	subject := "MERGE_FAILED ace"
 	body := "FailureType: build"